@@ -27,6 +27,7 @@ type Config struct {
 	JWT     JWT     `yaml:"jwt"`
 	Milvus  Milvus  `yaml:"milvus"`
 	Kafka   Kafka   `yaml:"kafka"`
+	LLM     LLM     `yaml:"llm"`
 }
 
 type Postgre struct {
@@ -61,6 +62,20 @@ type Kafka struct {
 	Brokers []string `yaml:"brokers"`
 }
 
+type LLM struct {
+	// CredentialSecret encrypts the provider credentials stored by accounts
+	CredentialSecret string `yaml:"credentialSecret"`
+	// AllowSystemCredentials lets accounts without their own credentials use the system keys
+	AllowSystemCredentials bool `yaml:"allowSystemCredentials"`
+	// DefaultEmbeddingProvider and DefaultEmbeddingModel pin datasets created without choosing an
+	// embedding model, datasets use the embedder of the vector store when they are empty
+	DefaultEmbeddingProvider string `yaml:"defaultEmbeddingProvider"`
+	DefaultEmbeddingModel    string `yaml:"defaultEmbeddingModel"`
+	// VectorDimensions is the dimension of the vector collection shared by all datasets, it must match
+	// the embedder of the vector store. When empty the dimension of the default embedding model is used
+	VectorDimensions int `yaml:"vectorDimensions"`
+}
+
 func GetConf() *Config {
 	once.Do(func() {
 		initConf()
@@ -15,6 +15,10 @@ const (
 	OpNe   Op = "ne"
 	OpLike Op = "like"
 	OpIn   Op = "in"
+	OpGt   Op = "gt"
+	OpGte  Op = "gte"
+	OpLt   Op = "lt"
+	OpLte  Op = "lte"
 
 	OpAnd Op = "and"
 	OpOr  Op = "or"
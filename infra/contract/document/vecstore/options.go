@@ -5,6 +5,7 @@ import (
 	"github.com/cloudwego/eino/components/retriever"
 
 	"github.com/crazyfrankie/voidx/infra/contract/document/progressbar"
+	"github.com/crazyfrankie/voidx/infra/contract/embedding"
 )
 
 type IndexerOptions struct {
@@ -12,12 +13,14 @@ type IndexerOptions struct {
 	Partition      *string // Storage sharding map
 	IndexingFields []string
 	ProgressBar    progressbar.ProgressBar
+	Embedding      embedding.Embedder // Overrides the embedder of the store
 }
 
 type RetrieverOptions struct {
 	MultiMatch   *MultiMatch // Multi-field query
 	PartitionKey *string
-	Partitions   []string // Query sharding map
+	Partitions   []string           // Query sharding map
+	Embedding    embedding.Embedder // Overrides the embedder of the store
 }
 
 type MultiMatch struct {
@@ -49,6 +52,12 @@ func WithProgressBar(progressBar progressbar.ProgressBar) indexer.Option {
 	})
 }
 
+func WithIndexerEmbedding(emb embedding.Embedder) indexer.Option {
+	return indexer.WrapImplSpecificOptFn(func(o *IndexerOptions) {
+		o.Embedding = emb
+	})
+}
+
 func WithMultiMatch(fields []string, query string) retriever.Option {
 	return retriever.WrapImplSpecificOptFn(func(o *RetrieverOptions) {
 		o.MultiMatch = &MultiMatch{
@@ -69,3 +78,9 @@ func WithPartitions(partitions []string) retriever.Option {
 		o.Partitions = partitions
 	})
 }
+
+func WithRetrieverEmbedding(emb embedding.Embedder) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *RetrieverOptions) {
+		o.Embedding = emb
+	})
+}
@@ -0,0 +1,23 @@
+package rerank
+
+import "context"
+
+type Reranker interface {
+	// Rerank scores the documents by their relevance to the query, results are ordered by score
+	Rerank(ctx context.Context, req *Request) (*Response, error)
+}
+
+type Request struct {
+	Query     string
+	Documents []string
+	TopN      int // keep the TopN best documents, 0 keeps all
+}
+
+type Response struct {
+	Results []*Result
+}
+
+type Result struct {
+	Index int // index of the document in the request
+	Score float64
+}
@@ -2,53 +2,54 @@ package progressbar
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
 	"github.com/crazyfrankie/voidx/infra/contract/cache"
-	"github.com/crazyfrankie/voidx/infra/contract/progressbar"
+	"github.com/crazyfrankie/voidx/infra/contract/document/progressbar"
 	"github.com/crazyfrankie/voidx/pkg/lang/conv"
 	"github.com/crazyfrankie/voidx/pkg/lang/ptr"
 )
 
 type ProgressBarImpl struct {
-	CacheCli     cache.Cmdable
-	PrimaryKeyID int64
-	Total        int64
-	ErrMsg       string
+	CacheCli cache.Cmdable
+	ID       string
+	Total    int64
+	ErrMsg   string
 }
 
 const (
 	ttl                             = time.Hour * 2
-	ProgressBarStartTimeRedisKey    = "RedisBiz.Knowledge_ProgressBar_StartTime_%d"
-	ProgressBarErrMsgRedisKey       = "RedisBiz.Knowledge_ProgressBar_ErrMsg_%d"
-	ProgressBarTotalNumRedisKey     = "RedisBiz.Knowledge_ProgressBar_TotalNum_%d"
-	ProgressBarProcessedNumRedisKey = "RedisBiz.Knowledge_ProgressBar_ProcessedNum_%d"
+	ProgressBarStartTimeRedisKey    = "RedisBiz.Knowledge_ProgressBar_StartTime_%s"
+	ProgressBarErrMsgRedisKey       = "RedisBiz.Knowledge_ProgressBar_ErrMsg_%s"
+	ProgressBarTotalNumRedisKey     = "RedisBiz.Knowledge_ProgressBar_TotalNum_%s"
+	ProgressBarProcessedNumRedisKey = "RedisBiz.Knowledge_ProgressBar_ProcessedNum_%s"
 	DefaultProcessTime              = 300
 	ProcessDone                     = 100
 	ProcessInit                     = 0
 )
 
-func NewProgressBar(ctx context.Context, pkID int64, total int64, CacheCli cache.Cmdable, needInit bool) progressbar.ProgressBar {
+// NewProgressBar creates a progress bar identified by id, needInit resets the
+// stored progress so that the writer starts from zero, readers pass false.
+func NewProgressBar(ctx context.Context, id string, total int64, CacheCli cache.Cmdable, needInit bool) progressbar.ProgressBar {
 	if needInit {
-		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarTotalNumRedisKey, pkID), total, ttl)
-		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarProcessedNumRedisKey, pkID), 0, ttl)
-		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarErrMsgRedisKey, pkID), "", ttl)
-		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarStartTimeRedisKey, pkID), time.Now().Unix(), ttl)
+		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarTotalNumRedisKey, id), total, ttl)
+		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarProcessedNumRedisKey, id), 0, ttl)
+		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarErrMsgRedisKey, id), "", ttl)
+		CacheCli.Set(ctx, fmt.Sprintf(ProgressBarStartTimeRedisKey, id), time.Now().Unix(), ttl)
 	}
 	return &ProgressBarImpl{
-		PrimaryKeyID: pkID,
-		Total:        total,
-		CacheCli:     CacheCli,
+		ID:       id,
+		Total:    total,
+		CacheCli: CacheCli,
 	}
 }
 
+// AddN advances the processed count. An error reported earlier does not stop
+// later parts from being counted, so one failed batch does not fail every
+// batch that shares the progress bar.
 func (p *ProgressBarImpl) AddN(n int) error {
-	if p.ErrMsg != "" {
-		return errors.New(p.ErrMsg)
-	}
-	_, err := p.CacheCli.IncrBy(context.Background(), fmt.Sprintf(ProgressBarProcessedNumRedisKey, p.PrimaryKeyID), int64(n)).Result()
+	_, err := p.CacheCli.IncrBy(context.Background(), fmt.Sprintf(ProgressBarProcessedNumRedisKey, p.ID), int64(n)).Result()
 	if err != nil {
 		return err
 	}
@@ -57,7 +58,7 @@ func (p *ProgressBarImpl) AddN(n int) error {
 
 func (p *ProgressBarImpl) ReportError(err error) error {
 	p.ErrMsg = err.Error()
-	_, err = p.CacheCli.Set(context.Background(), fmt.Sprintf(ProgressBarErrMsgRedisKey, p.PrimaryKeyID), err.Error(), ttl).Result()
+	_, err = p.CacheCli.Set(context.Background(), fmt.Sprintf(ProgressBarErrMsgRedisKey, p.ID), err.Error(), ttl).Result()
 	if err != nil {
 		return err
 	}
@@ -71,16 +72,15 @@ func (p *ProgressBarImpl) GetProgress(ctx context.Context) (percent int, remainS
 		startTime    *int64
 		err          error
 	)
-	errMsg, err = p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarErrMsgRedisKey, p.PrimaryKeyID)).Result()
+	// A reported error does not end the progress, the last error is returned
+	// together with the real percentage.
+	errMsg, err = p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarErrMsgRedisKey, p.ID)).Result()
 	if err == cache.Nil {
 		errMsg = ""
 	} else if err != nil {
 		return ProcessDone, 0, err.Error()
 	}
-	if len(errMsg) != 0 {
-		return ProcessDone, 0, errMsg
-	}
-	totalNumStr, err := p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarTotalNumRedisKey, p.PrimaryKeyID)).Result()
+	totalNumStr, err := p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarTotalNumRedisKey, p.ID)).Result()
 	if err == cache.Nil || len(totalNumStr) == 0 {
 		totalNum = ptr.Of(int64(0))
 	} else if err != nil {
@@ -93,7 +93,7 @@ func (p *ProgressBarImpl) GetProgress(ctx context.Context) (percent int, remainS
 			totalNum = ptr.Of(num)
 		}
 	}
-	processedNumStr, err := p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarProcessedNumRedisKey, p.PrimaryKeyID)).Result()
+	processedNumStr, err := p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarProcessedNumRedisKey, p.ID)).Result()
 	if err == cache.Nil || len(processedNumStr) == 0 {
 		processedNum = ptr.Of(int64(0))
 	} else if err != nil {
@@ -107,9 +107,9 @@ func (p *ProgressBarImpl) GetProgress(ctx context.Context) (percent int, remainS
 		}
 	}
 	if ptr.From(totalNum) == 0 {
-		return ProcessInit, DefaultProcessTime, ""
+		return ProcessInit, DefaultProcessTime, errMsg
 	}
-	startTimeStr, err := p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarStartTimeRedisKey, p.PrimaryKeyID)).Result()
+	startTimeStr, err := p.CacheCli.Get(ctx, fmt.Sprintf(ProgressBarStartTimeRedisKey, p.ID)).Result()
 	if err == cache.Nil || len(startTimeStr) == 0 {
 		startTime = ptr.Of(int64(0))
 	} else if err != nil {
@@ -122,12 +122,12 @@ func (p *ProgressBarImpl) GetProgress(ctx context.Context) (percent int, remainS
 			startTime = ptr.Of(num)
 		}
 	}
-	percent = int(float64(ptr.From(processedNum)) / float64(ptr.From(totalNum)) * 100)
-	if ptr.From(startTime) == 0 {
+	percent = min(int(float64(ptr.From(processedNum))/float64(ptr.From(totalNum))*100), ProcessDone)
+	if ptr.From(startTime) == 0 || ptr.From(processedNum) == 0 {
 		remainSec = DefaultProcessTime
 	} else {
 		usedSec := time.Now().Unix() - ptr.From(startTime)
-		remainSec = int(float64(ptr.From(totalNum)-ptr.From(processedNum)) / float64(ptr.From(processedNum)) * float64(usedSec))
+		remainSec = max(int(float64(ptr.From(totalNum)-ptr.From(processedNum))/float64(ptr.From(processedNum))*float64(usedSec)), 0)
 	}
 	return
 }
@@ -0,0 +1,95 @@
+package progressbar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
+)
+
+// memoryCache implements the cache commands used by the progress bar in memory
+type memoryCache struct {
+	cache.Cmdable
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryCache() *memoryCache {
+	cache.SetDefaultNilError(redis.Nil)
+	return &memoryCache{data: make(map[string]string)}
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value any, expiration time.Duration) cache.StatusCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = fmt.Sprint(value)
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) cache.StringCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if val, ok := c.data[key]; ok {
+		return redis.NewStringResult(val, nil)
+	}
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (c *memoryCache) IncrBy(ctx context.Context, key string, value int64) cache.IntCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	num, _ := strconv.ParseInt(c.data[key], 10, 64)
+	num += value
+	c.data[key] = strconv.FormatInt(num, 10)
+	return redis.NewIntResult(num, nil)
+}
+
+func TestGetProgress(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports the processed percentage", func(t *testing.T) {
+		bar := NewProgressBar(ctx, "doc", 200, newMemoryCache(), true)
+		require.NoError(t, bar.AddN(50))
+
+		percent, _, errMsg := bar.GetProgress(ctx)
+		assert.Equal(t, 25, percent)
+		assert.Empty(t, errMsg)
+	})
+
+	t.Run("an error keeps the real percentage", func(t *testing.T) {
+		cacheCli := newMemoryCache()
+		writer := NewProgressBar(ctx, "doc", 200, cacheCli, true)
+		require.NoError(t, writer.AddN(50))
+		require.NoError(t, writer.ReportError(errors.New("batch failed")))
+
+		reader := NewProgressBar(ctx, "doc", 0, cacheCli, false)
+		percent, _, errMsg := reader.GetProgress(ctx)
+		assert.Equal(t, 25, percent)
+		assert.Equal(t, "batch failed", errMsg)
+
+		// Later batches keep advancing and the last error stays visible at the end
+		require.NoError(t, writer.AddN(150))
+		percent, remainSec, errMsg := reader.GetProgress(ctx)
+		assert.Equal(t, ProcessDone, percent)
+		assert.Zero(t, remainSec)
+		assert.Equal(t, "batch failed", errMsg)
+	})
+
+	t.Run("missing progress starts from zero", func(t *testing.T) {
+		bar := NewProgressBar(ctx, "doc", 0, newMemoryCache(), false)
+
+		percent, remainSec, errMsg := bar.GetProgress(ctx)
+		assert.Equal(t, ProcessInit, percent)
+		assert.Equal(t, DefaultProcessTime, remainSec)
+		assert.Empty(t, errMsg)
+	})
+}
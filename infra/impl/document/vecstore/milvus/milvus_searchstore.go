@@ -64,7 +64,7 @@ func (m *milvusSearchStore) Store(ctx context.Context, docs []*schema.Document,
 	}
 
 	for _, part := range slices.Chunks(docs, batchSize) {
-		columns, err := m.documents2Columns(ctx, part, indexingFields)
+		columns, err := m.documents2Columns(ctx, part, indexingFields, m.embedding(implSpecOptions.Embedding))
 		if err != nil {
 			return nil, err
 		}
@@ -108,9 +108,9 @@ func (m *milvusSearchStore) Store(ctx context.Context, docs []*schema.Document,
 
 func (m *milvusSearchStore) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
 	cli := m.config.Client
-	emb := m.config.Embedding
 	options := retriever.GetCommonOptions(&retriever.Options{TopK: ptr.Of(topK)}, opts...)
 	implSpecOptions := retriever.GetImplSpecificOptions(&vecstore.RetrieverOptions{}, opts...)
+	emb := m.embedding(implSpecOptions.Embedding)
 
 	desc, err := cli.DescribeCollection(ctx, client.NewDescribeCollectionOption(m.collectionName))
 	if err != nil {
@@ -125,9 +125,13 @@ func (m *milvusSearchStore) Retrieve(ctx context.Context, query string, opts ...
 
 		fields       = desc.Schema.Fields
 		outputFields []string
-		enableSparse = m.enableSparse(fields)
+		enableSparse = m.enableSparse(fields, emb)
 	)
 
+	if err = checkDimensions(fields, emb); err != nil {
+		return nil, err
+	}
+
 	if options.DSLInfo != nil {
 		expr, err = m.dsl2Expr(options.DSLInfo)
 		if err != nil {
@@ -240,7 +244,7 @@ func (m *milvusSearchStore) Delete(ctx context.Context, ids []string) error {
 	return err
 }
 
-func (m *milvusSearchStore) documents2Columns(ctx context.Context, docs []*schema.Document, indexingFields sets.Set[string]) (
+func (m *milvusSearchStore) documents2Columns(ctx context.Context, docs []*schema.Document, indexingFields sets.Set[string], emb embedding.Embedder) (
 	cols []column.Column, err error) {
 
 	var (
@@ -379,7 +383,6 @@ func (m *milvusSearchStore) documents2Columns(ctx context.Context, docs []*schem
 				}
 
 				var (
-					emb    = m.config.Embedding
 					dense  [][]float64
 					sparse []map[int]float64
 				)
@@ -410,7 +413,7 @@ func (m *milvusSearchStore) documents2Columns(ctx context.Context, docs []*schem
 			if !ok {
 				return nil, fmt.Errorf("[documents2Columns] container type not []float64")
 			}
-			cols = append(cols, column.NewColumnFloatVector(fieldName, int(m.config.Embedding.Dimensions()), convertDense(c)))
+			cols = append(cols, column.NewColumnFloatVector(fieldName, int(emb.Dimensions()), convertDense(c)))
 		case vecstore.FieldTypeSparseVector:
 			c, ok := container.([]map[int]float64)
 			if !ok {
@@ -498,7 +501,34 @@ func (m *milvusSearchStore) resultSet2Document(result []client.ResultSet, metric
 	return docs, nil
 }
 
-func (m *milvusSearchStore) enableSparse(fields []*mentity.Field) bool {
+// embedding returns the embedder given in the options, or the one of the store
+func (m *milvusSearchStore) embedding(override embedding.Embedder) embedding.Embedder {
+	if override != nil {
+		return override
+	}
+	return m.config.Embedding
+}
+
+// checkDimensions rejects an embedder whose vectors do not fit the dense fields of the collection
+func checkDimensions(fields []*mentity.Field, emb embedding.Embedder) error {
+	if emb.Dimensions() <= 0 {
+		return nil
+	}
+	for _, field := range fields {
+		if field.DataType != mentity.FieldTypeFloatVector {
+			continue
+		}
+		dim, err := field.GetDim()
+		if err == nil && dim != emb.Dimensions() {
+			return fmt.Errorf("[Retrieve] embedding dimensions %d do not match field %s of dimensions %d",
+				emb.Dimensions(), field.Name, dim)
+		}
+	}
+
+	return nil
+}
+
+func (m *milvusSearchStore) enableSparse(fields []*mentity.Field, emb embedding.Embedder) bool {
 	found := false
 	for _, field := range fields {
 		if field.DataType == mentity.FieldTypeSparseVector {
@@ -507,7 +537,7 @@ func (m *milvusSearchStore) enableSparse(fields []*mentity.Field) bool {
 		}
 	}
 
-	return found && *m.config.EnableHybrid && m.config.Embedding.SupportStatus() == embedding.SupportDenseAndSparse
+	return found && *m.config.EnableHybrid && emb.SupportStatus() == embedding.SupportDenseAndSparse
 }
 
 func (m *milvusSearchStore) dsl2Expr(src map[string]interface{}) (string, error) {
@@ -524,7 +554,14 @@ func (m *milvusSearchStore) dsl2Expr(src map[string]interface{}) (string, error)
 	travDSL = func(dsl *vecstore.DSL) (string, error) {
 		kv := map[string]interface{}{
 			"field": dsl.Field,
-			"val":   dsl.Value,
+		}
+		if dsl.Op != vecstore.OpAnd && dsl.Op != vecstore.OpOr {
+			// values are json encoded so that strings are quoted and escaped
+			b, err := json.Marshal(dsl.Value)
+			if err != nil {
+				return "", err
+			}
+			kv["val"] = string(b)
 		}
 
 		switch dsl.Op {
@@ -535,12 +572,15 @@ func (m *milvusSearchStore) dsl2Expr(src map[string]interface{}) (string, error)
 		case vecstore.OpLike:
 			return pyfmt.Fmt("{field} LIKE {val}", kv)
 		case vecstore.OpIn:
-			b, err := json.Marshal(dsl.Value)
-			if err != nil {
-				return "", err
-			}
-			kv["val"] = string(b)
 			return pyfmt.Fmt("{field} IN {val}", kv)
+		case vecstore.OpGt:
+			return pyfmt.Fmt("{field} > {val}", kv)
+		case vecstore.OpGte:
+			return pyfmt.Fmt("{field} >= {val}", kv)
+		case vecstore.OpLt:
+			return pyfmt.Fmt("{field} < {val}", kv)
+		case vecstore.OpLte:
+			return pyfmt.Fmt("{field} <= {val}", kv)
 		case vecstore.OpAnd, vecstore.OpOr:
 			sub, ok := dsl.Value.([]*vecstore.DSL)
 			if !ok {
@@ -555,10 +595,11 @@ func (m *milvusSearchStore) dsl2Expr(src map[string]interface{}) (string, error)
 				items = append(items, str)
 			}
 
+			// parenthesize so that nested and/or keep their precedence
 			if dsl.Op == vecstore.OpAnd {
-				return strings.Join(items, " AND "), nil
+				return "(" + strings.Join(items, " AND ") + ")", nil
 			} else {
-				return strings.Join(items, " OR "), nil
+				return "(" + strings.Join(items, " OR ") + ")", nil
 			}
 		default:
 			return "", fmt.Errorf("[dsl2Expr] unknown op type=%s", dsl.Op)
@@ -0,0 +1,115 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/crazyfrankie/voidx/infra/contract/rerank"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+const pathRerank = "/rerank"
+
+type rerankReq struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+type rerankResp struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewHTTPReranker creates a reranker calling a cross-encoder service with the /rerank API shared
+// by bge-reranker servers, Xinference, vLLM and Jina
+func NewHTTPReranker(addr string, apiKey string, model string) (rerank.Reranker, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("rerank service address is empty")
+	}
+
+	return &reranker{
+		cli:    &http.Client{Timeout: time.Second * 30},
+		addr:   addr,
+		apiKey: apiKey,
+		model:  model,
+	}, nil
+}
+
+type reranker struct {
+	cli    *http.Client
+	addr   string
+	apiKey string
+	model  string
+}
+
+func (r *reranker) Rerank(ctx context.Context, req *rerank.Request) (*rerank.Response, error) {
+	if len(req.Documents) == 0 {
+		return &rerank.Response{}, nil
+	}
+
+	rb, err := sonic.Marshal(&rerankReq{
+		Model:     r.model,
+		Query:     req.Query,
+		Documents: req.Documents,
+		TopN:      req.TopN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	path, err := url.JoinPath(r.addr, pathRerank)
+	if err != nil {
+		return nil, fmt.Errorf("url join path failed, %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, path, bytes.NewReader(rb))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if r.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.cli.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("/rerank failed, %w", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("/rerank read response body failed, %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/rerank bad status code: %d, %s", resp.StatusCode, string(b))
+	}
+
+	rr := &rerankResp{}
+	if err = sonic.Unmarshal(b, rr); err != nil {
+		return nil, fmt.Errorf("/rerank unmarshal response failed, %w", err)
+	}
+
+	results := make([]*rerank.Result, 0, len(rr.Results))
+	for _, item := range rr.Results {
+		if item.Index < 0 || item.Index >= len(req.Documents) {
+			return nil, fmt.Errorf("/rerank returned invalid index %d", item.Index)
+		}
+		results = append(results, &rerank.Result{Index: item.Index, Score: item.RelevanceScore})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if req.TopN > 0 && len(results) > req.TopN {
+		results = results[:req.TopN]
+	}
+
+	return &rerank.Response{Results: results}, nil
+}
@@ -37,8 +37,14 @@ func (h *AIHandler) OptimizePrompt() gin.HandlerFunc {
 			return
 		}
 
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
 		// 获取流式响应
-		eventChan, err := h.svc.OptimizePrompt(c.Request.Context(), optimizeReq.Prompt)
+		eventChan, err := h.svc.OptimizePrompt(c.Request.Context(), userID, optimizeReq.Prompt)
 		if err != nil {
 			response.InternalServerErrorResponse(c, err)
 			return
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/google/uuid"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/ai/repository"
 	"github.com/crazyfrankie/voidx/internal/conversation/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/types/errno"
@@ -68,12 +68,14 @@ const OptimizePromptTemplate = `# 角色
 type AIService struct {
 	repo                *repository.AIRepo
 	conversationService *service.ConversationService
+	llmSvc              *llm.Service
 }
 
-func NewAIService(repo *repository.AIRepo, conversationService *service.ConversationService) *AIService {
+func NewAIService(repo *repository.AIRepo, conversationService *service.ConversationService, llmSvc *llm.Service) *AIService {
 	return &AIService{
 		repo:                repo,
 		conversationService: conversationService,
+		llmSvc:              llmSvc,
 	}
 }
 
@@ -93,28 +95,31 @@ func (s *AIService) GenerateSuggestedQuestions(ctx context.Context, messageID, u
 	histories := fmt.Sprintf("Human: %s\nAI: %s", message.Query, message.Answer)
 
 	// 3. 调用会话服务生成建议问题
-	return s.conversationService.GenerateSuggestedQuestions(ctx, histories)
+	return s.conversationService.GenerateSuggestedQuestions(ctx, userID, histories)
 }
 
 // OptimizePrompt 根据传递的prompt进行优化生成
-func (s *AIService) OptimizePrompt(ctx context.Context, prompt string) (<-chan string, error) {
+func (s *AIService) OptimizePrompt(ctx context.Context, userID uuid.UUID, prompt string) (<-chan string, error) {
+	// 使用账号的凭证创建OpenAI客户端
+	client, err := s.getOpenAIClient(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建事件通道
 	eventChan := make(chan string, 100)
 
 	// 启动异步处理
-	go s.processOptimizePrompt(ctx, prompt, eventChan)
+	go s.processOptimizePrompt(ctx, client, prompt, eventChan)
 
 	return eventChan, nil
 }
 
 // processOptimizePrompt 处理prompt优化
-func (s *AIService) processOptimizePrompt(ctx context.Context, prompt string, eventChan chan<- string) {
+func (s *AIService) processOptimizePrompt(ctx context.Context, client *openai.Client, prompt string, eventChan chan<- string) {
 	defer close(eventChan)
 
-	// 1. 创建OpenAI客户端
-	client := s.getOpenAIClient()
-
-	// 2. 构建消息
+	// 1. 构建消息
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -126,7 +131,7 @@ func (s *AIService) processOptimizePrompt(ctx context.Context, prompt string, ev
 		},
 	}
 
-	// 3. 创建流式请求
+	// 2. 创建流式请求
 	req := openai.ChatCompletionRequest{
 		Model:       openai.GPT4oMini,
 		Messages:    messages,
@@ -134,7 +139,7 @@ func (s *AIService) processOptimizePrompt(ctx context.Context, prompt string, ev
 		Temperature: 0.5,
 	}
 
-	// 4. 调用流式API
+	// 3. 调用流式API
 	stream, err := client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
 		// 发送错误事件
@@ -143,7 +148,7 @@ func (s *AIService) processOptimizePrompt(ctx context.Context, prompt string, ev
 	}
 	defer stream.Close()
 
-	// 5. 处理流式响应
+	// 4. 处理流式响应
 	var optimizedPrompt strings.Builder
 	for {
 		response, err := stream.Recv()
@@ -169,11 +174,17 @@ func (s *AIService) processOptimizePrompt(ctx context.Context, prompt string, ev
 	}
 }
 
-// getOpenAIClient 获取OpenAI客户端
-func (s *AIService) getOpenAIClient() *openai.Client {
-	config := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
-	if baseURL := os.Getenv("OPENAI_API_BASE"); baseURL != "" {
+// getOpenAIClient 使用账号的OpenAI凭证创建客户端
+func (s *AIService) getOpenAIClient(ctx context.Context, accountID uuid.UUID) (*openai.Client, error) {
+	credentials, err := s.llmSvc.ResolveCredentials(ctx, accountID, "openai")
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, _ := credentials["api_key"].(string)
+	config := openai.DefaultConfig(apiKey)
+	if baseURL, _ := credentials["base_url"].(string); baseURL != "" {
 		config.BaseURL = baseURL
 	}
-	return openai.NewClientWithConfig(config)
+	return openai.NewClientWithConfig(config), nil
 }
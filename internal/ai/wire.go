@@ -12,6 +12,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/ai/repository"
 	"github.com/crazyfrankie/voidx/internal/ai/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/ai/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 )
 
 type Handler = handler.AIHandler
@@ -27,12 +28,13 @@ var ProviderSet = wire.NewSet(
 	handler.NewAIHandler,
 )
 
-func InitAIModule(db *gorm.DB, conversationModule *conversation.ConversationModule) *AIModule {
+func InitAIModule(db *gorm.DB, conversationModule *conversation.ConversationModule, llmModule *llm.LLMModule) *AIModule {
 	wire.Build(
 		ProviderSet,
 
 		wire.Struct(new(AIModule), "*"),
 		wire.FieldsOf(new(*conversation.ConversationModule), "Service"),
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 	)
 	return new(AIModule)
 }
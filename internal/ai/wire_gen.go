@@ -12,17 +12,19 @@ import (
 	"github.com/crazyfrankie/voidx/internal/ai/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/ai/service"
 	"github.com/crazyfrankie/voidx/internal/conversation"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitAIModule(db *gorm.DB, conversationModule *conversation.ConversationModule) *AIModule {
+func InitAIModule(db *gorm.DB, conversationModule *conversation.ConversationModule, llmModule *llm.LLMModule) *AIModule {
 	aiDao := dao.NewAIDao(db)
 	aiRepo := repository.NewAIRepo(aiDao)
 	conversationService := conversationModule.Service
-	aiService := service.NewAIService(aiRepo, conversationService)
+	llmService := llmModule.Service
+	aiService := service.NewAIService(aiRepo, conversationService, llmService)
 	aiHandler := handler.NewAIHandler(aiService)
 	aiModule := &AIModule{
 		Handler: aiHandler,
@@ -42,6 +42,7 @@ func (h *AppHandler) RegisterRoute(r *gin.RouterGroup) {
 		appGroup.PUT("/:app_id/summary", h.UpdateDebugAppSummary())
 		appGroup.POST("/:app_id/conversation", h.DebugChat())
 		appGroup.POST("/:app_id/conversation/tasks/:task_id/stop", h.StopDebugChat())
+		appGroup.POST("/:app_id/conversation/tasks/:task_id/confirm", h.ConfirmDebugChat())
 		appGroup.GET("/:app_id/conversation/messages", h.GetDebugConversationWithPage())
 		appGroup.DELETE("/:app_id/debug-conversation")
 		appGroup.POST("/:app_id/publish", h.PublishApp())
@@ -493,6 +494,45 @@ func (h *AppHandler) StopDebugChat() gin.HandlerFunc {
 	}
 }
 
+// ConfirmDebugChat 确认、修改或拒绝调试会话中等待确认的工具调用
+func (h *AppHandler) ConfirmDebugChat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var confirmReq req.ConfirmToolCallReq
+		if err := c.ShouldBindJSON(&confirmReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		appIDStr := c.Param("app_id")
+		appID, err := uuid.Parse(appIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		taskIdStr := c.Param("task_id")
+		taskID, err := uuid.Parse(taskIdStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		err = h.appService.ConfirmDebugChat(c.Request.Context(), appID, taskID, userID, confirmReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
 // GetDebugConversationWithPage 获取应用的调试会话消息列表
 func (h *AppHandler) GetDebugConversationWithPage() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -126,6 +126,10 @@ func (r *AppRepo) GetWorkflows(ctx context.Context, workflowIDs []uuid.UUID, acc
 	return r.dao.GetWorkflows(ctx, workflowIDs, accountID, status)
 }
 
+func (r *AppRepo) GetPublishedApps(ctx context.Context, appIDs []uuid.UUID, accountID uuid.UUID) ([]*entity.App, error) {
+	return r.dao.GetPublishedApps(ctx, appIDs, accountID)
+}
+
 func (r *AppRepo) GetDatasets(ctx context.Context, workflowIDs []uuid.UUID, accountID uuid.UUID) ([]*entity.Dataset, error) {
 	return r.dao.GetDatasets(ctx, workflowIDs, accountID)
 }
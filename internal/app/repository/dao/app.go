@@ -283,6 +283,18 @@ func (d *AppDao) GetWorkflows(ctx context.Context, workflowIDs []uuid.UUID, acco
 	return workflows, nil
 }
 
+// GetPublishedApps 获取账号下已发布的应用
+func (d *AppDao) GetPublishedApps(ctx context.Context, appIDs []uuid.UUID, accountID uuid.UUID) ([]*entity.App, error) {
+	var apps []*entity.App
+	if err := d.db.WithContext(ctx).Model(&entity.App{}).Where("id IN (?) AND account_id = ? AND status = ?",
+		appIDs, accountID, consts.AppStatusPublished).
+		Find(&apps).Error; err != nil {
+		return nil, err
+	}
+
+	return apps, nil
+}
+
 // GetDatasets 获取知识库
 func (d *AppDao) GetDatasets(ctx context.Context, datasetIDs []uuid.UUID, accountID uuid.UUID) ([]*entity.Dataset, error) {
 	var datasets []*entity.Dataset
@@ -7,7 +7,7 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
-	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -23,14 +23,19 @@ import (
 	"github.com/crazyfrankie/voidx/internal/core/llm"
 	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	"github.com/crazyfrankie/voidx/internal/core/memory"
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
+	"github.com/crazyfrankie/voidx/internal/core/retrievers"
 	"github.com/crazyfrankie/voidx/internal/core/tools/api_tools/providers"
 	builtin "github.com/crazyfrankie/voidx/internal/core/tools/builtin_tools/providers"
+	llmsvc "github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/upload"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/pkg/dalle"
+	"github.com/crazyfrankie/voidx/pkg/jsonschema"
 	"github.com/crazyfrankie/voidx/pkg/logs"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
@@ -48,8 +53,9 @@ type AppService struct {
 	ossSvc              *upload.Service
 	agentManager        *agent.AgentQueueManagerFactory
 	llmService          *llm.LanguageModelManager
-	llm                 llmentity.BaseLanguageModel
+	llmSvc              *llmsvc.Service
 	tokenBufMem         *memory.TokenBufferMemory
+	userMemorySvc       *user_memory.Service
 	activeSessions      sync.Map
 }
 
@@ -57,7 +63,8 @@ func NewAppService(repo *repository.AppRepo,
 	appConfigSvc *app_config.Service, conversationSvc *conversation.Service,
 	retrieverSvc *retriever.Service, ossSvc *upload.Service, apiProvider *providers.APIProviderManager,
 	builtinProvider *builtin.BuiltinProviderManager, agentManager *agent.AgentQueueManagerFactory,
-	llmService *llm.LanguageModelManager, tokenBufMem *memory.TokenBufferMemory, llm llmentity.BaseLanguageModel) *AppService {
+	llmService *llm.LanguageModelManager, tokenBufMem *memory.TokenBufferMemory, llmSvc *llmsvc.Service,
+	userMemorySvc *user_memory.Service) *AppService {
 	return &AppService{
 		repo:                repo,
 		appConfigService:    appConfigSvc,
@@ -69,14 +76,20 @@ func NewAppService(repo *repository.AppRepo,
 		agentManager:        agentManager,
 		llmService:          llmService,
 		tokenBufMem:         tokenBufMem,
-		llm:                 llm,
+		llmSvc:              llmSvc,
+		userMemorySvc:       userMemorySvc,
 	}
 }
 
 // AutoCreateApp 根据传递的应用名称、描述、账号id利用AI创建一个Agent智能体
 func (s *AppService) AutoCreateApp(ctx context.Context, name, description string, accountID uuid.UUID) error {
-	// 创建DallEApiWrapper包装器
-	dalleCli := dalle.NewClient(os.Getenv("OPENAI_API_KEY"))
+	// 创建DallEApiWrapper包装器，使用账号配置的OpenAI凭证
+	credentials, err := s.llmService.ResolveCredentials(ctx, accountID, "openai")
+	if err != nil {
+		return err
+	}
+	apiKey, _ := credentials["api_key"].(string)
+	dalleCli := dalle.NewClient(apiKey)
 
 	iconPrompt := fmt.Sprintf(consts.GenerateIconPromptTemplate, name, description)
 
@@ -93,7 +106,7 @@ func (s *AppService) AutoCreateApp(ctx context.Context, name, description string
 	iconURL = res[0].URL
 
 	// 使用新的LLM服务生成预设提示词
-	model, err := s.llmService.CreateModel(ctx, "openai", "gpt-3.5-turbo", map[string]any{})
+	model, err := s.llmService.CreateModelForAccount(ctx, accountID, "openai", "gpt-3.5-turbo", map[string]any{})
 	if err != nil {
 		return err
 	}
@@ -355,6 +368,7 @@ func (s *AppService) CopyApp(ctx context.Context, appID uuid.UUID, accountID uui
 			PresetPrompt:         draftAppConfig.PresetPrompt,
 			Tools:                draftAppConfig.Tools,
 			Workflows:            draftAppConfig.Workflows,
+			Apps:                 draftAppConfig.Apps,
 			Datasets:             draftAppConfig.Datasets,
 			RetrievalConfig:      draftAppConfig.RetrievalConfig,
 			LongTermMemory:       draftAppConfig.LongTermMemory,
@@ -364,6 +378,8 @@ func (s *AppService) CopyApp(ctx context.Context, appID uuid.UUID, accountID uui
 			TextToSpeech:         draftAppConfig.TextToSpeech,
 			SuggestedAfterAnswer: draftAppConfig.SuggestedAfterAnswer,
 			ReviewConfig:         draftAppConfig.ReviewConfig,
+			StructuredOutput:     draftAppConfig.StructuredOutput,
+			ImageInput:           draftAppConfig.ImageInput,
 		}
 
 		_, err = s.repo.CreateAppConfigVersion(ctx, newDraftAppConfig)
@@ -467,7 +483,7 @@ func (s *AppService) UpdateDraftAppConfig(ctx context.Context, appID uuid.UUID,
 	}
 
 	// 2. 校验传递的草稿配置信息
-	validatedConfig, err := s.validateDraftAppConfig(draftAppConfig, accountID)
+	validatedConfig, err := s.validateDraftAppConfig(draftAppConfig, appID, accountID)
 	if err != nil {
 		return err
 	}
@@ -512,7 +528,8 @@ func (s *AppService) PublishDraftAppConfig(ctx context.Context, appID uuid.UUID,
 	if tools := draftAppConfig.Tools; len(tools) > 0 {
 		for _, tool := range tools {
 			processedTool := map[string]any{
-				"type": tool["type"],
+				"type":                 tool["type"],
+				"require_confirmation": tool["require_confirmation"],
 			}
 			if provider, exists := tool["provider"]; exists {
 				if providerMap, ok := provider.(map[string]any); ok {
@@ -539,6 +556,16 @@ func (s *AppService) PublishDraftAppConfig(ctx context.Context, appID uuid.UUID,
 		}
 	}
 
+	// 处理关联应用配置
+	var appIDs []string
+	if apps := draftAppConfig.Apps; len(apps) > 0 {
+		for _, a := range apps {
+			if idStr, ok := a["id"].(string); ok {
+				appIDs = append(appIDs, idStr)
+			}
+		}
+	}
+
 	appConfig := &entity.AppConfig{
 		ID:                   uuid.New(),
 		AppID:                appID,
@@ -547,6 +574,7 @@ func (s *AppService) PublishDraftAppConfig(ctx context.Context, appID uuid.UUID,
 		PresetPrompt:         draftAppConfig.PresetPrompt,
 		Tools:                processedTools,
 		Workflows:            workflowIDs,
+		Apps:                 appIDs,
 		RetrievalConfig:      draftAppConfig.RetrievalConfig,
 		LongTermMemory:       draftAppConfig.LongTermMemory,
 		OpeningStatement:     draftAppConfig.OpeningStatement,
@@ -555,6 +583,8 @@ func (s *AppService) PublishDraftAppConfig(ctx context.Context, appID uuid.UUID,
 		TextToSpeech:         draftAppConfig.TextToSpeech,
 		SuggestedAfterAnswer: draftAppConfig.SuggestedAfterAnswer,
 		ReviewConfig:         draftAppConfig.ReviewConfig,
+		StructuredOutput:     draftAppConfig.StructuredOutput,
+		ImageInput:           draftAppConfig.ImageInput,
 	}
 
 	_, err = s.repo.CreateAppConfig(ctx, appConfig)
@@ -618,6 +648,7 @@ func (s *AppService) PublishDraftAppConfig(ctx context.Context, appID uuid.UUID,
 		PresetPrompt:         draftAppConfigCopy.PresetPrompt,
 		Tools:                draftAppConfigCopy.Tools,
 		Workflows:            draftAppConfigCopy.Workflows,
+		Apps:                 draftAppConfigCopy.Apps,
 		Datasets:             draftAppConfigCopy.Datasets,
 		RetrievalConfig:      draftAppConfigCopy.RetrievalConfig,
 		LongTermMemory:       draftAppConfigCopy.LongTermMemory,
@@ -627,6 +658,8 @@ func (s *AppService) PublishDraftAppConfig(ctx context.Context, appID uuid.UUID,
 		TextToSpeech:         draftAppConfigCopy.TextToSpeech,
 		SuggestedAfterAnswer: draftAppConfigCopy.SuggestedAfterAnswer,
 		ReviewConfig:         draftAppConfigCopy.ReviewConfig,
+		StructuredOutput:     draftAppConfigCopy.StructuredOutput,
+		ImageInput:           draftAppConfigCopy.ImageInput,
 	}
 
 	_, err = s.repo.CreateAppConfigVersion(ctx, publishedVersion)
@@ -719,6 +752,7 @@ func (s *AppService) FallbackHistoryToDraft(ctx context.Context, appID, appConfi
 		"preset_prompt":     appConfigVersion.PresetPrompt,
 		"tools":             appConfigVersion.Tools,
 		"workflows":         appConfigVersion.Workflows,
+		"apps":              appConfigVersion.Apps,
 		"datasets":          appConfigVersion.Datasets,
 		"long_term_memory":  appConfigVersion.LongTermMemory,
 		"opening_statement": appConfigVersion.OpeningStatement,
@@ -727,9 +761,15 @@ func (s *AppService) FallbackHistoryToDraft(ctx context.Context, appID, appConfi
 		"text_to_speech":    appConfigVersion.TextToSpeech,
 		"review_config":     appConfigVersion.ReviewConfig,
 	}
+	if len(appConfigVersion.StructuredOutput) > 0 {
+		draftAppConfigDict["structured_output"] = appConfigVersion.StructuredOutput
+	}
+	if len(appConfigVersion.ImageInput) > 0 {
+		draftAppConfigDict["image_input"] = appConfigVersion.ImageInput
+	}
 
 	// 4. 校验历史版本配置信息
-	validatedConfig, err := s.validateDraftAppConfig(draftAppConfigDict, accountID)
+	validatedConfig, err := s.validateDraftAppConfig(draftAppConfigDict, appID, accountID)
 	if err != nil {
 		return nil, err
 	}
@@ -897,6 +937,12 @@ func (s *AppService) DebugChat(ctx context.Context, appID uuid.UUID, accountID u
 		return nil, err
 	}
 
+	// 5. 根据草稿配置加载应用的语言模型，备用模型组成回退链
+	languageModel, err := s.llmSvc.LoadLanguageModel(ctx, accountID, draftAppConfig.ModelConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	// 6. 使用TokenBufferMemory用于提取短期记忆
 	s.tokenBufMem.WithConversationID(debugConversation.ID)
 	history, err := s.tokenBufMem.GetHistoryPromptMessages(2000, 10)
@@ -905,7 +951,7 @@ func (s *AppService) DebugChat(ctx context.Context, appID uuid.UUID, accountID u
 	}
 
 	// 7. 将草稿配置中的tools转换成eino工具
-	tools, err := s.appConfigService.GetToolsByToolsConfig(ctx, draftAppConfig.Tools)
+	tools, err := s.appConfigService.GetToolsByToolsConfig(ctx, accountID, draftAppConfig.Tools)
 	if err != nil {
 		return nil, err
 	}
@@ -921,6 +967,12 @@ func (s *AppService) DebugChat(ctx context.Context, appID uuid.UUID, accountID u
 			return nil, err
 		}
 		tools = append(tools, datasetRetrieval)
+		// 表格知识库使用应用的模型生成SQL查询
+		tableTools, err := s.retrieverSvc.CreateTableQueryTools(ctx, accountID, datasets, languageModel)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, tableTools...)
 	}
 
 	// 9. 检测是否关联工作流
@@ -936,29 +988,41 @@ func (s *AppService) DebugChat(ctx context.Context, appID uuid.UUID, accountID u
 		tools = append(tools, workflowTools...)
 	}
 
-	// 10. 根据LLM是否支持tool_call决定使用不同的Agent
+	// 10. 检测是否关联了其他应用，将已发布的应用作为工具
+	if len(draftAppConfig.Apps) > 0 {
+		apps := make([]uuid.UUID, 0, len(draftAppConfig.Apps))
+		for _, a := range draftAppConfig.Apps {
+			idStr, _ := a["id"].(string)
+			if appID, err := uuid.Parse(idStr); err == nil {
+				apps = append(apps, appID)
+			}
+		}
+		appTools, err := s.appConfigService.GetToolsByAppIDs(ctx, accountID, apps)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, appTools...)
+	}
+
+	// 11. 根据LLM是否支持tool_call决定使用不同的Agent
 	agentCfg := &agenteneity.AgentConfig{
 		UserID:               accountID,
 		InvokeFrom:           consts.InvokeFromDebugger,
 		PresetPrompt:         draftAppConfig.PresetPrompt,
 		EnableLongTermMemory: draftAppConfig.LongTermMemory["enabled"].(bool),
 		Tools:                tools,
+		ConfirmationTools:    s.appConfigService.GetConfirmationToolNames(draftAppConfig.Tools),
+		OutputSchema:         s.appConfigService.GetOutputSchema(draftAppConfig.StructuredOutput),
 	}
 	if err := util.ConvertViaJSON(&agentCfg.ReviewConfig, draftAppConfig.ReviewConfig); err != nil {
 		return nil, err
 	}
-	agentIns := agent.NewFunctionCallAgent(s.llm, agentCfg, s.agentManager)
-	for _, f := range s.llm.GetFeatures() {
-		if f == llmentity.FeatureToolCall {
-			agentIns = agent.NewFunctionCallAgent(s.llm, agentCfg, s.agentManager)
-			break
-		}
-	}
+	agentIns := agent.NewAgent(languageModel, agentCfg, s.agentManager)
 	// 创建响应流通道
 	responseStream := make(chan string, 100)
 
 	// 启动异步处理
-	go s.processDebugChat(ctx, appID, agentIns, history, debugConversation, message, chatReq, accountID, responseStream)
+	go s.processDebugChat(ctx, appID, draftAppConfig, languageModel, agentIns, history, debugConversation, message, chatReq, accountID, responseStream)
 
 	return responseStream, nil
 }
@@ -967,6 +1031,8 @@ func (s *AppService) DebugChat(ctx context.Context, appID uuid.UUID, accountID u
 func (s *AppService) processDebugChat(
 	ctx context.Context,
 	appID uuid.UUID,
+	draftAppConfig *resp.AppDraftConfigResp,
+	languageModel llmentity.BaseLanguageModel,
 	agentIns agent.BaseAgent,
 	history []*schema.Message,
 	debugConversation *entity.Conversation,
@@ -982,13 +1048,17 @@ func (s *AppService) processDebugChat(
 		TaskID:         uuid.New(),
 		Messages:       history,
 		History:        history,
-		LongTermMemory: debugConversation.Summary,
+		LongTermMemory: s.userMemorySvc.RecallLongTermMemory(ctx, debugConversation, chatReq.Query, draftAppConfig.LongTermMemory),
 		IterationCount: 0,
 	}
 
 	// 添加当前用户消息
 	if len(chatReq.Query) > 0 {
-		userMsg := schema.UserMessage(chatReq.Query)
+		userMsg, err := s.llmService.BuildHumanMessageForAccount(ctx, accountID, languageModel, draftAppConfig.ImageInput, chatReq.Query, chatReq.ImageUrls)
+		if err != nil {
+			logs.CtxErrorf(ctx, "describe images with vision model failed: %v", err)
+			userMsg = schema.UserMessage(chatReq.Query)
+		}
 		agentState.Messages = append(agentState.Messages, userMsg)
 	}
 
@@ -1011,13 +1081,14 @@ func (s *AppService) processDebugChat(
 
 		// 除了ping事件，其他事件全部记录
 		if agentThought.Event != agenteneity.EventPing {
-			// 单独处理agent_message事件，因为该事件为数据叠加
-			if agentThought.Event == agenteneity.EventAgentMessage {
+			// 单独处理agent_message与agent_reasoning事件，因为该事件为数据叠加
+			if agentThought.Event == agenteneity.EventAgentMessage || agentThought.Event == agenteneity.EventAgentReasoning {
 				if existing, exists := agentThoughts[eventID]; exists {
 					// 叠加智能体消息事件
 					existing.Thought = existing.Thought + agentThought.Thought
 					existing.Answer = existing.Answer + agentThought.Answer
 					existing.Latency = agentThought.Latency
+					existing.MergeUsage(agentThought)
 					agentThoughts[eventID] = existing
 				} else {
 					// 初始化智能体消息事件
@@ -1031,17 +1102,19 @@ func (s *AppService) processDebugChat(
 
 		// 构建响应数据
 		data := map[string]any{
-			"id":              eventID,
-			"conversation_id": debugConversation.ID.String(),
-			"message_id":      message.ID.String(),
-			"task_id":         agentState.TaskID.String(),
-			"event":           string(agentThought.Event),
-			"thought":         agentThought.Thought,
-			"observation":     agentThought.Observation,
-			"tool":            agentThought.Tool,
-			"tool_input":      agentThought.ToolInput,
-			"answer":          agentThought.Answer,
-			"latency":         agentThought.Latency,
+			"id":                eventID,
+			"conversation_id":   debugConversation.ID.String(),
+			"message_id":        message.ID.String(),
+			"task_id":           agentState.TaskID.String(),
+			"event":             string(agentThought.Event),
+			"thought":           agentThought.Thought,
+			"observation":       agentThought.Observation,
+			"tool":              agentThought.Tool,
+			"tool_input":        agentThought.ToolInput,
+			"sub_thoughts":      agentThought.SubThoughts,
+			"answer":            agentThought.Answer,
+			"structured_output": agentThought.StructuredOutput,
+			"latency":           agentThought.Latency,
 		}
 
 		jsonData, _ := sonic.Marshal(data)
@@ -1081,6 +1154,12 @@ func (s *AppService) processDebugChat(
 		// 记录错误但不中断流程
 		logs.Errorf("Failed to save agent thoughts: %v", err)
 	}
+
+	// 投递长期记忆摘要任务
+	err = s.conversationService.ScheduleConversationSummary(ctx, accountID, debugConversation.ID, draftAppConfig.LongTermMemory, draftAppConfig.ModelConfig)
+	if err != nil {
+		logs.Errorf("Failed to schedule conversation summary: %v", err)
+	}
 }
 
 // StopDebugChat 根据传递的应用id+任务id+账号，停止某个应用的调试会话，中断流式事件
@@ -1096,6 +1175,31 @@ func (s *AppService) StopDebugChat(ctx context.Context, appID, taskID uuid.UUID,
 	return s.agentManager.StopTask(ctx, taskID, accountID, consts.InvokeFromDebugger)
 }
 
+// ConfirmDebugChat 根据传递的应用id+任务id+账号，对调试会话中等待确认的工具调用进行确认、修改或拒绝
+func (s *AppService) ConfirmDebugChat(ctx context.Context, appID, taskID uuid.UUID, accountID uuid.UUID, confirmReq req.ConfirmToolCallReq) error {
+	// 1. 获取应用信息并校验权限
+	_, err := s.GetApp(ctx, appID, accountID)
+	if err != nil {
+		return err
+	}
+
+	// 2. 修改工具参数时必须传递合法的json参数
+	if confirmReq.Action == string(agenteneity.ConfirmationEdit) {
+		var arguments map[string]any
+		if err := sonic.UnmarshalString(confirmReq.Arguments, &arguments); err != nil {
+			return errno.ErrValidate.AppendBizMessage(errors.New("修改后的工具参数必须是合法的JSON"))
+		}
+	}
+
+	// 3. 调用智能体队列管理器写入用户的确认结果
+	thoughtID, _ := uuid.Parse(confirmReq.ThoughtID)
+	return s.agentManager.ConfirmTask(ctx, taskID, thoughtID, accountID, consts.InvokeFromDebugger, &agenteneity.ConfirmationDecision{
+		Action:    agenteneity.ConfirmationAction(confirmReq.Action),
+		Arguments: confirmReq.Arguments,
+		Reason:    confirmReq.Reason,
+	})
+}
+
 // GetDebugConversationMessagesWithPage 根据传递的应用id+请求数据，获取调试会话消息列表分页数据
 func (s *AppService) GetDebugConversationMessagesWithPage(ctx context.Context, appID uuid.UUID, getReq req.GetDebugConversationMessagesWithPageReq, accountID uuid.UUID) ([]resp.DebugConversationMessageResp, resp.Paginator, error) {
 	// 1. 获取应用信息并校验权限
@@ -1134,10 +1238,12 @@ func (s *AppService) GetDebugConversationMessagesWithPage(ctx context.Context, a
 				ID:              at.ID,
 				MessageID:       message.ID,
 				Event:           at.Event,
+				Model:           at.Model,
 				Thought:         at.Thought,
 				Observation:     at.Observation,
 				Tool:            at.Tool,
 				ToolInput:       at.ToolInput,
+				SubThoughts:     at.SubThoughts,
 				Answer:          at.Answer,
 				TotalTokenCount: at.TotalTokenCount,
 				TotalPrice:      at.TotalPrice,
@@ -1151,6 +1257,7 @@ func (s *AppService) GetDebugConversationMessagesWithPage(ctx context.Context, a
 			Query:           message.Query,
 			ImageUrls:       message.ImageUrls,
 			Answer:          message.Answer,
+			Model:           message.Model,
 			Latency:         message.Latency,
 			TotalTokenCount: message.TotalTokenCount,
 			AgentThoughts:   agentThoughts,
@@ -1208,13 +1315,14 @@ func (s *AppService) RegenerateWebAppToken(ctx context.Context, appID uuid.UUID,
 }
 
 // validateDraftAppConfig 校验传递的应用草稿配置信息，返回校验后的数据
-func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accountID uuid.UUID) (map[string]any, error) {
+func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, appID uuid.UUID, accountID uuid.UUID) (map[string]any, error) {
 	// 1. 校验上传的草稿配置中对应的字段，至少拥有一个可以更新的配置
 	acceptableFields := []string{
 		"model_config", "dialog_round", "preset_prompt",
-		"tools", "workflows", "datasets", "retrieval_config",
+		"tools", "workflows", "apps", "datasets", "retrieval_config",
 		"long_term_memory", "opening_statement", "opening_questions",
 		"speech_to_text", "text_to_speech", "suggested_after_answer", "review_config",
+		"structured_output", "image_input",
 	}
 
 	// 2. 判断传递的草稿配置是否在可接受字段内
@@ -1238,10 +1346,12 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 	// 3. 校验model_config字段
 	if modelConfig, exists := draftAppConfig["model_config"]; exists {
 		if modelConfigMap, ok := modelConfig.(map[string]any); ok {
-			// 3.1 判断model_config键信息是否正确
+			// 3.1 判断model_config键信息是否正确，fallbacks与retry为可选键
 			requiredKeys := []string{"provider", "model", "parameters"}
-			if len(modelConfigMap) != 3 {
-				return nil, errno.ErrValidate.AppendBizMessage(errors.New("模型键配置格式错误，请核实后重试"))
+			for key := range modelConfigMap {
+				if !util.Contains([]string{"provider", "model", "parameters", "fallbacks", "retry"}, key) {
+					return nil, errno.ErrValidate.AppendBizMessage(errors.New("模型键配置格式错误，请核实后重试"))
+				}
 			}
 
 			for _, key := range requiredKeys {
@@ -1274,7 +1384,7 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 				return nil, errno.ErrNotFound.AppendBizMessage(errors.New("该服务提供商下不存在该模型，请核实后重试"))
 			}
 
-			var parameters map[string]any
+			parameters := make(map[string]any)
 			// 3.4 判断传递的parameters是否正确，如果不正确则设置默认值，并剔除多余字段，补全未传递的字段
 			for _, parameter := range model.Parameters {
 				// 3.5 从model_config中获取参数值，如果不存在则设置为默认值
@@ -1321,6 +1431,38 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 
 			// 3.13 覆盖Agent配置中的模型配置
 			modelConfigMap["parameters"] = parameters
+
+			// 3.14 校验备用模型列表，参数在加载模型时补全
+			if fallbacks, exists := modelConfigMap["fallbacks"]; exists {
+				fallbackList, ok := fallbacks.([]any)
+				if !ok || len(fallbackList) > llmentity.MaxFallbackModels {
+					return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("备用模型必须是列表且最多配置%d个", llmentity.MaxFallbackModels))
+				}
+				for _, fallback := range fallbackList {
+					fallbackMap, ok := fallback.(map[string]any)
+					if !ok {
+						return nil, errno.ErrValidate.AppendBizMessage(errors.New("备用模型配置格式错误，请核实后重试"))
+					}
+					fallbackProvider, _ := fallbackMap["provider"].(string)
+					fallbackModel, _ := fallbackMap["model"].(string)
+					if _, err := s.llmService.GetModelEntity(fallbackProvider, fallbackModel); err != nil {
+						return nil, errno.ErrNotFound.AppendBizMessage(errors.New("备用模型不存在，请核实后重试"))
+					}
+					if parameters, exists := fallbackMap["parameters"]; exists {
+						if _, ok := parameters.(map[string]any); !ok {
+							return nil, errno.ErrValidate.AppendBizMessage(errors.New("备用模型参数格式错误，请核实后重试"))
+						}
+					}
+				}
+			}
+
+			// 3.15 校验重试规则
+			if retry, exists := modelConfigMap["retry"]; exists {
+				if _, err := llmentity.ParseRetryPolicy(retry); err != nil {
+					return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("重试规则配置错误: %v", err))
+				}
+			}
+
 			draftAppConfig["model_config"] = modelConfigMap
 
 		} else {
@@ -1404,6 +1546,13 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 				return nil, errno.ErrValidate.AppendBizMessage(errors.New("插件自定义参数格式错误"))
 			}
 
+			// 校验require_confirmation参数，如果传递了则必须为布尔值
+			if requireConfirmation, exists := tool["require_confirmation"]; exists {
+				if _, ok := requireConfirmation.(bool); !ok {
+					return nil, errno.ErrValidate.AppendBizMessage(errors.New("工具确认开关必须是布尔值"))
+				}
+			}
+
 			// 6.7 校验对应的工具是否存在
 			if toolType == "builtin_tool" {
 				builtinTool, err := s.builtinProvider.GetTool(toolID)
@@ -1557,6 +1706,28 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 		if !ok || score < 0 || score > 1 {
 			return nil, errno.ErrValidate.AppendBizMessage(errors.New("最小匹配范围为0-1"))
 		}
+
+		// 重排序为可选配置，可以使用重排序模型或对话模型
+		rerankConfig, err := retrievers.ParseRerankConfig(rc["rerank"])
+		if err != nil {
+			return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("重排序配置格式错误，候选数量范围为1-%d", retrievers.MaxRerankTopN))
+		}
+		if rerankConfig != nil {
+			modelEntity, err := s.llmService.GetModelEntity(rerankConfig.Provider, rerankConfig.Model)
+			if err != nil || (modelEntity.ModelType != llmentity.ModelTypeRerank && modelEntity.ModelType != llmentity.ModelTypeChat) {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("重排序模型不存在，请核实后重试"))
+			}
+		}
+
+		// 混合检索的融合方式为可选配置，未配置时使用等权加权融合
+		if _, err := retrievers.ParseFusionConfig(rc["fusion"]); err != nil {
+			return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("混合检索融合配置格式错误: %v", err))
+		}
+
+		// 元数据过滤为可选配置，作用于所有关联的知识库
+		if _, err := retrievers.ParseMetadataFilter(rc["metadata_filter"]); err != nil {
+			return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("元数据过滤配置格式错误: %v", err))
+		}
 	}
 
 	// 10. 校验long_term_memory长期记忆配置
@@ -1713,8 +1884,25 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 			return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.enable格式错误"))
 		}
 
+		// 16.1 审核器为可选配置，与关键词组合使用
+		var moderators []moderation.ModeratorConfig
+		if rawModerators, exists := rc["moderators"]; exists {
+			items, ok := rawModerators.([]any)
+			if !ok || len(items) > 10 {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.moderators必须是列表且不能超过10个审核器"))
+			}
+			if err := util.ConvertViaJSON(&moderators, items); err != nil {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.moderators格式错误"))
+			}
+			for i, moderator := range moderators {
+				if err := moderation.ValidateModeratorConfig(moderator); err != nil {
+					return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("review.moderators第%d个审核器配置错误: %w", i+1, err))
+				}
+			}
+		}
+
 		keywords, ok := rc["keywords"].([]any)
-		if !ok || (enable && len(keywords) == 0) || len(keywords) > 100 {
+		if !ok || (enable && len(keywords) == 0 && len(moderators) == 0) || len(keywords) > 100 {
 			return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.keywords非空且不能超过100个关键词"))
 		}
 
@@ -1748,7 +1936,7 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 			return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.outputs_config格式错误"))
 		}
 
-		if len(outputsConfig) != 1 {
+		if len(outputsConfig) > 2 {
 			return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.outputs_config格式错误"))
 		}
 
@@ -1757,6 +1945,15 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 			return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.outputs_config格式错误"))
 		}
 
+		// 输出被拦截时的预设响应为可选配置
+		if outputPresetResponse, exists := outputsConfig["preset_response"]; exists {
+			if _, ok := outputPresetResponse.(string); !ok {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.outputs_config格式错误"))
+			}
+		} else if len(outputsConfig) != 1 {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("review.outputs_config格式错误"))
+		}
+
 		if enable {
 			if !inputEnable && !outputEnable {
 				return nil, errno.ErrValidate.AppendBizMessage(errors.New("输入审核和输出审核至少需要开启一项"))
@@ -1768,9 +1965,178 @@ func (s *AppService) validateDraftAppConfig(draftAppConfig map[string]any, accou
 		}
 	}
 
+	// 17. 校验apps关联应用列表
+	if apps, exists := draftAppConfig["apps"]; exists {
+		appsSlice, ok := apps.([]any)
+		if !ok {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("绑定应用列表参数格式错误"))
+		}
+
+		// 17.1 判断关联的应用列表是否超过5个
+		if len(appsSlice) > 5 {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("Agent绑定的应用数量不能超过5个"))
+		}
+
+		// 17.2 校验每个应用ID是否为UUID，且不能绑定应用自身
+		appIDs := make([]uuid.UUID, 0, len(appsSlice))
+		for _, item := range appsSlice {
+			idStr, ok := item.(string)
+			if !ok {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("应用参数必须是UUID"))
+			}
+			id, err := uuid.Parse(idStr)
+			if err != nil {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("应用参数必须是UUID"))
+			}
+			if id == appID {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("应用不能绑定自身"))
+			}
+			appIDs = append(appIDs, id)
+		}
+
+		// 17.3 判断是否重复关联了应用
+		if len(appIDs) != len(util.UniqueUUID(appIDs)) {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("绑定应用存在重复"))
+		}
+
+		// 17.4 校验关联应用的权限，只保留同账号下已发布的应用
+		appRecords, err := s.repo.GetPublishedApps(context.Background(), appIDs, accountID)
+		if err != nil {
+			return nil, errno.ErrNotFound.AppendBizMessage(errors.New("查询应用失败"))
+		}
+
+		validAppSet := make(map[uuid.UUID]bool)
+		for _, a := range appRecords {
+			validAppSet[a.ID] = true
+		}
+
+		validApps := make([]string, 0)
+		for _, id := range appIDs {
+			if validAppSet[id] {
+				validApps = append(validApps, id.String())
+			}
+		}
+
+		draftAppConfig["apps"] = validApps
+	}
+
+	// 18. 校验structured_output结构化输出配置
+	if structuredOutput, exists := draftAppConfig["structured_output"]; exists {
+		so, ok := structuredOutput.(map[string]any)
+		if !ok || len(so) != 2 {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("结构化输出设置格式错误"))
+		}
+
+		enable, ok := so["enable"].(bool)
+		if !ok {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("结构化输出设置格式错误"))
+		}
+
+		outputSchema, ok := so["schema"].(map[string]any)
+		if !ok {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("结构化输出的schema必须是一个字典"))
+		}
+
+		// 18.1 开启时schema必须是合法的JSON Schema，且根节点为object或array
+		if enable {
+			if err := jsonschema.CheckSchema(outputSchema); err != nil {
+				return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("结构化输出的schema格式错误: %v", err))
+			}
+		}
+	}
+
+	// 19. 校验image_input图片输入配置，vision_model为可选键
+	if imageInput, exists := draftAppConfig["image_input"]; exists {
+		ii, ok := imageInput.(map[string]any)
+		if !ok {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("图片输入设置格式错误"))
+		}
+		for key := range ii {
+			if !util.Contains([]string{"enable", "vision_model"}, key) {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("图片输入设置格式错误"))
+			}
+		}
+		if _, ok := ii["enable"].(bool); !ok {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("图片输入设置格式错误"))
+		}
+
+		// 19.1 视觉模型用于为不支持图片的主模型描述图片，必须支持图片输入
+		if visionModel, exists := ii["vision_model"]; exists {
+			vm, ok := visionModel.(map[string]any)
+			if !ok {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("视觉模型设置格式错误"))
+			}
+			providerName, _ := vm["provider"].(string)
+			modelName, _ := vm["model"].(string)
+			modelEntity, err := s.llmService.GetModelEntity(providerName, modelName)
+			if err != nil {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("视觉模型不存在，请核实后重试"))
+			}
+			if !llmentity.HasFeature(modelEntity.Features, llmentity.FeatureImageInput) {
+				return nil, errno.ErrValidate.AppendBizMessage(errors.New("视觉模型不支持图片输入，请更换模型"))
+			}
+			draftAppConfig["image_input"] = map[string]any{
+				"enable":       ii["enable"],
+				"vision_model": map[string]any{"provider": providerName, "model": modelName},
+			}
+		}
+	}
+
+	// 20. 根据模型能力校验配置，避免运行时才发现模型无法调用工具或读取图片
+	if err := s.validateModelCapabilities(draftAppConfig, appID); err != nil {
+		return nil, err
+	}
+
 	return draftAppConfig, nil
 }
 
+// validateModelCapabilities 校验模型是否支持配置中用到的能力，未传递的配置取自当前草稿
+func (s *AppService) validateModelCapabilities(draftAppConfig map[string]any, appID uuid.UUID) error {
+	// 1. 合并当前草稿配置，只更新部分字段时同样需要校验
+	merged := make(map[string]any, len(draftAppConfig))
+	if draft, err := s.repo.GetDraftAppConfigVersion(context.Background(), appID); err == nil && draft != nil {
+		merged["model_config"] = draft.ModelConfig
+		merged["tools"] = draft.Tools
+		merged["workflows"] = draft.Workflows
+		merged["apps"] = draft.Apps
+		merged["datasets"] = draft.Datasets
+		merged["image_input"] = draft.ImageInput
+	}
+	for key, value := range draftAppConfig {
+		merged[key] = value
+	}
+
+	// 2. 获取模型支持的特性，模型不存在时已在前面的校验中返回
+	modelConfig, _ := merged["model_config"].(map[string]any)
+	providerName, _ := modelConfig["provider"].(string)
+	modelName, _ := modelConfig["model"].(string)
+	if providerName == "" || modelName == "" {
+		return nil
+	}
+	modelEntity, err := s.llmService.GetModelEntity(providerName, modelName)
+	if err != nil {
+		return nil
+	}
+
+	// 3. 关联了工具、工作流、应用或知识库时，模型需要支持工具调用或ReACT
+	if !llmentity.SupportsTools(modelEntity.Features) {
+		for _, key := range []string{"tools", "workflows", "apps", "datasets"} {
+			if value := reflect.ValueOf(merged[key]); value.Kind() == reflect.Slice && value.Len() > 0 {
+				return errno.ErrValidate.AppendBizMessage(fmt.Errorf("模型%s不支持工具调用，无法关联工具、工作流、应用或知识库", modelName))
+			}
+		}
+	}
+
+	// 4. 开启图片输入时，模型需要支持图片输入或配置了视觉模型
+	imageInput, _ := merged["image_input"].(map[string]any)
+	if enable, _ := imageInput["enable"].(bool); enable &&
+		!llmentity.HasFeature(modelEntity.Features, llmentity.FeatureImageInput) && llm.VisionModelSpec(imageInput) == nil {
+		return errno.ErrValidate.AppendBizMessage(fmt.Errorf("模型%s不支持图片输入，请更换模型或配置视觉模型", modelName))
+	}
+
+	return nil
+}
+
 func (s *AppService) generateDefaultToken(ctx context.Context, appID uuid.UUID) (string, error) {
 	app, err := s.repo.GetAppByID(ctx, appID)
 	if err != nil {
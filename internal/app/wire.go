@@ -3,8 +3,6 @@
 package app
 
 import (
-	"context"
-
 	"github.com/crazyfrankie/voidx/internal/app/handler"
 	"github.com/crazyfrankie/voidx/internal/app/repository"
 	"github.com/crazyfrankie/voidx/internal/app/repository/dao"
@@ -13,12 +11,13 @@ import (
 	"github.com/crazyfrankie/voidx/internal/conversation"
 	"github.com/crazyfrankie/voidx/internal/core/agent"
 	llmcore "github.com/crazyfrankie/voidx/internal/core/llm"
-	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/core/tools/api_tools/providers"
 	builtin "github.com/crazyfrankie/voidx/internal/core/tools/builtin_tools/providers"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/upload"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 )
@@ -31,24 +30,12 @@ type AppModule struct {
 	Service *Service
 }
 
-func InitModel(llmManager *llmcore.LanguageModelManager) entities.BaseLanguageModel {
-	model, err := llmManager.CreateModel(context.Background(), "tongyi", "qwen-max", map[string]any{
-		"base_url": "https://dashscope.aliyuncs.com/compatible-mode/v1",
-	})
-	if err != nil {
-		panic(err)
-	}
-
-	return model
-}
-
 func InitAppModule(db *gorm.DB, memory *memory.TokenBufferMemory,
 	llmCore *llmcore.LanguageModelManager, appConfig *app_config.AppConfigModule, agentSvc *agent.AgentQueueManagerFactory,
-	ossSvc *upload.UploadModule, retrieverSvc *retriever.RetrieverModule,
+	ossSvc *upload.UploadModule, retrieverSvc *retriever.RetrieverModule, llmModule *llm.LLMModule,
 	apiProvider *providers.APIProviderManager, builtinProvider *builtin.BuiltinProviderManager,
-	convers *conversation.ConversationModule) *AppModule {
+	convers *conversation.ConversationModule, userMemoryModule *user_memory.UserMemoryModule) *AppModule {
 	wire.Build(
-		InitModel,
 		dao.NewAppDao,
 		repository.NewAppRepo,
 		service.NewAppService,
@@ -59,6 +46,8 @@ func InitAppModule(db *gorm.DB, memory *memory.TokenBufferMemory,
 		wire.FieldsOf(new(*conversation.ConversationModule), "Service"),
 		wire.FieldsOf(new(*upload.UploadModule), "Service"),
 		wire.FieldsOf(new(*retriever.RetrieverModule), "Service"),
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
+		wire.FieldsOf(new(*user_memory.UserMemoryModule), "Service"),
 	)
 	return new(AppModule)
 }
@@ -7,7 +7,6 @@
 package app
 
 import (
-	"context"
 	"github.com/crazyfrankie/voidx/internal/app/handler"
 	"github.com/crazyfrankie/voidx/internal/app/repository"
 	"github.com/crazyfrankie/voidx/internal/app/repository/dao"
@@ -16,26 +15,28 @@ import (
 	"github.com/crazyfrankie/voidx/internal/conversation"
 	"github.com/crazyfrankie/voidx/internal/core/agent"
 	"github.com/crazyfrankie/voidx/internal/core/llm"
-	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/core/tools/api_tools/providers"
 	providers2 "github.com/crazyfrankie/voidx/internal/core/tools/builtin_tools/providers"
+	llm2 "github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/upload"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitAppModule(db *gorm.DB, memory2 *memory.TokenBufferMemory, llmCore *llm.LanguageModelManager, appConfig *app_config.AppConfigModule, agentSvc *agent.AgentQueueManagerFactory, ossSvc *upload.UploadModule, retrieverSvc *retriever.RetrieverModule, apiProvider *providers.APIProviderManager, builtinProvider *providers2.BuiltinProviderManager, convers *conversation.ConversationModule) *AppModule {
+func InitAppModule(db *gorm.DB, memory2 *memory.TokenBufferMemory, llmCore *llm.LanguageModelManager, appConfig *app_config.AppConfigModule, agentSvc *agent.AgentQueueManagerFactory, ossSvc *upload.UploadModule, retrieverSvc *retriever.RetrieverModule, llmModule *llm2.LLMModule, apiProvider *providers.APIProviderManager, builtinProvider *providers2.BuiltinProviderManager, convers *conversation.ConversationModule, userMemoryModule *user_memory.UserMemoryModule) *AppModule {
 	appDao := dao.NewAppDao(db)
 	appRepo := repository.NewAppRepo(appDao)
 	appConfigService := appConfig.Service
 	conversationService := convers.Service
 	retrievalService := retrieverSvc.Service
 	ossService := ossSvc.Service
-	baseLanguageModel := InitModel(llmCore)
-	appService := service.NewAppService(appRepo, appConfigService, conversationService, retrievalService, ossService, apiProvider, builtinProvider, agentSvc, llmCore, memory2, baseLanguageModel)
+	llmService := llmModule.Service
+	userMemoryService := userMemoryModule.Service
+	appService := service.NewAppService(appRepo, appConfigService, conversationService, retrievalService, ossService, apiProvider, builtinProvider, agentSvc, llmCore, memory2, llmService, userMemoryService)
 	appHandler := handler.NewAppHandler(appService, appConfigService)
 	appModule := &AppModule{
 		Handler: appHandler,
@@ -54,14 +55,3 @@ type AppModule struct {
 	Handler *Handler
 	Service *Service
 }
-
-func InitModel(llmManager *llm.LanguageModelManager) entities.BaseLanguageModel {
-	model, err := llmManager.CreateModel(context.Background(), "tongyi", "qwen-max", map[string]any{
-		"base_url": "https://dashscope.aliyuncs.com/compatible-mode/v1",
-	})
-	if err != nil {
-		panic(err)
-	}
-
-	return model
-}
@@ -84,3 +84,23 @@ func (r *AppConfigRepo) GetAppDatasetJoins(ctx context.Context, appID uuid.UUID)
 func (r *AppConfigRepo) DeleteAppDatasetJoin(ctx context.Context, appID, datasetID uuid.UUID) error {
 	return r.dao.DeleteAppDatasetJoin(ctx, appID, datasetID)
 }
+
+// GetAppByID 根据ID获取应用
+func (r *AppConfigRepo) GetAppByID(ctx context.Context, id uuid.UUID) (*entity.App, error) {
+	return r.dao.GetAppByID(ctx, id)
+}
+
+// CreateConversation 创建会话
+func (r *AppConfigRepo) CreateConversation(ctx context.Context, conversation *entity.Conversation) error {
+	return r.dao.CreateConversation(ctx, conversation)
+}
+
+// CreateMessage 创建消息
+func (r *AppConfigRepo) CreateMessage(ctx context.Context, message *entity.Message) error {
+	return r.dao.CreateMessage(ctx, message)
+}
+
+// UpdateMessage 更新消息
+func (r *AppConfigRepo) UpdateMessage(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return r.dao.UpdateMessage(ctx, id, updates)
+}
@@ -59,6 +59,8 @@ func (d *AppConfigDao) CloneAppConfig(ctx context.Context, sourceID uuid.UUID) (
 		LongTermMemory:       sourceConfig.LongTermMemory,
 		TextToSpeech:         sourceConfig.TextToSpeech,
 		SpeechToText:         sourceConfig.SpeechToText,
+		StructuredOutput:     sourceConfig.StructuredOutput,
+		ImageInput:           sourceConfig.ImageInput,
 	}
 
 	err = d.db.WithContext(ctx).Create(&newConfig).Error
@@ -193,3 +195,28 @@ func (d *AppConfigDao) DeleteAppDatasetJoin(ctx context.Context, appID, datasetI
 		Where("app_id = ? AND dataset_id = ?", appID, datasetID).
 		Delete(&entity.AppDatasetJoin{}).Error
 }
+
+// GetAppByID 根据ID获取应用
+func (d *AppConfigDao) GetAppByID(ctx context.Context, id uuid.UUID) (*entity.App, error) {
+	var app entity.App
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&app).Error
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// CreateConversation 创建会话
+func (d *AppConfigDao) CreateConversation(ctx context.Context, conversation *entity.Conversation) error {
+	return d.db.WithContext(ctx).Create(conversation).Error
+}
+
+// CreateMessage 创建消息
+func (d *AppConfigDao) CreateMessage(ctx context.Context, message *entity.Message) error {
+	return d.db.WithContext(ctx).Create(message).Error
+}
+
+// UpdateMessage 更新消息
+func (d *AppConfigDao) UpdateMessage(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return d.db.WithContext(ctx).Model(&entity.Message{}).Where("id = ?", id).Updates(updates).Error
+}
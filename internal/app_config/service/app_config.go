@@ -12,12 +12,15 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/crazyfrankie/voidx/internal/app_config/repository"
+	"github.com/crazyfrankie/voidx/internal/core/agent"
 	"github.com/crazyfrankie/voidx/internal/core/llm"
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	apitools "github.com/crazyfrankie/voidx/internal/core/tools/api_tools/providers"
 	builtin "github.com/crazyfrankie/voidx/internal/core/tools/builtin_tools/providers"
 	"github.com/crazyfrankie/voidx/internal/core/workflow"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
+	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/crazyfrankie/voidx/types/errno"
@@ -29,17 +32,22 @@ type AppConfigService struct {
 	builtinProvider *builtin.BuiltinProviderManager
 	apiProvider     *apitools.APIProviderManager
 	workflowManager *workflow.WorkflowManager
+	agentManager    *agent.AgentQueueManagerFactory
+	retrieverSvc    *retriever.Service
 }
 
 func NewAppConfigService(repo *repository.AppConfigRepo, llmMgr *llm.LanguageModelManager,
 	builtinProvider *builtin.BuiltinProviderManager, apiProvider *apitools.APIProviderManager,
-	workflowManager *workflow.WorkflowManager) *AppConfigService {
+	workflowManager *workflow.WorkflowManager, agentManager *agent.AgentQueueManagerFactory,
+	retrieverSvc *retriever.Service) *AppConfigService {
 	return &AppConfigService{
 		repo:            repo,
 		llmMgr:          llmMgr,
 		builtinProvider: builtinProvider,
 		apiProvider:     apiProvider,
 		workflowManager: workflowManager,
+		agentManager:    agentManager,
+		retrieverSvc:    retrieverSvc,
 	}
 }
 
@@ -103,8 +111,19 @@ func (s *AppConfigService) GetDraftAppConfig(ctx context.Context, app *entity.Ap
 		}
 	}
 
-	// 8. 将数据转换成字典后返回
-	return s.processAndTransformAppConfig(validateModelConfig, tools, workflows, datasets, draftAppConfig), nil
+	// 8. 校验关联应用列表对应的数据
+	apps, validateApps := s.processAndValidateApps(ctx, app, draftAppConfig.Apps)
+	if !s.compareDatasetSlices(validateApps, draftAppConfig.Apps) {
+		err = s.repo.UpdateAppConfigVersion(ctx, app.DraftAppConfigID, map[string]any{
+			"apps": validateApps,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 9. 将数据转换成字典后返回
+	return s.processAndTransformAppConfig(validateModelConfig, tools, workflows, apps, datasets, draftAppConfig), nil
 }
 
 // GetAppConfig 根据传递的应用获取该应用的运行配置
@@ -184,11 +203,23 @@ func (s *AppConfigService) GetAppConfig(ctx context.Context, app *entity.App) (*
 		}
 	}
 
-	// 8. 将数据转换成字典后返回
+	// 8. 校验关联应用列表对应的数据
+	apps, validateApps := s.processAndValidateApps(ctx, app, appConfig.Apps)
+	if !s.compareDatasetSlices(validateApps, appConfig.Apps) {
+		err = s.repo.UpdateAppConfig(ctx, app.AppConfigID, map[string]any{
+			"apps": validateApps,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 9. 将数据转换成字典后返回
 	return s.processAndTransformAppConfig(
 		validateModelConfig,
 		tools,
 		workflows,
+		apps,
 		datasets,
 		&entity.AppConfigVersion{
 			ModelConfig:          appConfig.ModelConfig,
@@ -196,6 +227,7 @@ func (s *AppConfigService) GetAppConfig(ctx context.Context, app *entity.App) (*
 			PresetPrompt:         appConfig.PresetPrompt,
 			Tools:                appConfig.Tools,
 			Workflows:            appConfig.Workflows,
+			Apps:                 appConfig.Apps,
 			RetrievalConfig:      appConfig.RetrievalConfig,
 			LongTermMemory:       appConfig.LongTermMemory,
 			OpeningStatement:     appConfig.OpeningStatement,
@@ -204,12 +236,14 @@ func (s *AppConfigService) GetAppConfig(ctx context.Context, app *entity.App) (*
 			TextToSpeech:         appConfig.TextToSpeech,
 			SuggestedAfterAnswer: appConfig.SuggestedAfterAnswer,
 			ReviewConfig:         appConfig.ReviewConfig,
+			StructuredOutput:     appConfig.StructuredOutput,
+			ImageInput:           appConfig.ImageInput,
 		},
 	), nil
 }
 
-// GetToolsByToolsConfig 根据传递的工具配置列表获取eino工具列表
-func (s *AppConfigService) GetToolsByToolsConfig(ctx context.Context, toolConfigs []map[string]any) ([]tool.InvokableTool, error) {
+// GetToolsByToolsConfig 根据传递的工具配置列表获取eino工具列表，需要模型服务凭证的工具使用应用所属账号的凭证
+func (s *AppConfigService) GetToolsByToolsConfig(ctx context.Context, accountID uuid.UUID, toolConfigs []map[string]any) ([]tool.InvokableTool, error) {
 	// 1. 循环遍历所有工具配置列表信息
 	var res []tool.InvokableTool
 	for _, tool := range toolConfigs {
@@ -227,7 +261,7 @@ func (s *AppConfigService) GetToolsByToolsConfig(ctx context.Context, toolConfig
 			}
 
 			// 通过 builtin provider manager 获取实际的可执行工具
-			builtinTool, err := s.builtinProvider.GetTool(toolID)
+			builtinTool, err := s.getBuiltinTool(ctx, accountID, toolID)
 			if err != nil || builtinTool == nil {
 				continue
 			}
@@ -260,6 +294,60 @@ func (s *AppConfigService) GetToolsByToolsConfig(ctx context.Context, toolConfig
 	return res, nil
 }
 
+// GetConfirmationToolNames 根据工具配置列表获取需要用户确认后才能执行的工具名称，
+// 名称与GetToolsByToolsConfig构建出的工具名称保持一致，内置工具为工具id，API工具为"<提供者id>_<工具名称>"
+func (s *AppConfigService) GetConfirmationToolNames(toolConfigs []map[string]any) []string {
+	var names []string
+	for _, tool := range toolConfigs {
+		if requireConfirmation, _ := tool["require_confirmation"].(bool); !requireConfirmation {
+			continue
+		}
+
+		toolID, _ := tool["tool_id"].(string)
+		if toolID == "" {
+			continue
+		}
+		if toolType, _ := tool["type"].(string); toolType == "builtin_tool" {
+			names = append(names, toolID)
+			continue
+		}
+		if providerID, _ := tool["provider_id"].(string); providerID != "" {
+			names = append(names, apitools.ToolName(providerID, toolID))
+		}
+	}
+
+	return names
+}
+
+// getBuiltinTool 获取内置工具，需要模型服务凭证的工具使用账号的凭证创建
+func (s *AppConfigService) getBuiltinTool(ctx context.Context, accountID uuid.UUID, toolID string) (tool.InvokableTool, error) {
+	providerName, ok := s.builtinProvider.CredentialProvider(toolID)
+	if !ok {
+		return s.builtinProvider.GetTool(toolID)
+	}
+
+	credentials, err := s.llmMgr.ResolveCredentials(ctx, accountID, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.builtinProvider.CreateToolWithCredentials(toolID, credentials)
+}
+
+// GetOutputSchema 根据结构化输出配置获取最终回答需要遵循的JSON Schema，未开启时返回nil
+func (s *AppConfigService) GetOutputSchema(structuredOutput map[string]any) map[string]any {
+	if enable, _ := structuredOutput["enable"].(bool); !enable {
+		return nil
+	}
+
+	outputSchema, _ := structuredOutput["schema"].(map[string]any)
+	if len(outputSchema) == 0 {
+		return nil
+	}
+
+	return outputSchema
+}
+
 // GetToolsByWorkflowIDs 根据传递的工作流配置列表获取eino工具列表
 func (s *AppConfigService) GetToolsByWorkflowIDs(ctx context.Context, workflowIDs []uuid.UUID) ([]tool.InvokableTool, error) {
 	// 1. 根据传递的工作流id查询工作流记录信息
@@ -303,8 +391,41 @@ func (s *AppConfigService) GetToolsByWorkflowIDs(ctx context.Context, workflowID
 	return workflows, nil
 }
 
-// processAndTransformAppConfig 根据传递的插件列表、工作流列表、知识库列表以及应用配置创建字典信息
-func (s *AppConfigService) processAndTransformAppConfig(modelConfig map[string]any, tools []map[string]any, workflows []map[string]any, datasets []map[string]any, appConfig *entity.AppConfigVersion) *resp.AppDraftConfigResp {
+// GetToolsByAppIDs 根据传递的应用id列表获取eino工具列表，仅同账号下已发布的应用可以作为工具
+func (s *AppConfigService) GetToolsByAppIDs(ctx context.Context, accountID uuid.UUID, appIDs []uuid.UUID) ([]tool.InvokableTool, error) {
+	var apps []tool.InvokableTool
+
+	for _, appID := range appIDs {
+		// 1. 查询应用记录并校验归属以及发布状态
+		appRecord, err := s.repo.GetAppByID(ctx, appID)
+		if err != nil || appRecord == nil {
+			continue
+		}
+		if appRecord.AccountID != accountID ||
+			appRecord.Status != consts.AppStatusPublished ||
+			appRecord.AppConfigID == uuid.Nil {
+			continue
+		}
+
+		// 2. 使用应用名称与描述构建工具描述
+		description := appRecord.Name
+		if appRecord.Description != "" {
+			description = appRecord.Name + ": " + appRecord.Description
+		}
+
+		apps = append(apps, &AppTool{
+			svc:         s,
+			app:         appRecord,
+			name:        appToolName(appRecord.ID),
+			description: description,
+		})
+	}
+
+	return apps, nil
+}
+
+// processAndTransformAppConfig 根据传递的插件列表、工作流列表、关联应用列表、知识库列表以及应用配置创建字典信息
+func (s *AppConfigService) processAndTransformAppConfig(modelConfig map[string]any, tools []map[string]any, workflows []map[string]any, apps []map[string]any, datasets []map[string]any, appConfig *entity.AppConfigVersion) *resp.AppDraftConfigResp {
 	return &resp.AppDraftConfigResp{
 		Id:                   appConfig.ID,
 		ModelConfig:          modelConfig,
@@ -312,6 +433,7 @@ func (s *AppConfigService) processAndTransformAppConfig(modelConfig map[string]a
 		PresetPrompt:         appConfig.PresetPrompt,
 		Tools:                tools,
 		Workflows:            workflows,
+		Apps:                 apps,
 		Datasets:             datasets,
 		RetrievalConfig:      appConfig.RetrievalConfig,
 		LongTermMemory:       appConfig.LongTermMemory,
@@ -321,6 +443,8 @@ func (s *AppConfigService) processAndTransformAppConfig(modelConfig map[string]a
 		TextToSpeech:         appConfig.TextToSpeech,
 		SuggestedAfterAnswer: appConfig.SuggestedAfterAnswer,
 		ReviewConfig:         appConfig.ReviewConfig,
+		StructuredOutput:     appConfig.StructuredOutput,
+		ImageInput:           appConfig.ImageInput,
 	}
 }
 
@@ -376,8 +500,10 @@ func (s *AppConfigService) processAndValidateTools(ctx context.Context, toolConf
 
 			// 7. 组装内置工具展示信息
 			providerEntity := provider.ProviderEntity
+			requireConfirmation, _ := tool["require_confirmation"].(bool)
 			tools = append(tools, map[string]any{
-				"type": "builtin_tool",
+				"type":                 "builtin_tool",
+				"require_confirmation": requireConfirmation,
 				"provider": map[string]any{
 					"id":          providerEntity.Name,
 					"name":        providerEntity.Name,
@@ -421,8 +547,10 @@ func (s *AppConfigService) processAndValidateTools(ctx context.Context, toolConf
 				continue
 			}
 
+			requireConfirmation, _ := tool["require_confirmation"].(bool)
 			tools = append(tools, map[string]any{
-				"type": "api_tool",
+				"type":                 "api_tool",
+				"require_confirmation": requireConfirmation,
 				"provider": map[string]any{
 					"id":          provider.ID.String(),
 					"name":        provider.Name,
@@ -523,9 +651,55 @@ func (s *AppConfigService) processAndValidateModelConfig(modelConfigMap map[stri
 	validatedParams := s.validateModelParameters(provider, model, modelConfig["parameters"].(map[string]any))
 	modelConfig["parameters"] = validatedParams
 
+	// 6. 校验备用模型列表，剔除不存在的提供商或模型
+	if fallbacks, ok := modelConfigMap["fallbacks"].([]any); ok {
+		validFallbacks := make([]any, 0, len(fallbacks))
+		for _, fallback := range fallbacks {
+			if len(validFallbacks) >= llmentity.MaxFallbackModels {
+				break
+			}
+			if fallbackConfig, ok := s.processFallbackModelConfig(fallback); ok {
+				validFallbacks = append(validFallbacks, fallbackConfig)
+			}
+		}
+		modelConfig["fallbacks"] = validFallbacks
+	}
+
+	// 7. 重试规则不合法时剔除，运行时使用默认规则
+	if retry, exists := modelConfigMap["retry"]; exists {
+		if _, err := llmentity.ParseRetryPolicy(retry); err == nil {
+			modelConfig["retry"] = retry
+		}
+	}
+
 	return modelConfig
 }
 
+// processFallbackModelConfig 校验单个备用模型配置，提供商或模型不存在时返回false
+func (s *AppConfigService) processFallbackModelConfig(fallback any) (map[string]any, bool) {
+	fallbackMap, ok := fallback.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	provider, _ := fallbackMap["provider"].(string)
+	model, _ := fallbackMap["model"].(string)
+	if _, err := s.llmMgr.GetModelEntity(provider, model); err != nil {
+		return nil, false
+	}
+
+	parameters, ok := fallbackMap["parameters"].(map[string]any)
+	if !ok {
+		parameters = s.getModelDefaultParameters(provider, model)
+	}
+
+	return map[string]any{
+		"provider":   provider,
+		"model":      model,
+		"parameters": s.validateModelParameters(provider, model, parameters),
+	}, true
+}
+
 // processAndValidateWorkflows 根据传递的工作流列表并返回工作流配置和校验后的数据
 func (s *AppConfigService) processAndValidateWorkflows(ctx context.Context, workflowIDs []string) ([]map[string]any, []string) {
 	// 1. 校验工作流配置列表，如果引用了不存在/被删除的工作流，则需要剔除数据并更新，同时获取工作流的额外信息
@@ -561,6 +735,40 @@ func (s *AppConfigService) processAndValidateWorkflows(ctx context.Context, work
 	return workflows, validateWorkflows
 }
 
+// processAndValidateApps 根据传递的关联应用列表并返回应用配置和校验后的数据
+func (s *AppConfigService) processAndValidateApps(ctx context.Context, app *entity.App, appIDs []string) ([]map[string]any, []string) {
+	// 1. 校验关联应用列表，如果引用了不存在/未发布/不属于当前账号的应用，则需要剔除数据并更新
+	apps := make([]map[string]any, 0, len(appIDs))
+	validateApps := make([]string, 0, len(appIDs))
+
+	for _, appID := range appIDs {
+		appUUID, err := uuid.Parse(appID)
+		if err != nil || appUUID == app.ID {
+			continue
+		}
+
+		appRecord, err := s.repo.GetAppByID(ctx, appUUID)
+		if err != nil || appRecord == nil {
+			continue
+		}
+
+		if appRecord.AccountID != app.AccountID || appRecord.Status != consts.AppStatusPublished {
+			continue
+		}
+
+		// 2. 数据存在且已发布，添加到结果中
+		validateApps = append(validateApps, appID)
+		apps = append(apps, map[string]any{
+			"id":          appRecord.ID.String(),
+			"name":        appRecord.Name,
+			"icon":        appRecord.Icon,
+			"description": appRecord.Description,
+		})
+	}
+
+	return apps, validateApps
+}
+
 // 辅助方法
 
 // compareJSON 比较两个JSON是否相等
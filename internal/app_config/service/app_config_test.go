@@ -0,0 +1,24 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConfirmationToolNames(t *testing.T) {
+	s := &AppConfigService{}
+
+	names := s.GetConfirmationToolNames([]map[string]any{
+		{"type": "builtin_tool", "provider_id": "time", "tool_id": "current_time", "require_confirmation": false},
+		{"type": "builtin_tool", "provider_id": "dalle", "tool_id": "dalle3", "require_confirmation": true},
+		{"type": "api_tool", "provider_id": "6f1c2d3e-0000-4000-8000-000000000001", "tool_id": "delete_order", "require_confirmation": true},
+		{"type": "api_tool", "provider_id": "6f1c2d3e-0000-4000-8000-000000000001", "tool_id": "list_orders"},
+		// 缺少工具id或提供者id的配置无法构建工具，不需要确认
+		{"type": "api_tool", "tool_id": "broken", "require_confirmation": true},
+		{"type": "builtin_tool", "require_confirmation": true},
+	})
+
+	assert.Equal(t, []string{"dalle3", "6f1c2d3e-0000-4000-8000-000000000001_delete_order"}, names)
+	assert.Nil(t, s.GetConfirmationToolNames(nil))
+}
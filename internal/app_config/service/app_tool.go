@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/agent"
+	agententity "github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	llmcore "github.com/crazyfrankie/voidx/internal/core/llm"
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+// MaxAppToolDepth 应用作为工具时允许的最大嵌套调用深度，避免应用之间循环调用
+const MaxAppToolDepth = 3
+
+type appToolDepthKey struct{}
+
+// appToolDepthFromContext 获取当前上下文中应用工具的嵌套深度
+func appToolDepthFromContext(ctx context.Context) int {
+	depth, _ := ctx.Value(appToolDepthKey{}).(int)
+	return depth
+}
+
+// AppToolInput 应用工具的输入参数
+type AppToolInput struct {
+	Query string `json:"query"`
+}
+
+// AppTool 将已发布的应用包装成可供其他Agent调用的工具
+type AppTool struct {
+	svc         *AppConfigService
+	app         *entity.App
+	name        string
+	description string
+}
+
+// appToolName 根据应用id生成符合函数调用规范的工具名称
+func appToolName(appID uuid.UUID) string {
+	return "app_" + strings.ReplaceAll(appID.String(), "-", "")
+}
+
+func (a *AppTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: a.name,
+		Desc: a.description,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"query": {
+				Type:     schema.String,
+				Desc:     "需要交给该应用处理的问题或任务",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+func (a *AppTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	// 1. 校验嵌套深度，超过限制则直接返回错误
+	depth := appToolDepthFromContext(ctx)
+	if depth >= MaxAppToolDepth {
+		return "", fmt.Errorf("app tool nesting exceeds max depth %d", MaxAppToolDepth)
+	}
+	ctx = context.WithValue(ctx, appToolDepthKey{}, depth+1)
+
+	// 2. 解析输入参数
+	var input AppToolInput
+	if err := sonic.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return "", errors.New("query is required")
+	}
+
+	// 3. 构建子应用的Agent
+	agentIns, err := a.svc.buildAppAgent(ctx, a.app)
+	if err != nil {
+		return "", err
+	}
+
+	// 4. 为子应用创建独立的隐藏会话以及消息记录
+	conversation := &entity.Conversation{
+		ID:         uuid.New(),
+		AppID:      a.app.ID,
+		Name:       "New Conversation",
+		InvokeFrom: consts.InvokeFromAppTool,
+		CreatedBy:  a.app.AccountID,
+	}
+	if err := a.svc.repo.CreateConversation(ctx, conversation); err != nil {
+		return "", err
+	}
+
+	message := &entity.Message{
+		ID:             uuid.New(),
+		AppID:          a.app.ID,
+		ConversationID: conversation.ID,
+		InvokeFrom:     consts.InvokeFromAppTool,
+		CreatedBy:      a.app.AccountID,
+		Query:          input.Query,
+		ImageUrls:      []string{},
+		Status:         consts.MessageStatusNormal,
+	}
+	if err := a.svc.repo.CreateMessage(ctx, message); err != nil {
+		return "", err
+	}
+
+	// 5. 执行子应用Agent并汇总思考过程
+	startTime := time.Now()
+	thoughtChan, err := agentIns.Stream(ctx, agententity.AgentState{
+		TaskID:   uuid.New(),
+		Messages: []*schema.Message{schema.UserMessage(input.Query)},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var answer string
+	status := consts.MessageStatusNormal
+	var errMsg string
+	var order []string
+	agentThoughts := make(map[string]*agententity.AgentThought)
+	for agentThought := range thoughtChan {
+		if agentThought.Event == agententity.EventPing {
+			continue
+		}
+
+		eventID := agentThought.ID.String()
+		existing, exists := agentThoughts[eventID]
+		if !exists {
+			order = append(order, eventID)
+		}
+
+		switch agentThought.Event {
+		case agententity.EventAgentMessage:
+			answer += agentThought.Answer
+			if exists {
+				existing.Thought += agentThought.Thought
+				existing.Answer += agentThought.Answer
+				existing.Latency = agentThought.Latency
+				existing.MergeUsage(agentThought)
+				continue
+			}
+		case agententity.EventAgentReasoning:
+			if exists {
+				existing.Thought += agentThought.Thought
+				existing.Latency = agentThought.Latency
+				continue
+			}
+		case agententity.EventStop:
+			status = consts.MessageStatusStop
+		case agententity.EventTimeout:
+			status = consts.MessageStatusTimeout
+		case agententity.EventError:
+			status = consts.MessageStatusError
+			errMsg = agentThought.Observation
+		}
+		agentThoughts[eventID] = agentThought
+	}
+
+	// 6. 将子应用的思考过程挂载到父Agent的工具调用事件上
+	subThoughts := make([]agententity.AgentThought, 0, len(order))
+	var totalTokens int
+	var totalPrice float64
+	for _, eventID := range order {
+		subThoughts = append(subThoughts, *agentThoughts[eventID])
+		totalTokens += agentThoughts[eventID].TotalTokenCount
+		totalPrice += agentThoughts[eventID].TotalPrice
+	}
+	if collector := agententity.SubThoughtsCollectorFromContext(ctx); collector != nil {
+		collector.Add(subThoughts...)
+	}
+
+	// 7. 更新子会话中的消息记录
+	_ = a.svc.repo.UpdateMessage(ctx, message.ID, map[string]any{
+		"answer":            answer,
+		"status":            status,
+		"error":             errMsg,
+		"latency":           time.Since(startTime).Seconds(),
+		"total_token_count": totalTokens,
+		"total_price":       totalPrice,
+	})
+
+	if status == consts.MessageStatusError {
+		return "", fmt.Errorf("app %s failed: %s", a.app.Name, errMsg)
+	}
+
+	return answer, nil
+}
+
+// buildAppAgent 根据应用的运行配置构建对应的Agent
+func (s *AppConfigService) buildAppAgent(ctx context.Context, app *entity.App) (agent.BaseAgent, error) {
+	// 1. 获取应用的运行配置
+	appConfig, err := s.repo.GetAppConfigByID(ctx, app.AppConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 根据模型配置创建语言模型，备用模型组成回退链
+	modelConfig := s.processAndValidateModelConfig(appConfig.ModelConfig)
+	specs := []llmcore.ModelSpec{modelSpec(modelConfig)}
+	fallbacks, _ := modelConfig["fallbacks"].([]any)
+	for _, fallback := range fallbacks {
+		if fallbackConfig, ok := fallback.(map[string]any); ok {
+			specs = append(specs, modelSpec(fallbackConfig))
+		}
+	}
+	policy, _ := llmentity.ParseRetryPolicy(modelConfig["retry"])
+	llm, err := s.llmMgr.CreateFallbackModelForAccount(ctx, app.AccountID, specs, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 将应用配置中的工具、知识库、工作流以及关联应用转换成eino工具
+	tools, err := s.GetToolsByToolsConfig(ctx, app.AccountID, appConfig.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	joins, err := s.repo.GetAppDatasetJoins(ctx, app.ID)
+	if err == nil && len(joins) > 0 {
+		datasets := make([]uuid.UUID, 0, len(joins))
+		for _, join := range joins {
+			datasets = append(datasets, join.DatasetID)
+		}
+		datasetTool, err := s.retrieverSvc.CreateToolFromSearch(ctx, app.AccountID, datasets, consts.RetrievalSourceApp, appConfig.RetrievalConfig)
+		if err == nil {
+			tools = append(tools, datasetTool)
+		}
+		tableTools, err := s.retrieverSvc.CreateTableQueryTools(ctx, app.AccountID, datasets, llm)
+		if err == nil {
+			tools = append(tools, tableTools...)
+		}
+	}
+
+	workflowIDs := make([]uuid.UUID, 0, len(appConfig.Workflows))
+	for _, id := range appConfig.Workflows {
+		if workflowID, err := uuid.Parse(id); err == nil {
+			workflowIDs = append(workflowIDs, workflowID)
+		}
+	}
+	if workflowTools, err := s.GetToolsByWorkflowIDs(ctx, workflowIDs); err == nil {
+		tools = append(tools, workflowTools...)
+	}
+
+	appIDs := make([]uuid.UUID, 0, len(appConfig.Apps))
+	for _, id := range appConfig.Apps {
+		if appID, err := uuid.Parse(id); err == nil {
+			appIDs = append(appIDs, appID)
+		}
+	}
+	if appTools, err := s.GetToolsByAppIDs(ctx, app.AccountID, appIDs); err == nil {
+		tools = append(tools, appTools...)
+	}
+
+	// 4. 构建Agent配置，子应用不启用长期记忆，子应用的确认请求无法展示给用户，需要确认的工具直接拒绝执行
+	agentCfg := &agententity.AgentConfig{
+		UserID:                  app.AccountID,
+		InvokeFrom:              consts.InvokeFromAppTool,
+		PresetPrompt:            appConfig.PresetPrompt,
+		Tools:                   tools,
+		ConfirmationTools:       s.GetConfirmationToolNames(appConfig.Tools),
+		ConfirmationUnavailable: "应用作为工具被调用时无法确认工具调用",
+		OutputSchema:            s.GetOutputSchema(appConfig.StructuredOutput),
+	}
+	if err := util.ConvertViaJSON(&agentCfg.ReviewConfig, appConfig.ReviewConfig); err != nil {
+		return nil, err
+	}
+
+	// 5. 根据LLM是否支持tool_call决定使用不同的Agent
+	return agent.NewAgent(llm, agentCfg, s.agentManager), nil
+}
+
+// modelSpec 将校验后的模型配置转换为模型描述
+func modelSpec(modelConfig map[string]any) llmcore.ModelSpec {
+	provider, _ := modelConfig["provider"].(string)
+	modelName, _ := modelConfig["model"].(string)
+	parameters, _ := modelConfig["parameters"].(map[string]any)
+
+	return llmcore.ModelSpec{Provider: provider, Model: modelName, Parameters: parameters}
+}
@@ -10,9 +10,12 @@ import (
 	"github.com/crazyfrankie/voidx/internal/app_config/repository"
 	"github.com/crazyfrankie/voidx/internal/app_config/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/app_config/service"
+	"github.com/crazyfrankie/voidx/internal/core/agent"
 	"github.com/crazyfrankie/voidx/internal/core/llm"
 	apitools "github.com/crazyfrankie/voidx/internal/core/tools/api_tools/providers"
 	builtin "github.com/crazyfrankie/voidx/internal/core/tools/builtin_tools/providers"
+	"github.com/crazyfrankie/voidx/internal/core/workflow"
+	"github.com/crazyfrankie/voidx/internal/retriever"
 )
 
 type Service = service.AppConfigService
@@ -28,11 +31,13 @@ var ProviderSet = wire.NewSet(
 
 func InitAppConfigModule(db *gorm.DB, llmMgr *llm.LanguageModelManager,
 	builtinProvider *builtin.BuiltinProviderManager,
-	apiProvider *apitools.APIProviderManager) *AppConfigModule {
+	apiProvider *apitools.APIProviderManager, workflowManager *workflow.WorkflowManager,
+	agentManager *agent.AgentQueueManagerFactory, retrieverModule *retriever.RetrieverModule) *AppConfigModule {
 	wire.Build(
 		ProviderSet,
 
 		wire.Struct(new(AppConfigModule), "*"),
+		wire.FieldsOf(new(*retriever.RetrieverModule), "Service"),
 	)
 	return new(AppConfigModule)
 }
@@ -10,19 +10,23 @@ import (
 	"github.com/crazyfrankie/voidx/internal/app_config/repository"
 	"github.com/crazyfrankie/voidx/internal/app_config/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/app_config/service"
+	"github.com/crazyfrankie/voidx/internal/core/agent"
 	"github.com/crazyfrankie/voidx/internal/core/llm"
 	providers2 "github.com/crazyfrankie/voidx/internal/core/tools/api_tools/providers"
 	"github.com/crazyfrankie/voidx/internal/core/tools/builtin_tools/providers"
+	"github.com/crazyfrankie/voidx/internal/core/workflow"
+	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitAppConfigModule(db *gorm.DB, llmMgr *llm.LanguageModelManager, builtinProvider *providers.BuiltinProviderManager, apiProvider *providers2.APIProviderManager) *AppConfigModule {
+func InitAppConfigModule(db *gorm.DB, llmMgr *llm.LanguageModelManager, builtinProvider *providers.BuiltinProviderManager, apiProvider *providers2.APIProviderManager, workflowManager *workflow.WorkflowManager, agentManager *agent.AgentQueueManagerFactory, retrieverModule *retriever.RetrieverModule) *AppConfigModule {
 	appConfigDao := dao.NewAppConfigDao(db)
 	appConfigRepo := repository.NewAppConfigRepo(appConfigDao)
-	appConfigService := service.NewAppConfigService(appConfigRepo, llmMgr, builtinProvider, apiProvider)
+	retrievalService := retrieverModule.Service
+	appConfigService := service.NewAppConfigService(appConfigRepo, llmMgr, builtinProvider, apiProvider, workflowManager, agentManager, retrievalService)
 	appConfigModule := &AppConfigModule{
 		Service: appConfigService,
 	}
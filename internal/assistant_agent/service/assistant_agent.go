@@ -88,7 +88,7 @@ func (s *AssistantAgentService) Chat(ctx context.Context, userID uuid.UUID, chat
 		EnableLongTermMemory: true,
 		Tools:                []tool.InvokableTool{tl},
 	}
-	agentIns := agent.NewFunctionCallAgent(s.llm, agentCfg, s.agentManager)
+	agentIns := agent.NewAgent(s.llm, agentCfg, s.agentManager)
 
 	responseStream := make(chan string, 100)
 
@@ -118,7 +118,7 @@ func (s *AssistantAgentService) processChat(ctx context.Context,
 
 	// 添加当前用户消息
 	if len(chatReq.Query) > 0 {
-		userMsg := schema.UserMessage(chatReq.Query)
+		userMsg := s.llm.ConvertToHumanMessage(chatReq.Query, chatReq.ImageUrls)
 		agentState.Messages = append(agentState.Messages, userMsg)
 	}
 
@@ -141,13 +141,14 @@ func (s *AssistantAgentService) processChat(ctx context.Context,
 
 		// 除了ping事件，其他事件全部记录
 		if agentThought.Event != agenteneity.EventPing {
-			// 单独处理agent_message事件，因为该事件为数据叠加
-			if agentThought.Event == agenteneity.EventAgentMessage {
+			// 单独处理agent_message与agent_reasoning事件，因为该事件为数据叠加
+			if agentThought.Event == agenteneity.EventAgentMessage || agentThought.Event == agenteneity.EventAgentReasoning {
 				if existing, exists := agentThoughts[eventID]; exists {
 					// 叠加智能体消息事件
 					existing.Thought = existing.Thought + agentThought.Thought
 					existing.Answer = existing.Answer + agentThought.Answer
 					existing.Latency = agentThought.Latency
+					existing.MergeUsage(agentThought)
 					agentThoughts[eventID] = existing
 				} else {
 					// 初始化智能体消息事件
@@ -7,29 +7,33 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/google/uuid"
 	"github.com/sashabaranov/go-openai"
 
 	"github.com/crazyfrankie/voidx/internal/audio/repository"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/types/errno"
 )
 
 type AudioService struct {
-	repo *repository.AudioRepo
+	repo   *repository.AudioRepo
+	llmSvc *llm.Service
 }
 
-func NewAudioService(repo *repository.AudioRepo) *AudioService {
-	return &AudioService{repo: repo}
+func NewAudioService(repo *repository.AudioRepo, llmSvc *llm.Service) *AudioService {
+	return &AudioService{repo: repo, llmSvc: llmSvc}
 }
 
 // AudioToText 将传递的语音转换成文本
 func (s *AudioService) AudioToText(ctx context.Context, userID uuid.UUID, audioData []byte, filename string) (string, error) {
-	// 1. 创建OpenAI客户端
-	client := s.getOpenAIClient()
+	// 1. 使用账号的凭证创建OpenAI客户端
+	client, err := s.getOpenAIClient(ctx, userID)
+	if err != nil {
+		return "", err
+	}
 
 	// 2. 创建音频请求
 	req := openai.AudioRequest{
@@ -83,11 +87,17 @@ func (s *AudioService) MessageToAudio(ctx context.Context, userID, messageID uui
 		return nil, errno.ErrValidate.AppendBizMessage(errors.New("该应用未开启文字转语音功能"))
 	}
 
-	// 4. 创建事件通道
+	// 4. 使用账号的凭证创建OpenAI客户端
+	client, err := s.getOpenAIClient(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. 创建事件通道
 	eventChan := make(chan resp.TTSEvent, 100)
 
-	// 5. 启动异步TTS处理
-	go s.processTTS(ctx, message, conversation, ttsConfig, eventChan)
+	// 6. 启动异步TTS处理
+	go s.processTTS(ctx, client, message, conversation, ttsConfig, eventChan)
 
 	return eventChan, nil
 }
@@ -147,13 +157,10 @@ func (s *AudioService) getTTSConfig(ctx context.Context, message *entity.Message
 }
 
 // processTTS 处理TTS转换
-func (s *AudioService) processTTS(ctx context.Context, message *entity.Message, conversation *entity.Conversation, config *TTSConfig, eventChan chan<- resp.TTSEvent) {
+func (s *AudioService) processTTS(ctx context.Context, client *openai.Client, message *entity.Message, conversation *entity.Conversation, config *TTSConfig, eventChan chan<- resp.TTSEvent) {
 	defer close(eventChan)
 
-	// 1. 创建OpenAI客户端
-	client := s.getOpenAIClient()
-
-	// 2. 创建TTS请求
+	// 1. 创建TTS请求
 	req := openai.CreateSpeechRequest{
 		Model:          openai.TTSModel1,
 		Input:          message.Answer,
@@ -161,7 +168,7 @@ func (s *AudioService) processTTS(ctx context.Context, message *entity.Message,
 		ResponseFormat: openai.SpeechResponseFormatMp3,
 	}
 
-	// 3. 调用TTS服务
+	// 2. 调用TTS服务
 	response, err := client.CreateSpeech(ctx, req)
 	if err != nil {
 		// 发送错误事件
@@ -174,7 +181,7 @@ func (s *AudioService) processTTS(ctx context.Context, message *entity.Message,
 	}
 	defer response.Close()
 
-	// 4. 流式读取音频数据并发送事件
+	// 3. 流式读取音频数据并发送事件
 	buffer := make([]byte, 1024)
 	for {
 		n, err := response.Read(buffer)
@@ -200,7 +207,7 @@ func (s *AudioService) processTTS(ctx context.Context, message *entity.Message,
 		}
 	}
 
-	// 5. 发送结束事件
+	// 4. 发送结束事件
 	eventChan <- resp.TTSEvent{
 		ConversationID: conversation.ID.String(),
 		MessageID:      message.ID.String(),
@@ -208,11 +215,17 @@ func (s *AudioService) processTTS(ctx context.Context, message *entity.Message,
 	}
 }
 
-// getOpenAIClient 获取OpenAI客户端
-func (s *AudioService) getOpenAIClient() *openai.Client {
-	config := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
-	if baseURL := os.Getenv("OPENAI_API_BASE"); baseURL != "" {
+// getOpenAIClient 使用账号的OpenAI凭证创建客户端
+func (s *AudioService) getOpenAIClient(ctx context.Context, accountID uuid.UUID) (*openai.Client, error) {
+	credentials, err := s.llmSvc.ResolveCredentials(ctx, accountID, "openai")
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, _ := credentials["api_key"].(string)
+	config := openai.DefaultConfig(apiKey)
+	if baseURL, _ := credentials["base_url"].(string); baseURL != "" {
 		config.BaseURL = baseURL
 	}
-	return openai.NewClientWithConfig(config)
+	return openai.NewClientWithConfig(config), nil
 }
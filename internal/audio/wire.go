@@ -11,6 +11,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/audio/repository"
 	"github.com/crazyfrankie/voidx/internal/audio/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/audio/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 )
 
 type Handler = handler.AudioHandler
@@ -26,10 +27,11 @@ var ProviderSet = wire.NewSet(
 	handler.NewAudioHandler,
 )
 
-func InitAudioModule(db *gorm.DB) *AudioModule {
+func InitAudioModule(db *gorm.DB, llmModule *llm.LLMModule) *AudioModule {
 	wire.Build(
 		ProviderSet,
-		
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
+
 		wire.Struct(new(AudioModule), "*"),
 	)
 	return new(AudioModule)
@@ -11,16 +11,18 @@ import (
 	"github.com/crazyfrankie/voidx/internal/audio/repository"
 	"github.com/crazyfrankie/voidx/internal/audio/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/audio/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitAudioModule(db *gorm.DB) *AudioModule {
+func InitAudioModule(db *gorm.DB, llmModule *llm.LLMModule) *AudioModule {
 	audioDao := dao.NewAudioDao(db)
 	audioRepo := repository.NewAudioRepo(audioDao)
-	audioService := service.NewAudioService(audioRepo)
+	llmService := llmModule.Service
+	audioService := service.NewAudioService(audioRepo, llmService)
 	audioHandler := handler.NewAudioHandler(audioService)
 	audioModule := &AudioModule{
 		Handler: audioHandler,
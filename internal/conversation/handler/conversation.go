@@ -68,6 +68,7 @@ func (h *ConversationHandler) GetConversationMessagesWithPage() gin.HandlerFunc
 					Observation: at.Observation,
 					Tool:        at.Tool,
 					ToolInput:   at.ToolInput,
+					SubThoughts: at.SubThoughts,
 					Latency:     int(at.Latency),
 					Ctime:       at.Ctime,
 				})
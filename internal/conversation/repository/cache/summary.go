@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type SummaryCache struct {
+	cmd redis.Cmdable
+}
+
+func NewSummaryCache(cmd redis.Cmdable) *SummaryCache {
+	return &SummaryCache{
+		cmd: cmd,
+	}
+}
+
+// AcquireSummarySlot 尝试占用会话的摘要生成时间窗口，窗口内已被占用时返回false
+func (c *SummaryCache) AcquireSummarySlot(ctx context.Context, conversationID uuid.UUID, interval time.Duration) (bool, error) {
+	key := fmt.Sprintf("conversation:summary:throttle:%s", conversationID.String())
+
+	return c.cmd.SetNX(ctx, key, time.Now().Unix(), interval).Result()
+}
@@ -2,20 +2,23 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/crazyfrankie/voidx/internal/conversation/repository/cache"
 	"github.com/crazyfrankie/voidx/internal/conversation/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 )
 
 type ConversationRepo struct {
-	dao *dao.ConversationDao
+	dao   *dao.ConversationDao
+	cache *cache.SummaryCache
 }
 
-func NewConversationRepo(d *dao.ConversationDao) *ConversationRepo {
-	return &ConversationRepo{dao: d}
+func NewConversationRepo(d *dao.ConversationDao, c *cache.SummaryCache) *ConversationRepo {
+	return &ConversationRepo{dao: d, cache: c}
 }
 
 func (r *ConversationRepo) GetConversationByID(ctx context.Context, id uuid.UUID) (*entity.Conversation, error) {
@@ -66,6 +69,18 @@ func (r *ConversationRepo) UpdateConversationSummary(ctx context.Context, conver
 	return r.dao.UpdateConversationSummary(ctx, conversationID, summary)
 }
 
+func (r *ConversationRepo) UpdateConversation(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return r.dao.UpdateConversation(ctx, id, updates)
+}
+
+func (r *ConversationRepo) GetAnsweredMessagesAfter(ctx context.Context, conversationID uuid.UUID, after int64, limit int) ([]entity.Message, error) {
+	return r.dao.GetAnsweredMessagesAfter(ctx, conversationID, after, limit)
+}
+
+func (r *ConversationRepo) AcquireSummarySlot(ctx context.Context, conversationID uuid.UUID, interval time.Duration) (bool, error) {
+	return r.cache.AcquireSummarySlot(ctx, conversationID, interval)
+}
+
 func (r *ConversationRepo) GetConversationsByAccountID(
 	ctx context.Context,
 	accountID uuid.UUID,
@@ -78,6 +93,10 @@ func (r *ConversationRepo) CreateAgentThought(ctx context.Context, agentThought
 	return r.dao.CreateAgentThought(ctx, agentThought)
 }
 
+func (r *ConversationRepo) CreateModerationEvents(ctx context.Context, events []*entity.ModerationEvent) error {
+	return r.dao.CreateModerationEvents(ctx, events)
+}
+
 func (r *ConversationRepo) GetConversationAgentThoughts(ctx context.Context, conversationID uuid.UUID) ([]entity.AgentThought, error) {
 	return r.dao.GetConversationAgentThoughts(ctx, conversationID)
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/types/consts"
 )
 
 type ConversationDao struct {
@@ -124,6 +125,27 @@ func (d *ConversationDao) UpdateConversationSummary(ctx context.Context, convers
 		Where("id = ?", conversationID).Update("summary", summary).Error
 }
 
+func (d *ConversationDao) UpdateConversation(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return d.db.WithContext(ctx).Model(&entity.Conversation{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// GetAnsweredMessagesAfter 按创建时间升序获取会话中指定时间之后已正常回答的消息
+func (d *ConversationDao) GetAnsweredMessagesAfter(ctx context.Context, conversationID uuid.UUID, after int64, limit int) ([]entity.Message, error) {
+	var messages []entity.Message
+	err := d.db.WithContext(ctx).
+		Where("conversation_id = ? AND ctime > ? AND status = ? AND answer <> ''", conversationID, after, consts.MessageStatusNormal).
+		Order("ctime ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
 func (d *ConversationDao) GetConversationsByAccountID(
 	ctx context.Context,
 	accountID uuid.UUID,
@@ -159,6 +181,10 @@ func (d *ConversationDao) CreateAgentThought(ctx context.Context, agentThought *
 	return d.db.WithContext(ctx).Create(agentThought).Error
 }
 
+func (d *ConversationDao) CreateModerationEvents(ctx context.Context, events []*entity.ModerationEvent) error {
+	return d.db.WithContext(ctx).Create(events).Error
+}
+
 func (d *ConversationDao) GetConversationAgentThoughts(ctx context.Context, conversationID uuid.UUID) ([]entity.AgentThought, error) {
 	var agentThoughts []entity.AgentThought
 	if err := d.db.WithContext(ctx).Model(&entity.AgentThought{}).
@@ -4,29 +4,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
 	"github.com/sashabaranov/go-openai"
 
 	"github.com/crazyfrankie/voidx/internal/conversation/repository"
+	"github.com/crazyfrankie/voidx/internal/conversation/task"
 	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
+	"github.com/crazyfrankie/voidx/pkg/logs"
 	"github.com/crazyfrankie/voidx/pkg/util"
 	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/crazyfrankie/voidx/types/errno"
 )
 
+const (
+	// summaryInterval 同一会话两次投递长期记忆摘要任务的最小间隔
+	summaryInterval = time.Minute
+
+	// summaryBatchSize 单次摘要最多合并的消息数
+	summaryBatchSize = 20
+)
+
 type ConversationService struct {
-	repo *repository.ConversationRepo
+	repo     *repository.ConversationRepo
+	producer *task.SummaryProducer
+	llmSvc   *llm.Service
 }
 
-func NewConversationService(repo *repository.ConversationRepo) *ConversationService {
-	return &ConversationService{repo: repo}
+func NewConversationService(repo *repository.ConversationRepo, producer *task.SummaryProducer, llmSvc *llm.Service) *ConversationService {
+	return &ConversationService{repo: repo, producer: producer, llmSvc: llmSvc}
 }
 
 func (s *ConversationService) GetConversationMessagesWithPage(ctx context.Context,
@@ -254,9 +269,12 @@ func (s *ConversationService) GetConversations(ctx context.Context, accountID uu
 	return s.repo.GetConversationsByAccountID(ctx, accountID, getReq)
 }
 
-func (s *ConversationService) GenerateSuggestedQuestions(ctx context.Context, histories string) ([]string, error) {
-	// 使用OpenAI生成建议问题
-	client := s.getOpenAIClient()
+func (s *ConversationService) GenerateSuggestedQuestions(ctx context.Context, accountID uuid.UUID, histories string) ([]string, error) {
+	// 使用账号的OpenAI凭证生成建议问题
+	client, err := s.getOpenAIClient(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
 
 	prompt := fmt.Sprintf(`基于以下对话历史，生成3个相关的后续问题建议。请直接返回问题列表，每行一个问题，不要添加编号或其他格式：
 
@@ -315,29 +333,52 @@ func (s *ConversationService) GenerateSuggestedQuestions(ctx context.Context, hi
 	return result, nil
 }
 
-// getOpenAIClient 获取OpenAI客户端
-func (s *ConversationService) getOpenAIClient() *openai.Client {
-	config := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
-	if baseURL := os.Getenv("OPENAI_API_BASE"); baseURL != "" {
+// getOpenAIClient 使用账号的OpenAI凭证创建客户端
+func (s *ConversationService) getOpenAIClient(ctx context.Context, accountID uuid.UUID) (*openai.Client, error) {
+	credentials, err := s.llmSvc.ResolveCredentials(ctx, accountID, "openai")
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, _ := credentials["api_key"].(string)
+	config := openai.DefaultConfig(apiKey)
+	if baseURL, _ := credentials["base_url"].(string); baseURL != "" {
 		config.BaseURL = baseURL
 	}
-	return openai.NewClientWithConfig(config)
+	return openai.NewClientWithConfig(config), nil
 }
 
 // SaveAgentThoughts 保存Agent思考过程到数据库
 func (s *ConversationService) SaveAgentThoughts(ctx context.Context, accountID, appID, conversationID, messageID uuid.UUID, agentThoughts []entities.AgentThought) error {
 	// 构建最终答案
 	var finalAnswer string
-	var totalTokens int
+	var messageTokens, answerTokens, cachedTokens, totalTokens int
 	var totalPrice float64
+	usage := make(map[string]any)
 
-	// 从思考过程中提取最终答案和统计信息
+	// 从思考过程中提取最终答案和统计信息，一条消息可能包含多次LLM调用，需要累加
 	for _, thought := range agentThoughts {
 		if thought.Event == entities.EventAgentMessage && thought.Answer != "" {
 			finalAnswer = thought.Answer
 		}
+		// 配置了备用模型时，以最后一次实际作答的模型为准
+		if thought.Model != "" {
+			usage["model"] = thought.Model
+		}
+		if thought.TotalTokenCount == 0 {
+			continue
+		}
+		messageTokens += thought.MessageTokenCount
+		answerTokens += thought.AnswerTokenCount
+		cachedTokens += thought.CachedTokenCount
 		totalTokens += thought.TotalTokenCount
 		totalPrice += thought.TotalPrice
+
+		// 单价以最后一次LLM调用为准
+		usage["message_unit_price"] = thought.MessageUnitPrice
+		usage["message_price_unit"] = thought.MessagePriceUnit
+		usage["answer_unit_price"] = thought.AnswerUnitPrice
+		usage["answer_price_unit"] = thought.AnswerPriceUnit
 	}
 
 	// 更新消息的答案
@@ -350,6 +391,20 @@ func (s *ConversationService) SaveAgentThoughts(ctx context.Context, accountID,
 		}
 	}
 
+	// 更新消息的作答模型、token消耗以及费用
+	if totalTokens > 0 {
+		usage["message_token_count"] = messageTokens
+		usage["answer_token_count"] = answerTokens
+		usage["cached_token_count"] = cachedTokens
+		usage["total_token_count"] = totalTokens
+		usage["total_price"] = totalPrice
+	}
+	if len(usage) > 0 {
+		if err := s.repo.UpdateMessage(ctx, messageID, usage); err != nil {
+			return fmt.Errorf("failed to update message usage: %w", err)
+		}
+	}
+
 	// 保存Agent思考过程
 	for _, thought := range agentThoughts {
 		agentThoughtEntity := &entity.AgentThought{
@@ -359,23 +414,56 @@ func (s *ConversationService) SaveAgentThoughts(ctx context.Context, accountID,
 			ConversationID:    conversationID,
 			CreatedBy:         accountID,
 			Event:             string(thought.Event),
+			Model:             thought.Model,
 			Thought:           thought.Thought,
 			Observation:       thought.Observation,
 			Tool:              thought.Tool,
 			ToolInput:         thought.ToolInput,
+			SubThoughts:       []map[string]any{},
 			Answer:            thought.Answer,
 			MessageTokenCount: thought.MessageTokenCount,
+			MessageUnitPrice:  thought.MessageUnitPrice,
+			MessagePriceUnit:  thought.MessagePriceUnit,
 			AnswerTokenCount:  thought.AnswerTokenCount,
+			AnswerUnitPrice:   thought.AnswerUnitPrice,
+			AnswerPriceUnit:   thought.AnswerPriceUnit,
+			CachedTokenCount:  thought.CachedTokenCount,
 			TotalTokenCount:   thought.TotalTokenCount,
 			TotalPrice:        thought.TotalPrice,
 			Latency:           thought.Latency,
 		}
 
+		if len(thought.SubThoughts) > 0 {
+			_ = util.ConvertViaJSON(&agentThoughtEntity.SubThoughts, thought.SubThoughts)
+		}
+
 		err := s.repo.CreateAgentThought(ctx, agentThoughtEntity)
 		if err != nil {
 			// 记录错误但继续处理其他思考过程
 			continue
 		}
+
+		// 审核事件的每个命中单独记录，便于审计
+		if thought.Event == entities.EventModeration && len(thought.Moderation) > 0 {
+			events := make([]*entity.ModerationEvent, 0, len(thought.Moderation))
+			for _, hit := range thought.Moderation {
+				events = append(events, &entity.ModerationEvent{
+					AppID:          appID,
+					ConversationID: conversationID,
+					MessageID:      messageID,
+					CreatedBy:      accountID,
+					Stage:          string(hit.Stage),
+					Moderator:      hit.Moderator,
+					Category:       hit.Category,
+					Action:         string(hit.Action),
+					Match:          hit.Match,
+					Reason:         hit.Reason,
+				})
+			}
+			if err := s.repo.CreateModerationEvents(ctx, events); err != nil {
+				logs.Errorf("Failed to save moderation events of message %s: %v", messageID, err)
+			}
+		}
 	}
 
 	return nil
@@ -410,6 +498,78 @@ func (s *ConversationService) UpdateConversationSummary(ctx context.Context, con
 	return s.repo.UpdateConversationSummary(ctx, conversationID, summary)
 }
 
+// ScheduleConversationSummary 在会话消息完成后为开启了长期记忆的应用投递摘要任务，同一会话在时间窗口内只投递一次，
+// 窗口内未投递的消息会在下一次摘要时一并合并，摘要模型使用应用所属账号的凭证
+func (s *ConversationService) ScheduleConversationSummary(ctx context.Context, accountID, conversationID uuid.UUID, longTermMemory, modelConfig map[string]any) error {
+	// 1. 应用未开启长期记忆时无需生成摘要
+	if enable, _ := longTermMemory["enable"].(bool); !enable {
+		return nil
+	}
+
+	// 2. 占用会话的摘要时间窗口，已被占用则跳过本次投递
+	acquired, err := s.repo.AcquireSummarySlot(ctx, conversationID, summaryInterval)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return nil
+	}
+
+	// 3. 投递摘要任务，由消费者异步生成摘要
+	return s.producer.PublishSummaryTask(ctx, accountID, conversationID, modelConfig)
+}
+
+// SummarizeConversation 使用传递的模型将会话中尚未汇总的消息合并进长期记忆摘要，并返回会话以及本次汇总的消息
+func (s *ConversationService) SummarizeConversation(ctx context.Context, llm model.BaseChatModel, conversationID uuid.UUID) (*entity.Conversation, []entity.Message, error) {
+	// 1. 获取会话以及上次摘要之后已完成的消息
+	conversation, err := s.repo.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conversation.IsDeleted {
+		return nil, nil, nil
+	}
+
+	messages, err := s.repo.GetAnsweredMessagesAfter(ctx, conversationID, conversation.SummarizedAt, summaryBatchSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil, nil
+	}
+
+	// 2. 将新的会话拼接成文本并填充摘要模板
+	lines := make([]string, 0, len(messages)*2)
+	for _, message := range messages {
+		lines = append(lines, "Human: "+message.Query, "AI: "+message.Answer)
+	}
+	prompt := strings.NewReplacer(
+		"{summary}", conversation.Summary,
+		"{new_lines}", strings.Join(lines, "\n"),
+	).Replace(consts.SummarizerTemplate)
+
+	// 3. 调用模型生成新的摘要
+	response, err := llm.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate conversation summary: %w", err)
+	}
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return nil, nil, nil
+	}
+
+	// 4. 更新摘要以及已汇总的位置
+	err = s.repo.UpdateConversation(ctx, conversationID, map[string]any{
+		"summary":       summary,
+		"summarized_at": messages[len(messages)-1].Ctime,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conversation, messages, nil
+}
+
 func (s *ConversationService) GetConversationAgentThoughts(ctx context.Context, conversationID uuid.UUID) ([]entity.AgentThought, error) {
 	return s.repo.GetConversationAgentThoughts(ctx, conversationID)
 }
@@ -0,0 +1,84 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// SummaryTaskType 会话摘要任务类型
+type SummaryTaskType string
+
+const (
+	TaskTypeSummarize SummaryTaskType = "summarize"
+)
+
+// SummaryTopic 会话长期记忆摘要任务主题
+const SummaryTopic = "conversation.summary"
+
+// SummaryTask 会话摘要任务结构
+type SummaryTask struct {
+	TaskType       SummaryTaskType `json:"task_type"`
+	ConversationID uuid.UUID       `json:"conversation_id"`
+	AccountID      uuid.UUID       `json:"account_id"`
+	ModelConfig    map[string]any  `json:"model_config"`
+}
+
+// SummaryProducer 会话摘要任务生产者
+type SummaryProducer struct {
+	producer sarama.SyncProducer
+}
+
+// NewSummaryProducer 创建会话摘要任务生产者
+func NewSummaryProducer(brokers []string) (*SummaryProducer, error) {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	config.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
+	}
+
+	return &SummaryProducer{
+		producer: producer,
+	}, nil
+}
+
+// Close 关闭生产者
+func (p *SummaryProducer) Close() error {
+	return p.producer.Close()
+}
+
+// PublishSummaryTask 发布会话摘要任务，同一会话的任务写入同一分区以保证顺序
+func (p *SummaryProducer) PublishSummaryTask(ctx context.Context, accountID, conversationID uuid.UUID, modelConfig map[string]any) error {
+	task := SummaryTask{
+		TaskType:       TaskTypeSummarize,
+		ConversationID: conversationID,
+		AccountID:      accountID,
+		ModelConfig:    modelConfig,
+	}
+
+	data, err := sonic.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: SummaryTopic,
+		Key:   sarama.StringEncoder(conversationID.String()),
+		Value: sarama.StringEncoder(data),
+	}
+
+	_, _, err = p.producer.SendMessage(msg)
+	if err != nil {
+		return fmt.Errorf("failed to send message to topic %s: %w", SummaryTopic, err)
+	}
+
+	return nil
+}
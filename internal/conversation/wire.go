@@ -5,12 +5,17 @@ package conversation
 
 import (
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
+	"github.com/crazyfrankie/voidx/conf"
 	"github.com/crazyfrankie/voidx/internal/conversation/handler"
 	"github.com/crazyfrankie/voidx/internal/conversation/repository"
+	"github.com/crazyfrankie/voidx/internal/conversation/repository/cache"
 	"github.com/crazyfrankie/voidx/internal/conversation/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/conversation/service"
+	"github.com/crazyfrankie/voidx/internal/conversation/task"
+	"github.com/crazyfrankie/voidx/internal/llm"
 )
 
 type Handler = handler.ConversationHandler
@@ -23,14 +28,26 @@ type ConversationModule struct {
 
 var ConversationSet = wire.NewSet(
 	dao.NewConversationDao,
+	cache.NewSummaryCache,
 	repository.NewConversationRepo,
 	service.NewConversationService,
 	handler.NewConversationHandler,
 )
 
-func InitConversationModule(db *gorm.DB) *ConversationModule {
+func InitProducer() *task.SummaryProducer {
+	producer, err := task.NewSummaryProducer(conf.GetConf().Kafka.Brokers)
+	if err != nil {
+		panic(err)
+	}
+
+	return producer
+}
+
+func InitConversationModule(db *gorm.DB, cmd redis.Cmdable, llmModule *llm.LLMModule) *ConversationModule {
 	wire.Build(
+		InitProducer,
 		ConversationSet,
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 
 		wire.Struct(new(ConversationModule), "*"),
 	)
@@ -7,20 +7,28 @@
 package conversation
 
 import (
+	"github.com/crazyfrankie/voidx/conf"
 	"github.com/crazyfrankie/voidx/internal/conversation/handler"
 	"github.com/crazyfrankie/voidx/internal/conversation/repository"
+	"github.com/crazyfrankie/voidx/internal/conversation/repository/cache"
 	"github.com/crazyfrankie/voidx/internal/conversation/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/conversation/service"
+	"github.com/crazyfrankie/voidx/internal/conversation/task"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitConversationModule(db *gorm.DB) *ConversationModule {
+func InitConversationModule(db *gorm.DB, cmd redis.Cmdable, llmModule *llm.LLMModule) *ConversationModule {
 	conversationDao := dao.NewConversationDao(db)
-	conversationRepo := repository.NewConversationRepo(conversationDao)
-	conversationService := service.NewConversationService(conversationRepo)
+	summaryCache := cache.NewSummaryCache(cmd)
+	conversationRepo := repository.NewConversationRepo(conversationDao, summaryCache)
+	summaryProducer := InitProducer()
+	llmService := llmModule.Service
+	conversationService := service.NewConversationService(conversationRepo, summaryProducer, llmService)
 	conversationHandler := handler.NewConversationHandler(conversationService)
 	conversationModule := &ConversationModule{
 		Handler: conversationHandler,
@@ -40,4 +48,13 @@ type ConversationModule struct {
 	Service *Service
 }
 
-var ConversationSet = wire.NewSet(dao.NewConversationDao, repository.NewConversationRepo, service.NewConversationService, handler.NewConversationHandler)
+var ConversationSet = wire.NewSet(dao.NewConversationDao, cache.NewSummaryCache, repository.NewConversationRepo, service.NewConversationService, handler.NewConversationHandler)
+
+func InitProducer() *task.SummaryProducer {
+	producer, err := task.NewSummaryProducer(conf.GetConf().Kafka.Brokers)
+	if err != nil {
+		panic(err)
+	}
+
+	return producer
+}
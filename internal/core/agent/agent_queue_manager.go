@@ -10,9 +10,19 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/types/consts"
 )
 
+// ConfirmationTimeout 等待用户确认工具调用的最长时间
+const ConfirmationTimeout = 5 * time.Minute
+
+// confirmationTimeout、confirmationPollInterval 等待确认的超时时间与检查确认结果的间隔
+var (
+	confirmationTimeout      = ConfirmationTimeout
+	confirmationPollInterval = time.Second
+)
+
 // AgentQueueManagerFactory 智能体队列管理器工厂
 type AgentQueueManagerFactory struct {
 	redisClient redis.Cmdable
@@ -72,6 +82,12 @@ func (f *AgentQueueManagerFactory) StopTask(ctx context.Context, taskID uuid.UUI
 	return SetStopFlag(ctx, f.redisClient, taskID, invokeFrom, userID)
 }
 
+// ConfirmTask 提交对某次工具调用的确认结果（同意、修改参数或拒绝）
+func (f *AgentQueueManagerFactory) ConfirmTask(ctx context.Context, taskID, thoughtID uuid.UUID, userID uuid.UUID,
+	invokeFrom consts.InvokeFrom, decision *entities.ConfirmationDecision) error {
+	return SetConfirmation(ctx, f.redisClient, taskID, thoughtID, invokeFrom, userID, decision)
+}
+
 // AgentQueueManager 智能体队列管理器
 type AgentQueueManager struct {
 	userID      uuid.UUID
@@ -216,6 +232,41 @@ func (aqm *AgentQueueManager) isStopped(ctx context.Context, taskID uuid.UUID) b
 	return result.Err() == nil
 }
 
+// WaitForConfirmation 阻塞等待用户对某次工具调用的确认结果，任务被停止、超时或上下文取消时返回错误
+func (aqm *AgentQueueManager) WaitForConfirmation(ctx context.Context, taskID, thoughtID uuid.UUID) (*entities.ConfirmationDecision, error) {
+	confirmationCacheKey := aqm.generateTaskConfirmationCacheKey(taskID, thoughtID)
+
+	ticker := time.NewTicker(confirmationPollInterval)
+	defer ticker.Stop()
+
+	timeout := time.NewTimer(confirmationTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, fmt.Errorf("waiting for confirmation of task %s timed out", taskID)
+		case <-ticker.C:
+			if aqm.isStopped(ctx, taskID) {
+				return nil, fmt.Errorf("task %s has been stopped", taskID)
+			}
+
+			val, err := aqm.redisClient.GetDel(ctx, confirmationCacheKey).Result()
+			if err != nil {
+				continue
+			}
+
+			var decision entities.ConfirmationDecision
+			if err := sonic.Unmarshal([]byte(val), &decision); err != nil {
+				return nil, fmt.Errorf("invalid confirmation decision: %w", err)
+			}
+			return &decision, nil
+		}
+	}
+}
+
 // getQueue 根据传递的taskID获取对应的任务队列信息
 func (aqm *AgentQueueManager) getQueue(taskID uuid.UUID) chan *entities.AgentThought {
 	aqm.mu.Lock()
@@ -273,6 +324,36 @@ func SetStopFlag(ctx context.Context, redisClient redis.Cmdable, taskID uuid.UUI
 	return redisClient.SetEx(ctx, stoppedCacheKey, "1", 10*time.Minute).Err()
 }
 
+// SetConfirmation 根据传递的任务id+推理id写入用户对工具调用的确认结果
+func SetConfirmation(ctx context.Context, redisClient redis.Cmdable, taskID, thoughtID uuid.UUID, invokeFrom consts.InvokeFrom,
+	userID uuid.UUID, decision *entities.ConfirmationDecision) error {
+	aqm := &AgentQueueManager{redisClient: redisClient}
+
+	// 获取当前任务的缓存键，任务不存在则无法确认
+	result := redisClient.Get(ctx, aqm.generateTaskBelongCacheKey(taskID))
+	if result.Err() != nil {
+		return fmt.Errorf("task %s not found", taskID)
+	}
+
+	// 校验任务归属
+	userPrefix := "account"
+	if invokeFrom == consts.InvokeFromEndUser {
+		userPrefix = "end-user"
+	}
+
+	expectedValue := fmt.Sprintf("%s-%s", userPrefix, userID.String())
+	if result.Val() != expectedValue {
+		return fmt.Errorf("unauthorized to confirm task %s", taskID)
+	}
+
+	data, err := sonic.Marshal(decision)
+	if err != nil {
+		return err
+	}
+
+	return redisClient.SetEx(ctx, aqm.generateTaskConfirmationCacheKey(taskID, thoughtID), data, ConfirmationTimeout).Err()
+}
+
 // generateTaskBelongCacheKey 生成任务专属的缓存键
 func (aqm *AgentQueueManager) generateTaskBelongCacheKey(taskID uuid.UUID) string {
 	return fmt.Sprintf("generate_task_belong:%s", taskID.String())
@@ -283,6 +364,11 @@ func (aqm *AgentQueueManager) generateTaskStoppedCacheKey(taskID uuid.UUID) stri
 	return fmt.Sprintf("generate_task_stopped:%s", taskID.String())
 }
 
+// generateTaskConfirmationCacheKey 生成工具调用确认结果的缓存键
+func (aqm *AgentQueueManager) generateTaskConfirmationCacheKey(taskID, thoughtID uuid.UUID) string {
+	return fmt.Sprintf("generate_task_confirmation:%s:%s", taskID.String(), thoughtID.String())
+}
+
 // Close 关闭所有队列并清理资源
 func (aqm *AgentQueueManager) Close() {
 	aqm.mu.Lock()
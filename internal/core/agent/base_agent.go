@@ -11,6 +11,8 @@ import (
 	"github.com/cloudwego/eino/schema"
 
 	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
 )
 
 // BaseAgent represents the interface for all agent implementations
@@ -27,14 +29,18 @@ type baseAgentImpl struct {
 	llm          model.BaseChatModel
 	agentConfig  *entities.AgentConfig
 	queueFactory *AgentQueueManagerFactory
+
+	// reviewPipeline moderates inputs and outputs as configured in the review config
+	reviewPipeline *moderation.Pipeline
 }
 
 // NewBaseAgent creates a new base agent implementation
 func NewBaseAgent(llm model.BaseChatModel, config *entities.AgentConfig, queueFactory *AgentQueueManagerFactory) BaseAgent {
 	return &baseAgentImpl{
-		llm:          llm,
-		agentConfig:  config,
-		queueFactory: queueFactory,
+		llm:            llm,
+		agentConfig:    config,
+		queueFactory:   queueFactory,
+		reviewPipeline: newReviewPipeline(llm, config),
 	}
 }
 
@@ -47,7 +53,7 @@ func (b *baseAgentImpl) Invoke(ctx context.Context, input entities.AgentState) (
 
 	if len(input.Messages) > 0 {
 		lastMsg := input.Messages[len(input.Messages)-1]
-		content = lastMsg.Content
+		content = extractQueryFromMessage(lastMsg)
 		query = content
 		imageURLs = extractImageURLsFromMessage(lastMsg)
 	}
@@ -79,6 +85,7 @@ func (b *baseAgentImpl) Invoke(ctx context.Context, input entities.AgentState) (
 					existing.Thought = existing.Thought + agentThought.Thought
 					existing.Answer = existing.Answer + agentThought.Answer
 					existing.Latency = agentThought.Latency
+					existing.MergeUsage(agentThought)
 					agentThoughts[eventID] = existing
 				} else {
 					// Initialize message event
@@ -86,6 +93,17 @@ func (b *baseAgentImpl) Invoke(ctx context.Context, input entities.AgentState) (
 				}
 				// Update agent result answer
 				agentResult.Answer += agentThought.Answer
+				if agentThought.StructuredOutput != nil {
+					agentResult.StructuredOutput = agentThought.StructuredOutput
+				}
+			} else if agentThought.Event == entities.EventAgentReasoning {
+				// Reasoning is streamed in chunks as well
+				if existing, exists := agentThoughts[eventID]; exists {
+					existing.Thought = existing.Thought + agentThought.Thought
+					existing.Latency = agentThought.Latency
+				} else {
+					agentThoughts[eventID] = agentThought
+				}
 			} else {
 				// Handle other event types (overwrite)
 				agentThoughts[eventID] = agentThought
@@ -180,9 +198,48 @@ func (b *baseAgentImpl) Stream(ctx context.Context, input entities.AgentState) (
 	return thoughtChan, nil
 }
 
-// extractQueryFromMessage extracts the text content from a message
+// extractQueryFromMessage extracts the text content from a message, including the text parts of
+// a multimodal message
 func extractQueryFromMessage(msg *schema.Message) string {
-	return msg.Content
+	if msg.Content != "" || len(msg.MultiContent) == 0 {
+		return msg.Content
+	}
+
+	texts := make([]string, 0, len(msg.MultiContent))
+	for _, part := range msg.MultiContent {
+		if part.Type == schema.ChatMessagePartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// withQuery returns a copy of the message with its text replaced by the query, the images of a
+// multimodal message are kept
+func withQuery(msg *schema.Message, query string) *schema.Message {
+	replaced := *msg
+	if len(msg.MultiContent) == 0 {
+		replaced.Content = query
+		return &replaced
+	}
+
+	parts := []schema.ChatMessagePart{{Type: schema.ChatMessagePartTypeText, Text: query}}
+	for _, part := range msg.MultiContent {
+		if part.Type != schema.ChatMessagePartTypeText {
+			parts = append(parts, part)
+		}
+	}
+	replaced.MultiContent = parts
+	return &replaced
+}
+
+// NewAgent creates the agent suiting the model, models with native tool calling use function calls
+// and the others use ReACT prompting
+func NewAgent(llm llmentity.BaseLanguageModel, config *entities.AgentConfig, queueFactory *AgentQueueManagerFactory) BaseAgent {
+	if llmentity.SupportsToolCall(llm.GetFeatures()) {
+		return NewFunctionCallAgent(llm, config, queueFactory)
+	}
+	return NewReactAgent(llm, config, queueFactory)
 }
 
 // extractImageURLsFromMessage extracts image URLs from a message
@@ -218,3 +275,36 @@ func extractImageURLsFromMessage(msg *schema.Message) []string {
 
 	return imageURLs
 }
+
+// applyUsage fills the answering model, token usage and price of an LLM call into the thought, the usage
+// reported by the provider is preferred and the model's fallback tokenizer is used when it is missing
+func (b *baseAgentImpl) applyUsage(thought *entities.AgentThought, messages []*schema.Message, response *schema.Message) {
+	if model := llmentity.AnsweredModel(response); model != "" {
+		thought.Model = model
+	}
+
+	languageModel, isLanguageModel := b.llm.(llmentity.BaseLanguageModel)
+
+	usage, ok := llmentity.UsageFromMessage(response)
+	if !ok {
+		if !isLanguageModel {
+			return
+		}
+		usage = llmentity.EstimateUsage(languageModel.GetTokenizer(), messages, response)
+	}
+
+	var inputPrice, outputPrice, unit float64
+	if isLanguageModel {
+		inputPrice, outputPrice, unit = languageModel.GetPricing()
+	}
+
+	thought.MessageTokenCount = usage.PromptTokens
+	thought.MessageUnitPrice = inputPrice
+	thought.MessagePriceUnit = unit
+	thought.AnswerTokenCount = usage.CompletionTokens
+	thought.AnswerUnitPrice = outputPrice
+	thought.AnswerPriceUnit = unit
+	thought.CachedTokenCount = usage.CachedTokens
+	thought.TotalTokenCount = usage.TotalTokens
+	thought.TotalPrice = usage.CalculatePrice(inputPrice, outputPrice, unit)
+}
@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+const (
+	// contextSafetyMargin is kept free to absorb the error of local token counting
+	contextSafetyMargin = 256
+
+	// minObservationTokens is the floor a tool observation is truncated to
+	minObservationTokens = 256
+
+	// maxSummaryInputTokens caps the history sent to the model when summarizing it
+	maxSummaryInputTokens = 4096
+
+	// truncatedObservationNotice marks a truncated tool observation
+	truncatedObservationNotice = "\n...[工具输出过长，已截断]"
+)
+
+// contextBudget returns the tokens left for prompt messages in a single LLM call: the context
+// window minus the reserved output, the tool schemas and a safety margin.
+// It returns 0 when the context window of the model is unknown.
+func (b *baseAgentImpl) contextBudget(ctx context.Context) (int, llmentity.Tokenizer) {
+	languageModel, ok := b.llm.(llmentity.BaseLanguageModel)
+	if !ok {
+		return 0, nil
+	}
+
+	contextWindow, maxOutputTokens := languageModel.GetContextWindow()
+	if contextWindow <= 0 {
+		return 0, nil
+	}
+
+	tokenizer := languageModel.GetTokenizer()
+	budget := contextWindow - maxOutputTokens - b.countToolSchemaTokens(ctx, tokenizer) - contextSafetyMargin
+	if budget < minObservationTokens {
+		budget = minObservationTokens
+	}
+
+	return budget, tokenizer
+}
+
+// countToolSchemaTokens counts the tokens the bound tool schemas take in every call
+func (b *baseAgentImpl) countToolSchemaTokens(ctx context.Context, tokenizer llmentity.Tokenizer) int {
+	total := 0
+	for _, t := range b.agentConfig.Tools {
+		info, err := t.Info(ctx)
+		if err != nil || info == nil {
+			continue
+		}
+
+		total += tokenizer.CountTokens(info.Name) + tokenizer.CountTokens(info.Desc)
+		if info.ParamsOneOf != nil {
+			if params, err := info.ParamsOneOf.ToJSONSchema(); err == nil && params != nil {
+				if data, err := sonic.Marshal(params); err == nil {
+					total += tokenizer.CountTokens(string(data))
+				}
+			}
+		}
+	}
+
+	return total
+}
+
+// limitToolObservation truncates a tool output that would take more than a quarter of the budget
+func (b *baseAgentImpl) limitToolObservation(ctx context.Context, observation string) string {
+	budget, tokenizer := b.contextBudget(ctx)
+	if budget == 0 {
+		return observation
+	}
+
+	return truncateToTokens(tokenizer, observation, max(budget/4, minObservationTokens))
+}
+
+// fitContextWindow compresses state.Messages so that the next LLM call fits the model's context window.
+// Long tool observations are truncated first, then the oldest history turns are summarized and dropped,
+// and finally the tool observations of the current turn are truncated harder.
+func (b *baseAgentImpl) fitContextWindow(ctx context.Context, state *entities.AgentState) {
+	budget, tokenizer := b.contextBudget(ctx)
+	if budget == 0 || llmentity.CountMessagesTokens(tokenizer, state.Messages) <= budget {
+		return
+	}
+
+	// 1. Truncate tool observations taking more than a quarter of the budget
+	state.Messages = truncateToolMessages(tokenizer, state.Messages, max(budget/4, minObservationTokens))
+	if llmentity.CountMessagesTokens(tokenizer, state.Messages) <= budget {
+		return
+	}
+
+	// 2. Summarize and drop the oldest history turns
+	state.Messages = b.compressHistory(ctx, tokenizer, state, budget)
+	if llmentity.CountMessagesTokens(tokenizer, state.Messages) <= budget {
+		return
+	}
+
+	// 3. The current turn alone is still too large, truncate its tool observations harder
+	for limit := budget / 8; limit >= minObservationTokens; limit /= 2 {
+		state.Messages = truncateToolMessages(tokenizer, state.Messages, limit)
+		if llmentity.CountMessagesTokens(tokenizer, state.Messages) <= budget {
+			return
+		}
+	}
+}
+
+// compressHistory keeps the newest history turns that fit the budget and replaces the older ones
+// with a summary placed right after the system messages
+func (b *baseAgentImpl) compressHistory(ctx context.Context, tokenizer llmentity.Tokenizer, state *entities.AgentState, budget int) []*schema.Message {
+	messages := state.Messages
+
+	// 1. Split the messages into leading system messages, history and the current turn
+	historySet := make(map[*schema.Message]struct{}, len(state.History))
+	for _, msg := range state.History {
+		historySet[msg] = struct{}{}
+	}
+
+	head := 0
+	for head < len(messages) && messages[head].Role == schema.System {
+		head++
+	}
+	tail := head
+	for tail < len(messages) {
+		if _, ok := historySet[messages[tail]]; !ok {
+			break
+		}
+		tail++
+	}
+	system, history, current := messages[:head], messages[head:tail], messages[tail:]
+	if len(history) == 0 {
+		return messages
+	}
+
+	// 2. Keep the newest history messages that fit, leaving room for the summary
+	summaryReserve := min(budget/10, 1024)
+	available := budget - llmentity.CountMessagesTokens(tokenizer, system) -
+		llmentity.CountMessagesTokens(tokenizer, current) - summaryReserve
+
+	keepFrom, used := len(history), 0
+	for i := len(history) - 1; i >= 0; i-- {
+		tokens := llmentity.CountMessagesTokens(tokenizer, history[i:i+1])
+		if used+tokens > available {
+			break
+		}
+		used += tokens
+		keepFrom = i
+	}
+
+	// History is made of whole turns, so it must start with a human message
+	for keepFrom < len(history) && history[keepFrom].Role != schema.User {
+		keepFrom++
+	}
+	if keepFrom == 0 {
+		return messages
+	}
+
+	// 3. Summarize the dropped turns, they are simply dropped when summarizing fails
+	result := make([]*schema.Message, 0, len(messages)-keepFrom+1)
+	result = append(result, system...)
+	if summary := b.summarizeHistory(ctx, tokenizer, history[:keepFrom]); summary != "" {
+		result = append(result, schema.SystemMessage(
+			strings.ReplaceAll(entities.HistorySummaryMessageTemplate, "{summary}", summary)))
+	}
+	result = append(result, history[keepFrom:]...)
+	result = append(result, current...)
+
+	// The dropped turns no longer take part in later compressions
+	state.History = history[keepFrom:]
+
+	return result
+}
+
+// summarizeHistory asks the model to summarize the given history turns
+func (b *baseAgentImpl) summarizeHistory(ctx context.Context, tokenizer llmentity.Tokenizer, history []*schema.Message) string {
+	// Keep the newest part of the history when it is too long to summarize at once
+	var lines []string
+	used := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		prefix := "Human"
+		if history[i].Role == schema.Assistant {
+			prefix = "AI"
+		}
+		line := fmt.Sprintf("%s: %s", prefix, history[i].Content)
+
+		tokens := tokenizer.CountTokens(line)
+		if used+tokens > maxSummaryInputTokens {
+			break
+		}
+		used += tokens
+		lines = append([]string{line}, lines...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	response, err := b.llm.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(entities.HistorySummaryPromptTemplate),
+		schema.UserMessage(strings.Join(lines, "\n")),
+	})
+	if err != nil || response == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(response.Content)
+}
+
+// truncateToolMessages truncates every tool message longer than limit tokens,
+// the messages are copied so the shared history is left untouched
+func truncateToolMessages(tokenizer llmentity.Tokenizer, messages []*schema.Message, limit int) []*schema.Message {
+	result := make([]*schema.Message, len(messages))
+	for i, msg := range messages {
+		result[i] = msg
+		if msg.Role != schema.Tool {
+			continue
+		}
+
+		if content := truncateToTokens(tokenizer, msg.Content, limit); content != msg.Content {
+			truncated := *msg
+			truncated.Content = content
+			result[i] = &truncated
+		}
+	}
+
+	return result
+}
+
+// truncateToTokens cuts text down to at most limit tokens and appends a truncation notice
+func truncateToTokens(tokenizer llmentity.Tokenizer, text string, limit int) string {
+	tokens := tokenizer.CountTokens(text)
+	if tokens <= limit {
+		return text
+	}
+
+	// Cut by runes proportionally, then shrink until it fits
+	runes := []rune(text)
+	n := len(runes) * limit / tokens
+	for n > 0 && tokenizer.CountTokens(string(runes[:n])) > limit {
+		n = n * 9 / 10
+	}
+
+	return string(runes[:n]) + truncatedObservationNotice
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/cloudwego/eino/schema"
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
 	"github.com/crazyfrankie/voidx/types/consts"
 )
 
@@ -31,8 +32,18 @@ type AgentConfig struct {
 	// Tools represents the list of tools available to the agent
 	Tools []tool.InvokableTool `json:"tools"`
 
+	// ConfirmationTools lists the names of tools that must be approved by the user before they run
+	ConfirmationTools []string `json:"confirmation_tools"`
+
+	// ConfirmationUnavailable explains why the caller cannot ask the user for approval. When set,
+	// calls to ConfirmationTools are rejected right away with this reason instead of waiting
+	ConfirmationUnavailable string `json:"confirmation_unavailable"`
+
 	// ReviewConfig represents the configuration for content review
 	ReviewConfig ReviewConfig `json:"review_config"`
+
+	// OutputSchema is the JSON Schema the final answer must follow, empty for free-text answers
+	OutputSchema map[string]any `json:"output_schema"`
 }
 
 // ReviewConfig represents the configuration for content review
@@ -41,6 +52,8 @@ type ReviewConfig struct {
 	Keywords      []string            `json:"keywords"`
 	InputsConfig  ReviewInputsConfig  `json:"inputs_config"`
 	OutputsConfig ReviewOutputsConfig `json:"outputs_config"`
+	// Moderators are the pluggable moderators combined with the keywords
+	Moderators []moderation.ModeratorConfig `json:"moderators"`
 }
 
 // ReviewInputsConfig represents the configuration for input review
@@ -52,6 +65,8 @@ type ReviewInputsConfig struct {
 // ReviewOutputsConfig represents the configuration for output review
 type ReviewOutputsConfig struct {
 	Enable bool `json:"enable"`
+	// PresetResponse replaces a blocked answer
+	PresetResponse string `json:"preset_response"`
 }
 
 // AgentState represents the current state of an agent
@@ -95,10 +110,31 @@ const (
 
 	// MaxIterationResponse represents the response when max iterations are reached
 	MaxIterationResponse = "当前Agent迭代次数已超过限制，请重试"
+
+	// BlockedOutputResponse replaces a blocked answer when no preset response is configured
+	BlockedOutputResponse = "抱歉，该回答包含不适宜展示的内容，已被拦截"
 )
 
 // System prompt templates
 const (
+	HistorySummaryPromptTemplate = `你是一个对话摘要助手，下面是用户与AI之间较早的对话记录，由于上下文长度有限，这些记录将被移出对话。
+请将其压缩成一段简洁的摘要，保留用户的身份信息、偏好、已确认的事实、关键结论以及尚未完成的任务，去除寒暄和重复内容。
+直接输出摘要内容，不要添加任何前缀或解释，摘要长度不超过300字。`
+
+	HistorySummaryMessageTemplate = `<历史对话摘要>
+{summary}
+</历史对话摘要>`
+
+	StructuredOutputPromptTemplate = `请将上面的最终回答整理成符合下列JSON Schema的JSON数据。
+只输出JSON本身，不要输出任何解释、前缀或Markdown代码块标记。
+
+<JSON Schema>
+{schema}
+</JSON Schema>`
+
+	StructuredOutputRetryTemplate = `上一次输出的JSON不符合要求，错误信息: {error}
+请修正后重新输出完整的JSON，只输出JSON本身。`
+
 	AgentSystemPromptTemplate = `你是一个高度定制的智能体应用，旨在为用户提供准确、专业的内容生成和问题解答，请严格遵守以下规则：
 
 1.**预设任务执行**
@@ -5,6 +5,8 @@ import (
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
 )
 
 // QueueEvent represents different types of events in the agent queue
@@ -14,9 +16,12 @@ const (
 	EventPing                 QueueEvent = "ping"
 	EventAgentMessage         QueueEvent = "agent_message"
 	EventAgentThought         QueueEvent = "agent_thought"
+	EventAgentReasoning       QueueEvent = "agent_reasoning"
 	EventAgentAction          QueueEvent = "agent_action"
 	EventDatasetRetrieval     QueueEvent = "dataset_retrieval"
 	EventLongTermMemoryRecall QueueEvent = "long_term_memory_recall"
+	EventConfirmationRequired QueueEvent = "confirmation_required"
+	EventModeration           QueueEvent = "moderation"
 	EventAgentEnd             QueueEvent = "agent_end"
 	EventStop                 QueueEvent = "stop"
 	EventTimeout              QueueEvent = "timeout"
@@ -44,6 +49,12 @@ type AgentThought struct {
 	AnswerUnitPrice  float64 `json:"answer_unit_price,omitempty"`
 	AnswerPriceUnit  float64 `json:"answer_price_unit,omitempty"`
 
+	// Model is the "provider/model" that answered when the app uses a fallback chain
+	Model string `json:"model,omitempty"`
+
+	// CachedTokenCount is the part of MessageTokenCount served from the provider's prompt cache
+	CachedTokenCount int `json:"cached_token_count,omitempty"`
+
 	// Tool related fields
 	Tool      string                 `json:"tool,omitempty"`
 	ToolInput map[string]interface{} `json:"tool_input,omitempty"`
@@ -51,6 +62,16 @@ type AgentThought struct {
 	// Observation and error
 	Observation string `json:"observation,omitempty"`
 
+	// Moderation holds the hits of a moderation event
+	Moderation []moderation.Hit `json:"moderation,omitempty"`
+
+	// StructuredOutput is the parsed final answer when the agent has an output schema
+	StructuredOutput any `json:"structured_output,omitempty"`
+
+	// SubThoughts holds the thoughts produced by a nested agent (e.g. an app
+	// bound as a tool) while this action was executing
+	SubThoughts []AgentThought `json:"sub_thoughts,omitempty"`
+
 	// Statistics
 	TotalTokenCount int     `json:"total_token_count,omitempty"`
 	TotalPrice      float64 `json:"total_price,omitempty"`
@@ -60,12 +81,62 @@ type AgentThought struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// MergeUsage copies the token usage and price of another event of the same thought,
+// the statistics of a streamed message arrive with its last chunk
+func (t *AgentThought) MergeUsage(other *AgentThought) {
+	if other.TotalTokenCount == 0 {
+		return
+	}
+
+	t.MessageTokenCount = other.MessageTokenCount
+	t.MessageUnitPrice = other.MessageUnitPrice
+	t.MessagePriceUnit = other.MessagePriceUnit
+	t.AnswerTokenCount = other.AnswerTokenCount
+	t.AnswerUnitPrice = other.AnswerUnitPrice
+	t.AnswerPriceUnit = other.AnswerPriceUnit
+	t.CachedTokenCount = other.CachedTokenCount
+	t.TotalTokenCount = other.TotalTokenCount
+	t.TotalPrice = other.TotalPrice
+}
+
+// AddUsage adds the token usage and price of another LLM call made for the same thought
+func (t *AgentThought) AddUsage(other *AgentThought) {
+	t.MessageTokenCount += other.MessageTokenCount
+	t.AnswerTokenCount += other.AnswerTokenCount
+	t.CachedTokenCount += other.CachedTokenCount
+	t.TotalTokenCount += other.TotalTokenCount
+	t.TotalPrice += other.TotalPrice
+}
+
+// ConfirmationAction represents the decision a user made on a pending tool call
+type ConfirmationAction string
+
+const (
+	ConfirmationApprove ConfirmationAction = "approve"
+	ConfirmationEdit    ConfirmationAction = "edit"
+	ConfirmationReject  ConfirmationAction = "reject"
+)
+
+// ConfirmationDecision carries the user's decision on a tool call that requires confirmation
+type ConfirmationDecision struct {
+	Action ConfirmationAction `json:"action"`
+
+	// Arguments replaces the tool call arguments when Action is edit
+	Arguments string `json:"arguments,omitempty"`
+
+	// Reason is fed back to the model as the observation when Action is reject
+	Reason string `json:"reason,omitempty"`
+}
+
 // AgentResult represents the final result of an agent execution
 type AgentResult struct {
 	Query     string   `json:"query"`
 	Answer    string   `json:"answer"`
 	ImageURLs []string `json:"image_urls,omitempty"`
 
+	// StructuredOutput is the parsed answer when the agent has an output schema
+	StructuredOutput any `json:"structured_output,omitempty"`
+
 	// Message and thoughts
 	Message       []*schema.Message `json:"message,omitempty"`
 	AgentThoughts []AgentThought    `json:"agent_thoughts"`
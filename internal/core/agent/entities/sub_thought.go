@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"context"
+	"sync"
+)
+
+type subThoughtsCollectorKey struct{}
+
+// SubThoughtsCollector gathers thoughts produced by nested agents while a tool is running
+type SubThoughtsCollector struct {
+	mu       sync.Mutex
+	thoughts []AgentThought
+}
+
+// WithSubThoughtsCollector returns a context carrying a fresh collector
+func WithSubThoughtsCollector(ctx context.Context) (context.Context, *SubThoughtsCollector) {
+	collector := &SubThoughtsCollector{}
+	return context.WithValue(ctx, subThoughtsCollectorKey{}, collector), collector
+}
+
+// SubThoughtsCollectorFromContext returns the collector carried by ctx, or nil if there is none
+func SubThoughtsCollectorFromContext(ctx context.Context) *SubThoughtsCollector {
+	collector, _ := ctx.Value(subThoughtsCollectorKey{}).(*SubThoughtsCollector)
+	return collector
+}
+
+// Add appends thoughts to the collector
+func (c *SubThoughtsCollector) Add(thoughts ...AgentThought) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.thoughts = append(c.thoughts, thoughts...)
+}
+
+// Thoughts returns the collected thoughts
+func (c *SubThoughtsCollector) Thoughts() []AgentThought {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.thoughts
+}
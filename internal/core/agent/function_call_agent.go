@@ -3,16 +3,17 @@ package agent
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
 
 	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
 )
 
 // FunctionCallAgent represents an agent that uses function/tool calling capabilities
@@ -71,7 +72,7 @@ func (f *FunctionCallAgent) Stream(ctx context.Context, input entities.AgentStat
 // processAgentPipeline processes the input through the agent's execution pipeline
 func (f *FunctionCallAgent) processAgentPipeline(ctx context.Context, state entities.AgentState, queueManager *AgentQueueManager) error {
 	// 1. Preset operation node
-	if shouldEnd, err := f.presetOperationNode(ctx, state, queueManager); err != nil {
+	if shouldEnd, err := f.presetOperationNode(ctx, &state, queueManager); err != nil {
 		return err
 	} else if shouldEnd {
 		return nil
@@ -125,9 +126,8 @@ func (f *FunctionCallAgent) processAgentPipeline(ctx context.Context, state enti
 }
 
 // presetOperationNode handles preset operations including input review
-func (f *FunctionCallAgent) presetOperationNode(ctx context.Context, state entities.AgentState, queueManager *AgentQueueManager) (bool, error) {
-	reviewConfig := f.agentConfig.ReviewConfig
-	if !reviewConfig.Enable || !reviewConfig.InputsConfig.Enable {
+func (f *FunctionCallAgent) presetOperationNode(ctx context.Context, state *entities.AgentState, queueManager *AgentQueueManager) (bool, error) {
+	if !f.reviewPipeline.Enabled(moderation.StageInput) {
 		return false, nil
 	}
 
@@ -136,24 +136,28 @@ func (f *FunctionCallAgent) presetOperationNode(ctx context.Context, state entit
 		return false, nil
 	}
 
-	query := extractQueryFromMessage(state.Messages[len(state.Messages)-1])
+	lastMsg := state.Messages[len(state.Messages)-1]
+	result := f.moderate(ctx, state.TaskID, moderation.StageInput, extractQueryFromMessage(lastMsg), queueManager)
 
-	// Check for sensitive keywords
-	for _, keyword := range reviewConfig.Keywords {
-		if strings.Contains(strings.ToLower(query), strings.ToLower(keyword)) {
-			presetResponse := reviewConfig.InputsConfig.PresetResponse
+	// A blocked input is answered with the preset response
+	if result.Blocked() {
+		presetResponse := f.agentConfig.ReviewConfig.InputsConfig.PresetResponse
 
-			queueManager.Publish(state.TaskID, &entities.AgentThought{
-				ID:      uuid.New(),
-				TaskID:  state.TaskID,
-				Event:   entities.EventAgentMessage,
-				Thought: presetResponse,
-				Answer:  presetResponse,
-				Latency: 0,
-			})
+		queueManager.Publish(state.TaskID, &entities.AgentThought{
+			ID:      uuid.New(),
+			TaskID:  state.TaskID,
+			Event:   entities.EventAgentMessage,
+			Thought: presetResponse,
+			Answer:  presetResponse,
+			Latency: 0,
+		})
 
-			return true, nil
-		}
+		return true, nil
+	}
+
+	// A masked input goes on to the model without the matched text
+	if result.Content != extractQueryFromMessage(lastMsg) {
+		state.Messages[len(state.Messages)-1] = withQuery(lastMsg, result.Content)
 	}
 
 	return false, nil
@@ -188,10 +192,9 @@ func (f *FunctionCallAgent) longTermMemoryRecallNode(ctx context.Context, state
 		messages = append(messages, state.History...)
 	}
 
-	// Add current user message
+	// Add current user message, keeping the images of a multimodal message
 	if len(state.Messages) > 0 {
-		lastMsg := state.Messages[len(state.Messages)-1]
-		messages = append(messages, schema.UserMessage(lastMsg.Content))
+		messages = append(messages, state.Messages[len(state.Messages)-1])
 	}
 
 	// Update state with prepared messages
@@ -243,6 +246,9 @@ func (f *FunctionCallAgent) llmNode(ctx context.Context, state *entities.AgentSt
 		}
 	}
 
+	// Keep the prompt within the model's context window
+	f.fitContextWindow(ctx, state)
+
 	// Generate response
 	response, err := llmModel.Generate(ctx, state.Messages)
 	if err != nil {
@@ -250,30 +256,65 @@ func (f *FunctionCallAgent) llmNode(ctx context.Context, state *entities.AgentSt
 		return false, err
 	}
 
-	// Apply output review if enabled
-	content := f.applyOutputReview(response.Content)
+	// Reasoning models return their thinking apart from the answer
+	if response.ReasoningContent != "" {
+		queueManager.Publish(state.TaskID, &entities.AgentThought{
+			ID:      uuid.New(),
+			TaskID:  state.TaskID,
+			Event:   entities.EventAgentReasoning,
+			Thought: response.ReasoningContent,
+			Latency: time.Since(startTime).Seconds(),
+		})
+	}
+
+	// Review the final answer, tool calls are not shown to the user
+	content := response.Content
+	blocked := false
+	if len(response.ToolCalls) == 0 && f.reviewPipeline.Enabled(moderation.StageOutput) {
+		result := f.moderate(ctx, state.TaskID, moderation.StageOutput, response.Content, queueManager)
+		content = f.reviewedAnswer(result)
+		blocked = result.Blocked()
+	}
 
 	// Check if response has tool calls
+	var thought *entities.AgentThought
 	if len(response.ToolCalls) > 0 {
 		// This is a thought (tool calling)
-		queueManager.Publish(state.TaskID, &entities.AgentThought{
+		thought = &entities.AgentThought{
 			ID:      id,
 			TaskID:  state.TaskID,
 			Event:   entities.EventAgentThought,
 			Thought: fmt.Sprintf("Tool calls: %v", response.ToolCalls),
 			Latency: time.Since(startTime).Seconds(),
-		})
+		}
 	} else {
 		// This is a final message
-		queueManager.Publish(state.TaskID, &entities.AgentThought{
+		thought = &entities.AgentThought{
 			ID:      id,
 			TaskID:  state.TaskID,
 			Event:   entities.EventAgentMessage,
 			Thought: content,
 			Answer:  content,
 			Latency: time.Since(startTime).Seconds(),
-		})
+		}
+	}
+	f.applyUsage(thought, state.Messages, response)
+
+	// Turn the final answer into JSON following the output schema, from the reviewed answer
+	if len(response.ToolCalls) == 0 && len(f.agentConfig.OutputSchema) > 0 && !blocked {
+		reviewed := *response
+		reviewed.Content = content
+		answer, value, err := f.generateStructuredOutput(ctx, append(state.Messages, &reviewed), thought)
+		if err != nil {
+			queueManager.PublishError(state.TaskID, err)
+			return false, err
+		}
+		thought.Thought = answer
+		thought.Answer = answer
+		thought.StructuredOutput = value
+		thought.Latency = time.Since(startTime).Seconds()
 	}
+	queueManager.Publish(state.TaskID, thought)
 
 	// Update state
 	state.Messages = append(state.Messages, response)
@@ -317,15 +358,26 @@ func (f *FunctionCallAgent) toolsNode(ctx context.Context, state *entities.Agent
 		var toolResult string
 		var toolName string
 
+		// Collect thoughts of nested agents (apps bound as tools) during the call
+		toolCtx, subThoughts := entities.WithSubThoughtsCollector(ctx)
+
+		arguments := toolCall.Function.Arguments
+
 		if toolCall.Function.Name != "" {
 			toolName = toolCall.Function.Name
+
+			// Sensitive tools wait for the user to approve, edit or reject the call
+			if f.requiresConfirmation(toolName) {
+				arguments, toolResult = f.confirmToolCall(ctx, state.TaskID, toolName, arguments, queueManager)
+			}
+		}
+
+		if toolName != "" && toolResult == "" {
 			if toolInterface, exists := toolsMap[toolName]; exists {
 				// Try to cast to eino's InvokableTool interface
-				if tool, ok := toolInterface.(interface {
-					InvokableRun(context.Context, string, ...interface{}) (string, error)
-				}); ok {
+				if invokableTool, ok := toolInterface.(tool.InvokableTool); ok {
 					// Execute tool with correct signature (no options for now)
-					if result, err := tool.InvokableRun(ctx, toolCall.Function.Arguments); err == nil {
+					if result, err := invokableTool.InvokableRun(toolCtx, arguments); err == nil {
 						toolResult = result
 					} else {
 						toolResult = fmt.Sprintf("工具执行出错: %s", err.Error())
@@ -338,6 +390,9 @@ func (f *FunctionCallAgent) toolsNode(ctx context.Context, state *entities.Agent
 			}
 		}
 
+		// Huge tool outputs would crowd the context window out
+		toolResult = f.limitToolObservation(ctx, toolResult)
+
 		// Create tool message
 		toolMsg := schema.ToolMessage(toolResult, toolCall.ID, schema.WithToolName(toolName))
 		toolMessages = append(toolMessages, toolMsg)
@@ -354,7 +409,8 @@ func (f *FunctionCallAgent) toolsNode(ctx context.Context, state *entities.Agent
 			Event:       event,
 			Observation: toolResult,
 			Tool:        toolName,
-			ToolInput:   map[string]interface{}{"args": toolCall.Function.Arguments},
+			ToolInput:   map[string]interface{}{"args": arguments},
+			SubThoughts: subThoughts.Thoughts(),
 			Latency:     time.Since(startTime).Seconds(),
 		})
 	}
@@ -364,18 +420,52 @@ func (f *FunctionCallAgent) toolsNode(ctx context.Context, state *entities.Agent
 	return nil
 }
 
-// applyOutputReview applies output content review
-func (f *FunctionCallAgent) applyOutputReview(content string) string {
-	reviewConfig := f.agentConfig.ReviewConfig
-	if !reviewConfig.Enable || !reviewConfig.OutputsConfig.Enable {
-		return content
+// requiresConfirmation reports whether the named tool must be approved by the user before it runs
+func (f *FunctionCallAgent) requiresConfirmation(toolName string) bool {
+	for _, name := range f.agentConfig.ConfirmationTools {
+		if name == toolName {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmToolCall publishes a confirmation-required event and suspends until the user decides.
+// It returns the arguments the tool should run with, or a non-empty observation when the call
+// must not run (rejected, timed out or stopped), which is fed back to the model.
+func (f *FunctionCallAgent) confirmToolCall(ctx context.Context, taskID uuid.UUID, toolName, arguments string, queueManager *AgentQueueManager) (string, string) {
+	// Callers that cannot show an approval prompt reject the call instead of waiting for the timeout
+	if reason := f.agentConfig.ConfirmationUnavailable; reason != "" {
+		return arguments, fmt.Sprintf("工具未执行，该工具需要用户确认后才能执行: %s", reason)
 	}
 
-	reviewedContent := content
-	for _, keyword := range reviewConfig.Keywords {
-		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(keyword))
-		reviewedContent = re.ReplaceAllString(reviewedContent, "**")
+	thoughtID := uuid.New()
+	queueManager.Publish(taskID, &entities.AgentThought{
+		ID:        thoughtID,
+		TaskID:    taskID,
+		Event:     entities.EventConfirmationRequired,
+		Tool:      toolName,
+		ToolInput: map[string]interface{}{"args": arguments},
+	})
+
+	decision, err := queueManager.WaitForConfirmation(ctx, taskID, thoughtID)
+	if err != nil {
+		return arguments, fmt.Sprintf("工具未执行，未获得用户确认: %s", err.Error())
 	}
 
-	return reviewedContent
+	switch decision.Action {
+	case entities.ConfirmationApprove:
+		return arguments, ""
+	case entities.ConfirmationEdit:
+		if decision.Arguments == "" {
+			return arguments, ""
+		}
+		return decision.Arguments, ""
+	default:
+		observation := "用户拒绝执行该工具调用"
+		if decision.Reason != "" {
+			observation = fmt.Sprintf("%s，原因: %s", observation, decision.Reason)
+		}
+		return arguments, observation
+	}
 }
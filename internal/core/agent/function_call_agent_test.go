@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+// memoryRedis implements the redis commands used by the queue manager in memory
+type memoryRedis struct {
+	redis.Cmdable
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryRedis() *memoryRedis {
+	return &memoryRedis{data: make(map[string]string)}
+}
+
+func (r *memoryRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if val, ok := r.data[key]; ok {
+		return redis.NewStringResult(val, nil)
+	}
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (r *memoryRedis) GetDel(ctx context.Context, key string) *redis.StringCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if val, ok := r.data[key]; ok {
+		delete(r.data, key)
+		return redis.NewStringResult(val, nil)
+	}
+	return redis.NewStringResult("", redis.Nil)
+}
+
+func (r *memoryRedis) SetEx(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		r.data[key] = string(v)
+	case string:
+		r.data[key] = v
+	}
+	return redis.NewStatusResult("OK", nil)
+}
+
+// shortenConfirmationWait makes the gate poll quickly and time out after timeout
+func shortenConfirmationWait(t *testing.T, timeout time.Duration) {
+	t.Helper()
+
+	previousTimeout, previousInterval := confirmationTimeout, confirmationPollInterval
+	confirmationTimeout, confirmationPollInterval = timeout, 5*time.Millisecond
+	t.Cleanup(func() {
+		confirmationTimeout, confirmationPollInterval = previousTimeout, previousInterval
+	})
+}
+
+type confirmationResult struct {
+	arguments   string
+	observation string
+}
+
+// startConfirmation runs the gate for one tool call in the background and returns the
+// confirmation request it published together with a channel delivering the outcome
+func startConfirmation(t *testing.T, f *FunctionCallAgent, manager *AgentQueueManager, taskID uuid.UUID) (*entities.AgentThought, <-chan confirmationResult) {
+	t.Helper()
+
+	queue := manager.getQueue(taskID)
+	done := make(chan confirmationResult, 1)
+	go func() {
+		arguments, observation := f.confirmToolCall(context.Background(), taskID, "send_email", `{"to":"a@example.com"}`, manager)
+		done <- confirmationResult{arguments: arguments, observation: observation}
+	}()
+
+	select {
+	case thought := <-queue:
+		require.Equal(t, entities.EventConfirmationRequired, thought.Event)
+		require.Equal(t, "send_email", thought.Tool)
+		return thought, done
+	case <-time.After(time.Second):
+		t.Fatal("confirmation request was not published")
+		return nil, nil
+	}
+}
+
+func waitResult(t *testing.T, done <-chan confirmationResult) confirmationResult {
+	t.Helper()
+
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("confirmation gate did not return")
+		return confirmationResult{}
+	}
+}
+
+func TestConfirmToolCall(t *testing.T) {
+	userID := uuid.New()
+	newGate := func(config *entities.AgentConfig) (*FunctionCallAgent, *AgentQueueManagerFactory, *AgentQueueManager) {
+		factory := NewAgentQueueManagerFactory(newMemoryRedis())
+		f := &FunctionCallAgent{baseAgentImpl: &baseAgentImpl{agentConfig: config, queueFactory: factory}}
+		return f, factory, factory.CreateManager(userID, consts.InvokeFromDebugger)
+	}
+	config := &entities.AgentConfig{UserID: userID, ConfirmationTools: []string{"send_email"}}
+
+	t.Run("approve runs with the original arguments", func(t *testing.T) {
+		shortenConfirmationWait(t, time.Second)
+		f, factory, manager := newGate(config)
+		taskID := uuid.New()
+
+		thought, done := startConfirmation(t, f, manager, taskID)
+		require.NoError(t, factory.ConfirmTask(context.Background(), taskID, thought.ID, userID, consts.InvokeFromDebugger,
+			&entities.ConfirmationDecision{Action: entities.ConfirmationApprove}))
+
+		result := waitResult(t, done)
+		assert.Equal(t, `{"to":"a@example.com"}`, result.arguments)
+		assert.Empty(t, result.observation)
+	})
+
+	t.Run("edit runs with the edited arguments", func(t *testing.T) {
+		shortenConfirmationWait(t, time.Second)
+		f, factory, manager := newGate(config)
+		taskID := uuid.New()
+
+		thought, done := startConfirmation(t, f, manager, taskID)
+		require.NoError(t, factory.ConfirmTask(context.Background(), taskID, thought.ID, userID, consts.InvokeFromDebugger,
+			&entities.ConfirmationDecision{Action: entities.ConfirmationEdit, Arguments: `{"to":"b@example.com"}`}))
+
+		result := waitResult(t, done)
+		assert.Equal(t, `{"to":"b@example.com"}`, result.arguments)
+		assert.Empty(t, result.observation)
+	})
+
+	t.Run("reject skips the call with the reason", func(t *testing.T) {
+		shortenConfirmationWait(t, time.Second)
+		f, factory, manager := newGate(config)
+		taskID := uuid.New()
+
+		thought, done := startConfirmation(t, f, manager, taskID)
+		// Only the owner of the task can decide
+		assert.Error(t, factory.ConfirmTask(context.Background(), taskID, thought.ID, uuid.New(), consts.InvokeFromDebugger,
+			&entities.ConfirmationDecision{Action: entities.ConfirmationApprove}))
+		require.NoError(t, factory.ConfirmTask(context.Background(), taskID, thought.ID, userID, consts.InvokeFromDebugger,
+			&entities.ConfirmationDecision{Action: entities.ConfirmationReject, Reason: "wrong recipient"}))
+
+		result := waitResult(t, done)
+		assert.Contains(t, result.observation, "用户拒绝执行该工具调用")
+		assert.Contains(t, result.observation, "wrong recipient")
+	})
+
+	t.Run("timeout skips the call", func(t *testing.T) {
+		shortenConfirmationWait(t, 50*time.Millisecond)
+		f, _, manager := newGate(config)
+
+		_, done := startConfirmation(t, f, manager, uuid.New())
+
+		result := waitResult(t, done)
+		assert.Contains(t, result.observation, "未获得用户确认")
+		assert.Contains(t, result.observation, "timed out")
+	})
+
+	t.Run("unavailable confirmation rejects right away", func(t *testing.T) {
+		shortenConfirmationWait(t, time.Minute)
+		f, _, manager := newGate(&entities.AgentConfig{
+			UserID:                  userID,
+			ConfirmationTools:       []string{"send_email"},
+			ConfirmationUnavailable: "blocking mode",
+		})
+		taskID := uuid.New()
+
+		arguments, observation := f.confirmToolCall(context.Background(), taskID, "send_email", "{}", manager)
+		assert.Equal(t, "{}", arguments)
+		assert.Contains(t, observation, "blocking mode")
+		// No confirmation request is published because nobody could answer it
+		assert.Empty(t, manager.getQueue(taskID))
+	})
+}
+
+func TestRequiresConfirmation(t *testing.T) {
+	f := &FunctionCallAgent{baseAgentImpl: &baseAgentImpl{agentConfig: &entities.AgentConfig{
+		ConfirmationTools: []string{"send_email", "provider_delete_user"},
+	}}}
+
+	assert.True(t, f.requiresConfirmation("send_email"))
+	assert.True(t, f.requiresConfirmation("provider_delete_user"))
+	assert.False(t, f.requiresConfirmation("current_time"))
+	assert.False(t, f.requiresConfirmation(""))
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
+)
+
+// newReviewPipeline builds the moderation pipeline from the review config, the agent's own model
+// judges for the LLM moderators. The config is validated when the app is saved, so a moderator
+// that still fails to build is left out instead of failing the conversation.
+func newReviewPipeline(llm model.BaseChatModel, config *entities.AgentConfig) *moderation.Pipeline {
+	reviewConfig := config.ReviewConfig
+	pipeline, _ := moderation.NewPipeline(moderation.Config{
+		InputEnabled:  reviewConfig.Enable && reviewConfig.InputsConfig.Enable,
+		OutputEnabled: reviewConfig.Enable && reviewConfig.OutputsConfig.Enable,
+		Keywords:      reviewConfig.Keywords,
+		Moderators:    reviewConfig.Moderators,
+	}, llm)
+
+	return pipeline
+}
+
+// moderate reviews the content of a stage and publishes the hits, if any, for audit
+func (b *baseAgentImpl) moderate(ctx context.Context, taskID uuid.UUID, stage moderation.Stage, content string, queueManager *AgentQueueManager) *moderation.Result {
+	result := b.reviewPipeline.Moderate(ctx, stage, content)
+	b.publishModeration(taskID, result, queueManager)
+
+	return result
+}
+
+// publishModeration publishes a moderation event carrying the hits of the result.
+// The observation only names the rules that were hit, never the matched text.
+func (b *baseAgentImpl) publishModeration(taskID uuid.UUID, result *moderation.Result, queueManager *AgentQueueManager) {
+	if len(result.Hits) == 0 {
+		return
+	}
+
+	queueManager.Publish(taskID, &entities.AgentThought{
+		ID:          uuid.New(),
+		TaskID:      taskID,
+		Event:       entities.EventModeration,
+		Observation: result.Summary(),
+		Moderation:  result.Hits,
+	})
+}
+
+// reviewedAnswer returns the answer to show for a reviewed output
+func (b *baseAgentImpl) reviewedAnswer(result *moderation.Result) string {
+	if !result.Blocked() {
+		return result.Content
+	}
+	if presetResponse := b.agentConfig.ReviewConfig.OutputsConfig.PresetResponse; presetResponse != "" {
+		return presetResponse
+	}
+
+	return entities.BlockedOutputResponse
+}
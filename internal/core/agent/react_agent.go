@@ -14,6 +14,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 
 	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	"github.com/crazyfrankie/voidx/internal/core/moderation"
 )
 
 const pattern = "(?m)```json\\s*\\n([\\s\\S]+?)```"
@@ -75,7 +76,7 @@ func (r *ReactAgent) Stream(ctx context.Context, input entities.AgentState) (<-c
 // processAgentPipeline overrides the pipeline to use ReACT-specific logic
 func (r *ReactAgent) processAgentPipeline(ctx context.Context, state entities.AgentState, queueManager *AgentQueueManager) error {
 	// 1. Preset operation node
-	if shouldEnd, err := r.presetOperationNode(ctx, state, queueManager); err != nil {
+	if shouldEnd, err := r.presetOperationNode(ctx, &state, queueManager); err != nil {
 		return err
 	} else if shouldEnd {
 		return nil
@@ -168,10 +169,9 @@ func (r *ReactAgent) reactLongTermMemoryRecallNode(ctx context.Context, state *e
 		messages = append(messages, state.History...)
 	}
 
-	// Add current user message
+	// Add current user message, keeping the images of a multimodal message
 	if len(state.Messages) > 0 {
-		lastMsg := state.Messages[len(state.Messages)-1]
-		messages = append(messages, schema.UserMessage(lastMsg.Content))
+		messages = append(messages, state.Messages[len(state.Messages)-1])
 	}
 
 	// Update state with prepared messages
@@ -203,6 +203,9 @@ func (r *ReactAgent) reactLLMNode(ctx context.Context, state *entities.AgentStat
 	id := uuid.New()
 	startTime := time.Now()
 
+	// Keep the prompt within the model's context window
+	r.fitContextWindow(ctx, state)
+
 	// Use streaming to detect tool calls vs regular messages
 	streamReader, err := r.llm.Stream(ctx, state.Messages)
 	if err != nil {
@@ -212,8 +215,27 @@ func (r *ReactAgent) reactLLMNode(ctx context.Context, state *entities.AgentStat
 
 	var gatheredContent strings.Builder
 	var generationType string // "thought" for tool calls, "message" for regular response
+
+	// Reasoning models stream their thinking before the answer, it is published as its own thought
+	reasoningID := uuid.New()
+	var responseMeta *schema.ResponseMeta
+	var extra map[string]any
 	isFirstChunk := true
 
+	// A structured answer is published as a whole once it has been converted and validated
+	structured := len(r.agentConfig.OutputSchema) > 0
+
+	// The answer is also held back when a moderator has to review it as a whole, otherwise
+	// the chunks are reviewed as a stream that only holds back a possible partial match
+	reviewOutput := r.reviewPipeline.Enabled(moderation.StageOutput)
+	holdBack := structured || r.reviewPipeline.NeedsFullText(moderation.StageOutput)
+	outputStream := r.reviewPipeline.NewStream(ctx, moderation.StageOutput)
+	publishChunk := func(content string) {
+		if content = outputStream.Write(content); content != "" {
+			r.publishMessageChunk(state.TaskID, id, content, startTime, queueManager)
+		}
+	}
+
 	// Process streaming chunks
 	for {
 		chunk, err := streamReader.Recv()
@@ -221,8 +243,28 @@ func (r *ReactAgent) reactLLMNode(ctx context.Context, state *entities.AgentStat
 			break // End of stream
 		}
 
+		if chunk.ReasoningContent != "" {
+			queueManager.Publish(state.TaskID, &entities.AgentThought{
+				ID:      reasoningID,
+				TaskID:  state.TaskID,
+				Event:   entities.EventAgentReasoning,
+				Thought: chunk.ReasoningContent,
+				Latency: time.Since(startTime).Seconds(),
+			})
+		}
+
 		gatheredContent.WriteString(chunk.Content)
 
+		// Usage is usually reported with the last chunk
+		if chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+			responseMeta = chunk.ResponseMeta
+		}
+
+		// The model that answered is tagged on the first chunk
+		if extra == nil && len(chunk.Extra) > 0 {
+			extra = chunk.Extra
+		}
+
 		// Determine generation type based on content
 		if generationType == "" && gatheredContent.Len() >= 7 {
 			content := strings.TrimSpace(gatheredContent.String())
@@ -231,20 +273,27 @@ func (r *ReactAgent) reactLLMNode(ctx context.Context, state *entities.AgentStat
 			} else {
 				generationType = "message"
 				// Publish the initial content to avoid missing first characters
-				r.publishMessageChunk(state.TaskID, id, content, startTime, queueManager)
+				if !holdBack {
+					publishChunk(content)
+				}
 			}
 		}
 
 		// If it's a regular message, publish streaming chunks
-		if generationType == "message" && !isFirstChunk {
-			content := r.applyOutputReview(chunk.Content)
-			r.publishMessageChunk(state.TaskID, id, content, startTime, queueManager)
+		if generationType == "message" && !isFirstChunk && !holdBack {
+			publishChunk(chunk.Content)
 		}
 
 		isFirstChunk = false
 	}
 
 	finalContent := gatheredContent.String()
+	response := &schema.Message{
+		Role:         schema.Assistant,
+		Content:      finalContent,
+		ResponseMeta: responseMeta,
+		Extra:        extra,
+	}
 
 	// Handle tool calling (thought) generation
 	if generationType == "thought" {
@@ -252,17 +301,20 @@ func (r *ReactAgent) reactLLMNode(ctx context.Context, state *entities.AgentStat
 		if err != nil {
 			// If parsing fails, treat as regular message
 			generationType = "message"
-			content := r.applyOutputReview(finalContent)
-			r.publishMessageChunk(state.TaskID, id, content, startTime, queueManager)
+			if !holdBack {
+				publishChunk(finalContent)
+			}
 		} else {
 			// Publish thought event
-			queueManager.Publish(state.TaskID, &entities.AgentThought{
+			thought := &entities.AgentThought{
 				ID:      id,
 				TaskID:  state.TaskID,
 				Event:   entities.EventAgentThought,
 				Thought: finalContent,
 				Latency: time.Since(startTime).Seconds(),
-			})
+			}
+			r.applyUsage(thought, state.Messages, response)
+			queueManager.Publish(state.TaskID, thought)
 
 			// Create AI message with tool calls
 			aiMessage := &schema.Message{
@@ -277,21 +329,58 @@ func (r *ReactAgent) reactLLMNode(ctx context.Context, state *entities.AgentStat
 	}
 
 	// Handle regular message generation
-	if generationType == "message" {
+	if generationType == "message" || (structured && finalContent != "") {
 		// Publish final statistics
-		queueManager.Publish(state.TaskID, &entities.AgentThought{
+		thought := &entities.AgentThought{
 			ID:      id,
 			TaskID:  state.TaskID,
 			Event:   entities.EventAgentMessage,
 			Thought: "",
 			Answer:  "",
 			Latency: time.Since(startTime).Seconds(),
-		})
+		}
+		r.applyUsage(thought, state.Messages, response)
+
+		// Review the held back answer as a whole, or release the tail of the reviewed stream
+		answer := finalContent
+		blocked := false
+		if reviewOutput && holdBack {
+			result := r.moderate(ctx, state.TaskID, moderation.StageOutput, finalContent, queueManager)
+			answer = r.reviewedAnswer(result)
+			blocked = result.Blocked()
+		} else if reviewOutput {
+			if tail := outputStream.Flush(); tail != "" {
+				r.publishMessageChunk(state.TaskID, id, tail, startTime, queueManager)
+			}
+			result := outputStream.Result()
+			r.publishModeration(state.TaskID, result, queueManager)
+			answer = result.Content
+		}
+		if holdBack && (!structured || blocked) {
+			thought.Thought = answer
+			thought.Answer = answer
+		}
+
+		// Turn the final answer into JSON following the output schema, from the reviewed answer
+		if structured && !blocked {
+			reviewed := *response
+			reviewed.Content = answer
+			answer, value, err := r.generateStructuredOutput(ctx, append(state.Messages, &reviewed), thought)
+			if err != nil {
+				queueManager.PublishError(state.TaskID, err)
+				return false, err
+			}
+			thought.Thought = answer
+			thought.Answer = answer
+			thought.StructuredOutput = value
+			thought.Latency = time.Since(startTime).Seconds()
+		}
+		queueManager.Publish(state.TaskID, thought)
 
 		// Create AI message
 		aiMessage := &schema.Message{
 			Role:    schema.Assistant,
-			Content: r.applyOutputReview(finalContent),
+			Content: answer,
 		}
 		state.Messages = append(state.Messages, aiMessage)
 		state.IterationCount++
@@ -462,13 +551,12 @@ func (r *ReactAgent) parseToolCallFromContent(content string) ([]schema.ToolCall
 
 // publishMessageChunk publishes a message chunk for streaming
 func (r *ReactAgent) publishMessageChunk(taskID uuid.UUID, id uuid.UUID, content string, startTime time.Time, queueManager *AgentQueueManager) {
-	reviewedContent := r.applyOutputReview(content)
 	queueManager.Publish(taskID, &entities.AgentThought{
 		ID:      id,
 		TaskID:  taskID,
 		Event:   entities.EventAgentMessage,
-		Thought: reviewedContent,
-		Answer:  reviewedContent,
+		Thought: content,
+		Answer:  content,
 		Latency: time.Since(startTime).Seconds(),
 	})
 }
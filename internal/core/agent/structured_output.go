@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/internal/core/agent/entities"
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/pkg/jsonschema"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+const (
+	// structuredOutputAttempts is the first attempt plus one retry on invalid output
+	structuredOutputAttempts = 2
+
+	// finalAnswerToolName is the tool the model calls to hand over a structured answer
+	// when the provider has no JSON output mode
+	finalAnswerToolName = "final_answer"
+)
+
+// responseFormatModel is implemented by models that can be rebuilt with a JSON output mode
+type responseFormatModel interface {
+	WithResponseFormat(ctx context.Context, format *llmentity.ResponseFormat) (llmentity.BaseLanguageModel, error)
+}
+
+// generateStructuredOutput turns the conversation ending with the final answer into a JSON value
+// following the output schema. It prefers the model's JSON output mode, then a forced tool call,
+// then plain prompting; the result is validated and regenerated once with the validation error.
+// The usage of every call is added to the thought.
+func (b *baseAgentImpl) generateStructuredOutput(ctx context.Context, messages []*schema.Message, thought *entities.AgentThought) (string, any, error) {
+	schemaJSON, err := sonic.MarshalString(b.agentConfig.OutputSchema)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid output schema: %w", err)
+	}
+
+	prompt := make([]*schema.Message, 0, len(messages)+3)
+	prompt = append(prompt, messages...)
+	prompt = append(prompt, schema.UserMessage(strings.ReplaceAll(entities.StructuredOutputPromptTemplate, "{schema}", schemaJSON)))
+
+	var lastErr error
+	for attempt := 0; attempt < structuredOutputAttempts; attempt++ {
+		content, response, err := b.generateJSON(ctx, prompt)
+		if err != nil {
+			return "", nil, err
+		}
+
+		usage := &entities.AgentThought{}
+		b.applyUsage(usage, prompt, response)
+		thought.AddUsage(usage)
+
+		value, err := parseStructuredOutput(content, b.agentConfig.OutputSchema)
+		if err == nil {
+			return content, value, nil
+		}
+		lastErr = err
+
+		// Feed the violation back so the retry can correct it
+		prompt = append(prompt,
+			schema.AssistantMessage(content, nil),
+			schema.UserMessage(strings.ReplaceAll(entities.StructuredOutputRetryTemplate, "{error}", err.Error())),
+		)
+	}
+
+	return "", nil, fmt.Errorf("structured output does not match the schema: %w", lastErr)
+}
+
+// generateJSON generates one JSON answer with the best mode the model offers
+func (b *baseAgentImpl) generateJSON(ctx context.Context, prompt []*schema.Message) (string, *schema.Message, error) {
+	// 1. JSON output mode of the provider
+	if formatModel, ok := b.llm.(responseFormatModel); ok {
+		jsonModel, err := formatModel.WithResponseFormat(ctx, &llmentity.ResponseFormat{Schema: b.agentConfig.OutputSchema})
+		if err == nil {
+			response, err := jsonModel.Generate(ctx, prompt)
+			if err != nil {
+				return "", nil, err
+			}
+			return response.Content, response, nil
+		}
+	}
+
+	// 2. A forced tool call whose arguments are the answer, the arguments of a tool are always an object
+	if toolCallingModel, ok := b.llm.(model.ToolCallingChatModel); ok && isObjectSchema(b.agentConfig.OutputSchema) {
+		toolModel, err := toolCallingModel.WithTools([]*schema.ToolInfo{{
+			Name:        finalAnswerToolName,
+			Desc:        "提交最终回答，参数即为符合要求的结构化结果",
+			ParamsOneOf: schema.NewParamsOneOfByParams(parametersFromSchema(b.agentConfig.OutputSchema)),
+		}})
+		if err == nil {
+			response, err := toolModel.Generate(ctx, prompt, model.WithToolChoice(schema.ToolChoiceForced))
+			if err != nil {
+				return "", nil, err
+			}
+			for _, toolCall := range response.ToolCalls {
+				if toolCall.Function.Name == finalAnswerToolName {
+					return toolCall.Function.Arguments, response, nil
+				}
+			}
+			return response.Content, response, nil
+		}
+	}
+
+	// 3. Plain prompting
+	response, err := b.llm.Generate(ctx, prompt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return response.Content, response, nil
+}
+
+// parseStructuredOutput extracts the JSON from the model output and validates it against the schema
+func parseStructuredOutput(content string, outputSchema map[string]any) (any, error) {
+	var value any
+	if err := sonic.UnmarshalString(extractJSON(content), &value); err != nil {
+		return nil, fmt.Errorf("output is not valid JSON: %w", err)
+	}
+
+	if err := jsonschema.Validate(outputSchema, value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// extractJSON strips Markdown code fences and any text around the outermost JSON object or array
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	content = strings.TrimSpace(content)
+
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return content
+	}
+	closing := "}"
+	if content[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(content, closing)
+	if end < start {
+		return content
+	}
+
+	return content[start : end+1]
+}
+
+func isObjectSchema(outputSchema map[string]any) bool {
+	t, _ := outputSchema["type"].(string)
+	return t == "object"
+}
+
+// parametersFromSchema converts the properties of an object schema into eino tool parameters
+func parametersFromSchema(objectSchema map[string]any) map[string]*schema.ParameterInfo {
+	properties, _ := objectSchema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	if names, ok := objectSchema["required"].([]any); ok {
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	params := make(map[string]*schema.ParameterInfo, len(properties))
+	for name, raw := range properties {
+		propertySchema, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		param := parameterFromSchema(propertySchema)
+		param.Required = required[name]
+		params[name] = param
+	}
+
+	return params
+}
+
+func parameterFromSchema(propertySchema map[string]any) *schema.ParameterInfo {
+	param := &schema.ParameterInfo{}
+	param.Desc, _ = propertySchema["description"].(string)
+
+	t, _ := propertySchema["type"].(string)
+	switch t {
+	case "object":
+		param.Type = schema.Object
+		param.SubParams = parametersFromSchema(propertySchema)
+	case "array":
+		param.Type = schema.Array
+		if items, ok := propertySchema["items"].(map[string]any); ok {
+			param.ElemInfo = parameterFromSchema(items)
+		} else {
+			param.ElemInfo = &schema.ParameterInfo{Type: schema.String}
+		}
+	case "integer":
+		param.Type = schema.Integer
+	case "number":
+		param.Type = schema.Number
+	case "boolean":
+		param.Type = schema.Boolean
+	case "null":
+		param.Type = schema.Null
+	default:
+		param.Type = schema.String
+		if enum, ok := propertySchema["enum"].([]any); ok {
+			for _, value := range enum {
+				if s, ok := value.(string); ok {
+					param.Enum = append(param.Enum, s)
+				}
+			}
+		}
+	}
+
+	return param
+}
@@ -0,0 +1,470 @@
+package crawler
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/crazyfrankie/voidx/pkg/logs"
+)
+
+const (
+	// UserAgent 爬虫请求使用的User-Agent，同时用于匹配robots.txt中的规则组
+	UserAgent = "VoidxBot/1.0"
+
+	// DefaultMaxDepth 默认从种子页面开始跟随链接的最大深度
+	DefaultMaxDepth = 2
+	// DefaultMaxPages 默认单次爬取的最大页面数
+	DefaultMaxPages = 50
+	// MaxPagesLimit 单次爬取允许设置的最大页面数
+	MaxPagesLimit = 500
+
+	requestTimeout  = 30 * time.Second
+	maxBodySize     = 5 << 20
+	maxSitemapDepth = 3
+	maxRedirects    = 10
+)
+
+var (
+	// errNotHTML 页面不是HTML页面，爬取时直接跳过
+	errNotHTML = errors.New("页面不是HTML页面")
+	// errSkipped 页面重定向到了站点之外或robots.txt禁止爬取的地址，爬取时直接跳过
+	errSkipped = errors.New("页面重定向到了不允许爬取的地址")
+)
+
+// reservedPrefixes 除回环、内网与链路本地地址外，同样不允许访问的保留地址段
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Config 爬取配置，只会爬取种子地址与站点地图所在站点内的页面
+type Config struct {
+	SeedURLs   []string
+	SitemapURL string
+	// MaxDepth 从种子页面开始跟随链接的最大深度，0表示只爬取种子页面与站点地图中的页面
+	MaxDepth int
+	// MaxPages 最多请求的页面数，不大于0时使用DefaultMaxPages
+	MaxPages int
+}
+
+// Page 爬取得到的页面，Content为提取出的Markdown格式正文
+type Page struct {
+	URL     string
+	Title   string
+	Content string
+}
+
+// Crawler 网页爬虫，按照广度优先的顺序爬取站点内的页面，并遵守站点的robots.txt，
+// 爬虫只会连接公网地址，避免通过用户提交的地址或重定向访问服务所在的内网
+type Crawler struct {
+	client *http.Client
+}
+
+// NewCrawler 创建一个新的网页爬虫
+func NewCrawler() *Crawler {
+	return newCrawler(false)
+}
+
+// newCrawler 创建网页爬虫，allowPrivateNetwork为true时允许连接回环与内网地址，仅用于访问本地的测试服务
+func newCrawler(allowPrivateNetwork bool) *Crawler {
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if !allowPrivateNetwork {
+		// 在域名解析完成后检查实际连接的地址，避免域名解析到内网地址或在两次解析之间被修改
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("不允许访问内网地址%s", addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 不使用代理，代理会使连接检查只作用于代理地址
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Crawler{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+			// 重定向由do逐个检查后再跟随
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Crawl 从种子地址与站点地图开始爬取页面，单个页面请求失败时跳过该页面，
+// 被robots.txt禁止、meta robots为noindex或没有正文的页面不会出现在结果中
+func (c *Crawler) Crawl(ctx context.Context, config Config) ([]Page, error) {
+	maxPages := config.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	maxPages = min(maxPages, MaxPagesLimit)
+	maxDepth := max(config.MaxDepth, 0)
+
+	// 1. 解析种子地址与站点地图地址，记录允许爬取的站点
+	var seeds []*url.URL
+	sites := make(map[string]struct{})
+	for _, rawURL := range config.SeedURLs {
+		seed, err := normalizeURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, seed)
+		sites[siteKey(seed)] = struct{}{}
+	}
+	var sitemapURL *url.URL
+	if config.SitemapURL != "" {
+		var err error
+		if sitemapURL, err = normalizeURL(config.SitemapURL); err != nil {
+			return nil, err
+		}
+		sites[siteKey(sitemapURL)] = struct{}{}
+	}
+
+	// 2. 将种子地址与站点地图中的页面加入待爬取队列
+	type queuedURL struct {
+		url   *url.URL
+		depth int
+	}
+	var queue []queuedURL
+	visited := make(map[string]struct{})
+	enqueue := func(rawURL string, depth int) {
+		target, err := normalizeURL(rawURL)
+		if err != nil {
+			return
+		}
+		if _, ok := sites[siteKey(target)]; !ok {
+			return
+		}
+		if _, ok := visited[target.String()]; ok {
+			return
+		}
+		visited[target.String()] = struct{}{}
+		queue = append(queue, queuedURL{url: target, depth: depth})
+	}
+	for _, seed := range seeds {
+		enqueue(seed.String(), 0)
+	}
+	if sitemapURL != nil {
+		pageURLs, err := c.loadSitemap(ctx, sitemapURL, maxPages)
+		if err != nil {
+			return nil, err
+		}
+		for _, pageURL := range pageURLs {
+			enqueue(pageURL, 0)
+		}
+	}
+	if len(queue) == 0 {
+		return nil, errors.New("没有可以爬取的页面地址")
+	}
+
+	// 3. 按照广度优先的顺序爬取页面，并在深度限制内跟随页面中的站内链接
+	robots := make(map[string]*Robots)
+	var pages []Page
+	for fetched := 0; len(queue) > 0 && fetched < maxPages; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		item := queue[0]
+		queue = queue[1:]
+
+		if !c.allowedByRobots(ctx, robots, item.url) {
+			continue
+		}
+		fetched++
+		// 重定向到其他站点或被robots.txt禁止的地址时不再继续请求
+		pageURL, content, err := c.fetchPage(ctx, item.url, func(target *url.URL) error {
+			if _, ok := sites[siteKey(target)]; !ok || !c.allowedByRobots(ctx, robots, target) {
+				return errSkipped
+			}
+			return nil
+		})
+		if err != nil {
+			if !errors.Is(err, errNotHTML) && !errors.Is(err, errSkipped) {
+				logs.Warnf("Failed to crawl page %s: %v", item.url, err)
+			}
+			continue
+		}
+		visited[pageURL.String()] = struct{}{}
+
+		if !content.NoIndex && content.Content != "" {
+			title := content.Title
+			if title == "" {
+				title = pageURL.String()
+			}
+			pages = append(pages, Page{URL: pageURL.String(), Title: title, Content: content.Content})
+		}
+		if item.depth < maxDepth && !content.NoFollow {
+			for _, link := range content.Links {
+				enqueue(link, item.depth+1)
+			}
+		}
+	}
+
+	return pages, nil
+}
+
+// fetchPage 请求并解析HTML页面，返回重定向后的页面地址以及页面内容，重定向的目标地址需要通过allowRedirect的检查
+func (c *Crawler) fetchPage(ctx context.Context, pageURL *url.URL, allowRedirect func(*url.URL) error) (*url.URL, *PageContent, error) {
+	resp, err := c.get(ctx, pageURL, "text/html,application/xhtml+xml", allowRedirect)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil, errNotHTML
+	}
+
+	// 按照响应声明或页面meta中的编码转换为UTF-8
+	body, err := charset.NewReader(io.LimitReader(resp.Body, maxBodySize), contentType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("识别页面编码失败: %w", err)
+	}
+	finalURL, _ := normalizeURL(resp.Request.URL.String())
+	if finalURL == nil {
+		finalURL = pageURL
+	}
+	content, err := ExtractPage(body, finalURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return finalURL, content, nil
+}
+
+// loadSitemap 读取站点地图中的页面地址，支持sitemapindex嵌套与gzip压缩的站点地图，最多返回limit个地址
+func (c *Crawler) loadSitemap(ctx context.Context, sitemapURL *url.URL, limit int) ([]string, error) {
+	var pageURLs []string
+	visited := map[string]struct{}{sitemapURL.String(): {}}
+	current := []*url.URL{sitemapURL}
+	for depth := 0; depth < maxSitemapDepth && len(current) > 0 && len(pageURLs) < limit; depth++ {
+		var next []*url.URL
+		for _, sitemap := range current {
+			urls, sitemaps, err := c.fetchSitemap(ctx, sitemap)
+			if err != nil {
+				// 根站点地图无法读取时直接返回错误，嵌套的站点地图失败时跳过
+				if sitemap == sitemapURL {
+					return nil, err
+				}
+				logs.Warnf("Failed to load sitemap %s: %v", sitemap, err)
+				continue
+			}
+			pageURLs = append(pageURLs, urls...)
+			for _, rawURL := range sitemaps {
+				child, err := normalizeURL(rawURL)
+				if err != nil {
+					continue
+				}
+				if _, ok := visited[child.String()]; !ok {
+					visited[child.String()] = struct{}{}
+					next = append(next, child)
+				}
+			}
+		}
+		current = next
+	}
+	if len(pageURLs) > limit {
+		pageURLs = pageURLs[:limit]
+	}
+
+	return pageURLs, nil
+}
+
+// fetchSitemap 请求并解析单个站点地图
+func (c *Crawler) fetchSitemap(ctx context.Context, sitemapURL *url.URL) ([]string, []string, error) {
+	resp, err := c.get(ctx, sitemapURL, "application/xml,text/xml", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = io.LimitReader(resp.Body, maxBodySize)
+	if strings.HasSuffix(sitemapURL.Path, ".gz") {
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("解压站点地图失败: %w", err)
+		}
+		defer gzipReader.Close()
+		body = io.LimitReader(gzipReader, maxBodySize)
+	}
+
+	return ParseSitemap(body)
+}
+
+// allowedByRobots 判断页面是否允许爬取，每个站点的robots.txt只请求一次，
+// robots.txt不存在时允许爬取所有页面，站点出错时不爬取该站点
+func (c *Crawler) allowedByRobots(ctx context.Context, cache map[string]*Robots, pageURL *url.URL) bool {
+	site := pageURL.Scheme + "://" + pageURL.Host
+	robots, ok := cache[site]
+	if !ok {
+		robots = c.fetchRobots(ctx, site)
+		cache[site] = robots
+	}
+
+	path := pageURL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if pageURL.RawQuery != "" {
+		path += "?" + pageURL.RawQuery
+	}
+
+	return robots.Allowed(path)
+}
+
+// fetchRobots 请求站点的robots.txt，返回nil表示允许爬取所有页面
+func (c *Crawler) fetchRobots(ctx context.Context, site string) *Robots {
+	disallowAll := &Robots{rules: []robotsRule{{pattern: "/", allow: false}}}
+	robotsURL, err := url.Parse(site + "/robots.txt")
+	if err != nil {
+		return disallowAll
+	}
+
+	resp, err := c.do(ctx, robotsURL, "text/plain", nil)
+	if err != nil {
+		logs.Warnf("Failed to fetch %s: %v", robotsURL, err)
+		return disallowAll
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return ParseRobots(io.LimitReader(resp.Body, maxBodySize), UserAgent)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return disallowAll
+	default:
+		return nil
+	}
+}
+
+// get 发起GET请求，非200的响应返回错误
+func (c *Crawler) get(ctx context.Context, target *url.URL, accept string, allowRedirect func(*url.URL) error) (*http.Response, error) {
+	resp, err := c.do(ctx, target, accept, allowRedirect)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("请求%s失败, 状态码: %d", target, resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// do 使用爬虫的User-Agent发起GET请求并跟随重定向，allowRedirect不为nil时每个重定向的目标地址都需要通过检查
+func (c *Crawler) do(ctx context.Context, target *url.URL, accept string, allowRedirect func(*url.URL) error) (*http.Response, error) {
+	for redirects := 0; ; redirects++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		req.Header.Set("Accept", accept)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		location := resp.Header.Get("Location")
+		if !isRedirect(resp.StatusCode) || location == "" {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if redirects >= maxRedirects {
+			return nil, fmt.Errorf("请求%s失败, 重定向次数过多", target)
+		}
+		next, err := target.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("无效的重定向地址%s: %w", location, err)
+		}
+		if target, err = normalizeURL(next.String()); err != nil {
+			return nil, err
+		}
+		if allowRedirect != nil {
+			if err := allowRedirect(target); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// isRedirect 判断响应是否为需要跟随的重定向
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// isPublicAddr 判断地址是否为公网地址，回环、内网、链路本地、组播与保留地址均不允许访问
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeURL 解析地址并去除锚点，只支持http与https地址
+func normalizeURL(rawURL string) (*url.URL, error) {
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("无效的地址%s: %w", rawURL, err)
+	}
+	target.Scheme = strings.ToLower(target.Scheme)
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("无效的地址%s, 只支持http与https地址", rawURL)
+	}
+	target.Host = strings.ToLower(target.Host)
+	target.Fragment = ""
+	target.RawFragment = ""
+	if target.Path == "" {
+		target.Path = "/"
+	}
+
+	return target, nil
+}
+
+// siteKey 地址所在的站点，忽略www前缀，使用example.com与www.example.com访问的是同一个站点
+func siteKey(target *url.URL) string {
+	return strings.TrimPrefix(target.Host, "www.")
+}
@@ -0,0 +1,330 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSite 本地测试站点，记录所有收到的请求
+type testSite struct {
+	*httptest.Server
+	mu        sync.Mutex
+	routes    map[string]http.HandlerFunc
+	requested []string
+}
+
+// newTestSite 创建测试站点，routes按照包含查询参数的请求地址匹配，不存在的地址返回404
+func newTestSite(t *testing.T, routes map[string]http.HandlerFunc) *testSite {
+	t.Helper()
+
+	site := &testSite{routes: routes}
+	site.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site.mu.Lock()
+		site.requested = append(site.requested, r.URL.RequestURI())
+		handler, ok := site.routes[r.URL.RequestURI()]
+		site.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(site.Close)
+
+	return site
+}
+
+func (s *testSite) setRoute(path string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[path] = handler
+}
+
+func (s *testSite) requestedPaths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requested...)
+}
+
+// htmlPage 返回正文为content并包含links链接的HTML页面
+func htmlPage(title, content string, links ...string) http.HandlerFunc {
+	var body strings.Builder
+	body.WriteString("<html><head><title>" + title + "</title></head><body><p>" + content + "</p>")
+	for _, link := range links {
+		body.WriteString(`<a href="` + link + `">` + link + `</a>`)
+	}
+	body.WriteString("</body></html>")
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(body.String()))
+	}
+}
+
+func textFile(contentType string, content []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(content)
+	}
+}
+
+func redirectTo(location string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, location, http.StatusFound)
+	}
+}
+
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func pageURLs(pages []Page) []string {
+	urls := make([]string, 0, len(pages))
+	for _, page := range pages {
+		urls = append(urls, page.URL)
+	}
+	return urls
+}
+
+func TestCrawlRobots(t *testing.T) {
+	robots := `User-agent: OtherBot
+Disallow: /
+
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /*?session=
+`
+	site := newTestSite(t, map[string]http.HandlerFunc{
+		"/robots.txt": textFile("text/plain", []byte(robots)),
+		"/": htmlPage("Home", "home",
+			"/private/secret", "/private/public", "/files/doc.pdf", "/files/doc.pdf.html",
+			"/list?session=1", "/list?page=2", "/jump"),
+		"/private/secret":     htmlPage("Secret", "secret"),
+		"/private/public":     htmlPage("Public", "public"),
+		"/files/doc.pdf":      htmlPage("PDF", "pdf"),
+		"/files/doc.pdf.html": htmlPage("PDF page", "pdf page"),
+		"/list?session=1":     htmlPage("Session", "session"),
+		"/list?page=2":        htmlPage("List", "list"),
+		// 重定向的目标地址同样需要遵守robots.txt
+		"/jump":           redirectTo("/private/hidden"),
+		"/private/hidden": htmlPage("Hidden", "hidden"),
+	})
+
+	pages, err := newCrawler(true).Crawl(context.Background(), Config{SeedURLs: []string{site.URL}, MaxDepth: 1})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		site.URL + "/",
+		site.URL + "/private/public",
+		site.URL + "/files/doc.pdf.html",
+		site.URL + "/list?page=2",
+	}, pageURLs(pages))
+	requested := site.requestedPaths()
+	for _, path := range []string{"/private/secret", "/files/doc.pdf", "/list?session=1", "/private/hidden"} {
+		assert.NotContains(t, requested, path)
+	}
+	assert.Contains(t, requested, "/jump")
+	// robots.txt只请求一次
+	assert.Equal(t, 1, strings.Count(strings.Join(requested, "\n"), "/robots.txt"))
+}
+
+func TestCrawlDisallowAllRobots(t *testing.T) {
+	site := newTestSite(t, map[string]http.HandlerFunc{
+		"/robots.txt": textFile("text/plain", []byte("User-agent: VoidxBot\nDisallow: /\n\nUser-agent: *\nAllow: /\n")),
+		"/":           htmlPage("Home", "home"),
+	})
+
+	pages, err := newCrawler(true).Crawl(context.Background(), Config{SeedURLs: []string{site.URL}})
+	require.NoError(t, err)
+
+	assert.Empty(t, pages)
+	assert.Equal(t, []string{"/robots.txt"}, site.requestedPaths())
+}
+
+func TestCrawlLimits(t *testing.T) {
+	site := newTestSite(t, map[string]http.HandlerFunc{
+		"/":   htmlPage("Depth 0", "depth 0", "/d1"),
+		"/d1": htmlPage("Depth 1", "depth 1", "/d2", "/"),
+		"/d2": htmlPage("Depth 2", "depth 2", "/d3"),
+		"/d3": htmlPage("Depth 3", "depth 3"),
+	})
+
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{name: "seed only", config: Config{MaxDepth: 0}, want: []string{"/"}},
+		{name: "max depth", config: Config{MaxDepth: 2}, want: []string{"/", "/d1", "/d2"}},
+		{name: "max pages", config: Config{MaxDepth: 3, MaxPages: 2}, want: []string{"/", "/d1"}},
+		{name: "negative depth", config: Config{MaxDepth: -1}, want: []string{"/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.SeedURLs = []string{site.URL + "#top"}
+			pages, err := newCrawler(true).Crawl(context.Background(), tt.config)
+			require.NoError(t, err)
+
+			want := make([]string, 0, len(tt.want))
+			for _, path := range tt.want {
+				want = append(want, site.URL+path)
+			}
+			assert.Equal(t, want, pageURLs(pages))
+		})
+	}
+}
+
+func TestCrawlDomainScope(t *testing.T) {
+	other := newTestSite(t, map[string]http.HandlerFunc{
+		"/": htmlPage("Other", "other"),
+	})
+	site := newTestSite(t, map[string]http.HandlerFunc{
+		"/":      htmlPage("Home", "home", other.URL+"/", "/about", "/moved", "mailto:someone@example.com"),
+		"/about": htmlPage("About", "about"),
+		// 重定向到其他站点的页面不收录，也不会请求其他站点
+		"/moved": redirectTo(other.URL + "/"),
+	})
+
+	pages, err := newCrawler(true).Crawl(context.Background(), Config{SeedURLs: []string{site.URL}, MaxDepth: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{site.URL + "/", site.URL + "/about"}, pageURLs(pages))
+	assert.Empty(t, other.requestedPaths())
+}
+
+func TestCrawlSitemap(t *testing.T) {
+	site := newTestSite(t, nil)
+	site.routes = map[string]http.HandlerFunc{
+		"/sitemap.xml": textFile("application/xml", []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>`+site.URL+`/sitemaps/pages.xml.gz</loc></sitemap>
+<sitemap><loc>`+site.URL+`/sitemaps/more.xml</loc></sitemap>
+<sitemap><loc>`+site.URL+`/sitemaps/missing.xml</loc></sitemap>
+</sitemapindex>`)),
+		"/sitemaps/pages.xml.gz": textFile("application/gzip", gzipBytes(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>`+site.URL+`/a</loc></url>
+<url><loc> `+site.URL+`/b </loc></url>
+</urlset>`)),
+		"/sitemaps/more.xml": textFile("text/xml", []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>`+site.URL+`/c</loc></url>
+<url><loc>http://other.invalid/page</loc></url>
+<url><loc>`+site.URL+`/a</loc></url>
+</urlset>`)),
+		"/a": htmlPage("A", "a", "/d"),
+		"/b": htmlPage("B", "b"),
+		"/c": htmlPage("C", "c"),
+		"/d": htmlPage("D", "d"),
+	}
+
+	pages, err := newCrawler(true).Crawl(context.Background(), Config{SitemapURL: site.URL + "/sitemap.xml"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{site.URL + "/a", site.URL + "/b", site.URL + "/c"}, pageURLs(pages))
+	assert.NotContains(t, site.requestedPaths(), "/d")
+
+	t.Run("missing root sitemap", func(t *testing.T) {
+		_, err := newCrawler(true).Crawl(context.Background(), Config{SitemapURL: site.URL + "/none.xml"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCrawlRecrawlDetectsChanges(t *testing.T) {
+	site := newTestSite(t, map[string]http.HandlerFunc{
+		"/":        htmlPage("Home", "home", "/news", "/about"),
+		"/news":    htmlPage("News", "first version"),
+		"/about":   htmlPage("About", "about us"),
+		"/ignored": htmlPage("Ignored", "ignored"),
+	})
+	crawl := func() map[string]string {
+		pages, err := newCrawler(true).Crawl(context.Background(), Config{SeedURLs: []string{site.URL}, MaxDepth: 1})
+		require.NoError(t, err)
+		contents := make(map[string]string, len(pages))
+		for _, page := range pages {
+			contents[page.URL] = page.Content
+		}
+		return contents
+	}
+
+	first := crawl()
+	require.Len(t, first, 3)
+
+	// 内容未变化时提取出的正文保持一致，只有发生变化的页面正文不同
+	site.setRoute("/news", htmlPage("News", "second version"))
+	second := crawl()
+	require.Len(t, second, 3)
+	assert.Equal(t, first[site.URL+"/"], second[site.URL+"/"])
+	assert.Equal(t, first[site.URL+"/about"], second[site.URL+"/about"])
+	assert.NotEqual(t, first[site.URL+"/news"], second[site.URL+"/news"])
+	assert.Contains(t, second[site.URL+"/news"], "second version")
+}
+
+func TestCrawlerBlocksPrivateNetwork(t *testing.T) {
+	site := newTestSite(t, map[string]http.HandlerFunc{
+		"/robots.txt": textFile("text/plain", []byte("User-agent: *\nAllow: /\n")),
+		"/":           htmlPage("Home", "home"),
+	})
+	c := NewCrawler()
+
+	pages, err := c.Crawl(context.Background(), Config{SeedURLs: []string{site.URL}})
+	require.NoError(t, err)
+	assert.Empty(t, pages)
+
+	target, err := normalizeURL(site.URL)
+	require.NoError(t, err)
+	_, err = c.get(context.Background(), target, "text/html", nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "不允许访问内网地址")
+	}
+	assert.Empty(t, site.requestedPaths())
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "224.0.0.1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "::ffff:10.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, isPublicAddr(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
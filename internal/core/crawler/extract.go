@@ -0,0 +1,339 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedTags 不属于正文的标签，提取正文时整体跳过
+var skippedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Select: true, atom.Iframe: true,
+	atom.Svg: true, atom.Canvas: true, atom.Dialog: true,
+}
+
+// paragraphTags 段落级标签，前后使用空行分隔
+var paragraphTags = map[atom.Atom]bool{
+	atom.P: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Blockquote: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+	atom.Dl: true, atom.Figure: true, atom.Details: true, atom.Hr: true,
+}
+
+// lineTags 行级标签，前后换行
+var lineTags = map[atom.Atom]bool{
+	atom.Div: true, atom.Tr: true, atom.Dt: true, atom.Dd: true,
+	atom.Figcaption: true, atom.Summary: true, atom.Address: true, atom.Caption: true,
+}
+
+// headingLevels 标题标签对应的Markdown标题级别
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+var (
+	spacePattern      = regexp.MustCompile(`\s+`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// PageContent 从HTML页面中提取的内容
+type PageContent struct {
+	Title string
+	// Content 页面正文，标题转换为Markdown标题，便于使用Markdown分割器按标题分段
+	Content string
+	// Links 页面中的链接，已解析为去除锚点的绝对地址
+	Links []string
+	// NoIndex与NoFollow来自页面的meta robots，分别表示页面不应被收录、页面中的链接不应被跟随
+	NoIndex  bool
+	NoFollow bool
+}
+
+// ExtractPage 解析HTML页面，提取标题、正文与链接，正文优先取main、article等主体区域，
+// 并去除导航、页眉页脚、脚本等非正文内容
+func ExtractPage(r io.Reader, pageURL *url.URL) (*PageContent, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML页面失败: %w", err)
+	}
+
+	// 1. 读取页面标题、meta robots与页面中的链接，base标签会改变相对链接的解析地址
+	page := &PageContent{}
+	baseURL := pageURL
+	seen := make(map[string]struct{})
+	walkElements(root, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Title:
+			if page.Title == "" {
+				page.Title = collapseSpaces(textOf(n))
+			}
+		case atom.Base:
+			if href := attr(n, "href"); href != "" {
+				if base, err := pageURL.Parse(href); err == nil {
+					baseURL = base
+				}
+			}
+		case atom.Meta:
+			if strings.EqualFold(attr(n, "name"), "robots") {
+				content := strings.ToLower(attr(n, "content"))
+				page.NoIndex = strings.Contains(content, "noindex") || strings.Contains(content, "none")
+				page.NoFollow = strings.Contains(content, "nofollow") || strings.Contains(content, "none")
+			}
+		case atom.A:
+			if strings.Contains(strings.ToLower(attr(n, "rel")), "nofollow") {
+				break
+			}
+			if link, ok := resolveLink(baseURL, attr(n, "href")); ok {
+				if _, exists := seen[link]; !exists {
+					seen[link] = struct{}{}
+					page.Links = append(page.Links, link)
+				}
+			}
+		}
+		return true
+	})
+
+	// 2. 定位正文区域并转换为文本
+	writer := &textWriter{}
+	writer.render(findMainNode(root))
+	page.Content = writer.String()
+
+	// 3. 页面没有title时使用第一个一级标题
+	if page.Title == "" {
+		walkElements(root, func(n *html.Node) bool {
+			if n.DataAtom == atom.H1 && page.Title == "" {
+				page.Title = collapseSpaces(textOf(n))
+			}
+			return page.Title == ""
+		})
+	}
+
+	return page, nil
+}
+
+// findMainNode 查找页面的正文区域，依次尝试main标签、role为main的元素、article标签与body标签
+func findMainNode(root *html.Node) *html.Node {
+	matchers := []func(n *html.Node) bool{
+		func(n *html.Node) bool { return n.DataAtom == atom.Main },
+		func(n *html.Node) bool { return attr(n, "role") == "main" },
+		func(n *html.Node) bool { return n.DataAtom == atom.Article },
+		func(n *html.Node) bool { return n.DataAtom == atom.Body },
+	}
+	for _, matcher := range matchers {
+		var found *html.Node
+		walkElements(root, func(n *html.Node) bool {
+			if found == nil && matcher(n) {
+				found = n
+			}
+			return found == nil
+		})
+		if found != nil {
+			return found
+		}
+	}
+
+	return root
+}
+
+// textWriter 将HTML节点转换为文本，连续的空白合并为一个空格，块级元素之间换行
+type textWriter struct {
+	buf          []byte
+	pendingSpace bool
+}
+
+func (w *textWriter) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeText(n.Data)
+		return
+	case html.ElementNode:
+		if skippedTags[n.DataAtom] || isHidden(n) {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		if text := collapseSpaces(textOf(n)); text != "" {
+			w.newline(2)
+			w.writeString(strings.Repeat("#", level) + " " + text)
+			w.newline(2)
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		w.newline(1)
+		return
+	case atom.Pre:
+		if text := strings.Trim(textOf(n), "\n"); strings.TrimSpace(text) != "" {
+			w.newline(2)
+			w.writeString("```\n" + text + "\n```")
+			w.newline(2)
+		}
+		return
+	case atom.Li:
+		w.newline(1)
+		w.writeString("- ")
+	case atom.Td, atom.Th:
+		// 同一行的单元格之间使用竖线分隔
+		for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+			if sibling.DataAtom == atom.Td || sibling.DataAtom == atom.Th {
+				w.writeString(" | ")
+				break
+			}
+		}
+	}
+
+	breaks := 0
+	if paragraphTags[n.DataAtom] {
+		breaks = 2
+	} else if lineTags[n.DataAtom] || n.DataAtom == atom.Li {
+		breaks = 1
+	}
+	if breaks > 0 && n.DataAtom != atom.Li {
+		w.newline(breaks)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		w.render(child)
+	}
+	if breaks > 0 {
+		w.newline(breaks)
+	}
+}
+
+// writeText 写入行内文本，合并连续的空白
+func (w *textWriter) writeText(text string) {
+	collapsed := collapseSpaces(text)
+	if collapsed == "" {
+		w.pendingSpace = w.pendingSpace || text != ""
+		return
+	}
+	if w.pendingSpace || unicode.IsSpace(rune(text[0])) {
+		if n := len(w.buf); n > 0 && w.buf[n-1] != '\n' && w.buf[n-1] != ' ' {
+			w.buf = append(w.buf, ' ')
+		}
+	}
+	w.buf = append(w.buf, collapsed...)
+	w.pendingSpace = unicode.IsSpace(rune(text[len(text)-1]))
+}
+
+// writeString 原样写入文本
+func (w *textWriter) writeString(text string) {
+	w.pendingSpace = false
+	w.buf = append(w.buf, text...)
+}
+
+// newline 保证当前内容以count个换行结尾，内容为空时不写入换行
+func (w *textWriter) newline(count int) {
+	w.pendingSpace = false
+	w.buf = bytes.TrimRight(w.buf, " ")
+	if len(w.buf) == 0 {
+		return
+	}
+	existing := len(w.buf) - len(bytes.TrimRight(w.buf, "\n"))
+	for ; existing < count; existing++ {
+		w.buf = append(w.buf, '\n')
+	}
+}
+
+func (w *textWriter) String() string {
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(string(w.buf), "\n\n"))
+}
+
+// resolveLink 将链接解析为去除锚点的绝对地址，只保留http与https链接
+func resolveLink(baseURL *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+	link, err := baseURL.Parse(href)
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+		return "", false
+	}
+	link.Fragment = ""
+	link.RawFragment = ""
+
+	return link.String(), true
+}
+
+// walkElements 深度优先遍历元素节点，visit返回false时停止遍历
+func walkElements(n *html.Node, visit func(n *html.Node) bool) bool {
+	if n.Type == html.ElementNode && !visit(n) {
+		return false
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if !walkElements(child, visit) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// textOf 获取节点下所有文本节点的内容，跳过非正文标签
+func textOf(n *html.Node) string {
+	var builder strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && (skippedTags[n.DataAtom] || isHidden(n)) {
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+
+	return builder.String()
+}
+
+// isHidden 判断元素是否被标记为隐藏
+func isHidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch strings.ToLower(a.Key) {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if strings.EqualFold(a.Val, "true") {
+				return true
+			}
+		case "style":
+			style := strings.ReplaceAll(strings.ToLower(a.Val), " ", "")
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// attr 获取元素的属性值，不存在时返回空字符串
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func collapseSpaces(text string) string {
+	return strings.TrimSpace(spacePattern.ReplaceAllString(text, " "))
+}
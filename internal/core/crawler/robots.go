@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+)
+
+// robotsRule robots.txt中的一条Allow或Disallow规则
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// Robots 站点robots.txt中适用于当前爬虫的访问规则
+type Robots struct {
+	rules []robotsRule
+}
+
+// ParseRobots 解析robots.txt，优先使用与userAgent匹配的规则组，不存在时使用通配符*的规则组
+func ParseRobots(r io.Reader, userAgent string) *Robots {
+	userAgent = strings.ToLower(userAgent)
+
+	var (
+		matchedRules  []robotsRule
+		wildcardRules []robotsRule
+		matched       bool
+		// 当前规则组的User-agent列表，连续的User-agent行属于同一个规则组
+		agents      []string
+		inAgentList bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgentList {
+				agents = agents[:0]
+			}
+			agent := strings.ToLower(value)
+			agents = append(agents, agent)
+			inAgentList = true
+			// 存在与当前爬虫匹配的规则组时，即使规则组为空也不再使用通配符的规则组
+			if isAgentMatched(userAgent, agent) {
+				matched = true
+			}
+			continue
+		}
+		inAgentList = false
+		if key != "allow" && key != "disallow" {
+			continue
+		}
+		// 空的Disallow表示允许访问所有路径
+		if value == "" {
+			continue
+		}
+
+		rule := robotsRule{pattern: value, allow: key == "allow"}
+		if slices.Contains(agents, "*") {
+			wildcardRules = append(wildcardRules, rule)
+		}
+		if slices.ContainsFunc(agents, func(agent string) bool { return isAgentMatched(userAgent, agent) }) {
+			matchedRules = append(matchedRules, rule)
+		}
+	}
+
+	if matched {
+		return &Robots{rules: matchedRules}
+	}
+	return &Robots{rules: wildcardRules}
+}
+
+// isAgentMatched 判断规则组的User-agent是否指定了当前爬虫，通配符*不算作匹配
+func isAgentMatched(userAgent, agent string) bool {
+	return agent != "" && agent != "*" && strings.Contains(userAgent, agent)
+}
+
+// Allowed 判断路径是否允许访问，path需包含查询参数，最长匹配的规则生效，长度相同时Allow优先
+func (r *Robots) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+
+	allowed, matchedLength := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > matchedLength || (len(rule.pattern) == matchedLength && rule.allow) {
+			allowed, matchedLength = rule.allow, len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// matchRobotsPattern 使用robots.txt规则匹配路径，支持*通配任意字符以及$匹配路径结尾
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		// 结尾锚定时最后一段需要匹配路径的末尾
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		index := strings.Index(rest, part)
+		if index < 0 {
+			return false
+		}
+		rest = rest[index+len(part):]
+	}
+
+	return !anchored || rest == ""
+}
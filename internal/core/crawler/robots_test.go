@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRobots(t *testing.T) {
+	content := `# comment
+User-agent: *
+Disallow: /admin
+Allow: /admin/help
+
+User-agent: GoogleBot
+User-agent: voidxbot
+Disallow: /search # inline comment
+Disallow:
+
+Sitemap: https://example.com/sitemap.xml
+`
+
+	t.Run("matched group replaces wildcard group", func(t *testing.T) {
+		robots := ParseRobots(strings.NewReader(content), UserAgent)
+		assert.False(t, robots.Allowed("/search?q=1"))
+		assert.True(t, robots.Allowed("/admin"))
+	})
+
+	t.Run("wildcard group for other agents", func(t *testing.T) {
+		robots := ParseRobots(strings.NewReader(content), "OtherBot/2.0")
+		assert.False(t, robots.Allowed("/admin/users"))
+		assert.True(t, robots.Allowed("/admin/help"))
+		assert.True(t, robots.Allowed("/search"))
+	})
+
+	t.Run("empty matched group allows everything", func(t *testing.T) {
+		robots := ParseRobots(strings.NewReader("User-agent: *\nDisallow: /\n\nUser-agent: VoidxBot\n"), UserAgent)
+		assert.True(t, robots.Allowed("/"))
+	})
+
+	t.Run("nil robots allows everything", func(t *testing.T) {
+		var robots *Robots
+		assert.True(t, robots.Allowed("/anything"))
+	})
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	robots := &Robots{rules: []robotsRule{
+		{pattern: "/private", allow: false},
+		{pattern: "/private/public", allow: true},
+		{pattern: "/*.pdf$", allow: false},
+		{pattern: "/*?session=", allow: false},
+		{pattern: "/page", allow: false},
+		{pattern: "/page", allow: true},
+	}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/", want: true},
+		{path: "/private", want: false},
+		{path: "/private-notes", want: false},
+		{path: "/private/public/index.html", want: true},
+		{path: "/docs/manual.pdf", want: false},
+		{path: "/docs/manual.pdf?download=1", want: true},
+		{path: "/docs/manual.pdf.html", want: true},
+		{path: "/list?session=abc", want: false},
+		{path: "/list?page=2", want: true},
+		// 长度相同的规则Allow优先
+		{path: "/page/1", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, robots.Allowed(tt.path))
+		})
+	}
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/", path: "/anything", want: true},
+		{pattern: "/fish", path: "/fish.html", want: true},
+		{pattern: "/fish", path: "/Fish", want: false},
+		{pattern: "/fish*", path: "/fishheads/yummy", want: true},
+		{pattern: "/*.php", path: "/folder/index.php?a=1", want: true},
+		{pattern: "/*.php$", path: "/index.php", want: true},
+		{pattern: "/*.php$", path: "/index.php?a=1", want: false},
+		{pattern: "/fish*.php", path: "/fish/salmon.php", want: true},
+		{pattern: "/fish*.php", path: "/fish.PHP", want: false},
+		{pattern: "/a*b*c$", path: "/a-b-b-c", want: true},
+		{pattern: "/a*b*c$", path: "/a-b-c-d", want: false},
+		{pattern: "/exact$", path: "/exact", want: true},
+		{pattern: "/exact$", path: "/exactly", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchRobotsPattern(tt.pattern, tt.path))
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package crawler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sitemapDocument 同时兼容urlset与sitemapindex两种站点地图格式
+type sitemapDocument struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// ParseSitemap 解析站点地图，返回其中的页面地址以及嵌套的子站点地图地址
+func ParseSitemap(r io.Reader) ([]string, []string, error) {
+	var document sitemapDocument
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, nil, fmt.Errorf("解析站点地图失败: %w", err)
+	}
+
+	return trimLocations(document.URLs), trimLocations(document.Sitemaps), nil
+}
+
+// trimLocations 去除地址首尾的空白并过滤空地址
+func trimLocations(locations []string) []string {
+	result := make([]string, 0, len(locations))
+	for _, location := range locations {
+		if location = strings.TrimSpace(location); location != "" {
+			result = append(result, location)
+		}
+	}
+
+	return result
+}
@@ -8,19 +8,25 @@ import (
 	"time"
 
 	"github.com/cloudwego/eino/components/embedding"
-	"github.com/pkoukk/tiktoken-go"
 	"github.com/redis/go-redis/v9"
 
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 )
 
 type EmbeddingService struct {
-	cmd      redis.Cmdable
-	embedder embedding.Embedder
+	cmd       redis.Cmdable
+	embedder  embedding.Embedder
+	tokenizer llmentity.Tokenizer
 }
 
 func NewEmbeddingService(cmd redis.Cmdable, embedder embedding.Embedder) *EmbeddingService {
-	return &EmbeddingService{cmd: cmd, embedder: embedder}
+	return &EmbeddingService{
+		cmd:      cmd,
+		embedder: embedder,
+		// 分段的token数只用于切分与统计，统一使用OpenAI嵌入模型的编码(cl100k_base)计算
+		tokenizer: llmentity.NewTokenizer("openai", "text-embedding-3-small"),
+	}
 }
 
 func (s *EmbeddingService) Embeddings(ctx context.Context, query string) ([]float64, error) {
@@ -85,12 +91,7 @@ func (s *EmbeddingService) EmbedTexts(ctx context.Context, texts []string) ([][]
 }
 
 func (s *EmbeddingService) CalculateTokenCount(query string) int {
-	encoding, err := tiktoken.EncodingForModel("gpt-3.5")
-	if err != nil {
-		return -1
-	}
-
-	return len(encoding.EncodeOrdinary(query))
+	return s.tokenizer.CountTokens(query)
 }
 
 func cacheKey(query string) string {
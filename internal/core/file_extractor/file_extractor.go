@@ -12,6 +12,7 @@ import (
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/upload"
 )
@@ -59,6 +60,24 @@ func (f *FileExtractor) Load(ctx context.Context, uploadFile *entity.UploadFile,
 	return LoadFromFile(filePath, returnText, isUnstructured)
 }
 
+// LoadTable loads a CSV or XLSX file from UploadFile record as table rows
+func (f *FileExtractor) LoadTable(ctx context.Context, uploadFile *entity.UploadFile) (*table.Table, error) {
+	// Create a temporary directory
+	tempDir, err := os.MkdirTemp("", "file_extractor_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Download file from object storage
+	filePath := filepath.Join(tempDir, filepath.Base(uploadFile.Key))
+	if err := f.uploadSvc.DownloadFile(ctx, uploadFile.Key, filePath); err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	return table.ReadFile(filePath)
+}
+
 // LoadFromURL loads a file from URL and returns eino documents
 func LoadFromURL(url string, returnText bool) ([]*schema.Document, error) {
 	// Download file from URL
@@ -70,6 +70,25 @@ var DefaultModelParameterTemplate = map[DefaultModelParameterName]ModelParameter
 	},
 }
 
+// DefaultModelParameters returns the parameters of a model configured without a YAML file,
+// max_tokens is capped at the max output tokens when they are known
+func DefaultModelParameters(maxOutputTokens int) []ModelParameter {
+	names := []DefaultModelParameterName{
+		ParameterTemperature, ParameterTopP, ParameterPresencePenalty, ParameterFrequencyPenalty, ParameterMaxTokens,
+	}
+
+	parameters := make([]ModelParameter, 0, len(names))
+	for _, name := range names {
+		parameter := DefaultModelParameterTemplate[name]
+		if name == ParameterMaxTokens && maxOutputTokens > 0 {
+			parameter.Max = floatPtr(float64(maxOutputTokens))
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	return parameters
+}
+
 func floatPtr(f float64) *float64 {
 	return &f
 }
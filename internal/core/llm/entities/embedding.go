@@ -0,0 +1,14 @@
+package entities
+
+import (
+	"context"
+
+	"github.com/crazyfrankie/voidx/infra/contract/embedding"
+)
+
+// ConfigDimensions is the config key carrying the dimensions of an embedding model, it defaults
+// to the dimensions declared in the model's YAML file
+const ConfigDimensions = "dimensions"
+
+// EmbeddingFactory creates an embedder for a model of the provider with the given configuration
+type EmbeddingFactory func(ctx context.Context, modelName string, config map[string]any) (embedding.Embedder, error)
@@ -0,0 +1,419 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// ExtraKeyModel is the key of the response message extra naming the model that answered
+const ExtraKeyModel = "answered_by"
+
+const (
+	// MaxFallbackModels is the number of models a chain may fall back to after the primary one
+	MaxFallbackModels = 3
+
+	// maxBackoff caps the exponential backoff between two attempts
+	maxBackoff = 30 * time.Second
+)
+
+// ErrorClass groups provider errors that share a retry rule
+type ErrorClass string
+
+const (
+	ErrorClassRateLimit   ErrorClass = "rate_limit"
+	ErrorClassServerError ErrorClass = "server_error"
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassOther       ErrorClass = "other"
+)
+
+var (
+	statusCodePattern = regexp.MustCompile(`(?i)status(?:\s*code)?\s*[:=]?\s*(\d{3})`)
+	serverCodePattern = regexp.MustCompile(`\b5\d\d\b`)
+)
+
+// ClassifyError sorts a provider error into an error class. The SDKs behind the providers have
+// no common error type, so besides timeouts the class is read from the status code in the message.
+func ClassifyError(err error) ErrorClass {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+
+	message := strings.ToLower(err.Error())
+	if match := statusCodePattern.FindStringSubmatch(message); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		switch {
+		case code == 429:
+			return ErrorClassRateLimit
+		case code == 408 || code == 504:
+			return ErrorClassTimeout
+		case code >= 500:
+			return ErrorClassServerError
+		default:
+			return ErrorClassOther
+		}
+	}
+
+	switch {
+	case strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests") || strings.Contains(message, "429"):
+		return ErrorClassRateLimit
+	case strings.Contains(message, "timeout") || strings.Contains(message, "timed out"):
+		return ErrorClassTimeout
+	case serverCodePattern.MatchString(message):
+		return ErrorClassServerError
+	default:
+		return ErrorClassOther
+	}
+}
+
+// RetryRule is how often a model is retried for an error class before falling back to the next one
+type RetryRule struct {
+	MaxRetries int `json:"max_retries"`
+	// BackoffMs is the wait before the first retry, it doubles with every further retry
+	BackoffMs int `json:"backoff_ms"`
+}
+
+// backoff returns the wait before the given retry, counted from zero
+func (r RetryRule) backoff(retry int) time.Duration {
+	wait := time.Duration(r.BackoffMs) * time.Millisecond
+	for i := 0; i < retry && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, maxBackoff)
+}
+
+// RetryPolicy holds the retry rule of every error class and the timeout of a single attempt
+type RetryPolicy struct {
+	RateLimit   RetryRule `json:"rate_limit"`
+	ServerError RetryRule `json:"server_error"`
+	Timeout     RetryRule `json:"timeout"`
+	Other       RetryRule `json:"other"`
+
+	// TimeoutMs limits a single attempt, for a stream only the wait for its first chunk; zero means no limit
+	TimeoutMs int `json:"timeout_ms"`
+}
+
+// DefaultRetryPolicy returns the policy used when the model config has none
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		RateLimit:   RetryRule{MaxRetries: 2, BackoffMs: 1000},
+		ServerError: RetryRule{MaxRetries: 1, BackoffMs: 500},
+		Timeout:     RetryRule{MaxRetries: 1},
+		Other:       RetryRule{},
+		TimeoutMs:   60000,
+	}
+}
+
+// Validate checks that the policy stays within sensible bounds
+func (p RetryPolicy) Validate() error {
+	for class, rule := range map[ErrorClass]RetryRule{
+		ErrorClassRateLimit:   p.RateLimit,
+		ErrorClassServerError: p.ServerError,
+		ErrorClassTimeout:     p.Timeout,
+		ErrorClassOther:       p.Other,
+	} {
+		if rule.MaxRetries < 0 || rule.MaxRetries > 5 {
+			return fmt.Errorf("max_retries of %s must be between 0 and 5", class)
+		}
+		if rule.BackoffMs < 0 || rule.BackoffMs > int(maxBackoff/time.Millisecond) {
+			return fmt.Errorf("backoff_ms of %s must be between 0 and %d", class, maxBackoff/time.Millisecond)
+		}
+	}
+	if p.TimeoutMs < 0 || p.TimeoutMs > 600000 {
+		return errors.New("timeout_ms must be between 0 and 600000")
+	}
+
+	return nil
+}
+
+// ParseRetryPolicy reads the retry policy of a model config, whatever it leaves out keeps the default
+func ParseRetryPolicy(value any) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+	if value == nil {
+		return policy, nil
+	}
+
+	data, err := sonic.Marshal(value)
+	if err != nil {
+		return DefaultRetryPolicy(), err
+	}
+	if err := sonic.Unmarshal(data, &policy); err != nil {
+		return DefaultRetryPolicy(), err
+	}
+	if err := policy.Validate(); err != nil {
+		return DefaultRetryPolicy(), err
+	}
+
+	return policy, nil
+}
+
+// Rule returns the retry rule of an error class
+func (p RetryPolicy) Rule(class ErrorClass) RetryRule {
+	switch class {
+	case ErrorClassRateLimit:
+		return p.RateLimit
+	case ErrorClassServerError:
+		return p.ServerError
+	case ErrorClassTimeout:
+		return p.Timeout
+	default:
+		return p.Other
+	}
+}
+
+// FallbackCandidate is a model of a fallback chain and the name it is recorded with
+type FallbackCandidate struct {
+	Name  string
+	Model BaseLanguageModel
+}
+
+// FallbackModel calls an ordered chain of models. A failing call is retried on the same model as
+// the rule of its error class allows, then handed to the next model of the chain. The response
+// carries the name of the model that answered in its extra under ExtraKeyModel.
+type FallbackModel struct {
+	candidates []FallbackCandidate
+	policy     RetryPolicy
+
+	mu       sync.RWMutex
+	answered int
+}
+
+// NewFallbackModel creates a fallback chain, the first candidate is the primary model
+func NewFallbackModel(candidates []FallbackCandidate, policy RetryPolicy) *FallbackModel {
+	return &FallbackModel{
+		candidates: candidates,
+		policy:     policy,
+	}
+}
+
+// Generate returns the response of the first model of the chain that answers
+func (f *FallbackModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var response *schema.Message
+	err := f.call(ctx, func(ctx context.Context, candidate FallbackCandidate) error {
+		msg, err := candidate.Model.Generate(ctx, input, opts...)
+		if err != nil {
+			return err
+		}
+
+		response = tagModel(msg, candidate.Name)
+		return nil
+	}, true)
+
+	return response, err
+}
+
+// Stream returns the stream of the first model of the chain whose stream starts. A model only
+// falls back before its first chunk, an error in the middle of a stream ends it.
+func (f *FallbackModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	var stream *schema.StreamReader[*schema.Message]
+	err := f.call(ctx, func(ctx context.Context, candidate FallbackCandidate) error {
+		attemptCtx, cancel := context.WithCancel(ctx)
+
+		// The attempt times out while waiting for the first chunk only
+		var timer *time.Timer
+		if f.policy.TimeoutMs > 0 {
+			timer = time.AfterFunc(time.Duration(f.policy.TimeoutMs)*time.Millisecond, cancel)
+		}
+
+		reader, err := candidate.Model.Stream(attemptCtx, input, opts...)
+		var first *schema.Message
+		if err == nil {
+			first, err = reader.Recv()
+		}
+		if timer != nil && !timer.Stop() {
+			err = context.DeadlineExceeded
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			if reader != nil {
+				reader.Close()
+			}
+			cancel()
+			return err
+		}
+
+		stream = relayStream(reader, tagModel(first, candidate.Name), err, cancel)
+		return nil
+	}, false)
+
+	return stream, err
+}
+
+// call runs the attempt on every candidate in order until one succeeds
+func (f *FallbackModel) call(ctx context.Context, attempt func(ctx context.Context, candidate FallbackCandidate) error, withTimeout bool) error {
+	errs := make([]error, 0, len(f.candidates))
+	for i, candidate := range f.candidates {
+		for retry := 0; ; retry++ {
+			attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+			if withTimeout && f.policy.TimeoutMs > 0 {
+				attemptCtx, cancel = context.WithTimeout(ctx, time.Duration(f.policy.TimeoutMs)*time.Millisecond)
+			}
+			err := attempt(attemptCtx, candidate)
+			cancel()
+
+			if err == nil {
+				f.mu.Lock()
+				f.answered = i
+				f.mu.Unlock()
+				return nil
+			}
+			if ctx.Err() != nil {
+				return err
+			}
+
+			rule := f.policy.Rule(ClassifyError(err))
+			if retry >= rule.MaxRetries {
+				errs = append(errs, fmt.Errorf("%s: %w", candidate.Name, err))
+				break
+			}
+
+			select {
+			case <-time.After(rule.backoff(retry)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return fmt.Errorf("all models of the fallback chain failed: %w", errors.Join(errs...))
+}
+
+// relayStream replays the first chunk, or the error ending the stream at once, before the rest
+// of the stream and releases the attempt once the stream has ended
+func relayStream(reader *schema.StreamReader[*schema.Message], first *schema.Message, firstErr error, release context.CancelFunc) *schema.StreamReader[*schema.Message] {
+	out, writer := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer release()
+		defer writer.Close()
+		defer reader.Close()
+
+		if firstErr != nil {
+			return
+		}
+		if writer.Send(first, nil) {
+			return
+		}
+		for {
+			chunk, err := reader.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if writer.Send(chunk, err) || err != nil {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// tagModel records the model that answered in the extra of the response
+func tagModel(msg *schema.Message, name string) *schema.Message {
+	if msg == nil {
+		return nil
+	}
+	if msg.Extra == nil {
+		msg.Extra = make(map[string]any, 1)
+	}
+	msg.Extra[ExtraKeyModel] = name
+
+	return msg
+}
+
+// AnsweredModel returns the name of the model that answered, empty when it is unknown
+func AnsweredModel(msg *schema.Message) string {
+	if msg == nil {
+		return ""
+	}
+	name, _ := msg.Extra[ExtraKeyModel].(string)
+	return name
+}
+
+// current returns the model that answered last, the primary model before any call
+func (f *FallbackModel) current() BaseLanguageModel {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.candidates[f.answered].Model
+}
+
+// GetFeatures returns the features of the primary model, the agent is chosen for it
+func (f *FallbackModel) GetFeatures() []ModelFeature {
+	return f.candidates[0].Model.GetFeatures()
+}
+
+// GetMetadata returns the metadata of the model that answered last
+func (f *FallbackModel) GetMetadata() map[string]any {
+	return f.current().GetMetadata()
+}
+
+// GetPricing returns the pricing of the model that answered last
+func (f *FallbackModel) GetPricing() (float64, float64, float64) {
+	return f.current().GetPricing()
+}
+
+// GetTokenizer returns the tokenizer of the model that answered last
+func (f *FallbackModel) GetTokenizer() Tokenizer {
+	return f.current().GetTokenizer()
+}
+
+// GetContextWindow returns the smallest known context window of the chain, so a prompt that fits
+// the primary model also fits the models it falls back to
+func (f *FallbackModel) GetContextWindow() (int, int) {
+	contextWindow, maxOutputTokens := f.candidates[0].Model.GetContextWindow()
+	for _, candidate := range f.candidates[1:] {
+		window, maxOutput := candidate.Model.GetContextWindow()
+		if window > 0 && (contextWindow == 0 || window < contextWindow) {
+			contextWindow, maxOutputTokens = window, maxOutput
+		}
+	}
+
+	return contextWindow, maxOutputTokens
+}
+
+// ConvertToHumanMessage converts the query with the primary model
+func (f *FallbackModel) ConvertToHumanMessage(query string, imageURLs []string) *schema.Message {
+	return f.candidates[0].Model.ConvertToHumanMessage(query, imageURLs)
+}
+
+// WithResponseFormat returns a chain of the models that support the response format
+func (f *FallbackModel) WithResponseFormat(ctx context.Context, format *ResponseFormat) (BaseLanguageModel, error) {
+	candidates := make([]FallbackCandidate, 0, len(f.candidates))
+	for _, candidate := range f.candidates {
+		formatModel, ok := candidate.Model.(interface {
+			WithResponseFormat(ctx context.Context, format *ResponseFormat) (BaseLanguageModel, error)
+		})
+		if !ok {
+			continue
+		}
+		jsonModel, err := formatModel.WithResponseFormat(ctx, format)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, FallbackCandidate{Name: candidate.Name, Model: jsonModel})
+	}
+	if len(candidates) == 0 {
+		return nil, NotSupportedError("response format is not supported by any model of the fallback chain")
+	}
+
+	return NewFallbackModel(candidates, f.policy), nil
+}
@@ -14,6 +14,8 @@ type ModelType string
 const (
 	ModelTypeChat       ModelType = "chat"
 	ModelTypeCompletion ModelType = "completion"
+	ModelTypeEmbedding  ModelType = "embedding"
+	ModelTypeRerank     ModelType = "rerank"
 )
 
 // ModelFeature represents capabilities supported by a model
@@ -75,11 +77,16 @@ type ModelEntity struct {
 	Features        []ModelFeature   `json:"features" yaml:"features"`
 	ContextWindow   int              `json:"context_window" yaml:"context_window"`
 	MaxOutputTokens int              `json:"max_output_tokens" yaml:"max_output_tokens"`
+	Dimensions      int              `json:"dimensions,omitempty" yaml:"dimensions"`
 	Attributes      map[string]any   `json:"attributes" yaml:"attributes"`
 	Parameters      []ModelParameter `json:"parameters" yaml:"parameters"`
 	Metadata        map[string]any   `json:"metadata" yaml:"metadata"`
 }
 
+// ProviderTypeOpenAICompatible is the type of providers registered by accounts for services
+// speaking the OpenAI API at their own base URL
+const ProviderTypeOpenAICompatible = "openai_compatible"
+
 // ProviderEntity represents a language model provider configuration
 type ProviderEntity struct {
 	Name                string      `json:"name" yaml:"name"`
@@ -90,20 +97,40 @@ type ProviderEntity struct {
 	SupportedModelTypes []ModelType `json:"supported_model_types" yaml:"supported_model_types"`
 }
 
+// ConfigResponseFormat is the model config key carrying a *ResponseFormat
+const ConfigResponseFormat = "response_format"
+
+// ResponseFormat asks a model to answer with a JSON object, providers that accept a schema
+// are constrained by it and the others are guided by the prompt only
+type ResponseFormat struct {
+	Schema map[string]any `json:"schema"`
+}
+
 // BaseLanguageModel is the interface that wraps eino's ChatModel with additional features
 type BaseLanguageModel interface {
 	model.BaseChatModel
 	GetFeatures() []ModelFeature
 	GetMetadata() map[string]any
 	GetPricing() (float64, float64, float64) // input_price, output_price, unit
+	GetTokenizer() Tokenizer
+	GetContextWindow() (int, int) // context_window, max_output_tokens
 	ConvertToHumanMessage(query string, imageURLs []string) *schema.Message
 }
 
 // LLMModel wraps an eino ChatModel to implement BaseLanguageModel
 type LLMModel struct {
 	model.BaseChatModel
-	features []ModelFeature
-	metadata map[string]any
+	features  []ModelFeature
+	metadata  map[string]any
+	tokenizer Tokenizer
+
+	contextWindow   int
+	maxOutputTokens int
+
+	// config and rebuild recreate the model with a response format,
+	// rebuild is nil when the provider has no JSON output mode
+	config  map[string]any
+	rebuild func(ctx context.Context, config map[string]any) (BaseLanguageModel, error)
 }
 
 // NewLLMModel creates a new wrapper for eino ChatModel
@@ -132,11 +159,69 @@ func (w *LLMModel) GetPricing() (float64, float64, float64) {
 		return 0.0, 0.0, 0.0
 	}
 
-	input, _ := pricing["input"].(float64)
-	output, _ := pricing["output"].(float64)
-	unit, _ := pricing["unit"].(float64)
+	return toFloat(pricing["input"]), toFloat(pricing["output"]), toFloat(pricing["unit"])
+}
+
+// SetTokenizer sets the tokenizer used to count tokens when the provider reports no usage
+func (w *LLMModel) SetTokenizer(tokenizer Tokenizer) {
+	w.tokenizer = tokenizer
+}
+
+// GetTokenizer returns the fallback tokenizer of the model
+func (w *LLMModel) GetTokenizer() Tokenizer {
+	if w.tokenizer == nil {
+		return estimateTokenizer{}
+	}
+	return w.tokenizer
+}
+
+// SetContextWindow sets the context window of the model and the tokens reserved for its output
+func (w *LLMModel) SetContextWindow(contextWindow, maxOutputTokens int) {
+	w.contextWindow = contextWindow
+	w.maxOutputTokens = maxOutputTokens
+}
+
+// GetContextWindow returns the context window of the model and the tokens reserved for its output,
+// a zero context window means it is unknown
+func (w *LLMModel) GetContextWindow() (int, int) {
+	return w.contextWindow, w.maxOutputTokens
+}
+
+// SetRebuilder sets the config the model was created with and the function recreating it
+func (w *LLMModel) SetRebuilder(config map[string]any, rebuild func(ctx context.Context, config map[string]any) (BaseLanguageModel, error)) {
+	w.config = config
+	w.rebuild = rebuild
+}
+
+// WithResponseFormat returns a copy of the model answering with a JSON object in the given format
+func (w *LLMModel) WithResponseFormat(ctx context.Context, format *ResponseFormat) (BaseLanguageModel, error) {
+	if w.rebuild == nil {
+		return nil, NotSupportedError("response format is not supported by the provider")
+	}
+
+	config := make(map[string]any, len(w.config)+1)
+	for k, v := range w.config {
+		config[k] = v
+	}
+	config[ConfigResponseFormat] = format
 
-	return input, output, unit
+	return w.rebuild(ctx, config)
+}
+
+// toFloat converts the numeric values decoded from yaml/json to float64
+func toFloat(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return 0.0
+	}
 }
 
 // ConvertToHumanMessage converts query and image URLs to human messages
@@ -170,7 +255,12 @@ func (w *LLMModel) ConvertToHumanMessage(query string, imageURLs []string) *sche
 
 // hasFeature checks if the model has a specific feature
 func (w *LLMModel) hasFeature(feature ModelFeature) bool {
-	for _, f := range w.features {
+	return HasFeature(w.features, feature)
+}
+
+// HasFeature checks if the features contain a specific feature
+func HasFeature(features []ModelFeature, feature ModelFeature) bool {
+	for _, f := range features {
 		if f == feature {
 			return true
 		}
@@ -178,6 +268,16 @@ func (w *LLMModel) hasFeature(feature ModelFeature) bool {
 	return false
 }
 
+// SupportsToolCall checks if the features allow native tool calling
+func SupportsToolCall(features []ModelFeature) bool {
+	return HasFeature(features, FeatureToolCall) || HasFeature(features, FeatureFunctionCall)
+}
+
+// SupportsTools checks if a model with the features can use tools, natively or by ReACT prompting
+func SupportsTools(features []ModelFeature) bool {
+	return SupportsToolCall(features) || HasFeature(features, FeatureAgentThought)
+}
+
 // ModelFactory is a function type for creating language models
 type ModelFactory func(ctx context.Context, modelName string, config map[string]any) (BaseLanguageModel, error)
 
@@ -207,6 +307,14 @@ func InvalidConfigError(message string) *LanguageModelError {
 	}
 }
 
+// NotSupportedError creates a not supported error
+func NotSupportedError(message string) *LanguageModelError {
+	return &LanguageModelError{
+		Message: message,
+		Code:    "NOT_SUPPORTED",
+	}
+}
+
 // Provider represents a simplified provider for service layer
 type Provider struct {
 	Name           string         `json:"name"`
@@ -0,0 +1,10 @@
+package entities
+
+import (
+	"context"
+
+	"github.com/crazyfrankie/voidx/infra/contract/rerank"
+)
+
+// RerankFactory creates a reranker for a rerank model of the provider with the given configuration
+type RerankFactory func(ctx context.Context, modelName string, config map[string]any) (rerank.Reranker, error)
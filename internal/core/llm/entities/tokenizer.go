@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// Tokenizer counts tokens locally, it is the fallback when a provider response carries no usage
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// providerEncodings maps each provider to the tiktoken encoding closest to its own tokenizer,
+// providers whose vocabulary is not public are approximated with cl100k_base
+var providerEncodings = map[string]string{
+	"openai":   "o200k_base",
+	"moonshot": "cl100k_base",
+	"deepseek": "cl100k_base",
+	"tongyi":   "cl100k_base",
+	"wenxin":   "cl100k_base",
+	"ollama":   "cl100k_base",
+
+	ProviderTypeOpenAICompatible: "cl100k_base",
+}
+
+// encodings caches the loaded tiktoken encodings by name, building one is expensive
+var encodings sync.Map
+
+// NewTokenizer returns the fallback tokenizer of the given provider and model,
+// the encoding is loaded lazily on first use
+func NewTokenizer(provider, modelName string) Tokenizer {
+	return &lazyTokenizer{provider: provider, modelName: modelName}
+}
+
+// lazyTokenizer resolves the underlying tokenizer on first use
+type lazyTokenizer struct {
+	provider  string
+	modelName string
+	once      sync.Once
+	tokenizer Tokenizer
+}
+
+func (t *lazyTokenizer) CountTokens(text string) int {
+	t.once.Do(func() {
+		t.tokenizer = resolveTokenizer(t.provider, t.modelName)
+	})
+
+	return t.tokenizer.CountTokens(text)
+}
+
+// resolveTokenizer picks the most accurate tokenizer available for the provider and model
+func resolveTokenizer(provider, modelName string) Tokenizer {
+	// 1. OpenAI models use the exact encoding of the model when tiktoken knows it
+	encodingName := ""
+	if provider == "openai" {
+		encodingName = encodingNameForModel(modelName)
+	}
+
+	// 2. Other providers use the encoding configured for the provider
+	if encodingName == "" {
+		name, ok := providerEncodings[provider]
+		if !ok {
+			name = "cl100k_base"
+		}
+		encodingName = name
+	}
+	if encoding, err := getEncoding(encodingName); err == nil {
+		return &tiktokenTokenizer{encoding: encoding}
+	}
+
+	// 3. The encoding files could not be loaded, fall back to estimating by characters
+	return estimateTokenizer{}
+}
+
+// encodingNameForModel returns the tiktoken encoding name of an OpenAI model, empty when unknown
+func encodingNameForModel(modelName string) string {
+	if encodingName, ok := tiktoken.MODEL_TO_ENCODING[modelName]; ok {
+		return encodingName
+	}
+	for prefix, encodingName := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(modelName, prefix) {
+			return encodingName
+		}
+	}
+
+	return ""
+}
+
+func getEncoding(encodingName string) (*tiktoken.Tiktoken, error) {
+	if encoding, ok := encodings.Load(encodingName); ok {
+		return encoding.(*tiktoken.Tiktoken), nil
+	}
+
+	encoding, err := tiktoken.GetEncoding(encodingName)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := encodings.LoadOrStore(encodingName, encoding)
+
+	return actual.(*tiktoken.Tiktoken), nil
+}
+
+// tiktokenTokenizer counts tokens with a tiktoken encoding
+type tiktokenTokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+func (t *tiktokenTokenizer) CountTokens(text string) int {
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// estimateTokenizer estimates tokens without a vocabulary: one token per CJK character
+// and roughly one token per four other characters
+type estimateTokenizer struct{}
+
+func (estimateTokenizer) CountTokens(text string) int {
+	var cjk, others int
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			others++
+		}
+	}
+
+	return cjk + (others+3)/4
+}
+
+// CountMessagesTokens counts the tokens of a prompt, following OpenAI's chat format
+// every message costs 4 extra tokens and every reply is primed with 3 tokens
+func CountMessagesTokens(tokenizer Tokenizer, messages []*schema.Message) int {
+	if len(messages) == 0 {
+		return 0
+	}
+
+	total := 3
+	for _, msg := range messages {
+		total += countMessageTokens(tokenizer, msg)
+	}
+
+	return total
+}
+
+func countMessageTokens(tokenizer Tokenizer, msg *schema.Message) int {
+	tokens := 4 + tokenizer.CountTokens(msg.Content)
+	for _, part := range msg.MultiContent {
+		if part.Type == schema.ChatMessagePartTypeText {
+			tokens += tokenizer.CountTokens(part.Text)
+		}
+	}
+	for _, toolCall := range msg.ToolCalls {
+		tokens += tokenizer.CountTokens(toolCall.Function.Name)
+		tokens += tokenizer.CountTokens(toolCall.Function.Arguments)
+	}
+
+	return tokens
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"github.com/cloudwego/eino/schema"
+)
+
+// TokenUsage represents the token usage of a single LLM call
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	CachedTokens     int `json:"cached_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// UsageFromMessage extracts the usage reported by the provider in the response meta,
+// the second return value is false when the provider did not report any usage
+func UsageFromMessage(msg *schema.Message) (TokenUsage, bool) {
+	if msg == nil || msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+		return TokenUsage{}, false
+	}
+
+	usage := msg.ResponseMeta.Usage
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 && usage.TotalTokens == 0 {
+		return TokenUsage{}, false
+	}
+
+	totalTokens := usage.TotalTokens
+	if totalTokens == 0 {
+		totalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	return TokenUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		CachedTokens:     usage.PromptTokenDetails.CachedTokens,
+		TotalTokens:      totalTokens,
+	}, true
+}
+
+// EstimateUsage counts the usage of an LLM call locally with the given tokenizer,
+// used when the provider response carries no usage
+func EstimateUsage(tokenizer Tokenizer, messages []*schema.Message, response *schema.Message) TokenUsage {
+	promptTokens := CountMessagesTokens(tokenizer, messages)
+	completionTokens := 0
+	if response != nil {
+		completionTokens = countMessageTokens(tokenizer, response)
+	}
+
+	return TokenUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// CalculatePrice calculates the price of the usage, input and output prices are per unit tokens
+func (u TokenUsage) CalculatePrice(inputPrice, outputPrice, unit float64) float64 {
+	if unit <= 0 {
+		return 0
+	}
+
+	return (float64(u.PromptTokens)*inputPrice + float64(u.CompletionTokens)*outputPrice) / unit
+}
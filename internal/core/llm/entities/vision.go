@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+const describeImagesPrompt = `请详细描述下面的图片内容，包括其中的文字、物体、场景以及与问题相关的细节，多张图片请按顺序分别描述。
+用户的问题是：%s`
+
+// HumanMessage converts the query and images to a human message for the model. A model without
+// image input gets the images described to text by the vision model instead, the images are
+// dropped when there is no vision model either.
+func HumanMessage(ctx context.Context, llm BaseLanguageModel, vision BaseLanguageModel, query string, imageURLs []string) (*schema.Message, error) {
+	if len(imageURLs) == 0 || HasFeature(llm.GetFeatures(), FeatureImageInput) {
+		return llm.ConvertToHumanMessage(query, imageURLs), nil
+	}
+	if vision == nil {
+		return schema.UserMessage(query), nil
+	}
+
+	description, err := DescribeImages(ctx, vision, query, imageURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.UserMessage(fmt.Sprintf("%s\n\n<image_description>\n%s\n</image_description>", query, description)), nil
+}
+
+// DescribeImages asks the vision model to describe the images with regard to the query
+func DescribeImages(ctx context.Context, vision BaseLanguageModel, query string, imageURLs []string) (string, error) {
+	if !HasFeature(vision.GetFeatures(), FeatureImageInput) {
+		return "", NotSupportedError("vision model does not support image input")
+	}
+
+	msg, err := vision.Generate(ctx, []*schema.Message{
+		vision.ConvertToHumanMessage(fmt.Sprintf(describeImagesPrompt, query), imageURLs),
+	})
+	if err != nil {
+		return "", fmt.Errorf("describe images failed: %w", err)
+	}
+
+	return strings.TrimSpace(msg.Content), nil
+}
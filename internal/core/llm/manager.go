@@ -2,21 +2,46 @@ package llm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
+	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 
+	"github.com/crazyfrankie/voidx/infra/contract/embedding"
+	"github.com/crazyfrankie/voidx/infra/contract/rerank"
 	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	"github.com/crazyfrankie/voidx/internal/core/llm/provider"
 )
 
+// ProviderLoader resolves providers that are not built in, such as those registered by accounts
+type ProviderLoader interface {
+	// LoadProvider returns the provider with the name, or a not found error
+	LoadProvider(name string) (*provider.Provider, error)
+}
+
+// CredentialResolver resolves the credentials an account uses for a provider
+type CredentialResolver interface {
+	// ResolveCredentials returns the credentials such as api_key and base_url, or an error when
+	// the account has none and may not fall back to the system defaults
+	ResolveCredentials(ctx context.Context, accountID uuid.UUID, providerName string) (map[string]any, error)
+}
+
 // LanguageModelManager manages all language model providers and their models
 type LanguageModelManager struct {
 	providerMap map[string]*provider.Provider
+	loader      ProviderLoader
+	resolver    CredentialResolver
 	mu          sync.RWMutex
+
+	// embedders caches the embedders by provider, model and credentials, since some of them
+	// probe their service when created
+	embedders sync.Map
 }
 
 // NewLanguageModelManager creates a new language model manager instance
@@ -67,17 +92,51 @@ func (lmm *LanguageModelManager) initialize() error {
 	return nil
 }
 
-// GetProvider returns a provider by name
-func (lmm *LanguageModelManager) GetProvider(providerName string) (*provider.Provider, error) {
+// SetProviderLoader sets the loader consulted for providers that are not built in
+func (lmm *LanguageModelManager) SetProviderLoader(loader ProviderLoader) {
+	lmm.mu.Lock()
+	defer lmm.mu.Unlock()
+
+	lmm.loader = loader
+}
+
+// SetCredentialResolver sets the resolver of the credentials used by CreateModelForAccount
+func (lmm *LanguageModelManager) SetCredentialResolver(resolver CredentialResolver) {
+	lmm.mu.Lock()
+	defer lmm.mu.Unlock()
+
+	lmm.resolver = resolver
+}
+
+// ResolveCredentials returns the credentials the account uses for the provider, for callers that talk
+// to the provider's service directly instead of through a model. Without a resolver there are none.
+func (lmm *LanguageModelManager) ResolveCredentials(ctx context.Context, accountID uuid.UUID, providerName string) (map[string]any, error) {
 	lmm.mu.RLock()
-	defer lmm.mu.RUnlock()
+	resolver := lmm.resolver
+	lmm.mu.RUnlock()
+
+	if resolver == nil {
+		return map[string]any{}, nil
+	}
+
+	return resolver.ResolveCredentials(ctx, accountID, providerName)
+}
 
+// GetProvider returns a provider by name, built-in providers take precedence over loaded ones
+func (lmm *LanguageModelManager) GetProvider(providerName string) (*provider.Provider, error) {
+	lmm.mu.RLock()
 	pv, exists := lmm.providerMap[providerName]
-	if !exists {
-		return nil, entities.NotFoundError("该模型服务提供商不存在，请核实后重试")
+	loader := lmm.loader
+	lmm.mu.RUnlock()
+
+	if exists {
+		return pv, nil
+	}
+	if loader != nil {
+		return loader.LoadProvider(providerName)
 	}
 
-	return pv, nil
+	return nil, entities.NotFoundError("该模型服务提供商不存在，请核实后重试")
 }
 
 // GetProviders returns all available providers
@@ -131,6 +190,153 @@ func (lmm *LanguageModelManager) CreateModel(ctx context.Context, providerName s
 	return pv.CreateModel(ctx, modelName, config)
 }
 
+// CreateModelForAccount creates a language model instance with the credentials of the account,
+// the resolved credentials take precedence over those in the config
+func (lmm *LanguageModelManager) CreateModelForAccount(ctx context.Context, accountID uuid.UUID, providerName string, modelName string, config map[string]any) (entities.BaseLanguageModel, error) {
+	lmm.mu.RLock()
+	resolver := lmm.resolver
+	lmm.mu.RUnlock()
+
+	if resolver == nil {
+		return lmm.CreateModel(ctx, providerName, modelName, config)
+	}
+
+	credentials, err := resolver.ResolveCredentials(ctx, accountID, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	withCredentials := make(map[string]any, len(config)+len(credentials))
+	for k, v := range config {
+		withCredentials[k] = v
+	}
+	for k, v := range credentials {
+		withCredentials[k] = v
+	}
+
+	return lmm.CreateModel(ctx, providerName, modelName, withCredentials)
+}
+
+// CreateEmbedderForAccount creates an embedder for an embedding model with the credentials of the
+// account, embedders created with the same credentials are shared
+func (lmm *LanguageModelManager) CreateEmbedderForAccount(ctx context.Context, accountID uuid.UUID, providerName string, modelName string) (embedding.Embedder, error) {
+	pv, err := lmm.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	lmm.mu.RLock()
+	resolver := lmm.resolver
+	lmm.mu.RUnlock()
+
+	credentials := map[string]any{}
+	if resolver != nil {
+		if credentials, err = resolver.ResolveCredentials(ctx, accountID, providerName); err != nil {
+			return nil, err
+		}
+	}
+
+	key := embedderKey(providerName, modelName, credentials)
+	if embedder, ok := lmm.embedders.Load(key); ok {
+		return embedder.(embedding.Embedder), nil
+	}
+
+	embedder, err := pv.CreateEmbedder(ctx, modelName, credentials)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := lmm.embedders.LoadOrStore(key, embedder)
+
+	return actual.(embedding.Embedder), nil
+}
+
+// CreateRerankerForAccount creates a reranker for a rerank or chat model with the credentials of
+// the account
+func (lmm *LanguageModelManager) CreateRerankerForAccount(ctx context.Context, accountID uuid.UUID, providerName string, modelName string) (rerank.Reranker, error) {
+	pv, err := lmm.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	lmm.mu.RLock()
+	resolver := lmm.resolver
+	lmm.mu.RUnlock()
+
+	credentials := map[string]any{}
+	if resolver != nil {
+		if credentials, err = resolver.ResolveCredentials(ctx, accountID, providerName); err != nil {
+			return nil, err
+		}
+	}
+
+	return pv.CreateReranker(ctx, modelName, credentials)
+}
+
+// embedderKey identifies an embedder by its provider, model and credentials without keeping
+// the credentials in the key
+func embedderKey(providerName string, modelName string, credentials map[string]any) string {
+	keys := make([]string, 0, len(credentials))
+	for k := range credentials {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", providerName, modelName)
+	for _, k := range keys {
+		fmt.Fprintf(h, "\x00%s=%v", k, credentials[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ModelSpec names a model of a provider and the parameters it is created with
+type ModelSpec struct {
+	Provider   string
+	Model      string
+	Parameters map[string]any
+}
+
+// CreateFallbackModelForAccount creates a fallback chain of the models with the credentials of the
+// account, the first spec is the primary model. A model that cannot be created is left out of the
+// chain, an error is returned only when none can be created.
+func (lmm *LanguageModelManager) CreateFallbackModelForAccount(ctx context.Context, accountID uuid.UUID, specs []ModelSpec, policy entities.RetryPolicy) (entities.BaseLanguageModel, error) {
+	candidates := make([]entities.FallbackCandidate, 0, len(specs))
+	var firstErr error
+	for _, spec := range specs {
+		llm, err := lmm.CreateModelForAccount(ctx, accountID, spec.Provider, spec.Model, spec.Parameters)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		candidates = append(candidates, entities.FallbackCandidate{
+			Name:  spec.Provider + "/" + spec.Model,
+			Model: llm,
+		})
+	}
+	if len(candidates) == 0 {
+		if firstErr == nil {
+			firstErr = entities.InvalidConfigError("no model to create")
+		}
+		return nil, firstErr
+	}
+
+	return entities.NewFallbackModel(candidates, policy), nil
+}
+
+// ValidateCredentials checks the credentials of a provider with a minimal model call
+func (lmm *LanguageModelManager) ValidateCredentials(ctx context.Context, providerName string, credentials map[string]any) error {
+	pv, err := lmm.GetProvider(providerName)
+	if err != nil {
+		return err
+	}
+
+	return pv.ValidateCredentials(ctx, credentials)
+}
+
 // GetModelEntity returns a model entity by provider and model name
 func (lmm *LanguageModelManager) GetModelEntity(providerName string, modelName string) (*entities.ModelEntity, error) {
 	pv, err := lmm.GetProvider(providerName)
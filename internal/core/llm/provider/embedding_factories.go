@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	opt "github.com/cloudwego/eino/components/embedding"
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/crazyfrankie/voidx/infra/contract/embedding"
+	httpembedding "github.com/crazyfrankie/voidx/infra/impl/embedding"
+	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/pkg/lang/slices"
+)
+
+// ProviderHTTPEmbedding is the provider of the self-hosted HTTP embedding service
+const ProviderHTTPEmbedding = "http_embedding"
+
+const embeddingBatchSize = 100
+
+// getEmbeddingFactory returns the embedding factory of the provider
+func getEmbeddingFactory(providerName string) (entities.EmbeddingFactory, error) {
+	switch providerName {
+	case "openai":
+		return GetOpenAIEmbeddingFactory("", true), nil
+	case "tongyi":
+		return GetOpenAIEmbeddingFactory("https://dashscope.aliyuncs.com/compatible-mode/v1", true), nil
+	case "ollama":
+		return GetOllamaEmbeddingFactory(), nil
+	case ProviderHTTPEmbedding:
+		return GetHTTPEmbeddingFactory(), nil
+	default:
+		return nil, entities.NotFoundError(fmt.Sprintf("unsupported embedding provider: %s", providerName))
+	}
+}
+
+// GetOpenAIEmbeddingFactory returns the factory of embedders speaking the OpenAI embeddings API,
+// the base URL defaults to the given one when the config has none
+func GetOpenAIEmbeddingFactory(defaultBaseURL string, requireAPIKey bool) entities.EmbeddingFactory {
+	return func(ctx context.Context, modelName string, config map[string]any) (embedding.Embedder, error) {
+		apiKey, _ := config["api_key"].(string)
+		if apiKey == "" && requireAPIKey {
+			return nil, entities.InvalidConfigError("api_key is required for embedding models")
+		}
+
+		clientConfig := openai.DefaultConfig(apiKey)
+		if defaultBaseURL != "" {
+			clientConfig.BaseURL = defaultBaseURL
+		}
+		if baseURL, ok := config["base_url"].(string); ok && baseURL != "" {
+			clientConfig.BaseURL = baseURL
+		}
+
+		return &openAIEmbedder{
+			cli:   openai.NewClientWithConfig(clientConfig),
+			model: modelName,
+			dims:  embeddingDimensions(config),
+		}, nil
+	}
+}
+
+// GetOllamaEmbeddingFactory returns the Ollama embedding factory, Ollama serves the OpenAI
+// embeddings API under /v1 of its base URL
+func GetOllamaEmbeddingFactory() entities.EmbeddingFactory {
+	return func(ctx context.Context, modelName string, config map[string]any) (embedding.Embedder, error) {
+		baseURL, _ := config["base_url"].(string)
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+
+		withBaseURL := make(map[string]any, len(config)+2)
+		for k, v := range config {
+			withBaseURL[k] = v
+		}
+		withBaseURL["base_url"] = strings.TrimRight(baseURL, "/") + "/v1"
+		withBaseURL["api_key"] = "ollama"
+
+		return GetOpenAIEmbeddingFactory("", false)(ctx, modelName, withBaseURL)
+	}
+}
+
+// GetHTTPEmbeddingFactory returns the factory of the self-hosted HTTP embedding service, which
+// serves a single model and may return sparse vectors as well
+func GetHTTPEmbeddingFactory() entities.EmbeddingFactory {
+	return func(ctx context.Context, modelName string, config map[string]any) (embedding.Embedder, error) {
+		baseURL, _ := config["base_url"].(string)
+		if baseURL == "" {
+			return nil, entities.InvalidConfigError("base_url is required for the HTTP embedding service")
+		}
+
+		return httpembedding.NewEmbedding(baseURL, int64(embeddingDimensions(config)), embeddingBatchSize)
+	}
+}
+
+// embeddingDimensions reads the dimensions from the config
+func embeddingDimensions(config map[string]any) int {
+	switch dims := config[entities.ConfigDimensions].(type) {
+	case int:
+		return dims
+	case float64:
+		return int(dims)
+	default:
+		return 0
+	}
+}
+
+// openAIEmbedder embeds texts with the OpenAI embeddings API, it only produces dense vectors
+type openAIEmbedder struct {
+	cli   *openai.Client
+	model string
+	dims  int
+}
+
+func (e *openAIEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...opt.Option) ([][]float64, error) {
+	dense := make([][]float64, 0, len(texts))
+	for _, part := range slices.Chunks(texts, embeddingBatchSize) {
+		resp, err := e.cli.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+			Input: part,
+			Model: openai.EmbeddingModel(e.model),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create embeddings failed, %w", err)
+		}
+		if len(resp.Data) != len(part) {
+			return nil, fmt.Errorf("create embeddings returned %d vectors for %d texts", len(resp.Data), len(part))
+		}
+
+		sort.Slice(resp.Data, func(i, j int) bool {
+			return resp.Data[i].Index < resp.Data[j].Index
+		})
+		for _, data := range resp.Data {
+			if e.dims > 0 && len(data.Embedding) != e.dims {
+				return nil, fmt.Errorf("embedding model %s returned %d dimensions, expected %d", e.model, len(data.Embedding), e.dims)
+			}
+
+			vector := make([]float64, len(data.Embedding))
+			for i, v := range data.Embedding {
+				vector[i] = float64(v)
+			}
+			dense = append(dense, vector)
+		}
+	}
+
+	return dense, nil
+}
+
+func (e *openAIEmbedder) EmbedStringsHybrid(ctx context.Context, texts []string, opts ...opt.Option) ([][]float64, []map[int]float64, error) {
+	return nil, nil, fmt.Errorf("embedding model %s does not support sparse vectors", e.model)
+}
+
+func (e *openAIEmbedder) Dimensions() int64 {
+	return int64(e.dims)
+}
+
+func (e *openAIEmbedder) SupportStatus() embedding.SupportStatus {
+	return embedding.SupportDense
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/cloudwego/eino-ext/components/model/qwen"
 
 	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
 )
 
 // GetOpenAIModelFactory returns the OpenAI model factory
@@ -30,6 +31,9 @@ func GetOpenAIModelFactory(modelType entities.ModelType) (entities.ModelFactory,
 			APIKey: apiKey,
 			Model:  modelName,
 		}
+		if baseURL, ok := config["base_url"].(string); ok && baseURL != "" {
+			openaiConfig.BaseURL = baseURL
+		}
 
 		// Apply optional parameters
 		if temperature, ok := config["temperature"].(float64); ok {
@@ -52,6 +56,13 @@ func GetOpenAIModelFactory(modelType entities.ModelType) (entities.ModelFactory,
 			openaiConfig.FrequencyPenalty = &fp
 		}
 
+		// Answer with a JSON object when a response format is requested
+		if responseFormat(config) != nil {
+			openaiConfig.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			}
+		}
+
 		// Create eino OpenAI chat model
 		chatModel, err := openai.NewChatModel(ctx, openaiConfig)
 		if err != nil {
@@ -85,6 +96,9 @@ func GetDeepSeekModelFactory(modelType entities.ModelType) (entities.ModelFactor
 			APIKey: apiKey,
 			Model:  modelName,
 		}
+		if baseURL, ok := config["base_url"].(string); ok && baseURL != "" {
+			deepseekConfig.BaseURL = baseURL
+		}
 
 		// Apply optional parameters
 		if temperature, ok := config["temperature"].(float64); ok {
@@ -99,6 +113,11 @@ func GetDeepSeekModelFactory(modelType entities.ModelType) (entities.ModelFactor
 			deepseekConfig.MaxTokens = maxTokens
 		}
 
+		// Answer with a JSON object when a response format is requested
+		if responseFormat(config) != nil {
+			deepseekConfig.ResponseFormatType = deepseek.ResponseFormatTypeJSONObject
+		}
+
 		// Create eino DeepSeek chat model
 		chatModel, err := deepseek.NewChatModel(ctx, deepseekConfig)
 		if err != nil {
@@ -129,8 +148,12 @@ func GetTongyiModelFactory(modelType entities.ModelType) (entities.ModelFactory,
 
 		// Build Qwen configuration
 		qwenConfig := &qwen.ChatModelConfig{
-			APIKey: apiKey,
-			Model:  modelName,
+			APIKey:  apiKey,
+			Model:   modelName,
+			BaseURL: "https://dashscope.aliyuncs.com/compatible-mode/v1",
+		}
+		if baseURL, ok := config["base_url"].(string); ok && baseURL != "" {
+			qwenConfig.BaseURL = baseURL
 		}
 
 		// Apply optional parameters
@@ -146,6 +169,13 @@ func GetTongyiModelFactory(modelType entities.ModelType) (entities.ModelFactory,
 			qwenConfig.MaxTokens = &maxTokens
 		}
 
+		// Answer with a JSON object when a response format is requested
+		if responseFormat(config) != nil {
+			qwenConfig.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			}
+		}
+
 		// Create eino Qwen chat model
 		chatModel, err := qwen.NewChatModel(ctx, qwenConfig)
 		if err != nil {
@@ -186,6 +216,15 @@ func GetOllamaModelFactory(modelType entities.ModelType) (entities.ModelFactory,
 			ollamaConfig.Options.TopP = tp
 		}
 
+		// Ollama constrains the output with the JSON schema itself
+		if format := responseFormat(config); format != nil {
+			schema, err := sonic.Marshal(format.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal response schema: %w", err)
+			}
+			ollamaConfig.Format = schema
+		}
+
 		// Create eino Ollama chat model
 		chatModel, err := ollama.NewChatModel(ctx, ollamaConfig)
 		if err != nil {
@@ -234,6 +273,13 @@ func GetMoonshotModelFactory(modelType entities.ModelType) (entities.ModelFactor
 			openaiConfig.MaxTokens = &maxTokens
 		}
 
+		// Answer with a JSON object when a response format is requested
+		if responseFormat(config) != nil {
+			openaiConfig.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			}
+		}
+
 		// Create eino OpenAI chat model (compatible with Moonshot)
 		chatModel, err := openai.NewChatModel(ctx, openaiConfig)
 		if err != nil {
@@ -249,6 +295,70 @@ func GetMoonshotModelFactory(modelType entities.ModelType) (entities.ModelFactor
 	}, nil
 }
 
+// GetOpenAICompatibleModelFactory returns the factory of services speaking the OpenAI API at their own
+// base URL, such as vLLM or other gateways
+func GetOpenAICompatibleModelFactory(modelType entities.ModelType) (entities.ModelFactory, error) {
+	if modelType != entities.ModelTypeChat {
+		return nil, entities.InvalidConfigError("OpenAI-compatible providers only support chat models")
+	}
+
+	return func(ctx context.Context, modelName string, config map[string]any) (entities.BaseLanguageModel, error) {
+		baseURL, _ := config["base_url"].(string)
+		if baseURL == "" {
+			return nil, entities.InvalidConfigError("base_url is required for OpenAI-compatible models")
+		}
+
+		// Local gateways often run without authentication, so the API key is optional
+		apiKey, _ := config["api_key"].(string)
+
+		openaiConfig := &openai.ChatModelConfig{
+			APIKey:  apiKey,
+			Model:   modelName,
+			BaseURL: baseURL,
+		}
+
+		// Apply optional parameters
+		if temperature, ok := config["temperature"].(float64); ok {
+			temp := float32(temperature)
+			openaiConfig.Temperature = &temp
+		}
+		if topP, ok := config["top_p"].(float64); ok {
+			tp := float32(topP)
+			openaiConfig.TopP = &tp
+		}
+		if maxTokens, ok := config["max_tokens"].(int); ok {
+			openaiConfig.MaxTokens = &maxTokens
+		}
+		if presencePenalty, ok := config["presence_penalty"].(float64); ok {
+			pp := float32(presencePenalty)
+			openaiConfig.PresencePenalty = &pp
+		}
+		if frequencyPenalty, ok := config["frequency_penalty"].(float64); ok {
+			fp := float32(frequencyPenalty)
+			openaiConfig.FrequencyPenalty = &fp
+		}
+
+		// Answer with a JSON object when a response format is requested
+		if responseFormat(config) != nil {
+			openaiConfig.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			}
+		}
+
+		chatModel, err := openai.NewChatModel(ctx, openaiConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI-compatible chat model: %w", err)
+		}
+
+		// Get features and metadata from config
+		features := extractFeatures(config)
+		metadata := extractMetadata(config)
+
+		// Wrap with our interface
+		return entities.NewLLMModel(chatModel, features, metadata), nil
+	}, nil
+}
+
 // GetWenxinModelFactory returns the Wenxin model factory
 func GetWenxinModelFactory(modelType entities.ModelType) (entities.ModelFactory, error) {
 	if modelType != entities.ModelTypeChat {
@@ -261,6 +371,12 @@ func GetWenxinModelFactory(modelType entities.ModelType) (entities.ModelFactory,
 	}, nil
 }
 
+// responseFormat extracts the requested response format from configuration
+func responseFormat(config map[string]any) *entities.ResponseFormat {
+	format, _ := config[entities.ConfigResponseFormat].(*entities.ResponseFormat)
+	return format
+}
+
 // extractFeatures extracts model features from configuration
 func extractFeatures(config map[string]any) []entities.ModelFeature {
 	var features []entities.ModelFeature
@@ -5,25 +5,40 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
+	"github.com/cloudwego/eino/schema"
 	"gopkg.in/yaml.v3"
 
+	"github.com/crazyfrankie/voidx/infra/contract/embedding"
+	"github.com/crazyfrankie/voidx/infra/contract/rerank"
 	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
 )
 
 // Provider represents a language model service provider
 type Provider struct {
 	Name            string                                       `json:"name"`
+	Type            string                                       `json:"type"`
 	Position        int                                          `json:"position"`
 	ProviderEntity  entities.ProviderEntity                      `json:"provider_entity"`
 	ModelEntityMap  map[string]entities.ModelEntity              `json:"model_entity_map"`
 	ModelFactoryMap map[entities.ModelType]entities.ModelFactory `json:"model_factory_map"`
+
+	// EmbeddingFactory creates the embedding models of the provider, nil when it has none
+	EmbeddingFactory entities.EmbeddingFactory `json:"-"`
+
+	// RerankFactory creates the rerank models of the provider, nil when it has none
+	RerankFactory entities.RerankFactory `json:"-"`
+
+	// Credentials such as base_url and api_key are added to the config of every model created
+	Credentials map[string]any `json:"-"`
 }
 
 // NewProvider creates a new provider instance
 func NewProvider(name string, position int, providerEntity entities.ProviderEntity) (*Provider, error) {
 	provider := &Provider{
 		Name:            name,
+		Type:            name,
 		Position:        position,
 		ProviderEntity:  providerEntity,
 		ModelEntityMap:  make(map[string]entities.ModelEntity),
@@ -32,6 +47,23 @@ func NewProvider(name string, position int, providerEntity entities.ProviderEnti
 
 	// Initialize model factories for supported model types
 	for _, modelType := range providerEntity.SupportedModelTypes {
+		if modelType == entities.ModelTypeEmbedding {
+			factory, err := getEmbeddingFactory(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get embedding factory for %s: %w", name, err)
+			}
+			provider.EmbeddingFactory = factory
+			continue
+		}
+		if modelType == entities.ModelTypeRerank {
+			factory, err := getRerankFactory(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get rerank factory for %s: %w", name, err)
+			}
+			provider.RerankFactory = factory
+			continue
+		}
+
 		factory, err := getModelFactory(name, modelType)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get model factory for %s/%s: %w", name, modelType, err)
@@ -47,6 +79,37 @@ func NewProvider(name string, position int, providerEntity entities.ProviderEnti
 	return provider, nil
 }
 
+// NewOpenAICompatibleProvider creates a provider for a service speaking the OpenAI API, its models
+// are given instead of being loaded from YAML and use the default parameters when they have none
+func NewOpenAICompatibleProvider(name string, position int, providerEntity entities.ProviderEntity,
+	modelEntities []entities.ModelEntity, credentials map[string]any) (*Provider, error) {
+	factory, err := GetOpenAICompatibleModelFactory(entities.ModelTypeChat)
+	if err != nil {
+		return nil, err
+	}
+
+	providerEntity.SupportedModelTypes = []entities.ModelType{entities.ModelTypeChat}
+	provider := &Provider{
+		Name:            name,
+		Type:            entities.ProviderTypeOpenAICompatible,
+		Position:        position,
+		ProviderEntity:  providerEntity,
+		ModelEntityMap:  make(map[string]entities.ModelEntity, len(modelEntities)),
+		ModelFactoryMap: map[entities.ModelType]entities.ModelFactory{entities.ModelTypeChat: factory},
+		Credentials:     credentials,
+	}
+
+	for _, modelEntity := range modelEntities {
+		modelEntity.ModelType = entities.ModelTypeChat
+		if len(modelEntity.Parameters) == 0 {
+			modelEntity.Parameters = entities.DefaultModelParameters(modelEntity.MaxOutputTokens)
+		}
+		provider.ModelEntityMap[modelEntity.ModelName] = modelEntity
+	}
+
+	return provider, nil
+}
+
 // loadModelEntities loads model entities from YAML configuration files
 func (p *Provider) loadModelEntities() error {
 	// Get the current working directory and construct the provider path
@@ -248,6 +311,11 @@ func (p *Provider) configToModelEntity(config map[string]any) (*entities.ModelEn
 			entity.MaxOutputTokens = mot
 		}
 	}
+	if dimensions, exists := config["dimensions"]; exists {
+		if dims, ok := dimensions.(int); ok {
+			entity.Dimensions = dims
+		}
+	}
 	if attributes, exists := config["attributes"]; exists {
 		if attr, ok := attributes.(map[string]any); ok {
 			entity.Attributes = attr
@@ -304,6 +372,141 @@ func (p *Provider) GetModelEntities() []*entities.ModelEntity {
 	return entities
 }
 
+// ValidateCredentials checks the credentials by making a minimal call to the first chat model,
+// or to the first embedding or rerank model of providers without chat models
+func (p *Provider) ValidateCredentials(ctx context.Context, credentials map[string]any) error {
+	modelNames := p.modelNamesOfType(entities.ModelTypeChat)
+	if len(modelNames) == 0 {
+		embeddingModels := p.modelNamesOfType(entities.ModelTypeEmbedding)
+		if len(embeddingModels) == 0 {
+			return p.validateRerankCredentials(ctx, credentials)
+		}
+
+		embedder, err := p.CreateEmbedder(ctx, embeddingModels[0], credentials)
+		if err != nil {
+			return err
+		}
+		if _, err := embedder.EmbedStrings(ctx, []string{"ping"}); err != nil {
+			return entities.InvalidConfigError(fmt.Sprintf("credentials validation failed: %v", err))
+		}
+		return nil
+	}
+
+	config := make(map[string]any, len(credentials)+1)
+	for k, v := range credentials {
+		config[k] = v
+	}
+	config["max_tokens"] = 1
+
+	llm, err := p.CreateModel(ctx, modelNames[0], config)
+	if err != nil {
+		return err
+	}
+	if _, err := llm.Generate(ctx, []*schema.Message{schema.UserMessage("ping")}); err != nil {
+		return entities.InvalidConfigError(fmt.Sprintf("credentials validation failed: %v", err))
+	}
+
+	return nil
+}
+
+// validateRerankCredentials checks the credentials by reranking with the first rerank model
+func (p *Provider) validateRerankCredentials(ctx context.Context, credentials map[string]any) error {
+	rerankModels := p.modelNamesOfType(entities.ModelTypeRerank)
+	if len(rerankModels) == 0 {
+		return entities.NotSupportedError("provider has no model to validate credentials with")
+	}
+
+	reranker, err := p.CreateReranker(ctx, rerankModels[0], credentials)
+	if err != nil {
+		return err
+	}
+	if _, err := reranker.Rerank(ctx, &rerank.Request{Query: "ping", Documents: []string{"ping"}}); err != nil {
+		return entities.InvalidConfigError(fmt.Sprintf("credentials validation failed: %v", err))
+	}
+
+	return nil
+}
+
+// modelNamesOfType returns the sorted names of the models of the type
+func (p *Provider) modelNamesOfType(modelType entities.ModelType) []string {
+	modelNames := make([]string, 0, len(p.ModelEntityMap))
+	for name, entity := range p.ModelEntityMap {
+		if entity.ModelType == modelType {
+			modelNames = append(modelNames, name)
+		}
+	}
+	sort.Strings(modelNames)
+
+	return modelNames
+}
+
+// CreateEmbedder creates an embedder for an embedding model, the dimensions default to those of
+// the model and the credentials of the provider always apply
+func (p *Provider) CreateEmbedder(ctx context.Context, modelName string, config map[string]any) (embedding.Embedder, error) {
+	entity, err := p.GetModelEntity(modelName)
+	if err != nil {
+		return nil, err
+	}
+	if entity.ModelType != entities.ModelTypeEmbedding || p.EmbeddingFactory == nil {
+		return nil, entities.InvalidConfigError(fmt.Sprintf("%s is not an embedding model", modelName))
+	}
+
+	withDefaults := make(map[string]any, len(config)+len(p.Credentials)+1)
+	for k, v := range config {
+		withDefaults[k] = v
+	}
+	if _, exists := config[entities.ConfigDimensions]; !exists && entity.Dimensions > 0 {
+		withDefaults[entities.ConfigDimensions] = entity.Dimensions
+	}
+	for k, v := range p.Credentials {
+		withDefaults[k] = v
+	}
+
+	return p.EmbeddingFactory(ctx, modelName, withDefaults)
+}
+
+// CreateReranker creates a reranker for a rerank model, chat models can rerank as well by scoring
+// the documents themselves
+func (p *Provider) CreateReranker(ctx context.Context, modelName string, config map[string]any) (rerank.Reranker, error) {
+	entity, err := p.GetModelEntity(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch entity.ModelType {
+	case entities.ModelTypeRerank:
+		if p.RerankFactory == nil {
+			return nil, entities.InvalidConfigError(fmt.Sprintf("%s is not a rerank model", modelName))
+		}
+
+		withCredentials := make(map[string]any, len(config)+len(p.Credentials))
+		for k, v := range config {
+			withCredentials[k] = v
+		}
+		for k, v := range p.Credentials {
+			withCredentials[k] = v
+		}
+
+		return p.RerankFactory(ctx, modelName, withCredentials)
+	case entities.ModelTypeChat:
+		// Scores should be stable across calls
+		withTemperature := make(map[string]any, len(config)+1)
+		for k, v := range config {
+			withTemperature[k] = v
+		}
+		withTemperature[string(entities.ParameterTemperature)] = 0.0
+
+		llm, err := p.CreateModel(ctx, modelName, withTemperature)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewLLMReranker(llm), nil
+	default:
+		return nil, entities.InvalidConfigError(fmt.Sprintf("%s can not be used for reranking", modelName))
+	}
+}
+
 // CreateModel creates a language model instance
 func (p *Provider) CreateModel(ctx context.Context, modelName string, config map[string]any) (entities.BaseLanguageModel, error) {
 	entity, err := p.GetModelEntity(modelName)
@@ -316,7 +519,68 @@ func (p *Provider) CreateModel(ctx context.Context, modelName string, config map
 		return nil, err
 	}
 
-	return factory(ctx, modelName, config)
+	// Attach the model's metadata (pricing etc.) and features unless the caller provided its own,
+	// the credentials of the provider always apply
+	withDefaults := make(map[string]any, len(config)+len(p.Credentials)+2)
+	for k, v := range config {
+		withDefaults[k] = v
+	}
+	if _, exists := config["metadata"]; !exists && len(entity.Metadata) > 0 {
+		withDefaults["metadata"] = entity.Metadata
+	}
+	if _, exists := config["features"]; !exists && len(entity.Features) > 0 {
+		features := make([]any, 0, len(entity.Features))
+		for _, feature := range entity.Features {
+			features = append(features, string(feature))
+		}
+		withDefaults["features"] = features
+	}
+	for k, v := range p.Credentials {
+		withDefaults[k] = v
+	}
+	config = withDefaults
+
+	llm, err := factory(ctx, modelName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if llmModel, ok := llm.(*entities.LLMModel); ok {
+		llmModel.SetTokenizer(entities.NewTokenizer(p.Type, modelName))
+
+		// The output reservation follows max_tokens when the caller limits it
+		maxOutputTokens := entity.MaxOutputTokens
+		switch maxTokens := config["max_tokens"].(type) {
+		case int:
+			if maxTokens > 0 {
+				maxOutputTokens = maxTokens
+			}
+		case float64:
+			if maxTokens > 0 {
+				maxOutputTokens = int(maxTokens)
+			}
+		}
+		llmModel.SetContextWindow(entity.ContextWindow, maxOutputTokens)
+
+		// Providers with a JSON output mode can rebuild the model with a response format
+		if supportsResponseFormat(p.Type) {
+			llmModel.SetRebuilder(config, func(ctx context.Context, config map[string]any) (entities.BaseLanguageModel, error) {
+				return factory(ctx, modelName, config)
+			})
+		}
+	}
+
+	return llm, nil
+}
+
+// supportsResponseFormat reports whether the provider factory honours a requested response format
+func supportsResponseFormat(providerName string) bool {
+	switch providerName {
+	case "openai", "moonshot", "deepseek", "tongyi", "ollama", entities.ProviderTypeOpenAICompatible:
+		return true
+	default:
+		return false
+	}
 }
 
 // getModelFactory returns the appropriate model factory based on provider and model type
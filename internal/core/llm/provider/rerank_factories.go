@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/infra/contract/rerank"
+	httprerank "github.com/crazyfrankie/voidx/infra/impl/rerank"
+	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/pkg/lang/slices"
+)
+
+// ProviderHTTPRerank is the provider of the self-hosted HTTP rerank service
+const ProviderHTTPRerank = "http_rerank"
+
+const (
+	// llmRerankBatchSize is the number of documents scored by one chat model call
+	llmRerankBatchSize = 10
+	// llmRerankMaxRunes limits the length of each document shown to the chat model
+	llmRerankMaxRunes = 1000
+)
+
+const llmRerankPrompt = `你是一个检索结果相关性评估器，请评估下面每个文档与用户问题的相关性，给出0到1之间的分数，1表示完全相关，0表示完全无关。
+只输出一个JSON数组，按文档顺序依次给出每个文档的分数，例如[0.9, 0.1]，不要输出其他内容。
+
+问题：%s
+
+%s`
+
+// getRerankFactory returns the rerank factory of the provider
+func getRerankFactory(providerName string) (entities.RerankFactory, error) {
+	switch providerName {
+	case ProviderHTTPRerank:
+		return GetHTTPRerankFactory(), nil
+	default:
+		return nil, entities.NotFoundError(fmt.Sprintf("unsupported rerank provider: %s", providerName))
+	}
+}
+
+// GetHTTPRerankFactory returns the factory of the self-hosted cross-encoder service, such as a
+// bge-reranker server
+func GetHTTPRerankFactory() entities.RerankFactory {
+	return func(ctx context.Context, modelName string, config map[string]any) (rerank.Reranker, error) {
+		baseURL, _ := config["base_url"].(string)
+		if baseURL == "" {
+			return nil, entities.InvalidConfigError("base_url is required for the HTTP rerank service")
+		}
+		apiKey, _ := config["api_key"].(string)
+
+		return httprerank.NewHTTPReranker(baseURL, apiKey, modelName)
+	}
+}
+
+// NewLLMReranker creates a reranker asking a chat model to score the documents
+func NewLLMReranker(llm entities.BaseLanguageModel) rerank.Reranker {
+	return &llmReranker{llm: llm}
+}
+
+// llmReranker scores documents with a chat model in batches
+type llmReranker struct {
+	llm entities.BaseLanguageModel
+}
+
+func (r *llmReranker) Rerank(ctx context.Context, req *rerank.Request) (*rerank.Response, error) {
+	results := make([]*rerank.Result, 0, len(req.Documents))
+	for i, part := range slices.Chunks(req.Documents, llmRerankBatchSize) {
+		scores, err := r.score(ctx, req.Query, part)
+		if err != nil {
+			return nil, err
+		}
+		for j, score := range scores {
+			results = append(results, &rerank.Result{Index: i*llmRerankBatchSize + j, Score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if req.TopN > 0 && len(results) > req.TopN {
+		results = results[:req.TopN]
+	}
+
+	return &rerank.Response{Results: results}, nil
+}
+
+// score asks the chat model for the relevance scores of the documents
+func (r *llmReranker) score(ctx context.Context, query string, documents []string) ([]float64, error) {
+	var sb strings.Builder
+	for i, doc := range documents {
+		if runes := []rune(doc); len(runes) > llmRerankMaxRunes {
+			doc = string(runes[:llmRerankMaxRunes])
+		}
+		fmt.Fprintf(&sb, "文档[%d]：%s\n\n", i+1, doc)
+	}
+
+	msg, err := r.llm.Generate(ctx, []*schema.Message{
+		schema.UserMessage(fmt.Sprintf(llmRerankPrompt, query, sb.String())),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("llm rerank failed, %w", err)
+	}
+
+	content := msg.Content
+	start, end := strings.Index(content, "["), strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("llm rerank returned no scores: %s", content)
+	}
+	var scores []float64
+	if err := json.Unmarshal([]byte(content[start:end+1]), &scores); err != nil {
+		return nil, fmt.Errorf("llm rerank returned invalid scores, %w", err)
+	}
+	if len(scores) != len(documents) {
+		return nil, fmt.Errorf("llm rerank returned %d scores for %d documents", len(scores), len(documents))
+	}
+
+	return scores, nil
+}
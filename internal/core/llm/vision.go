@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+)
+
+// VisionModelSpec returns the vision model of the image input config of an app, nil when images
+// are disabled or the main model is expected to read them itself
+func VisionModelSpec(imageInput map[string]any) *ModelSpec {
+	if enable, _ := imageInput["enable"].(bool); !enable {
+		return nil
+	}
+	visionModel, ok := imageInput["vision_model"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	providerName, _ := visionModel["provider"].(string)
+	modelName, _ := visionModel["model"].(string)
+	if providerName == "" || modelName == "" {
+		return nil
+	}
+
+	return &ModelSpec{Provider: providerName, Model: modelName}
+}
+
+// BuildHumanMessageForAccount converts the query and images to a human message for the model, the
+// vision model of the image input config describes the images when the model has no image input
+func (lmm *LanguageModelManager) BuildHumanMessageForAccount(ctx context.Context, accountID uuid.UUID, llm entities.BaseLanguageModel,
+	imageInput map[string]any, query string, imageURLs []string) (*schema.Message, error) {
+	var vision entities.BaseLanguageModel
+	if len(imageURLs) > 0 && !entities.HasFeature(llm.GetFeatures(), entities.FeatureImageInput) {
+		if spec := VisionModelSpec(imageInput); spec != nil {
+			var err error
+			vision, err = lmm.CreateModelForAccount(ctx, accountID, spec.Provider, spec.Model, spec.Parameters)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return entities.HumanMessage(ctx, llm, vision, query, imageURLs)
+}
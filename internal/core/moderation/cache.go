@@ -0,0 +1,41 @@
+package moderation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// compiled caches the keyword and regex moderators by their rules. Agents are built for every
+// message, building the automaton and compiling the patterns each time is too expensive.
+// The cached moderators are read-only and safe to share between pipelines.
+var compiled sync.Map
+
+// cachedModerator returns the moderator built for the rules, building it on first use.
+// Failed builds are not cached.
+func cachedModerator(kind ModeratorType, category string, action Action, rules []string, build func() (Moderator, error)) (Moderator, error) {
+	key := moderatorKey(kind, category, action, rules)
+	if moderator, ok := compiled.Load(key); ok {
+		return moderator.(Moderator), nil
+	}
+
+	moderator, err := build()
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := compiled.LoadOrStore(key, moderator)
+
+	return actual.(Moderator), nil
+}
+
+// moderatorKey identifies a moderator by its type, category, action and rules
+func moderatorKey(kind ModeratorType, category string, action Action, rules []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", kind, category, action)
+	for _, rule := range rules {
+		fmt.Fprintf(h, "\x00%s", rule)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
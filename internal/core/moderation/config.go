@@ -0,0 +1,167 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+)
+
+// ModeratorType identifies a moderator implementation in the app config
+type ModeratorType string
+
+const (
+	ModeratorTypeKeyword ModeratorType = "keyword"
+	ModeratorTypeRegex   ModeratorType = "regex"
+	ModeratorTypeLLM     ModeratorType = "llm"
+	ModeratorTypeHTTP    ModeratorType = "http"
+)
+
+// ModeratorConfig configures one moderator of an app
+type ModeratorConfig struct {
+	Type     ModeratorType `json:"type"`
+	Category string        `json:"category"`
+	Action   Action        `json:"action"`
+	// Stages the moderator runs at, both stages when empty
+	Stages []Stage `json:"stages"`
+
+	// Keywords for the keyword moderator
+	Keywords []string `json:"keywords,omitempty"`
+	// Patterns for the regex moderator
+	Patterns []string `json:"patterns,omitempty"`
+	// Categories checked by the LLM judge
+	Categories []string `json:"categories,omitempty"`
+	// URL, Headers and Timeout (in seconds) of the external endpoint
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Timeout int               `json:"timeout,omitempty"`
+}
+
+// runsAt reports whether the moderator reviews the stage
+func (c ModeratorConfig) runsAt(stage Stage) bool {
+	return len(c.Stages) == 0 || slices.Contains(c.Stages, stage)
+}
+
+// Config is the review config of an app
+type Config struct {
+	InputEnabled  bool
+	OutputEnabled bool
+	// Keywords is the legacy keyword list, it blocks inputs and masks outputs
+	Keywords   []string
+	Moderators []ModeratorConfig
+}
+
+// NewPipeline builds the pipeline of an app. The judge model backs the LLM moderators.
+// Invalid moderators are skipped and reported in the joined error, the pipeline built from
+// the valid ones is always returned.
+func NewPipeline(cfg Config, judge model.BaseChatModel) (*Pipeline, error) {
+	pipeline := &Pipeline{moderators: map[Stage][]Moderator{}, mask: DefaultMask}
+	enabled := map[Stage]bool{StageInput: cfg.InputEnabled, StageOutput: cfg.OutputEnabled}
+
+	// The legacy keywords block inputs and mask outputs
+	if len(cfg.Keywords) > 0 {
+		if cfg.InputEnabled {
+			pipeline.moderators[StageInput] = append(pipeline.moderators[StageInput],
+				keywordModerator("keyword", ActionBlock, cfg.Keywords))
+		}
+		if cfg.OutputEnabled {
+			pipeline.moderators[StageOutput] = append(pipeline.moderators[StageOutput],
+				keywordModerator("keyword", ActionMask, cfg.Keywords))
+		}
+	}
+
+	var errs []error
+	for i, moderatorCfg := range cfg.Moderators {
+		moderator, err := newModerator(moderatorCfg, judge)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("moderator %d: %w", i, err))
+			continue
+		}
+
+		for _, stage := range []Stage{StageInput, StageOutput} {
+			if enabled[stage] && moderatorCfg.runsAt(stage) {
+				pipeline.moderators[stage] = append(pipeline.moderators[stage], moderator)
+			}
+		}
+	}
+
+	return pipeline, errors.Join(errs...)
+}
+
+// ValidateModeratorConfig checks a moderator config, the model of an LLM moderator is only known at run time
+func ValidateModeratorConfig(cfg ModeratorConfig) error {
+	if cfg.Type == ModeratorTypeLLM {
+		return validateCommon(cfg)
+	}
+
+	_, err := newModerator(cfg, nil)
+	return err
+}
+
+// validateCommon checks the fields shared by all moderator types
+func validateCommon(cfg ModeratorConfig) error {
+	if cfg.Action.severity() == 0 {
+		return fmt.Errorf("invalid action %q", cfg.Action)
+	}
+	for _, stage := range cfg.Stages {
+		if stage != StageInput && stage != StageOutput {
+			return fmt.Errorf("invalid stage %q", stage)
+		}
+	}
+
+	return nil
+}
+
+// keywordModerator returns the cached keyword moderator of the keywords
+func keywordModerator(category string, action Action, keywords []string) Moderator {
+	moderator, _ := cachedModerator(ModeratorTypeKeyword, category, action, keywords, func() (Moderator, error) {
+		return NewKeywordModerator(category, action, keywords), nil
+	})
+
+	return moderator
+}
+
+func newModerator(cfg ModeratorConfig, judge model.BaseChatModel) (Moderator, error) {
+	if err := validateCommon(cfg); err != nil {
+		return nil, err
+	}
+
+	category := cfg.Category
+	if category == "" {
+		category = string(cfg.Type)
+	}
+
+	switch cfg.Type {
+	case ModeratorTypeKeyword:
+		if len(cfg.Keywords) == 0 {
+			return nil, errors.New("keyword moderator requires keywords")
+		}
+		return keywordModerator(category, cfg.Action, cfg.Keywords), nil
+	case ModeratorTypeRegex:
+		if len(cfg.Patterns) == 0 {
+			return nil, errors.New("regex moderator requires patterns")
+		}
+		return cachedModerator(ModeratorTypeRegex, category, cfg.Action, cfg.Patterns, func() (Moderator, error) {
+			return NewRegexModerator(category, cfg.Action, cfg.Patterns)
+		})
+	case ModeratorTypeLLM:
+		if judge == nil {
+			return nil, errors.New("llm moderator requires a model")
+		}
+		return NewLLMJudgeModerator(judge, cfg.Action, cfg.Categories), nil
+	case ModeratorTypeHTTP:
+		endpoint, err := url.Parse(cfg.URL)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint url %q", cfg.URL)
+		}
+		if cfg.Timeout < 0 {
+			return nil, errors.New("timeout must not be negative")
+		}
+		return NewHTTPModerator(cfg.URL, cfg.Headers, cfg.Action, time.Duration(cfg.Timeout)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown moderator type %q", cfg.Type)
+	}
+}
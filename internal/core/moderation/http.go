@@ -0,0 +1,108 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// defaultHTTPTimeout bounds a call to an external moderation endpoint
+const defaultHTTPTimeout = 5 * time.Second
+
+// httpModerationRequest is posted to the external endpoint
+type httpModerationRequest struct {
+	Text  string `json:"text"`
+	Stage Stage  `json:"stage"`
+}
+
+// httpModerationResponse is expected from the external endpoint
+type httpModerationResponse struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+	Reason     string   `json:"reason"`
+}
+
+// HTTPModerator delegates the review to an external moderation service
+type HTTPModerator struct {
+	url     string
+	headers map[string]string
+	act     Action
+	client  *http.Client
+}
+
+// NewHTTPModerator creates a moderator calling the endpoint, a non-positive timeout uses the default
+func NewHTTPModerator(url string, headers map[string]string, action Action, timeout time.Duration) *HTTPModerator {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	return &HTTPModerator{
+		url:     url,
+		headers: headers,
+		act:     action,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (m *HTTPModerator) Name() string {
+	return "http"
+}
+
+func (m *HTTPModerator) Moderate(ctx context.Context, stage Stage, text string) ([]Hit, error) {
+	body, err := sonic.Marshal(httpModerationRequest{Text: text, Stage: stage})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range m.headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := m.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("moderation endpoint unreachable: %w", err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation endpoint returned %d: %s", response.StatusCode, data)
+	}
+
+	var verdict httpModerationResponse
+	if err := sonic.Unmarshal(data, &verdict); err != nil {
+		return nil, fmt.Errorf("invalid moderation response: %w", err)
+	}
+	if !verdict.Flagged {
+		return nil, nil
+	}
+
+	if len(verdict.Categories) == 0 {
+		verdict.Categories = []string{"unknown"}
+	}
+	hits := make([]Hit, 0, len(verdict.Categories))
+	for _, category := range verdict.Categories {
+		hits = append(hits, Hit{
+			Stage:     stage,
+			Moderator: m.Name(),
+			Category:  category,
+			Action:    m.act,
+			Reason:    verdict.Reason,
+		})
+	}
+
+	return hits, nil
+}
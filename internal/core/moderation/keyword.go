@@ -0,0 +1,160 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeywordModerator finds keywords case-insensitively with an Aho-Corasick automaton, so the cost of a
+// review grows with the length of the text rather than with the number of keywords
+type KeywordModerator struct {
+	category string
+	act      Action
+	matcher  *acMatcher
+}
+
+// NewKeywordModerator builds the automaton for the keywords once, empty keywords are ignored
+func NewKeywordModerator(category string, action Action, keywords []string) *KeywordModerator {
+	return &KeywordModerator{
+		category: category,
+		act:      action,
+		matcher:  newACMatcher(keywords),
+	}
+}
+
+func (m *KeywordModerator) Name() string {
+	return "keyword"
+}
+
+func (m *KeywordModerator) Moderate(_ context.Context, stage Stage, text string) ([]Hit, error) {
+	var hits []Hit
+	m.matcher.find(text, func(start, end int) {
+		hits = append(hits, Hit{
+			Stage:     stage,
+			Moderator: m.Name(),
+			Category:  m.category,
+			Action:    m.act,
+			Match:     text[start:end],
+			Start:     start,
+			End:       end,
+		})
+	})
+
+	return hits, nil
+}
+
+// MaxMatchLen is the length in runes of the longest keyword
+func (m *KeywordModerator) MaxMatchLen() int {
+	return m.matcher.maxLen
+}
+
+func (m *KeywordModerator) blocks() bool {
+	return m.act == ActionBlock
+}
+
+func (m *KeywordModerator) window() int {
+	return m.matcher.maxLen
+}
+
+// acNode is a state of the automaton
+type acNode struct {
+	next map[rune]int
+	fail int
+	// output is the rune length of every keyword ending at this state, including those reached by fail links
+	output []int
+}
+
+type acMatcher struct {
+	nodes  []acNode
+	maxLen int
+}
+
+func newACMatcher(keywords []string) *acMatcher {
+	m := &acMatcher{nodes: []acNode{{next: map[rune]int{}}}}
+
+	// 1. Build the trie of the lower-cased keywords
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" {
+			continue
+		}
+
+		state, length := 0, 0
+		for _, r := range keyword {
+			child, ok := m.nodes[state].next[r]
+			if !ok {
+				child = len(m.nodes)
+				m.nodes = append(m.nodes, acNode{next: map[rune]int{}})
+				m.nodes[state].next[r] = child
+			}
+			state = child
+			length++
+		}
+		m.nodes[state].output = append(m.nodes[state].output, length)
+		m.maxLen = max(m.maxLen, length)
+	}
+
+	// 2. Link every state to the longest proper suffix that is also a prefix, breadth first
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for r, child := range m.nodes[state].next {
+			fail := m.nodes[state].fail
+			for fail > 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if target, ok := m.nodes[fail].next[r]; ok && target != child {
+				m.nodes[child].fail = target
+			}
+			m.nodes[child].output = append(m.nodes[child].output, m.nodes[m.nodes[child].fail].output...)
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+// find reports the byte span of every keyword occurrence in the text, overlapping occurrences included
+func (m *acMatcher) find(text string, report func(start, end int)) {
+	if m.maxLen == 0 {
+		return
+	}
+
+	// offsets keeps the byte offset of the last maxLen runes to turn rune lengths into byte spans
+	offsets := make([]int, m.maxLen)
+	state, index := 0, 0
+	for pos, r := range text {
+		offsets[index%m.maxLen] = pos
+		index++
+
+		r = unicode.ToLower(r)
+		for state > 0 {
+			if _, ok := m.nodes[state].next[r]; ok {
+				break
+			}
+			state = m.nodes[state].fail
+		}
+		if next, ok := m.nodes[state].next[r]; ok {
+			state = next
+		}
+
+		if len(m.nodes[state].output) == 0 {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[pos:])
+		end := pos + size
+		for _, length := range m.nodes[state].output {
+			report(offsets[(index-length)%m.maxLen], end)
+		}
+	}
+}
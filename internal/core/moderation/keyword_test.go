@@ -0,0 +1,51 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACMatcherFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords []string
+		text     string
+		want     []string
+	}{
+		{name: "no keywords", keywords: nil, text: "anything", want: nil},
+		{name: "blank keywords ignored", keywords: []string{" ", ""}, text: "anything", want: nil},
+		{name: "single", keywords: []string{"bad"}, text: "a bad day", want: []string{"bad"}},
+		{name: "case insensitive", keywords: []string{"Bad"}, text: "BAD and bAd", want: []string{"BAD", "bAd"}},
+		{name: "overlapping", keywords: []string{"he", "she", "hers"}, text: "ushers", want: []string{"she", "he", "hers"}},
+		{name: "suffix via fail link", keywords: []string{"abcd", "bc"}, text: "abce", want: []string{"bc"}},
+		{name: "repeated", keywords: []string{"aa"}, text: "aaaa", want: []string{"aa", "aa", "aa"}},
+		{name: "multibyte", keywords: []string{"敏感词"}, text: "这是敏感词测试敏感词", want: []string{"敏感词", "敏感词"}},
+		{name: "mixed width", keywords: []string{"x词"}, text: "ax词b", want: []string{"x词"}},
+		{name: "no match", keywords: []string{"foo"}, text: "fo fo", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			newACMatcher(tt.keywords).find(tt.text, func(start, end int) {
+				got = append(got, tt.text[start:end])
+			})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKeywordModerator(t *testing.T) {
+	moderator := NewKeywordModerator("spam", ActionMask, []string{"buy now", "免费"})
+	assert.Equal(t, 7, moderator.MaxMatchLen())
+
+	hits, err := moderator.Moderate(context.Background(), StageOutput, "Buy Now, 免费!")
+	assert.NoError(t, err)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, Hit{Stage: StageOutput, Moderator: "keyword", Category: "spam", Action: ActionMask, Match: "Buy Now", Start: 0, End: 7}, hits[0])
+	assert.Equal(t, "免费", hits[1].Match)
+	assert.Equal(t, 9, hits[1].Start)
+	assert.Equal(t, 15, hits[1].End)
+}
@@ -0,0 +1,100 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// defaultJudgeCategories are checked when an LLM judge is configured without categories
+var defaultJudgeCategories = []string{"违法违规", "色情低俗", "暴力恐怖", "仇恨歧视", "自我伤害"}
+
+const judgePromptTemplate = `你是一个内容安全审核员，请判断下面的{stage}是否属于以下任意一个违规类别：{categories}。
+
+请只输出一个JSON对象，不要输出任何其他内容，格式如下：
+{"flagged": 是否违规(true或false), "category": "命中的类别，未违规时为空字符串", "reason": "简短的判断依据"}
+
+待审核内容：
+<content>
+{content}
+</content>`
+
+// judgeVerdict is the answer of the judge model
+type judgeVerdict struct {
+	Flagged  bool   `json:"flagged"`
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+}
+
+// LLMJudgeModerator asks a chat model whether the content violates any of the categories
+type LLMJudgeModerator struct {
+	llm        model.BaseChatModel
+	act        Action
+	categories []string
+}
+
+// NewLLMJudgeModerator creates a judge backed by the model, the default categories are used when none are given
+func NewLLMJudgeModerator(llm model.BaseChatModel, action Action, categories []string) *LLMJudgeModerator {
+	if len(categories) == 0 {
+		categories = defaultJudgeCategories
+	}
+
+	return &LLMJudgeModerator{
+		llm:        llm,
+		act:        action,
+		categories: categories,
+	}
+}
+
+func (m *LLMJudgeModerator) Name() string {
+	return "llm"
+}
+
+func (m *LLMJudgeModerator) Moderate(ctx context.Context, stage Stage, text string) ([]Hit, error) {
+	stageName := "用户输入"
+	if stage == StageOutput {
+		stageName = "AI回答"
+	}
+	prompt := strings.NewReplacer(
+		"{stage}", stageName,
+		"{categories}", strings.Join(m.categories, "、"),
+		"{content}", text,
+	).Replace(judgePromptTemplate)
+
+	response, err := m.llm.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return nil, fmt.Errorf("judge model failed: %w", err)
+	}
+
+	var verdict judgeVerdict
+	if err := sonic.UnmarshalString(jsonObject(response.Content), &verdict); err != nil {
+		return nil, fmt.Errorf("invalid judge verdict %q: %w", response.Content, err)
+	}
+	if !verdict.Flagged {
+		return nil, nil
+	}
+
+	return []Hit{{
+		Stage:     stage,
+		Moderator: m.Name(),
+		Category:  verdict.Category,
+		Action:    m.act,
+		Reason:    verdict.Reason,
+	}}, nil
+}
+
+// jsonObject cuts the outermost JSON object out of a model answer that may be wrapped in code fences
+func jsonObject(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return content
+	}
+
+	return content[start : end+1]
+}
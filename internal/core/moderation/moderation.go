@@ -0,0 +1,235 @@
+// Package moderation reviews user inputs and model outputs with a pipeline of pluggable moderators.
+// A moderator reports hits, each with a category and an action; the pipeline combines the hits of all
+// moderators configured for a stage into one result.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action is what happens to content that hits a moderation rule
+type Action string
+
+const (
+	// ActionBlock replaces the whole content with a preset response
+	ActionBlock Action = "block"
+	// ActionMask replaces the matched text with the mask
+	ActionMask Action = "mask"
+	// ActionFlag keeps the content and only records the hit
+	ActionFlag Action = "flag"
+)
+
+// severity orders the actions so the strongest one decides the result
+func (a Action) severity() int {
+	switch a {
+	case ActionBlock:
+		return 3
+	case ActionMask:
+		return 2
+	case ActionFlag:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Stage is the point of a conversation the content is reviewed at
+type Stage string
+
+const (
+	StageInput  Stage = "input"
+	StageOutput Stage = "output"
+)
+
+// DefaultMask replaces masked text
+const DefaultMask = "**"
+
+// Hit is a single rule violation found by a moderator
+type Hit struct {
+	Stage     Stage  `json:"stage"`
+	Moderator string `json:"moderator"`
+	Category  string `json:"category"`
+	Action    Action `json:"action"`
+	Match     string `json:"match,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+
+	// Start and End are the byte offsets of the match, both are zero when the hit covers the whole content
+	Start int `json:"-"`
+	End   int `json:"-"`
+}
+
+// hasSpan reports whether the hit points at a part of the content that can be masked
+func (h Hit) hasSpan() bool {
+	return h.End > h.Start
+}
+
+// Result is the combined outcome of all moderators of a stage
+type Result struct {
+	Stage  Stage  `json:"stage"`
+	Action Action `json:"action,omitempty"`
+	Hits   []Hit  `json:"hits,omitempty"`
+
+	// Content is the reviewed content after masking
+	Content string `json:"-"`
+}
+
+// Blocked reports whether the content must not be used
+func (r *Result) Blocked() bool {
+	return r.Action == ActionBlock
+}
+
+// Merge adds the hits of another result of the same stage, the stronger action wins
+func (r *Result) Merge(other *Result) {
+	r.Hits = append(r.Hits, other.Hits...)
+	if other.Action.severity() > r.Action.severity() {
+		r.Action = other.Action
+	}
+}
+
+// Summary describes the hits without the matched text, suitable for showing to end users
+func (r *Result) Summary() string {
+	parts := make([]string, 0, len(r.Hits))
+	for _, hit := range r.Hits {
+		parts = append(parts, fmt.Sprintf("%s:%s(%s)", hit.Moderator, hit.Category, hit.Action))
+	}
+
+	return fmt.Sprintf("%s %s: %s", r.Stage, r.Action, strings.Join(parts, ", "))
+}
+
+// Moderator reviews a piece of text
+type Moderator interface {
+	// Name identifies the moderator type in hits
+	Name() string
+
+	// Moderate returns the hits found in the text
+	Moderate(ctx context.Context, stage Stage, text string) ([]Hit, error)
+}
+
+// spanModerator is implemented by moderators that only look at the text itself and report
+// the exact span of every match, so they can review partial content such as streamed chunks
+type spanModerator interface {
+	Moderator
+
+	// blocks reports whether a match blocks the whole content instead of a part of it
+	blocks() bool
+
+	// window is the length in runes of the longest possible match, negative when unbounded
+	window() int
+}
+
+// Pipeline runs the moderators configured for each stage
+type Pipeline struct {
+	moderators map[Stage][]Moderator
+	mask       string
+}
+
+// Enabled reports whether any moderator reviews the stage
+func (p *Pipeline) Enabled(stage Stage) bool {
+	return p != nil && len(p.moderators[stage]) > 0
+}
+
+// NeedsFullText reports whether the stage has moderators that must see the complete content before
+// it is shown, either because they judge the text as a whole or because they may block it
+func (p *Pipeline) NeedsFullText(stage Stage) bool {
+	if p == nil {
+		return false
+	}
+	for _, moderator := range p.moderators[stage] {
+		span, ok := moderator.(spanModerator)
+		if !ok || span.blocks() || span.window() < 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Moderate runs every moderator of the stage over the text. A moderator that fails does not stop
+// the others, its error is recorded as a flag hit so the failure is kept for audit.
+func (p *Pipeline) Moderate(ctx context.Context, stage Stage, text string) *Result {
+	result := &Result{Stage: stage, Content: text}
+	if !p.Enabled(stage) || text == "" {
+		return result
+	}
+
+	result.Hits = p.collect(ctx, stage, text)
+	result.Action = resolve(result.Hits)
+	if result.Action == ActionMask {
+		result.Content = applyMask(text, result.Hits, p.mask)
+	}
+
+	return result
+}
+
+// collect runs the moderators of the stage and gathers their hits
+func (p *Pipeline) collect(ctx context.Context, stage Stage, text string) []Hit {
+	var hits []Hit
+	for _, moderator := range p.moderators[stage] {
+		found, err := moderator.Moderate(ctx, stage, text)
+		if err != nil {
+			hits = append(hits, Hit{
+				Stage:     stage,
+				Moderator: moderator.Name(),
+				Category:  "error",
+				Action:    ActionFlag,
+				Reason:    err.Error(),
+			})
+			continue
+		}
+		hits = append(hits, found...)
+	}
+
+	// A mask hit without a span cannot be masked, it blocks the whole content instead
+	for i := range hits {
+		if hits[i].Action == ActionMask && !hits[i].hasSpan() {
+			hits[i].Action = ActionBlock
+		}
+	}
+
+	return hits
+}
+
+// resolve returns the strongest action of the hits
+func resolve(hits []Hit) Action {
+	var action Action
+	for _, hit := range hits {
+		if hit.Action.severity() > action.severity() {
+			action = hit.Action
+		}
+	}
+
+	return action
+}
+
+// applyMask replaces the spans of all mask hits, overlapping spans are merged first
+func applyMask(text string, hits []Hit, mask string) string {
+	spans := make([][2]int, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Action == ActionMask && hit.hasSpan() {
+			spans = append(spans, [2]int{hit.Start, hit.End})
+		}
+	}
+	if len(spans) == 0 {
+		return text
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var builder strings.Builder
+	last := 0
+	for i := 0; i < len(spans); {
+		start, end := spans[i][0], spans[i][1]
+		for i++; i < len(spans) && spans[i][0] < end; i++ {
+			end = max(end, spans[i][1])
+		}
+		builder.WriteString(text[last:start])
+		builder.WriteString(mask)
+		last = end
+	}
+	builder.WriteString(text[last:])
+
+	return builder.String()
+}
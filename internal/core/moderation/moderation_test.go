@@ -0,0 +1,122 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubModerator reports fixed hits or an error
+type stubModerator struct {
+	hits []Hit
+	err  error
+}
+
+func (m *stubModerator) Name() string { return "stub" }
+
+func (m *stubModerator) Moderate(context.Context, Stage, string) ([]Hit, error) {
+	return m.hits, m.err
+}
+
+func TestPipelineModerate(t *testing.T) {
+	pipeline, err := NewPipeline(Config{
+		InputEnabled:  true,
+		OutputEnabled: true,
+		Keywords:      []string{"secret"},
+		Moderators: []ModeratorConfig{
+			{Type: ModeratorTypeRegex, Category: "phone", Action: ActionMask, Patterns: []string{`1\d{10}`}, Stages: []Stage{StageOutput}},
+			{Type: ModeratorTypeKeyword, Category: "watch", Action: ActionFlag, Keywords: []string{"refund"}},
+		},
+	}, nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	// Legacy keywords block inputs
+	result := pipeline.Moderate(ctx, StageInput, "tell me the secret")
+	assert.True(t, result.Blocked())
+
+	// The regex only runs on outputs, legacy keywords mask outputs
+	result = pipeline.Moderate(ctx, StageInput, "call 13800000000")
+	assert.Equal(t, Action(""), result.Action)
+	result = pipeline.Moderate(ctx, StageOutput, "the secret is 13800000000")
+	assert.Equal(t, ActionMask, result.Action)
+	assert.Equal(t, "the ** is **", result.Content)
+
+	// A flag keeps the content
+	result = pipeline.Moderate(ctx, StageOutput, "about the refund")
+	assert.Equal(t, ActionFlag, result.Action)
+	assert.Equal(t, "about the refund", result.Content)
+	assert.Equal(t, "output flag: keyword:watch(flag)", result.Summary())
+}
+
+func TestNewPipelineSkipsInvalidModerators(t *testing.T) {
+	pipeline, err := NewPipeline(Config{
+		OutputEnabled: true,
+		Moderators: []ModeratorConfig{
+			{Type: ModeratorTypeRegex, Action: ActionMask, Patterns: []string{"("}},
+			{Type: ModeratorTypeKeyword, Action: "drop", Keywords: []string{"x"}},
+			{Type: ModeratorTypeLLM, Action: ActionBlock},
+			{Type: ModeratorTypeHTTP, Action: ActionBlock, URL: "ftp://example.com"},
+			{Type: ModeratorTypeKeyword, Action: ActionBlock, Keywords: []string{"ok"}},
+		},
+	}, nil)
+	assert.Error(t, err)
+	assert.True(t, pipeline.Enabled(StageOutput))
+	assert.False(t, pipeline.Enabled(StageInput))
+	assert.True(t, pipeline.Moderate(context.Background(), StageOutput, "ok").Blocked())
+}
+
+func TestPipelineCollect(t *testing.T) {
+	pipeline := &Pipeline{mask: DefaultMask, moderators: map[Stage][]Moderator{
+		StageOutput: {
+			&stubModerator{err: errors.New("unavailable")},
+			&stubModerator{hits: []Hit{{Stage: StageOutput, Moderator: "stub", Action: ActionMask}}},
+		},
+	}}
+
+	result := pipeline.Moderate(context.Background(), StageOutput, "text")
+	assert.Len(t, result.Hits, 2)
+	// A failing moderator is recorded as a flag
+	assert.Equal(t, "error", result.Hits[0].Category)
+	assert.Equal(t, ActionFlag, result.Hits[0].Action)
+	// A mask without a span blocks the whole content
+	assert.Equal(t, ActionBlock, result.Hits[1].Action)
+	assert.True(t, result.Blocked())
+}
+
+func TestApplyMask(t *testing.T) {
+	hits := []Hit{
+		{Action: ActionMask, Start: 6, End: 9},
+		{Action: ActionMask, Start: 0, End: 3},
+		{Action: ActionMask, Start: 7, End: 11},
+		{Action: ActionFlag, Start: 12, End: 14},
+	}
+	assert.Equal(t, "**abc**xyz", applyMask("abcabcabcabxyz", hits, DefaultMask))
+	assert.Equal(t, "text", applyMask("text", nil, DefaultMask))
+}
+
+func TestNewPipelineReusesCompiledModerators(t *testing.T) {
+	cfg := Config{
+		OutputEnabled: true,
+		Keywords:      []string{"a", "b"},
+		Moderators: []ModeratorConfig{
+			{Type: ModeratorTypeRegex, Action: ActionMask, Patterns: []string{`\d+`}},
+		},
+	}
+
+	first, err := NewPipeline(cfg, nil)
+	assert.NoError(t, err)
+	second, err := NewPipeline(cfg, nil)
+	assert.NoError(t, err)
+	assert.Same(t, first.moderators[StageOutput][0], second.moderators[StageOutput][0])
+	assert.Same(t, first.moderators[StageOutput][1], second.moderators[StageOutput][1])
+
+	// A different action is a different moderator
+	cfg.Moderators[0].Action = ActionFlag
+	third, err := NewPipeline(cfg, nil)
+	assert.NoError(t, err)
+	assert.NotSame(t, first.moderators[StageOutput][1], third.moderators[StageOutput][1])
+}
@@ -0,0 +1,132 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+)
+
+// RegexModerator matches a set of regular expressions compiled once when the moderator is built
+type RegexModerator struct {
+	category string
+	act      Action
+	patterns []*regexp.Regexp
+	// maxLen is the length in runes of the longest possible match, negative when unbounded
+	maxLen int
+}
+
+// NewRegexModerator compiles the patterns, an invalid pattern fails the whole rule set
+func NewRegexModerator(category string, action Action, patterns []string) (*RegexModerator, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	maxLen := 0
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+
+		if maxLen >= 0 {
+			parsed, err := syntax.Parse(pattern, syntax.Perl)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			length := maxMatchLen(parsed.Simplify())
+			if length < 0 {
+				maxLen = -1
+			} else {
+				maxLen = max(maxLen, length)
+			}
+		}
+	}
+
+	return &RegexModerator{
+		category: category,
+		act:      action,
+		patterns: compiled,
+		maxLen:   maxLen,
+	}, nil
+}
+
+func (m *RegexModerator) Name() string {
+	return "regex"
+}
+
+func (m *RegexModerator) Moderate(_ context.Context, stage Stage, text string) ([]Hit, error) {
+	var hits []Hit
+	for _, re := range m.patterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if loc[1] == loc[0] {
+				continue
+			}
+			hits = append(hits, Hit{
+				Stage:     stage,
+				Moderator: m.Name(),
+				Category:  m.category,
+				Action:    m.act,
+				Match:     text[loc[0]:loc[1]],
+				Start:     loc[0],
+				End:       loc[1],
+			})
+		}
+	}
+
+	return hits, nil
+}
+
+func (m *RegexModerator) blocks() bool {
+	return m.act == ActionBlock
+}
+
+func (m *RegexModerator) window() int {
+	return m.maxLen
+}
+
+// maxMatchLen returns the length in runes of the longest text the expression can match, or -1
+// when it is unbounded. Zero-width assertions count as one rune, as they look at a neighbour of
+// the match that must have arrived before the match can be trusted.
+func maxMatchLen(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpNoMatch:
+		return 0
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return 1
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		return 1
+	case syntax.OpCapture, syntax.OpQuest:
+		return maxMatchLen(re.Sub[0])
+	case syntax.OpRepeat:
+		length := maxMatchLen(re.Sub[0])
+		if re.Max < 0 || length < 0 {
+			return -1
+		}
+		return length * re.Max
+	case syntax.OpConcat:
+		total := 0
+		for _, sub := range re.Sub {
+			length := maxMatchLen(sub)
+			if length < 0 {
+				return -1
+			}
+			total += length
+		}
+		return total
+	case syntax.OpAlternate:
+		longest := 0
+		for _, sub := range re.Sub {
+			length := maxMatchLen(sub)
+			if length < 0 {
+				return -1
+			}
+			longest = max(longest, length)
+		}
+		return longest
+	default:
+		// OpStar, OpPlus and anything unknown
+		return -1
+	}
+}
@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stream reviews content that arrives in deltas, such as a streamed answer. It holds back only
+// the shortest suffix that could still be the start of a match, so the rest can be shown at once
+// while no matched text is ever released.
+//
+// A stream only works for stages whose moderators can review partial content, callers must check
+// Pipeline.NeedsFullText before using one.
+type Stream struct {
+	ctx      context.Context
+	pipeline *Pipeline
+	stage    Stage
+
+	// hold is the number of trailing runes that must be held back
+	hold int
+	// text is everything received so far and base is where the pending part starts in it.
+	// Moderators always review the whole text, so anchors and word boundaries next to the
+	// pending part are evaluated against the real surrounding text.
+	text string
+	base int
+
+	result  *Result
+	emitted strings.Builder
+}
+
+// NewStream starts reviewing streamed content of the stage
+func (p *Pipeline) NewStream(ctx context.Context, stage Stage) *Stream {
+	hold := 0
+	if p.Enabled(stage) {
+		for _, moderator := range p.moderators[stage] {
+			if span, ok := moderator.(spanModerator); ok {
+				hold = max(hold, span.window()-1)
+			}
+		}
+	}
+
+	return &Stream{
+		ctx:      ctx,
+		pipeline: p,
+		stage:    stage,
+		hold:     hold,
+		result:   &Result{Stage: stage},
+	}
+}
+
+// Write adds a delta and returns the reviewed text that is safe to show, possibly empty
+func (s *Stream) Write(delta string) string {
+	s.text += delta
+	pending := s.text[s.base:]
+
+	// Any match not yet complete starts within the last hold runes
+	cut := len(pending)
+	for i := 0; i < s.hold && cut > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(pending[:cut])
+		cut -= size
+	}
+
+	return s.release(cut)
+}
+
+// Flush reviews and returns everything held back, it must be called once the stream has ended
+func (s *Stream) Flush() string {
+	return s.release(len(s.text) - s.base)
+}
+
+// Result returns the hits of everything released so far, its content is the released text
+func (s *Stream) Result() *Result {
+	s.result.Content = s.emitted.String()
+	return s.result
+}
+
+// release reviews the pending text and releases it up to the cut, the cut is relative to the pending text
+func (s *Stream) release(cut int) string {
+	if cut == 0 {
+		return ""
+	}
+	pending := s.text[s.base:]
+	if !s.pipeline.Enabled(s.stage) {
+		return s.take(pending[:cut], cut)
+	}
+
+	// Matches in the released text were handled already, the others are moved to pending offsets
+	var hits []Hit
+	for _, hit := range s.pipeline.collect(s.ctx, s.stage, s.text) {
+		if hit.hasSpan() {
+			if hit.Start < s.base {
+				continue
+			}
+			hit.Start -= s.base
+			hit.End -= s.base
+		}
+		hits = append(hits, hit)
+	}
+
+	// A match crossing the cut is complete already, it is released as a whole
+	for moved := true; moved; {
+		moved = false
+		for _, hit := range hits {
+			if hit.Start < cut && hit.End > cut {
+				cut = hit.End
+				moved = true
+			}
+		}
+	}
+
+	// Matches after the cut are found again with the next delta
+	released := make([]Hit, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Start < cut {
+			released = append(released, hit)
+		}
+	}
+	s.result.Merge(&Result{Action: resolve(released), Hits: released})
+
+	return s.take(applyMask(pending[:cut], released, s.pipeline.mask), cut)
+}
+
+// take moves the released part out of the pending text and records the text shown for it
+func (s *Stream) take(text string, cut int) string {
+	s.base += cut
+	s.emitted.WriteString(text)
+
+	return text
+}
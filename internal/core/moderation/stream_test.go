@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOutputPipeline(t *testing.T, moderators ...ModeratorConfig) *Pipeline {
+	t.Helper()
+	pipeline, err := NewPipeline(Config{OutputEnabled: true, Moderators: moderators}, nil)
+	assert.NoError(t, err)
+	assert.False(t, pipeline.NeedsFullText(StageOutput))
+	return pipeline
+}
+
+// streamAll writes the deltas one by one and returns what was shown after each of them and at the end
+func streamAll(stream *Stream, deltas []string) ([]string, string) {
+	shown := make([]string, 0, len(deltas)+1)
+	for _, delta := range deltas {
+		shown = append(shown, stream.Write(delta))
+	}
+	shown = append(shown, stream.Flush())
+	return shown, strings.Join(shown, "")
+}
+
+func TestStreamHoldsBackPartialMatches(t *testing.T) {
+	pipeline := newOutputPipeline(t, ModeratorConfig{Type: ModeratorTypeKeyword, Action: ActionMask, Keywords: []string{"secret"}})
+
+	stream := pipeline.NewStream(context.Background(), StageOutput)
+	shown, all := streamAll(stream, []string{"the sec", "ret is out", " now"})
+
+	// The keyword spans two deltas and is never shown unmasked
+	assert.Equal(t, "the ** is out now", all)
+	for _, part := range shown {
+		assert.NotContains(t, part, "sec")
+	}
+	assert.Equal(t, ActionMask, stream.Result().Action)
+	assert.Equal(t, all, stream.Result().Content)
+}
+
+func TestStreamMatchesDeltaByDelta(t *testing.T) {
+	pipeline := newOutputPipeline(t, ModeratorConfig{Type: ModeratorTypeRegex, Action: ActionMask, Patterns: []string{`1\d{10}`}})
+	text := "call 13800000000 or 13900000000 today"
+
+	for size := 1; size <= len(text); size++ {
+		var deltas []string
+		for i := 0; i < len(text); i += size {
+			deltas = append(deltas, text[i:min(i+size, len(text))])
+		}
+
+		_, all := streamAll(pipeline.NewStream(context.Background(), StageOutput), deltas)
+		assert.Equal(t, "call ** or ** today", all, "delta size %d", size)
+	}
+}
+
+func TestStreamAnchors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		deltas  []string
+		want    string
+	}{
+		{
+			name:    "start anchor only matches the start of the answer",
+			pattern: `^secret`,
+			deltas:  []string{"the answer is long enough ", "secret", " here"},
+			want:    "the answer is long enough secret here",
+		},
+		{
+			name:    "start anchor at the real start",
+			pattern: `^secret`,
+			deltas:  []string{"sec", "ret here"},
+			want:    "** here",
+		},
+		{
+			name:    "word boundary sees the released text",
+			pattern: `\bcat\b`,
+			deltas:  []string{"concat", "enate a cat", "!"},
+			want:    "concatenate a **!",
+		},
+		{
+			name:    "word boundary across the cut",
+			pattern: `\bcat\b`,
+			deltas:  []string{"a long prefix bob", "cat", " and cat"},
+			want:    "a long prefix bobcat and **",
+		},
+		{
+			name:    "no word boundary",
+			pattern: `\Bcat`,
+			deltas:  []string{"some text ", "cat and bob", "cat"},
+			want:    "some text cat and bob**",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := newOutputPipeline(t, ModeratorConfig{Type: ModeratorTypeRegex, Action: ActionMask, Patterns: []string{tt.pattern}})
+			_, all := streamAll(pipeline.NewStream(context.Background(), StageOutput), tt.deltas)
+			assert.Equal(t, tt.want, all)
+		})
+	}
+}
+
+func TestStreamDisabledStage(t *testing.T) {
+	pipeline, err := NewPipeline(Config{InputEnabled: true, Keywords: []string{"x"}}, nil)
+	assert.NoError(t, err)
+
+	stream := pipeline.NewStream(context.Background(), StageOutput)
+	assert.Equal(t, "x marks", stream.Write("x marks"))
+	assert.Equal(t, "", stream.Flush())
+}
+
+func TestNeedsFullText(t *testing.T) {
+	pipeline, err := NewPipeline(Config{
+		OutputEnabled: true,
+		Moderators:    []ModeratorConfig{{Type: ModeratorTypeKeyword, Action: ActionBlock, Keywords: []string{"x"}}},
+	}, nil)
+	assert.NoError(t, err)
+	assert.True(t, pipeline.NeedsFullText(StageOutput))
+
+	pipeline, err = NewPipeline(Config{
+		OutputEnabled: true,
+		Moderators:    []ModeratorConfig{{Type: ModeratorTypeRegex, Action: ActionMask, Patterns: []string{`a+`}}},
+	}, nil)
+	assert.NoError(t, err)
+	assert.True(t, pipeline.NeedsFullText(StageOutput))
+}
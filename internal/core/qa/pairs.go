@@ -0,0 +1,144 @@
+package qa
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/table"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/pkg/util"
+)
+
+// MaxQuestionVariants 每个问答对最多保留的相似问法数量
+const MaxQuestionVariants = 10
+
+// 识别问题、答案与相似问法列时支持的表头，比较时忽略大小写与首尾空白
+var (
+	questionHeaders = []string{"question", "questions", "q", "问题", "问"}
+	answerHeaders   = []string{"answer", "answers", "a", "答案", "答", "回答"}
+	variantHeaders  = []string{"similar_questions", "similar questions", "question_variants", "variants", "相似问题", "相似问法"}
+)
+
+// variantSeparatorPattern 相似问法列中多个问法之间的分隔符
+var variantSeparatorPattern = regexp.MustCompile(`\r?\n|\||｜|；|;`)
+
+// Pair 问答对，Variants为相似问法，召回时与问题一同参与匹配
+type Pair struct {
+	Question string
+	Answer   string
+	Variants []string
+}
+
+// ExtractPairs 从表格中提取问答对，按表头识别问题、答案与相似问法列，
+// 无法识别时使用前两列作为问题与答案，问题或答案为空的行会被跳过
+func ExtractPairs(data *table.Table) ([]Pair, error) {
+	questionIndex := headerIndex(data.Header, questionHeaders)
+	answerIndex := headerIndex(data.Header, answerHeaders)
+	variantIndex := headerIndex(data.Header, variantHeaders)
+	if questionIndex < 0 || answerIndex < 0 {
+		if len(data.Header) < 2 {
+			return nil, errors.New("问答对文件至少需要包含问题与答案两列")
+		}
+		questionIndex, answerIndex = 0, 1
+		if variantIndex == 0 || variantIndex == 1 {
+			variantIndex = -1
+		}
+	}
+
+	pairs := make([]Pair, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		pair := Pair{
+			Question: strings.TrimSpace(row[questionIndex]),
+			Answer:   strings.TrimSpace(row[answerIndex]),
+		}
+		if pair.Question == "" || pair.Answer == "" {
+			continue
+		}
+		if variantIndex >= 0 {
+			pair.Variants = NormalizeVariants(pair.Question, variantSeparatorPattern.Split(row[variantIndex], -1))
+		}
+		pairs = append(pairs, pair)
+	}
+	if len(pairs) == 0 {
+		return nil, errors.New("文件中没有有效的问答对")
+	}
+
+	return pairs, nil
+}
+
+// NormalizeVariants 去除相似问法中的空白、重复以及与问题相同的问法，最多保留MaxQuestionVariants个
+func NormalizeVariants(question string, variants []string) []string {
+	result := make([]string, 0, len(variants))
+	seen := map[string]struct{}{strings.TrimSpace(question): {}}
+	for _, variant := range variants {
+		variant = strings.TrimSpace(variant)
+		if variant == "" {
+			continue
+		}
+		if _, exists := seen[variant]; exists {
+			continue
+		}
+		seen[variant] = struct{}{}
+		result = append(result, variant)
+		if len(result) >= MaxQuestionVariants {
+			break
+		}
+	}
+
+	return result
+}
+
+// IndexContents 问答对建立索引使用的文本，问题与每个相似问法分别写入一条向量记录
+func IndexContents(question string, variants []string) []string {
+	return append([]string{question}, NormalizeVariants(question, variants)...)
+}
+
+// Hash 问答对片段的哈希，由问题与答案共同计算，修改其中任意一项后哈希都会变化
+func Hash(question, answer string) string {
+	return util.GenerateHash(question + "\n" + answer)
+}
+
+// VectorID 获取片段第index条索引文本对应的向量记录ID，问题沿用片段的NodeID，
+// 相似问法使用由NodeID与序号派生的固定ID，同一问答对的多条记录写入时不会相互覆盖
+func VectorID(nodeID uuid.UUID, index int) string {
+	if index == 0 {
+		return nodeID.String()
+	}
+
+	return uuid.NewSHA1(nodeID, []byte(strconv.Itoa(index))).String()
+}
+
+// SegmentVectorIDs 获取片段在向量数据库中可能存在的全部记录ID，用于删除片段的向量记录。
+// 问答对按最大相似问法数量生成，相似问法被修改后旧的记录同样会被删除，未建立索引的片段返回nil
+func SegmentVectorIDs(segment *entity.Segment) []string {
+	if segment.NodeID == uuid.Nil {
+		return nil
+	}
+	if segment.Question == "" {
+		return []string{segment.NodeID.String()}
+	}
+
+	ids := make([]string, 0, 1+MaxQuestionVariants)
+	for i := 0; i <= MaxQuestionVariants; i++ {
+		ids = append(ids, VectorID(segment.NodeID, i))
+	}
+	return ids
+}
+
+// headerIndex 获取表头中第一个匹配候选名称的列，不存在时返回-1
+func headerIndex(header []string, candidates []string) int {
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, candidate := range candidates {
+			if name == candidate {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
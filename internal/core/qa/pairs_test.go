@@ -0,0 +1,123 @@
+package qa
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/internal/core/table"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+func TestVectorID(t *testing.T) {
+	nodeID := uuid.New()
+
+	// 问题沿用NodeID，兼容只写入了一条记录的旧数据
+	assert.Equal(t, nodeID.String(), VectorID(nodeID, 0))
+
+	// 每个相似问法的ID互不相同，且对同一片段保持稳定
+	seen := map[string]bool{}
+	for i := 0; i <= MaxQuestionVariants; i++ {
+		id := VectorID(nodeID, i)
+		assert.False(t, seen[id])
+		seen[id] = true
+		assert.Equal(t, id, VectorID(nodeID, i))
+	}
+	assert.NotEqual(t, VectorID(nodeID, 1), VectorID(uuid.New(), 1))
+}
+
+func TestSegmentVectorIDs(t *testing.T) {
+	nodeID := uuid.New()
+
+	assert.Nil(t, SegmentVectorIDs(&entity.Segment{Content: "parent"}))
+	assert.Equal(t, []string{nodeID.String()}, SegmentVectorIDs(&entity.Segment{NodeID: nodeID, Content: "text"}))
+
+	// 问答对按最大相似问法数量生成，覆盖当前保存的相似问法与被修改前写入的记录
+	segment := &entity.Segment{NodeID: nodeID, Question: "q", QuestionVariants: []string{"v1"}}
+	ids := SegmentVectorIDs(segment)
+	assert.Len(t, ids, 1+MaxQuestionVariants)
+	for i := range IndexContents(segment.Question, segment.QuestionVariants) {
+		assert.Contains(t, ids, VectorID(nodeID, i))
+	}
+}
+
+func TestExtractPairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    *table.Table
+		want    []Pair
+		wantErr string
+	}{
+		{
+			name: "english headers in any order and case",
+			data: &table.Table{
+				Header: []string{"ID", " Answer ", "Similar Questions", "QUESTION"},
+				Rows:   [][]string{{"1", "a1", "v1|v2；q1", "q1"}},
+			},
+			want: []Pair{{Question: "q1", Answer: "a1", Variants: []string{"v1", "v2"}}},
+		},
+		{
+			name: "chinese headers",
+			data: &table.Table{
+				Header: []string{"问题", "相似问法", "答案"},
+				Rows:   [][]string{{"问1", "问法1\n问法2", "答1"}},
+			},
+			want: []Pair{{Question: "问1", Answer: "答1", Variants: []string{"问法1", "问法2"}}},
+		},
+		{
+			name: "unknown headers fall back to the first two columns",
+			data: &table.Table{
+				Header: []string{"title", "body"},
+				Rows:   [][]string{{"q1", "a1"}},
+			},
+			want: []Pair{{Question: "q1", Answer: "a1"}},
+		},
+		{
+			name: "fallback ignores a variant column among the first two",
+			data: &table.Table{
+				Header: []string{"variants", "body", "extra"},
+				Rows:   [][]string{{"q1", "a1", "x"}},
+			},
+			want: []Pair{{Question: "q1", Answer: "a1"}},
+		},
+		{
+			name: "rows without question or answer are skipped",
+			data: &table.Table{
+				Header: []string{"question", "answer"},
+				Rows:   [][]string{{" ", "a1"}, {"q2", ""}, {" q3 ", " a3 "}},
+			},
+			want: []Pair{{Question: "q3", Answer: "a3"}},
+		},
+		{
+			name:    "a single column is rejected",
+			data:    &table.Table{Header: []string{"question"}, Rows: [][]string{{"q1"}}},
+			wantErr: "至少需要包含问题与答案两列",
+		},
+		{
+			name:    "no valid pairs",
+			data:    &table.Table{Header: []string{"question", "answer"}, Rows: [][]string{{"q1", ""}}},
+			wantErr: "没有有效的问答对",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, err := ExtractPairs(tt.data)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, pairs)
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, Hash("q", "a"), Hash("q", "a"))
+	assert.NotEqual(t, Hash("q", "a"), Hash("q2", "a"))
+	assert.NotEqual(t, Hash("q", "a"), Hash("q", "a2"))
+}
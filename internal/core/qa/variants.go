@@ -0,0 +1,56 @@
+package qa
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// listMarkerPattern 匹配模型回答中每行开头的列表序号，如"1."、"2、"、"-"
+var listMarkerPattern = regexp.MustCompile(`^\s*(?:\d+[.、)）]|[-*•])\s*`)
+
+const questionVariantsPromptTemplate = `你是一个FAQ知识库的维护助手，请为下面的问答对生成{count}个与问题意思相同、但表述方式不同的相似问法，
+用于提升用户使用不同说法提问时的召回率。
+
+问题：{question}
+答案：{answer}
+
+请严格遵守以下规则：
+1. 相似问法必须能够使用上面的答案回答，不能改变问题的含义。
+2. 尽量使用用户日常提问的口语化表达，可以使用同义词、调整语序、省略或补充主语。
+3. 每行输出一个相似问法，不要输出序号、解释或其他内容。`
+
+// GenerateVariants 使用模型为问答对生成count个相似问法，返回的问法已去除重复及与问题相同的问法
+func GenerateVariants(ctx context.Context, chatModel model.BaseChatModel, pair Pair, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	count = min(count, MaxQuestionVariants)
+
+	prompt := strings.NewReplacer(
+		"{count}", strconv.Itoa(count),
+		"{question}", pair.Question,
+		"{answer}", pair.Answer,
+	).Replace(questionVariantsPromptTemplate)
+	response, err := chatModel.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate question variants: %w", err)
+	}
+
+	lines := strings.Split(response.Content, "\n")
+	variants := make([]string, 0, len(lines))
+	for _, line := range lines {
+		variants = append(variants, listMarkerPattern.ReplaceAllString(line, ""))
+	}
+	variants = NormalizeVariants(pair.Question, variants)
+	if len(variants) > count {
+		variants = variants[:count]
+	}
+
+	return variants, nil
+}
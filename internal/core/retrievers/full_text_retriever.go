@@ -12,69 +12,96 @@ import (
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 )
 
-// FullTextRetriever 全文检索器
+const (
+	// DefaultFullTextTopK 未指定召回数量时全文检索返回的最大片段数
+	DefaultFullTextTopK = 50
+
+	// bm25K1 控制词频饱和速度
+	bm25K1 = 1.2
+	// bm25B 控制片段长度归一化的强度
+	bm25B = 0.75
+)
+
+// FullTextRetriever 全文检索器，基于倒排索引与BM25打分
 type FullTextRetriever struct {
-	db           *gorm.DB
-	datasetIDs   []uuid.UUID
-	jiebaService *JiebaService
+	db             *gorm.DB
+	datasetIDs     []uuid.UUID
+	jiebaService   *JiebaService
+	metadataFilter *MetadataFilter
 }
 
-// NewFullTextRetriever 创建一个新的全文检索器，支持多个数据集
-func NewFullTextRetriever(db *gorm.DB, datasetIDs []uuid.UUID, jiebaService *JiebaService) *FullTextRetriever {
+// NewFullTextRetriever 创建一个新的全文检索器，支持多个数据集，metadataFilter为nil时不过滤片段元数据
+func NewFullTextRetriever(db *gorm.DB, datasetIDs []uuid.UUID, jiebaService *JiebaService, metadataFilter *MetadataFilter) *FullTextRetriever {
 	return &FullTextRetriever{
-		db:           db,
-		datasetIDs:   datasetIDs,
-		jiebaService: jiebaService,
+		db:             db,
+		datasetIDs:     datasetIDs,
+		jiebaService:   jiebaService,
+		metadataFilter: metadataFilter,
 	}
 }
 
+// bm25Hit 片段的BM25得分
+type bm25Hit struct {
+	SegmentID uuid.UUID
+	Score     float64
+}
+
 func (r *FullTextRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
-	// 1.将查询query转换成关键词列表
-	keywords := r.jiebaService.ExtractKeywords(query, 10)
-	if len(keywords) == 0 {
+	// 1.将查询query转换成词项列表
+	frequencies, _ := r.jiebaService.TermFrequencies(query)
+	if len(frequencies) == 0 {
 		return []*schema.Document{}, nil
 	}
+	terms := make([]string, 0, len(frequencies))
+	for term := range frequencies {
+		terms = append(terms, term)
+	}
 
-	keywordTables, err := r.getKeywordTables(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get keyword tables: %w", err)
-	}
-
-	// 3.遍历所有的知识库关键词表，找到匹配query关键词的id列表
-	var allIDs []string
-	for _, keywordTable := range keywordTables {
-		// 4.遍历每一个关键词表的每一项
-		for keyword, segmentIDs := range keywordTable {
-			// 5.如果数据存在则提取关键词对应的片段id列表
-			for _, kw := range keywords {
-				if keyword == kw {
-					allIDs = append(allIDs, segmentIDs...)
-				}
-			}
-		}
+	topK := DefaultFullTextTopK
+	if k := retriever.GetCommonOptions(&retriever.Options{}, opts...).TopK; k != nil && *k > 0 {
+		topK = *k
 	}
 
-	if len(allIDs) == 0 {
+	// 2.获取知识库的片段总数与平均长度
+	segmentCount, avgLength, err := r.getCorpusStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get corpus stats: %w", err)
+	}
+	if segmentCount == 0 {
 		return []*schema.Document{}, nil
 	}
-	// 6.统计segment_id出现的频率
-	idCounter := make(map[string]int)
-	for _, id := range allIDs {
-		idCounter[id]++
+
+	// 3.在倒排索引中计算BM25得分并取前topK个片段
+	hits, err := r.searchPostings(ctx, terms, segmentCount, avgLength, topK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search postings: %w", err)
+	}
+	if len(hits) == 0 {
+		return []*schema.Document{}, nil
 	}
 
-	// 7. 根据得到的id列表检索数据库得到片段列表信息
-	segments, err := r.getSegmentsByIDs(ctx, allIDs)
+	// 4. 根据得到的id列表检索数据库得到片段列表信息
+	segmentIDs := make([]uuid.UUID, 0, len(hits))
+	for _, hit := range hits {
+		segmentIDs = append(segmentIDs, hit.SegmentID)
+	}
+	segments, err := r.getSegmentsByIDs(ctx, segmentIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get segments: %w", err)
 	}
+	segmentMap := make(map[uuid.UUID]entity.Segment, len(segments))
+	for _, segment := range segments {
+		segmentMap[segment.ID] = segment
+	}
 
-	// 8. 根据频率进行排序
-	sortedSegments := r.sortSegmentsByFrequency(segments, allIDs, idCounter)
+	// 5. 按照得分顺序构建 Document 列表
+	documents := make([]*schema.Document, 0, len(hits))
+	for _, hit := range hits {
+		segment, exists := segmentMap[hit.SegmentID]
+		if !exists {
+			continue
+		}
 
-	// 9. 构建 Document 列表
-	documents := make([]*schema.Document, 0, len(sortedSegments))
-	for _, segment := range sortedSegments {
 		doc := &schema.Document{
 			Content: segment.Content,
 			MetaData: map[string]any{
@@ -85,7 +112,8 @@ func (r *FullTextRetriever) Retrieve(ctx context.Context, query string, opts ...
 				"node_id":          segment.NodeID,
 				"document_enabled": true,
 				"segment_enabled":  true,
-				"score":            0,
+				"metadata":         segment.Metadata,
+				"score":            hit.Score,
 			},
 		}
 		documents = append(documents, doc)
@@ -94,44 +122,77 @@ func (r *FullTextRetriever) Retrieve(ctx context.Context, query string, opts ...
 	return documents, nil
 }
 
-// getKeywordTables 获取知识库关键词表
-func (r *FullTextRetriever) getKeywordTables(ctx context.Context) ([]map[string][]string, error) {
-	var keywords []entity.Keyword
+// getCorpusStats 汇总所选知识库的片段总数与平均片段长度
+func (r *FullTextRetriever) getCorpusStats(ctx context.Context) (int64, float64, error) {
+	var stats struct {
+		SegmentCount int64
+		TotalLength  int64
+	}
 
-	// 查询指定数据集的关键词表
-	err := r.db.Where("dataset_id IN ?", r.datasetIDs).Find(&keywords).Error
+	err := r.db.WithContext(ctx).Model(&entity.Keyword{}).
+		Select("COALESCE(SUM(segment_count), 0) AS segment_count, COALESCE(SUM(total_length), 0) AS total_length").
+		Where("dataset_id IN ?", r.datasetIDs).
+		Scan(&stats).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to query keyword tables: %w", err)
+		return 0, 0, err
+	}
+	if stats.SegmentCount <= 0 {
+		return 0, 0, nil
 	}
 
-	// 转换为 map 格式
-	result := make([]map[string][]string, 0, len(keywords))
-	for _, kt := range keywords {
-		if kt.KeywordMap != nil {
-			result = append(result, kt.KeywordMap)
-		}
+	avgLength := float64(stats.TotalLength) / float64(stats.SegmentCount)
+	if avgLength <= 0 {
+		avgLength = 1
 	}
 
-	return result, nil
+	return stats.SegmentCount, avgLength, nil
 }
 
-// getSegmentsByIDs 根据ID获取片段
-func (r *FullTextRetriever) getSegmentsByIDs(ctx context.Context, ids []string) ([]entity.Segment, error) {
-	// Convert string IDs to UUIDs
-	uuids := make([]uuid.UUID, 0, len(ids))
-	for _, id := range ids {
-		if parsedUUID, err := uuid.Parse(id); err == nil {
-			uuids = append(uuids, parsedUUID)
-		}
+// searchPostings 读取查询词项的倒排记录，按BM25累加每个片段的得分
+func (r *FullTextRetriever) searchPostings(ctx context.Context, terms []string, segmentCount int64, avgLength float64, topK int) ([]bm25Hit, error) {
+	var hits []bm25Hit
+	if err := r.postingsQuery(ctx, terms, segmentCount, avgLength, topK).Scan(&hits).Error; err != nil {
+		return nil, err
 	}
 
-	if len(uuids) == 0 {
-		return []entity.Segment{}, nil
+	return hits, nil
+}
+
+// postingsQuery 构建BM25打分查询，得分为每个词项的IDF与饱和词频的乘积之和
+func (r *FullTextRetriever) postingsQuery(ctx context.Context, terms []string, segmentCount int64, avgLength float64, topK int) *gorm.DB {
+	// 词项的文档频率，用于计算IDF
+	documentFrequency := r.db.Model(&entity.KeywordPosting{}).
+		Select("term AS df_term, COUNT(*) AS df").
+		Where("dataset_id IN ? AND term IN ?", r.datasetIDs, terms).
+		Group("term")
+
+	query := r.db.WithContext(ctx).Model(&entity.KeywordPosting{}).
+		Select(`segment_id,
+			SUM(LN(1 + (CAST(? AS DOUBLE PRECISION) - f.df + 0.5) / (f.df + 0.5))
+				* term_frequency * CAST(? AS DOUBLE PRECISION)
+				/ (term_frequency + CAST(? AS DOUBLE PRECISION) * (1 - CAST(? AS DOUBLE PRECISION) + CAST(? AS DOUBLE PRECISION) * segment_length / CAST(? AS DOUBLE PRECISION)))) AS score`,
+			segmentCount, bm25K1+1, bm25K1, bm25B, bm25B, avgLength).
+		Joins("JOIN (?) AS f ON f.df_term = term", documentFrequency).
+		Where("dataset_id IN ? AND term IN ?", r.datasetIDs, terms)
+	// 只在元数据满足过滤条件的片段中打分
+	if r.metadataFilter != nil {
+		query = query.Where("segment_id IN (?)", r.db.Model(&entity.Segment{}).
+			Select("id").
+			Where("dataset_id IN ?", r.datasetIDs).
+			Where(r.metadataFilter.Expression()))
 	}
 
+	return query.
+		Group("segment_id").
+		Order("score DESC").
+		Limit(topK)
+}
+
+// getSegmentsByIDs 根据ID获取已启用的片段
+func (r *FullTextRetriever) getSegmentsByIDs(ctx context.Context, ids []uuid.UUID) ([]entity.Segment, error) {
 	var segments []entity.Segment
 	err := r.db.WithContext(ctx).
-		Where("id IN ? AND dataset_id IN ?", uuids, r.datasetIDs).
+		Where("id IN ? AND dataset_id IN ? AND enabled = ?", ids, r.datasetIDs, true).
 		Find(&segments).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to query segments: %w", err)
@@ -139,22 +200,3 @@ func (r *FullTextRetriever) getSegmentsByIDs(ctx context.Context, ids []string)
 
 	return segments, nil
 }
-
-// sortSegmentsByFrequency 根据频率对片段进行排序
-func (r *FullTextRetriever) sortSegmentsByFrequency(segments []entity.Segment, topKIDs []string, idCounter map[string]int) []entity.Segment {
-	// 创建片段映射
-	segmentMap := make(map[string]entity.Segment)
-	for _, segment := range segments {
-		segmentMap[segment.ID.String()] = segment
-	}
-
-	// 按照频率排序返回片段
-	sortedSegments := make([]entity.Segment, 0, len(topKIDs))
-	for _, id := range topKIDs {
-		if segment, exists := segmentMap[id]; exists {
-			sortedSegments = append(sortedSegments, segment)
-		}
-	}
-
-	return sortedSegments
-}
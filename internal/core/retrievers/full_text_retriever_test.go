@@ -0,0 +1,67 @@
+package retrievers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB returns a postgres gorm.DB that only builds statements, no connection is made
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestPostingsQuery(t *testing.T) {
+	datasetIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	r := NewFullTextRetriever(dryRunDB(t), datasetIDs, nil, nil)
+
+	stmt := r.postingsQuery(context.Background(), []string{"向量", "检索"}, 100, 42.5, 5).Find(&[]bm25Hit{}).Statement
+	sql := stmt.SQL.String()
+
+	// IDF uses the smoothed form ln(1 + (N - df + 0.5) / (df + 0.5)) so common terms never score negative
+	assert.Contains(t, sql, "LN(1 + (CAST($1 AS DOUBLE PRECISION) - f.df + 0.5) / (f.df + 0.5))")
+	// tf * (k1 + 1) / (tf + k1 * (1 - b + b * len / avgLen))
+	assert.Contains(t, sql, "* term_frequency * CAST($2 AS DOUBLE PRECISION)")
+	assert.Contains(t, sql, "/ (term_frequency + CAST($3 AS DOUBLE PRECISION) * (1 - CAST($4 AS DOUBLE PRECISION) + CAST($5 AS DOUBLE PRECISION) * segment_length / CAST($6 AS DOUBLE PRECISION)))")
+	// document frequency is counted per term over the selected datasets only
+	assert.Contains(t, sql, `JOIN (SELECT term AS df_term, COUNT(*) AS df FROM "keyword_postings" WHERE dataset_id IN ($7,$8) AND term IN ($9,$10) GROUP BY "term") AS f ON f.df_term = term`)
+	assert.Contains(t, sql, `WHERE dataset_id IN ($11,$12) AND term IN ($13,$14) GROUP BY "segment_id" ORDER BY score DESC LIMIT $15`)
+	assert.NotContains(t, sql, `"segments"`)
+
+	assert.Equal(t, []any{
+		int64(100), bm25K1 + 1, bm25K1, bm25B, bm25B, 42.5,
+		datasetIDs[0], datasetIDs[1], "向量", "检索",
+		datasetIDs[0], datasetIDs[1], "向量", "检索",
+		5,
+	}, stmt.Vars)
+}
+
+func TestPostingsQueryMetadataFilter(t *testing.T) {
+	datasetID := uuid.New()
+	filter, err := ParseMetadataFilter(map[string]any{
+		"conditions": []any{
+			map[string]any{"field": "lang", "op": "eq", "value": "zh"},
+		},
+	})
+	assert.NoError(t, err)
+	r := NewFullTextRetriever(dryRunDB(t), []uuid.UUID{datasetID}, nil, filter)
+
+	stmt := r.postingsQuery(context.Background(), []string{"检索"}, 10, 8, 3).Find(&[]bm25Hit{}).Statement
+
+	// only segments matching the filter are scored, the segments are limited to the selected datasets too
+	assert.Contains(t, stmt.SQL.String(),
+		`AND segment_id IN (SELECT "id" FROM "segments" WHERE dataset_id IN ($11) AND (metadata @> CAST($12 AS JSONB)))`)
+	assert.Equal(t, datasetID, stmt.Vars[10])
+	assert.Equal(t, `{"lang":"zh"}`, stmt.Vars[11])
+	assert.Equal(t, 3, stmt.Vars[len(stmt.Vars)-1])
+}
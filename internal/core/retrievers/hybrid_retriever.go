@@ -10,59 +10,194 @@ import (
 	"github.com/google/uuid"
 )
 
+// FusionMethod 混合检索的结果融合方式
+type FusionMethod string
+
+const (
+	// FusionWeighted 两路得分各自做min-max归一化后加权求和
+	FusionWeighted FusionMethod = "weighted"
+	// FusionRRF 倒数排名融合，只依赖两路结果的排名
+	FusionRRF FusionMethod = "rrf"
+	// FusionSemanticFirst 优先使用语义检索结果，不足k个时用全文检索结果补齐
+	FusionSemanticFirst FusionMethod = "semantic_first"
+)
+
+const (
+	// DefaultRRFK 倒数排名融合的平滑常数
+	DefaultRRFK = 60
+	// DefaultCandidateTopK 每一路检索默认召回的候选数量
+	DefaultCandidateTopK = 20
+	// MaxCandidateTopK 每一路检索最多召回的候选数量
+	MaxCandidateTopK = 100
+)
+
+// FusionConfig 混合检索的融合配置
+type FusionConfig struct {
+	Method         FusionMethod
+	SemanticWeight float64
+	FullTextWeight float64
+	RRFK           int
+	SemanticTopK   int
+	FullTextTopK   int
+}
+
+// DefaultFusionConfig 返回默认的融合配置，两路等权加权融合
+func DefaultFusionConfig() *FusionConfig {
+	return &FusionConfig{
+		Method:         FusionWeighted,
+		SemanticWeight: 0.5,
+		FullTextWeight: 0.5,
+		RRFK:           DefaultRRFK,
+		SemanticTopK:   DefaultCandidateTopK,
+		FullTextTopK:   DefaultCandidateTopK,
+	}
+}
+
+// ParseFusionConfig 解析检索配置中的fusion融合配置，未配置时返回默认配置
+func ParseFusionConfig(value any) (*FusionConfig, error) {
+	config := DefaultFusionConfig()
+	if value == nil {
+		return config, nil
+	}
+
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("fusion config must be an object")
+	}
+
+	if method, exists := m["method"]; exists {
+		s, ok := method.(string)
+		switch FusionMethod(s) {
+		case FusionWeighted, FusionRRF, FusionSemanticFirst:
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("unsupported fusion method: %v", method)
+		}
+		config.Method = FusionMethod(s)
+	}
+
+	semanticWeight, hasSemantic, err := fusionNumber(m, "semantic_weight")
+	if err != nil {
+		return nil, err
+	}
+	fullTextWeight, hasFullText, err := fusionNumber(m, "full_text_weight")
+	if err != nil {
+		return nil, err
+	}
+	// 只配置一路权重时，另一路取其补数
+	switch {
+	case hasSemantic && hasFullText:
+		config.SemanticWeight, config.FullTextWeight = semanticWeight, fullTextWeight
+	case hasSemantic:
+		config.SemanticWeight, config.FullTextWeight = semanticWeight, 1-semanticWeight
+	case hasFullText:
+		config.SemanticWeight, config.FullTextWeight = 1-fullTextWeight, fullTextWeight
+	}
+	if config.SemanticWeight < 0 || config.FullTextWeight < 0 || config.SemanticWeight+config.FullTextWeight <= 0 {
+		return nil, fmt.Errorf("fusion weights must be non-negative and not both zero")
+	}
+	total := config.SemanticWeight + config.FullTextWeight
+	config.SemanticWeight, config.FullTextWeight = config.SemanticWeight/total, config.FullTextWeight/total
+
+	if rrfK, exists, err := fusionNumber(m, "rrf_k"); err != nil {
+		return nil, err
+	} else if exists {
+		if rrfK < 1 || rrfK > 1000 {
+			return nil, fmt.Errorf("rrf_k must be between 1 and 1000")
+		}
+		config.RRFK = int(rrfK)
+	}
+
+	for key, target := range map[string]*int{"semantic_top_k": &config.SemanticTopK, "full_text_top_k": &config.FullTextTopK} {
+		topK, exists, err := fusionNumber(m, key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		if topK < 1 || topK > MaxCandidateTopK {
+			return nil, fmt.Errorf("%s must be between 1 and %d", key, MaxCandidateTopK)
+		}
+		*target = int(topK)
+	}
+
+	return config, nil
+}
+
+// fusionNumber 读取融合配置中的数值，兼容JSON解码的float64与代码传入的int
+func fusionNumber(m map[string]any, key string) (float64, bool, error) {
+	value, exists := m[key]
+	if !exists {
+		return 0, false, nil
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, true, nil
+	case float32:
+		return float64(v), true, nil
+	case int:
+		return float64(v), true, nil
+	default:
+		return 0, false, fmt.Errorf("%s must be a number", key)
+	}
+}
+
 // HybridRetriever 混合检索器，结合全文检索和语义检索
 type HybridRetriever struct {
 	fullTextRetriever retriever.Retriever
 	semanticRetriever retriever.Retriever
 	datasetIDs        []uuid.UUID
-	fullTextWeight    float64
-	semanticWeight    float64
+	config            *FusionConfig
+	k                 int
+	scoreThreshold    float64
 }
 
-// NewHybridRetriever 创建一个新的混合检索器，支持多个数据集
+// NewHybridRetriever 创建一个新的混合检索器，支持多个数据集，k小于等于0时保留所有融合后的结果。
+// 只有语义相似度的取值范围是固定的，scoreThreshold仅用于过滤语义检索的候选结果
 func NewHybridRetriever(fullTextRetriever retriever.Retriever, semanticRetriever retriever.Retriever,
-	datasetIDs []uuid.UUID, options map[string]any) *HybridRetriever {
-	// 默认权重配置
-	fullTextWeight := 0.5
-	semanticWeight := 0.5
-
-	if weight, ok := options["full_text_weight"].(float64); ok {
-		fullTextWeight = weight
-	}
-	if weight, ok := options["semantic_weight"].(float64); ok {
-		semanticWeight = weight
-	}
-
-	// 确保权重总和为1
-	totalWeight := fullTextWeight + semanticWeight
-	if totalWeight > 0 {
-		fullTextWeight = fullTextWeight / totalWeight
-		semanticWeight = semanticWeight / totalWeight
+	datasetIDs []uuid.UUID, config *FusionConfig, k int, scoreThreshold float64) *HybridRetriever {
+	if config == nil {
+		config = DefaultFusionConfig()
 	}
 
 	return &HybridRetriever{
 		fullTextRetriever: fullTextRetriever,
 		semanticRetriever: semanticRetriever,
 		datasetIDs:        datasetIDs,
-		fullTextWeight:    fullTextWeight,
-		semanticWeight:    semanticWeight,
+		config:            config,
+		k:                 k,
+		scoreThreshold:    scoreThreshold,
 	}
 }
 
 func (r *HybridRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
-	// 1. 并行执行全文检索和语义检索
+	// 调用方传入的召回数量与阈值优先，例如重排序检索器需要更多的候选结果
+	k, scoreThreshold := r.k, r.scoreThreshold
+	options := retriever.GetCommonOptions(&retriever.Options{}, opts...)
+	if options.TopK != nil && *options.TopK > 0 {
+		k = *options.TopK
+	}
+	if options.ScoreThreshold != nil {
+		scoreThreshold = *options.ScoreThreshold
+	}
+
+	// 1. 并行执行全文检索和语义检索，每一路按各自的候选数量召回
 	fullTextChan := make(chan retrievalResult, 1)
 	semanticChan := make(chan retrievalResult, 1)
 
 	// 启动全文检索
 	go func() {
-		docs, err := r.fullTextRetriever.Retrieve(ctx, query)
+		docs, err := r.fullTextRetriever.Retrieve(ctx, query, retriever.WithTopK(r.config.FullTextTopK))
 		fullTextChan <- retrievalResult{docs: docs, err: err}
 	}()
 
 	// 启动语义检索
 	go func() {
-		docs, err := r.semanticRetriever.Retrieve(ctx, query)
+		docs, err := r.semanticRetriever.Retrieve(ctx, query, retriever.WithTopK(r.config.SemanticTopK))
 		semanticChan <- retrievalResult{docs: docs, err: err}
 	}()
 
@@ -78,10 +213,34 @@ func (r *HybridRetriever) Retrieve(ctx context.Context, query string, opts ...re
 		return nil, fmt.Errorf("semantic retrieval failed: %w", semanticResult.err)
 	}
 
-	// 2. 合并和重新排序结果
-	mergedDocs := r.mergeAndRerankResults(fullTextResult.docs, semanticResult.docs)
+	// 2. 整理两路候选结果，过滤无效的片段与低于阈值的语义结果
+	fullTextCandidates := r.candidates(fullTextResult.docs, r.config.FullTextTopK, 0)
+	semanticCandidates := r.candidates(semanticResult.docs, r.config.SemanticTopK, scoreThreshold)
+
+	// 3. 按配置的方式融合结果
+	var fused []*hybridDocument
+	switch r.config.Method {
+	case FusionRRF:
+		fused = r.fuseRRF(fullTextCandidates, semanticCandidates)
+	case FusionSemanticFirst:
+		fused = r.fuseSemanticFirst(fullTextCandidates, semanticCandidates)
+	default:
+		fused = r.fuseWeighted(fullTextCandidates, semanticCandidates)
+	}
 
-	return mergedDocs, nil
+	// 4. 截取前k个结果，并记录各路得分
+	if k > 0 && len(fused) > k {
+		fused = fused[:k]
+	}
+	result := make([]*schema.Document, 0, len(fused))
+	for _, hybridDoc := range fused {
+		hybridDoc.document.MetaData["score"] = hybridDoc.combinedScore
+		hybridDoc.document.MetaData["full_text_score"] = hybridDoc.fullTextScore
+		hybridDoc.document.MetaData["semantic_score"] = hybridDoc.semanticScore
+		result = append(result, hybridDoc.document)
+	}
+
+	return result, nil
 }
 
 // retrievalResult 检索结果结构
@@ -90,115 +249,182 @@ type retrievalResult struct {
 	err  error
 }
 
-// mergeAndRerankResults 合并和重新排序结果
-func (r *HybridRetriever) mergeAndRerankResults(fullTextDocs []*schema.Document, semanticDocs []*schema.Document) []*schema.Document {
-	// 创建文档映射，以segment_id为键
-	docMap := make(map[string]*hybridDocument)
+// hybridDocument 混合文档结构，排名从1开始，未命中的一路排名为0
+type hybridDocument struct {
+	document      *schema.Document
+	fullTextRank  int
+	semanticRank  int
+	fullTextScore float64
+	semanticScore float64
+	combinedScore float64
+}
+
+// candidate 单路检索的候选结果
+type candidate struct {
+	segmentID string
+	document  *schema.Document
+	rank      int
+	score     float64
+}
 
-	// 处理全文检索结果
-	for i, doc := range fullTextDocs {
+// candidates 整理单路检索结果，剔除无效及重复的片段、低于阈值的结果，并截取前topK个
+func (r *HybridRetriever) candidates(docs []*schema.Document, topK int, scoreThreshold float64) []candidate {
+	result := make([]candidate, 0, len(docs))
+	seen := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
 		segmentID := r.getSegmentID(doc)
-		if segmentID == "" {
+		if segmentID == "" || !r.isFromValidDataset(doc) {
+			continue
+		}
+		if _, exists := seen[segmentID]; exists {
 			continue
 		}
 
-		// 验证文档是否属于指定的数据集
-		if !r.isFromValidDataset(doc) {
+		score := documentScore(doc)
+		if scoreThreshold > 0 && score < scoreThreshold {
 			continue
 		}
 
-		hybridDoc := &hybridDocument{
-			document:      doc,
-			fullTextRank:  i + 1,
-			semanticRank:  -1,
-			fullTextScore: 1.0 / float64(i+1), // 基于排名的分数
-			semanticScore: 0.0,
-			combinedScore: 0.0,
+		seen[segmentID] = struct{}{}
+		result = append(result, candidate{segmentID: segmentID, document: doc, rank: len(result) + 1, score: score})
+		if topK > 0 && len(result) >= topK {
+			break
 		}
-		docMap[segmentID] = hybridDoc
 	}
 
-	// 处理语义检索结果
-	for i, doc := range semanticDocs {
-		segmentID := r.getSegmentID(doc)
-		if segmentID == "" {
-			continue
+	return result
+}
+
+// fuseWeighted 两路得分各自min-max归一化后加权求和，未命中的一路得分为0
+func (r *HybridRetriever) fuseWeighted(fullTextCandidates, semanticCandidates []candidate) []*hybridDocument {
+	docMap, order := r.merge(fullTextCandidates, semanticCandidates)
+
+	fullTextNorm := minMaxNormalize(fullTextCandidates)
+	semanticNorm := minMaxNormalize(semanticCandidates)
+	for _, hybridDoc := range docMap {
+		segmentID := r.getSegmentID(hybridDoc.document)
+		hybridDoc.combinedScore = r.config.FullTextWeight*fullTextNorm[segmentID] + r.config.SemanticWeight*semanticNorm[segmentID]
+	}
+
+	return sortByCombinedScore(docMap, order)
+}
+
+// fuseRRF 倒数排名融合，每一路贡献 权重/(rrf_k+排名)
+func (r *HybridRetriever) fuseRRF(fullTextCandidates, semanticCandidates []candidate) []*hybridDocument {
+	docMap, order := r.merge(fullTextCandidates, semanticCandidates)
+
+	rrfK := float64(r.config.RRFK)
+	for _, hybridDoc := range docMap {
+		score := 0.0
+		if hybridDoc.fullTextRank > 0 {
+			score += r.config.FullTextWeight / (rrfK + float64(hybridDoc.fullTextRank))
+		}
+		if hybridDoc.semanticRank > 0 {
+			score += r.config.SemanticWeight / (rrfK + float64(hybridDoc.semanticRank))
 		}
+		hybridDoc.combinedScore = score
+	}
 
-		// 验证文档是否属于指定的数据集 - 修复：支持多个数据集
-		if !r.isFromValidDataset(doc) {
+	return sortByCombinedScore(docMap, order)
+}
+
+// fuseSemanticFirst 语义检索结果按原顺序在前，全文检索结果按BM25顺序补齐，
+// 补齐结果的得分为归一化后的全文得分按最后一个语义得分缩放，保证得分与顺序一致
+func (r *HybridRetriever) fuseSemanticFirst(fullTextCandidates, semanticCandidates []candidate) []*hybridDocument {
+	docMap, _ := r.merge(fullTextCandidates, semanticCandidates)
+
+	result := make([]*hybridDocument, 0, len(docMap))
+	fillScale := 1.0
+	for _, c := range semanticCandidates {
+		hybridDoc := docMap[c.segmentID]
+		hybridDoc.combinedScore = hybridDoc.semanticScore
+		fillScale = hybridDoc.semanticScore
+		result = append(result, hybridDoc)
+	}
+
+	fullTextNorm := minMaxNormalize(fullTextCandidates)
+	for _, c := range fullTextCandidates {
+		hybridDoc := docMap[c.segmentID]
+		if hybridDoc.semanticRank > 0 {
 			continue
 		}
+		hybridDoc.combinedScore = fullTextNorm[c.segmentID] * fillScale
+		result = append(result, hybridDoc)
+	}
 
-		if hybridDoc, exists := docMap[segmentID]; exists {
-			// 文档在两个结果中都存在
-			hybridDoc.semanticRank = i + 1
-			if score, ok := doc.MetaData["score"].(float64); ok {
-				hybridDoc.semanticScore = score
-			} else if score, ok := doc.MetaData["score"].(float32); ok {
-				hybridDoc.semanticScore = float64(score)
-			} else {
-				hybridDoc.semanticScore = 1.0 / float64(i+1)
-			}
-		} else {
-			// 文档只在语义检索结果中存在
-			semanticScore := 0.0
-			if score, ok := doc.MetaData["score"].(float64); ok {
-				semanticScore = score
-			} else if score, ok := doc.MetaData["score"].(float32); ok {
-				semanticScore = float64(score)
-			} else {
-				semanticScore = 1.0 / float64(i+1)
-			}
-
-			hybridDoc := &hybridDocument{
-				document:      doc,
-				fullTextRank:  -1,
-				semanticRank:  i + 1,
-				fullTextScore: 0.0,
-				semanticScore: semanticScore,
-				combinedScore: 0.0,
-			}
-			docMap[segmentID] = hybridDoc
-		}
-	}
-
-	// 计算组合分数
-	for _, hybridDoc := range docMap {
-		hybridDoc.combinedScore = r.fullTextWeight*hybridDoc.fullTextScore + r.semanticWeight*hybridDoc.semanticScore
-		// 更新文档元数据中的分数
-		hybridDoc.document.MetaData["score"] = hybridDoc.combinedScore
-		hybridDoc.document.MetaData["full_text_score"] = hybridDoc.fullTextScore
-		hybridDoc.document.MetaData["semantic_score"] = hybridDoc.semanticScore
+	return result
+}
+
+// merge 以segment_id合并两路候选结果，返回合并后的文档及首次出现的顺序
+func (r *HybridRetriever) merge(fullTextCandidates, semanticCandidates []candidate) (map[string]*hybridDocument, []string) {
+	docMap := make(map[string]*hybridDocument, len(fullTextCandidates)+len(semanticCandidates))
+	order := make([]string, 0, len(fullTextCandidates)+len(semanticCandidates))
+
+	for _, c := range semanticCandidates {
+		docMap[c.segmentID] = &hybridDocument{document: c.document, semanticRank: c.rank, semanticScore: c.score}
+		order = append(order, c.segmentID)
+	}
+	for _, c := range fullTextCandidates {
+		if hybridDoc, exists := docMap[c.segmentID]; exists {
+			hybridDoc.fullTextRank = c.rank
+			hybridDoc.fullTextScore = c.score
+			continue
+		}
+		docMap[c.segmentID] = &hybridDocument{document: c.document, fullTextRank: c.rank, fullTextScore: c.score}
+		order = append(order, c.segmentID)
 	}
 
-	// 转换为切片并按组合分数排序
-	hybridDocs := make([]*hybridDocument, 0, len(docMap))
-	for _, hybridDoc := range docMap {
-		hybridDocs = append(hybridDocs, hybridDoc)
+	return docMap, order
+}
+
+// sortByCombinedScore 按融合得分降序排列，得分相同时保持合并顺序
+func sortByCombinedScore(docMap map[string]*hybridDocument, order []string) []*hybridDocument {
+	result := make([]*hybridDocument, 0, len(order))
+	for _, segmentID := range order {
+		result = append(result, docMap[segmentID])
 	}
 
-	sort.Slice(hybridDocs, func(i, j int) bool {
-		return hybridDocs[i].combinedScore > hybridDocs[j].combinedScore
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].combinedScore > result[j].combinedScore
 	})
 
-	// 提取最终文档列表
-	result := make([]*schema.Document, 0, len(hybridDocs))
-	for _, hybridDoc := range hybridDocs {
-		result = append(result, hybridDoc.document)
+	return result
+}
+
+// minMaxNormalize 将单路得分归一化到[0,1]，所有得分相同时均视为1
+func minMaxNormalize(candidates []candidate) map[string]float64 {
+	normalized := make(map[string]float64, len(candidates))
+	if len(candidates) == 0 {
+		return normalized
 	}
 
-	return result
+	minScore, maxScore := candidates[0].score, candidates[0].score
+	for _, c := range candidates[1:] {
+		minScore = min(minScore, c.score)
+		maxScore = max(maxScore, c.score)
+	}
+
+	for _, c := range candidates {
+		if maxScore == minScore {
+			normalized[c.segmentID] = 1
+			continue
+		}
+		normalized[c.segmentID] = (c.score - minScore) / (maxScore - minScore)
+	}
+
+	return normalized
 }
 
-// hybridDocument 混合文档结构
-type hybridDocument struct {
-	document      *schema.Document
-	fullTextRank  int
-	semanticRank  int
-	fullTextScore float64
-	semanticScore float64
-	combinedScore float64
+// documentScore 读取检索结果的得分
+func documentScore(doc *schema.Document) float64 {
+	switch score := doc.MetaData["score"].(type) {
+	case float64:
+		return score
+	case float32:
+		return float64(score)
+	default:
+		return doc.Score()
+	}
 }
 
 // getSegmentID 从文档元数据中获取segment_id
@@ -0,0 +1,185 @@
+package retrievers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubRetriever returns fixed documents and records the top k it was asked for
+type stubRetriever struct {
+	docs []*schema.Document
+	err  error
+	topK int
+}
+
+func (r *stubRetriever) Retrieve(_ context.Context, _ string, opts ...retriever.Option) ([]*schema.Document, error) {
+	if k := retriever.GetCommonOptions(&retriever.Options{}, opts...).TopK; k != nil {
+		r.topK = *k
+	}
+
+	return r.docs, r.err
+}
+
+var fusionDatasetID = uuid.New()
+
+func scoredDoc(segmentID string, score float64) *schema.Document {
+	return &schema.Document{
+		Content: segmentID,
+		MetaData: map[string]any{
+			"segment_id": segmentID,
+			"dataset_id": fusionDatasetID.String(),
+			"score":      score,
+		},
+	}
+}
+
+func segmentIDs(docs []*schema.Document) []string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.MetaData["segment_id"].(string))
+	}
+
+	return ids
+}
+
+func TestParseFusionConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    *FusionConfig
+		wantErr bool
+	}{
+		{name: "default", value: nil, want: DefaultFusionConfig()},
+		{
+			name:  "rrf with both weights normalized",
+			value: map[string]any{"method": "rrf", "semantic_weight": 3.0, "full_text_weight": 1, "rrf_k": 10},
+			want:  &FusionConfig{Method: FusionRRF, SemanticWeight: 0.75, FullTextWeight: 0.25, RRFK: 10, SemanticTopK: 20, FullTextTopK: 20},
+		},
+		{
+			name:  "single weight takes the complement",
+			value: map[string]any{"semantic_weight": 0.7},
+			want:  &FusionConfig{Method: FusionWeighted, SemanticWeight: 0.7, FullTextWeight: 1 - 0.7, RRFK: 60, SemanticTopK: 20, FullTextTopK: 20},
+		},
+		{
+			name:  "candidate top k",
+			value: map[string]any{"method": "semantic_first", "semantic_top_k": 5, "full_text_top_k": float64(100)},
+			want:  &FusionConfig{Method: FusionSemanticFirst, SemanticWeight: 0.5, FullTextWeight: 0.5, RRFK: 60, SemanticTopK: 5, FullTextTopK: 100},
+		},
+		{name: "not an object", value: "rrf", wantErr: true},
+		{name: "unknown method", value: map[string]any{"method": "max"}, wantErr: true},
+		{name: "method not a string", value: map[string]any{"method": 1}, wantErr: true},
+		{name: "negative weight", value: map[string]any{"semantic_weight": 1.5}, wantErr: true},
+		{name: "zero weights", value: map[string]any{"semantic_weight": 0, "full_text_weight": 0}, wantErr: true},
+		{name: "weight not a number", value: map[string]any{"semantic_weight": "0.5"}, wantErr: true},
+		{name: "rrf_k out of range", value: map[string]any{"rrf_k": 0}, wantErr: true},
+		{name: "top k out of range", value: map[string]any{"semantic_top_k": MaxCandidateTopK + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFusionConfig(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Method, got.Method)
+			assert.InDelta(t, tt.want.SemanticWeight, got.SemanticWeight, 1e-9)
+			assert.InDelta(t, tt.want.FullTextWeight, got.FullTextWeight, 1e-9)
+			assert.Equal(t, tt.want.RRFK, got.RRFK)
+			assert.Equal(t, tt.want.SemanticTopK, got.SemanticTopK)
+			assert.Equal(t, tt.want.FullTextTopK, got.FullTextTopK)
+		})
+	}
+}
+
+func TestHybridRetrieverWeighted(t *testing.T) {
+	// BM25 scores are unbounded, both lists are min-max normalized before weighting
+	fullText := &stubRetriever{docs: []*schema.Document{scoredDoc("a", 12), scoredDoc("b", 7), scoredDoc("c", 2)}}
+	semantic := &stubRetriever{docs: []*schema.Document{scoredDoc("c", 0.9), scoredDoc("b", 0.8), scoredDoc("d", 0.4)}}
+	config := DefaultFusionConfig()
+	config.SemanticWeight, config.FullTextWeight = 0.6, 0.4
+
+	docs, err := NewHybridRetriever(fullText, semantic, []uuid.UUID{fusionDatasetID}, config, 0, 0).Retrieve(context.Background(), "q")
+	assert.NoError(t, err)
+
+	// a: 0.4*1, b: 0.4*0.5 + 0.6*0.8, c: 0.6*1, d: 0
+	assert.Equal(t, []string{"b", "c", "a", "d"}, segmentIDs(docs))
+	assert.InDelta(t, 0.68, docs[0].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.6, docs[1].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.4, docs[2].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.0, docs[3].MetaData["score"], 1e-9)
+	assert.Equal(t, 7.0, docs[0].MetaData["full_text_score"])
+	assert.Equal(t, 0.8, docs[0].MetaData["semantic_score"])
+}
+
+func TestHybridRetrieverRRF(t *testing.T) {
+	fullText := &stubRetriever{docs: []*schema.Document{scoredDoc("a", 30), scoredDoc("b", 20)}}
+	semantic := &stubRetriever{docs: []*schema.Document{scoredDoc("b", 0.9), scoredDoc("c", 0.8)}}
+	config := DefaultFusionConfig()
+	config.Method, config.RRFK = FusionRRF, 10
+
+	docs, err := NewHybridRetriever(fullText, semantic, []uuid.UUID{fusionDatasetID}, config, 0, 0).Retrieve(context.Background(), "q")
+	assert.NoError(t, err)
+
+	// only ranks count: b is found by both, a ranks first in full text and c second in semantic
+	assert.Equal(t, []string{"b", "a", "c"}, segmentIDs(docs))
+	assert.InDelta(t, 0.5/12+0.5/11, docs[0].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.5/11, docs[1].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.5/12, docs[2].MetaData["score"], 1e-9)
+}
+
+func TestHybridRetrieverSemanticFirst(t *testing.T) {
+	fullText := &stubRetriever{docs: []*schema.Document{scoredDoc("a", 9), scoredDoc("b", 5), scoredDoc("c", 1)}}
+	semantic := &stubRetriever{docs: []*schema.Document{scoredDoc("b", 0.9), scoredDoc("d", 0.6)}}
+	config := DefaultFusionConfig()
+	config.Method = FusionSemanticFirst
+
+	docs, err := NewHybridRetriever(fullText, semantic, []uuid.UUID{fusionDatasetID}, config, 0, 0).Retrieve(context.Background(), "q")
+	assert.NoError(t, err)
+
+	// the full text fill is scaled below the last semantic score so scores stay in order
+	assert.Equal(t, []string{"b", "d", "a", "c"}, segmentIDs(docs))
+	assert.InDelta(t, 0.9, docs[0].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.6, docs[1].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.6, docs[2].MetaData["score"], 1e-9)
+	assert.InDelta(t, 0.0, docs[3].MetaData["score"], 1e-9)
+}
+
+func TestHybridRetrieverCandidates(t *testing.T) {
+	other := scoredDoc("x", 0.99)
+	other.MetaData["dataset_id"] = uuid.NewString()
+	fullText := &stubRetriever{docs: []*schema.Document{scoredDoc("a", 3), scoredDoc("a", 2), scoredDoc("b", 1)}}
+	semantic := &stubRetriever{docs: []*schema.Document{other, scoredDoc("c", 0.8), scoredDoc("d", 0.2)}}
+	config := DefaultFusionConfig()
+	config.Method, config.FullTextTopK, config.SemanticTopK = FusionRRF, 7, 9
+
+	r := NewHybridRetriever(fullText, semantic, []uuid.UUID{fusionDatasetID}, config, 10, 0.5)
+	docs, err := r.Retrieve(context.Background(), "q")
+	assert.NoError(t, err)
+
+	// each side is asked for its own candidate count
+	assert.Equal(t, 7, fullText.topK)
+	assert.Equal(t, 9, semantic.topK)
+	// other datasets, duplicates and semantic hits below the threshold are dropped
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, segmentIDs(docs))
+
+	// the caller's top k and threshold take precedence
+	docs, err = r.Retrieve(context.Background(), "q", retriever.WithTopK(1), retriever.WithScoreThreshold(0.1))
+	assert.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
+
+func TestHybridRetrieverError(t *testing.T) {
+	fullText := &stubRetriever{err: errors.New("boom")}
+	semantic := &stubRetriever{}
+
+	_, err := NewHybridRetriever(fullText, semantic, []uuid.UUID{fusionDatasetID}, nil, 0, 0).Retrieve(context.Background(), "q")
+	assert.ErrorContains(t, err, "full text retrieval failed")
+}
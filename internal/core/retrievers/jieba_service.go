@@ -2,6 +2,7 @@ package retrievers
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/yanyiwu/gojieba"
 )
@@ -59,6 +60,42 @@ func (s *JiebaService) ExtractKeywords(text string, topK int) []string {
 	return keywords
 }
 
+// TermFrequencies 对文本分词并统计词频，剔除停用词与标点，返回词频表与词项总数
+func (s *JiebaService) TermFrequencies(text string) (map[string]int, int) {
+	frequencies := make(map[string]int)
+	length := 0
+	for _, word := range s.jieba.Cut(text, true) {
+		term := strings.ToLower(strings.TrimSpace(word))
+		if !isTerm(term) {
+			continue
+		}
+		if _, isStopword := s.stopword[term]; isStopword {
+			continue
+		}
+
+		frequencies[term]++
+		length++
+	}
+
+	return frequencies, length
+}
+
+// maxTermLength 词项的最大字节数，与倒排索引表的字段长度一致
+const maxTermLength = 255
+
+// isTerm 判断分词结果是否为可检索的词项，至少包含一个文字或数字
+func isTerm(word string) bool {
+	if len(word) > maxTermLength {
+		return false
+	}
+	for _, r := range word {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return true
+		}
+	}
+	return false
+}
+
 // CutForSearch 对文本进行搜索引擎模式分词
 func (s *JiebaService) CutForSearch(text string) []string {
 	return s.jieba.CutForSearch(text, true)
@@ -0,0 +1,154 @@
+package retrievers
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+const (
+	// MaxMetadataFields 每个知识库最多可以定义的元数据字段数量
+	MaxMetadataFields = 20
+	// MaxMetadataSelectOptions select类型字段最多可以定义的选项数量
+	MaxMetadataSelectOptions = 50
+	// maxMetadataStringLength 字符串类型元数据值的最大长度
+	maxMetadataStringLength = 255
+)
+
+// metadataFieldNamePattern 元数据字段名只允许字母、数字与下划线，保证可以安全地写入过滤表达式
+var metadataFieldNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// ValidateMetadataFields 校验知识库的元数据字段定义
+func ValidateMetadataFields(fields []entity.MetadataField) error {
+	if len(fields) > MaxMetadataFields {
+		return fmt.Errorf("at most %d metadata fields are allowed", MaxMetadataFields)
+	}
+
+	names := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		if !metadataFieldNamePattern.MatchString(field.Name) {
+			return fmt.Errorf("invalid metadata field name %q", field.Name)
+		}
+		if _, exists := names[field.Name]; exists {
+			return fmt.Errorf("duplicate metadata field %q", field.Name)
+		}
+		names[field.Name] = struct{}{}
+
+		switch field.Type {
+		case consts.MetadataFieldTypeString, consts.MetadataFieldTypeNumber, consts.MetadataFieldTypeTime:
+			if len(field.Options) > 0 {
+				return fmt.Errorf("metadata field %q of type %s does not accept options", field.Name, field.Type)
+			}
+		case consts.MetadataFieldTypeSelect:
+			if len(field.Options) == 0 || len(field.Options) > MaxMetadataSelectOptions {
+				return fmt.Errorf("metadata field %q must have 1-%d options", field.Name, MaxMetadataSelectOptions)
+			}
+			for i, option := range field.Options {
+				if option == "" || len(option) > maxMetadataStringLength {
+					return fmt.Errorf("invalid option %q of metadata field %q", option, field.Name)
+				}
+				if slices.Contains(field.Options[:i], option) {
+					return fmt.Errorf("duplicate option %q of metadata field %q", option, field.Name)
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported type %q of metadata field %q", field.Type, field.Name)
+		}
+	}
+
+	return nil
+}
+
+// NormalizeMetadata 按照知识库的元数据字段定义校验文档元数据，并转换为统一的存储格式，
+// 时间类型统一存储为秒级时间戳，值为nil的字段会被移除
+func NormalizeMetadata(fields []entity.MetadataField, values map[string]any) (map[string]any, error) {
+	fieldMap := make(map[string]entity.MetadataField, len(fields))
+	for _, field := range fields {
+		fieldMap[field.Name] = field
+	}
+
+	metadata := make(map[string]any, len(values))
+	for name, value := range values {
+		field, ok := fieldMap[name]
+		if !ok {
+			return nil, fmt.Errorf("metadata field %q is not defined in the dataset", name)
+		}
+		if value == nil {
+			continue
+		}
+
+		switch field.Type {
+		case consts.MetadataFieldTypeString:
+			str, ok := value.(string)
+			if !ok || len(str) > maxMetadataStringLength {
+				return nil, fmt.Errorf("metadata field %q must be a string of at most %d characters", name, maxMetadataStringLength)
+			}
+			metadata[name] = str
+		case consts.MetadataFieldTypeNumber:
+			number, ok := toFloat(value)
+			if !ok {
+				return nil, fmt.Errorf("metadata field %q must be a number", name)
+			}
+			metadata[name] = number
+		case consts.MetadataFieldTypeTime:
+			timestamp, ok := toTimestamp(value)
+			if !ok {
+				return nil, fmt.Errorf("metadata field %q must be a unix timestamp or an RFC3339 time", name)
+			}
+			metadata[name] = timestamp
+		case consts.MetadataFieldTypeSelect:
+			str, ok := value.(string)
+			if !ok || !slices.Contains(field.Options, str) {
+				return nil, fmt.Errorf("metadata field %q must be one of %v", name, field.Options)
+			}
+			metadata[name] = str
+		}
+	}
+
+	return metadata, nil
+}
+
+// toFloat 将json解码得到的数值统一转换为float64
+func toFloat(value any) (float64, bool) {
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case int:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	default:
+		return 0, false
+	}
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+
+	return number, true
+}
+
+// toTimestamp 将秒级时间戳或RFC3339格式的时间转换为秒级时间戳
+func toTimestamp(value any) (int64, bool) {
+	if str, ok := value.(string); ok {
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return 0, false
+		}
+		return t.Unix(), true
+	}
+
+	number, ok := toFloat(value)
+	if !ok || number != math.Trunc(number) {
+		return 0, false
+	}
+
+	return int64(number), true
+}
@@ -0,0 +1,207 @@
+package retrievers
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// MaxMetadataConditions 元数据过滤表达式最多可以包含的条件数量
+const MaxMetadataConditions = 20
+
+// MetadataCondition 元数据过滤条件，时间类型字段的取值为秒级时间戳
+type MetadataCondition struct {
+	Field string
+	Op    vecstore.Op
+	Value any
+}
+
+// MetadataFilter 元数据过滤表达式，对应检索配置中的metadata_filter字段，
+// 格式为{"logic": "and|or", "conditions": [{"field": "", "op": "", "value": ...}]}
+type MetadataFilter struct {
+	Logic      vecstore.Op
+	Conditions []MetadataCondition
+}
+
+// ParseMetadataFilter 解析检索配置中的metadata_filter字段，未配置或没有条件时返回nil
+func ParseMetadataFilter(value any) (*MetadataFilter, error) {
+	if value == nil {
+		return nil, nil
+	}
+	mf, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("metadata filter must be a map")
+	}
+
+	filter := &MetadataFilter{Logic: vecstore.OpAnd}
+	switch logic := mf["logic"].(type) {
+	case nil:
+	case string:
+		filter.Logic = vecstore.Op(logic)
+		if filter.Logic != vecstore.OpAnd && filter.Logic != vecstore.OpOr {
+			return nil, fmt.Errorf("metadata filter logic must be and or or")
+		}
+	default:
+		return nil, fmt.Errorf("metadata filter logic must be a string")
+	}
+
+	var conditions []any
+	switch c := mf["conditions"].(type) {
+	case nil:
+	case []any:
+		conditions = c
+	case []map[string]any:
+		for _, item := range c {
+			conditions = append(conditions, item)
+		}
+	default:
+		return nil, fmt.Errorf("metadata filter conditions must be a list")
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	if len(conditions) > MaxMetadataConditions {
+		return nil, fmt.Errorf("metadata filter allows at most %d conditions", MaxMetadataConditions)
+	}
+
+	for _, item := range conditions {
+		condition, err := parseMetadataCondition(item)
+		if err != nil {
+			return nil, err
+		}
+		filter.Conditions = append(filter.Conditions, condition)
+	}
+
+	return filter, nil
+}
+
+// parseMetadataCondition 解析单个过滤条件，并按操作符校验取值的类型
+func parseMetadataCondition(value any) (MetadataCondition, error) {
+	mc, ok := value.(map[string]any)
+	if !ok {
+		return MetadataCondition{}, fmt.Errorf("metadata filter condition must be a map")
+	}
+
+	field, _ := mc["field"].(string)
+	if !metadataFieldNamePattern.MatchString(field) {
+		return MetadataCondition{}, fmt.Errorf("invalid metadata filter field %q", field)
+	}
+	op, _ := mc["op"].(string)
+	condition := MetadataCondition{Field: field, Op: vecstore.Op(op)}
+
+	switch condition.Op {
+	case vecstore.OpEq, vecstore.OpNe:
+		scalar, ok := toScalar(mc["value"])
+		if !ok {
+			return MetadataCondition{}, fmt.Errorf("value of metadata filter field %q must be a string, number or bool", field)
+		}
+		condition.Value = scalar
+	case vecstore.OpLike:
+		str, ok := mc["value"].(string)
+		if !ok || str == "" {
+			return MetadataCondition{}, fmt.Errorf("value of metadata filter field %q must be a non-empty string", field)
+		}
+		condition.Value = str
+	case vecstore.OpIn:
+		items, ok := mc["value"].([]any)
+		if !ok || len(items) == 0 {
+			return MetadataCondition{}, fmt.Errorf("value of metadata filter field %q must be a non-empty list", field)
+		}
+		values := make([]any, 0, len(items))
+		for _, item := range items {
+			scalar, ok := toScalar(item)
+			if !ok {
+				return MetadataCondition{}, fmt.Errorf("values of metadata filter field %q must be strings, numbers or bools", field)
+			}
+			values = append(values, scalar)
+		}
+		condition.Value = values
+	case vecstore.OpGt, vecstore.OpGte, vecstore.OpLt, vecstore.OpLte:
+		// 范围比较只支持数值，时间可以传递RFC3339格式，统一转换为秒级时间戳
+		number, ok := toFloat(mc["value"])
+		if !ok {
+			timestamp, isTime := toTimestamp(mc["value"])
+			if !isTime {
+				return MetadataCondition{}, fmt.Errorf("value of metadata filter field %q must be a number or an RFC3339 time", field)
+			}
+			number = float64(timestamp)
+		}
+		condition.Value = number
+	default:
+		return MetadataCondition{}, fmt.Errorf("unsupported metadata filter op %q", op)
+	}
+
+	return condition, nil
+}
+
+// toScalar 校验取值为字符串、数值或布尔值，数值统一转换为float64
+func toScalar(value any) (any, bool) {
+	switch v := value.(type) {
+	case string, bool:
+		return v, true
+	default:
+		return toFloat(v)
+	}
+}
+
+// DSL 将过滤表达式转换为向量数据库的过滤条件，元数据存储在向量记录的metadata字段中
+func (f *MetadataFilter) DSL() *vecstore.DSL {
+	sub := make([]*vecstore.DSL, 0, len(f.Conditions))
+	for _, condition := range f.Conditions {
+		sub = append(sub, &vecstore.DSL{
+			Op:    condition.Op,
+			Field: fmt.Sprintf("metadata[%q]", condition.Field),
+			Value: condition.Value,
+		})
+	}
+
+	return &vecstore.DSL{Op: f.Logic, Value: sub}
+}
+
+// Expression 将过滤表达式转换为片段表metadata列上的查询条件，供全文检索过滤片段使用
+func (f *MetadataFilter) Expression() clause.Expr {
+	items := make([]string, 0, len(f.Conditions))
+	vars := make([]any, 0, len(f.Conditions)*2)
+	for _, condition := range f.Conditions {
+		switch condition.Op {
+		case vecstore.OpEq, vecstore.OpNe:
+			// 使用jsonb包含判断，字符串、数值与布尔值都按照json类型比较
+			contained, _ := sonic.Marshal(map[string]any{condition.Field: condition.Value})
+			item := "metadata @> CAST(? AS JSONB)"
+			if condition.Op == vecstore.OpNe {
+				item = "NOT (" + item + ")"
+			}
+			items = append(items, item)
+			vars = append(vars, string(contained))
+		case vecstore.OpLike:
+			items = append(items, "metadata ->> ? LIKE ?")
+			vars = append(vars, condition.Field, condition.Value)
+		case vecstore.OpIn:
+			// json数组包含标量时，等价于标量属于该数组
+			values, _ := sonic.Marshal(condition.Value)
+			items = append(items, "CAST(? AS JSONB) @> (metadata -> ?)")
+			vars = append(vars, string(values), condition.Field)
+		case vecstore.OpGt, vecstore.OpGte, vecstore.OpLt, vecstore.OpLte:
+			operator := map[vecstore.Op]string{
+				vecstore.OpGt:  ">",
+				vecstore.OpGte: ">=",
+				vecstore.OpLt:  "<",
+				vecstore.OpLte: "<=",
+			}[condition.Op]
+			// 只比较数值类型的取值，避免非数值取值转换失败
+			items = append(items, "CASE WHEN jsonb_typeof(metadata -> ?) = 'number' THEN CAST(metadata ->> ? AS NUMERIC) END "+operator+" ?")
+			vars = append(vars, condition.Field, condition.Field, condition.Value)
+		}
+	}
+
+	logic := " AND "
+	if f.Logic == vecstore.OpOr {
+		logic = " OR "
+	}
+
+	return clause.Expr{SQL: "(" + strings.Join(items, logic) + ")", Vars: vars}
+}
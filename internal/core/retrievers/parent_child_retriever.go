@@ -0,0 +1,146 @@
+package retrievers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+// parentChildOverFetch 父子分段检索时内部检索器多召回的倍数，同一父片段的多个子片段去重后仍能凑够k个结果
+const parentChildOverFetch = 3
+
+// ParentChildRetriever 父子分段检索器，使用子片段召回，返回子片段所属父片段的内容，
+// 同一父片段只保留排名最靠前的子片段，非父子分段的片段原样返回
+type ParentChildRetriever struct {
+	retriever retriever.Retriever
+	db        *gorm.DB
+	k         int
+}
+
+// NewParentChildRetriever 创建一个新的父子分段检索器，k小于等于0时不限制返回数量
+func NewParentChildRetriever(retriever retriever.Retriever, db *gorm.DB, k int) *ParentChildRetriever {
+	return &ParentChildRetriever{
+		retriever: retriever,
+		db:        db,
+		k:         k,
+	}
+}
+
+func (r *ParentChildRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	// 1. 使用内部检索器多召回一些片段，调用方传入的召回数量优先
+	k := r.k
+	if topK := retriever.GetCommonOptions(&retriever.Options{}, opts...).TopK; topK != nil && *topK > 0 {
+		k = *topK
+	}
+	if k > 0 {
+		opts = append(slices.Clone(opts), retriever.WithTopK(k*parentChildOverFetch))
+	}
+	docs, err := r.retriever.Retrieve(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	// 2. 查询召回片段所属的父片段
+	segmentIDs := make([]uuid.UUID, 0, len(docs))
+	for _, doc := range docs {
+		if segmentID, err := uuid.Parse(fmt.Sprint(doc.MetaData["segment_id"])); err == nil {
+			segmentIDs = append(segmentIDs, segmentID)
+		}
+	}
+	parents, err := r.getParents(ctx, segmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(parents) == 0 {
+		return truncate(docs, k), nil
+	}
+
+	// 3. 将子片段替换为父片段内容，并按照父片段去重
+	results := make([]*schema.Document, 0, len(docs))
+	seen := make(map[uuid.UUID]struct{}, len(docs))
+	for _, doc := range docs {
+		segmentID, _ := uuid.Parse(fmt.Sprint(doc.MetaData["segment_id"]))
+		parent, ok := parents[segmentID]
+		if !ok {
+			results = append(results, doc)
+			continue
+		}
+		// 父片段被禁用时，其子片段也不再返回
+		if parent == nil {
+			continue
+		}
+		if _, exists := seen[parent.ID]; exists {
+			continue
+		}
+		seen[parent.ID] = struct{}{}
+
+		doc.MetaData["child_content"] = doc.Content
+		doc.MetaData["parent_segment_id"] = parent.ID.String()
+		doc.Content = parent.Content
+		results = append(results, doc)
+	}
+
+	// 4. 去重后截取前k个结果
+	return truncate(results, k), nil
+}
+
+// truncate 截取前k个文档，k小于等于0时不截取
+func truncate(docs []*schema.Document, k int) []*schema.Document {
+	if k > 0 && len(docs) > k {
+		return docs[:k]
+	}
+
+	return docs
+}
+
+// getParents 获取子片段id到父片段的映射，父片段未启用时映射为nil，非子片段不在映射中
+func (r *ParentChildRetriever) getParents(ctx context.Context, segmentIDs []uuid.UUID) (map[uuid.UUID]*entity.Segment, error) {
+	if len(segmentIDs) == 0 {
+		return nil, nil
+	}
+
+	var children []entity.Segment
+	err := r.db.WithContext(ctx).
+		Select("id", "parent_id").
+		Where("id IN ? AND parent_id <> ?", segmentIDs, uuid.Nil).
+		Find(&children).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query child segments: %w", err)
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+
+	parentIDs := make([]uuid.UUID, 0, len(children))
+	for _, child := range children {
+		parentIDs = append(parentIDs, child.ParentID)
+	}
+	var parents []entity.Segment
+	err = r.db.WithContext(ctx).
+		Where("id IN ? AND enabled = ?", parentIDs, true).
+		Find(&parents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query parent segments: %w", err)
+	}
+	parentMap := make(map[uuid.UUID]*entity.Segment, len(parents))
+	for i := range parents {
+		parentMap[parents[i].ID] = &parents[i]
+	}
+
+	result := make(map[uuid.UUID]*entity.Segment, len(children))
+	for _, child := range children {
+		result[child.ID] = parentMap[child.ParentID]
+	}
+
+	return result, nil
+}
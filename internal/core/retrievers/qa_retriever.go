@@ -0,0 +1,108 @@
+package retrievers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+// QARetriever 问答对检索器，使用问题与相似问法召回，返回问答对的答案，
+// 同一问答对只保留排名最靠前的召回结果，非问答对的片段原样返回
+type QARetriever struct {
+	retriever retriever.Retriever
+	db        *gorm.DB
+}
+
+// NewQARetriever 创建一个新的问答对检索器
+func NewQARetriever(retriever retriever.Retriever, db *gorm.DB) *QARetriever {
+	return &QARetriever{
+		retriever: retriever,
+		db:        db,
+	}
+}
+
+func (r *QARetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	// 1. 使用内部检索器召回片段
+	docs, err := r.retriever.Retrieve(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	// 2. 查询召回结果中的问答对片段
+	segmentIDs := make([]uuid.UUID, 0, len(docs))
+	for _, doc := range docs {
+		if segmentID, err := uuid.Parse(fmt.Sprint(doc.MetaData["segment_id"])); err == nil {
+			segmentIDs = append(segmentIDs, segmentID)
+		}
+	}
+	pairs, err := r.getPairs(ctx, segmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return docs, nil
+	}
+
+	// 3. 将命中的问题替换为答案，并按照问答对去重
+	return substituteAnswers(docs, pairs), nil
+}
+
+// substituteAnswers 将召回的问答对替换为答案，同一问答对只保留排名最靠前的结果，不在pairs中的片段原样返回
+func substituteAnswers(docs []*schema.Document, pairs map[uuid.UUID]*entity.Segment) []*schema.Document {
+	results := make([]*schema.Document, 0, len(docs))
+	seen := make(map[uuid.UUID]struct{}, len(docs))
+	for _, doc := range docs {
+		segmentID, _ := uuid.Parse(fmt.Sprint(doc.MetaData["segment_id"]))
+		pair, ok := pairs[segmentID]
+		if !ok {
+			results = append(results, doc)
+			continue
+		}
+		if _, exists := seen[pair.ID]; exists {
+			continue
+		}
+		seen[pair.ID] = struct{}{}
+
+		// 语义检索召回的是问题或相似问法的向量记录，全文检索直接返回片段的答案
+		doc.MetaData["question"] = pair.Question
+		if doc.Content != pair.Content {
+			doc.MetaData["matched_question"] = doc.Content
+		}
+		doc.Content = pair.Content
+		results = append(results, doc)
+	}
+
+	return results
+}
+
+// getPairs 获取片段id到问答对片段的映射，非问答对片段不在映射中
+func (r *QARetriever) getPairs(ctx context.Context, segmentIDs []uuid.UUID) (map[uuid.UUID]*entity.Segment, error) {
+	if len(segmentIDs) == 0 {
+		return nil, nil
+	}
+
+	var segments []entity.Segment
+	err := r.db.WithContext(ctx).
+		Select("id", "content", "question").
+		Where("id IN ? AND question <> ''", segmentIDs).
+		Find(&segments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question answer segments: %w", err)
+	}
+
+	result := make(map[uuid.UUID]*entity.Segment, len(segments))
+	for i := range segments {
+		result[segments[i].ID] = &segments[i]
+	}
+
+	return result, nil
+}
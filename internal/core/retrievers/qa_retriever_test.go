@@ -0,0 +1,56 @@
+package retrievers
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+func retrievedDoc(segmentID uuid.UUID, content string) *schema.Document {
+	return &schema.Document{
+		ID:       uuid.NewString(),
+		Content:  content,
+		MetaData: map[string]any{"segment_id": segmentID.String()},
+	}
+}
+
+func TestSubstituteAnswers(t *testing.T) {
+	pair := &entity.Segment{ID: uuid.New(), Question: "如何退款", Content: "在订单页申请退款"}
+	otherPair := &entity.Segment{ID: uuid.New(), Question: "如何开票", Content: "在发票页申请开票"}
+	plainID := uuid.New()
+	pairs := map[uuid.UUID]*entity.Segment{pair.ID: pair, otherPair.ID: otherPair}
+
+	docs := []*schema.Document{
+		retrievedDoc(pair.ID, "怎么退钱"),
+		retrievedDoc(plainID, "普通片段"),
+		retrievedDoc(pair.ID, "如何退款"),
+		retrievedDoc(otherPair.ID, "在发票页申请开票"),
+	}
+
+	results := substituteAnswers(docs, pairs)
+
+	// 同一问答对只保留排名最靠前的结果，其余片段保持原有顺序
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, docs[0].ID, results[0].ID)
+		assert.Equal(t, docs[1].ID, results[1].ID)
+		assert.Equal(t, docs[3].ID, results[2].ID)
+	}
+
+	// 语义检索命中相似问法时替换为答案，并记录命中的问法
+	assert.Equal(t, "在订单页申请退款", results[0].Content)
+	assert.Equal(t, "如何退款", results[0].MetaData["question"])
+	assert.Equal(t, "怎么退钱", results[0].MetaData["matched_question"])
+
+	// 非问答对片段原样返回
+	assert.Equal(t, "普通片段", results[1].Content)
+	assert.NotContains(t, results[1].MetaData, "question")
+
+	// 全文检索直接召回答案时不记录命中的问法
+	assert.Equal(t, "在发票页申请开票", results[2].Content)
+	assert.Equal(t, "如何开票", results[2].MetaData["question"])
+	assert.NotContains(t, results[2].MetaData, "matched_question")
+}
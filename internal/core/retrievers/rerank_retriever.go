@@ -0,0 +1,130 @@
+package retrievers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/infra/contract/rerank"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+)
+
+const (
+	// DefaultRerankTopN 默认参与重排序的候选结果数量
+	DefaultRerankTopN = 20
+	// MaxRerankTopN 参与重排序的候选结果数量上限
+	MaxRerankTopN = 50
+)
+
+// RerankConfig 重排序配置，对应检索配置中的rerank字段
+type RerankConfig struct {
+	Provider string
+	Model    string
+	TopN     int
+}
+
+// ParseRerankConfig 解析检索配置中的rerank字段，未配置或未启用时返回nil
+func ParseRerankConfig(value any) (*RerankConfig, error) {
+	if value == nil {
+		return nil, nil
+	}
+	rc, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("rerank config must be a map")
+	}
+	if enable, _ := rc["enable"].(bool); !enable {
+		return nil, nil
+	}
+
+	config := &RerankConfig{TopN: DefaultRerankTopN}
+	config.Provider, _ = rc["provider"].(string)
+	config.Model, _ = rc["model"].(string)
+	if config.Provider == "" || config.Model == "" {
+		return nil, fmt.Errorf("rerank provider and model are required")
+	}
+
+	switch topN := rc["top_n"].(type) {
+	case nil:
+	case int:
+		config.TopN = topN
+	case float64:
+		config.TopN = int(topN)
+	default:
+		return nil, fmt.Errorf("rerank top_n must be a number")
+	}
+	if config.TopN < 1 || config.TopN > MaxRerankTopN {
+		return nil, fmt.Errorf("rerank top_n must be between 1 and %d", MaxRerankTopN)
+	}
+
+	return config, nil
+}
+
+// RerankRetriever 重排序检索器，使用重排序模型对任意检索器召回的前topN个候选结果重新打分，再截取前k个
+type RerankRetriever struct {
+	retriever retriever.Retriever
+	reranker  rerank.Reranker
+	topN      int
+	k         int
+}
+
+// NewRerankRetriever 创建一个新的重排序检索器，k小于等于0时保留所有重排序后的结果
+func NewRerankRetriever(retriever retriever.Retriever, reranker rerank.Reranker, topN int, k int) *RerankRetriever {
+	return &RerankRetriever{
+		retriever: retriever,
+		reranker:  reranker,
+		topN:      topN,
+		k:         k,
+	}
+}
+
+func (r *RerankRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	// 1. 召回topN个候选结果
+	opts = append(opts, retriever.WithTopK(r.topN))
+	docs, err := r.retriever.Retrieve(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) > r.topN {
+		docs = docs[:r.topN]
+	}
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	// 2. 调用重排序模型为候选结果打分
+	contents := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		contents = append(contents, doc.Content)
+	}
+	res, err := r.reranker.Rerank(ctx, &rerank.Request{
+		Query:     query,
+		Documents: contents,
+		TopN:      r.k,
+	})
+	if err != nil {
+		// 重排序服务不可用时退化为原检索结果，不影响检索本身
+		logs.CtxErrorf(ctx, "rerank failed, fall back to retrieval order: %v", err)
+		if r.k > 0 && len(docs) > r.k {
+			docs = docs[:r.k]
+		}
+		return docs, nil
+	}
+
+	// 3. 按重排序得分排列结果，并记录重排序得分
+	reranked := make([]*schema.Document, 0, len(res.Results))
+	for _, result := range res.Results {
+		doc := docs[result.Index]
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any)
+		}
+		doc.MetaData["rerank_score"] = result.Score
+		reranked = append(reranked, doc)
+	}
+	if r.k > 0 && len(reranked) > r.k {
+		reranked = reranked[:r.k]
+	}
+
+	return reranked, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
+	"github.com/crazyfrankie/voidx/internal/core/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -31,15 +32,18 @@ type RetrieverFactory struct {
 	vectorStore  vecstore.SearchStore
 	embedder     *embedding.EmbeddingService
 	jiebaService *JiebaService
+	llmManager   *llm.LanguageModelManager
 }
 
 // NewRetrieverFactory 创建一个新的检索器工厂
-func NewRetrieverFactory(db *gorm.DB, vectorStore vecstore.SearchStore, embedder *embedding.EmbeddingService, jiebaService *JiebaService) *RetrieverFactory {
+func NewRetrieverFactory(db *gorm.DB, vectorStore vecstore.SearchStore, embedder *embedding.EmbeddingService,
+	jiebaService *JiebaService, llmManager *llm.LanguageModelManager) *RetrieverFactory {
 	return &RetrieverFactory{
 		db:           db,
 		vectorStore:  vectorStore,
 		embedder:     embedder,
 		jiebaService: jiebaService,
+		llmManager:   llmManager,
 	}
 }
 
@@ -51,41 +55,142 @@ func (f *RetrieverFactory) CreateRetriever(ctx context.Context, retrieverType Re
 		return nil, fmt.Errorf("datasetIDs cannot be empty")
 	}
 
+	// 元数据过滤同时作用于全文检索与语义检索
+	metadataFilter, err := ParseMetadataFilter(options["metadata_filter"])
+	if err != nil {
+		return nil, err
+	}
+
+	var ret retriever.Retriever
 	switch retrieverType {
 	case RetrieverTypeFullText:
-		return f.createFullTextRetriever(ctx, datasetIDs)
+		ret, err = f.createFullTextRetriever(ctx, datasetIDs, metadataFilter)
 	case RetrieverTypeSemantic:
-		return f.createSemanticRetriever(ctx, datasetIDs, options)
+		ret, err = f.createSemanticRetriever(ctx, datasetIDs, metadataFilter)
 	case RetrieverTypeHybrid:
-		return f.createHybridRetriever(ctx, datasetIDs, options)
+		ret, err = f.createHybridRetriever(ctx, datasetIDs, metadataFilter, options)
 	default:
 		return nil, fmt.Errorf("unsupported retriever type: %s", retrieverType)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 父子分段的子片段召回后替换为父片段内容，问答对召回后替换为答案，重排序基于替换后的内容进行
+	k, _ := options["k"].(int)
+	ret = NewQARetriever(NewParentChildRetriever(ret, f.db, k), f.db)
+
+	return f.withRerank(ctx, ret, options)
+}
+
+// withRerank 检索选项启用了重排序时，使用重排序检索器包装检索器
+func (f *RetrieverFactory) withRerank(ctx context.Context, ret retriever.Retriever, options map[string]any) (retriever.Retriever, error) {
+	config, err := ParseRerankConfig(options["rerank"])
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return ret, nil
+	}
+
+	// 重排序模型使用检索发起账号的凭证
+	accountID, ok := options["account_id"].(uuid.UUID)
+	if !ok {
+		return nil, fmt.Errorf("account_id is required for reranking")
+	}
+	reranker, err := f.llmManager.CreateRerankerForAccount(ctx, accountID, config.Provider, config.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rerank model: %w", err)
+	}
+
+	k, _ := options["k"].(int)
+
+	return NewRerankRetriever(ret, reranker, config.TopN, k), nil
 }
 
 // createFullTextRetriever 创建全文检索器，支持多个数据集
-func (f *RetrieverFactory) createFullTextRetriever(ctx context.Context, datasetIDs []uuid.UUID) (retriever.Retriever, error) {
-	return NewFullTextRetriever(f.db, datasetIDs, f.jiebaService), nil
+func (f *RetrieverFactory) createFullTextRetriever(ctx context.Context, datasetIDs []uuid.UUID, metadataFilter *MetadataFilter) (retriever.Retriever, error) {
+	return NewFullTextRetriever(f.db, datasetIDs, f.jiebaService, metadataFilter), nil
 }
 
 // createSemanticRetriever 创建语义检索器，支持多个数据集
-func (f *RetrieverFactory) createSemanticRetriever(ctx context.Context, datasetIDs []uuid.UUID, options map[string]any) (retriever.Retriever, error) {
-	return NewSemanticRetriever(f.vectorStore, f.embedder, datasetIDs), nil
+func (f *RetrieverFactory) createSemanticRetriever(ctx context.Context, datasetIDs []uuid.UUID, metadataFilter *MetadataFilter) (retriever.Retriever, error) {
+	groups, err := f.groupDatasetsByEmbedder(ctx, datasetIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSemanticRetriever(f.vectorStore, f.embedder, groups, metadataFilter), nil
+}
+
+// groupDatasetsByEmbedder 按数据集绑定的向量模型分组，未绑定模型的数据集使用向量库默认的模型
+func (f *RetrieverFactory) groupDatasetsByEmbedder(ctx context.Context, datasetIDs []uuid.UUID) ([]*DatasetGroup, error) {
+	var datasets []*entity.Dataset
+	err := f.db.WithContext(ctx).
+		Select("id", "account_id", "embedding_provider", "embedding_model").
+		Where("id IN ?", datasetIDs).
+		Find(&datasets).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load datasets: %w", err)
+	}
+
+	groups := make([]*DatasetGroup, 0)
+	groupByKey := make(map[string]*DatasetGroup)
+	for _, dataset := range datasets {
+		key := ""
+		if dataset.EmbeddingModel != "" {
+			// 凭证按账号区分，不同账号的数据集即使模型相同也需要分开检索
+			key = fmt.Sprintf("%s/%s/%s", dataset.AccountID, dataset.EmbeddingProvider, dataset.EmbeddingModel)
+		}
+
+		group, ok := groupByKey[key]
+		if !ok {
+			group = &DatasetGroup{}
+			if key != "" {
+				group.Embedder, err = f.llmManager.CreateEmbedderForAccount(ctx, dataset.AccountID,
+					dataset.EmbeddingProvider, dataset.EmbeddingModel)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load embedding model of dataset %s: %w", dataset.ID, err)
+				}
+			}
+			groupByKey[key] = group
+			groups = append(groups, group)
+		}
+		group.DatasetIDs = append(group.DatasetIDs, dataset.ID)
+	}
+
+	return groups, nil
 }
 
 // createHybridRetriever 创建混合检索器，支持多个数据集
-func (f *RetrieverFactory) createHybridRetriever(ctx context.Context, datasetIDs []uuid.UUID, options map[string]any) (retriever.Retriever, error) {
-	fullTextRetriever, err := f.createFullTextRetriever(ctx, datasetIDs)
+func (f *RetrieverFactory) createHybridRetriever(ctx context.Context, datasetIDs []uuid.UUID, metadataFilter *MetadataFilter,
+	options map[string]any) (retriever.Retriever, error) {
+	fullTextRetriever, err := f.createFullTextRetriever(ctx, datasetIDs, metadataFilter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create full text retriever: %w", err)
 	}
 
-	semanticRetriever, err := f.createSemanticRetriever(ctx, datasetIDs, options)
+	semanticRetriever, err := f.createSemanticRetriever(ctx, datasetIDs, metadataFilter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create semantic retriever: %w", err)
 	}
 
-	return NewHybridRetriever(fullTextRetriever, semanticRetriever, datasetIDs, options), nil
+	fusionConfig, err := ParseFusionConfig(options["fusion"])
+	if err != nil {
+		return nil, err
+	}
+
+	k, _ := options["k"].(int)
+	scoreThreshold := 0.0
+	switch threshold := options["score_threshold"].(type) {
+	case float32:
+		scoreThreshold = float64(threshold)
+	case float64:
+		scoreThreshold = threshold
+	}
+
+	return NewHybridRetriever(fullTextRetriever, semanticRetriever, datasetIDs, fusionConfig, k, scoreThreshold), nil
 }
 
 // ValidateDatasetAccess 验证用户对数据集的访问权限
@@ -3,8 +3,11 @@ package retrievers
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	contractemb "github.com/crazyfrankie/voidx/infra/contract/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
 	"github.com/google/uuid"
 
@@ -12,54 +15,80 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
-// SemanticRetriever implements semantic search using vector embeddings with eino
+// SemanticRetriever implements semantic search using vector embeddings with eino, datasets pinned
+// to different embedding models are searched separately, each with its own model
 type SemanticRetriever struct {
-	vecstore   vecstore.SearchStore
-	embedder   *embedding.EmbeddingService
-	datasetIDs []uuid.UUID
+	vecstore       vecstore.SearchStore
+	embedder       *embedding.EmbeddingService
+	groups         []*DatasetGroup
+	metadataFilter *MetadataFilter
 }
 
-// NewSemanticRetriever creates a new semantic retriever
-func NewSemanticRetriever(vecstore vecstore.SearchStore, embedder *embedding.EmbeddingService, datasetIDs []uuid.UUID) *SemanticRetriever {
+// DatasetGroup is a set of datasets whose vectors were produced by the same embedder,
+// a nil Embedder means the default embedder of the vector store
+type DatasetGroup struct {
+	DatasetIDs []uuid.UUID
+	Embedder   contractemb.Embedder
+}
+
+// NewSemanticRetriever creates a new semantic retriever, a nil metadataFilter searches all segments
+func NewSemanticRetriever(vecstore vecstore.SearchStore, embedder *embedding.EmbeddingService, groups []*DatasetGroup,
+	metadataFilter *MetadataFilter) *SemanticRetriever {
 	return &SemanticRetriever{
-		vecstore:   vecstore,
-		embedder:   embedder,
-		datasetIDs: datasetIDs,
+		vecstore:       vecstore,
+		embedder:       embedder,
+		groups:         groups,
+		metadataFilter: metadataFilter,
 	}
 }
 
 // Retrieve implements the eino retriever interface
 func (r *SemanticRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
-	filters := make(map[string]any)
-	// 添加数据集ID过滤
-	if len(r.datasetIDs) > 0 {
-		datasetIDStrs := make([]string, 0, len(r.datasetIDs))
-		for _, id := range r.datasetIDs {
-			datasetIDStrs = append(datasetIDStrs, id.String())
+	docs := make([]*schema.Document, 0)
+	for _, group := range r.groups {
+		groupOpts := append(slices.Clone(opts), retriever.WithDSLInfo(datasetFilter(group.DatasetIDs, r.metadataFilter).DSL()))
+		if group.Embedder != nil {
+			groupOpts = append(groupOpts, vecstore.WithRetrieverEmbedding(group.Embedder))
 		}
-		filters["dataset_id"] = map[string]any{
-			"contains_any": datasetIDStrs,
+
+		groupDocs, err := r.vecstore.Retrieve(ctx, query, groupOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("retrieve failed, %w", err)
 		}
+		docs = append(docs, groupDocs...)
 	}
 
-	// 添加其他过滤条件
-	filters["document_enabled"] = map[string]any{
-		"equal": true,
-	}
-	filters["segment_enabled"] = map[string]any{
-		"equal": true,
+	// 不同向量模型的检索结果按得分合并
+	if len(r.groups) > 1 {
+		sort.SliceStable(docs, func(i, j int) bool {
+			return docs[i].Score() > docs[j].Score()
+		})
+		if topK := retriever.GetCommonOptions(&retriever.Options{}, opts...).TopK; topK != nil && *topK > 0 && len(docs) > *topK {
+			docs = docs[:*topK]
+		}
 	}
 
-	docs, err := r.vecstore.Retrieve(ctx, query, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("retrieve failed, %w", err)
+	return docs, nil
+}
+
+// datasetFilter builds the filter restricting the search to enabled segments of the datasets
+// whose metadata matches the metadata filter
+func datasetFilter(datasetIDs []uuid.UUID, metadataFilter *MetadataFilter) *vecstore.DSL {
+	ids := make([]any, 0, len(datasetIDs))
+	for _, id := range datasetIDs {
+		ids = append(ids, id.String())
 	}
 
-	if len(docs) == 0 {
-		return []*schema.Document{}, nil
+	conditions := []*vecstore.DSL{
+		{Op: vecstore.OpIn, Field: "dataset_id", Value: ids},
+		{Op: vecstore.OpEq, Field: "document_enabled", Value: true},
+		{Op: vecstore.OpEq, Field: "segment_enabled", Value: true},
+	}
+	if metadataFilter != nil {
+		conditions = append(conditions, metadataFilter.DSL())
 	}
 
-	return docs, nil
+	return &vecstore.DSL{Op: vecstore.OpAnd, Value: conditions}
 }
 
 // cosineSimilarity calculates cosine similarity between two vectors
@@ -0,0 +1,418 @@
+package table
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxColumns 单个表格允许的最大列数
+	MaxColumns = 100
+	// MaxRows 单个文件允许导入的最大行数
+	MaxRows = 100000
+	// maxXLSXPartSize XLSX中单个XML文件解压后的最大字节数，避免解压炸弹
+	maxXLSXPartSize = 256 << 20
+)
+
+// Table 从CSV/XLSX文件中读取的表格数据，Header为第一个非空行，Rows中每行的长度与Header一致
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// ReadFile 根据文件扩展名读取CSV或XLSX文件，XLSX只读取第一个工作表
+func ReadFile(filePath string) (*Table, error) {
+	var records [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		records, err = readCSV(filePath)
+	case ".xlsx":
+		records, err = readXLSX(filePath)
+	default:
+		return nil, fmt.Errorf("unsupported table file extension: %s", filepath.Ext(filePath))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newTable(records)
+}
+
+// newTable 跳过空行并以第一个非空行作为表头，数据行补齐或截断为表头的长度
+func newTable(records [][]string) (*Table, error) {
+	table := &Table{}
+	for _, record := range records {
+		if isEmptyRecord(record) {
+			continue
+		}
+		if table.Header == nil {
+			// 去除表头末尾的空列
+			end := len(record)
+			for end > 0 && strings.TrimSpace(record[end-1]) == "" {
+				end--
+			}
+			table.Header = make([]string, end)
+			for i := range end {
+				table.Header[i] = strings.TrimSpace(record[i])
+			}
+			continue
+		}
+
+		row := make([]string, len(table.Header))
+		for i := 0; i < len(row) && i < len(record); i++ {
+			row[i] = strings.TrimSpace(record[i])
+		}
+		table.Rows = append(table.Rows, row)
+		if len(table.Rows) > MaxRows {
+			return nil, fmt.Errorf("table has more than %d rows", MaxRows)
+		}
+	}
+
+	if len(table.Header) == 0 {
+		return nil, errors.New("table header is empty")
+	}
+	if len(table.Header) > MaxColumns {
+		return nil, fmt.Errorf("table has more than %d columns", MaxColumns)
+	}
+
+	return table, nil
+}
+
+// isEmptyRecord 判断一行是否所有单元格都为空
+func isEmptyRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// readCSV 读取CSV文件，兼容带有UTF-8 BOM与列数不一致的文件
+func readCSV(filePath string) ([][]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv file: %w", err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv file: %w", err)
+	}
+
+	return records, nil
+}
+
+type xlsxWorkbook struct {
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		RelationshipID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+// xlsxRichText 共享字符串与行内字符串，富文本由多个r元素组成
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID         int    `xml:"numFmtId,attr"`
+		FormatCode string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+// dateStyles 获取单元格样式下标到是否为日期格式的映射
+func (s xlsxStyles) dateStyles() []bool {
+	customFormats := make(map[int]string, len(s.NumFmts))
+	for _, numFmt := range s.NumFmts {
+		customFormats[numFmt.ID] = numFmt.FormatCode
+	}
+
+	result := make([]bool, len(s.CellXfs))
+	for i, xf := range s.CellXfs {
+		if formatCode, ok := customFormats[xf.NumFmtID]; ok {
+			result[i] = isDateFormatCode(formatCode)
+			continue
+		}
+		result[i] = isBuiltinDateFormat(xf.NumFmtID)
+	}
+	return result
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Style  int          `xml:"s,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX 读取XLSX文件第一个工作表中的单元格文本，数值保留单元格中存储的原始值，日期格式的数值转换为日期文本
+func readXLSX(filePath string) ([][]string, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx file: %w", err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	// 1. 读取共享字符串表，文本单元格中存储的是共享字符串的下标
+	var sharedStrings xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(file, &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. 读取样式表，日期在单元格中存储为序列号，只能通过单元格样式的数字格式区分
+	var styles xlsxStyles
+	if file, ok := files["xl/styles.xml"]; ok {
+		if err := decodeXLSXPart(file, &styles); err != nil {
+			return nil, err
+		}
+	}
+	dateStyles := styles.dateStyles()
+
+	// 3. 读取第一个工作表
+	sheetPath, date1904 := firstSheetPath(files)
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, errors.New("xlsx file has no worksheet")
+	}
+	var worksheet xlsxWorksheet
+	if err := decodeXLSXPart(sheetFile, &worksheet); err != nil {
+		return nil, err
+	}
+
+	// 4. 按单元格引用的列号还原每行数据，空单元格不会出现在XML中
+	var records [][]string
+	for _, row := range worksheet.Rows {
+		if row.Index > len(records)+1 {
+			records = append(records, make([][]string, row.Index-len(records)-1)...)
+		}
+
+		var record []string
+		for _, cell := range row.Cells {
+			column := xlsxColumnIndex(cell.Ref)
+			if column < 0 {
+				column = len(record)
+			}
+			// 超出最大列数的单元格不会被导入，无需为其分配空间
+			if column > MaxColumns {
+				continue
+			}
+			for len(record) <= column {
+				record = append(record, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err == nil && index >= 0 && index < len(sharedStrings.Items) {
+					record[column] = sharedStrings.Items[index].String()
+				}
+			case "inlineStr":
+				record[column] = cell.Inline.String()
+			case "b":
+				record[column] = strconv.FormatBool(cell.Value == "1")
+			case "e":
+				record[column] = ""
+			default:
+				record[column] = cell.Value
+				if cell.Style >= 0 && cell.Style < len(dateStyles) && dateStyles[cell.Style] {
+					if date, ok := xlsxSerialToTime(cell.Value, date1904); ok {
+						record[column] = date
+					}
+				}
+			}
+		}
+		records = append(records, record)
+		if len(records) > MaxRows+1 {
+			return nil, fmt.Errorf("table has more than %d rows", MaxRows)
+		}
+	}
+
+	return records, nil
+}
+
+// firstSheetPath 根据workbook与关系文件获取第一个工作表的路径，解析失败时使用默认路径，
+// 同时返回工作簿是否使用1904日期系统
+func firstSheetPath(files map[string]*zip.File) (string, bool) {
+	defaultPath := "xl/worksheets/sheet1.xml"
+
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+	workbookFile, ok := files["xl/workbook.xml"]
+	if !ok || decodeXLSXPart(workbookFile, &workbook) != nil {
+		return defaultPath, false
+	}
+	date1904 := workbook.Properties.Date1904
+	relationshipsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok || decodeXLSXPart(relationshipsFile, &relationships) != nil || len(workbook.Sheets) == 0 {
+		return defaultPath, date1904
+	}
+
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID != workbook.Sheets[0].RelationshipID {
+			continue
+		}
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/"), date1904
+		}
+		return path.Join("xl", relationship.Target), date1904
+	}
+
+	return defaultPath, date1904
+}
+
+// isBuiltinDateFormat 判断内置数字格式是否为日期时间格式，27-36与50-58为东亚语言环境的日期格式
+func isBuiltinDateFormat(numFmtID int) bool {
+	return (numFmtID >= 14 && numFmtID <= 22) ||
+		(numFmtID >= 27 && numFmtID <= 36) ||
+		(numFmtID >= 45 && numFmtID <= 47) ||
+		(numFmtID >= 50 && numFmtID <= 58)
+}
+
+// isDateFormatCode 判断自定义数字格式是否为日期时间格式，忽略引号中的文本、转义字符与方括号中的颜色和区域设置
+func isDateFormatCode(formatCode string) bool {
+	// 只使用第一段格式，其余为负数、零与文本的格式
+	inQuote, escaped := false, false
+	var bracket *strings.Builder
+	for _, r := range formatCode {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote:
+			inQuote = r != '"'
+		case bracket != nil:
+			if r != ']' {
+				bracket.WriteRune(r)
+				continue
+			}
+			// [h]:mm等经过时间格式同样表示时间
+			if content := strings.ToLower(bracket.String()); content != "" && strings.Trim(content, "hms") == "" {
+				return true
+			}
+			bracket = nil
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = true
+		case r == '[':
+			bracket = &strings.Builder{}
+		case r == ';':
+			return false
+		case strings.ContainsRune("yYmMdDhHsS", r):
+			return true
+		}
+	}
+	return false
+}
+
+// xlsxSerialToTime 将日期序列号转换为日期文本，没有时间部分时只保留日期
+func xlsxSerialToTime(value string, date1904 bool) (string, bool) {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 0 {
+		return "", false
+	}
+
+	// Excel将1900年视为闰年，序列号60为不存在的1900-02-29，此后的序列号以1899-12-30为起点
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	case serial < 60:
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+	seconds := int64(math.Round(serial * 86400))
+	t := epoch.Add(time.Duration(seconds) * time.Second)
+
+	if seconds%86400 == 0 {
+		return t.Format(time.DateOnly), true
+	}
+	return t.Format(time.DateTime), true
+}
+
+// decodeXLSXPart 解析XLSX压缩包中的XML文件
+func decodeXLSXPart(file *zip.File, v any) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file.Name, err)
+	}
+	return nil
+}
+
+// xlsxColumnIndex 将单元格引用(如"AB12")中的列字母转换为从0开始的列号
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
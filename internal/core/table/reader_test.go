@@ -0,0 +1,143 @@
+package table
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeXLSX 将XML文件打包为XLSX文件
+func writeXLSX(t *testing.T, parts map[string]string) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "test.xlsx")
+	file, err := os.Create(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range parts {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return filePath
+}
+
+func worksheetXML(rows string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`
+}
+
+func TestReadXLSXSharedAndInlineStrings(t *testing.T) {
+	filePath := writeXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Data" sheetId="1" r:id="rId2"/><sheet name="Other" sheetId="2" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>name</t></si><si><t>note</t></si><si><r><t>Rich </t></r><r><t>text</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": worksheetXML(`<row r="1"><c r="A1" t="inlineStr"><is><t>wrong sheet</t></is></c></row>`),
+		"xl/worksheets/data.xml": worksheetXML(`
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>flag</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" t="inlineStr"><is><r><t>in</t></r><r><t>line</t></r></is></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="4"><c r="B4" t="s"><v>99</v></c><c r="C4" t="e"><v>#DIV/0!</v></c><c r="A4" t="inlineStr"><is><t>gap</t></is></c></row>`),
+	})
+
+	table, err := ReadFile(filePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"name", "note", "flag"}, table.Header)
+	assert.Equal(t, [][]string{
+		{"Rich text", "inline", "true"},
+		// 缺失的第3行是空行会被跳过，越界的共享字符串下标与错误值读取为空
+		{"gap", "", ""},
+	}, table.Rows)
+}
+
+func TestReadXLSXDateSerials(t *testing.T) {
+	styles := `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="3">
+<numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm"/>
+<numFmt numFmtId="165" formatCode="[Red]0.00;[Blue]&quot;-&quot;0.00"/>
+<numFmt numFmtId="166" formatCode="[h]:mm"/>
+</numFmts>
+<cellXfs count="6"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/><xf numFmtId="166"/><xf numFmtId="4"/></cellXfs>
+</styleSheet>`
+	sheet := worksheetXML(`
+<row r="1"><c r="A1" t="inlineStr"><is><t>date</t></is></c><c r="B1" t="inlineStr"><is><t>datetime</t></is></c><c r="C1" t="inlineStr"><is><t>amount</t></is></c><c r="D1" t="inlineStr"><is><t>elapsed</t></is></c><c r="E1" t="inlineStr"><is><t>plain</t></is></c></row>
+<row r="2"><c r="A2" s="1"><v>45292</v></c><c r="B2" s="2"><v>45292.5</v></c><c r="C2" s="3"><v>45292</v></c><c r="D2" s="4"><v>1.25</v></c><c r="E2" s="5"><v>1234.5</v></c></row>
+<row r="3"><c r="A3" s="1"><v>60</v></c><c r="B3" s="2"><v>0.999999999</v></c><c r="C3" s="1" t="s"><v>0</v></c><c r="E3"><v>7</v></c></row>`)
+
+	t.Run("1900 date system", func(t *testing.T) {
+		filePath := writeXLSX(t, map[string]string{
+			"xl/styles.xml":            styles,
+			"xl/sharedStrings.xml":     `<sst><si><t>text</t></si></sst>`,
+			"xl/worksheets/sheet1.xml": sheet,
+		})
+
+		table, err := ReadFile(filePath)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"date", "datetime", "amount", "elapsed", "plain"}, table.Header)
+		assert.Equal(t, [][]string{
+			// 带颜色的数字格式与普通数字格式保留原始值
+			{"2024-01-01", "2024-01-01 12:00:00", "45292", "1900-01-01 06:00:00", "1234.5"},
+			// 序列号60为Excel中不存在的1900-02-29，四舍五入到秒后进位到下一天，文本单元格不受日期样式影响
+			{"1900-02-28", "1900-01-01", "text", "", "7"},
+		}, table.Rows)
+	})
+
+	t.Run("1904 date system", func(t *testing.T) {
+		filePath := writeXLSX(t, map[string]string{
+			"xl/workbook.xml":          `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><workbookPr date1904="1"/></workbook>`,
+			"xl/styles.xml":            styles,
+			"xl/sharedStrings.xml":     `<sst><si><t>text</t></si></sst>`,
+			"xl/worksheets/sheet1.xml": sheet,
+		})
+
+		table, err := ReadFile(filePath)
+		require.NoError(t, err)
+
+		assert.Equal(t, "2028-01-02", table.Rows[0][0])
+		assert.Equal(t, "2028-01-02 12:00:00", table.Rows[0][1])
+	})
+}
+
+func TestIsDateFormatCode(t *testing.T) {
+	tests := []struct {
+		formatCode string
+		want       bool
+	}{
+		{formatCode: "yyyy/m/d", want: true},
+		{formatCode: "hh:mm:ss", want: true},
+		{formatCode: "[$-804]yyyy\"年\"m\"月\"d\"日\"", want: true},
+		{formatCode: "[mm]:ss", want: true},
+		{formatCode: "General", want: false},
+		{formatCode: "#,##0.00", want: false},
+		{formatCode: "0.00\" days\"", want: false},
+		{formatCode: "0\\d", want: false},
+		{formatCode: "[Magenta]0.00", want: false},
+		{formatCode: "0.00;\"due \"yyyy", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formatCode, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDateFormatCode(tt.formatCode))
+		})
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	assert.Equal(t, 0, xlsxColumnIndex("A1"))
+	assert.Equal(t, 25, xlsxColumnIndex("Z9"))
+	assert.Equal(t, 27, xlsxColumnIndex("AB12"))
+	assert.Equal(t, -1, xlsxColumnIndex("12"))
+}
@@ -0,0 +1,206 @@
+package table
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document"
+)
+
+// maxColumnNameLength Postgres标识符的最大长度
+const maxColumnNameLength = 63
+
+// thousandsPattern 匹配带千分位分隔符的数字，如"1,234.5"
+var thousandsPattern = regexp.MustCompile(`^[+-]?\d{1,3}(,\d{3})+(\.\d+)?$`)
+
+// timeLayouts 推断时间类型时支持的格式
+var timeLayouts = []string{
+	time.DateOnly,
+	time.DateTime,
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006/01/02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/1/2",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+}
+
+// InferSchema 根据表头与数据推断表结构，字段名转换为小写蛇形命名，无法转换时使用column_N，
+// 原始表头记录在Description中；字段类型依次尝试整数、小数、布尔、时间，均不满足时为字符串
+func InferSchema(table *Table) *document.TableSchema {
+	schema := &document.TableSchema{Columns: make([]*document.Column, 0, len(table.Header))}
+	used := make(map[string]int, len(table.Header))
+	for i, header := range table.Header {
+		name := columnName(header)
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		// 重名字段添加序号后缀
+		if count := used[name]; count > 0 {
+			used[name] = count + 1
+			name = fmt.Sprintf("%s_%d", name, count+1)
+		}
+		used[name]++
+
+		values := make([]string, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			values = append(values, row[i])
+		}
+		schema.Columns = append(schema.Columns, &document.Column{
+			ID:          int64(i + 1),
+			Name:        name,
+			Type:        inferColumnType(values),
+			Description: header,
+			Nullable:    true,
+			Sequence:    i,
+		})
+	}
+
+	return schema
+}
+
+// columnName 将表头转换为只包含小写字母、数字与下划线的字段名，不能以数字开头
+func columnName(header string) string {
+	var name strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			name.WriteRune(r)
+		} else if name.Len() > 0 && !strings.HasSuffix(name.String(), "_") {
+			name.WriteByte('_')
+		}
+	}
+
+	result := strings.TrimRight(name.String(), "_")
+	if result == "" || unicode.IsDigit(rune(result[0])) {
+		return ""
+	}
+	if len(result) > maxColumnNameLength-4 {
+		result = strings.TrimRight(result[:maxColumnNameLength-4], "_")
+	}
+	return result
+}
+
+// inferColumnType 推断一列数据的类型，空值不参与推断，全部为空时为字符串
+func inferColumnType(values []string) document.TableColumnType {
+	candidates := []document.TableColumnType{
+		document.TableColumnTypeInteger,
+		document.TableColumnTypeNumber,
+		document.TableColumnTypeBoolean,
+		document.TableColumnTypeTime,
+	}
+
+	hasValue := false
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		hasValue = true
+
+		remaining := candidates[:0]
+		for _, candidate := range candidates {
+			if _, err := ParseValue(candidate, value); err == nil {
+				remaining = append(remaining, candidate)
+			}
+		}
+		candidates = remaining
+		if len(candidates) == 0 {
+			return document.TableColumnTypeString
+		}
+	}
+	if !hasValue {
+		return document.TableColumnTypeString
+	}
+
+	return candidates[0]
+}
+
+// ParseValue 将单元格文本转换为字段类型对应的值，空文本转换为nil
+func ParseValue(columnType document.TableColumnType, value string) (any, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	switch columnType {
+	case document.TableColumnTypeInteger, document.TableColumnTypeNumber:
+		// 以0开头的编号(如邮编、工号)需要保留前导0，不作为数字处理
+		normalized := normalizeNumber(value)
+		if digits := strings.TrimLeft(normalized, "+-"); len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+			return nil, fmt.Errorf("invalid number: %s", value)
+		}
+		if columnType == document.TableColumnTypeInteger {
+			return strconv.ParseInt(normalized, 10, 64)
+		}
+		number, err := strconv.ParseFloat(normalized, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("invalid number: %s", value)
+		}
+		return number, nil
+	case document.TableColumnTypeBoolean:
+		switch strings.ToLower(value) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean: %s", value)
+	case document.TableColumnTypeTime:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time: %s", value)
+	default:
+		return value, nil
+	}
+}
+
+// normalizeNumber 去除数字中的千分位分隔符
+func normalizeNumber(value string) string {
+	if thousandsPattern.MatchString(value) {
+		return strings.ReplaceAll(value, ",", "")
+	}
+	return value
+}
+
+// widenColumnType 获取能同时容纳两种类型数据的字段类型，整数与小数合并为小数，其余不同类型合并为字符串
+func widenColumnType(a, b document.TableColumnType) document.TableColumnType {
+	switch {
+	case a == b:
+		return a
+	case (a == document.TableColumnTypeInteger && b == document.TableColumnTypeNumber) ||
+		(a == document.TableColumnTypeNumber && b == document.TableColumnTypeInteger):
+		return document.TableColumnTypeNumber
+	default:
+		return document.TableColumnTypeString
+	}
+}
+
+// postgresType 字段类型对应的Postgres数据类型
+func postgresType(columnType document.TableColumnType) string {
+	switch columnType {
+	case document.TableColumnTypeInteger:
+		return "BIGINT"
+	case document.TableColumnTypeNumber:
+		return "DOUBLE PRECISION"
+	case document.TableColumnTypeBoolean:
+		return "BOOLEAN"
+	case document.TableColumnTypeTime:
+		return "TIMESTAMP"
+	default:
+		return "TEXT"
+	}
+}
+
+// quoteIdentifier 使用双引号引用Postgres标识符
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
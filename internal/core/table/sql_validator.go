@@ -0,0 +1,374 @@
+package table
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document"
+)
+
+// sqlTokenKind SQL词法单元类型
+type sqlTokenKind int
+
+const (
+	sqlTokenIdent sqlTokenKind = iota
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenSymbol
+)
+
+// sqlToken SQL词法单元，未加引号的标识符统一转换为小写
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// sqlKeywords 查询语句中允许出现的关键字、类型名与时间单位
+var sqlKeywords = toSet(
+	"select", "distinct", "on", "from", "where", "group", "by", "having", "order", "asc", "desc",
+	"nulls", "first", "last", "limit", "offset", "fetch", "next", "row", "rows", "only", "ties",
+	"with", "as", "materialized", "join", "inner", "left", "right", "full", "outer", "cross", "natural",
+	"using", "lateral", "and", "or", "not", "in", "is", "null", "true", "false", "like", "ilike",
+	"similar", "to", "between", "symmetric", "case", "when", "then", "else", "end", "exists", "any",
+	"all", "some", "union", "intersect", "except", "over", "partition", "window", "range", "groups",
+	"preceding", "following", "unbounded", "current", "filter", "within", "interval", "at", "time",
+	"zone", "escape", "for", "both", "leading", "trailing", "values", "cast", "current_date",
+	"current_timestamp", "localtimestamp",
+	"varchar", "char", "character", "varying", "text", "integer", "int", "bigint", "smallint",
+	"numeric", "decimal", "real", "float", "double", "precision", "boolean", "bool", "date",
+	"timestamp", "timestamptz", "without",
+	"year", "month", "day", "hour", "minute", "second", "week", "quarter", "dow", "doy", "epoch",
+	"years", "months", "days", "hours", "minutes", "seconds",
+)
+
+// sqlFunctions 查询语句中允许调用的函数
+var sqlFunctions = toSet(
+	"count", "sum", "avg", "min", "max", "stddev", "stddev_pop", "stddev_samp", "variance",
+	"var_pop", "var_samp", "percentile_cont", "percentile_disc", "mode", "string_agg", "array_agg",
+	"bool_and", "bool_or", "corr", "covar_pop", "covar_samp",
+	"row_number", "rank", "dense_rank", "percent_rank", "cume_dist", "ntile", "lag", "lead",
+	"first_value", "last_value", "nth_value",
+	"round", "ceil", "ceiling", "floor", "abs", "sqrt", "power", "exp", "ln", "log", "mod", "sign",
+	"trunc", "greatest", "least", "coalesce", "nullif",
+	"lower", "upper", "initcap", "length", "char_length", "trim", "ltrim", "rtrim", "btrim",
+	"substring", "substr", "replace", "concat", "concat_ws", "position", "strpos", "left", "right",
+	"split_part", "lpad", "rpad", "reverse", "regexp_replace", "starts_with",
+	"to_char", "to_date", "to_timestamp", "to_number", "date_trunc", "date_part", "extract", "age",
+	"now", "make_date", "date",
+)
+
+// sqlDeniedKeywords 会修改数据的关键字，出现在查询中的任意位置都会被拒绝
+var sqlDeniedKeywords = toSet("insert", "update", "delete", "merge", "into")
+
+// sqlClauseKeywords 结束FROM子句的关键字
+var sqlClauseKeywords = toSet(
+	"where", "group", "having", "order", "limit", "offset", "fetch", "window", "union", "intersect",
+	"except", "for",
+)
+
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// ValidateSQL 校验模型生成的SQL是否为只查询数据表tableName的单条SELECT语句，
+// 只允许引用数据表中的字段、查询中定义的CTE与别名，以及白名单中的函数，返回去除末尾分号后的SQL
+func ValidateSQL(sql string, tableName string, schema *document.TableSchema) (string, error) {
+	tokens, err := tokenizeSQL(sql)
+	if err != nil {
+		return "", err
+	}
+
+	// 1. 只允许执行单条SELECT或WITH查询语句
+	for len(tokens) > 0 && tokens[len(tokens)-1].kind == sqlTokenSymbol && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return "", errors.New("SQL语句为空")
+	}
+	if first := tokens[0]; first.kind != sqlTokenIdent || (first.text != "select" && first.text != "with") {
+		return "", errors.New("只允许执行SELECT查询语句")
+	}
+
+	// 2. 收集查询中可以引用的名称，FROM/JOIN后只能出现数据表与语句开头定义的CTE，其余位置还可以使用字段与别名
+	tables := map[string]bool{tableName: true}
+	names := map[string]bool{tableName: true}
+	for _, column := range schema.Columns {
+		names[column.Name] = true
+	}
+	for name := range leadingCTENames(tokens) {
+		tables[name] = true
+		names[name] = true
+	}
+	castTypes := castTypeIndexes(tokens)
+	for i, token := range tokens {
+		if token.kind != sqlTokenIdent && token.kind != sqlTokenQuotedIdent {
+			continue
+		}
+		switch {
+		case i+2 < len(tokens) && isKeyword(tokens[i+1], "as") && isSymbol(tokens[i+2], "("):
+			// WINDOW name AS (...)与子查询中的CTE，只能作为名称引用，不能出现在FROM之后
+			names[token.text] = true
+		case i > 0 && isKeyword(tokens[i-1], "as") && !castTypes[i]:
+			names[token.text] = true
+		case i > 0 && token.kind == sqlTokenIdent && !sqlKeywords[token.text] &&
+			(isSymbol(tokens[i-1], ")") || tables[tokens[i-1].text]):
+			// 省略AS的数据表与子查询别名
+			names[token.text] = true
+		}
+	}
+
+	// 3. 逐个检查标识符与函数，并记录当前是否位于FROM子句中
+	depth := 0
+	fromClause := map[int]bool{}
+	callParens := map[int]bool{}
+	expectTable := false
+	for i, token := range tokens {
+		var prev, next *sqlToken
+		if i > 0 {
+			prev = &tokens[i-1]
+		}
+		if i+1 < len(tokens) {
+			next = &tokens[i+1]
+		}
+
+		switch token.kind {
+		case sqlTokenSymbol:
+			switch token.text {
+			case ";":
+				return "", errors.New("只允许执行单条SQL语句")
+			case "(":
+				depth++
+				callParens[depth] = prev != nil && prev.kind == sqlTokenIdent && !sqlKeywords[prev.text]
+				fromClause[depth] = false
+				expectTable = false
+			case ")":
+				fromClause[depth] = false
+				depth--
+			case ",":
+				expectTable = fromClause[depth]
+			}
+			continue
+		case sqlTokenString, sqlTokenNumber:
+			continue
+		}
+
+		if expectTable {
+			if token.kind == sqlTokenIdent && token.text == "lateral" {
+				continue
+			}
+			if !tables[token.text] || (next != nil && (isSymbol(*next, "(") || isSymbol(*next, "."))) {
+				return "", fmt.Errorf("只能查询数据表%s，不能查询%s", tableName, token.text)
+			}
+			expectTable = false
+			continue
+		}
+
+		if token.kind == sqlTokenQuotedIdent {
+			if castTypes[i] || (prev != nil && isSymbol(*prev, "::")) {
+				return "", fmt.Errorf("不支持的类型: %s", token.text)
+			}
+			if next != nil && isSymbol(*next, "(") {
+				return "", fmt.Errorf("不支持的函数: %s", token.text)
+			}
+			if !names[token.text] {
+				return "", fmt.Errorf("未知的字段或数据表: %s", token.text)
+			}
+			continue
+		}
+
+		switch {
+		case sqlDeniedKeywords[token.text]:
+			return "", fmt.Errorf("不允许执行%s操作", strings.ToUpper(token.text))
+		case token.text == "for" && !callParens[depth]:
+			// SUBSTRING(... FOR ...)之外的FOR只会是FOR UPDATE/SHARE等行锁子句
+			return "", errors.New("不允许对数据行加锁")
+		case token.text == "from" && !callParens[depth], token.text == "join":
+			// EXTRACT(... FROM ...)等函数参数中的FROM不是FROM子句
+			fromClause[depth] = true
+			expectTable = true
+		case sqlClauseKeywords[token.text] && !callParens[depth]:
+			fromClause[depth] = false
+		case castTypes[i] || (prev != nil && isSymbol(*prev, "::")):
+			// ::与CAST(... AS type)中的类型名只能是白名单中的类型，regclass等类型会泄露数据库中的其他对象
+			if !sqlKeywords[token.text] {
+				return "", fmt.Errorf("不支持的类型: %s", token.text)
+			}
+		case next != nil && isSymbol(*next, "(") && !sqlKeywords[token.text]:
+			// 函数名不能通过别名绕过白名单
+			if !sqlFunctions[token.text] {
+				return "", fmt.Errorf("不支持的函数: %s", token.text)
+			}
+		case sqlKeywords[token.text] || sqlFunctions[token.text] || names[token.text]:
+		default:
+			return "", fmt.Errorf("未知的字段或数据表: %s", token.text)
+		}
+	}
+
+	sql = strings.TrimSpace(sql)
+	for strings.HasSuffix(sql, ";") {
+		sql = strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+	}
+	return sql, nil
+}
+
+// leadingCTENames 获取语句开头WITH子句定义的CTE名称，这些CTE在整条语句中可见。
+// 子查询中的WITH只在子查询内生效，不能用于在外层遮蔽其他数据表，因此不在结果中
+func leadingCTENames(tokens []sqlToken) map[string]bool {
+	ctes := make(map[string]bool)
+	if len(tokens) == 0 || !isKeyword(tokens[0], "with") {
+		return ctes
+	}
+
+	for i := 1; i < len(tokens); {
+		// name AS [NOT] [MATERIALIZED] (...)
+		name := tokens[i]
+		if name.kind != sqlTokenQuotedIdent && (name.kind != sqlTokenIdent || sqlKeywords[name.text]) {
+			break
+		}
+		j := i + 1
+		if j >= len(tokens) || !isKeyword(tokens[j], "as") {
+			break
+		}
+		j++
+		if j < len(tokens) && isKeyword(tokens[j], "not") {
+			j++
+		}
+		if j < len(tokens) && isKeyword(tokens[j], "materialized") {
+			j++
+		}
+		if j >= len(tokens) || !isSymbol(tokens[j], "(") {
+			break
+		}
+
+		// 跳过CTE的查询体
+		depth := 0
+		for ; j < len(tokens); j++ {
+			if isSymbol(tokens[j], "(") {
+				depth++
+			} else if isSymbol(tokens[j], ")") {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if j >= len(tokens) {
+			break
+		}
+		ctes[name.text] = true
+
+		if j+1 >= len(tokens) || !isSymbol(tokens[j+1], ",") {
+			break
+		}
+		i = j + 2
+	}
+
+	return ctes
+}
+
+// castTypeIndexes 返回CAST(... AS type)中类型名的下标，这些位置的AS之后是类型而不是别名
+func castTypeIndexes(tokens []sqlToken) map[int]bool {
+	indexes := make(map[int]bool)
+	var castParens []bool
+	for i, token := range tokens {
+		switch {
+		case isSymbol(token, "("):
+			castParens = append(castParens, i > 0 && isKeyword(tokens[i-1], "cast"))
+		case isSymbol(token, ")"):
+			if len(castParens) > 0 {
+				castParens = castParens[:len(castParens)-1]
+			}
+		case i > 0 && isKeyword(tokens[i-1], "as") && len(castParens) > 0 && castParens[len(castParens)-1]:
+			indexes[i] = true
+		}
+	}
+	return indexes
+}
+
+func isKeyword(token sqlToken, keyword string) bool {
+	return token.kind == sqlTokenIdent && token.text == keyword
+}
+
+func isSymbol(token sqlToken, symbol string) bool {
+	return token.kind == sqlTokenSymbol && token.text == symbol
+}
+
+// tokenizeSQL 将SQL切分为词法单元，不支持注释、参数占位符、美元符号引用与带前缀的字符串
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	runes := []rune(sql)
+	var tokens []sqlToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-',
+			r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			return nil, errors.New("SQL中不允许包含注释")
+		case r == '$' || r == '?':
+			return nil, fmt.Errorf("SQL中不允许包含%c", r)
+		case r == '\'' || r == '"':
+			// 字符串与带引号的标识符，连续两个引号表示引号本身
+			var text strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					if j+1 < len(runes) && runes[j+1] == r {
+						text.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				text.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, errors.New("SQL中存在未闭合的引号")
+			}
+			kind := sqlTokenString
+			if r == '"' {
+				kind = sqlTokenQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: text.String()})
+			i = j + 1
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			if j < len(runes) && (runes[j] == '\'' || runes[j] == '"') {
+				// E'...'等带前缀的字符串有不同的转义规则，会导致切分结果与数据库不一致
+				return nil, errors.New("SQL中不允许使用带前缀的字符串")
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenIdent, text: strings.ToLower(string(runes[i:j]))})
+			i = j
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' ||
+				((runes[j] == 'e' || runes[j] == 'E') && j+1 < len(runes) && (unicode.IsDigit(runes[j+1]) || runes[j+1] == '-' || runes[j+1] == '+')) ||
+				((runes[j] == '-' || runes[j] == '+') && (runes[j-1] == 'e' || runes[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: string(runes[i:j])})
+			i = j
+		default:
+			// 多字符运算符只需要识别类型转换符号::
+			if r == ':' && i+1 < len(runes) && runes[i+1] == ':' {
+				tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: "::"})
+				i += 2
+				continue
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenSymbol, text: string(r)})
+			i++
+		}
+	}
+
+	return tokens, nil
+}
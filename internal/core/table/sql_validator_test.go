@@ -0,0 +1,212 @@
+package table
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document"
+)
+
+const testTableName = "t_sales"
+
+var testSchema = &document.TableSchema{
+	Name: testTableName,
+	Columns: []*document.Column{
+		{Name: "region", Type: document.TableColumnTypeString},
+		{Name: "amount", Type: document.TableColumnTypeNumber},
+		{Name: "sold_at", Type: document.TableColumnTypeTime},
+		{Name: "Product Name", Type: document.TableColumnTypeString},
+	},
+}
+
+func TestValidateSQLAllowed(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "simple select",
+			sql:  "SELECT region, SUM(amount) FROM t_sales GROUP BY region ORDER BY 2 DESC LIMIT 10",
+		},
+		{
+			name: "trailing semicolons are stripped",
+			sql:  "select * from t_sales;; ",
+			want: "select * from t_sales",
+		},
+		{
+			name: "quoted column and table",
+			sql:  `SELECT "Product Name" FROM "t_sales" WHERE "Product Name" ILIKE '%tea%'`,
+		},
+		{
+			name: "alias with and without as",
+			sql:  "SELECT s.region AS r, COUNT(*) total FROM t_sales s WHERE s.amount > 0 GROUP BY r",
+		},
+		{
+			name: "leading cte",
+			sql:  "WITH monthly AS (SELECT date_trunc('month', sold_at) AS m, SUM(amount) AS total FROM t_sales GROUP BY 1) SELECT m, total FROM monthly ORDER BY m",
+		},
+		{
+			name: "several ctes referencing each other",
+			sql:  "WITH a AS (SELECT region FROM t_sales), b AS MATERIALIZED (SELECT region FROM a) SELECT * FROM b JOIN a ON a.region = b.region",
+		},
+		{
+			name: "cte shadowing another table name stays a cte",
+			sql:  "WITH pg_user AS (SELECT region FROM t_sales) SELECT * FROM pg_user",
+		},
+		{
+			name: "subquery in from and where",
+			sql:  "SELECT x.region FROM (SELECT region FROM t_sales) x WHERE x.region IN (SELECT region FROM t_sales WHERE amount > 100)",
+		},
+		{
+			name: "extract and substring use from and for inside calls",
+			sql:  "SELECT EXTRACT(YEAR FROM sold_at), SUBSTRING(region FROM 1 FOR 2) FROM t_sales",
+		},
+		{
+			name: "whitelisted casts",
+			sql:  "SELECT amount::numeric, sold_at::date, CAST(amount AS integer) FROM t_sales",
+		},
+		{
+			name: "alias inside a cast subquery",
+			sql:  "SELECT CAST((SELECT max(amount) AS top FROM t_sales) AS integer) AS top_amount FROM t_sales",
+		},
+		{
+			name: "window function with named window",
+			sql:  "SELECT region, rank() OVER w FROM t_sales WINDOW w AS (PARTITION BY region ORDER BY amount DESC)",
+		},
+		{
+			name: "strings may contain anything",
+			sql:  "SELECT * FROM t_sales WHERE region = 'x; DROP TABLE t_sales -- pg_catalog.pg_user'",
+		},
+		{
+			name: "escaped quote in string",
+			sql:  "SELECT * FROM t_sales WHERE region = 'O''Brien'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateSQL(tt.sql, testTableName, testSchema)
+			assert.NoError(t, err)
+			want := tt.want
+			if want == "" {
+				want = tt.sql
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestValidateSQLRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr string
+	}{
+		// 语句类型
+		{name: "empty", sql: " ; ", wantErr: "SQL语句为空"},
+		{name: "update statement", sql: "UPDATE t_sales SET amount = 0", wantErr: "只允许执行SELECT查询语句"},
+		{name: "table statement", sql: "TABLE pg_authid", wantErr: "只允许执行SELECT查询语句"},
+		{name: "select into", sql: "SELECT * INTO copy FROM t_sales", wantErr: "不允许执行INTO操作"},
+		{name: "data modifying cte", sql: "WITH d AS (DELETE FROM t_sales RETURNING *) SELECT * FROM d", wantErr: "不允许执行DELETE操作"},
+
+		// 多条语句
+		{name: "stacked statement", sql: "SELECT * FROM t_sales; DROP TABLE t_sales", wantErr: "只允许执行单条SQL语句"},
+		{name: "stacked select", sql: "SELECT 1; SELECT * FROM pg_shadow;", wantErr: "只允许执行单条SQL语句"},
+
+		// 注释
+		{name: "line comment", sql: "SELECT * FROM t_sales -- ; DROP TABLE t_sales", wantErr: "SQL中不允许包含注释"},
+		{name: "block comment", sql: "SELECT * FROM /* x */ t_sales", wantErr: "SQL中不允许包含注释"},
+		{name: "comment hiding a table", sql: "SELECT * FROM t_sales/**/, pg_user", wantErr: "SQL中不允许包含注释"},
+
+		// 其他数据表
+		{name: "other table", sql: "SELECT * FROM users", wantErr: "只能查询数据表t_sales，不能查询users"},
+		{name: "other table in join", sql: "SELECT * FROM t_sales JOIN accounts ON true", wantErr: "不能查询accounts"},
+		{name: "other table after comma", sql: "SELECT * FROM t_sales s, accounts", wantErr: "不能查询accounts"},
+		{name: "other table in subquery", sql: "SELECT * FROM t_sales WHERE region IN (SELECT region FROM accounts)", wantErr: "不能查询accounts"},
+		{name: "other table in select list", sql: "SELECT (SELECT region FROM accounts LIMIT 1) FROM t_sales", wantErr: "不能查询accounts"},
+		{name: "schema qualified target table", sql: "SELECT * FROM public.t_sales", wantErr: "不能查询public"},
+		{name: "target table used as schema", sql: "SELECT * FROM t_sales.secret", wantErr: "不能查询t_sales"},
+		{name: "only keyword", sql: "SELECT * FROM ONLY accounts", wantErr: "不能查询only"},
+		{name: "lateral function", sql: "SELECT * FROM t_sales CROSS JOIN LATERAL pg_ls_dir('.')", wantErr: "不能查询pg_ls_dir"},
+		{name: "set returning function", sql: "SELECT * FROM generate_series(1, 10)", wantErr: "不能查询generate_series"},
+
+		// 系统表
+		{name: "pg_catalog table", sql: "SELECT * FROM pg_catalog.pg_tables", wantErr: "不能查询pg_catalog"},
+		{name: "unqualified catalog table", sql: "SELECT * FROM pg_shadow", wantErr: "不能查询pg_shadow"},
+		{name: "information schema", sql: "SELECT * FROM information_schema.tables", wantErr: "不能查询information_schema"},
+		{name: "pg_catalog function", sql: "SELECT pg_catalog.current_setting('data_directory')", wantErr: "未知的字段或数据表: pg_catalog"},
+		{name: "pg_catalog in exists", sql: "SELECT * FROM t_sales WHERE EXISTS (SELECT 1 FROM pg_catalog.pg_user)", wantErr: "不能查询pg_catalog"},
+
+		// 带引号的标识符
+		{name: "quoted other table", sql: `SELECT * FROM "pg_authid"`, wantErr: "不能查询pg_authid"},
+		{name: "quoted schema", sql: `SELECT * FROM "pg_catalog"."pg_user"`, wantErr: "不能查询pg_catalog"},
+		{name: "quoted table with different case", sql: `SELECT * FROM "T_SALES"`, wantErr: "不能查询T_SALES"},
+		{name: "quoted function", sql: `SELECT "pg_sleep"(10) FROM t_sales`, wantErr: "不支持的函数: pg_sleep"},
+		{name: "quoted unknown column", sql: `SELECT "rolpassword" FROM t_sales`, wantErr: "未知的字段或数据表: rolpassword"},
+		{name: "unicode escaped identifier", sql: `SELECT * FROM U&"pg_user"`, wantErr: "不能查询u"},
+		{name: "unterminated quote", sql: `SELECT "region FROM t_sales`, wantErr: "SQL中存在未闭合的引号"},
+
+		// 带前缀的字符串与特殊字符
+		{name: "escape string", sql: `SELECT E'\'' FROM t_sales`, wantErr: "SQL中不允许使用带前缀的字符串"},
+		{name: "dollar quoted string", sql: "SELECT $$x$$ FROM t_sales", wantErr: "SQL中不允许包含$"},
+		{name: "placeholder", sql: "SELECT * FROM t_sales WHERE region = ?", wantErr: "SQL中不允许包含?"},
+
+		// CTE遮蔽
+		{name: "cte reading other table", sql: "WITH x AS (SELECT * FROM pg_shadow) SELECT * FROM x", wantErr: "不能查询pg_shadow"},
+		{
+			name:    "nested cte does not shadow outer from",
+			sql:     "SELECT * FROM pg_authid WHERE 1 = (WITH pg_authid AS (SELECT 1) SELECT 1)",
+			wantErr: "不能查询pg_authid",
+		},
+		{
+			name:    "window name is not a table",
+			sql:     "SELECT (SELECT count(*) FROM w) FROM t_sales WINDOW w AS (PARTITION BY region)",
+			wantErr: "不能查询w",
+		},
+		{
+			name:    "window list after cte name is not a cte list",
+			sql:     "WITH a AS (SELECT 1) SELECT (SELECT count(*) FROM pg_authid) FROM t_sales WINDOW a AS (), pg_authid AS ()",
+			wantErr: "不能查询pg_authid",
+		},
+		{name: "recursive cte", sql: "WITH RECURSIVE r AS (SELECT 1) SELECT * FROM r", wantErr: "未知的字段或数据表: recursive"},
+
+		// 行锁
+		{name: "for update", sql: "SELECT * FROM t_sales FOR UPDATE", wantErr: "不允许对数据行加锁"},
+		{name: "for share", sql: "SELECT * FROM t_sales FOR SHARE", wantErr: "不允许对数据行加锁"},
+		{name: "for key share", sql: "SELECT * FROM t_sales FOR KEY SHARE", wantErr: "不允许对数据行加锁"},
+		{name: "for no key update", sql: "SELECT * FROM t_sales FOR NO KEY UPDATE NOWAIT", wantErr: "不允许对数据行加锁"},
+		{name: "for share in subquery", sql: "SELECT * FROM (SELECT * FROM t_sales FOR SHARE) s", wantErr: "不允许对数据行加锁"},
+
+		// 白名单外的函数
+		{name: "pg_sleep", sql: "SELECT pg_sleep(10)", wantErr: "不支持的函数: pg_sleep"},
+		{name: "read file", sql: "SELECT pg_read_file('/etc/passwd') FROM t_sales", wantErr: "不支持的函数: pg_read_file"},
+		{name: "set_config", sql: "SELECT set_config('role', 'postgres', false)", wantErr: "不支持的函数: set_config"},
+		{name: "dblink", sql: "SELECT * FROM t_sales WHERE region = dblink('host=x', 'select 1')", wantErr: "不支持的函数: dblink"},
+		{name: "function behind column alias", sql: "SELECT region AS lo_import, lo_import('/etc/passwd') FROM t_sales", wantErr: "不支持的函数: lo_import"},
+		{name: "function on table qualifier", sql: "SELECT t_sales.pg_sleep(1) FROM t_sales", wantErr: "不支持的函数: pg_sleep"},
+		{name: "function without parentheses", sql: "SELECT current_user FROM t_sales", wantErr: "未知的字段或数据表: current_user"},
+		{name: "version", sql: "SELECT version()", wantErr: "不支持的函数: version"},
+
+		// 类型转换
+		{name: "regclass cast", sql: "SELECT 'pg_authid'::regclass FROM t_sales", wantErr: "不支持的类型: regclass"},
+		{name: "qualified cast type", sql: "SELECT region::pg_catalog.text FROM t_sales", wantErr: "不支持的类型: pg_catalog"},
+		{name: "regclass in CAST", sql: "SELECT CAST('pg_authid' AS regclass) FROM t_sales", wantErr: "不支持的类型: regclass"},
+		{name: "qualified type in CAST", sql: "SELECT CAST(region AS pg_catalog.text) FROM t_sales", wantErr: "不支持的类型: pg_catalog"},
+		{name: "quoted type in CAST", sql: `SELECT CAST('pg_authid' AS "regclass") FROM t_sales`, wantErr: "不支持的类型: regclass"},
+		{name: "quoted cast type named by an alias", sql: `SELECT region AS "regclass", 'pg_authid'::"regclass" FROM t_sales`, wantErr: "不支持的类型: regclass"},
+
+		// 未知字段
+		{name: "unknown column", sql: "SELECT secret FROM t_sales", wantErr: "未知的字段或数据表: secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateSQL(tt.sql, testTableName, testSchema)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
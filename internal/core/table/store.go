@@ -0,0 +1,352 @@
+package table
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+const (
+	// documentIDColumn 数据表中记录行所属文档的内部字段
+	documentIDColumn = "_document_id"
+	// rowIDColumn 数据表的自增主键
+	rowIDColumn = "_row_id"
+	// insertBatchSize 导入数据时每批写入的行数
+	insertBatchSize = 500
+	// queryTimeout 单次查询的最长执行时间
+	queryTimeout = 10 * time.Second
+)
+
+// TableName 知识库对应的数据表名称
+func TableName(datasetID uuid.UUID) string {
+	return "dataset_table_" + strings.ReplaceAll(datasetID.String(), "-", "")
+}
+
+// TableDataset 表格知识库及其数据表
+type TableDataset struct {
+	Dataset entity.Dataset
+	Table   entity.DatasetTable
+}
+
+// QueryResult SQL查询结果，Truncated表示结果超过了行数限制被截断
+type QueryResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	Truncated bool     `json:"truncated"`
+}
+
+// Store 表格知识库的数据表存储，每个知识库的数据存储在独立的数据表中，
+// 查询时只暴露已启用文档的数据，并在只读事务中执行
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 创建表格知识库的数据表存储
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// GetTable 获取知识库的数据表，知识库还没有导入过数据时返回nil
+func (s *Store) GetTable(ctx context.Context, datasetID uuid.UUID) (*entity.DatasetTable, error) {
+	var datasetTable entity.DatasetTable
+	err := s.db.WithContext(ctx).Where("dataset_id = ?", datasetID).First(&datasetTable).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dataset table: %w", err)
+	}
+
+	return &datasetTable, nil
+}
+
+// ListTableDatasets 获取账号下指定知识库中已经导入过数据的表格知识库
+func (s *Store) ListTableDatasets(ctx context.Context, accountID uuid.UUID, datasetIDs []uuid.UUID) ([]TableDataset, error) {
+	if len(datasetIDs) == 0 {
+		return nil, nil
+	}
+
+	var tables []entity.DatasetTable
+	err := s.db.WithContext(ctx).
+		Where("account_id = ? AND dataset_id IN ?", accountID, datasetIDs).
+		Find(&tables).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dataset tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	tableIDs := make([]uuid.UUID, 0, len(tables))
+	for _, datasetTable := range tables {
+		tableIDs = append(tableIDs, datasetTable.DatasetID)
+	}
+	var datasets []entity.Dataset
+	err = s.db.WithContext(ctx).Where("id IN ?", tableIDs).Order("ctime ASC").Find(&datasets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list table datasets: %w", err)
+	}
+
+	tableMap := make(map[uuid.UUID]entity.DatasetTable, len(tables))
+	for _, datasetTable := range tables {
+		tableMap[datasetTable.DatasetID] = datasetTable
+	}
+	result := make([]TableDataset, 0, len(datasets))
+	for _, dataset := range datasets {
+		result = append(result, TableDataset{Dataset: dataset, Table: tableMap[dataset.ID]})
+	}
+
+	return result, nil
+}
+
+// Import 将表格数据导入知识库的数据表并返回导入的行数，首次导入时根据数据推断字段并建表，
+// 之后导入的文件表头必须与数据表一致，数据无法转换为已有字段类型时放宽字段类型
+func (s *Store) Import(ctx context.Context, dataset *entity.Dataset, documentID uuid.UUID, data *Table) (int, error) {
+	incoming := InferSchema(data)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 同一知识库的文档串行导入，避免并发建表与修改字段类型
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", TableName(dataset.ID)).Error; err != nil {
+			return fmt.Errorf("failed to lock dataset table: %w", err)
+		}
+
+		// 2. 首次导入时创建数据表，否则校验表头并按需放宽字段类型
+		var datasetTable entity.DatasetTable
+		err := tx.Where("dataset_id = ?", dataset.ID).First(&datasetTable).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			datasetTable = entity.DatasetTable{
+				AccountID: dataset.AccountID,
+				DatasetID: dataset.ID,
+				Name:      TableName(dataset.ID),
+				Columns:   incoming.Columns,
+			}
+			if err := createTable(tx, &datasetTable); err != nil {
+				return err
+			}
+			if err := tx.Create(&datasetTable).Error; err != nil {
+				return fmt.Errorf("failed to create dataset table record: %w", err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to get dataset table: %w", err)
+		default:
+			if err := mergeColumns(tx, &datasetTable, incoming.Columns, data); err != nil {
+				return err
+			}
+		}
+
+		// 3. 按照字段类型转换数据后分批写入
+		columns := make(map[string]*document.Column, len(datasetTable.Columns))
+		for _, column := range datasetTable.Columns {
+			columns[column.Name] = column
+		}
+		batch := make([]map[string]any, 0, insertBatchSize)
+		for i, row := range data.Rows {
+			record := map[string]any{documentIDColumn: documentID}
+			for j, column := range incoming.Columns {
+				columnType := columns[column.Name].Type
+				value, err := ParseValue(columnType, row[j])
+				if err != nil {
+					return fmt.Errorf("第%d行字段%s的值%q无法转换为%s类型", i+1, column.Description, row[j], postgresType(columnType))
+				}
+				record[column.Name] = value
+			}
+			batch = append(batch, record)
+
+			if len(batch) == insertBatchSize || i == len(data.Rows)-1 {
+				if err := tx.Table(datasetTable.Name).Create(&batch).Error; err != nil {
+					return fmt.Errorf("failed to insert table rows: %w", err)
+				}
+				batch = make([]map[string]any, 0, insertBatchSize)
+			}
+		}
+
+		// 4. 更新数据表的行数
+		return tx.Model(&entity.DatasetTable{}).
+			Where("id = ?", datasetTable.ID).
+			Update("row_count", gorm.Expr("row_count + ?", len(data.Rows))).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data.Rows), nil
+}
+
+// createTable 创建知识库的数据表，同名的遗留数据表会被删除
+func createTable(tx *gorm.DB, datasetTable *entity.DatasetTable) error {
+	definitions := []string{
+		quoteIdentifier(rowIDColumn) + " BIGSERIAL PRIMARY KEY",
+		quoteIdentifier(documentIDColumn) + " UUID NOT NULL",
+	}
+	for _, column := range datasetTable.Columns {
+		definitions = append(definitions, quoteIdentifier(column.Name)+" "+postgresType(column.Type))
+	}
+
+	statements := []string{
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdentifier(datasetTable.Name)),
+		fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(datasetTable.Name), strings.Join(definitions, ", ")),
+		fmt.Sprintf("CREATE INDEX %s ON %s (%s)",
+			quoteIdentifier(datasetTable.Name+"_document_id_idx"), quoteIdentifier(datasetTable.Name), quoteIdentifier(documentIDColumn)),
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to create table %s: %w", datasetTable.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// mergeColumns 校验导入文件的字段与数据表一致，数据无法转换为已有字段类型时修改字段类型
+func mergeColumns(tx *gorm.DB, datasetTable *entity.DatasetTable, incoming []*document.Column, data *Table) error {
+	columns := make(map[string]*document.Column, len(datasetTable.Columns))
+	for _, column := range datasetTable.Columns {
+		columns[column.Name] = column
+	}
+	if len(incoming) != len(datasetTable.Columns) {
+		return fmt.Errorf("表头与已导入的文档不一致，应包含%d列，实际为%d列", len(datasetTable.Columns), len(incoming))
+	}
+
+	changed := false
+	for i, column := range incoming {
+		existing, ok := columns[column.Name]
+		if !ok {
+			return fmt.Errorf("表头与已导入的文档不一致，数据表中不存在字段%s", column.Description)
+		}
+
+		compatible := true
+		for _, row := range data.Rows {
+			if _, err := ParseValue(existing.Type, row[i]); err != nil {
+				compatible = false
+				break
+			}
+		}
+		if compatible {
+			continue
+		}
+
+		existing.Type = widenColumnType(existing.Type, column.Type)
+		statement := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s",
+			quoteIdentifier(datasetTable.Name), quoteIdentifier(existing.Name), postgresType(existing.Type),
+			quoteIdentifier(existing.Name), postgresType(existing.Type))
+		if err := tx.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to alter column %s: %w", existing.Name, err)
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	return tx.Model(datasetTable).Select("columns").Updates(datasetTable).Error
+}
+
+// DeleteDocumentRows 删除文档导入到数据表中的所有行
+func (s *Store) DeleteDocumentRows(ctx context.Context, datasetID, documentID uuid.UUID) error {
+	datasetTable, err := s.GetTable(ctx, datasetID)
+	if err != nil || datasetTable == nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?",
+			quoteIdentifier(datasetTable.Name), quoteIdentifier(documentIDColumn)), documentID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete table rows: %w", result.Error)
+		}
+
+		return tx.Model(&entity.DatasetTable{}).
+			Where("id = ?", datasetTable.ID).
+			Update("row_count", gorm.Expr("GREATEST(row_count - ?, 0)", result.RowsAffected)).Error
+	})
+}
+
+// DropTable 删除知识库的数据表及其记录
+func (s *Store) DropTable(ctx context.Context, datasetID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdentifier(TableName(datasetID)))).Error; err != nil {
+			return fmt.Errorf("failed to drop dataset table: %w", err)
+		}
+
+		return tx.Where("dataset_id = ?", datasetID).Delete(&entity.DatasetTable{}).Error
+	})
+}
+
+// Query 校验并执行模型生成的SQL，最多返回limit行数据。
+// 执行时使用与数据表同名的CTE覆盖数据表，只暴露已启用文档的数据并隐藏内部字段
+func (s *Store) Query(ctx context.Context, datasetTable *entity.DatasetTable, sql string, limit int) (*QueryResult, error) {
+	// 1. 校验SQL只查询当前数据表
+	sql, err := ValidateSQL(sql, datasetTable.Name, &document.TableSchema{Name: datasetTable.Name, Columns: datasetTable.Columns})
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 构建最终执行的查询语句，多查询一行用于判断结果是否被截断
+	columns := make([]string, 0, len(datasetTable.Columns))
+	for _, column := range datasetTable.Columns {
+		columns = append(columns, quoteIdentifier(column.Name))
+	}
+	query := fmt.Sprintf(
+		"WITH %s AS (SELECT %s FROM %s WHERE %s IN (SELECT id FROM document WHERE dataset_id = '%s' AND enabled = true)) "+
+			"SELECT * FROM (%s) AS query_result LIMIT %d",
+		quoteIdentifier(datasetTable.Name), strings.Join(columns, ", "), quoteIdentifier(datasetTable.Name),
+		quoteIdentifier(documentIDColumn), datasetTable.DatasetID, sql, limit+1,
+	)
+
+	// 3. 在只读事务中执行查询，并限制执行时间
+	result := &QueryResult{Rows: make([][]any, 0)}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET TRANSACTION READ ONLY").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", queryTimeout.Milliseconds())).Error; err != nil {
+			return err
+		}
+
+		rows, err := tx.Raw(query).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		if result.Columns, err = rows.Columns(); err != nil {
+			return err
+		}
+		for rows.Next() {
+			values := make([]any, len(result.Columns))
+			pointers := make([]any, len(values))
+			for i := range values {
+				pointers[i] = &values[i]
+			}
+			if err := rows.Scan(pointers...); err != nil {
+				return err
+			}
+			for i, value := range values {
+				if bytes, ok := value.([]byte); ok {
+					values[i] = string(bytes)
+				}
+			}
+			result.Rows = append(result.Rows, values)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SQL执行失败: %w", err)
+	}
+
+	if len(result.Rows) > limit {
+		result.Rows = result.Rows[:limit]
+		result.Truncated = true
+	}
+
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package table
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// sqlFencePattern 匹配模型回答中```sql代码块内的SQL
+var sqlFencePattern = regexp.MustCompile("(?s)```(?:sql|SQL)?\\s*(.*?)```")
+
+const textToSQLPromptTemplate = `你是一个PostgreSQL数据分析专家，请根据用户的问题编写一条查询数据表的SQL语句。
+
+数据表名称：{table}
+数据表字段（字段名 类型：原始表头）：
+{columns}
+
+数据示例：
+{samples}
+
+请严格遵守以下规则：
+1. 只能编写一条SELECT查询语句，不能修改数据，不能查询其他数据表。
+2. 字段名必须使用双引号包裹，例如"{example}"，只能使用上面列出的字段。
+3. 统计、汇总、排名类问题请使用聚合函数与ORDER BY直接计算出结果，不要返回全部明细数据。
+4. 不能包含注释、参数占位符或多条语句。
+5. 只输出SQL语句本身，不要输出任何解释。`
+
+// GenerateSQL 使用模型根据问题生成查询数据表的SQL，lastSQL与lastErr不为空时会要求模型修正上一次生成的SQL
+func GenerateSQL(ctx context.Context, chatModel model.BaseChatModel, datasetTable *entity.DatasetTable,
+	samples *QueryResult, question, lastSQL string, lastErr error) (string, error) {
+	// 1. 构建包含表结构与示例数据的系统提示
+	columns := make([]string, 0, len(datasetTable.Columns))
+	for _, column := range datasetTable.Columns {
+		columns = append(columns, fmt.Sprintf("- \"%s\" %s：%s", column.Name, postgresType(column.Type), column.Description))
+	}
+	sampleText := "无"
+	if samples != nil && len(samples.Rows) > 0 {
+		sampleText, _ = sonic.MarshalString(samples)
+	}
+	example := ""
+	if len(datasetTable.Columns) > 0 {
+		example = datasetTable.Columns[0].Name
+	}
+	prompt := strings.NewReplacer(
+		"{table}", datasetTable.Name,
+		"{columns}", strings.Join(columns, "\n"),
+		"{samples}", sampleText,
+		"{example}", example,
+	).Replace(textToSQLPromptTemplate)
+
+	// 2. 上一次生成的SQL执行失败时，将错误信息反馈给模型修正
+	messages := []*schema.Message{schema.SystemMessage(prompt), schema.UserMessage(question)}
+	if lastErr != nil {
+		messages = append(messages,
+			schema.AssistantMessage(lastSQL, nil),
+			schema.UserMessage(fmt.Sprintf("上面的SQL执行失败：%v\n请修正后重新输出完整的SQL语句。", lastErr)),
+		)
+	}
+
+	response, err := chatModel.Generate(ctx, messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate sql: %w", err)
+	}
+
+	return extractSQL(response.Content), nil
+}
+
+// extractSQL 从模型回答中提取SQL，兼容使用代码块包裹的回答
+func extractSQL(content string) string {
+	if match := sqlFencePattern.FindStringSubmatch(content); match != nil {
+		content = match[1]
+	}
+
+	return strings.TrimSpace(content)
+}
@@ -53,6 +53,11 @@ func (apm *APIProviderManager) GetTool(ctx context.Context, toolEntity *entities
 	}, nil
 }
 
+// ToolName 返回API工具在Agent中使用的名称，由提供者id与工具名称组成
+func ToolName(providerID, toolName string) string {
+	return fmt.Sprintf("%s_%s", providerID, toolName)
+}
+
 // APITool 实现了eino的InvokableTool接口的API工具
 type APITool struct {
 	entity     *entities.APIToolEntity
@@ -98,7 +103,7 @@ func (at *APITool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	}
 
 	return &schema.ToolInfo{
-		Name:        fmt.Sprintf("%s_%s", at.entity.ID, at.entity.Name),
+		Name:        ToolName(at.entity.ID, at.entity.Name),
 		Desc:        at.entity.Description,
 		ParamsOneOf: paramsOneOf,
 	}, nil
@@ -212,8 +212,8 @@ func (m *BuiltinProviderManager) registerTools() error {
 		return fmt.Errorf("failed to create wikipedia_search tool: %w", err)
 	}
 
-	// 注册图像工具
-	m.toolMap["dalle3"], err = dalle.NewDalle3Tool()
+	// 注册图像工具，此处的实例只用于展示工具信息，调用时按账号凭证通过CreateToolWithCredentials创建
+	m.toolMap["dalle3"], err = dalle.NewDalle3Tool("")
 	if err != nil {
 		return fmt.Errorf("failed to create dalle3 tool: %w", err)
 	}
@@ -308,6 +308,29 @@ func (m *BuiltinProviderManager) GetTool(name string) (tool.InvokableTool, error
 	return t, nil
 }
 
+// credentialTools 调用时需要使用账号模型服务凭证的内置工具及对应的模型服务提供商
+var credentialTools = map[string]string{
+	"dalle3": "openai",
+}
+
+// CredentialProvider 返回工具调用时需要使用其凭证的模型服务提供商，不需要凭证的工具返回false
+func (m *BuiltinProviderManager) CredentialProvider(name string) (string, bool) {
+	provider, ok := credentialTools[name]
+	return provider, ok
+}
+
+// CreateToolWithCredentials 使用传递的模型服务凭证创建需要凭证的内置工具
+func (m *BuiltinProviderManager) CreateToolWithCredentials(name string, credentials map[string]any) (tool.InvokableTool, error) {
+	apiKey, _ := credentials["api_key"].(string)
+
+	switch name {
+	case "dalle3":
+		return dalle.NewDalle3Tool(apiKey)
+	default:
+		return m.GetTool(name)
+	}
+}
+
 // ListTools 列出所有工具
 func (m *BuiltinProviderManager) ListTools() []string {
 	m.mu.RLock()
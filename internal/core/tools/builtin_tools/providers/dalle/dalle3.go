@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
@@ -47,9 +46,8 @@ type OpenAIImageResponse struct {
 	} `json:"error,omitempty"`
 }
 
-// dalle3Tool DALLE-3图像生成工具实现
-func dalle3Tool(ctx context.Context, req Dalle3Request) (Dalle3Response, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
+// dalle3Tool DALLE-3图像生成工具实现，使用传递的OpenAI密钥调用图像接口
+func dalle3Tool(ctx context.Context, apiKey string, req Dalle3Request) (Dalle3Response, error) {
 	if apiKey == "" {
 		return Dalle3Response{
 			Success: false,
@@ -129,7 +127,10 @@ func generateImage(ctx context.Context, prompt, apiKey string) (string, error) {
 	return imageResp.Data[0].URL, nil
 }
 
-// NewDalle3Tool 创建DALLE-3图像生成工具
-func NewDalle3Tool() (tool.InvokableTool, error) {
-	return utils.InferTool("dalle3", "DALLE-3图像生成工具，可以根据文本描述生成图像", dalle3Tool)
+// NewDalle3Tool 创建DALLE-3图像生成工具，apiKey为调用方账号配置的OpenAI密钥
+func NewDalle3Tool(apiKey string) (tool.InvokableTool, error) {
+	return utils.InferTool("dalle3", "DALLE-3图像生成工具，可以根据文本描述生成图像",
+		func(ctx context.Context, req Dalle3Request) (Dalle3Response, error) {
+			return dalle3Tool(ctx, apiKey, req)
+		})
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
 	"github.com/crazyfrankie/voidx/internal/core/workflow/entities"
+	"github.com/crazyfrankie/voidx/types/consts"
 )
 
 // DatasetRetrievalNode represents a dataset retrieval workflow node
@@ -132,10 +133,11 @@ func (n *DatasetRetrievalNode) extractVariablesFromState(state *entities.Workflo
 func (n *DatasetRetrievalNode) performRetrieval(ctx context.Context, query string) ([]map[string]interface{}, error) {
 	// Get retrieval configuration
 	topK := 5
-	if topKValue, exists := n.nodeData.RetrievalConfig["top_k"]; exists {
-		if topKInt, ok := topKValue.(int); ok {
-			topK = topKInt
-		}
+	switch topKValue := n.nodeData.RetrievalConfig["top_k"].(type) {
+	case int:
+		topK = topKValue
+	case float64:
+		topK = int(topKValue)
 	}
 
 	retrievalMode := "hybrid"
@@ -145,20 +147,29 @@ func (n *DatasetRetrievalNode) performRetrieval(ctx context.Context, query strin
 		}
 	}
 
-	// Use the first dataset ID for retrieval (in a real implementation, you might want to handle multiple datasets)
 	if len(n.nodeData.DatasetIDs) == 0 {
 		return nil, fmt.Errorf("no dataset IDs configured for retrieval")
 	}
 
-	// Create retriever configuration
-	config := n.retrieverService.GetDefaultConfig(retrievers.RetrieverType(retrievalMode), n.nodeData.DatasetIDs[0])
-	config.TopK = topK
+	// Rerank, fusion and metadata filter settings are passed through to the retrievers
+	options := map[string]any{
+		"k":          topK,
+		"account_id": n.accountID,
+	}
+	for _, key := range []string{"rerank", "fusion", "metadata_filter"} {
+		if value, exists := n.nodeData.RetrievalConfig[key]; exists {
+			options[key] = value
+		}
+	}
 
 	// Perform retrieval using the service
-	documents, err := n.retrieverService.RetrieveDocuments(ctx, query, config)
+	documents, err := n.retrieverService.Search(ctx, query, n.nodeData.DatasetIDs, consts.RetrievalStrategy(retrievalMode), options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve documents: %w", err)
 	}
+	if topK > 0 && len(documents) > topK {
+		documents = documents[:topK]
+	}
 
 	// Convert documents to map format
 	results := make([]map[string]interface{}, len(documents))
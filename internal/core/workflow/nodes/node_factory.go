@@ -226,6 +226,10 @@ func (f *NodeFactory) ParseNodeData(nodeMap map[string]interface{}) (entities.No
 				nodeData.RetrievalConfig = configMap
 			}
 		}
+		// Reject malformed metadata filters when the workflow is loaded rather than on every run
+		if _, err := retrievers.ParseMetadataFilter(nodeData.RetrievalConfig["metadata_filter"]); err != nil {
+			return nil, fmt.Errorf("invalid metadata filter of dataset retrieval node: %w", err)
+		}
 		// Parse inputs and outputs
 		if err := f.parseInputsOutputs(nodeMap, &nodeData.Inputs, &nodeData.Outputs); err != nil {
 			return nil, err
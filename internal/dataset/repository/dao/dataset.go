@@ -266,3 +266,27 @@ func (d *DatasetDao) GetCharacterCount(ctx context.Context, datasetID uuid.UUID)
 		Scan(&totalCharacterCount).Error
 	return int(totalCharacterCount), err
 }
+
+// CountDocumentsWithMetadata 统计知识库下填写了指定元数据字段的文档数，传递values时只统计取值属于values的文档
+func (d *DatasetDao) CountDocumentsWithMetadata(ctx context.Context, datasetID uuid.UUID, field string, values []string) (int, error) {
+	query := d.db.WithContext(ctx).Model(&entity.Document{}).Where("dataset_id = ?", datasetID)
+	if len(values) > 0 {
+		query = query.Where("metadata ->> ? IN ?", field, values)
+	} else {
+		query = query.Where("metadata -> ? IS NOT NULL", field)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return int(count), err
+}
+
+// GetDatasetTable 获取表格知识库的数据表，知识库还没有导入过数据时返回nil
+func (d *DatasetDao) GetDatasetTable(ctx context.Context, datasetID uuid.UUID) (*entity.DatasetTable, error) {
+	var tables []entity.DatasetTable
+	err := d.db.WithContext(ctx).Where("dataset_id = ?", datasetID).Limit(1).Find(&tables).Error
+	if err != nil || len(tables) == 0 {
+		return nil, err
+	}
+	return &tables[0], nil
+}
@@ -113,3 +113,11 @@ func (r *DatasetRepo) GetRelatedAppCount(ctx context.Context, datasetID uuid.UUI
 func (r *DatasetRepo) GetCharacterCount(ctx context.Context, datasetID uuid.UUID) (int, error) {
 	return r.dao.GetCharacterCount(ctx, datasetID)
 }
+
+func (r *DatasetRepo) CountDocumentsWithMetadata(ctx context.Context, datasetID uuid.UUID, field string, values []string) (int, error) {
+	return r.dao.CountDocumentsWithMetadata(ctx, datasetID, field, values)
+}
+
+func (r *DatasetRepo) GetDatasetTable(ctx context.Context, datasetID uuid.UUID) (*entity.DatasetTable, error) {
+	return r.dao.GetDatasetTable(ctx, datasetID)
+}
@@ -4,16 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/google/uuid"
 
+	"github.com/crazyfrankie/voidx/conf"
+	"github.com/crazyfrankie/voidx/internal/core/retrievers"
 	"github.com/crazyfrankie/voidx/internal/dataset/repository"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/segment"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
 	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/crazyfrankie/voidx/types/errno"
@@ -23,14 +28,17 @@ type DatasetService struct {
 	repo             *repository.DatasetRepo
 	retrieverService *retriever.Service
 	segmentService   *segment.Service
+	llmService       *llm.Service
 	producer         *DatasetProducer
 }
 
-func NewDatasetService(repo *repository.DatasetRepo, retrieverSvc *retriever.Service, segmentService *segment.Service, producer *DatasetProducer) *DatasetService {
+func NewDatasetService(repo *repository.DatasetRepo, retrieverSvc *retriever.Service, segmentService *segment.Service,
+	llmService *llm.Service, producer *DatasetProducer) *DatasetService {
 	return &DatasetService{
 		repo:             repo,
 		retrieverService: retrieverSvc,
 		segmentService:   segmentService,
+		llmService:       llmService,
 		producer:         producer,
 	}
 }
@@ -41,11 +49,45 @@ func (s *DatasetService) CreateDataset(ctx context.Context, createReq req.Create
 		return err
 	}
 
+	// 校验文档元数据字段定义
+	if err := retrievers.ValidateMetadataFields(createReq.MetadataFields); err != nil {
+		return errno.ErrValidate.AppendBizMessage(fmt.Errorf("元数据字段定义错误: %v", err))
+	}
+	if createReq.MetadataFields == nil {
+		createReq.MetadataFields = make([]entity.MetadataField, 0)
+	}
+
+	if createReq.Type == "" {
+		createReq.Type = consts.DatasetTypeText
+	}
+
 	dataset := &entity.Dataset{
-		AccountID:   userID,
-		Name:        createReq.Name,
-		Description: createReq.Description,
-		Icon:        createReq.Icon,
+		AccountID:      userID,
+		Name:           createReq.Name,
+		Description:    createReq.Description,
+		Icon:           createReq.Icon,
+		Type:           createReq.Type,
+		MetadataFields: createReq.MetadataFields,
+	}
+
+	// 表格知识库通过SQL查询，不需要向量模型
+	if dataset.Type == consts.DatasetTypeTable {
+		return s.repo.CreateDataset(ctx, dataset)
+	}
+
+	// 未选择向量模型时使用配置的默认向量模型，均未配置时使用向量数据库默认的向量模型
+	provider, model := createReq.EmbeddingProvider, createReq.EmbeddingModel
+	if provider == "" {
+		provider, model = conf.GetConf().LLM.DefaultEmbeddingProvider, conf.GetConf().LLM.DefaultEmbeddingModel
+	}
+	if provider != "" {
+		modelEntity, err := s.llmService.ValidateEmbeddingModel(ctx, userID, provider, model)
+		if err != nil {
+			return err
+		}
+		dataset.EmbeddingProvider = provider
+		dataset.EmbeddingModel = model
+		dataset.EmbeddingDimensions = modelEntity.Dimensions
 	}
 
 	return s.repo.CreateDataset(ctx, dataset)
@@ -69,11 +111,16 @@ func (s *DatasetService) GetDatasetsWithPage(ctx context.Context, pageReq req.Ge
 		if err != nil {
 			// 如果计算字段失败，使用默认值
 			datasetResp = &resp.DatasetResp{
-				ID:          dataset.ID,
-				Name:        dataset.Name,
-				Description: dataset.Description,
-				Ctime:       dataset.Ctime,
-				Utime:       dataset.Utime,
+				ID:                  dataset.ID,
+				Name:                dataset.Name,
+				Description:         dataset.Description,
+				EmbeddingProvider:   dataset.EmbeddingProvider,
+				EmbeddingModel:      dataset.EmbeddingModel,
+				EmbeddingDimensions: dataset.EmbeddingDimensions,
+				MetadataFields:      dataset.MetadataFields,
+				Ctime:               dataset.Ctime,
+				Utime:               dataset.Utime,
+				Type:                dataset.Type,
 			}
 		}
 		datasetResps[i] = *datasetResp
@@ -136,10 +183,63 @@ func (s *DatasetService) UpdateDataset(ctx context.Context, datasetID uuid.UUID,
 	if updateReq.Permission != "" {
 		updates["permission"] = updateReq.Permission
 	}
+	if updateReq.MetadataFields != nil {
+		fields := *updateReq.MetadataFields
+		if err := retrievers.ValidateMetadataFields(fields); err != nil {
+			return errno.ErrValidate.AppendBizMessage(fmt.Errorf("元数据字段定义错误: %v", err))
+		}
+		if err := s.checkMetadataFieldsInUse(ctx, dataset, fields); err != nil {
+			return err
+		}
+		if fields == nil {
+			fields = make([]entity.MetadataField, 0)
+		}
+		fieldsJSON, _ := sonic.Marshal(fields)
+		updates["metadata_fields"] = string(fieldsJSON)
+	}
 
 	return s.repo.UpdateDataset(ctx, datasetID, updates)
 }
 
+// checkMetadataFieldsInUse 检查元数据字段定义的修改是否会使文档已有的元数据失效，
+// 删除字段、修改字段类型以及删除select选项时，要求没有文档在使用对应的字段或选项
+func (s *DatasetService) checkMetadataFieldsInUse(ctx context.Context, dataset *entity.Dataset, fields []entity.MetadataField) error {
+	newFields := make(map[string]entity.MetadataField, len(fields))
+	for _, field := range fields {
+		newFields[field.Name] = field
+	}
+
+	for _, oldField := range dataset.MetadataFields {
+		// 1.计算失效的取值，values为空表示该字段的所有取值均失效
+		var values []string
+		newField, ok := newFields[oldField.Name]
+		if ok && newField.Type == oldField.Type {
+			if oldField.Type != consts.MetadataFieldTypeSelect {
+				continue
+			}
+			for _, option := range oldField.Options {
+				if !slices.Contains(newField.Options, option) {
+					values = append(values, option)
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+		}
+
+		// 2.统计使用失效取值的文档数
+		count, err := s.repo.CountDocumentsWithMetadata(ctx, dataset.ID, oldField.Name, values)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errno.ErrValidate.AppendBizMessage(fmt.Errorf("元数据字段%s正在被%d个文档使用，无法删除或修改", oldField.Name, count))
+		}
+	}
+
+	return nil
+}
+
 func (s *DatasetService) DeleteDataset(ctx context.Context, datasetID uuid.UUID) error {
 	userID, err := util.GetCurrentUserID(ctx)
 	if err != nil {
@@ -179,6 +279,9 @@ func (s *DatasetService) Hit(ctx context.Context, datasetID uuid.UUID, hitReq re
 	if dataset.AccountID != userID {
 		return nil, errno.ErrForbidden.AppendBizMessage(errors.New("无权限访问该知识库"))
 	}
+	if dataset.Type == consts.DatasetTypeTable {
+		return nil, errno.ErrValidate.AppendBizMessage(errors.New("表格知识库通过应用中的表格查询工具使用，不支持召回测试"))
+	}
 
 	// 调用检索服务进行检索
 	searchReq := req.SearchRequest{
@@ -192,6 +295,27 @@ func (s *DatasetService) Hit(ctx context.Context, datasetID uuid.UUID, hitReq re
 	} else {
 		searchReq.RetrieverType = string(consts.RetrievalStrategySemantic)
 	}
+	searchReq.Options = make(map[string]any)
+	if hitReq.RerankingEnabled {
+		rerank := map[string]any{
+			"enable":   true,
+			"provider": hitReq.RerankingProvider,
+			"model":    hitReq.RerankingModel,
+		}
+		if hitReq.RerankingTopN > 0 {
+			rerank["top_n"] = hitReq.RerankingTopN
+		}
+		searchReq.Options["rerank"] = rerank
+	}
+	if hitReq.RetrievalStrategy == string(consts.RetrievalStrategyHybrid) {
+		searchReq.Options["fusion"] = s.buildFusionOption(hitReq)
+	}
+	if hitReq.MetadataFilter != nil {
+		if _, err := retrievers.ParseMetadataFilter(hitReq.MetadataFilter); err != nil {
+			return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("元数据过滤条件格式错误: %v", err))
+		}
+		searchReq.Options["metadata_filter"] = hitReq.MetadataFilter
+	}
 
 	searchResults, err := s.retrieverService.SearchInDatasets(ctx, userID, searchReq)
 	if err != nil {
@@ -209,20 +333,26 @@ func (s *DatasetService) Hit(ctx context.Context, datasetID uuid.UUID, hitReq re
 	}
 
 	sortSearchs := s.filterSearchResult(searchResults, segments)
+	segmentMap := make(map[uuid.UUID]entity.Segment, len(segments))
+	for _, seg := range segments {
+		segmentMap[seg.ID] = seg
+	}
 
-	// 转换为响应格式
-	hitRes := make([]resp.HitResult, len(segments))
-	for i, res := range segments {
+	// 转换为响应格式，保持检索(重排序)结果的顺序
+	hitRes := make([]resp.HitResult, 0, len(sortSearchs))
+	for _, search := range sortSearchs {
+		res := segmentMap[search.SegmentID]
 		hit := resp.HitResult{
 			Document: resp.DocumentResp{
 				ID:   res.DocumentID,
-				Name: sortSearchs[i].DocumentName,
+				Name: search.DocumentName,
 			},
 			SegmentID:      res.ID,
 			DocumentID:     res.DocumentID,
 			DatasetID:      res.DatasetID,
 			Content:        res.Content,
-			Score:          searchResults[i].Score,
+			Score:          search.Score,
+			RerankScore:    search.RerankScore,
 			Position:       res.Position,
 			Keywords:       res.Keywords,
 			CharacterCount: res.CharacterCount,
@@ -234,12 +364,43 @@ func (s *DatasetService) Hit(ctx context.Context, datasetID uuid.UUID, hitReq re
 			Ctime:          res.Ctime,
 			Utime:          res.Utime,
 		}
+		if search.ParentSegmentID != nil {
+			hit.ParentSegmentID = search.ParentSegmentID
+			hit.ParentContent = search.Content
+		}
+		if res.Question != "" {
+			hit.Question = res.Question
+			hit.QuestionVariants = res.QuestionVariants
+			hit.MatchedQuestion = search.MatchedQuestion
+		}
 		hitRes = append(hitRes, hit)
 	}
 
 	return hitRes, nil
 }
 
+// buildFusionOption 根据检索测试请求构建混合检索的融合配置，未传递的字段使用默认值
+func (s *DatasetService) buildFusionOption(hitReq req.HitReq) map[string]any {
+	fusion := make(map[string]any)
+	if hitReq.FusionMethod != "" {
+		fusion["method"] = hitReq.FusionMethod
+	}
+	if hitReq.SemanticWeight != nil {
+		fusion["semantic_weight"] = *hitReq.SemanticWeight
+	}
+	if hitReq.RRFK > 0 {
+		fusion["rrf_k"] = hitReq.RRFK
+	}
+	if hitReq.SemanticTopK > 0 {
+		fusion["semantic_top_k"] = hitReq.SemanticTopK
+	}
+	if hitReq.FullTextTopK > 0 {
+		fusion["full_text_top_k"] = hitReq.FullTextTopK
+	}
+
+	return fusion
+}
+
 func (s *DatasetService) GetDatasetQueries(ctx context.Context, datasetID uuid.UUID) ([]resp.DatasetQueryResp, error) {
 	userID, err := util.GetCurrentUserID(ctx)
 	if err != nil {
@@ -354,15 +515,44 @@ func (s *DatasetService) buildDatasetResponse(ctx context.Context, dataset *enti
 	}
 	wg.Wait()
 
+	// 表格知识库返回数据表结构
+	var tableResp *resp.DatasetTableResp
+	if dataset.Type == consts.DatasetTypeTable {
+		datasetTable, err := s.repo.GetDatasetTable(ctx, dataset.ID)
+		if err != nil {
+			return nil, err
+		}
+		if datasetTable != nil {
+			tableResp = &resp.DatasetTableResp{
+				Name:     datasetTable.Name,
+				RowCount: datasetTable.RowCount,
+				Columns:  make([]resp.TableColumnResp, 0, len(datasetTable.Columns)),
+			}
+			for _, column := range datasetTable.Columns {
+				tableResp.Columns = append(tableResp.Columns, resp.TableColumnResp{
+					Name:        column.Name,
+					Type:        column.Type.String(),
+					Description: column.Description,
+				})
+			}
+		}
+	}
+
 	return &resp.DatasetResp{
-		ID:              dataset.ID,
-		Name:            dataset.Name,
-		Description:     dataset.Description,
-		DocumentCount:   res.documentCount,
-		HitCount:        res.hitCount,
-		RelatedAppCount: res.relatedAppCount,
-		CharacterCount:  res.characterCount,
-		Ctime:           dataset.Ctime,
-		Utime:           dataset.Utime,
+		ID:                  dataset.ID,
+		Name:                dataset.Name,
+		Description:         dataset.Description,
+		DocumentCount:       res.documentCount,
+		HitCount:            res.hitCount,
+		RelatedAppCount:     res.relatedAppCount,
+		CharacterCount:      res.characterCount,
+		EmbeddingProvider:   dataset.EmbeddingProvider,
+		EmbeddingModel:      dataset.EmbeddingModel,
+		EmbeddingDimensions: dataset.EmbeddingDimensions,
+		MetadataFields:      dataset.MetadataFields,
+		Ctime:               dataset.Ctime,
+		Utime:               dataset.Utime,
+		Type:                dataset.Type,
+		Table:               tableResp,
 	}, nil
 }
@@ -5,6 +5,7 @@ package dataset
 
 import (
 	"github.com/crazyfrankie/voidx/conf"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/segment"
 	"github.com/google/wire"
@@ -40,7 +41,7 @@ func InitProducer() *service.DatasetProducer {
 }
 
 func InitDatasetHandler(db *gorm.DB,
-	retrieverModule *retriever.RetrieverModule, segmentModule *segment.SegmentModule) *DataSetModule {
+	retrieverModule *retriever.RetrieverModule, segmentModule *segment.SegmentModule, llmModule *llm.LLMModule) *DataSetModule {
 	wire.Build(
 		InitProducer,
 		DatasetSet,
@@ -48,6 +49,7 @@ func InitDatasetHandler(db *gorm.DB,
 		wire.Struct(new(DataSetModule), "*"),
 		wire.FieldsOf(new(*retriever.RetrieverModule), "Service"),
 		wire.FieldsOf(new(*segment.SegmentModule), "Service"),
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 	)
 	return new(DataSetModule)
 }
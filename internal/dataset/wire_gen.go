@@ -12,6 +12,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/dataset/repository"
 	"github.com/crazyfrankie/voidx/internal/dataset/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/dataset/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/segment"
 	"github.com/google/wire"
@@ -20,13 +21,14 @@ import (
 
 // Injectors from wire.go:
 
-func InitDatasetHandler(db *gorm.DB, retrieverModule *retriever.RetrieverModule, segmentModule *segment.SegmentModule) *DataSetModule {
+func InitDatasetHandler(db *gorm.DB, retrieverModule *retriever.RetrieverModule, segmentModule *segment.SegmentModule, llmModule *llm.LLMModule) *DataSetModule {
 	datasetDao := dao.NewDatasetDao(db)
 	datasetRepo := repository.NewDatasetRepo(datasetDao)
 	retrievalService := retrieverModule.Service
 	segmentService := segmentModule.Service
+	llmService := llmModule.Service
 	datasetProducer := InitProducer()
-	datasetService := service.NewDatasetService(datasetRepo, retrievalService, segmentService, datasetProducer)
+	datasetService := service.NewDatasetService(datasetRepo, retrievalService, segmentService, llmService, datasetProducer)
 	datasetHandler := handler.NewDatasetHandler(datasetService)
 	dataSetModule := &DataSetModule{
 		Handler: datasetHandler,
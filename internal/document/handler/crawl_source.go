@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/base/response"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+func (h *DocumentHandler) CreateCrawlSource() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetIDStr := c.Param("dataset_id")
+		datasetID, err := uuid.Parse(datasetIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		var createReq req.CreateCrawlSourceReq
+		if err := c.ShouldBind(&createReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		source, err := h.svc.CreateCrawlSource(c.Request.Context(), datasetID, createReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, source)
+	}
+}
+
+func (h *DocumentHandler) GetCrawlSources() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetIDStr := c.Param("dataset_id")
+		datasetID, err := uuid.Parse(datasetIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		sources, err := h.svc.GetCrawlSources(c.Request.Context(), datasetID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, sources)
+	}
+}
+
+func (h *DocumentHandler) RecrawlSource() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetIDStr := c.Param("dataset_id")
+		datasetID, err := uuid.Parse(datasetIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		sourceIDStr := c.Param("source_id")
+		sourceID, err := uuid.Parse(sourceIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		err = h.svc.RecrawlSource(c.Request.Context(), datasetID, sourceID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
+func (h *DocumentHandler) DeleteCrawlSource() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetIDStr := c.Param("dataset_id")
+		datasetID, err := uuid.Parse(datasetIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		sourceIDStr := c.Param("source_id")
+		sourceID, err := uuid.Parse(sourceIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		err = h.svc.DeleteCrawlSource(c.Request.Context(), datasetID, sourceID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
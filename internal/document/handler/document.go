@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"io"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
@@ -25,9 +27,19 @@ func (h *DocumentHandler) RegisterRoute(r *gin.RouterGroup) {
 		documentGroup.GET("", h.GetDocumentsWithPage())
 		documentGroup.GET("/:document_id", h.GetDocument())
 		documentGroup.PUT("/:document_id/name", h.UpdateDocument())
+		documentGroup.PUT("/:document_id/content", h.UpdateDocumentContent())
 		documentGroup.DELETE("/:document_id", h.DeleteDocument())
 		documentGroup.PUT("/:document_id/enabled", h.UpdateDocumentEnabled())
 		documentGroup.GET("/batch/:batch", h.GetDocumentStatus())
+		documentGroup.GET("/batch/:batch/stream", h.StreamDocumentStatus())
+	}
+
+	crawlSourceGroup := r.Group("datasets/:dataset_id/crawl-sources")
+	{
+		crawlSourceGroup.POST("", h.CreateCrawlSource())
+		crawlSourceGroup.GET("", h.GetCrawlSources())
+		crawlSourceGroup.POST("/:source_id/crawl", h.RecrawlSource())
+		crawlSourceGroup.DELETE("/:source_id", h.DeleteCrawlSource())
 	}
 }
 
@@ -145,6 +157,40 @@ func (h *DocumentHandler) UpdateDocument() gin.HandlerFunc {
 	}
 }
 
+func (h *DocumentHandler) UpdateDocumentContent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetIDStr := c.Param("dataset_id")
+		datasetID, err := uuid.Parse(datasetIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		documentIDStr := c.Param("document_id")
+		documentID, err := uuid.Parse(documentIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		var updateReq req.UpdateDocumentContentReq
+		if err := c.ShouldBind(&updateReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		batch, err := h.svc.UpdateDocumentContent(c.Request.Context(), datasetID, documentID, updateReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, gin.H{
+			"batch": batch,
+		})
+	}
+}
+
 func (h *DocumentHandler) DeleteDocument() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		datasetIDStr := c.Param("dataset_id")
@@ -225,3 +271,37 @@ func (h *DocumentHandler) GetDocumentStatus() gin.HandlerFunc {
 		response.Data(c, res)
 	}
 }
+
+// StreamDocumentStatus 以SSE的方式持续推送处理批次下文档的构建进度
+func (h *DocumentHandler) StreamDocumentStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		datasetIDStr := c.Param("dataset_id")
+		datasetID, err := uuid.Parse(datasetIDStr)
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		batch := c.Param("batch")
+
+		res, err := h.svc.StreamDocumentsStatus(c.Request.Context(), datasetID, batch)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		// 流式输出
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case status, ok := <-res:
+				if !ok {
+					return false
+				}
+				c.SSEvent("message", status)
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/types/consts"
 )
 
 type DocumentDao struct {
@@ -71,6 +72,19 @@ func (d *DocumentDao) UpdateDocument(ctx context.Context, id uuid.UUID, updates
 		Updates(updates).Error
 }
 
+// UpdateDocumentMetadata 更新文档信息，并将更新后的元数据同步到文档的所有片段
+func (d *DocumentDao) UpdateDocumentMetadata(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Document{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.Segment{}).
+			Where("document_id = ?", id).
+			Update("metadata", updates["metadata"]).Error
+	})
+}
+
 func (d *DocumentDao) DeleteDocument(ctx context.Context, id uuid.UUID) error {
 	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 删除相关的片段
@@ -174,6 +188,22 @@ func (d *DocumentDao) GetHitCountByDocument(ctx context.Context, documentID uuid
 	return int(count), nil
 }
 
+// GetIndexSegmentCountByDocument 获取文档需要向量化的片段数与已完成向量化的片段数，父子分段中的父片段没有NodeID，不计入其中
+func (d *DocumentDao) GetIndexSegmentCountByDocument(ctx context.Context, documentID uuid.UUID) (int, int, error) {
+	var result struct {
+		Total     int64
+		Completed int64
+	}
+	err := d.db.WithContext(ctx).Model(&entity.Segment{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE status = ?) AS completed", consts.SegmentStatusCompleted).
+		Where("document_id = ? AND node_id <> ?", documentID, uuid.Nil).
+		Scan(&result).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(result.Total), int(result.Completed), nil
+}
+
 // GetCompletedSegmentCountByDocument 获取文档的已完成片段数
 func (d *DocumentDao) GetCompletedSegmentCountByDocument(ctx context.Context, documentID uuid.UUID) (int, error) {
 	var count int64
@@ -195,3 +225,56 @@ func (d *DocumentDao) GetUploadFileByID(ctx context.Context, fileID uuid.UUID) (
 	}
 	return &file, nil
 }
+
+// CreateCrawlSource 创建网页爬取来源
+func (d *DocumentDao) CreateCrawlSource(ctx context.Context, source *entity.CrawlSource) error {
+	return d.db.WithContext(ctx).Create(source).Error
+}
+
+// GetCrawlSourceByID 根据ID获取网页爬取来源
+func (d *DocumentDao) GetCrawlSourceByID(ctx context.Context, id uuid.UUID) (*entity.CrawlSource, error) {
+	var source entity.CrawlSource
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&source).Error
+	if err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// GetCrawlSourcesByDatasetID 获取知识库下的网页爬取来源列表
+func (d *DocumentDao) GetCrawlSourcesByDatasetID(ctx context.Context, datasetID uuid.UUID) ([]entity.CrawlSource, error) {
+	var sources []entity.CrawlSource
+	err := d.db.WithContext(ctx).
+		Where("dataset_id = ?", datasetID).
+		Order("ctime DESC").
+		Find(&sources).Error
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// GetDocumentCountByCrawlSource 获取网页爬取来源创建的文档数
+func (d *DocumentDao) GetDocumentCountByCrawlSource(ctx context.Context, sourceID uuid.UUID) (int, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&entity.Document{}).
+		Where("crawl_source_id = ?", sourceID).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// DeleteCrawlSource 删除网页爬取来源，已爬取的文档保留为普通文档
+func (d *DocumentDao) DeleteCrawlSource(ctx context.Context, id uuid.UUID) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Document{}).
+			Where("crawl_source_id = ?", id).
+			Update("crawl_source_id", uuid.Nil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&entity.CrawlSource{}).Error
+	})
+}
@@ -38,6 +38,10 @@ func (r *DocumentRepo) UpdateDocument(ctx context.Context, id uuid.UUID, updates
 	return r.dao.UpdateDocument(ctx, id, updates)
 }
 
+func (r *DocumentRepo) UpdateDocumentMetadata(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return r.dao.UpdateDocumentMetadata(ctx, id, updates)
+}
+
 func (r *DocumentRepo) DeleteDocument(ctx context.Context, id uuid.UUID) error {
 	return r.dao.DeleteDocument(ctx, id)
 }
@@ -79,6 +83,11 @@ func (r *DocumentRepo) GetHitCountByDocument(ctx context.Context, documentID uui
 	return r.dao.GetHitCountByDocument(ctx, documentID)
 }
 
+// GetIndexSegmentCountByDocument 获取文档需要向量化的片段数与已完成向量化的片段数
+func (r *DocumentRepo) GetIndexSegmentCountByDocument(ctx context.Context, documentID uuid.UUID) (int, int, error) {
+	return r.dao.GetIndexSegmentCountByDocument(ctx, documentID)
+}
+
 // GetCompletedSegmentCountByDocument 获取文档的已完成片段数
 func (r *DocumentRepo) GetCompletedSegmentCountByDocument(ctx context.Context, documentID uuid.UUID) (int, error) {
 	return r.dao.GetCompletedSegmentCountByDocument(ctx, documentID)
@@ -88,3 +97,28 @@ func (r *DocumentRepo) GetCompletedSegmentCountByDocument(ctx context.Context, d
 func (r *DocumentRepo) GetUploadFileByID(ctx context.Context, fileID uuid.UUID) (*entity.UploadFile, error) {
 	return r.dao.GetUploadFileByID(ctx, fileID)
 }
+
+// CreateCrawlSource 创建网页爬取来源
+func (r *DocumentRepo) CreateCrawlSource(ctx context.Context, source *entity.CrawlSource) error {
+	return r.dao.CreateCrawlSource(ctx, source)
+}
+
+// GetCrawlSourceByID 根据ID获取网页爬取来源
+func (r *DocumentRepo) GetCrawlSourceByID(ctx context.Context, id uuid.UUID) (*entity.CrawlSource, error) {
+	return r.dao.GetCrawlSourceByID(ctx, id)
+}
+
+// GetCrawlSourcesByDatasetID 获取知识库下的网页爬取来源列表
+func (r *DocumentRepo) GetCrawlSourcesByDatasetID(ctx context.Context, datasetID uuid.UUID) ([]entity.CrawlSource, error) {
+	return r.dao.GetCrawlSourcesByDatasetID(ctx, datasetID)
+}
+
+// GetDocumentCountByCrawlSource 获取网页爬取来源创建的文档数
+func (r *DocumentRepo) GetDocumentCountByCrawlSource(ctx context.Context, sourceID uuid.UUID) (int, error) {
+	return r.dao.GetDocumentCountByCrawlSource(ctx, sourceID)
+}
+
+// DeleteCrawlSource 删除网页爬取来源
+func (r *DocumentRepo) DeleteCrawlSource(ctx context.Context, id uuid.UUID) error {
+	return r.dao.DeleteCrawlSource(ctx, id)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/crawler"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/internal/models/resp"
+	processruleservice "github.com/crazyfrankie/voidx/internal/process_rule/service"
+	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/consts"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+// CreateCrawlSource 创建网页爬取来源并调用异步任务爬取页面，每个页面会创建一个文档
+func (s *DocumentService) CreateCrawlSource(ctx context.Context, datasetID uuid.UUID, createReq req.CreateCrawlSourceReq) (*resp.CrawlSourceResp, error) {
+	userID, err := util.GetCurrentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 检测知识库权限，网页只能导入到文本知识库
+	dataset, err := s.repo.GetDatasetByID(ctx, datasetID)
+	if err != nil {
+		return nil, errno.ErrNotFound.AppendBizMessage(errors.New("知识库不存在"))
+	}
+	if dataset.AccountID != userID {
+		return nil, errno.ErrForbidden.AppendBizMessage(errors.New("当前用户无该知识库权限或知识库不存在"))
+	}
+	if dataset.Type == consts.DatasetTypeTable || dataset.Type == consts.DatasetTypeQA {
+		return nil, errno.ErrValidate.AppendBizMessage(errors.New("表格与问答对知识库不支持导入网页"))
+	}
+	if len(createReq.SeedURLs) == 0 && createReq.SitemapURL == "" {
+		return nil, errno.ErrValidate.AppendBizMessage(errors.New("种子地址与站点地图至少需要传递一个"))
+	}
+	if err := processruleservice.ValidateProcessRule(createReq.ProcessType, createReq.Rule); err != nil {
+		return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("处理规则错误: %v", err))
+	}
+
+	// 2. 创建处理规则，爬取得到的所有页面使用同一个处理规则
+	processRule := &entity.ProcessRule{
+		AccountID: userID,
+		DatasetID: datasetID,
+		Mode:      createReq.ProcessType,
+		Rule:      createReq.Rule,
+	}
+	if err := s.repo.CreateProcessRule(ctx, processRule); err != nil {
+		return nil, err
+	}
+
+	// 3. 创建爬取来源并记录到数据库中
+	maxDepth := crawler.DefaultMaxDepth
+	if createReq.MaxDepth != nil {
+		maxDepth = *createReq.MaxDepth
+	}
+	maxPages := createReq.MaxPages
+	if maxPages == 0 {
+		maxPages = crawler.DefaultMaxPages
+	}
+	source := &entity.CrawlSource{
+		AccountID:       userID,
+		DatasetID:       datasetID,
+		ProcessRuleID:   processRule.ID,
+		SeedURLs:        append([]string{}, createReq.SeedURLs...),
+		SitemapURL:      createReq.SitemapURL,
+		MaxDepth:        maxDepth,
+		MaxPages:        maxPages,
+		RecrawlInterval: createReq.RecrawlInterval,
+		Status:          string(consts.CrawlStatusWaiting),
+	}
+	if source.RecrawlInterval > 0 {
+		// 首次爬取由异步任务完成，定期爬取从一个间隔之后开始，避免与异步任务重复爬取
+		source.NextCrawlAt = time.Now().Add(time.Duration(source.RecrawlInterval) * time.Second).UnixMilli()
+	}
+	if err := s.repo.CreateCrawlSource(ctx, source); err != nil {
+		return nil, err
+	}
+
+	// 4. 调用异步任务爬取页面
+	if err := s.taskProducer.PublishCrawlTask(ctx, source.ID); err != nil {
+		return nil, fmt.Errorf("failed to publish crawl task: %w", err)
+	}
+
+	return s.buildCrawlSourceResp(ctx, source), nil
+}
+
+// GetCrawlSources 获取知识库下的网页爬取来源列表
+func (s *DocumentService) GetCrawlSources(ctx context.Context, datasetID uuid.UUID) ([]*resp.CrawlSourceResp, error) {
+	userID, err := util.GetCurrentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset, err := s.repo.GetDatasetByID(ctx, datasetID)
+	if err != nil {
+		return nil, errno.ErrNotFound.AppendBizMessage(errors.New("知识库不存在"))
+	}
+	if dataset.AccountID != userID {
+		return nil, errno.ErrForbidden.AppendBizMessage(errors.New("当前用户无该知识库权限或知识库不存在"))
+	}
+
+	sources, err := s.repo.GetCrawlSourcesByDatasetID(ctx, datasetID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*resp.CrawlSourceResp, 0, len(sources))
+	for i := range sources {
+		res = append(res, s.buildCrawlSourceResp(ctx, &sources[i]))
+	}
+
+	return res, nil
+}
+
+// RecrawlSource 立即重新爬取网页爬取来源，只有内容发生变化的页面会重新建立索引
+func (s *DocumentService) RecrawlSource(ctx context.Context, datasetID, sourceID uuid.UUID) error {
+	source, err := s.getCrawlSource(ctx, datasetID, sourceID)
+	if err != nil {
+		return err
+	}
+	if source.Status == string(consts.CrawlStatusCrawling) {
+		return errno.ErrValidate.AppendBizMessage(errors.New("当前来源正在爬取中，请稍后尝试"))
+	}
+
+	if err := s.taskProducer.PublishCrawlTask(ctx, source.ID); err != nil {
+		return fmt.Errorf("failed to publish crawl task: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCrawlSource 删除网页爬取来源并停止定期重新爬取，已爬取的文档保留在知识库中
+func (s *DocumentService) DeleteCrawlSource(ctx context.Context, datasetID, sourceID uuid.UUID) error {
+	source, err := s.getCrawlSource(ctx, datasetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.DeleteCrawlSource(ctx, source.ID)
+}
+
+// getCrawlSource 获取网页爬取来源并校验权限
+func (s *DocumentService) getCrawlSource(ctx context.Context, datasetID, sourceID uuid.UUID) (*entity.CrawlSource, error) {
+	userID, err := util.GetCurrentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.repo.GetCrawlSourceByID(ctx, sourceID)
+	if err != nil {
+		return nil, errno.ErrNotFound.AppendBizMessage(errors.New("爬取来源不存在"))
+	}
+	if source.AccountID != userID || source.DatasetID != datasetID {
+		return nil, errno.ErrForbidden.AppendBizMessage(errors.New("当前用户无该爬取来源权限或爬取来源不存在"))
+	}
+
+	return source, nil
+}
+
+func (s *DocumentService) buildCrawlSourceResp(ctx context.Context, source *entity.CrawlSource) *resp.CrawlSourceResp {
+	documentCount, err := s.repo.GetDocumentCountByCrawlSource(ctx, source.ID)
+	if err != nil {
+		documentCount = 0
+	}
+
+	return &resp.CrawlSourceResp{
+		ID:              source.ID,
+		DatasetID:       source.DatasetID,
+		SeedURLs:        source.SeedURLs,
+		SitemapURL:      source.SitemapURL,
+		MaxDepth:        source.MaxDepth,
+		MaxPages:        source.MaxPages,
+		RecrawlInterval: source.RecrawlInterval,
+		Batch:           source.Batch,
+		DocumentCount:   documentCount,
+		Status:          source.Status,
+		Error:           source.Error,
+		LastCrawledAt:   source.LastCrawledAt,
+		NextCrawlAt:     source.NextCrawlAt,
+		Ctime:           source.Ctime,
+	}
+}
@@ -9,25 +9,36 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
+	progressbarimpl "github.com/crazyfrankie/voidx/infra/impl/document/progressbar"
+	"github.com/crazyfrankie/voidx/internal/core/retrievers"
 	"github.com/crazyfrankie/voidx/internal/document/repository"
 	"github.com/crazyfrankie/voidx/internal/document/task"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
+	processruleservice "github.com/crazyfrankie/voidx/internal/process_rule/service"
 	"github.com/crazyfrankie/voidx/pkg/logs"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/crazyfrankie/voidx/types/errno"
 )
 
+// documentStatusStreamInterval 流式推送文档构建状态的间隔
+const documentStatusStreamInterval = time.Second
+
 type DocumentService struct {
 	repo         *repository.DocumentRepo
 	taskProducer *task.DocumentProducer
+	cacheClient  cache.Cmdable
 }
 
-func NewDocumentService(repo *repository.DocumentRepo, taskProducer *task.DocumentProducer) *DocumentService {
+func NewDocumentService(repo *repository.DocumentRepo, taskProducer *task.DocumentProducer, cacheClient cache.Cmdable) *DocumentService {
 	return &DocumentService{
 		repo:         repo,
 		taskProducer: taskProducer,
+		cacheClient:  cacheClient,
 	}
 }
 
@@ -56,11 +67,7 @@ func (s *DocumentService) CreateDocuments(ctx context.Context, datasetID uuid.UU
 
 	// 过滤合法的文档文件
 	validUploadFiles := make([]entity.UploadFile, 0)
-	allowedExtensions := map[string]bool{
-		".txt": true, ".md": true, ".pdf": true, ".doc": true, ".docx": true,
-		".html": true, ".htm": true, ".rtf": true, ".csv": true, ".json": true,
-	}
-
+	allowedExtensions := allowedDocumentExtensions(dataset.Type)
 	for _, file := range uploadFiles {
 		if allowedExtensions[file.Extension] {
 			validUploadFiles = append(validUploadFiles, file)
@@ -71,7 +78,10 @@ func (s *DocumentService) CreateDocuments(ctx context.Context, datasetID uuid.UU
 		return nil, "", errno.ErrValidate.AppendBizMessage(errors.New("暂未解析到合法文件，请重新上传"))
 	}
 
-	// 3. 创建批次与处理规则并记录到数据库中
+	// 3. 校验处理规则，创建批次与处理规则并记录到数据库中
+	if err := processruleservice.ValidateProcessRule(createReq.ProcessType, createReq.Rule); err != nil {
+		return nil, "", errno.ErrValidate.AppendBizMessage(fmt.Errorf("处理规则错误: %v", err))
+	}
 	batch := fmt.Sprintf("%d%06d", time.Now().Unix(), time.Now().Nanosecond()%1000000)
 
 	// 创建处理规则
@@ -163,15 +173,10 @@ func (s *DocumentService) GetDocumentsStatus(ctx context.Context, datasetID uuid
 	// 3. 循环遍历文档列表提取文档的状态信息
 	documentsStatus := make([]resp.DocumentStatusResp, 0, len(documents))
 	for _, document := range documents {
-		// 4. 查询每个文档的总片段数和已构建完成的片段数
-		segmentCount, err := s.repo.GetSegmentCountByDocument(ctx, document.ID)
+		// 4. 查询每个文档需要向量化的片段数和已写入向量数据库的片段数，父子分段中的父片段不建立索引，不计入其中
+		segmentCount, completedSegmentCount, err := s.repo.GetIndexSegmentCountByDocument(ctx, document.ID)
 		if err != nil {
-			segmentCount = 0
-		}
-
-		completedSegmentCount, err := s.repo.GetCompletedSegmentCountByDocument(ctx, document.ID)
-		if err != nil {
-			completedSegmentCount = 0
+			segmentCount, completedSegmentCount = 0, 0
 		}
 
 		// 获取上传文件信息
@@ -180,6 +185,9 @@ func (s *DocumentService) GetDocumentsStatus(ctx context.Context, datasetID uuid
 			uploadFile, _ = s.repo.GetUploadFileByID(ctx, document.UploadFileID)
 		}
 
+		// 获取文档的构建进度、预计剩余时间与最近的错误信息
+		progress, remainingTime, errMsg := s.getDocumentProgress(ctx, &document)
+
 		status := resp.DocumentStatusResp{
 			ID:                    document.ID,
 			Name:                  document.Name,
@@ -187,6 +195,9 @@ func (s *DocumentService) GetDocumentsStatus(ctx context.Context, datasetID uuid
 			SegmentCount:          segmentCount,
 			CompletedSegmentCount: completedSegmentCount,
 			Status:                document.Status,
+			Progress:              progress,
+			RemainingTime:         remainingTime,
+			Error:                 errMsg,
 			ProcessingStartedAt:   document.ProcessingStartedAt,
 			ParsingCompletedAt:    document.ParsingCompletedAt,
 			SplittingCompletedAt:  document.SplittingCompletedAt,
@@ -208,6 +219,47 @@ func (s *DocumentService) GetDocumentsStatus(ctx context.Context, datasetID uuid
 	return documentsStatus, nil
 }
 
+// StreamDocumentsStatus 定时推送处理批次下文档列表的状态，所有文档构建完成或失败后关闭通道
+func (s *DocumentService) StreamDocumentsStatus(ctx context.Context, datasetID uuid.UUID, batch string) (<-chan []resp.DocumentStatusResp, error) {
+	// 1. 获取首次的文档状态，同时完成权限校验
+	documentsStatus, err := s.GetDocumentsStatus(ctx, datasetID, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 创建协程定时查询文档状态并推送
+	statusChan := make(chan []resp.DocumentStatusResp)
+	go func() {
+		defer close(statusChan)
+
+		ticker := time.NewTicker(documentStatusStreamInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case statusChan <- documentsStatus:
+			case <-ctx.Done():
+				return
+			}
+			if isDocumentsFinished(documentsStatus) {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			documentsStatus, err = s.GetDocumentsStatus(ctx, datasetID, batch)
+			if err != nil {
+				logs.Errorf("Failed to get documents status of batch %s: %v", batch, err)
+				return
+			}
+		}
+	}()
+
+	return statusChan, nil
+}
+
 func (s *DocumentService) GetDocumentsWithPage(ctx context.Context, datasetID uuid.UUID, pageReq req.GetDocumentsWithPageReq) ([]resp.DocumentResp, resp.Paginator, error) {
 	userID, err := util.GetCurrentUserID(ctx)
 	if err != nil {
@@ -312,8 +364,110 @@ func (s *DocumentService) UpdateDocument(ctx context.Context, datasetID, documen
 	if updateReq.Name != "" {
 		updates["name"] = updateReq.Name
 	}
+	if updateReq.Metadata == nil {
+		return s.repo.UpdateDocument(ctx, documentID, updates)
+	}
 
-	return s.repo.UpdateDocument(ctx, documentID, updates)
+	// 按照知识库的元数据字段定义校验元数据，并同步到文档的所有片段
+	metadata, err := retrievers.NormalizeMetadata(dataset.MetadataFields, updateReq.Metadata)
+	if err != nil {
+		return errno.ErrValidate.AppendBizMessage(fmt.Errorf("文档元数据错误: %v", err))
+	}
+	metadataJSON, _ := sonic.Marshal(metadata)
+	updates["metadata"] = string(metadataJSON)
+	if err := s.repo.UpdateDocumentMetadata(ctx, documentID, updates); err != nil {
+		return err
+	}
+
+	// 已经构建完成的文档需要异步更新向量数据库中的片段记录
+	if document.Status == string(consts.DocumentStatusCompleted) {
+		if err := s.taskProducer.PublishUpdateDocumentMetadataTask(ctx, documentID); err != nil {
+			return fmt.Errorf("failed to publish update document metadata task: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateDocumentContent 使用新的文件或处理规则更新文档内容，异步任务按片段hash增量重建索引，返回文档新的处理批次
+func (s *DocumentService) UpdateDocumentContent(ctx context.Context, datasetID, documentID uuid.UUID, updateReq req.UpdateDocumentContentReq) (string, error) {
+	userID, err := util.GetCurrentUserID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// 1. 检测知识库与文档权限
+	dataset, err := s.repo.GetDatasetByID(ctx, datasetID)
+	if err != nil {
+		return "", errno.ErrNotFound.AppendBizMessage(errors.New("知识库不存在"))
+	}
+	if dataset.AccountID != userID {
+		return "", errno.ErrForbidden.AppendBizMessage(errors.New("无权限修改该知识库"))
+	}
+	if dataset.Type == consts.DatasetTypeTable || dataset.Type == consts.DatasetTypeQA {
+		return "", errno.ErrValidate.AppendBizMessage(errors.New("表格与问答对知识库不支持增量更新，请删除文档后重新上传"))
+	}
+
+	document, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return "", errno.ErrNotFound.AppendBizMessage(errors.New("文档不存在"))
+	}
+	if document.DatasetID != datasetID {
+		return "", errno.ErrValidate.AppendBizMessage(errors.New("文档不属于该知识库"))
+	}
+
+	// 2. 只有构建完成或失败的文档可以更新内容
+	if document.Status != string(consts.DocumentStatusCompleted) && document.Status != string(consts.DocumentStatusError) {
+		return "", errno.ErrValidate.AppendBizMessage(errors.New("当前文档正在构建中，请稍后尝试"))
+	}
+	if updateReq.UploadFileID == nil && updateReq.ProcessType == "" {
+		return "", errno.ErrValidate.AppendBizMessage(errors.New("需要传递新的文件或处理规则"))
+	}
+
+	// 3. 校验新的文件权限与文件扩展
+	batch := fmt.Sprintf("%d%06d", time.Now().Unix(), time.Now().Nanosecond()%1000000)
+	updates := map[string]any{
+		"batch":  batch,
+		"status": consts.DocumentStatusWaiting,
+	}
+	if updateReq.UploadFileID != nil {
+		uploadFiles, err := s.repo.GetUploadFilesByIDs(ctx, userID, []uuid.UUID{*updateReq.UploadFileID})
+		if err != nil {
+			return "", err
+		}
+		if len(uploadFiles) == 0 || !allowedDocumentExtensions(dataset.Type)[uploadFiles[0].Extension] {
+			return "", errno.ErrValidate.AppendBizMessage(errors.New("暂未解析到合法文件，请重新上传"))
+		}
+		updates["upload_file_id"] = uploadFiles[0].ID
+	}
+
+	// 4. 传递了处理规则时校验并创建新的处理规则
+	if updateReq.ProcessType != "" {
+		if err := processruleservice.ValidateProcessRule(updateReq.ProcessType, updateReq.Rule); err != nil {
+			return "", errno.ErrValidate.AppendBizMessage(fmt.Errorf("处理规则错误: %v", err))
+		}
+		processRule := &entity.ProcessRule{
+			AccountID: userID,
+			DatasetID: datasetID,
+			Mode:      updateReq.ProcessType,
+			Rule:      updateReq.Rule,
+		}
+		if err := s.repo.CreateProcessRule(ctx, processRule); err != nil {
+			return "", err
+		}
+		updates["process_rule_id"] = processRule.ID
+	}
+
+	if err := s.repo.UpdateDocument(ctx, documentID, updates); err != nil {
+		return "", err
+	}
+
+	// 5. 发布增量更新任务
+	if err := s.taskProducer.PublishUpdateDocumentContentTask(ctx, documentID); err != nil {
+		return "", fmt.Errorf("failed to publish update document content task: %w", err)
+	}
+
+	return batch, nil
 }
 
 func (s *DocumentService) DeleteDocument(ctx context.Context, datasetID, documentID uuid.UUID) error {
@@ -393,6 +547,48 @@ func (s *DocumentService) UpdateDocumentEnabled(ctx context.Context, datasetID,
 	return s.repo.UpdateDocument(ctx, documentID, updates)
 }
 
+// getDocumentProgress 从进度条中获取文档的构建进度与最近的错误信息，等待中的文档不读取进度条，
+// 已完成的文档进度固定为完成，部分片段失败时仍返回最近的错误信息，进度条中没有错误信息时使用文档记录的错误信息
+func (s *DocumentService) getDocumentProgress(ctx context.Context, document *entity.Document) (int, int, string) {
+	if document.Status == string(consts.DocumentStatusWaiting) {
+		return progressbarimpl.ProcessInit, progressbarimpl.DefaultProcessTime, ""
+	}
+
+	progressBar := progressbarimpl.NewProgressBar(ctx, document.ID.String(), 0, s.cacheClient, false)
+	progress, remainingTime, errMsg := progressBar.GetProgress(ctx)
+	if errMsg == "" {
+		errMsg = document.Error
+	}
+	if document.Status == string(consts.DocumentStatusCompleted) {
+		return progressbarimpl.ProcessDone, 0, errMsg
+	}
+
+	return progress, remainingTime, errMsg
+}
+
+// isDocumentsFinished 判断文档列表是否都已构建完成或失败
+func isDocumentsFinished(documentsStatus []resp.DocumentStatusResp) bool {
+	for _, status := range documentsStatus {
+		if status.Status != string(consts.DocumentStatusCompleted) && status.Status != string(consts.DocumentStatusError) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// allowedDocumentExtensions 知识库可以接收的文档文件扩展名，表格知识库与问答对知识库只接收可以按行读取的表格文件
+func allowedDocumentExtensions(datasetType consts.DatasetType) map[string]bool {
+	if datasetType == consts.DatasetTypeTable || datasetType == consts.DatasetTypeQA {
+		return map[string]bool{".csv": true, ".xlsx": true}
+	}
+
+	return map[string]bool{
+		".txt": true, ".md": true, ".pdf": true, ".doc": true, ".docx": true,
+		".html": true, ".htm": true, ".rtf": true, ".csv": true, ".json": true,
+	}
+}
+
 func (s *DocumentService) buildDocumentResp(ctx context.Context, doc *entity.Document) (*resp.DocumentResp, error) {
 	type statsResult struct {
 		segmentCount int
@@ -440,6 +636,8 @@ func (s *DocumentService) buildDocumentResp(ctx context.Context, doc *entity.Doc
 		HitCount:       res.hitCount,
 		Enabled:        doc.Enabled,
 		DisabledAt:     doc.DisabledAt,
+		Metadata:       doc.Metadata,
+		SourceURL:      doc.SourceURL,
 		Ctime:          doc.Ctime,
 		Utime:          doc.Utime,
 	}, nil
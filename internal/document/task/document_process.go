@@ -14,9 +14,12 @@ import (
 type DocumentTaskType string
 
 const (
-	TaskTypeBuild         DocumentTaskType = "build"
-	TaskTypeUpdateEnabled DocumentTaskType = "update_enabled"
-	TaskTypeDelete        DocumentTaskType = "delete"
+	TaskTypeBuild          DocumentTaskType = "build"
+	TaskTypeUpdateEnabled  DocumentTaskType = "update_enabled"
+	TaskTypeUpdateMetadata DocumentTaskType = "update_metadata"
+	TaskTypeUpdateContent  DocumentTaskType = "update_content"
+	TaskTypeDelete         DocumentTaskType = "delete"
+	TaskTypeCrawl          DocumentTaskType = "crawl"
 )
 
 // DocumentTask 文档任务结构
@@ -24,6 +27,8 @@ type DocumentTask struct {
 	TaskType   DocumentTaskType `json:"task_type"`
 	DocumentID uuid.UUID        `json:"document_id"`
 	DatasetID  uuid.UUID        `json:"dataset_id,omitempty"`
+	// CrawlSourceID 网页爬取任务对应的爬取来源
+	CrawlSourceID uuid.UUID `json:"crawl_source_id,omitempty"`
 }
 
 // DocumentProducer 文档任务生产者
@@ -73,6 +78,26 @@ func (p *DocumentProducer) PublishUpdateDocumentEnabledTask(ctx context.Context,
 	return p.publishTask(ctx, "document.update_enabled", task)
 }
 
+// PublishUpdateDocumentMetadataTask 发布更新文档元数据任务
+func (p *DocumentProducer) PublishUpdateDocumentMetadataTask(ctx context.Context, documentID uuid.UUID) error {
+	task := DocumentTask{
+		TaskType:   TaskTypeUpdateMetadata,
+		DocumentID: documentID,
+	}
+
+	return p.publishTask(ctx, "document.update_metadata", task)
+}
+
+// PublishUpdateDocumentContentTask 发布增量更新文档内容任务
+func (p *DocumentProducer) PublishUpdateDocumentContentTask(ctx context.Context, documentID uuid.UUID) error {
+	task := DocumentTask{
+		TaskType:   TaskTypeUpdateContent,
+		DocumentID: documentID,
+	}
+
+	return p.publishTask(ctx, "document.update_content", task)
+}
+
 // PublishDeleteDocumentTask 发布删除文档任务
 func (p *DocumentProducer) PublishDeleteDocumentTask(ctx context.Context, datasetID, documentID uuid.UUID) error {
 	task := DocumentTask{
@@ -84,6 +109,16 @@ func (p *DocumentProducer) PublishDeleteDocumentTask(ctx context.Context, datase
 	return p.publishTask(ctx, "document.delete", task)
 }
 
+// PublishCrawlTask 发布网页爬取任务
+func (p *DocumentProducer) PublishCrawlTask(ctx context.Context, crawlSourceID uuid.UUID) error {
+	task := DocumentTask{
+		TaskType:      TaskTypeCrawl,
+		CrawlSourceID: crawlSourceID,
+	}
+
+	return p.publishTask(ctx, "document.crawl", task)
+}
+
 // publishTask 发布任务到Kafka
 func (p *DocumentProducer) publishTask(ctx context.Context, topic string, task DocumentTask) error {
 	data, err := sonic.Marshal(task)
@@ -5,6 +5,7 @@ package document
 
 import (
 	"github.com/crazyfrankie/voidx/conf"
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
 	"github.com/crazyfrankie/voidx/internal/document/task"
 	"github.com/google/wire"
 	"gorm.io/gorm"
@@ -37,7 +38,7 @@ func InitProducer() *task.DocumentProducer {
 	return producer
 }
 
-func InitDocumentModule(db *gorm.DB) *DocumentModule {
+func InitDocumentModule(db *gorm.DB, cacheClient cache.Cmdable) *DocumentModule {
 	wire.Build(
 		InitProducer,
 		DocumentSet,
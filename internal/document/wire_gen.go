@@ -8,6 +8,7 @@ package document
 
 import (
 	"github.com/crazyfrankie/voidx/conf"
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
 	"github.com/crazyfrankie/voidx/internal/document/handler"
 	"github.com/crazyfrankie/voidx/internal/document/repository"
 	"github.com/crazyfrankie/voidx/internal/document/repository/dao"
@@ -19,11 +20,11 @@ import (
 
 // Injectors from wire.go:
 
-func InitDocumentModule(db *gorm.DB) *DocumentModule {
+func InitDocumentModule(db *gorm.DB, cacheClient cache.Cmdable) *DocumentModule {
 	documentDao := dao.NewDocumentDao(db)
 	documentRepo := repository.NewDocumentRepo(documentDao)
 	documentProducer := InitProducer()
-	documentService := service.NewDocumentService(documentRepo, documentProducer)
+	documentService := service.NewDocumentService(documentRepo, documentProducer, cacheClient)
 	documentHandler := handler.NewDocumentHandler(documentService)
 	documentModule := &DocumentModule{
 		Handler: documentHandler,
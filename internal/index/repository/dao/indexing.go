@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
 )
 
 type IndexingDao struct {
@@ -39,6 +40,18 @@ func (d *IndexingDao) GetDocumentByID(ctx context.Context, documentID uuid.UUID)
 	return &document, nil
 }
 
+func (d *IndexingDao) GetDatasetByID(ctx context.Context, datasetID uuid.UUID) (*entity.Dataset, error) {
+	var dataset entity.Dataset
+	err := d.db.WithContext(ctx).Where("id = ?", datasetID).First(&dataset).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dataset, nil
+}
+
 func (d *IndexingDao) UpdateDocument(ctx context.Context, documentID uuid.UUID, updates map[string]any) error {
 	return d.db.WithContext(ctx).Model(&entity.Document{}).Where("id = ?", documentID).Updates(updates).Error
 }
@@ -60,6 +73,10 @@ func (d *IndexingDao) DeleteSegmentsByDocumentID(ctx context.Context, documentID
 	return d.db.WithContext(ctx).Where("document_id = ?", documentID).Delete(&entity.Segment{}).Error
 }
 
+func (d *IndexingDao) DeleteSegmentsByIDs(ctx context.Context, segmentIDs []uuid.UUID) error {
+	return d.db.WithContext(ctx).Where("id IN ?", segmentIDs).Delete(&entity.Segment{}).Error
+}
+
 func (d *IndexingDao) DeleteDocumentsByDatasetID(ctx context.Context, datasetID uuid.UUID) error {
 	return d.db.WithContext(ctx).Where("dataset_id = ?", datasetID).Delete(&entity.Document{}).Error
 }
@@ -69,7 +86,12 @@ func (d *IndexingDao) DeleteSegmentsByDatasetID(ctx context.Context, datasetID u
 }
 
 func (d *IndexingDao) DeleteKeywordTablesByDatasetID(ctx context.Context, datasetID uuid.UUID) error {
-	return d.db.WithContext(ctx).Where("dataset_id = ?", datasetID).Delete(&entity.Keyword{}).Error
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dataset_id = ?", datasetID).Delete(&entity.KeywordPosting{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("dataset_id = ?", datasetID).Delete(&entity.Keyword{}).Error
+	})
 }
 
 func (d *IndexingDao) DeleteDatasetQueriesByDatasetID(ctx context.Context, datasetID uuid.UUID) error {
@@ -114,6 +136,69 @@ func (d *IndexingDao) UpdateSegment(ctx context.Context, segmentID uuid.UUID, up
 	return d.db.WithContext(ctx).Model(&entity.Segment{}).Where("id = ?", segmentID).Updates(updates).Error
 }
 
-func (d *IndexingDao) UpdateKeywordTable(ctx context.Context, keywordTableID uuid.UUID, updates map[string]any) error {
-	return d.db.WithContext(ctx).Model(&entity.Keyword{}).Where("id = ?", keywordTableID).Updates(updates).Error
+func (d *IndexingDao) CreateDocument(ctx context.Context, document *entity.Document) error {
+	return d.db.WithContext(ctx).Create(document).Error
+}
+
+func (d *IndexingDao) GetLatestDocumentPosition(ctx context.Context, datasetID uuid.UUID) (int, error) {
+	var maxPosition int
+	err := d.db.WithContext(ctx).Model(&entity.Document{}).
+		Where("dataset_id = ?", datasetID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&maxPosition).Error
+	if err != nil {
+		return 0, err
+	}
+	return maxPosition, nil
+}
+
+func (d *IndexingDao) GetDocumentsByCrawlSourceID(ctx context.Context, crawlSourceID uuid.UUID) ([]*entity.Document, error) {
+	var documents []*entity.Document
+	err := d.db.WithContext(ctx).Where("crawl_source_id = ?", crawlSourceID).Find(&documents).Error
+	if err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (d *IndexingDao) GetCrawlSourceByID(ctx context.Context, crawlSourceID uuid.UUID) (*entity.CrawlSource, error) {
+	var source entity.CrawlSource
+	err := d.db.WithContext(ctx).Where("id = ?", crawlSourceID).First(&source).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+// ClaimCrawlSource 将爬取来源标记为爬取中，来源正在被爬取且未超时时返回false
+func (d *IndexingDao) ClaimCrawlSource(ctx context.Context, crawlSourceID uuid.UUID, staleBefore int64) (bool, error) {
+	res := d.db.WithContext(ctx).Model(&entity.CrawlSource{}).
+		Where("id = ? AND (status <> ? OR utime < ?)", crawlSourceID, consts.CrawlStatusCrawling, staleBefore).
+		Updates(map[string]any{"status": consts.CrawlStatusCrawling, "error": ""})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (d *IndexingDao) UpdateCrawlSource(ctx context.Context, crawlSourceID uuid.UUID, updates map[string]any) error {
+	return d.db.WithContext(ctx).Model(&entity.CrawlSource{}).Where("id = ?", crawlSourceID).Updates(updates).Error
+}
+
+func (d *IndexingDao) GetDueCrawlSources(ctx context.Context, now int64) ([]*entity.CrawlSource, error) {
+	var sources []*entity.CrawlSource
+	err := d.db.WithContext(ctx).
+		Where("recrawl_interval > 0 AND next_crawl_at <= ? AND status <> ?", now, consts.CrawlStatusCrawling).
+		Find(&sources).Error
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func (d *IndexingDao) DeleteCrawlSourcesByDatasetID(ctx context.Context, datasetID uuid.UUID) error {
+	return d.db.WithContext(ctx).Where("dataset_id = ?", datasetID).Delete(&entity.CrawlSource{}).Error
 }
@@ -25,6 +25,10 @@ func (r *IndexingRepo) GetDocumentByID(ctx context.Context, documentID uuid.UUID
 	return r.dao.GetDocumentByID(ctx, documentID)
 }
 
+func (r *IndexingRepo) GetDatasetByID(ctx context.Context, datasetID uuid.UUID) (*entity.Dataset, error) {
+	return r.dao.GetDatasetByID(ctx, datasetID)
+}
+
 func (r *IndexingRepo) UpdateDocument(ctx context.Context, documentID uuid.UUID, updates map[string]any) error {
 	return r.dao.UpdateDocument(ctx, documentID, updates)
 }
@@ -41,6 +45,10 @@ func (r *IndexingRepo) DeleteSegmentsByDocumentID(ctx context.Context, documentI
 	return r.dao.DeleteSegmentsByDocumentID(ctx, documentID)
 }
 
+func (r *IndexingRepo) DeleteSegmentsByIDs(ctx context.Context, segmentIDs []uuid.UUID) error {
+	return r.dao.DeleteSegmentsByIDs(ctx, segmentIDs)
+}
+
 func (r *IndexingRepo) DeleteDocumentsByDatasetID(ctx context.Context, datasetID uuid.UUID) error {
 	return r.dao.DeleteDocumentsByDatasetID(ctx, datasetID)
 }
@@ -77,6 +85,34 @@ func (r *IndexingRepo) UpdateSegment(ctx context.Context, segmentID uuid.UUID, u
 	return r.dao.UpdateSegment(ctx, segmentID, updates)
 }
 
-func (r *IndexingRepo) UpdateKeywordTable(ctx context.Context, keywordTableID uuid.UUID, updates map[string]any) error {
-	return r.dao.UpdateKeywordTable(ctx, keywordTableID, updates)
+func (r *IndexingRepo) CreateDocument(ctx context.Context, document *entity.Document) error {
+	return r.dao.CreateDocument(ctx, document)
+}
+
+func (r *IndexingRepo) GetLatestDocumentPosition(ctx context.Context, datasetID uuid.UUID) (int, error) {
+	return r.dao.GetLatestDocumentPosition(ctx, datasetID)
+}
+
+func (r *IndexingRepo) GetDocumentsByCrawlSourceID(ctx context.Context, crawlSourceID uuid.UUID) ([]*entity.Document, error) {
+	return r.dao.GetDocumentsByCrawlSourceID(ctx, crawlSourceID)
+}
+
+func (r *IndexingRepo) GetCrawlSourceByID(ctx context.Context, crawlSourceID uuid.UUID) (*entity.CrawlSource, error) {
+	return r.dao.GetCrawlSourceByID(ctx, crawlSourceID)
+}
+
+func (r *IndexingRepo) ClaimCrawlSource(ctx context.Context, crawlSourceID uuid.UUID, staleBefore int64) (bool, error) {
+	return r.dao.ClaimCrawlSource(ctx, crawlSourceID, staleBefore)
+}
+
+func (r *IndexingRepo) UpdateCrawlSource(ctx context.Context, crawlSourceID uuid.UUID, updates map[string]any) error {
+	return r.dao.UpdateCrawlSource(ctx, crawlSourceID, updates)
+}
+
+func (r *IndexingRepo) GetDueCrawlSources(ctx context.Context, now int64) ([]*entity.CrawlSource, error) {
+	return r.dao.GetDueCrawlSources(ctx, now)
+}
+
+func (r *IndexingRepo) DeleteCrawlSourcesByDatasetID(ctx context.Context, datasetID uuid.UUID) error {
+	return r.dao.DeleteCrawlSourcesByDatasetID(ctx, datasetID)
 }
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/crawler"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/consts"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+// crawlStaleTimeout 爬取中的来源超过该时间没有更新时视为爬取任务已中断，允许重新爬取
+const crawlStaleTimeout = time.Hour
+
+// crawlHeartbeatInterval 爬取过程中刷新来源更新时间的间隔，需小于crawlStaleTimeout，耗时较长的爬取不会被视为已中断
+const crawlHeartbeatInterval = crawlStaleTimeout / 4
+
+// maxDocumentNameLength 使用页面标题作为文档名称时的最大长度
+const maxDocumentNameLength = 100
+
+// CrawlSource 爬取网页来源中的页面，新页面创建文档并构建，内容发生变化的页面增量更新文档，
+// 内容未变化的页面直接跳过，来源中已不存在的页面保留原有文档
+func (s *IndexingService) CrawlSource(ctx context.Context, crawlSourceID uuid.UUID) error {
+	// 1. 获取爬取来源并标记为爬取中，同一来源同时只会有一个爬取任务
+	source, err := s.repo.GetCrawlSourceByID(ctx, crawlSourceID)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("爬取来源不存在"))
+	}
+	claimed, err := s.repo.ClaimCrawlSource(ctx, source.ID, time.Now().Add(-crawlStaleTimeout).Unix())
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		logs.Infof("Crawl source %s is already being crawled, skipped", source.ID)
+		return nil
+	}
+
+	// 2. 爬取页面并同步到文档，完成后记录爬取结果与下一次爬取的时间
+	batch := fmt.Sprintf("%d%06d", time.Now().Unix(), time.Now().Nanosecond()%1000000)
+	status, errMsg := consts.CrawlStatusCompleted, ""
+	stopHeartbeat := s.keepCrawlSourceAlive(ctx, source.ID)
+	err = s.crawlPages(ctx, source, batch)
+	stopHeartbeat()
+	if err != nil {
+		logs.Errorf("Failed to crawl source %s: %v", source.ID, err)
+		status, errMsg = consts.CrawlStatusError, err.Error()
+	}
+
+	now := time.Now()
+	var nextCrawlAt int64
+	if source.RecrawlInterval > 0 {
+		nextCrawlAt = now.Add(time.Duration(source.RecrawlInterval) * time.Second).UnixMilli()
+	}
+
+	return s.repo.UpdateCrawlSource(ctx, source.ID, map[string]any{
+		"status":          status,
+		"error":           errMsg,
+		"batch":           batch,
+		"last_crawled_at": now.UnixMilli(),
+		"next_crawl_at":   nextCrawlAt,
+	})
+}
+
+// RecrawlDueSources 重新爬取所有到达下一次爬取时间的来源
+func (s *IndexingService) RecrawlDueSources(ctx context.Context) error {
+	sources, err := s.repo.GetDueCrawlSources(ctx, time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.CrawlSource(ctx, source.ID); err != nil {
+			logs.Errorf("Failed to recrawl source %s: %v", source.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// keepCrawlSourceAlive 在爬取与构建文档期间定期刷新来源的更新时间，直到返回的函数被调用
+func (s *IndexingService) keepCrawlSourceAlive(ctx context.Context, crawlSourceID uuid.UUID) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(crawlHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := s.repo.UpdateCrawlSource(ctx, crawlSourceID, map[string]any{"utime": time.Now().Unix()})
+				if err != nil {
+					logs.Errorf("Failed to refresh crawl source %s: %v", crawlSourceID, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// crawlPages 爬取来源中的页面，并将页面正文以Markdown文件的形式同步为知识库文档
+func (s *IndexingService) crawlPages(ctx context.Context, source *entity.CrawlSource, batch string) error {
+	// 1. 爬取来源中的所有页面
+	pages, err := s.crawler.Crawl(ctx, crawler.Config{
+		SeedURLs:   source.SeedURLs,
+		SitemapURL: source.SitemapURL,
+		MaxDepth:   source.MaxDepth,
+		MaxPages:   source.MaxPages,
+	})
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return errors.New("没有爬取到包含正文的页面")
+	}
+
+	// 2. 获取来源已有的文档，按照页面地址与页面对应
+	documents, err := s.repo.GetDocumentsByCrawlSourceID(ctx, source.ID)
+	if err != nil {
+		return err
+	}
+	documentsByURL := make(map[string]*entity.Document, len(documents))
+	for _, document := range documents {
+		documentsByURL[document.SourceURL] = document
+	}
+	position, err := s.repo.GetLatestDocumentPosition(ctx, source.DatasetID)
+	if err != nil {
+		return err
+	}
+
+	// 3. 循环同步每个页面，单个页面失败时跳过该页面
+	var newDocumentIDs []uuid.UUID
+	for _, page := range pages {
+		sourceHash := util.GenerateHash(page.Content)
+		document, exists := documentsByURL[page.URL]
+		if exists && skipCrawledPage(document, sourceHash) {
+			continue
+		}
+
+		uploadFileID, err := s.uploadPage(ctx, source.AccountID, page, sourceHash)
+		if err != nil {
+			logs.Errorf("Failed to upload crawled page %s: %v", page.URL, err)
+			continue
+		}
+
+		// 4. 页面已有文档时替换文档文件并增量更新，否则创建新文档
+		if exists {
+			err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+				"upload_file_id": uploadFileID,
+				"batch":          batch,
+				"name":           documentName(page.Title),
+				"source_hash":    sourceHash,
+				"status":         consts.DocumentStatusWaiting,
+			})
+			if err != nil {
+				logs.Errorf("Failed to update crawled document %s: %v", document.ID, err)
+				continue
+			}
+			if err := s.UpdateDocumentContent(ctx, document.ID); err != nil {
+				logs.Errorf("Failed to update crawled document %s: %v", document.ID, err)
+			}
+			continue
+		}
+
+		position++
+		newDocument := &entity.Document{
+			AccountID:     source.AccountID,
+			DatasetID:     source.DatasetID,
+			UploadFileID:  uploadFileID,
+			ProcessRuleID: source.ProcessRuleID,
+			Batch:         batch,
+			Name:          documentName(page.Title),
+			Position:      position,
+			Enabled:       true,
+			CrawlSourceID: source.ID,
+			SourceURL:     page.URL,
+			SourceHash:    sourceHash,
+		}
+		if err := s.repo.CreateDocument(ctx, newDocument); err != nil {
+			logs.Errorf("Failed to create crawled document %s: %v", page.URL, err)
+			continue
+		}
+		newDocumentIDs = append(newDocumentIDs, newDocument.ID)
+	}
+
+	// 5. 构建新创建的文档
+	if len(newDocumentIDs) > 0 {
+		if err := s.BuildDocuments(ctx, newDocumentIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// skipCrawledPage 判断已有文档的页面是否跳过同步，内容未变化或文档正在处理中的页面跳过，处理中的页面留到下一次爬取时再同步
+func skipCrawledPage(document *entity.Document, sourceHash string) bool {
+	if document.SourceHash == sourceHash {
+		return true
+	}
+
+	return document.Status != string(consts.DocumentStatusCompleted) && document.Status != string(consts.DocumentStatusError)
+}
+
+// uploadPage 将页面正文保存为Markdown文件，文件名由页面地址与正文的hash组成，避免不同页面与版本之间互相覆盖
+func (s *IndexingService) uploadPage(ctx context.Context, accountID uuid.UUID, page crawler.Page, sourceHash string) (uuid.UUID, error) {
+	content := page.Content
+	if page.Title != "" && !strings.HasPrefix(content, "# ") {
+		content = "# " + page.Title + "\n\n" + content
+	}
+	filename := fmt.Sprintf("crawl-%s-%s.md", util.GenerateHash(page.URL)[:16], sourceHash[:16])
+
+	uploadFile, err := s.uploadService.UploadFile(ctx, []byte(content), false, filename, accountID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uploadFile.ID, nil
+}
+
+// documentName 使用页面标题作为文档名称，超出长度时截断
+func documentName(title string) string {
+	runes := []rune(title)
+	if len(runes) > maxDocumentNameLength {
+		return string(runes[:maxDocumentNameLength])
+	}
+
+	return title
+}
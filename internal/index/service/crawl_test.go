@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+func TestSkipCrawledPage(t *testing.T) {
+	hash := util.GenerateHash("page content")
+	changed := util.GenerateHash("changed content")
+
+	tests := []struct {
+		name   string
+		status consts.DocumentStatus
+		hash   string
+		want   bool
+	}{
+		{name: "unchanged completed page", status: consts.DocumentStatusCompleted, hash: hash, want: true},
+		{name: "unchanged failed page", status: consts.DocumentStatusError, hash: hash, want: true},
+		{name: "changed completed page", status: consts.DocumentStatusCompleted, hash: changed, want: false},
+		{name: "changed failed page", status: consts.DocumentStatusError, hash: changed, want: false},
+		{name: "changed page still indexing", status: consts.DocumentStatusIndexing, hash: changed, want: true},
+		{name: "changed page waiting", status: consts.DocumentStatusWaiting, hash: changed, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := &entity.Document{Status: string(tt.status), SourceHash: hash}
+			assert.Equal(t, tt.want, skipCrawledPage(document, tt.hash))
+		})
+	}
+}
+
+func TestDocumentName(t *testing.T) {
+	assert.Equal(t, "title", documentName("title"))
+	assert.Len(t, []rune(documentName(string(make([]rune, maxDocumentNameLength+10)))), maxDocumentNameLength)
+}
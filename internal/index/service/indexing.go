@@ -5,21 +5,31 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
 	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	"github.com/crazyfrankie/voidx/internal/core/crawler"
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/file_extractor"
+	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/core/qa"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/index/repository"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/process_rule"
+	processruleservice "github.com/crazyfrankie/voidx/internal/process_rule/service"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/upload"
 	"github.com/crazyfrankie/voidx/pkg/logs"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
@@ -30,33 +40,48 @@ import (
 type IndexingService struct {
 	repo                *repository.IndexingRepo
 	redisClient         redis.Cmdable
+	cacheClient         cache.Cmdable
 	fileExtractor       *file_extractor.FileExtractor
 	processRuleService  *process_rule.Service
 	embeddingsService   *embedding.EmbeddingService
 	jiebaService        *retrievers.JiebaService
 	keywordTableService *retriever.KeyWordService
 	vectorStore         vecstore.SearchStore
+	llmService          *llm.Service
+	tableStore          *table.Store
+	crawler             *crawler.Crawler
+	uploadService       *upload.Service
 }
 
 func NewIndexingService(
 	repo *repository.IndexingRepo,
 	redisClient redis.Cmdable,
+	cacheClient cache.Cmdable,
 	fileExtractor *file_extractor.FileExtractor,
 	processRuleService *process_rule.Service,
 	embeddingsService *embedding.EmbeddingService,
 	jiebaService *retrievers.JiebaService,
 	keywordTableService *retriever.KeyWordService,
 	vectorStore vecstore.SearchStore,
+	llmService *llm.Service,
+	tableStore *table.Store,
+	crawler *crawler.Crawler,
+	uploadService *upload.Service,
 ) *IndexingService {
 	return &IndexingService{
 		repo:                repo,
 		redisClient:         redisClient,
+		cacheClient:         cacheClient,
 		fileExtractor:       fileExtractor,
 		processRuleService:  processRuleService,
 		embeddingsService:   embeddingsService,
 		jiebaService:        jiebaService,
 		keywordTableService: keywordTableService,
 		vectorStore:         vectorStore,
+		llmService:          llmService,
+		tableStore:          tableStore,
+		crawler:             crawler,
+		uploadService:       uploadService,
 	}
 }
 
@@ -72,9 +97,10 @@ func (s *IndexingService) BuildDocuments(ctx context.Context, documentIDs []uuid
 	for _, document := range documents {
 		if err := s.buildSingleDocument(ctx, document); err != nil {
 			logs.Errorf("Failed to build document %s: %v", document.ID, err)
+			s.progressBar(ctx, document.ID, false).ReportError(err)
 
 			// 更新文档状态为错误
-			now := time.Now()
+			now := time.Now().UnixMilli()
 			s.repo.UpdateDocument(ctx, document.ID, map[string]any{
 				"status":     consts.DocumentStatusError,
 				"error":      err.Error(),
@@ -88,15 +114,36 @@ func (s *IndexingService) BuildDocuments(ctx context.Context, documentIDs []uuid
 
 // buildSingleDocument 构建单个文档
 func (s *IndexingService) buildSingleDocument(ctx context.Context, document *entity.Document) error {
-	// 3. 更新当前状态为解析中，并记录开始处理的时间
+	// 3. 更新当前状态为解析中，并记录开始处理的时间，同时清空上一次构建的进度
 	now := time.Now().UnixMilli()
 	err := s.repo.UpdateDocument(ctx, document.ID, map[string]any{
 		"status":                consts.DocumentStatusParsing,
 		"processing_started_at": &now,
+		"error":                 "",
 	})
 	if err != nil {
 		return err
 	}
+	s.progressBar(ctx, document.ID, true)
+
+	// 表格知识库的文档直接导入为数据表，不需要分割与建立索引
+	dataset, err := s.repo.GetDatasetByID(ctx, document.DatasetID)
+	if err != nil {
+		return err
+	}
+	if dataset.Type == consts.DatasetTypeTable {
+		if err := s.importTable(ctx, dataset, document); err != nil {
+			return fmt.Errorf("表格导入失败: %w", err)
+		}
+		return nil
+	}
+	// 问答对知识库的文档每行导入为一个问答对片段，不需要分割
+	if dataset.Type == consts.DatasetTypeQA {
+		if err := s.importQAPairs(ctx, document); err != nil {
+			return fmt.Errorf("问答对导入失败: %w", err)
+		}
+		return nil
+	}
 
 	// 4. 执行文档加载步骤，并更新文档的状态与时间
 	lcDocuments, err := s.parsing(ctx, document)
@@ -125,6 +172,393 @@ func (s *IndexingService) buildSingleDocument(ctx context.Context, document *ent
 	return nil
 }
 
+// UpdateDocumentContent 使用文档新的文件或处理规则重新构建文档，按照片段hash与已有片段比对，
+// 内容未变化的片段保留原有的关键词与向量，只为新增的片段建立索引，并删除不再存在的片段
+func (s *IndexingService) UpdateDocumentContent(ctx context.Context, documentID uuid.UUID) error {
+	document, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if document == nil {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("当前文档不存在"))
+	}
+
+	if err := s.updateSingleDocument(ctx, document); err != nil {
+		logs.Errorf("Failed to update document %s: %v", document.ID, err)
+		s.progressBar(ctx, document.ID, false).ReportError(err)
+
+		// 更新文档状态为错误
+		now := time.Now().UnixMilli()
+		s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+			"status":     consts.DocumentStatusError,
+			"error":      err.Error(),
+			"stopped_at": &now,
+		})
+	}
+
+	return nil
+}
+
+// updateSingleDocument 增量更新单个文档
+func (s *IndexingService) updateSingleDocument(ctx context.Context, document *entity.Document) error {
+	// 1. 更新当前状态为解析中，并记录开始处理的时间，同时清空上一次构建的进度
+	now := time.Now().UnixMilli()
+	err := s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"status":                consts.DocumentStatusParsing,
+		"processing_started_at": &now,
+		"error":                 "",
+	})
+	if err != nil {
+		return err
+	}
+	s.progressBar(ctx, document.ID, true)
+
+	// 2. 加载文档并按照新的处理规则分割
+	lcDocuments, err := s.parsing(ctx, document)
+	if err != nil {
+		return fmt.Errorf("文档解析失败: %w", err)
+	}
+	processRule, err := s.repo.GetProcessRuleByID(ctx, document.ProcessRuleID)
+	if err != nil {
+		return err
+	}
+	lcChunks, err := s.splitChunks(ctx, document, processRule, lcDocuments)
+	if err != nil {
+		return fmt.Errorf("文档分割失败: %w", err)
+	}
+
+	// 3. 将分割得到的片段与已有片段按hash比对，父子分段比对父片段，父片段的hash包含子片段的分割配置，
+	// 父片段内容与子片段分割配置都未变化时保留其所有子片段
+	segments, err := s.repo.GetSegmentsByDocumentID(ctx, document.ID)
+	if err != nil {
+		return err
+	}
+	hierarchical := consts.ProcessType(processRule.Mode) == consts.ProcessTypeHierarchical
+	var childSplitterKey string
+	if hierarchical {
+		childSplitterKey = s.processRuleService.GetChildSplitterKeyByProcessRule(processRule)
+	}
+	hashes := make([]string, len(lcChunks))
+	for i, lcChunk := range lcChunks {
+		headingPath, _ := lcChunk.MetaData["heading_path"].([]string)
+		hashes[i] = segmentHash(processruleservice.TextChunk{Content: lcChunk.Content, HeadingPath: headingPath}.ContextualContent(), childSplitterKey)
+	}
+	diff := diffSegments(segments, hashes, hierarchical)
+	for segmentID, position := range diff.moved {
+		if err := s.repo.UpdateSegment(ctx, segmentID, map[string]any{"position": position}); err != nil {
+			return err
+		}
+	}
+	newChunks := make([]*schema.Document, 0, len(diff.added))
+	newPositions := make([]int, 0, len(diff.added))
+	for _, index := range diff.added {
+		newChunks = append(newChunks, lcChunks[index])
+		newPositions = append(newPositions, index+1)
+	}
+
+	// 4. 删除不再存在的片段及其关键词与向量记录
+	var removedIDs []uuid.UUID
+	var removedVectorIDs []string
+	for _, segment := range diff.removed {
+		removedIDs = append(removedIDs, segment.ID)
+		removedVectorIDs = append(removedVectorIDs, qa.SegmentVectorIDs(segment)...)
+	}
+	if len(removedIDs) > 0 {
+		if len(removedVectorIDs) > 0 {
+			if err := s.vectorStore.Delete(ctx, removedVectorIDs); err != nil {
+				logs.Errorf("Failed to delete vector database records: %v", err)
+			}
+		}
+		if err := s.keywordTableService.RemoveSegmentIDs(ctx, document.DatasetID, removedIDs); err != nil {
+			logs.Errorf("Failed to delete keyword table records: %v", err)
+		}
+		if err := s.repo.DeleteSegmentsByIDs(ctx, removedIDs); err != nil {
+			return err
+		}
+	}
+
+	// 5. 按照新的顺序存储新增的片段
+	lcSegments, _, err := s.createSegments(ctx, document, processRule, newChunks, newPositions)
+	if err != nil {
+		return fmt.Errorf("文档分割失败: %w", err)
+	}
+	logs.Infof("Document %s updated incrementally: %d segments kept, %d added, %d removed",
+		document.ID, len(diff.kept), len(newChunks), len(removedIDs))
+
+	// 6. 重新统计文档的token数，父子分段只统计子片段
+	segments, err = s.repo.GetSegmentsByDocumentID(ctx, document.ID)
+	if err != nil {
+		return err
+	}
+	var totalTokenCount int
+	for _, segment := range segments {
+		if segment.NodeID != uuid.Nil {
+			totalTokenCount += segment.TokenCount
+		}
+	}
+	now = time.Now().UnixMilli()
+	err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"token_count":            totalTokenCount,
+		"status":                 consts.DocumentStatusIndexing,
+		"splitting_completed_at": &now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 7. 只为新增的片段构建关键词索引并写入向量数据库
+	if err := s.indexing(ctx, document, lcSegments); err != nil {
+		return fmt.Errorf("文档索引构建失败: %w", err)
+	}
+	if err := s.completed(ctx, document, lcSegments); err != nil {
+		return fmt.Errorf("文档存储失败: %w", err)
+	}
+
+	return nil
+}
+
+// segmentDiff 增量更新时分割得到的片段与已有片段的比对结果
+type segmentDiff struct {
+	// kept 保留的片段id，父子分段中为保留的父片段
+	kept map[uuid.UUID]struct{}
+	// moved 保留但位置发生变化的片段id及其新位置
+	moved map[uuid.UUID]int
+	// added 需要新建的片段在分割结果中的下标，片段的位置为下标+1
+	added []int
+	// removed 需要删除的片段，包含被删除父片段下的子片段
+	removed []*entity.Segment
+}
+
+// segmentHash 计算片段的hash，父子分段的父片段同时包含子片段的分割配置，配置变化时父片段需要重新切分
+func segmentHash(content, childSplitterKey string) string {
+	if childSplitterKey == "" {
+		return util.GenerateHash(content)
+	}
+
+	return util.GenerateHash(content + "\n" + childSplitterKey)
+}
+
+// diffSegments 将分割得到的片段hash按顺序与文档已有的片段比对，hash相同的片段按出现顺序一一复用，
+// 父子分段只比对父片段，保留的父片段下的子片段一并保留，分段模式发生变化时已有片段无法复用
+func diffSegments(segments []*entity.Segment, hashes []string, hierarchical bool) *segmentDiff {
+	// 1. 收集可以复用的已有片段，父子分段中的父片段不建立索引，NodeID为空
+	candidates := make(map[string][]*entity.Segment)
+	for _, segment := range segments {
+		if segment.ParentID != uuid.Nil || segment.Status != consts.SegmentStatusCompleted {
+			continue
+		}
+		if hierarchical == (segment.NodeID == uuid.Nil) {
+			candidates[segment.Hash] = append(candidates[segment.Hash], segment)
+		}
+	}
+
+	// 2. 按顺序匹配分割得到的片段，未匹配到的片段需要新建
+	diff := &segmentDiff{kept: make(map[uuid.UUID]struct{}), moved: make(map[uuid.UUID]int)}
+	for i, hash := range hashes {
+		position := i + 1
+		matched := candidates[hash]
+		if len(matched) == 0 {
+			diff.added = append(diff.added, i)
+			continue
+		}
+		segment := matched[0]
+		candidates[hash] = matched[1:]
+		diff.kept[segment.ID] = struct{}{}
+		if segment.Position != position {
+			diff.moved[segment.ID] = position
+		}
+	}
+
+	// 3. 未被保留的片段全部删除，保留的父片段下的子片段同样保留
+	for _, segment := range segments {
+		if _, ok := diff.kept[segment.ID]; ok {
+			continue
+		}
+		if _, ok := diff.kept[segment.ParentID]; ok && segment.ParentID != uuid.Nil {
+			continue
+		}
+		diff.removed = append(diff.removed, segment)
+	}
+
+	return diff
+}
+
+// importTable 将表格知识库的文档导入到知识库的数据表中，并完成状态更新
+func (s *IndexingService) importTable(ctx context.Context, dataset *entity.Dataset, document *entity.Document) error {
+	// 1. 获取upload_file并读取表格数据
+	uploadFile, err := s.repo.GetUploadFileByID(ctx, document.UploadFileID)
+	if err != nil {
+		return err
+	}
+	data, err := s.fileExtractor.LoadTable(ctx, uploadFile)
+	if err != nil {
+		return err
+	}
+
+	// 2. 更新文档状态为索引中，表格不需要分割
+	var characterCount int
+	for _, row := range data.Rows {
+		for _, cell := range row {
+			characterCount += len(cell)
+		}
+	}
+	now := time.Now().UnixMilli()
+	err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"character_count":        characterCount,
+		"status":                 consts.DocumentStatusIndexing,
+		"parsing_completed_at":   &now,
+		"splitting_completed_at": &now,
+	})
+	if err != nil {
+		return err
+	}
+	_ = s.progressBar(ctx, document.ID, false).AddN(progressSplit)
+
+	// 3. 重新构建时先删除文档已导入的行，再将数据导入数据表
+	if err := s.tableStore.DeleteDocumentRows(ctx, dataset.ID, document.ID); err != nil {
+		return err
+	}
+	if _, err := s.tableStore.Import(ctx, dataset, document.ID, data); err != nil {
+		return err
+	}
+
+	// 4. 更新文档的状态数据
+	now = time.Now().UnixMilli()
+	return s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"status":                consts.DocumentStatusCompleted,
+		"indexing_completed_at": &now,
+		"completed_at":          &now,
+		"enabled":               true,
+	})
+}
+
+// importQAPairs 将问答对知识库的文档导入为问答对片段，问题与相似问法建立索引，片段内容为答案
+func (s *IndexingService) importQAPairs(ctx context.Context, document *entity.Document) error {
+	// 1. 获取upload_file并读取问答对
+	uploadFile, err := s.repo.GetUploadFileByID(ctx, document.UploadFileID)
+	if err != nil {
+		return err
+	}
+	data, err := s.fileExtractor.LoadTable(ctx, uploadFile)
+	if err != nil {
+		return err
+	}
+	pairs, err := qa.ExtractPairs(data)
+	if err != nil {
+		return err
+	}
+
+	var characterCount int
+	for _, pair := range pairs {
+		characterCount += len(pair.Question) + len(pair.Answer)
+	}
+	now := time.Now().UnixMilli()
+	err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"character_count":      characterCount,
+		"status":               consts.DocumentStatusSplitting,
+		"parsing_completed_at": &now,
+	})
+	if err != nil {
+		return err
+	}
+	_ = s.progressBar(ctx, document.ID, false).AddN(progressParsed)
+
+	// 2. 处理规则配置了相似问法时使用模型为每个问答对生成，生成失败的问答对只使用文件中的问法，每处理一个问答对推进一次进度
+	progressBar := s.stageProgressBar(ctx, document.ID, len(pairs), progressParsed, progressChunked)
+	processRule, err := s.repo.GetProcessRuleByID(ctx, document.ProcessRuleID)
+	if err != nil {
+		return err
+	}
+	if config := s.processRuleService.GetQuestionVariantConfigByProcessRule(processRule); config != nil {
+		chatModel, err := s.llmService.LoadLanguageModel(ctx, document.AccountID, config.ModelConfig)
+		if err != nil {
+			return fmt.Errorf("加载相似问法生成模型失败: %w", err)
+		}
+		for i, pair := range pairs {
+			variants, err := qa.GenerateVariants(ctx, chatModel, pair, config.Count)
+			_ = progressBar.AddN(1)
+			if err != nil {
+				logs.Errorf("Failed to generate question variants for document %s: %v", document.ID, err)
+				continue
+			}
+			pairs[i].Variants = qa.NormalizeVariants(pair.Question, append(pair.Variants, variants...))
+		}
+	}
+	progressBar.finish()
+
+	// 3. 存储问答对片段，问题用于关键词索引，问题与每个相似问法分别写入一条向量记录
+	maxPosition, err := s.repo.GetMaxSegmentPosition(ctx, document.ID)
+	if err != nil {
+		maxPosition = 0
+	}
+	progressBar = s.stageProgressBar(ctx, document.ID, len(pairs), progressChunked, progressSplit)
+	var lcQuestions, lcVectors []*schema.Document
+	var totalTokenCount int
+	for _, pair := range pairs {
+		maxPosition++
+		segment := &entity.Segment{
+			ID:               uuid.New(),
+			AccountID:        document.AccountID,
+			DatasetID:        document.DatasetID,
+			DocumentID:       document.ID,
+			NodeID:           uuid.New(),
+			Position:         maxPosition,
+			Content:          pair.Answer,
+			CharacterCount:   len(pair.Answer),
+			TokenCount:       s.embeddingsService.CalculateTokenCount(pair.Answer),
+			Hash:             qa.Hash(pair.Question, pair.Answer),
+			Status:           consts.SegmentStatusWaiting,
+			Metadata:         document.Metadata,
+			Question:         pair.Question,
+			QuestionVariants: pair.Variants,
+		}
+		if err := s.repo.CreateSegment(ctx, segment); err != nil {
+			return err
+		}
+		totalTokenCount += segment.TokenCount
+
+		for i, content := range qa.IndexContents(segment.Question, segment.QuestionVariants) {
+			lcSegment := &schema.Document{
+				ID:      qa.VectorID(segment.NodeID, i),
+				Content: content,
+				MetaData: map[string]any{
+					"account_id":       document.AccountID.String(),
+					"dataset_id":       document.DatasetID.String(),
+					"document_id":      document.ID.String(),
+					"segment_id":       segment.ID.String(),
+					"node_id":          segment.NodeID.String(),
+					"document_enabled": false,
+					"segment_enabled":  false,
+					"metadata":         document.Metadata,
+				},
+			}
+			if i == 0 {
+				lcQuestions = append(lcQuestions, lcSegment)
+			}
+			lcVectors = append(lcVectors, lcSegment)
+		}
+		_ = progressBar.AddN(1)
+	}
+	progressBar.finish()
+
+	now = time.Now().UnixMilli()
+	err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"token_count":            totalTokenCount,
+		"status":                 consts.DocumentStatusIndexing,
+		"splitting_completed_at": &now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 4. 构建关键词索引并写入向量数据库
+	if err := s.indexing(ctx, document, lcQuestions); err != nil {
+		return err
+	}
+
+	return s.completed(ctx, document, lcVectors)
+}
+
 // UpdateDocumentEnabled 根据传递的文档id更新文档状态，同时修改向量数据库中的记录
 func (s *IndexingService) UpdateDocumentEnabled(ctx context.Context, documentID uuid.UUID) error {
 	// 1. 构建缓存键
@@ -183,10 +617,10 @@ func (s *IndexingService) UpdateDocumentEnabled(ctx context.Context, documentID
 
 	// 5. 更新关键词表对应的数据
 	if document.Enabled {
-		// 6. 从禁用改为启用，需要新增关键词
+		// 6. 从禁用改为启用，需要新增关键词，父子分段中的父片段不建立索引
 		var enabledSegmentIDs []uuid.UUID
 		for _, segment := range segments {
-			if segment.Enabled {
+			if segment.Enabled && segment.NodeID != uuid.Nil {
 				enabledSegmentIDs = append(enabledSegmentIDs, segment.ID)
 			}
 		}
@@ -205,6 +639,84 @@ func (s *IndexingService) UpdateDocumentEnabled(ctx context.Context, documentID
 	return nil
 }
 
+// UpdateDocumentMetadata 将文档的元数据同步到向量数据库中已完成片段的记录上
+func (s *IndexingService) UpdateDocumentMetadata(ctx context.Context, documentID uuid.UUID) error {
+	// 1. 获取文档及其所属知识库
+	document, err := s.repo.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if document == nil {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("当前文档不存在"))
+	}
+	dataset, err := s.repo.GetDatasetByID(ctx, document.DatasetID)
+	if err != nil {
+		return err
+	}
+
+	// 2. 向量需与检索时使用同一个模型生成
+	embedder, err := s.llmService.LoadDatasetEmbedder(ctx, dataset)
+	if err != nil {
+		return fmt.Errorf("加载知识库向量模型失败: %w", err)
+	}
+	var storeOpts []indexer.Option
+	if embedder != nil {
+		storeOpts = append(storeOpts, vecstore.WithIndexerEmbedding(embedder))
+	}
+
+	// 3. 查询文档下已完成的片段
+	segments, err := s.repo.GetSegmentsByDocumentID(ctx, documentID)
+	if err != nil {
+		return err
+	}
+
+	// 4. 向量数据库不支持局部更新，删除后使用新的元数据重新写入
+	for _, segment := range segments {
+		if segment.Status != consts.SegmentStatusCompleted || segment.NodeID == uuid.Nil {
+			continue
+		}
+
+		// 问答对片段的问题与每个相似问法分别对应一条向量记录
+		contents := []string{segment.Content}
+		if segment.Question != "" {
+			contents = qa.IndexContents(segment.Question, segment.QuestionVariants)
+		}
+		documents := make([]*schema.Document, 0, len(contents))
+		for i, content := range contents {
+			documents = append(documents, &schema.Document{
+				ID:      qa.VectorID(segment.NodeID, i),
+				Content: content,
+				MetaData: map[string]any{
+					"account_id":       segment.AccountID.String(),
+					"dataset_id":       segment.DatasetID.String(),
+					"document_id":      segment.DocumentID.String(),
+					"segment_id":       segment.ID.String(),
+					"node_id":          segment.NodeID.String(),
+					"document_enabled": document.Enabled,
+					"segment_enabled":  segment.Enabled,
+					"heading_path":     segment.HeadingPath,
+					"metadata":         document.Metadata,
+				},
+			})
+		}
+
+		err := s.vectorStore.Delete(ctx, qa.SegmentVectorIDs(segment))
+		if err == nil {
+			_, err = s.vectorStore.Store(ctx, documents, storeOpts...)
+		}
+		if err != nil {
+			logs.Errorf("Failed to update segment %s metadata in vector database: %v", segment.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// BackfillKeywordPostings 为倒排索引上线前已完成索引的片段补建倒排记录
+func (s *IndexingService) BackfillKeywordPostings(ctx context.Context) error {
+	return s.keywordTableService.BackfillPostings(ctx)
+}
+
 // DeleteDocument 根据传递的知识库id+文档id删除文档信息
 func (s *IndexingService) DeleteDocument(ctx context.Context, datasetID, documentID uuid.UUID) error {
 	// 1. 查找该文档下的所有片段id列表
@@ -214,14 +726,17 @@ func (s *IndexingService) DeleteDocument(ctx context.Context, datasetID, documen
 	}
 
 	var segmentIDs []uuid.UUID
+	var vectorIDs []string
 	for _, segment := range segments {
 		segmentIDs = append(segmentIDs, segment.ID)
+		vectorIDs = append(vectorIDs, qa.SegmentVectorIDs(segment)...)
 	}
 
-	// 2. 调用向量数据库删除其关联记录
-	err = s.vectorStore.Delete(ctx, []string{documentID.String()})
-	if err != nil {
-		logs.Errorf("Failed to delete vector database records: %v", err)
+	// 2. 调用向量数据库删除其关联记录，问答对的每个相似问法各对应一条记录
+	if len(vectorIDs) > 0 {
+		if err := s.vectorStore.Delete(ctx, vectorIDs); err != nil {
+			logs.Errorf("Failed to delete vector database records: %v", err)
+		}
 	}
 
 	// 3. 删除postgres关联的segment记录
@@ -236,6 +751,12 @@ func (s *IndexingService) DeleteDocument(ctx context.Context, datasetID, documen
 		logs.Errorf("Failed to delete keyword table records: %v", err)
 	}
 
+	// 5. 删除表格知识库中该文档导入的数据
+	err = s.tableStore.DeleteDocumentRows(ctx, datasetID, documentID)
+	if err != nil {
+		logs.Errorf("Failed to delete dataset table rows: %v", err)
+	}
+
 	return nil
 }
 
@@ -265,7 +786,19 @@ func (s *IndexingService) DeleteDataset(ctx context.Context, datasetID uuid.UUID
 		logs.Errorf("Failed to delete dataset query records: %v", err)
 	}
 
-	// 5. 调用向量数据库删除知识库的关联记录
+	// 5. 删除表格知识库的数据表
+	err = s.tableStore.DropTable(ctx, datasetID)
+	if err != nil {
+		logs.Errorf("Failed to drop dataset table: %v", err)
+	}
+
+	// 6. 删除知识库的网页爬取来源
+	err = s.repo.DeleteCrawlSourcesByDatasetID(ctx, datasetID)
+	if err != nil {
+		logs.Errorf("Failed to delete crawl source records: %v", err)
+	}
+
+	// 7. 调用向量数据库删除知识库的关联记录
 	// 需要根据实际的向量存储实现来删除知识库相关文档
 	// 这里暂时注释掉，因为SearchStore接口没有DeleteDocumentsByID方法
 	// err = s.vectorStore.Delete(ctx, datasetNodeIDs)
@@ -309,22 +842,68 @@ func (s *IndexingService) parsing(ctx context.Context, document *entity.Document
 	if err != nil {
 		return nil, err
 	}
+	_ = s.progressBar(ctx, document.ID, false).AddN(progressParsed)
 
 	return lcDocuments, nil
 }
 
 // splitting 根据传递的信息进行文档分割，拆分成小块片段
 func (s *IndexingService) splitting(ctx context.Context, document *entity.Document, lcDocuments []*schema.Document) ([]*schema.Document, error) {
-	// 1. 根据process_rule获取文本分割器
+	// 1. 根据process_rule分割文档列表
 	processRule, err := s.repo.GetProcessRuleByID(ctx, document.ProcessRuleID)
 	if err != nil {
 		return nil, err
 	}
+	lcChunks, err := s.splitChunks(ctx, document, processRule, lcDocuments)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 获取对应文档下得到最大片段位置，新片段依次排在其后
+	maxPosition, err := s.repo.GetMaxSegmentPosition(ctx, document.ID)
+	if err != nil {
+		maxPosition = 0
+	}
+	positions := make([]int, len(lcChunks))
+	for i := range positions {
+		positions[i] = maxPosition + i + 1
+	}
+
+	// 3. 存储片段数据
+	lcSegments, totalTokenCount, err := s.createSegments(ctx, document, processRule, lcChunks, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. 更新文档的数据，涵盖状态、token数等内容，父子分段只统计子片段
+	now := time.Now().UnixMilli()
+	err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
+		"token_count":            totalTokenCount,
+		"status":                 consts.DocumentStatusIndexing,
+		"splitting_completed_at": &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lcSegments, nil
+}
+
+// splitChunks 按照处理规则清除多余的字符串并将文档列表分割为片段，父子分段模式下得到的是父片段，
+// 片段的元数据中记录所在的标题路径
+func (s *IndexingService) splitChunks(ctx context.Context, document *entity.Document, processRule *entity.ProcessRule,
+	lcDocuments []*schema.Document) ([]*schema.Document, error) {
+	// 1. 根据process_rule获取文本分割器
+	lengthFunction, embedFunction, err := s.splitterFunctions(ctx, document)
+	if err != nil {
+		return nil, err
+	}
 
 	textSplitter, err := s.processRuleService.GetTextSplitterByProcessRule(
 		ctx,
 		processRule,
-		s.embeddingsService.CalculateTokenCount,
+		lengthFunction,
+		embedFunction,
 	)
 	if err != nil {
 		return nil, err
@@ -339,33 +918,73 @@ func (s *IndexingService) splitting(ctx context.Context, document *entity.Docume
 		lcDocument.Content = cleanedText
 	}
 
-	// 3. 分割文档列表为片段列表
-	var lcSegments []*schema.Document
+	// 3. 分割文档列表为片段列表，并记录片段所在的标题路径，每分割完一个文档推进一次进度
+	progressBar := s.stageProgressBar(ctx, document.ID, len(lcDocuments), progressParsed, progressChunked)
+	var lcChunks []*schema.Document
 	for _, lcDocument := range lcDocuments {
-		chunks, err := textSplitter.SplitText(lcDocument.Content)
+		chunks, err := textSplitter.SplitChunks(lcDocument.Content)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, chunk := range chunks {
-			lcSegments = append(lcSegments, &schema.Document{
-				Content:  chunk,
-				MetaData: lcDocument.MetaData,
+			lcChunks = append(lcChunks, &schema.Document{
+				Content:  chunk.Content,
+				MetaData: map[string]any{"heading_path": chunk.HeadingPath},
 			})
 		}
+		_ = progressBar.AddN(1)
 	}
+	progressBar.finish()
 
-	// 4. 获取对应文档下得到最大片段位置
-	maxPosition, err := s.repo.GetMaxSegmentPosition(ctx, document.ID)
-	if err != nil {
-		maxPosition = 0
+	return lcChunks, nil
+}
+
+// createSegments 将分割得到的片段存储到postgres数据库中，positions为每个片段的位置，
+// 父子分段模式下存储父片段并将其切分为子片段，子片段排在所有父片段之后，
+// 返回需要建立索引的片段列表以及这些片段的token总数
+func (s *IndexingService) createSegments(ctx context.Context, document *entity.Document, processRule *entity.ProcessRule,
+	lcChunks []*schema.Document, positions []int) ([]*schema.Document, int, error) {
+	// 1. 父子分段模式下存储父片段，并将父片段切分为子片段，后续只有子片段建立索引
+	lcSegments := lcChunks
+	parentIDs := make([]uuid.UUID, len(lcChunks))
+	if consts.ProcessType(processRule.Mode) == consts.ProcessTypeHierarchical {
+		lengthFunction, embedFunction, err := s.splitterFunctions(ctx, document)
+		if err != nil {
+			return nil, 0, err
+		}
+		childSplitter, err := s.processRuleService.GetChildTextSplitterByProcessRule(
+			ctx,
+			processRule,
+			lengthFunction,
+			embedFunction,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		childSplitterKey := s.processRuleService.GetChildSplitterKeyByProcessRule(processRule)
+		lcSegments, parentIDs, err = s.splittingChildren(ctx, document, childSplitter, childSplitterKey, lcChunks, positions)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// 子片段的位置排在所有父片段之后
+		childPosition := slices.Max(append([]int{0}, positions...))
+		positions = make([]int, len(lcSegments))
+		for i := range positions {
+			childPosition++
+			positions[i] = childPosition
+		}
 	}
 
-	// 5. 循环处理片段数据并添加元数据，同时存储到postgres数据库中
-	var segments []*entity.Segment
-	for _, lcSegment := range lcSegments {
-		maxPosition++
-		content := lcSegment.Content
+	// 2. 循环处理片段数据并添加元数据，同时存储到postgres数据库中
+	progressBar := s.stageProgressBar(ctx, document.ID, len(lcSegments), progressChunked, progressSplit)
+	defer progressBar.finish()
+	var totalTokenCount int
+	for i, lcSegment := range lcSegments {
+		// 标题路径拼接在片段内容之前，向量与全文检索都能利用章节上下文
+		headingPath, _ := lcSegment.MetaData["heading_path"].([]string)
+		content := processruleservice.TextChunk{Content: lcSegment.Content, HeadingPath: headingPath}.ContextualContent()
 		nodeID := uuid.New()
 
 		segment := &entity.Segment{
@@ -374,54 +993,146 @@ func (s *IndexingService) splitting(ctx context.Context, document *entity.Docume
 			DatasetID:      document.DatasetID,
 			DocumentID:     document.ID,
 			NodeID:         nodeID,
-			Position:       maxPosition,
+			ParentID:       parentIDs[i],
+			Position:       positions[i],
 			Content:        content,
 			CharacterCount: len(content),
 			TokenCount:     s.embeddingsService.CalculateTokenCount(content),
+			HeadingPath:    headingPath,
 			Hash:           util.GenerateHash(content),
 			Status:         consts.SegmentStatusWaiting,
+			Metadata:       document.Metadata,
 		}
 
-		err = s.repo.CreateSegment(ctx, segment)
+		err := s.repo.CreateSegment(ctx, segment)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
-		lcSegment.MetaData = map[string]any{
-			"account_id":       document.AccountID.String(),
-			"dataset_id":       document.DatasetID.String(),
-			"document_id":      document.ID.String(),
-			"segment_id":       segment.ID.String(),
-			"node_id":          nodeID.String(),
-			"document_enabled": false,
-			"segment_enabled":  false,
+		lcSegments[i] = &schema.Document{
+			ID:      nodeID.String(),
+			Content: content,
+			MetaData: map[string]any{
+				"account_id":       document.AccountID.String(),
+				"dataset_id":       document.DatasetID.String(),
+				"document_id":      document.ID.String(),
+				"segment_id":       segment.ID.String(),
+				"node_id":          nodeID.String(),
+				"document_enabled": false,
+				"segment_enabled":  false,
+				"heading_path":     headingPath,
+				"metadata":         document.Metadata,
+			},
 		}
-
-		segments = append(segments, segment)
+		totalTokenCount += segment.TokenCount
+		_ = progressBar.AddN(1)
 	}
 
-	// 6. 更新文档的数据，涵盖状态、token数等内容
-	var totalTokenCount int
-	for _, segment := range segments {
-		totalTokenCount += segment.TokenCount
+	return lcSegments, totalTokenCount, nil
+}
+
+// splittingChildren 存储父片段并将其切分为子片段，父片段只用于返回上下文，不提取关键词也不写入向量数据库，
+// positions为每个父片段的位置，父片段的hash包含childSplitterKey，返回子片段列表以及每个子片段对应的父片段id
+func (s *IndexingService) splittingChildren(
+	ctx context.Context,
+	document *entity.Document,
+	childSplitter processruleservice.TextSplitter,
+	childSplitterKey string,
+	lcParents []*schema.Document,
+	positions []int,
+) ([]*schema.Document, []uuid.UUID, error) {
+	var lcChildren []*schema.Document
+	var parentIDs []uuid.UUID
+	for i, lcParent := range lcParents {
+		// 1. 父片段直接标记为完成，是否参与检索由子片段的状态决定
+		headingPath, _ := lcParent.MetaData["heading_path"].([]string)
+		content := processruleservice.TextChunk{Content: lcParent.Content, HeadingPath: headingPath}.ContextualContent()
+		now := time.Now().UnixMilli()
+		parent := &entity.Segment{
+			ID:             uuid.New(),
+			AccountID:      document.AccountID,
+			DatasetID:      document.DatasetID,
+			DocumentID:     document.ID,
+			Position:       positions[i],
+			Content:        content,
+			CharacterCount: len(content),
+			TokenCount:     s.embeddingsService.CalculateTokenCount(content),
+			HeadingPath:    headingPath,
+			Hash:           segmentHash(content, childSplitterKey),
+			Enabled:        true,
+			Status:         consts.SegmentStatusCompleted,
+			CompletedAt:    now,
+			Metadata:       document.Metadata,
+		}
+		if err := s.repo.CreateSegment(ctx, parent); err != nil {
+			return nil, nil, err
+		}
+
+		// 2. 将父片段切分为子片段，子片段的标题路径以父片段的标题路径为前缀
+		chunks, err := childSplitter.SplitChunks(lcParent.Content)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, chunk := range chunks {
+			lcChildren = append(lcChildren, &schema.Document{
+				Content:  chunk.Content,
+				MetaData: map[string]any{"heading_path": slices.Concat(headingPath, chunk.HeadingPath)},
+			})
+			parentIDs = append(parentIDs, parent.ID)
+		}
 	}
 
-	now := time.Now().UnixMilli()
-	err = s.repo.UpdateDocument(ctx, document.ID, map[string]any{
-		"token_count":            totalTokenCount,
-		"status":                 consts.DocumentStatusIndexing,
-		"splitting_completed_at": &now,
-	})
+	return lcChildren, parentIDs, nil
+}
+
+// splitterFunctions 获取文本分割器使用的长度计算函数与向量化函数，
+// 长度按知识库向量模型的分词器计算，向量化使用知识库固定的向量模型
+func (s *IndexingService) splitterFunctions(ctx context.Context, document *entity.Document) (func(string) int, processruleservice.EmbedFunction, error) {
+	dataset, err := s.repo.GetDatasetByID(ctx, document.DatasetID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return lcSegments, nil
+	lengthFunction := s.embeddingsService.CalculateTokenCount
+	if dataset.EmbeddingModel != "" {
+		lengthFunction = llmentity.NewTokenizer(dataset.EmbeddingProvider, dataset.EmbeddingModel).CountTokens
+	}
+
+	// 只有语义分割器会调用向量化函数，向量模型在首次调用时加载
+	embedFunction := func(texts []string) ([][]float64, error) {
+		embedder, err := s.llmService.LoadDatasetEmbedder(ctx, dataset)
+		if err != nil {
+			return nil, fmt.Errorf("加载知识库向量模型失败: %w", err)
+		}
+		if embedder != nil {
+			return embedder.EmbedStrings(ctx, texts)
+		}
+
+		embeddings, err := s.embeddingsService.EmbedTexts(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		result := make([][]float64, len(embeddings))
+		for i, embedding := range embeddings {
+			result[i] = make([]float64, len(embedding))
+			for j, v := range embedding {
+				result[i][j] = float64(v)
+			}
+		}
+		return result, nil
+	}
+
+	return lengthFunction, embedFunction, nil
 }
 
-// indexing 根据传递的信息构建索引，涵盖关键词提取、词表构建
+// indexing 根据传递的信息构建索引，涵盖关键词提取、倒排索引构建
 func (s *IndexingService) indexing(ctx context.Context, document *entity.Document, lcSegments []*schema.Document) error {
+	progressBar := s.stageProgressBar(ctx, document.ID, len(lcSegments), progressSplit, progressIndexed)
+	defer progressBar.finish()
+	segmentIDs := make([]uuid.UUID, 0, len(lcSegments))
 	for _, lcSegment := range lcSegments {
+		_ = progressBar.AddN(1)
+
 		// 1. 提取每一个片段对应的关键词，关键词的数量最多不超过10个
 		keywords := s.jiebaService.ExtractKeywords(lcSegment.Content, 10)
 
@@ -439,48 +1150,15 @@ func (s *IndexingService) indexing(ctx context.Context, document *entity.Documen
 			logs.Errorf("Failed to update segment keywords: %v", err)
 			continue
 		}
+		segmentIDs = append(segmentIDs, segmentID)
+	}
 
-		// 3. 获取当前知识库的关键词表
-		keywordTableRecord, err := s.keywordTableService.GetKeywordByDateSet(ctx, document.DatasetID)
-		if err != nil {
-			logs.Errorf("Failed to get keyword table: %v", err)
-			continue
-		}
-
-		// 4. 解析关键词表
-		keywordTable := keywordTableRecord.KeywordMap
-		// 5. 循环将新关键词添加到关键词表中
-		for _, keyword := range keywords {
-			if _, exists := keywordTable[keyword]; !exists {
-				keywordTable[keyword] = []string{}
-			}
-
-			// 检查是否已存在该片段ID
-			segmentIDStr := lcSegment.MetaData["segment_id"].(string)
-			found := false
-			for _, existingID := range keywordTable[keyword] {
-				if existingID == segmentIDStr {
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				keywordTable[keyword] = append(keywordTable[keyword], segmentIDStr)
-			}
-		}
-
-		// 6. 更新关键词表
-		updatedKeywordTableJSON, _ := sonic.Marshal(keywordTable)
-		err = s.repo.UpdateKeywordTable(ctx, keywordTableRecord.ID, map[string]any{
-			"keyword_table": string(updatedKeywordTableJSON),
-		})
-		if err != nil {
-			logs.Errorf("Failed to update keyword table: %v", err)
-		}
+	// 3. 将片段批量写入知识库的倒排索引
+	if err := s.keywordTableService.AddKeywords(ctx, document.DatasetID, segmentIDs); err != nil {
+		logs.Errorf("Failed to add segments to inverted index: %v", err)
 	}
 
-	// 7. 更新文档状态
+	// 4. 更新文档状态
 	now := time.Now().UnixMilli()
 	return s.repo.UpdateDocument(ctx, document.ID, map[string]any{
 		"indexing_completed_at": &now,
@@ -495,8 +1173,26 @@ func (s *IndexingService) completed(ctx context.Context, document *entity.Docume
 		lcSegment.MetaData["segment_enabled"] = true
 	}
 
-	// 2. 调用向量数据库，每次存储10条数据，避免一次传递过多的数据
+	// 2. 加载知识库固定的向量模型，向量需与检索时使用同一个模型生成
+	dataset, err := s.repo.GetDatasetByID(ctx, document.DatasetID)
+	if err != nil {
+		return err
+	}
+	embedder, err := s.llmService.LoadDatasetEmbedder(ctx, dataset)
+	if err != nil {
+		return fmt.Errorf("加载知识库向量模型失败: %w", err)
+	}
+	var storeOpts []indexer.Option
+	if embedder != nil {
+		storeOpts = append(storeOpts, vecstore.WithIndexerEmbedding(embedder))
+	}
+
+	// 3. 调用向量数据库，每次存储10条数据，避免一次传递过多的数据，向量数据库每向量化并写入一批记录会推进文档的构建进度，
+	// 写入失败的批次不会推进进度，所有批次处理完成后补齐
+	progressBar := s.stageProgressBar(ctx, document.ID, len(lcSegments), progressIndexed, documentProgressTotal)
+	storeOpts = append(storeOpts, vecstore.WithProgressBar(progressBar))
 	batchSize := 10
+	var lastErr string
 	for i := 0; i < len(lcSegments); i += batchSize {
 		end := i + batchSize
 		if end > len(lcSegments) {
@@ -508,16 +1204,22 @@ func (s *IndexingService) completed(ctx context.Context, document *entity.Docume
 		var documents []*schema.Document
 
 		for _, chunk := range chunks {
+			if nodeID, err := uuid.Parse(fmt.Sprint(chunk.MetaData["node_id"])); err == nil {
+				nodeIDs = append(nodeIDs, nodeID)
+			}
+			// 向量记录ID由片段确定，删除片段时才能找到对应的记录
 			documents = append(documents, &schema.Document{
+				ID:       chunk.ID,
 				Content:  chunk.Content,
 				MetaData: chunk.MetaData,
 			})
 		}
 
 		// 存储到向量数据库
-		_, err := s.vectorStore.Store(ctx, documents)
+		_, err := s.vectorStore.Store(ctx, documents, storeOpts...)
 		if err != nil {
 			logs.Errorf("Failed to build document segment index: %v", err)
+			lastErr = err.Error()
 
 			// 更新片段状态为错误
 			now := time.Now().UnixMilli()
@@ -543,12 +1245,14 @@ func (s *IndexingService) completed(ctx context.Context, document *entity.Docume
 		}
 	}
 
-	// 3. 更新文档的状态数据
+	// 4. 更新文档的状态数据，部分片段写入失败时文档仍然完成，并记录最近一次的错误信息
+	progressBar.finish()
 	now := time.Now().UnixMilli()
 	return s.repo.UpdateDocument(ctx, document.ID, map[string]any{
 		"status":       consts.DocumentStatusCompleted,
 		"completed_at": &now,
 		"enabled":      true,
+		"error":        lastErr,
 	})
 }
 
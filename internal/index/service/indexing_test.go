@@ -0,0 +1,133 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+func testSegment(content string, position int, parentID uuid.UUID, indexed bool) *entity.Segment {
+	segment := &entity.Segment{
+		ID:       uuid.New(),
+		ParentID: parentID,
+		Position: position,
+		Content:  content,
+		Hash:     segmentHash(content, ""),
+		Status:   consts.SegmentStatusCompleted,
+	}
+	if indexed {
+		segment.NodeID = uuid.New()
+	}
+	return segment
+}
+
+func segmentIDs(segments []*entity.Segment) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(segments))
+	for _, segment := range segments {
+		ids = append(ids, segment.ID)
+	}
+	return ids
+}
+
+func hashesOf(childSplitterKey string, contents ...string) []string {
+	hashes := make([]string, 0, len(contents))
+	for _, content := range contents {
+		hashes = append(hashes, segmentHash(content, childSplitterKey))
+	}
+	return hashes
+}
+
+func TestDiffSegments(t *testing.T) {
+	t.Run("keeps unchanged, adds new and removes missing segments", func(t *testing.T) {
+		a := testSegment("a", 1, uuid.Nil, true)
+		b := testSegment("b", 2, uuid.Nil, true)
+		c := testSegment("c", 3, uuid.Nil, true)
+
+		diff := diffSegments([]*entity.Segment{a, b, c}, hashesOf("", "a", "new", "c"), false)
+
+		assert.Equal(t, map[uuid.UUID]struct{}{a.ID: {}, c.ID: {}}, diff.kept)
+		assert.Empty(t, diff.moved)
+		assert.Equal(t, []int{1}, diff.added)
+		assert.Equal(t, []uuid.UUID{b.ID}, segmentIDs(diff.removed))
+	})
+
+	t.Run("moved segments get their new position", func(t *testing.T) {
+		a := testSegment("a", 1, uuid.Nil, true)
+		b := testSegment("b", 2, uuid.Nil, true)
+
+		diff := diffSegments([]*entity.Segment{a, b}, hashesOf("", "intro", "b", "a"), false)
+
+		assert.Equal(t, map[uuid.UUID]int{a.ID: 3}, diff.moved)
+		assert.Equal(t, []int{0}, diff.added)
+		assert.Empty(t, diff.removed)
+	})
+
+	t.Run("duplicate contents are reused one by one", func(t *testing.T) {
+		first := testSegment("same", 1, uuid.Nil, true)
+		second := testSegment("same", 2, uuid.Nil, true)
+
+		diff := diffSegments([]*entity.Segment{first, second}, hashesOf("", "same", "same", "same"), false)
+
+		assert.Len(t, diff.kept, 2)
+		assert.Equal(t, []int{2}, diff.added)
+		assert.Empty(t, diff.removed)
+	})
+
+	t.Run("unfinished segments are rebuilt", func(t *testing.T) {
+		failed := testSegment("a", 1, uuid.Nil, true)
+		failed.Status = consts.SegmentStatusError
+
+		diff := diffSegments([]*entity.Segment{failed}, hashesOf("", "a"), false)
+
+		assert.Empty(t, diff.kept)
+		assert.Equal(t, []int{0}, diff.added)
+		assert.Equal(t, []uuid.UUID{failed.ID}, segmentIDs(diff.removed))
+	})
+
+	t.Run("hierarchical mode keeps children of kept parents", func(t *testing.T) {
+		key := "child-config"
+		kept := testSegment("parent a", 1, uuid.Nil, false)
+		kept.Hash = segmentHash("parent a", key)
+		removed := testSegment("parent b", 2, uuid.Nil, false)
+		removed.Hash = segmentHash("parent b", key)
+		keptChild := testSegment("child a", 3, kept.ID, true)
+		removedChild := testSegment("child b", 4, removed.ID, true)
+
+		diff := diffSegments([]*entity.Segment{kept, removed, keptChild, removedChild}, hashesOf(key, "parent a", "parent c"), true)
+
+		assert.Equal(t, map[uuid.UUID]struct{}{kept.ID: {}}, diff.kept)
+		assert.Equal(t, []int{1}, diff.added)
+		assert.ElementsMatch(t, []uuid.UUID{removed.ID, removedChild.ID}, segmentIDs(diff.removed))
+	})
+
+	t.Run("changed child splitter config re-splits every parent", func(t *testing.T) {
+		parent := testSegment("parent", 1, uuid.Nil, false)
+		parent.Hash = segmentHash("parent", "old-child-config")
+		child := testSegment("child", 2, parent.ID, true)
+
+		diff := diffSegments([]*entity.Segment{parent, child}, hashesOf("new-child-config", "parent"), true)
+
+		assert.Empty(t, diff.kept)
+		assert.Equal(t, []int{0}, diff.added)
+		assert.ElementsMatch(t, []uuid.UUID{parent.ID, child.ID}, segmentIDs(diff.removed))
+	})
+
+	t.Run("changed process mode reuses nothing", func(t *testing.T) {
+		flat := testSegment("a", 1, uuid.Nil, true)
+
+		diff := diffSegments([]*entity.Segment{flat}, hashesOf("", "a"), true)
+
+		assert.Empty(t, diff.kept)
+		assert.Equal(t, []uuid.UUID{flat.ID}, segmentIDs(diff.removed))
+	})
+}
+
+func TestSegmentHash(t *testing.T) {
+	assert.Equal(t, segmentHash("content", ""), segmentHash("content", ""))
+	assert.NotEqual(t, segmentHash("content", ""), segmentHash("content", "child"))
+	assert.NotEqual(t, segmentHash("content", "child a"), segmentHash("content", "child b"))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document/progressbar"
+	progressbarimpl "github.com/crazyfrankie/voidx/infra/impl/document/progressbar"
+)
+
+// 文档构建进度条的总量固定为documentProgressTotal，开始构建时即可确定，各阶段按固定比例推进，
+// 以下为解析、分割、片段存储与关键词索引完成时进度条所在的位置，剩余部分随向量化与写入向量数据库推进
+const (
+	documentProgressTotal = 1000
+	progressParsed        = 100
+	progressChunked       = 200
+	progressSplit         = 300
+	progressIndexed       = 400
+)
+
+// stageProgressBar 将阶段内的处理数量按比例换算为文档进度条的推进量，阶段处理完成时恰好推进units
+type stageProgressBar struct {
+	progressbar.ProgressBar
+	total    int
+	units    int
+	done     int
+	reported int
+}
+
+// progressBar 获取文档的构建进度条，needInit为true时重置已记录的进度与错误并设置总量
+func (s *IndexingService) progressBar(ctx context.Context, documentID uuid.UUID, needInit bool) progressbar.ProgressBar {
+	return progressbarimpl.NewProgressBar(ctx, documentID.String(), documentProgressTotal, s.cacheClient, needInit)
+}
+
+// stageProgressBar 获取文档构建中从from推进到to的阶段进度条，total为该阶段需要处理的数量
+func (s *IndexingService) stageProgressBar(ctx context.Context, documentID uuid.UUID, total, from, to int) *stageProgressBar {
+	return &stageProgressBar{
+		ProgressBar: s.progressBar(ctx, documentID, false),
+		total:       total,
+		units:       to - from,
+	}
+}
+
+func (p *stageProgressBar) AddN(n int) error {
+	p.done = min(p.done+n, p.total)
+	target := p.units
+	if p.total > 0 {
+		target = p.units * p.done / p.total
+	}
+	delta := target - p.reported
+	if delta <= 0 {
+		return nil
+	}
+	p.reported = target
+
+	return p.ProgressBar.AddN(delta)
+}
+
+// finish 补齐阶段内未推进的部分，处理数量为0或部分处理失败时进度仍能到达阶段终点
+func (p *stageProgressBar) finish() {
+	_ = p.AddN(p.total)
+}
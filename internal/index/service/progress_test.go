@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingProgressBar 记录每次推进的数量
+type recordingProgressBar struct {
+	added []int
+	err   error
+}
+
+func (p *recordingProgressBar) AddN(n int) error {
+	p.added = append(p.added, n)
+	return nil
+}
+
+func (p *recordingProgressBar) ReportError(err error) error {
+	p.err = err
+	return nil
+}
+
+func (p *recordingProgressBar) GetProgress(context.Context) (int, int, string) {
+	return 0, 0, ""
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+func TestStageProgressBar(t *testing.T) {
+	t.Run("scales processed items to stage units", func(t *testing.T) {
+		base := &recordingProgressBar{}
+		bar := &stageProgressBar{ProgressBar: base, total: 3, units: 600}
+
+		assert.NoError(t, bar.AddN(1))
+		assert.NoError(t, bar.AddN(1))
+		assert.NoError(t, bar.AddN(1))
+		assert.Equal(t, []int{200, 200, 200}, base.added)
+
+		// 超出阶段数量的推进会被忽略
+		assert.NoError(t, bar.AddN(5))
+		bar.finish()
+		assert.Equal(t, 600, sum(base.added))
+	})
+
+	t.Run("small steps accumulate without losing units", func(t *testing.T) {
+		base := &recordingProgressBar{}
+		bar := &stageProgressBar{ProgressBar: base, total: 1000, units: 100}
+
+		for range 1000 {
+			assert.NoError(t, bar.AddN(1))
+		}
+		assert.Len(t, base.added, 100)
+		assert.Equal(t, 100, sum(base.added))
+	})
+
+	t.Run("finish fills skipped and failed items", func(t *testing.T) {
+		base := &recordingProgressBar{}
+		bar := &stageProgressBar{ProgressBar: base, total: 4, units: 400}
+
+		assert.NoError(t, bar.AddN(1))
+		assert.NoError(t, bar.ReportError(errors.New("batch failed")))
+		assert.NoError(t, bar.AddN(1))
+		bar.finish()
+		assert.Equal(t, []int{100, 100, 200}, base.added)
+		assert.EqualError(t, base.err, "batch failed")
+	})
+
+	t.Run("empty stage advances on finish", func(t *testing.T) {
+		base := &recordingProgressBar{}
+		bar := &stageProgressBar{ProgressBar: base, units: 100}
+
+		bar.finish()
+		bar.finish()
+		assert.Equal(t, []int{100}, base.added)
+	})
+}
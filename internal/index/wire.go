@@ -3,15 +3,20 @@
 package index
 
 import (
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
 	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	"github.com/crazyfrankie/voidx/internal/core/crawler"
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/file_extractor"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/index/repository"
 	"github.com/crazyfrankie/voidx/internal/index/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/index/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/process_rule"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/upload"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -23,17 +28,21 @@ type IndexModule struct {
 	Service *Service
 }
 
-func InitIndexModule(db *gorm.DB, cmd redis.Cmdable, fileExtractor *file_extractor.FileExtractor,
+func InitIndexModule(db *gorm.DB, cmd redis.Cmdable, cacheClient cache.Cmdable, fileExtractor *file_extractor.FileExtractor,
 	embeddingsSvc *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, processRuleService *process_rule.ProcessRuleModule,
-	keywordSvc *retriever.RetrieverModule, vectorDatabaseService vecstore.SearchStore) *IndexModule {
+	keywordSvc *retriever.RetrieverModule, vectorDatabaseService vecstore.SearchStore, llmModule *llm.LLMModule, uploadModule *upload.UploadModule) *IndexModule {
 	wire.Build(
 		dao.NewIndexingDao,
 		repository.NewIndexingRepo,
+		table.NewStore,
+		crawler.NewCrawler,
 		service.NewIndexingService,
 
 		wire.Struct(new(IndexModule), "*"),
 		wire.FieldsOf(new(*retriever.RetrieverModule), "Keyword"),
 		wire.FieldsOf(new(*process_rule.ProcessRuleModule), "Service"),
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
+		wire.FieldsOf(new(*upload.UploadModule), "Service"),
 	)
 	return new(IndexModule)
 }
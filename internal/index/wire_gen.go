@@ -7,27 +7,36 @@
 package index
 
 import (
+	"github.com/crazyfrankie/voidx/infra/contract/cache"
 	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	"github.com/crazyfrankie/voidx/internal/core/crawler"
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/file_extractor"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/index/repository"
 	"github.com/crazyfrankie/voidx/internal/index/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/index/service"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/process_rule"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/upload"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitIndexModule(db *gorm.DB, cmd redis.Cmdable, fileExtractor *file_extractor.FileExtractor, embeddingsSvc *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, processRuleService *process_rule.ProcessRuleModule, keywordSvc *retriever.RetrieverModule, vectorDatabaseService vecstore.SearchStore) *IndexModule {
+func InitIndexModule(db *gorm.DB, cmd redis.Cmdable, cacheClient cache.Cmdable, fileExtractor *file_extractor.FileExtractor, embeddingsSvc *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, processRuleService *process_rule.ProcessRuleModule, keywordSvc *retriever.RetrieverModule, vectorDatabaseService vecstore.SearchStore, llmModule *llm.LLMModule, uploadModule *upload.UploadModule) *IndexModule {
 	indexingDao := dao.NewIndexingDao(db)
 	indexingRepo := repository.NewIndexingRepo(indexingDao)
 	serviceProcessRuleService := processRuleService.Service
 	keywordService := keywordSvc.KeyWord
-	indexingService := service.NewIndexingService(indexingRepo, cmd, fileExtractor, serviceProcessRuleService, embeddingsSvc, jiebaService, keywordService, vectorDatabaseService)
+	llmService := llmModule.Service
+	store := table.NewStore(db)
+	crawlerCrawler := crawler.NewCrawler()
+	ossService := uploadModule.Service
+	indexingService := service.NewIndexingService(indexingRepo, cmd, cacheClient, fileExtractor, serviceProcessRuleService, embeddingsSvc, jiebaService, keywordService, vectorDatabaseService, llmService, store, crawlerCrawler, ossService)
 	indexModule := &IndexModule{
 		Service: indexingService,
 	}
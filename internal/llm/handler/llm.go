@@ -4,9 +4,13 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/crazyfrankie/voidx/internal/base/response"
 	"github.com/crazyfrankie/voidx/internal/llm/service"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/errno"
 )
 
 type LLMHandler struct {
@@ -23,15 +27,38 @@ func (h *LLMHandler) RegisterRoute(r *gin.RouterGroup) {
 	llmGroup := r.Group("llms")
 	{
 		llmGroup.GET("", h.GetProviders())
+		llmGroup.GET("/embedding-models", h.GetEmbeddingModels())
 		llmGroup.GET("/:provider/icon", h.GetProviderIcon())
 		llmGroup.GET("/:provider/:model", h.GetModelEntity())
 	}
+
+	modelProviderGroup := r.Group("model-providers")
+	{
+		modelProviderGroup.GET("", h.GetModelProviders())
+		modelProviderGroup.POST("", h.CreateModelProvider())
+		modelProviderGroup.GET("/:provider_id", h.GetModelProvider())
+		modelProviderGroup.PUT("/:provider_id", h.UpdateModelProvider())
+		modelProviderGroup.DELETE("/:provider_id", h.DeleteModelProvider())
+	}
+
+	credentialGroup := r.Group("provider-credentials")
+	{
+		credentialGroup.GET("", h.GetProviderCredentials())
+		credentialGroup.PUT("/:provider", h.SaveProviderCredential())
+		credentialGroup.DELETE("/:provider", h.DeleteProviderCredential())
+	}
 }
 
 // GetProviders 获取所有模型提供商
 func (h *LLMHandler) GetProviders() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		providers, err := h.llmService.GetProviders(c.Request.Context())
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		providers, err := h.llmService.GetProviders(c.Request.Context(), userID)
 		if err != nil {
 			response.InternalServerErrorResponse(c, err)
 			return
@@ -41,6 +68,13 @@ func (h *LLMHandler) GetProviders() gin.HandlerFunc {
 	}
 }
 
+// GetEmbeddingModels 获取所有向量模型，用于创建知识库时选择
+func (h *LLMHandler) GetEmbeddingModels() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.Data(c, h.llmService.GetEmbeddingModels(c.Request.Context()))
+	}
+}
+
 // GetModelEntity 获取模型实体信息
 func (h *LLMHandler) GetModelEntity() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -73,3 +107,187 @@ func (h *LLMHandler) GetProviderIcon() gin.HandlerFunc {
 		c.Data(http.StatusOK, mimeType, iconData)
 	}
 }
+
+// GetModelProviders 获取当前账号接入的模型服务提供商列表
+func (h *LLMHandler) GetModelProviders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		modelProviders, err := h.llmService.GetModelProviders(c.Request.Context(), userID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, modelProviders)
+	}
+}
+
+// CreateModelProvider 接入OpenAI兼容的模型服务提供商
+func (h *LLMHandler) CreateModelProvider() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var createReq req.CreateModelProviderReq
+		if err := c.ShouldBindJSON(&createReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		modelProvider, err := h.llmService.CreateModelProvider(c.Request.Context(), userID, createReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, modelProvider)
+	}
+}
+
+// GetModelProvider 获取模型服务提供商详情
+func (h *LLMHandler) GetModelProvider() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		providerID, err := uuid.Parse(c.Param("provider_id"))
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		modelProvider, err := h.llmService.GetModelProvider(c.Request.Context(), userID, providerID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, modelProvider)
+	}
+}
+
+// UpdateModelProvider 更新模型服务提供商
+func (h *LLMHandler) UpdateModelProvider() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		providerID, err := uuid.Parse(c.Param("provider_id"))
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		var updateReq req.UpdateModelProviderReq
+		if err := c.ShouldBindJSON(&updateReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		if err := h.llmService.UpdateModelProvider(c.Request.Context(), userID, providerID, updateReq); err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
+// DeleteModelProvider 删除模型服务提供商
+func (h *LLMHandler) DeleteModelProvider() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		providerID, err := uuid.Parse(c.Param("provider_id"))
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		if err := h.llmService.DeleteModelProvider(c.Request.Context(), userID, providerID); err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
+// GetProviderCredentials 获取当前账号配置的提供商凭证列表
+func (h *LLMHandler) GetProviderCredentials() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		credentials, err := h.llmService.GetProviderCredentials(c.Request.Context(), userID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, credentials)
+	}
+}
+
+// SaveProviderCredential 保存内置模型服务提供商的凭证
+func (h *LLMHandler) SaveProviderCredential() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var saveReq req.SaveProviderCredentialReq
+		if err := c.ShouldBindJSON(&saveReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		if err := h.llmService.SaveProviderCredential(c.Request.Context(), userID, c.Param("provider"), saveReq); err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
+// DeleteProviderCredential 删除内置模型服务提供商的凭证
+func (h *LLMHandler) DeleteProviderCredential() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		if err := h.llmService.DeleteProviderCredential(c.Request.Context(), userID, c.Param("provider")); err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
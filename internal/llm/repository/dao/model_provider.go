@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+type ModelProviderDao struct {
+	db *gorm.DB
+}
+
+func NewModelProviderDao(db *gorm.DB) *ModelProviderDao {
+	return &ModelProviderDao{db: db}
+}
+
+// CreateModelProvider 创建模型服务提供商
+func (d *ModelProviderDao) CreateModelProvider(ctx context.Context, modelProvider *entity.ModelProvider) error {
+	return d.db.WithContext(ctx).Create(modelProvider).Error
+}
+
+// GetModelProviderByID 根据ID获取模型服务提供商
+func (d *ModelProviderDao) GetModelProviderByID(ctx context.Context, id uuid.UUID) (*entity.ModelProvider, error) {
+	var modelProvider entity.ModelProvider
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&modelProvider).Error
+	if err != nil {
+		return nil, err
+	}
+	return &modelProvider, nil
+}
+
+// GetModelProvidersByAccountID 获取账号下的所有模型服务提供商
+func (d *ModelProviderDao) GetModelProvidersByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.ModelProvider, error) {
+	var modelProviders []entity.ModelProvider
+	err := d.db.WithContext(ctx).
+		Where("account_id = ?", accountID).
+		Order("ctime DESC").
+		Find(&modelProviders).Error
+	return modelProviders, err
+}
+
+// GetModelProviderByName 获取账号下指定名字的模型服务提供商
+func (d *ModelProviderDao) GetModelProviderByName(ctx context.Context, accountID uuid.UUID, name string) (*entity.ModelProvider, error) {
+	var modelProvider entity.ModelProvider
+	err := d.db.WithContext(ctx).
+		Where("account_id = ? AND name = ?", accountID, name).
+		First(&modelProvider).Error
+	if err != nil {
+		return nil, err
+	}
+	return &modelProvider, nil
+}
+
+// UpdateModelProvider 更新模型服务提供商
+func (d *ModelProviderDao) UpdateModelProvider(ctx context.Context, modelProvider *entity.ModelProvider) error {
+	return d.db.WithContext(ctx).Save(modelProvider).Error
+}
+
+// DeleteModelProvider 删除模型服务提供商
+func (d *ModelProviderDao) DeleteModelProvider(ctx context.Context, id uuid.UUID) error {
+	return d.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.ModelProvider{}).Error
+}
+
+// GetModelProvidersWithAPIKey 获取所有配置了密钥的模型服务提供商
+func (d *ModelProviderDao) GetModelProvidersWithAPIKey(ctx context.Context) ([]entity.ModelProvider, error) {
+	var modelProviders []entity.ModelProvider
+	err := d.db.WithContext(ctx).Where("api_key <> ''").Find(&modelProviders).Error
+	return modelProviders, err
+}
+
+// UpdateModelProviderAPIKey 更新模型服务提供商的密钥
+func (d *ModelProviderDao) UpdateModelProviderAPIKey(ctx context.Context, id uuid.UUID, apiKey string) error {
+	return d.db.WithContext(ctx).Model(&entity.ModelProvider{}).
+		Where("id = ?", id).
+		Update("api_key", apiKey).Error
+}
+
+// GetProviderCredential 获取账号为指定提供商配置的凭证
+func (d *ModelProviderDao) GetProviderCredential(ctx context.Context, accountID uuid.UUID, provider string) (*entity.ProviderCredential, error) {
+	var credential entity.ProviderCredential
+	err := d.db.WithContext(ctx).
+		Where("account_id = ? AND provider = ?", accountID, provider).
+		First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// GetProviderCredentialsByAccountID 获取账号配置的所有提供商凭证
+func (d *ModelProviderDao) GetProviderCredentialsByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.ProviderCredential, error) {
+	var credentials []entity.ProviderCredential
+	err := d.db.WithContext(ctx).
+		Where("account_id = ?", accountID).
+		Order("ctime DESC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// SaveProviderCredential 创建或更新提供商凭证
+func (d *ModelProviderDao) SaveProviderCredential(ctx context.Context, credential *entity.ProviderCredential) error {
+	return d.db.WithContext(ctx).Save(credential).Error
+}
+
+// DeleteProviderCredential 删除账号为指定提供商配置的凭证
+func (d *ModelProviderDao) DeleteProviderCredential(ctx context.Context, accountID uuid.UUID, provider string) error {
+	return d.db.WithContext(ctx).
+		Where("account_id = ? AND provider = ?", accountID, provider).
+		Delete(&entity.ProviderCredential{}).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/llm/repository/dao"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
+
+type ModelProviderRepo struct {
+	dao *dao.ModelProviderDao
+}
+
+func NewModelProviderRepo(d *dao.ModelProviderDao) *ModelProviderRepo {
+	return &ModelProviderRepo{dao: d}
+}
+
+// CreateModelProvider 创建模型服务提供商
+func (r *ModelProviderRepo) CreateModelProvider(ctx context.Context, modelProvider *entity.ModelProvider) error {
+	return r.dao.CreateModelProvider(ctx, modelProvider)
+}
+
+// GetModelProviderByID 根据ID获取模型服务提供商
+func (r *ModelProviderRepo) GetModelProviderByID(ctx context.Context, id uuid.UUID) (*entity.ModelProvider, error) {
+	return r.dao.GetModelProviderByID(ctx, id)
+}
+
+// GetModelProvidersByAccountID 获取账号下的所有模型服务提供商
+func (r *ModelProviderRepo) GetModelProvidersByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.ModelProvider, error) {
+	return r.dao.GetModelProvidersByAccountID(ctx, accountID)
+}
+
+// GetModelProviderByName 获取账号下指定名字的模型服务提供商
+func (r *ModelProviderRepo) GetModelProviderByName(ctx context.Context, accountID uuid.UUID, name string) (*entity.ModelProvider, error) {
+	return r.dao.GetModelProviderByName(ctx, accountID, name)
+}
+
+// UpdateModelProvider 更新模型服务提供商
+func (r *ModelProviderRepo) UpdateModelProvider(ctx context.Context, modelProvider *entity.ModelProvider) error {
+	return r.dao.UpdateModelProvider(ctx, modelProvider)
+}
+
+// DeleteModelProvider 删除模型服务提供商
+func (r *ModelProviderRepo) DeleteModelProvider(ctx context.Context, id uuid.UUID) error {
+	return r.dao.DeleteModelProvider(ctx, id)
+}
+
+// GetModelProvidersWithAPIKey 获取所有配置了密钥的模型服务提供商
+func (r *ModelProviderRepo) GetModelProvidersWithAPIKey(ctx context.Context) ([]entity.ModelProvider, error) {
+	return r.dao.GetModelProvidersWithAPIKey(ctx)
+}
+
+// UpdateModelProviderAPIKey 更新模型服务提供商的密钥
+func (r *ModelProviderRepo) UpdateModelProviderAPIKey(ctx context.Context, id uuid.UUID, apiKey string) error {
+	return r.dao.UpdateModelProviderAPIKey(ctx, id, apiKey)
+}
+
+// GetProviderCredential 获取账号为指定提供商配置的凭证
+func (r *ModelProviderRepo) GetProviderCredential(ctx context.Context, accountID uuid.UUID, provider string) (*entity.ProviderCredential, error) {
+	return r.dao.GetProviderCredential(ctx, accountID, provider)
+}
+
+// GetProviderCredentialsByAccountID 获取账号配置的所有提供商凭证
+func (r *ModelProviderRepo) GetProviderCredentialsByAccountID(ctx context.Context, accountID uuid.UUID) ([]entity.ProviderCredential, error) {
+	return r.dao.GetProviderCredentialsByAccountID(ctx, accountID)
+}
+
+// SaveProviderCredential 创建或更新提供商凭证
+func (r *ModelProviderRepo) SaveProviderCredential(ctx context.Context, credential *entity.ProviderCredential) error {
+	return r.dao.SaveProviderCredential(ctx, credential)
+}
+
+// DeleteProviderCredential 删除账号为指定提供商配置的凭证
+func (r *ModelProviderRepo) DeleteProviderCredential(ctx context.Context, accountID uuid.UUID, provider string) error {
+	return r.dao.DeleteProviderCredential(ctx, accountID, provider)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/conf"
+	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/internal/models/resp"
+	"github.com/crazyfrankie/voidx/pkg/lang/crypto"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+// systemCredentialEnvs 内置提供商的系统默认凭证对应的环境变量，依次为api_key与base_url
+var systemCredentialEnvs = map[string][2]string{
+	"openai":   {"OPENAI_API_KEY", "OPENAI_API_BASE"},
+	"deepseek": {"DEEPSEEK_API_KEY", "DEEPSEEK_API_BASE"},
+	"tongyi":   {"DASHSCOPE_API_KEY", "DASHSCOPE_API_BASE"},
+	"moonshot": {"MOONSHOT_API_KEY", ""},
+	"ollama":   {"", "OLLAMA_BASE_URL"},
+	// 自部署向量服务与重排序服务只需要服务地址
+	"http_embedding": {"", "EMBEDDING_HTTP_BASE_URL"},
+	"http_rerank":    {"", "RERANK_HTTP_BASE_URL"},
+}
+
+// SaveProviderCredential 保存账号为内置提供商配置的凭证，保存前会调用一次模型校验凭证是否可用
+func (s *LLMService) SaveProviderCredential(ctx context.Context, accountID uuid.UUID, providerName string, saveReq req.SaveProviderCredentialReq) error {
+	// 1. 只有内置提供商需要配置凭证，自定义提供商的凭证随提供商保存
+	pv, err := s.llmCore.GetProvider(providerName)
+	if err != nil || pv.Type == entities.ProviderTypeOpenAICompatible {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("该模型服务提供商不存在"))
+	}
+
+	// 2. 未传递api_key时沿用原有密钥
+	credential, err := s.repo.GetProviderCredential(ctx, accountID, providerName)
+	if err != nil {
+		credential = &entity.ProviderCredential{AccountID: accountID, Provider: providerName}
+	}
+	credentials := map[string]string{
+		"api_key":  saveReq.APIKey,
+		"base_url": strings.TrimRight(saveReq.BaseURL, "/"),
+	}
+	if credentials["api_key"] == "" && credential.Credentials != "" {
+		existing, err := decryptCredentials(credential.Credentials)
+		if err != nil {
+			return err
+		}
+		credentials["api_key"] = existing["api_key"]
+	}
+	if credentials["api_key"] == "" && systemCredentialEnvs[providerName][0] != "" {
+		return errno.ErrValidate.AppendBizMessage(errors.New("api_key不能为空"))
+	}
+
+	// 3. 调用模型校验凭证
+	if err := s.llmCore.ValidateCredentials(ctx, providerName, toCredentialConfig(credentials)); err != nil {
+		return errno.ErrValidate.AppendBizMessage(errors.New("凭证校验失败，请核实后重试"))
+	}
+
+	// 4. 加密后保存凭证
+	encrypted, err := encryptCredentials(credentials)
+	if err != nil {
+		return err
+	}
+	credential.Credentials = encrypted
+
+	return s.repo.SaveProviderCredential(ctx, credential)
+}
+
+// GetProviderCredentials 获取账号配置的所有提供商凭证
+func (s *LLMService) GetProviderCredentials(ctx context.Context, accountID uuid.UUID) ([]*resp.ProviderCredentialResp, error) {
+	credentials, err := s.repo.GetProviderCredentialsByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*resp.ProviderCredentialResp, 0, len(credentials))
+	for _, credential := range credentials {
+		decrypted, err := decryptCredentials(credential.Credentials)
+		if err != nil {
+			decrypted = map[string]string{}
+		}
+
+		res = append(res, &resp.ProviderCredentialResp{
+			Provider: credential.Provider,
+			APIKey:   maskAPIKey(decrypted["api_key"]),
+			BaseURL:  decrypted["base_url"],
+			Utime:    credential.Utime,
+			Ctime:    credential.Ctime,
+		})
+	}
+
+	return res, nil
+}
+
+// DeleteProviderCredential 删除账号为内置提供商配置的凭证
+func (s *LLMService) DeleteProviderCredential(ctx context.Context, accountID uuid.UUID, providerName string) error {
+	if _, err := s.repo.GetProviderCredential(ctx, accountID, providerName); err != nil {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("该提供商凭证不存在"))
+	}
+
+	return s.repo.DeleteProviderCredential(ctx, accountID, providerName)
+}
+
+// ResolveCredentials 解析账号使用提供商时的凭证，实现core中的CredentialResolver：
+// 自定义提供商只能由其所属账号使用，内置提供商优先使用账号自己的凭证，仅在允许时回退到系统默认凭证
+func (s *LLMService) ResolveCredentials(ctx context.Context, accountID uuid.UUID, providerName string) (map[string]any, error) {
+	// 1. 自定义提供商的凭证随提供商记录保存，校验归属后才返回
+	pv, err := s.llmCore.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	if pv.Type == entities.ProviderTypeOpenAICompatible {
+		providerID, _ := uuid.Parse(providerName)
+		modelProvider, err := s.getModelProvider(ctx, accountID, providerID)
+		if err != nil {
+			return nil, err
+		}
+		return modelProviderCredentials(modelProvider)
+	}
+
+	// 2. 账号配置了凭证时使用账号自己的凭证
+	credential, err := s.repo.GetProviderCredential(ctx, accountID, providerName)
+	if err == nil {
+		credentials, err := decryptCredentials(credential.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		return toCredentialConfig(credentials), nil
+	}
+
+	// 3. 未配置时仅在允许的情况下回退到系统默认凭证
+	if !conf.GetConf().LLM.AllowSystemCredentials {
+		return nil, errno.ErrForbidden.AppendBizMessage(errors.New("请先为该模型服务提供商配置凭证"))
+	}
+
+	return systemCredentials(providerName), nil
+}
+
+// systemCredentials 从环境变量读取内置提供商的系统默认凭证
+func systemCredentials(providerName string) map[string]any {
+	envs := systemCredentialEnvs[providerName]
+	credentials := make(map[string]string, 2)
+	if envs[0] != "" {
+		credentials["api_key"] = os.Getenv(envs[0])
+	}
+	if envs[1] != "" {
+		credentials["base_url"] = os.Getenv(envs[1])
+	}
+
+	return toCredentialConfig(credentials)
+}
+
+// toCredentialConfig 转换凭证为模型配置，空值不会覆盖工厂的默认配置
+func toCredentialConfig(credentials map[string]string) map[string]any {
+	config := make(map[string]any, len(credentials))
+	for k, v := range credentials {
+		if v != "" {
+			config[k] = v
+		}
+	}
+
+	return config
+}
+
+// encryptCredentials 序列化并加密凭证
+func encryptCredentials(credentials map[string]string) (string, error) {
+	data, err := sonic.Marshal(credentials)
+	if err != nil {
+		return "", err
+	}
+
+	return encryptSecret(string(data))
+}
+
+// decryptCredentials 解密并反序列化凭证
+func decryptCredentials(encrypted string) (map[string]string, error) {
+	data, err := decryptSecret(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials map[string]string
+	if err := sonic.UnmarshalString(data, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// encryptSecret 使用配置的凭证密钥加密，空值不加密
+func encryptSecret(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	encrypted, err := crypto.AESGCMEncrypt(conf.GetConf().LLM.CredentialSecret, plaintext)
+	if err != nil {
+		return "", errno.ErrInternalServer.AppendBizMessage(errors.New("凭证加密失败，请检查凭证密钥配置"))
+	}
+
+	return encrypted, nil
+}
+
+// decryptSecret 使用配置的凭证密钥解密
+func decryptSecret(encrypted string) (string, error) {
+	if encrypted == "" {
+		return "", nil
+	}
+
+	plaintext, err := crypto.AESGCMDecrypt(conf.GetConf().LLM.CredentialSecret, encrypted)
+	if err != nil {
+		return "", errno.ErrInternalServer.AppendBizMessage(errors.New("凭证解密失败，请检查凭证密钥配置"))
+	}
+
+	return plaintext, nil
+}
+
+// EncryptPlaintextAPIKeys 加密凭证加密上线前以明文保存的自定义提供商密钥，服务启动时执行，可重复执行。
+// 只有无法按密文格式解码的密钥才会被视为明文，避免凭证密钥配置错误时把密文再加密一次
+func (s *LLMService) EncryptPlaintextAPIKeys(ctx context.Context) error {
+	modelProviders, err := s.repo.GetModelProvidersWithAPIKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, modelProvider := range modelProviders {
+		if _, err := decryptSecret(modelProvider.APIKey); err == nil {
+			continue
+		}
+		if crypto.LooksAESGCMEncrypted(modelProvider.APIKey) {
+			logs.Warnf("API key of model provider %s cannot be decrypted, check the credential secret", modelProvider.ID)
+			continue
+		}
+
+		apiKey, err := encryptSecret(modelProvider.APIKey)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdateModelProviderAPIKey(ctx, modelProvider.ID, apiKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -7,27 +7,56 @@ import (
 	"mime"
 	"os"
 	"path/filepath"
+	"sort"
 
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/conf"
+	"github.com/crazyfrankie/voidx/infra/contract/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/llm"
 	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/llm/repository"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
+	"github.com/crazyfrankie/voidx/pkg/logs"
 	"github.com/crazyfrankie/voidx/types/errno"
 )
 
 type LLMService struct {
 	llmCore *llm.LanguageModelManager
+	repo    *repository.ModelProviderRepo
 }
 
-func NewLLMService(llmCore *llm.LanguageModelManager) *LLMService {
-	return &LLMService{
+func NewLLMService(llmCore *llm.LanguageModelManager, repo *repository.ModelProviderRepo) *LLMService {
+	s := &LLMService{
 		llmCore: llmCore,
+		repo:    repo,
 	}
+
+	// 内置提供商之外的名字由数据库中账号接入的提供商解析，模型使用所属账号的凭证创建
+	llmCore.SetProviderLoader(s)
+	llmCore.SetCredentialResolver(s)
+
+	return s
 }
 
-// GetProviders 获取所有模型提供商
-func (s *LLMService) GetProviders(ctx context.Context) ([]*resp.ProviderResp, error) {
+// GetProviders 获取所有模型提供商，包括当前账号接入的提供商
+func (s *LLMService) GetProviders(ctx context.Context, accountID uuid.UUID) ([]*resp.ProviderResp, error) {
 	providers := s.llmCore.GetProviders()
 
+	modelProviders, err := s.repo.GetModelProvidersByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range modelProviders {
+		pv, err := buildModelProvider(&modelProviders[i])
+		if err != nil {
+			continue
+		}
+		providers = append(providers, pv)
+	}
+
 	providerResps := make([]*resp.ProviderResp, 0, len(providers))
 	for _, provider := range providers {
 		modelTypes := make([]entities.ModelType, 0, len(provider.ProviderEntity.SupportedModelTypes))
@@ -37,6 +66,7 @@ func (s *LLMService) GetProviders(ctx context.Context) ([]*resp.ProviderResp, er
 
 		providerResps = append(providerResps, &resp.ProviderResp{
 			Name:        provider.Name,
+			Type:        provider.Type,
 			Label:       provider.ProviderEntity.Label,
 			Description: provider.ProviderEntity.Description,
 			Icon:        provider.ProviderEntity.Icon,
@@ -62,12 +92,91 @@ func (s *LLMService) GetModelEntity(ctx context.Context, provider, modelName str
 		Features:        convertFeatures(en.Features),
 		ContextWindow:   en.ContextWindow,
 		MaxOutputTokens: en.MaxOutputTokens,
+		Dimensions:      en.Dimensions,
 		Attributes:      en.Attributes,
 		Parameters:      convertParameters(en.Parameters),
 		Metadata:        en.Metadata,
 	}, nil
 }
 
+// GetEmbeddingModels 获取所有内置提供商的向量模型，按提供商及模型名称排序
+func (s *LLMService) GetEmbeddingModels(ctx context.Context) []*resp.EmbeddingModelResp {
+	res := make([]*resp.EmbeddingModelResp, 0)
+	for providerName, models := range s.llmCore.GetModelsByType(entities.ModelTypeEmbedding) {
+		pv, err := s.llmCore.GetProvider(providerName)
+		if err != nil {
+			continue
+		}
+		for _, model := range models {
+			res = append(res, &resp.EmbeddingModelResp{
+				Provider:      providerName,
+				ProviderLabel: pv.ProviderEntity.Label,
+				ModelName:     model.ModelName,
+				Label:         model.Label,
+				Dimensions:    model.Dimensions,
+			})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Provider != res[j].Provider {
+			return res[i].Provider < res[j].Provider
+		}
+		return res[i].ModelName < res[j].ModelName
+	})
+
+	return res
+}
+
+// ValidateEmbeddingModel 校验账号能否使用该向量模型，会按账号凭证创建一次向量模型
+func (s *LLMService) ValidateEmbeddingModel(ctx context.Context, accountID uuid.UUID, provider, modelName string) (*entities.ModelEntity, error) {
+	// 1. 校验模型存在且为向量模型
+	en, err := s.llmCore.GetModelEntity(provider, modelName)
+	if err != nil || en.ModelType != entities.ModelTypeEmbedding {
+		return nil, errno.ErrNotFound.AppendBizMessage(errors.New("该向量模型不存在，请核实后重试"))
+	}
+
+	// 2. 所有知识库共用同一个向量集合，模型维度必须与集合维度一致
+	if dimensions := s.vectorDimensions(); dimensions > 0 && en.Dimensions > 0 && en.Dimensions != dimensions {
+		return nil, errno.ErrValidate.AppendBizMessage(
+			fmt.Errorf("该向量模型的维度为%d，与向量数据库的维度%d不一致，请选择其他向量模型", en.Dimensions, dimensions))
+	}
+
+	// 3. 校验账号配置了可用的凭证
+	if _, err := s.llmCore.CreateEmbedderForAccount(ctx, accountID, provider, modelName); err != nil {
+		return nil, errno.ErrValidate.AppendBizMessage(errors.New("向量模型加载失败，请检查模型服务提供商凭证"))
+	}
+
+	return en, nil
+}
+
+// vectorDimensions 获取向量集合的维度，未配置时使用默认向量模型的维度，无法确定时返回0
+func (s *LLMService) vectorDimensions() int {
+	cfg := conf.GetConf().LLM
+	if cfg.VectorDimensions > 0 {
+		return cfg.VectorDimensions
+	}
+	if cfg.DefaultEmbeddingModel == "" {
+		return 0
+	}
+
+	en, err := s.llmCore.GetModelEntity(cfg.DefaultEmbeddingProvider, cfg.DefaultEmbeddingModel)
+	if err != nil {
+		return 0
+	}
+
+	return en.Dimensions
+}
+
+// LoadDatasetEmbedder 加载知识库固定的向量模型，知识库未固定向量模型时返回nil，使用向量数据库默认的向量模型
+func (s *LLMService) LoadDatasetEmbedder(ctx context.Context, dataset *entity.Dataset) (embedding.Embedder, error) {
+	if dataset == nil || dataset.EmbeddingModel == "" {
+		return nil, nil
+	}
+
+	return s.llmCore.CreateEmbedderForAccount(ctx, dataset.AccountID, dataset.EmbeddingProvider, dataset.EmbeddingModel)
+}
+
 // GetProviderIcon 获取模型提供商图标
 func (s *LLMService) GetProviderIcon(ctx context.Context, providerName string) ([]byte, string, error) {
 	provider, err := s.llmCore.GetProvider(providerName)
@@ -104,50 +213,71 @@ func (s *LLMService) GetProviderIcon(ctx context.Context, providerName string) (
 	return byteData, mimetype, nil
 }
 
-// LoadLanguageModel 从模型配置加载语言模型
-func (s *LLMService) LoadLanguageModel(modelConfig map[string]any) (entities.BaseLanguageModel, error) {
-	// 验证并处理模型配置
-	validConfig, err := s.processAndValidateModelConfig(modelConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to validate model config: %w", err)
+// LoadLanguageModel 从模型配置加载语言模型，模型使用传递账号的凭证创建。
+// 主模型之后配置的备用模型组成回退链，模型调用失败时按错误类型重试后依次回退
+func (s *LLMService) LoadLanguageModel(ctx context.Context, accountID uuid.UUID, modelConfig map[string]any) (entities.BaseLanguageModel, error) {
+	// 1. 验证并处理主模型配置，不合法时使用默认模型
+	specs := make([]llm.ModelSpec, 0, 1+entities.MaxFallbackModels)
+	spec, ok := s.normalizeModelConfig(modelConfig)
+	if !ok {
+		spec, _ = s.normalizeModelConfig(s.getDefaultModelConfig())
+	}
+	specs = append(specs, spec)
+
+	// 2. 追加合法的备用模型，不合法的直接跳过
+	fallbacks, _ := modelConfig["fallbacks"].([]any)
+	for _, fallback := range fallbacks {
+		if len(specs) > entities.MaxFallbackModels {
+			break
+		}
+		fallbackConfig, _ := fallback.(map[string]any)
+		if spec, ok := s.normalizeModelConfig(fallbackConfig); ok {
+			specs = append(specs, spec)
+		}
 	}
 
-	providerName := validConfig["provider"].(string)
-	modelName := validConfig["model"].(string)
-	parameters := validConfig["parameters"].(map[string]interface{})
+	// 3. 解析重试规则，不合法时使用默认规则
+	policy, _ := entities.ParseRetryPolicy(modelConfig["retry"])
 
-	// 创建模型实例
-	return s.llmCore.CreateModel(context.Background(), providerName, modelName, parameters)
+	return s.llmCore.CreateFallbackModelForAccount(ctx, accountID, specs, policy)
 }
 
-// processAndValidateModelConfig 处理并验证模型配置
-func (s *LLMService) processAndValidateModelConfig(modelConfig map[string]any) (map[string]any, error) {
-	// 检查模型配置格式
-	if modelConfig == nil {
-		return s.getDefaultModelConfig(), nil
+// BuildHumanMessage 根据模型能力构建本次提问的用户消息，模型不支持图片输入时由应用配置的视觉模型将图片转述为文本，
+// 视觉模型不可用时仅保留文本提问
+func (s *LLMService) BuildHumanMessage(ctx context.Context, accountID uuid.UUID, languageModel entities.BaseLanguageModel,
+	imageInput map[string]any, query string, imageURLs []string) *schema.Message {
+	msg, err := s.llmCore.BuildHumanMessageForAccount(ctx, accountID, languageModel, imageInput, query, imageURLs)
+	if err != nil {
+		logs.CtxErrorf(ctx, "describe images with vision model failed: %v", err)
+		return schema.UserMessage(query)
 	}
 
+	return msg
+}
+
+// normalizeModelConfig 校验模型配置中的提供商与模型并补全参数，提供商或模型不存在时返回false
+func (s *LLMService) normalizeModelConfig(modelConfig map[string]any) (llm.ModelSpec, bool) {
 	// 提取配置信息
 	providerName, ok := modelConfig["provider"].(string)
 	if !ok || providerName == "" {
-		return s.getDefaultModelConfig(), nil
+		return llm.ModelSpec{}, false
 	}
 
 	modelName, ok := modelConfig["model"].(string)
 	if !ok || modelName == "" {
-		return s.getDefaultModelConfig(), nil
+		return llm.ModelSpec{}, false
 	}
 
 	// 验证提供商是否存在
 	provider, err := s.llmCore.GetProvider(providerName)
 	if err != nil {
-		return s.getDefaultModelConfig(), nil
+		return llm.ModelSpec{}, false
 	}
 
 	// 验证模型是否存在
 	modelEntity, err := provider.GetModelEntity(modelName)
 	if err != nil {
-		return s.getDefaultModelConfig(), nil
+		return llm.ModelSpec{}, false
 	}
 
 	// 处理参数
@@ -189,11 +319,11 @@ func (s *LLMService) processAndValidateModelConfig(modelConfig map[string]any) (
 		parameters[param.Name] = value
 	}
 
-	return map[string]any{
-		"provider":   providerName,
-		"model":      modelName,
-		"parameters": parameters,
-	}, nil
+	return llm.ModelSpec{
+		Provider:   providerName,
+		Model:      modelName,
+		Parameters: parameters,
+	}, true
 }
 
 // validateParameterType 验证参数类型
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/core/llm/provider"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/internal/models/resp"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+// CreateModelProvider 为账号接入一个OpenAI兼容的模型服务提供商
+func (s *LLMService) CreateModelProvider(ctx context.Context, accountID uuid.UUID, createReq req.CreateModelProviderReq) (*resp.ModelProviderResp, error) {
+	// 1. 同一账号下提供商名字不能重复
+	if err := s.checkModelProviderName(ctx, accountID, uuid.Nil, createReq.Name); err != nil {
+		return nil, err
+	}
+
+	// 2. 校验模型列表
+	models, err := convertModelProviderModels(createReq.Models)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 调用模型校验服务地址与密钥
+	modelProvider := &entity.ModelProvider{
+		AccountID:   accountID,
+		Type:        entities.ProviderTypeOpenAICompatible,
+		Name:        strings.TrimSpace(createReq.Name),
+		Description: createReq.Description,
+		BaseURL:     strings.TrimRight(createReq.BaseURL, "/"),
+		Models:      models,
+	}
+	if err := validateModelProvider(ctx, modelProvider, createReq.APIKey); err != nil {
+		return nil, err
+	}
+
+	// 4. 加密密钥后创建提供商记录
+	modelProvider.APIKey, err = encryptSecret(createReq.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateModelProvider(ctx, modelProvider); err != nil {
+		return nil, err
+	}
+
+	return toModelProviderResp(modelProvider), nil
+}
+
+// GetModelProviders 获取账号接入的所有模型服务提供商
+func (s *LLMService) GetModelProviders(ctx context.Context, accountID uuid.UUID) ([]*resp.ModelProviderResp, error) {
+	modelProviders, err := s.repo.GetModelProvidersByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*resp.ModelProviderResp, 0, len(modelProviders))
+	for i := range modelProviders {
+		res = append(res, toModelProviderResp(&modelProviders[i]))
+	}
+
+	return res, nil
+}
+
+// GetModelProvider 获取指定的模型服务提供商
+func (s *LLMService) GetModelProvider(ctx context.Context, accountID, providerID uuid.UUID) (*resp.ModelProviderResp, error) {
+	modelProvider, err := s.getModelProvider(ctx, accountID, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toModelProviderResp(modelProvider), nil
+}
+
+// UpdateModelProvider 更新模型服务提供商，未传递api_key时保留原有密钥
+func (s *LLMService) UpdateModelProvider(ctx context.Context, accountID, providerID uuid.UUID, updateReq req.UpdateModelProviderReq) error {
+	// 1. 获取提供商并校验权限
+	modelProvider, err := s.getModelProvider(ctx, accountID, providerID)
+	if err != nil {
+		return err
+	}
+
+	// 2. 校验名字与模型列表
+	if err := s.checkModelProviderName(ctx, accountID, providerID, updateReq.Name); err != nil {
+		return err
+	}
+	models, err := convertModelProviderModels(updateReq.Models)
+	if err != nil {
+		return err
+	}
+
+	// 3. 使用更新后的配置调用模型校验服务地址与密钥
+	apiKey := updateReq.APIKey
+	if apiKey == "" {
+		if apiKey, err = decryptSecret(modelProvider.APIKey); err != nil {
+			return err
+		}
+	}
+	modelProvider.Name = strings.TrimSpace(updateReq.Name)
+	modelProvider.Description = updateReq.Description
+	modelProvider.BaseURL = strings.TrimRight(updateReq.BaseURL, "/")
+	modelProvider.Models = models
+	if err := validateModelProvider(ctx, modelProvider, apiKey); err != nil {
+		return err
+	}
+
+	// 4. 更新提供商记录
+	if updateReq.APIKey != "" {
+		if modelProvider.APIKey, err = encryptSecret(updateReq.APIKey); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.UpdateModelProvider(ctx, modelProvider)
+}
+
+// DeleteModelProvider 删除模型服务提供商，引用该提供商的应用在加载模型时会回退到默认模型
+func (s *LLMService) DeleteModelProvider(ctx context.Context, accountID, providerID uuid.UUID) error {
+	if _, err := s.getModelProvider(ctx, accountID, providerID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteModelProvider(ctx, providerID)
+}
+
+// LoadProvider 根据名字从数据库加载账号接入的提供商，名字即提供商记录的ID，实现core中的ProviderLoader。
+// 加载的提供商只描述模型不携带凭证，凭证仅在校验归属后由ResolveCredentials提供给所属账号
+func (s *LLMService) LoadProvider(name string) (*provider.Provider, error) {
+	providerID, err := uuid.Parse(name)
+	if err != nil {
+		return nil, entities.NotFoundError("该模型服务提供商不存在，请核实后重试")
+	}
+
+	modelProvider, err := s.repo.GetModelProviderByID(context.Background(), providerID)
+	if err != nil {
+		return nil, entities.NotFoundError("该模型服务提供商不存在，请核实后重试")
+	}
+
+	return buildModelProvider(modelProvider)
+}
+
+// getModelProvider 获取模型服务提供商并校验是否属于当前账号
+func (s *LLMService) getModelProvider(ctx context.Context, accountID, providerID uuid.UUID) (*entity.ModelProvider, error) {
+	modelProvider, err := s.repo.GetModelProviderByID(ctx, providerID)
+	if err != nil {
+		return nil, errno.ErrNotFound.AppendBizMessage(errors.New("该模型服务提供商不存在"))
+	}
+	if modelProvider.AccountID != accountID {
+		return nil, errno.ErrForbidden.AppendBizMessage(errors.New("该模型服务提供商不存在或无权限"))
+	}
+
+	return modelProvider, nil
+}
+
+// checkModelProviderName 校验账号下提供商名字是否重复，更新时排除自身
+func (s *LLMService) checkModelProviderName(ctx context.Context, accountID, providerID uuid.UUID, name string) error {
+	existing, err := s.repo.GetModelProviderByName(ctx, accountID, strings.TrimSpace(name))
+	if err == nil && existing.ID != providerID {
+		return errno.ErrValidate.AppendBizMessage(errors.New("该模型服务提供商名字已存在"))
+	}
+
+	return nil
+}
+
+// convertModelProviderModels 转换请求中的模型列表，同一提供商下模型名字不能重复
+func convertModelProviderModels(modelReqs []req.ModelProviderModelReq) ([]entity.ModelProviderModel, error) {
+	seen := make(map[string]bool, len(modelReqs))
+	models := make([]entity.ModelProviderModel, 0, len(modelReqs))
+	for _, modelReq := range modelReqs {
+		name := strings.TrimSpace(modelReq.Model)
+		if name == "" || seen[name] {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("模型名字不能为空且不能重复"))
+		}
+		if modelReq.ContextWindow > 0 && modelReq.MaxOutputTokens >= modelReq.ContextWindow {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("最大输出标记数必须小于上下文窗口"))
+		}
+		seen[name] = true
+
+		models = append(models, entity.ModelProviderModel{
+			Model:           name,
+			Label:           modelReq.Label,
+			ContextWindow:   modelReq.ContextWindow,
+			MaxOutputTokens: modelReq.MaxOutputTokens,
+			Features:        modelReq.Features,
+			Pricing: entity.ModelProviderPrice{
+				Input:  modelReq.Pricing.Input,
+				Output: modelReq.Pricing.Output,
+				Unit:   modelReq.Pricing.Unit,
+			},
+		})
+	}
+
+	return models, nil
+}
+
+// buildModelProvider 将数据库中的提供商记录构建为core中的提供商，提供商不携带服务地址与密钥
+func buildModelProvider(modelProvider *entity.ModelProvider) (*provider.Provider, error) {
+	modelEntities := make([]entities.ModelEntity, 0, len(modelProvider.Models))
+	for _, model := range modelProvider.Models {
+		label := model.Label
+		if label == "" {
+			label = model.Model
+		}
+
+		features := make([]entities.ModelFeature, 0, len(model.Features))
+		for _, feature := range model.Features {
+			features = append(features, entities.ModelFeature(feature))
+		}
+
+		modelEntities = append(modelEntities, entities.ModelEntity{
+			ModelName:       model.Model,
+			Label:           label,
+			ModelType:       entities.ModelTypeChat,
+			Features:        features,
+			ContextWindow:   model.ContextWindow,
+			MaxOutputTokens: model.MaxOutputTokens,
+			Attributes:      map[string]any{"mode": "chat"},
+			Metadata: map[string]any{
+				"pricing": map[string]any{
+					"input":  model.Pricing.Input,
+					"output": model.Pricing.Output,
+					"unit":   model.Pricing.Unit,
+				},
+			},
+		})
+	}
+
+	name := modelProvider.ID.String()
+	return provider.NewOpenAICompatibleProvider(name, 0, entities.ProviderEntity{
+		Name:        name,
+		Label:       modelProvider.Name,
+		Description: modelProvider.Description,
+	}, modelEntities, nil)
+}
+
+// validateModelProvider 使用提供商的第一个模型发起一次最小调用，校验服务地址与密钥是否可用
+func validateModelProvider(ctx context.Context, modelProvider *entity.ModelProvider, apiKey string) error {
+	pv, err := buildModelProvider(modelProvider)
+	if err != nil {
+		return errno.ErrValidate.AppendBizMessage(errors.New("模型服务提供商配置不合法"))
+	}
+
+	credentials := toCredentialConfig(map[string]string{
+		"base_url": modelProvider.BaseURL,
+		"api_key":  apiKey,
+	})
+	if err := pv.ValidateCredentials(ctx, credentials); err != nil {
+		return errno.ErrValidate.AppendBizMessage(errors.New("凭证校验失败，请核实服务地址与密钥后重试"))
+	}
+
+	return nil
+}
+
+// modelProviderCredentials 解密提供商记录的凭证，调用方需先校验提供商归属
+func modelProviderCredentials(modelProvider *entity.ModelProvider) (map[string]any, error) {
+	apiKey, err := decryptSecret(modelProvider.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCredentialConfig(map[string]string{
+		"base_url": modelProvider.BaseURL,
+		"api_key":  apiKey,
+	}), nil
+}
+
+// toModelProviderResp 转换提供商记录为响应，密钥只返回首尾部分
+func toModelProviderResp(modelProvider *entity.ModelProvider) *resp.ModelProviderResp {
+	apiKey, _ := decryptSecret(modelProvider.APIKey)
+
+	return &resp.ModelProviderResp{
+		ID:          modelProvider.ID,
+		Type:        modelProvider.Type,
+		Name:        modelProvider.Name,
+		Description: modelProvider.Description,
+		BaseURL:     modelProvider.BaseURL,
+		APIKey:      maskAPIKey(apiKey),
+		Models:      modelProvider.Models,
+		Utime:       modelProvider.Utime,
+		Ctime:       modelProvider.Ctime,
+	}
+}
+
+// maskAPIKey 对密钥脱敏
+func maskAPIKey(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return strings.Repeat("*", len(apiKey))
+	}
+
+	return apiKey[:3] + strings.Repeat("*", 8) + apiKey[len(apiKey)-4:]
+}
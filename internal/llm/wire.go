@@ -4,9 +4,12 @@ package llm
 
 import (
 	"github.com/google/wire"
+	"gorm.io/gorm"
 
 	"github.com/crazyfrankie/voidx/internal/core/llm"
 	"github.com/crazyfrankie/voidx/internal/llm/handler"
+	"github.com/crazyfrankie/voidx/internal/llm/repository"
+	"github.com/crazyfrankie/voidx/internal/llm/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/llm/service"
 )
 
@@ -17,8 +20,10 @@ type LLMModule struct {
 	Service *Service
 }
 
-func InitLLMModule(llmCore *llm.LanguageModelManager) *LLMModule {
+func InitLLMModule(db *gorm.DB, llmCore *llm.LanguageModelManager) *LLMModule {
 	wire.Build(
+		dao.NewModelProviderDao,
+		repository.NewModelProviderRepo,
 		service.NewLLMService,
 		handler.NewLLMHandler,
 
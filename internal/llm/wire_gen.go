@@ -9,13 +9,18 @@ package llm
 import (
 	"github.com/crazyfrankie/voidx/internal/core/llm"
 	"github.com/crazyfrankie/voidx/internal/llm/handler"
+	"github.com/crazyfrankie/voidx/internal/llm/repository"
+	"github.com/crazyfrankie/voidx/internal/llm/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/llm/service"
+	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitLLMModule(llmCore *llm.LanguageModelManager) *LLMModule {
-	llmService := service.NewLLMService(llmCore)
+func InitLLMModule(db *gorm.DB, llmCore *llm.LanguageModelManager) *LLMModule {
+	modelProviderDao := dao.NewModelProviderDao(db)
+	modelProviderRepo := repository.NewModelProviderRepo(modelProviderDao)
+	llmService := service.NewLLMService(llmCore, modelProviderRepo)
 	llmHandler := handler.NewLLMHandler(llmService)
 	llmModule := &LLMModule{
 		Handler: llmHandler,
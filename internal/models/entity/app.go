@@ -30,6 +30,7 @@ type AppConfig struct {
 	PresetPrompt         string           `gorm:"type:text;not null;default:''" json:"preset_prompt"`
 	Tools                []map[string]any `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"tools"`
 	Workflows            []string         `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"workflows"`
+	Apps                 []string         `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"apps"`
 	RetrievalConfig      map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"retrieval_config"`
 	LongTermMemory       map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"long_term_memory"`
 	OpeningStatement     string           `gorm:"type:text;not null;default:''" json:"opening_statement"`
@@ -38,6 +39,8 @@ type AppConfig struct {
 	TextToSpeech         map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"text_to_speech"`
 	SuggestedAfterAnswer map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{\"enable\": true}'::jsonb" json:"suggested_after_answer"`
 	ReviewConfig         map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"review_config"`
+	StructuredOutput     map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"structured_output"`
+	ImageInput           map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"image_input"`
 	Utime                int64            `gorm:"autoUpdateTime" json:"utime"`
 	Ctime                int64            `gorm:"autoCreateTime" json:"ctime"`
 }
@@ -51,6 +54,7 @@ type AppConfigVersion struct {
 	PresetPrompt         string               `gorm:"type:text;not null;default:''" json:"preset_prompt"`
 	Tools                []map[string]any     `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"tools"`
 	Workflows            []string             `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"workflows"`
+	Apps                 []string             `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"apps"`
 	Datasets             []string             `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"datasets"`
 	RetrievalConfig      map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"retrieval_config"`
 	LongTermMemory       map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"long_term_memory"`
@@ -60,6 +64,8 @@ type AppConfigVersion struct {
 	TextToSpeech         map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"text_to_speech"`
 	SuggestedAfterAnswer map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{\"enable\": true}'::jsonb" json:"suggested_after_answer"`
 	ReviewConfig         map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"review_config"`
+	StructuredOutput     map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"structured_output"`
+	ImageInput           map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"image_input"`
 	Version              int                  `gorm:"not null;default:0" json:"version"`
 	ConfigType           consts.AppConfigType `gorm:"size:255;not null;default:''" json:"config_type"`
 	Utime                int64                `gorm:"autoUpdateTime" json:"utime"`
@@ -7,16 +7,17 @@ import (
 
 // Conversation 交流会话模型
 type Conversation struct {
-	ID         uuid.UUID         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	AppID      uuid.UUID         `gorm:"type:uuid;not null;index:conversation_app_id_idx" json:"app_id"`
-	Name       string            `gorm:"size:255;not null;default:''" json:"name"`
-	Summary    string            `gorm:"type:text;not null;default:''" json:"summary"`
-	IsPinned   bool              `gorm:"not null;default:false" json:"is_pinned"`
-	IsDeleted  bool              `gorm:"not null;default:false" json:"is_deleted"`
-	InvokeFrom consts.InvokeFrom `gorm:"size:255;not null;default:''" json:"invoke_from"`
-	CreatedBy  uuid.UUID         `gorm:"type:uuid;index:conversation_app_created_by_idx" json:"created_by"`
-	Utime      int64             `gorm:"autoUpdateTime" json:"utime"`
-	Ctime      int64             `gorm:"autoCreateTime" json:"ctime"`
+	ID           uuid.UUID         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID        uuid.UUID         `gorm:"type:uuid;not null;index:conversation_app_id_idx" json:"app_id"`
+	Name         string            `gorm:"size:255;not null;default:''" json:"name"`
+	Summary      string            `gorm:"type:text;not null;default:''" json:"summary"`
+	SummarizedAt int64             `gorm:"not null;default:0" json:"summarized_at"`
+	IsPinned     bool              `gorm:"not null;default:false" json:"is_pinned"`
+	IsDeleted    bool              `gorm:"not null;default:false" json:"is_deleted"`
+	InvokeFrom   consts.InvokeFrom `gorm:"size:255;not null;default:''" json:"invoke_from"`
+	CreatedBy    uuid.UUID         `gorm:"type:uuid;index:conversation_app_created_by_idx" json:"created_by"`
+	Utime        int64             `gorm:"autoUpdateTime" json:"utime"`
+	Ctime        int64             `gorm:"autoCreateTime" json:"ctime"`
 }
 
 // Message 交流消息模型
@@ -26,6 +27,7 @@ type Message struct {
 	ConversationID    uuid.UUID            `gorm:"type:uuid;not null;index:message_conversation_id_idx" json:"conversation_id"`
 	InvokeFrom        consts.InvokeFrom    `gorm:"size:255;not null;default:''" json:"invoke_from"`
 	CreatedBy         uuid.UUID            `gorm:"type:uuid;not null;index:message_created_by_idx" json:"created_by"`
+	Model             string               `gorm:"size:255;not null;default:''" json:"model"`
 	Query             string               `gorm:"type:text;not null;default:''" json:"query"`
 	ImageUrls         []string             `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"image_urls"`
 	MessageTokenCount int                  `gorm:"not null;default:0" json:"message_token_count"`
@@ -35,6 +37,7 @@ type Message struct {
 	AnswerTokenCount  int                  `gorm:"not null;default:0" json:"answer_token_count"`
 	AnswerUnitPrice   float64              `gorm:"type:decimal(10,7);not null;default:0.0" json:"answer_unit_price"`
 	AnswerPriceUnit   float64              `gorm:"type:decimal(10,4);not null;default:0.0" json:"answer_price_unit"`
+	CachedTokenCount  int                  `gorm:"not null;default:0" json:"cached_token_count"`
 	Latency           float64              `gorm:"not null;default:0.0" json:"latency"`
 	IsDeleted         bool                 `gorm:"not null;default:false" json:"is_deleted"`
 	Status            consts.MessageStatus `gorm:"size:255;not null;default:''" json:"status"`
@@ -55,10 +58,12 @@ type AgentThought struct {
 	CreatedBy         uuid.UUID        `gorm:"type:uuid;not null" json:"created_by"`
 	Position          int              `gorm:"not null;default:0" json:"position"`
 	Event             string           `gorm:"size:255;not null;default:''" json:"event"`
+	Model             string           `gorm:"size:255;not null;default:''" json:"model"`
 	Thought           string           `gorm:"type:text;not null;default:''" json:"thought"`
 	Observation       string           `gorm:"type:text;not null;default:''" json:"observation"`
 	Tool              string           `gorm:"type:text;not null;default:''" json:"tool"`
 	ToolInput         map[string]any   `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"tool_input"`
+	SubThoughts       []map[string]any `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"sub_thoughts"`
 	Message           []map[string]any `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"message"`
 	MessageTokenCount int              `gorm:"not null;default:0" json:"message_token_count"`
 	MessageUnitPrice  float64          `gorm:"type:decimal(10,7);not null;default:0.0" json:"message_unit_price"`
@@ -67,9 +72,26 @@ type AgentThought struct {
 	AnswerTokenCount  int              `gorm:"not null;default:0" json:"answer_token_count"`
 	AnswerUnitPrice   float64          `gorm:"type:decimal(10,7);not null;default:0.0" json:"answer_unit_price"`
 	AnswerPriceUnit   float64          `gorm:"type:decimal(10,4);not null;default:0.0" json:"answer_price_unit"`
+	CachedTokenCount  int              `gorm:"not null;default:0" json:"cached_token_count"`
 	TotalTokenCount   int              `gorm:"not null;default:0" json:"total_token_count"`
 	TotalPrice        float64          `gorm:"type:decimal(10,7);not null;default:0.0" json:"total_price"`
 	Latency           float64          `gorm:"not null;default:0.0" json:"latency"`
 	Utime             int64            `gorm:"autoUpdateTime" json:"utime"`
 	Ctime             int64            `gorm:"autoCreateTime" json:"ctime"`
 }
+
+// ModerationEvent 内容审核事件模型，记录输入输出审核命中的规则，用于审计
+type ModerationEvent struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID          uuid.UUID `gorm:"type:uuid;not null;index:moderation_event_app_id_idx" json:"app_id"`
+	ConversationID uuid.UUID `gorm:"type:uuid;not null;index:moderation_event_conversation_id_idx" json:"conversation_id"`
+	MessageID      uuid.UUID `gorm:"type:uuid;not null" json:"message_id"`
+	CreatedBy      uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	Stage          string    `gorm:"size:255;not null;default:''" json:"stage"`
+	Moderator      string    `gorm:"size:255;not null;default:''" json:"moderator"`
+	Category       string    `gorm:"size:255;not null;default:''" json:"category"`
+	Action         string    `gorm:"size:255;not null;default:''" json:"action"`
+	Match          string    `gorm:"type:text;not null;default:''" json:"match"`
+	Reason         string    `gorm:"type:text;not null;default:''" json:"reason"`
+	Ctime          int64     `gorm:"autoCreateTime" json:"ctime"`
+}
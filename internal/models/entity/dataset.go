@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"github.com/crazyfrankie/voidx/infra/contract/document"
 	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/google/uuid"
 )
@@ -12,8 +13,23 @@ type Dataset struct {
 	Name        string    `gorm:"size:255;not null;default:'';index:dataset_account_id_name_idx,composite:account_name" json:"name"`
 	Icon        string    `gorm:"size:255;not null;default:''" json:"icon"`
 	Description string    `gorm:"type:text;not null;default:''" json:"description"`
-	Utime       int64     `gorm:"autoUpdateTime" json:"utime"`
-	Ctime       int64     `gorm:"autoCreateTime" json:"ctime"`
+	// 知识库类型，创建后不可修改
+	Type consts.DatasetType `gorm:"size:255;not null;default:'text'" json:"type"`
+	// 创建时固定的向量模型，为空时使用向量数据库默认的向量模型
+	EmbeddingProvider   string `gorm:"size:255;not null;default:''" json:"embedding_provider"`
+	EmbeddingModel      string `gorm:"size:255;not null;default:''" json:"embedding_model"`
+	EmbeddingDimensions int    `gorm:"not null;default:0" json:"embedding_dimensions"`
+	// 文档可以填写的元数据字段定义
+	MetadataFields []MetadataField `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"metadata_fields"`
+	Utime          int64           `gorm:"autoUpdateTime" json:"utime"`
+	Ctime          int64           `gorm:"autoCreateTime" json:"ctime"`
+}
+
+// MetadataField 知识库元数据字段定义，select类型的字段只能从Options中取值
+type MetadataField struct {
+	Name    string                   `json:"name"`
+	Type    consts.MetadataFieldType `json:"type"`
+	Options []string                 `json:"options,omitempty"`
 }
 
 // Document 文档表模型
@@ -37,22 +53,32 @@ type Document struct {
 	Enabled              bool      `gorm:"not null;default:false" json:"enabled"`
 	DisabledAt           int64     `gorm:"" json:"disabled_at"`
 	Status               string    `gorm:"size:255;not null;default:'waiting'" json:"status"`
-	Utime                int64     `gorm:"autoUpdateTime" json:"utime"`
-	Ctime                int64     `gorm:"autoCreateTime" json:"ctime"`
+	Error                string    `gorm:"type:text;not null;default:''" json:"error"`
+	// 按知识库元数据字段定义填写的元数据，会同步到文档的所有片段上
+	Metadata map[string]any `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"metadata"`
+	// 来自网页爬取的文档记录所属的爬取来源、页面地址以及页面正文的hash，重新爬取时用于判断页面是否变化
+	CrawlSourceID uuid.UUID `gorm:"type:uuid;index:document_crawl_source_id_idx" json:"crawl_source_id"`
+	SourceURL     string    `gorm:"size:2048;not null;default:''" json:"source_url"`
+	SourceHash    string    `gorm:"size:255;not null;default:''" json:"source_hash"`
+	Utime         int64     `gorm:"autoUpdateTime" json:"utime"`
+	Ctime         int64     `gorm:"autoCreateTime" json:"ctime"`
 }
 
-// Segment 片段表模型
+// Segment 片段表模型，父子分段中的父片段只用于返回上下文，不建立索引，NodeID为空，子片段通过ParentID关联父片段，
+// 按文档结构切分时HeadingPath记录片段所在章节的标题路径
 type Segment struct {
 	ID                  uuid.UUID            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	AccountID           uuid.UUID            `gorm:"type:uuid;not null;index:segment_account_id_idx" json:"account_id"`
 	DatasetID           uuid.UUID            `gorm:"type:uuid;not null;index:segment_dataset_id_idx" json:"dataset_id"`
 	DocumentID          uuid.UUID            `gorm:"type:uuid;not null;index:segment_document_id_idx" json:"document_id"`
 	NodeID              uuid.UUID            `gorm:"type:uuid;not null" json:"node_id"`
+	ParentID            uuid.UUID            `gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000000';index:segment_parent_id_idx" json:"parent_id"`
 	Position            int                  `gorm:"not null;default:1" json:"position"`
 	Content             string               `gorm:"type:text;not null;default:''" json:"content"`
 	CharacterCount      int                  `gorm:"not null;default:0" json:"character_count"`
 	TokenCount          int                  `gorm:"not null;default:0" json:"token_count"`
 	Keywords            []string             `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"keywords"`
+	HeadingPath         []string             `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"heading_path"`
 	Hash                string               `gorm:"size:255;not null;default:''" json:"hash"`
 	HitCount            int                  `gorm:"not null;default:0" json:"hit_count"`
 	Enabled             bool                 `gorm:"not null;default:false" json:"enabled"`
@@ -63,8 +89,12 @@ type Segment struct {
 	StoppedAt           int64                `gorm:"" json:"stopped_at"`
 	Error               string               `gorm:"type:text;not null;default:''" json:"error"`
 	Status              consts.SegmentStatus `gorm:"size:255;not null;default:'waiting'" json:"status"`
-	Utime               int64                `gorm:"autoUpdateTime" json:"utime"`
-	Ctime               int64                `gorm:"autoCreateTime" json:"ctime"`
+	Metadata            map[string]any       `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"metadata"`
+	// 问答对知识库中Content为答案，使用Question与QuestionVariants中的相似问法建立索引
+	Question         string   `gorm:"type:text;not null;default:''" json:"question"`
+	QuestionVariants []string `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"question_variants"`
+	Utime            int64    `gorm:"autoUpdateTime" json:"utime"`
+	Ctime            int64    `gorm:"autoCreateTime" json:"ctime"`
 }
 
 type DocumentSegment struct {
@@ -78,13 +108,25 @@ type DocumentSegment struct {
 	Embedding  []float64 `gorm:"" json:"embedding"`
 }
 
-// Keyword 关键词表模型
+// Keyword 关键词表模型，记录知识库倒排索引的BM25统计信息
 type Keyword struct {
-	ID         uuid.UUID           `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	DatasetID  uuid.UUID           `gorm:"type:uuid;not null;index:keyword_table_dataset_id_idx" json:"dataset_id"`
-	KeywordMap map[string][]string `gorm:"type:jsonb;serializer:json;not null;default:'{}'::jsonb" json:"keyword_map"`
-	Utime      int64               `gorm:"autoUpdateTime" json:"utime"`
-	Ctime      int64               `gorm:"autoCreateTime" json:"ctime"`
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DatasetID    uuid.UUID `gorm:"type:uuid;not null;index:keyword_table_dataset_id_idx" json:"dataset_id"`
+	SegmentCount int       `gorm:"not null;default:0" json:"segment_count"`
+	TotalLength  int64     `gorm:"not null;default:0" json:"total_length"`
+	Utime        int64     `gorm:"autoUpdateTime" json:"utime"`
+	Ctime        int64     `gorm:"autoCreateTime" json:"ctime"`
+}
+
+// KeywordPosting 倒排索引表模型，记录词项在片段中的词频
+type KeywordPosting struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DatasetID     uuid.UUID `gorm:"type:uuid;not null;index:keyword_posting_dataset_id_term_idx,priority:1" json:"dataset_id"`
+	Term          string    `gorm:"size:255;not null;index:keyword_posting_dataset_id_term_idx,priority:2" json:"term"`
+	SegmentID     uuid.UUID `gorm:"type:uuid;not null;index:keyword_posting_segment_id_idx" json:"segment_id"`
+	TermFrequency int       `gorm:"not null;default:0" json:"term_frequency"`
+	SegmentLength int       `gorm:"not null;default:0" json:"segment_length"`
+	Ctime         int64     `gorm:"autoCreateTime" json:"ctime"`
 }
 
 // DatasetQuery 知识库查询表模型
@@ -99,6 +141,40 @@ type DatasetQuery struct {
 	Ctime       int64     `gorm:"autoCreateTime" json:"ctime"`
 }
 
+// DatasetTable 表格知识库的数据表模型，知识库的所有行数据存储在名为Name的独立数据表中，
+// 每行通过_document_id关联所属的文档，Columns记录推断出的字段类型
+type DatasetTable struct {
+	ID        uuid.UUID          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID uuid.UUID          `gorm:"type:uuid;not null;index:dataset_table_account_id_idx" json:"account_id"`
+	DatasetID uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:dataset_table_dataset_id_idx" json:"dataset_id"`
+	Name      string             `gorm:"size:255;not null;default:''" json:"name"`
+	Columns   []*document.Column `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"columns"`
+	RowCount  int64              `gorm:"not null;default:0" json:"row_count"`
+	Utime     int64              `gorm:"autoUpdateTime" json:"utime"`
+	Ctime     int64              `gorm:"autoCreateTime" json:"ctime"`
+}
+
+// CrawlSource 网页爬取来源表模型，从种子地址或站点地图爬取站点内的页面，每个页面创建一个文档，
+// RecrawlInterval大于0时按照间隔(秒)定期重新爬取，并重新索引内容发生变化的页面
+type CrawlSource struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID       uuid.UUID `gorm:"type:uuid;not null;index:crawl_source_account_id_idx" json:"account_id"`
+	DatasetID       uuid.UUID `gorm:"type:uuid;not null;index:crawl_source_dataset_id_idx" json:"dataset_id"`
+	ProcessRuleID   uuid.UUID `gorm:"type:uuid;not null" json:"process_rule_id"`
+	SeedURLs        []string  `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"seed_urls"`
+	SitemapURL      string    `gorm:"size:2048;not null;default:''" json:"sitemap_url"`
+	MaxDepth        int       `gorm:"not null;default:0" json:"max_depth"`
+	MaxPages        int       `gorm:"not null;default:0" json:"max_pages"`
+	RecrawlInterval int64     `gorm:"not null;default:0" json:"recrawl_interval"`
+	Batch           string    `gorm:"size:255;not null;default:''" json:"batch"`
+	Status          string    `gorm:"size:255;not null;default:'waiting'" json:"status"`
+	Error           string    `gorm:"type:text;not null;default:''" json:"error"`
+	LastCrawledAt   int64     `gorm:"not null;default:0" json:"last_crawled_at"`
+	NextCrawlAt     int64     `gorm:"not null;default:0;index:crawl_source_next_crawl_at_idx" json:"next_crawl_at"`
+	Utime           int64     `gorm:"autoUpdateTime" json:"utime"`
+	Ctime           int64     `gorm:"autoCreateTime" json:"ctime"`
+}
+
 // ProcessRule 文档处理规则表模型
 type ProcessRule struct {
 	ID        uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
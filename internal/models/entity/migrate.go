@@ -24,18 +24,26 @@ func AutoMigrate(db *gorm.DB) error {
 		&Conversation{},
 		&Message{},
 		&AgentThought{},
+		&ModerationEvent{},
 
 		// Dataset 相关表
 		&Dataset{},
 		&Document{},
 		&Segment{},
 		&Keyword{},
+		&KeywordPosting{},
 		&DatasetQuery{},
 		&ProcessRule{},
+		&DatasetTable{},
+		&CrawlSource{},
 
 		// EndUser 相关表
 		&EndUser{},
 
+		// ModelProvider 相关表
+		&ModelProvider{},
+		&ProviderCredential{},
+
 		// Platform 相关表
 		&WechatConfig{},
 		&WechatEndUser{},
@@ -44,6 +52,9 @@ func AutoMigrate(db *gorm.DB) error {
 		// Upload 相关表
 		&UploadFile{},
 
+		// UserMemory 相关表
+		&UserMemory{},
+
 		// Workflow 相关表
 		&Workflow{},
 		&WorkflowResult{},
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+)
+
+// ModelProvider 自定义模型服务提供商模型，记录账号接入的OpenAI兼容服务
+type ModelProvider struct {
+	ID          uuid.UUID            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID   uuid.UUID            `gorm:"type:uuid;not null;index:model_provider_account_id_idx" json:"account_id"`
+	Type        string               `gorm:"size:255;not null;default:''" json:"type"`
+	Name        string               `gorm:"size:255;not null;default:''" json:"name"`
+	Description string               `gorm:"type:text;not null;default:''" json:"description"`
+	BaseURL     string               `gorm:"size:255;not null;default:''" json:"base_url"`
+	APIKey      string               `gorm:"type:text;not null;default:''" json:"-"`
+	Models      []ModelProviderModel `gorm:"type:jsonb;serializer:json;not null;default:'[]'::jsonb" json:"models"`
+	Utime       int64                `gorm:"autoUpdateTime" json:"utime"`
+	Ctime       int64                `gorm:"autoCreateTime" json:"ctime"`
+}
+
+// ModelProviderModel 自定义模型服务提供商下的模型配置
+type ModelProviderModel struct {
+	Model           string             `json:"model"`
+	Label           string             `json:"label"`
+	ContextWindow   int                `json:"context_window"`
+	MaxOutputTokens int                `json:"max_output_tokens"`
+	Features        []string           `json:"features"`
+	Pricing         ModelProviderPrice `json:"pricing"`
+}
+
+// ModelProviderPrice 模型的计费单价，与内置模型metadata.pricing含义一致
+type ModelProviderPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+	Unit   float64 `json:"unit"`
+}
+
+// ProviderCredential 账号为内置模型服务提供商配置的凭证，凭证JSON加密后存储
+type ProviderCredential struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AccountID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:provider_credential_account_id_provider_idx" json:"account_id"`
+	Provider    string    `gorm:"size:255;not null;default:'';uniqueIndex:provider_credential_account_id_provider_idx" json:"provider"`
+	Credentials string    `gorm:"type:text;not null;default:''" json:"-"`
+	Utime       int64     `gorm:"autoUpdateTime" json:"utime"`
+	Ctime       int64     `gorm:"autoCreateTime" json:"ctime"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"github.com/crazyfrankie/voidx/types/consts"
+	"github.com/google/uuid"
+)
+
+// UserMemory 用户记忆模型，记录从会话中提取的跨会话事实与偏好
+type UserMemory struct {
+	ID             uuid.UUID                  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AppID          uuid.UUID                  `gorm:"type:uuid;not null;index:user_memory_app_id_owner_idx" json:"app_id"`
+	OwnerType      consts.UserMemoryOwnerType `gorm:"size:255;not null;default:'';index:user_memory_app_id_owner_idx" json:"owner_type"`
+	OwnerID        uuid.UUID                  `gorm:"type:uuid;not null;index:user_memory_app_id_owner_idx" json:"owner_id"`
+	ConversationID uuid.UUID                  `gorm:"type:uuid" json:"conversation_id"`
+	Content        string                     `gorm:"type:text;not null;default:''" json:"content"`
+	Utime          int64                      `gorm:"autoUpdateTime" json:"utime"`
+	Ctime          int64                      `gorm:"autoCreateTime" json:"ctime"`
+}
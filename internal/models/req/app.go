@@ -55,6 +55,14 @@ type DebugChatReq struct {
 	ImageUrls []string `json:"image_urls"`
 }
 
+// ConfirmToolCallReq 确认/修改/拒绝需要人工确认的工具调用请求
+type ConfirmToolCallReq struct {
+	ThoughtID string `json:"thought_id" binding:"required,uuid"`
+	Action    string `json:"action" binding:"required,oneof=approve edit reject"`
+	Arguments string `json:"arguments"`
+	Reason    string `json:"reason"`
+}
+
 // GetDebugConversationMessagesWithPageReq 获取调试会话消息分页列表请求
 type GetDebugConversationMessagesWithPageReq struct {
 	CurrentPage int   `form:"current_page" binding:"required,min=1"`
@@ -92,6 +100,8 @@ type UpdateAppConfigReq struct {
 	SpeechToText     map[string]any   `json:"speech_to_text,omitempty"`
 	TextToSpeech     map[string]any   `json:"text_to_speech,omitempty"`
 	ReviewConfig     map[string]any   `json:"review_config,omitempty"`
+	StructuredOutput map[string]any   `json:"structured_output,omitempty"`
+	ImageInput       map[string]any   `json:"image_input,omitempty"`
 }
 
 // UpdateAppSummaryReq 更新应用长记忆请求
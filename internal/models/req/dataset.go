@@ -1,12 +1,24 @@
 package req
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
 
 // CreateDatasetReq 创建知识库请求
 type CreateDatasetReq struct {
 	Name        string `json:"name" binding:"required,max=100"`
 	Description string `json:"description" binding:"max=500"`
 	Icon        string `json:"icon"`
+	// 向量模型在创建后不可修改，未传递时使用默认向量模型
+	EmbeddingProvider string `json:"embedding_provider" binding:"required_with=EmbeddingModel"`
+	EmbeddingModel    string `json:"embedding_model" binding:"required_with=EmbeddingProvider"`
+	// 文档可以填写的元数据字段定义
+	MetadataFields []entity.MetadataField `json:"metadata_fields"`
+	// 知识库类型，创建后不可修改，未传递时为文本知识库
+	Type consts.DatasetType `json:"type" binding:"omitempty,oneof=text table qa"`
 }
 
 // UpdateDatasetReq 更新知识库请求
@@ -14,6 +26,8 @@ type UpdateDatasetReq struct {
 	Name        string `json:"name" binding:"omitempty,max=100"`
 	Description string `json:"description" binding:"omitempty,max=500"`
 	Permission  string `json:"permission" binding:"omitempty,oneof=only_me all_team_members"`
+	// 传递时整体替换元数据字段定义，文档仍在使用的字段与选项不能删除或修改类型
+	MetadataFields *[]entity.MetadataField `json:"metadata_fields"`
 }
 
 // GetDatasetsWithPageReq 获取知识库分页列表请求
@@ -25,12 +39,21 @@ type GetDatasetsWithPageReq struct {
 
 // HitReq 检索测试请求
 type HitReq struct {
-	Query             string  `json:"query" binding:"required,max=2000"`
-	RetrievalStrategy string  `json:"retrieval_strategy" binding:"required,oneof=semantic full_text hybrid"`
-	K                 int     `json:"k" binding:"required,min=1,max=20"`
-	Score             float32 `json:"score" binding:"required,min=0,max=1"`
-	RerankingModel    string  `json:"reranking_model"`
-	RerankingEnabled  bool    `json:"reranking_enabled"`
+	Query             string   `json:"query" binding:"required,max=2000"`
+	RetrievalStrategy string   `json:"retrieval_strategy" binding:"required,oneof=semantic full_text hybrid"`
+	K                 int      `json:"k" binding:"required,min=1,max=20"`
+	Score             float32  `json:"score" binding:"required,min=0,max=1"`
+	RerankingEnabled  bool     `json:"reranking_enabled"`
+	RerankingProvider string   `json:"reranking_provider" binding:"required_if=RerankingEnabled true"`
+	RerankingModel    string   `json:"reranking_model" binding:"required_if=RerankingEnabled true"`
+	RerankingTopN     int      `json:"reranking_top_n" binding:"omitempty,min=1,max=50"`
+	FusionMethod      string   `json:"fusion_method" binding:"omitempty,oneof=weighted rrf semantic_first"`
+	SemanticWeight    *float64 `json:"semantic_weight" binding:"omitempty,min=0,max=1"`
+	RRFK              int      `json:"rrf_k" binding:"omitempty,min=1,max=1000"`
+	SemanticTopK      int      `json:"semantic_top_k" binding:"omitempty,min=1,max=100"`
+	FullTextTopK      int      `json:"full_text_top_k" binding:"omitempty,min=1,max=100"`
+	// 元数据过滤表达式，格式与检索配置中的metadata_filter一致
+	MetadataFilter map[string]any `json:"metadata_filter"`
 }
 
 // CreateDocumentsReq 创建文档请求
@@ -43,6 +66,27 @@ type CreateDocumentsReq struct {
 // UpdateDocumentReq 更新文档请求
 type UpdateDocumentReq struct {
 	Name string `json:"name" binding:"omitempty,max=255"`
+	// 传递时整体替换文档的元数据，值为null的字段会被移除
+	Metadata map[string]any `json:"metadata"`
+}
+
+// UpdateDocumentContentReq 更新文档内容请求，传递新的文件或新的处理规则，未变化的片段会被保留
+type UpdateDocumentContentReq struct {
+	UploadFileID *uuid.UUID     `json:"upload_file_id"`
+	ProcessType  string         `json:"process_type"`
+	Rule         map[string]any `json:"rule"`
+}
+
+// CreateCrawlSourceReq 创建网页爬取来源请求，种子地址与站点地图至少传递一个，
+// RecrawlInterval为定期重新爬取的间隔(秒)，为0时只爬取一次
+type CreateCrawlSourceReq struct {
+	SeedURLs        []string       `json:"seed_urls" binding:"max=20,dive,url"`
+	SitemapURL      string         `json:"sitemap_url" binding:"omitempty,url"`
+	MaxDepth        *int           `json:"max_depth" binding:"omitempty,min=0,max=5"`
+	MaxPages        int            `json:"max_pages" binding:"min=0,max=500"`
+	RecrawlInterval int64          `json:"recrawl_interval" binding:"omitempty,min=3600"`
+	ProcessType     string         `json:"process_type"`
+	Rule            map[string]any `json:"rule"`
 }
 
 // GetDocumentsWithPageReq 获取文档分页列表请求
@@ -56,6 +100,11 @@ type GetDocumentsWithPageReq struct {
 type CreateSegmentReq struct {
 	Content  string   `json:"content" binding:"required"`
 	Keywords []string `json:"keywords"`
+	// 父子分段的文档中传递时在该父片段下新增子片段
+	ParentID *uuid.UUID `json:"parent_id"`
+	// 问答对知识库中必须传递问题，Content为答案
+	Question         string   `json:"question" binding:"max=2000"`
+	QuestionVariants []string `json:"question_variants" binding:"max=10,dive,max=2000"`
 }
 
 // UpdateSegmentReq 更新片段请求
@@ -63,6 +112,9 @@ type UpdateSegmentReq struct {
 	Content       string   `json:"content" binding:"omitempty"`
 	Keywords      []string `json:"keywords"`
 	EnabledStatus *bool    `json:"enabled_status"`
+	// 问答对片段的问题，以及传递时整体替换的相似问法
+	Question         string    `json:"question" binding:"omitempty,max=2000"`
+	QuestionVariants *[]string `json:"question_variants" binding:"omitempty,max=10,dive,max=2000"`
 }
 
 // GetSegmentsWithPageReq 获取片段分页列表请求
@@ -9,3 +9,44 @@ type GetModelsReq struct {
 type GetModelsByTypeReq struct {
 	ModelType string `uri:"model_type" binding:"required"`
 }
+
+// ModelProviderModelReq 自定义模型服务提供商下的模型配置
+type ModelProviderModelReq struct {
+	Model           string                `json:"model" binding:"required,max=255"`
+	Label           string                `json:"label" binding:"omitempty,max=255"`
+	ContextWindow   int                   `json:"context_window" binding:"min=0"`
+	MaxOutputTokens int                   `json:"max_output_tokens" binding:"min=0"`
+	Features        []string              `json:"features" binding:"omitempty,dive,oneof=tool_calling function_calling agent_thought image_input"`
+	Pricing         ModelProviderPriceReq `json:"pricing"`
+}
+
+// ModelProviderPriceReq 模型的计费单价
+type ModelProviderPriceReq struct {
+	Input  float64 `json:"input" binding:"min=0"`
+	Output float64 `json:"output" binding:"min=0"`
+	Unit   float64 `json:"unit" binding:"min=0"`
+}
+
+// CreateModelProviderReq 创建OpenAI兼容的模型服务提供商请求
+type CreateModelProviderReq struct {
+	Name        string                  `json:"name" binding:"required,max=60"`
+	Description string                  `json:"description" binding:"omitempty,max=800"`
+	BaseURL     string                  `json:"base_url" binding:"required,url,max=255"`
+	APIKey      string                  `json:"api_key" binding:"omitempty,max=1024"`
+	Models      []ModelProviderModelReq `json:"models" binding:"required,min=1,max=100,dive"`
+}
+
+// UpdateModelProviderReq 更新模型服务提供商请求，api_key为空时保留原有密钥
+type UpdateModelProviderReq struct {
+	Name        string                  `json:"name" binding:"required,max=60"`
+	Description string                  `json:"description" binding:"omitempty,max=800"`
+	BaseURL     string                  `json:"base_url" binding:"required,url,max=255"`
+	APIKey      string                  `json:"api_key" binding:"omitempty,max=1024"`
+	Models      []ModelProviderModelReq `json:"models" binding:"required,min=1,max=100,dive"`
+}
+
+// SaveProviderCredentialReq 保存内置模型服务提供商凭证请求，api_key为空时保留原有密钥
+type SaveProviderCredentialReq struct {
+	APIKey  string `json:"api_key" binding:"omitempty,max=1024"`
+	BaseURL string `json:"base_url" binding:"omitempty,url,max=255"`
+}
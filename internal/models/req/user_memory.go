@@ -0,0 +1,24 @@
+package req
+
+import "github.com/google/uuid"
+
+// GetUserMemoriesWithPageReq 获取用户记忆分页列表请求
+type GetUserMemoriesWithPageReq struct {
+	AppID       uuid.UUID `form:"app_id" binding:"required"`
+	OwnerType   string    `form:"owner_type" binding:"required,oneof=end_user account"`
+	OwnerID     uuid.UUID `form:"owner_id" binding:"required"`
+	CurrentPage int       `form:"current_page"`
+	PageSize    int       `form:"page_size" binding:"max=100"`
+}
+
+// UpdateUserMemoryReq 更新用户记忆请求
+type UpdateUserMemoryReq struct {
+	Content string `json:"content" binding:"required,max=1000"`
+}
+
+// DeleteUserMemoriesReq 删除用户全部记忆请求
+type DeleteUserMemoriesReq struct {
+	AppID     uuid.UUID `form:"app_id" binding:"required"`
+	OwnerType string    `form:"owner_type" binding:"required,oneof=end_user account"`
+	OwnerID   uuid.UUID `form:"owner_id" binding:"required"`
+}
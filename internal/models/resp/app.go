@@ -35,18 +35,20 @@ type MessageResp struct {
 
 // AgentThought 智能体思考过程
 type AgentThought struct {
-	ID              uuid.UUID      `json:"id"`
-	MessageID       uuid.UUID      `json:"message_id"`
-	Event           string         `json:"event"`
-	Thought         string         `json:"thought"`
-	Observation     string         `json:"observation"`
-	Tool            string         `json:"tool"`
-	ToolInput       map[string]any `json:"tool_input"`
-	Answer          string         `json:"answer"`
-	TotalTokenCount int            `json:"total_token_count"`
-	TotalPrice      float64        `json:"total_price"`
-	Latency         float64        `json:"latency"`
-	Ctime           int64          `json:"ctime"`
+	ID              uuid.UUID        `json:"id"`
+	MessageID       uuid.UUID        `json:"message_id"`
+	Event           string           `json:"event"`
+	Model           string           `json:"model"`
+	Thought         string           `json:"thought"`
+	Observation     string           `json:"observation"`
+	Tool            string           `json:"tool"`
+	ToolInput       map[string]any   `json:"tool_input"`
+	SubThoughts     []map[string]any `json:"sub_thoughts"`
+	Answer          string           `json:"answer"`
+	TotalTokenCount int              `json:"total_token_count"`
+	TotalPrice      float64          `json:"total_price"`
+	Latency         float64          `json:"latency"`
+	Ctime           int64            `json:"ctime"`
 }
 
 // Paginator 分页器
@@ -71,6 +73,7 @@ type DebugConversationMessageResp struct {
 	AgentThoughts   []AgentThought `json:"agent_thoughts"`
 	Query           string         `json:"query"`
 	Answer          string         `json:"answer"`
+	Model           string         `json:"model"`
 	ImageUrls       []string       `json:"image_urls"`
 	TotalTokenCount int            `json:"total_token_count"`
 	TotalPrice      float64        `json:"total_price"`
@@ -109,6 +112,7 @@ type AppDraftConfigResp struct {
 	PresetPrompt         string           `json:"preset_prompt"`
 	Tools                []map[string]any `json:"tools"`
 	Workflows            []map[string]any `json:"workflows"`
+	Apps                 []map[string]any `json:"apps"`
 	Datasets             []map[string]any `json:"datasets"`
 	RetrievalConfig      map[string]any   `json:"retrieval_config"`
 	LongTermMemory       map[string]any   `json:"long_term_memory"`
@@ -118,6 +122,8 @@ type AppDraftConfigResp struct {
 	TextToSpeech         map[string]any   `json:"text_to_speech"`
 	SuggestedAfterAnswer map[string]any   `json:"suggested_after_answer"`
 	ReviewConfig         map[string]any   `json:"review_config"`
+	StructuredOutput     map[string]any   `json:"structured_output"`
+	ImageInput           map[string]any   `json:"image_input"`
 }
 
 type GetPublishHistoriesWithPageResp struct {
@@ -15,13 +15,14 @@ type GetConversationMessagePageResp struct {
 }
 
 type GetConversationMessagePageAgentThought struct {
-	ID          uuid.UUID      `json:"id"`
-	Position    int            `json:"position"`
-	Event       string         `json:"event"`
-	Thought     string         `json:"thought"`
-	Observation string         `json:"observation"`
-	Tool        string         `json:"tool"`
-	ToolInput   map[string]any `json:"tool_input"`
-	Latency     int            `json:"latency"`
-	Ctime       int64          `json:"ctime"`
+	ID          uuid.UUID        `json:"id"`
+	Position    int              `json:"position"`
+	Event       string           `json:"event"`
+	Thought     string           `json:"thought"`
+	Observation string           `json:"observation"`
+	Tool        string           `json:"tool"`
+	ToolInput   map[string]any   `json:"tool_input"`
+	SubThoughts []map[string]any `json:"sub_thoughts"`
+	Latency     int              `json:"latency"`
+	Ctime       int64            `json:"ctime"`
 }
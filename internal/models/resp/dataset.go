@@ -2,6 +2,9 @@ package resp
 
 import (
 	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
 )
 
 // DatasetResp 知识库响应
@@ -13,24 +16,67 @@ type DatasetResp struct {
 	HitCount        int       `json:"hit_count"`
 	RelatedAppCount int       `json:"related_app_count"`
 	CharacterCount  int       `json:"character_count"`
-	Ctime           int64     `json:"ctime"`
-	Utime           int64     `json:"utime"`
+	// 知识库固定使用的向量模型
+	EmbeddingProvider   string `json:"embedding_provider"`
+	EmbeddingModel      string `json:"embedding_model"`
+	EmbeddingDimensions int    `json:"embedding_dimensions"`
+	// 文档可以填写的元数据字段定义
+	MetadataFields []entity.MetadataField `json:"metadata_fields"`
+	Ctime          int64                  `json:"ctime"`
+	Utime          int64                  `json:"utime"`
+	// 知识库类型，表格知识库导入过数据后返回推断出的数据表结构
+	Type  consts.DatasetType `json:"type"`
+	Table *DatasetTableResp  `json:"table,omitempty"`
+}
+
+// DatasetTableResp 表格知识库的数据表结构
+type DatasetTableResp struct {
+	Name     string            `json:"name"`
+	RowCount int64             `json:"row_count"`
+	Columns  []TableColumnResp `json:"columns"`
+}
+
+// TableColumnResp 数据表字段，Description为导入文件中的原始表头
+type TableColumnResp struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
 }
 
 // DocumentResp 文档响应
 type DocumentResp struct {
-	ID             uuid.UUID `json:"id"`
-	DatasetID      uuid.UUID `json:"dataset_id"`
-	Position       int       `json:"position"`
-	Name           string    `json:"name"`
-	Status         string    `json:"status"`
-	SegmentCount   int       `json:"segment_count"`
-	CharacterCount int       `json:"character_count"`
-	HitCount       int       `json:"hit_count"`
-	Enabled        bool      `json:"enabled"`
-	DisabledAt     int64     `json:"disabled_at"`
-	Ctime          int64     `json:"ctime"`
-	Utime          int64     `json:"utime"`
+	ID             uuid.UUID      `json:"id"`
+	DatasetID      uuid.UUID      `json:"dataset_id"`
+	Position       int            `json:"position"`
+	Name           string         `json:"name"`
+	Status         string         `json:"status"`
+	SegmentCount   int            `json:"segment_count"`
+	CharacterCount int            `json:"character_count"`
+	HitCount       int            `json:"hit_count"`
+	Enabled        bool           `json:"enabled"`
+	DisabledAt     int64          `json:"disabled_at"`
+	Metadata       map[string]any `json:"metadata"`
+	SourceURL      string         `json:"source_url"`
+	Ctime          int64          `json:"ctime"`
+	Utime          int64          `json:"utime"`
+}
+
+// CrawlSourceResp 网页爬取来源响应
+type CrawlSourceResp struct {
+	ID              uuid.UUID `json:"id"`
+	DatasetID       uuid.UUID `json:"dataset_id"`
+	SeedURLs        []string  `json:"seed_urls"`
+	SitemapURL      string    `json:"sitemap_url"`
+	MaxDepth        int       `json:"max_depth"`
+	MaxPages        int       `json:"max_pages"`
+	RecrawlInterval int64     `json:"recrawl_interval"`
+	Batch           string    `json:"batch"`
+	DocumentCount   int       `json:"document_count"`
+	Status          string    `json:"status"`
+	Error           string    `json:"error"`
+	LastCrawledAt   int64     `json:"last_crawled_at"`
+	NextCrawlAt     int64     `json:"next_crawl_at"`
+	Ctime           int64     `json:"ctime"`
 }
 
 type DocumentStatusResp struct {
@@ -43,6 +89,9 @@ type DocumentStatusResp struct {
 	SegmentCount          int       `json:"segment_count"`
 	CompletedSegmentCount int       `json:"completed_segment_count"`
 	Status                string    `json:"status"`
+	Progress              int       `json:"progress"`
+	RemainingTime         int       `json:"remaining_time"`
+	Error                 string    `json:"error"`
 	ProcessingStartedAt   int64     `json:"processing_started_at"`
 	ParsingCompletedAt    int64     `json:"parsing_completed_at"`
 	SplittingCompletedAt  int64     `json:"splitting_completed_at"`
@@ -62,6 +111,7 @@ type SegmentResp struct {
 	WordCount   int        `json:"word_count"`
 	TokensCount int        `json:"tokens_count"`
 	Keywords    []string   `json:"keywords"`
+	HeadingPath []string   `json:"heading_path"`
 	HitCount    int        `json:"hit_count"`
 	Enabled     bool       `json:"enabled"`
 	DisabledAt  *int64     `json:"disabled_at"`
@@ -69,6 +119,12 @@ type SegmentResp struct {
 	Status      string     `json:"status"`
 	Ctime       int64      `json:"ctime"`
 	Utime       int64      `json:"utime"`
+	// 父子分段中子片段所属的父片段id，以及父片段下的子片段列表
+	ParentID *uuid.UUID    `json:"parent_id,omitempty"`
+	Children []SegmentResp `json:"children,omitempty"`
+	// 问答对片段的问题与相似问法，Content为答案
+	Question         string   `json:"question,omitempty"`
+	QuestionVariants []string `json:"question_variants,omitempty"`
 }
 
 // DatasetQueryResp 知识库查询记录响应
@@ -89,6 +145,7 @@ type HitResult struct {
 	DatasetID      uuid.UUID    `json:"dataset_id"`
 	Content        string       `json:"content"`
 	Score          float64      `json:"score"`
+	RerankScore    *float64     `json:"rerank_score,omitempty"`
 	Keywords       []string     `json:"keywords"`
 	Position       int          `json:"position"`
 	CharacterCount int          `json:"character_count"`
@@ -99,4 +156,11 @@ type HitResult struct {
 	Status         string       `json:"status"`
 	Ctime          int64        `json:"ctime"`
 	Utime          int64        `json:"utime"`
+	// 父子分段命中子片段时返回其父片段，召回内容以父片段内容为准
+	ParentSegmentID *uuid.UUID `json:"parent_segment_id,omitempty"`
+	ParentContent   string     `json:"parent_content,omitempty"`
+	// 问答对命中时返回问题与相似问法，MatchedQuestion为语义检索命中的问题或相似问法
+	Question         string   `json:"question,omitempty"`
+	QuestionVariants []string `json:"question_variants,omitempty"`
+	MatchedQuestion  string   `json:"matched_question,omitempty"`
 }
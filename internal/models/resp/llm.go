@@ -1,10 +1,16 @@
 package resp
 
-import "github.com/crazyfrankie/voidx/internal/core/llm/entities"
+import (
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/llm/entities"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+)
 
 // ProviderResp 提供商响应
 type ProviderResp struct {
 	Name        string               `json:"name"`
+	Type        string               `json:"type"`
 	Label       string               `json:"label"`
 	Description string               `json:"description"`
 	Icon        string               `json:"icon"`
@@ -32,6 +38,7 @@ type ModelEntityResp struct {
 	Features        []string             `json:"features"`
 	ContextWindow   int                  `json:"context_window"`
 	MaxOutputTokens int                  `json:"max_output_tokens"`
+	Dimensions      int                  `json:"dimensions,omitempty"`
 	Attributes      map[string]any       `json:"attributes"`
 	Parameters      []ModelParameterResp `json:"parameters"`
 	Metadata        map[string]any       `json:"metadata"`
@@ -56,3 +63,34 @@ type ModelParameterOptionResp struct {
 	Label string `json:"label"`
 	Value any    `json:"value"`
 }
+
+// ModelProviderResp 自定义模型服务提供商响应，密钥只返回脱敏后的值
+type ModelProviderResp struct {
+	ID          uuid.UUID                   `json:"id"`
+	Type        string                      `json:"type"`
+	Name        string                      `json:"name"`
+	Description string                      `json:"description"`
+	BaseURL     string                      `json:"base_url"`
+	APIKey      string                      `json:"api_key"`
+	Models      []entity.ModelProviderModel `json:"models"`
+	Utime       int64                       `json:"utime"`
+	Ctime       int64                       `json:"ctime"`
+}
+
+// ProviderCredentialResp 提供商凭证响应，密钥只返回脱敏后的值
+type ProviderCredentialResp struct {
+	Provider string `json:"provider"`
+	APIKey   string `json:"api_key"`
+	BaseURL  string `json:"base_url"`
+	Utime    int64  `json:"utime"`
+	Ctime    int64  `json:"ctime"`
+}
+
+// EmbeddingModelResp 向量模型响应
+type EmbeddingModelResp struct {
+	Provider      string `json:"provider"`
+	ProviderLabel string `json:"provider_label"`
+	ModelName     string `json:"model_name"`
+	Label         string `json:"label"`
+	Dimensions    int    `json:"dimensions"`
+}
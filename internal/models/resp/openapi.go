@@ -4,15 +4,16 @@ import "github.com/google/uuid"
 
 // OpenAPIChatResp 开放API聊天响应
 type OpenAPIChatResp struct {
-	ID              uuid.UUID             `json:"id"`
-	EndUserID       uuid.UUID             `json:"end_user_id"`
-	ConversationID  uuid.UUID             `json:"conversation_id"`
-	Query           string                `json:"query"`
-	ImageUrls       []string              `json:"image_urls"`
-	Answer          string                `json:"answer"`
-	TotalTokenCount int                   `json:"total_token_count"`
-	Latency         float64               `json:"latency"`
-	AgentThoughts   []OpenAPIAgentThought `json:"agent_thoughts"`
+	ID               uuid.UUID             `json:"id"`
+	EndUserID        uuid.UUID             `json:"end_user_id"`
+	ConversationID   uuid.UUID             `json:"conversation_id"`
+	Query            string                `json:"query"`
+	ImageUrls        []string              `json:"image_urls"`
+	Answer           string                `json:"answer"`
+	StructuredOutput any                   `json:"structured_output,omitempty"`
+	TotalTokenCount  int                   `json:"total_token_count"`
+	Latency          float64               `json:"latency"`
+	AgentThoughts    []OpenAPIAgentThought `json:"agent_thoughts"`
 }
 
 // OpenAPIAgentThought 开放API智能体思考过程
@@ -9,6 +9,7 @@ type SearchResult struct {
 	DocumentName   string    `json:"document_name"`
 	DatasetID      uuid.UUID `json:"dataset_id"`
 	Score          float64   `json:"score"`
+	RerankScore    *float64  `json:"rerank_score,omitempty"`
 	Position       int       `json:"position"`
 	Content        string    `json:"content"`
 	Keywords       []string  `json:"keywords"`
@@ -19,4 +20,10 @@ type SearchResult struct {
 	Status         string    `json:"status"`
 	Ctime          int64     `json:"ctime"`
 	Utime          int64     `json:"utime"`
+	// 父子分段命中子片段时，Content为父片段内容，ChildContent为命中的子片段内容
+	ParentSegmentID *uuid.UUID `json:"parent_segment_id,omitempty"`
+	ChildContent    string     `json:"child_content,omitempty"`
+	// 问答对命中时，Content为答案，Question为问答对的问题，MatchedQuestion为语义检索命中的问题或相似问法
+	Question        string `json:"question,omitempty"`
+	MatchedQuestion string `json:"matched_question,omitempty"`
 }
@@ -0,0 +1,15 @@
+package resp
+
+import "github.com/google/uuid"
+
+// GetUserMemoriesWithPageResp 获取用户记忆分页列表数据响应
+type GetUserMemoriesWithPageResp struct {
+	ID             uuid.UUID `json:"id"`
+	AppID          uuid.UUID `json:"app_id"`
+	OwnerType      string    `json:"owner_type"`
+	OwnerID        uuid.UUID `json:"owner_id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	Content        string    `json:"content"`
+	Utime          int64     `json:"utime"`
+	Ctime          int64     `json:"ctime"`
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/crazyfrankie/voidx/internal/base/response"
 	"github.com/crazyfrankie/voidx/internal/models/req"
@@ -27,6 +28,7 @@ func (h *OpenAPIHandler) RegisterRoute(r *gin.RouterGroup) {
 	openAPIGroup := r.Group("openapi")
 	{
 		openAPIGroup.POST("chat", h.Chat())
+		openAPIGroup.POST("chat/:task_id/confirm", h.ConfirmChat())
 	}
 }
 
@@ -91,3 +93,33 @@ func (h *OpenAPIHandler) Chat() gin.HandlerFunc {
 		}
 	}
 }
+
+// ConfirmChat 确认、修改或拒绝流式对话中等待确认的工具调用
+func (h *OpenAPIHandler) ConfirmChat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var confirmReq req.ConfirmToolCallReq
+		if err := c.ShouldBindJSON(&confirmReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate.AppendBizMessage(errors.New("请求参数验证失败")))
+			return
+		}
+
+		taskID, err := uuid.Parse(c.Param("task_id"))
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate.AppendBizMessage(errors.New("task_id格式错误")))
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		if err := h.svc.ConfirmChat(c.Request.Context(), userID, taskID, confirmReq); err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
@@ -21,10 +23,12 @@ import (
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/internal/openapi/repository"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/pkg/logs"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
 	"github.com/crazyfrankie/voidx/types/consts"
+	"github.com/crazyfrankie/voidx/types/errno"
 )
 
 type OpenAPIService struct {
@@ -36,11 +40,13 @@ type OpenAPIService struct {
 	appSvc              *app.Service
 	agentManager        *agent.AgentQueueManagerFactory
 	tokeBufMem          *memory.TokenBufferMemory
+	userMemorySvc       *user_memory.Service
 }
 
 func NewOpenAPIService(repo *repository.OpenAPIRepo, conversationService *service.ConversationService,
 	retrieverSvc *retriever.Service, llmSvc *llm.Service, appConfigSvc *app_config.Service,
-	appSvc *app.Service, agentManager *agent.AgentQueueManagerFactory, tokeBufMem *memory.TokenBufferMemory) *OpenAPIService {
+	appSvc *app.Service, agentManager *agent.AgentQueueManagerFactory, tokeBufMem *memory.TokenBufferMemory,
+	userMemorySvc *user_memory.Service) *OpenAPIService {
 	return &OpenAPIService{
 		repo:                repo,
 		conversationService: conversationService,
@@ -50,6 +56,7 @@ func NewOpenAPIService(repo *repository.OpenAPIRepo, conversationService *servic
 		appSvc:              appSvc,
 		agentManager:        agentManager,
 		tokeBufMem:          tokeBufMem,
+		userMemorySvc:       userMemorySvc,
 	}
 }
 
@@ -99,14 +106,21 @@ func (s *OpenAPIService) Chat(ctx context.Context, userID uuid.UUID, chatReq req
 		return nil, err
 	}
 
-	// 7. 获取历史消息（Chat方法中暂不使用，但保留调用以验证功能）
-	_, err = s.tokeBufMem.GetHistoryPromptMessages(2000, appConfig.DialogRound)
+	// 6. 从语言模型中根据模型配置获取模型实例
+	llm, err := s.llmSvc.LoadLanguageModel(ctx, app.AccountID, appConfig.ModelConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// 7. 获取历史消息
+	s.tokeBufMem.WithConversationID(conversation.ID)
+	history, err := s.tokeBufMem.GetHistoryPromptMessages(2000, appConfig.DialogRound)
 	if err != nil {
 		return nil, err
 	}
 
 	// 8. 将配置中的tools转换成LangChain工具
-	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, appConfig.Tools)
+	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, app.AccountID, appConfig.Tools)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +138,11 @@ func (s *OpenAPIService) Chat(ctx context.Context, userID uuid.UUID, chatReq req
 			if err == nil {
 				tools = append(tools, datasetTool)
 			}
+			// 表格知识库使用应用的模型生成SQL查询
+			tableTools, err := s.retrieverSvc.CreateTableQueryTools(ctx, userID, datasets, llm)
+			if err == nil {
+				tools = append(tools, tableTools...)
+			}
 		}
 	}
 
@@ -143,21 +162,66 @@ func (s *OpenAPIService) Chat(ctx context.Context, userID uuid.UUID, chatReq req
 		}
 	}
 
-	// 11. 创建Agent并处理（简化版本，直接返回模拟回答）
-	// 在Chat方法中，我们不使用history变量，因为这是同步版本
-	answer := fmt.Sprintf("这是对问题「%s」的回答。", chatReq.Query)
+	// 11. 创建Agent并以阻塞方式执行，阻塞模式下调用方拿不到task_id与thought_id，需要确认的工具直接拒绝执行
+	agentInstance, err := s.newAgent(ctx, endUser, appConfig, llm, tools, blockingConfirmationUnavailable)
+	if err != nil {
+		return nil, err
+	}
+
+	agentState := agenteneity.AgentState{
+		TaskID:         uuid.New(),
+		Messages:       history,
+		History:        history,
+		LongTermMemory: s.userMemorySvc.RecallLongTermMemory(ctx, conversation, chatReq.Query, appConfig.LongTermMemory),
+	}
+	if len(chatReq.Query) > 0 {
+		agentState.Messages = append(agentState.Messages, s.llmSvc.BuildHumanMessage(ctx, app.AccountID, llm, appConfig.ImageInput, chatReq.Query, chatReq.ImageUrls))
+	}
+
+	agentResult, err := agentInstance.Invoke(ctx, agentState)
+	if err != nil {
+		return nil, err
+	}
+
+	// 12. 保存Agent思考过程到数据库
+	err = s.conversationService.SaveAgentThoughts(ctx, endUser.TenantID, app.ID, conversation.ID, message.ID, agentResult.AgentThoughts)
+	if err != nil {
+		logs.Errorf("Failed to save agent thoughts: %v", err)
+	}
+	err = s.conversationService.ScheduleConversationSummary(ctx, app.AccountID, conversation.ID, appConfig.LongTermMemory, appConfig.ModelConfig)
+	if err != nil {
+		logs.Errorf("Failed to schedule conversation summary: %v", err)
+	}
+
+	// 13. 构建响应
+	totalTokenCount := 0
+	agentThoughts := make([]resp.OpenAPIAgentThought, 0, len(agentResult.AgentThoughts))
+	for _, thought := range agentResult.AgentThoughts {
+		totalTokenCount += thought.TotalTokenCount
+		toolInput, _ := sonic.MarshalString(thought.ToolInput)
+		agentThoughts = append(agentThoughts, resp.OpenAPIAgentThought{
+			ID:          thought.ID.String(),
+			Event:       string(thought.Event),
+			Thought:     thought.Thought,
+			Observation: thought.Observation,
+			Tool:        thought.Tool,
+			ToolInput:   toolInput,
+			Latency:     thought.Latency,
+			CreatedAt:   time.Now().Unix(),
+		})
+	}
 
-	// 12. 构建响应
 	return &resp.OpenAPIChatResp{
-		ID:              message.ID,
-		EndUserID:       endUser.ID,
-		ConversationID:  conversation.ID,
-		Query:           chatReq.Query,
-		ImageUrls:       chatReq.ImageUrls,
-		Answer:          answer,
-		TotalTokenCount: 0,
-		Latency:         0.5,
-		AgentThoughts:   []resp.OpenAPIAgentThought{},
+		ID:               message.ID,
+		EndUserID:        endUser.ID,
+		ConversationID:   conversation.ID,
+		Query:            chatReq.Query,
+		ImageUrls:        chatReq.ImageUrls,
+		Answer:           agentResult.Answer,
+		StructuredOutput: agentResult.StructuredOutput,
+		TotalTokenCount:  totalTokenCount,
+		Latency:          agentResult.Latency,
+		AgentThoughts:    agentThoughts,
 	}, nil
 }
 
@@ -208,7 +272,7 @@ func (s *OpenAPIService) ProcessStreamChat(ctx context.Context, userID uuid.UUID
 	}
 
 	// 6. 从语言模型中根据模型配置获取模型实例
-	llm, err := s.llmSvc.LoadLanguageModel(appConfig.ModelConfig)
+	llm, err := s.llmSvc.LoadLanguageModel(ctx, app.AccountID, appConfig.ModelConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -221,7 +285,7 @@ func (s *OpenAPIService) ProcessStreamChat(ctx context.Context, userID uuid.UUID
 	}
 
 	// 8. 将配置中的tools转换成LangChain工具
-	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, appConfig.Tools)
+	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, app.AccountID, appConfig.Tools)
 	if err != nil {
 		return nil, err
 	}
@@ -239,6 +303,11 @@ func (s *OpenAPIService) ProcessStreamChat(ctx context.Context, userID uuid.UUID
 			if err == nil {
 				tools = append(tools, datasetTool)
 			}
+			// 表格知识库使用应用的模型生成SQL查询
+			tableTools, err := s.retrieverSvc.CreateTableQueryTools(ctx, userID, datasets, llm)
+			if err == nil {
+				tools = append(tools, tableTools...)
+			}
 		}
 	}
 
@@ -258,6 +327,21 @@ func (s *OpenAPIService) ProcessStreamChat(ctx context.Context, userID uuid.UUID
 		}
 	}
 
+	// 检测是否关联了其他应用
+	if len(appConfig.Apps) > 0 {
+		apps := make([]uuid.UUID, 0, len(appConfig.Apps))
+		for _, a := range appConfig.Apps {
+			idStr, _ := a["id"].(string)
+			if appID, err := uuid.Parse(idStr); err == nil {
+				apps = append(apps, appID)
+			}
+		}
+		appTools, err := s.appConfigSvc.GetToolsByAppIDs(ctx, app.AccountID, apps)
+		if err == nil {
+			tools = append(tools, appTools...)
+		}
+	}
+
 	// 11. 创建响应流通道
 	responseStream := make(chan string, 100)
 
@@ -267,6 +351,25 @@ func (s *OpenAPIService) ProcessStreamChat(ctx context.Context, userID uuid.UUID
 	return responseStream, nil
 }
 
+// ConfirmChat 对开放API流式对话中等待确认的工具调用进行确认、修改或拒绝
+func (s *OpenAPIService) ConfirmChat(ctx context.Context, userID, taskID uuid.UUID, confirmReq req.ConfirmToolCallReq) error {
+	// 1. 修改工具参数时必须传递合法的json参数
+	if confirmReq.Action == string(agenteneity.ConfirmationEdit) {
+		var arguments map[string]any
+		if err := sonic.UnmarshalString(confirmReq.Arguments, &arguments); err != nil {
+			return errno.ErrValidate.AppendBizMessage(errors.New("修改后的工具参数必须是合法的JSON"))
+		}
+	}
+
+	// 2. 调用智能体队列管理器写入确认结果，任务归属由队列管理器校验
+	thoughtID, _ := uuid.Parse(confirmReq.ThoughtID)
+	return s.agentManager.ConfirmTask(ctx, taskID, thoughtID, userID, consts.InvokeFromServiceAPI, &agenteneity.ConfirmationDecision{
+		Action:    agenteneity.ConfirmationAction(confirmReq.Action),
+		Arguments: confirmReq.Arguments,
+		Reason:    confirmReq.Reason,
+	})
+}
+
 // getOrCreateEndUser 获取或创建终端用户
 func (s *OpenAPIService) getOrCreateEndUser(ctx context.Context, endUserID uuid.UUID, tenantID, appID uuid.UUID) (*entity.EndUser, error) {
 	if endUserID != uuid.Nil {
@@ -323,22 +426,9 @@ func (s *OpenAPIService) processStreamChatAsync(
 ) {
 	defer close(responseStream)
 
-	// 创建Agent配置
-	agentConfig := &agenteneity.AgentConfig{
-		UserID:               endUser.TenantID,
-		InvokeFrom:           consts.InvokeFromServiceAPI,
-		PresetPrompt:         appConfig.PresetPrompt,
-		EnableLongTermMemory: appConfig.LongTermMemory["enabled"].(bool),
-		Tools:                tools,
-	}
-
-	if appConfig.LongTermMemory != nil {
-		if enable, ok := appConfig.LongTermMemory["enable"].(bool); ok {
-			agentConfig.EnableLongTermMemory = enable
-		}
-	}
-
-	if err := util.ConvertViaJSON(&agentConfig.ReviewConfig, appConfig.ReviewConfig); err != nil {
+	// 根据应用配置创建Agent
+	agentInstance, err := s.newAgent(ctx, endUser, appConfig, llm, tools, "")
+	if err != nil {
 		select {
 		case responseStream <- fmt.Sprintf("event: error\ndata: %s\n\n", err.Error()):
 		case <-ctx.Done():
@@ -346,30 +436,18 @@ func (s *OpenAPIService) processStreamChatAsync(
 		return
 	}
 
-	// 根据LLM特性选择Agent类型
-	var agentInstance agent.BaseAgent
-	for _, feature := range llm.GetFeatures() {
-		if feature == llmentity.FeatureToolCall {
-			agentInstance = agent.NewFunctionCallAgent(llm, agentConfig, s.agentManager)
-			break
-		}
-	}
-	if agentInstance == nil {
-		agentInstance = agent.NewReactAgent(llm, agentConfig, s.agentManager)
-	}
-
 	// 创建Agent状态
 	agentState := agenteneity.AgentState{
 		TaskID:         uuid.New(),
 		Messages:       history,
 		History:        history,
-		LongTermMemory: conversation.Summary,
+		LongTermMemory: s.userMemorySvc.RecallLongTermMemory(ctx, conversation, chatReq.Query, appConfig.LongTermMemory),
 		IterationCount: 0,
 	}
 
 	// 添加当前用户消息
 	if len(chatReq.Query) > 0 {
-		userMsg := schema.UserMessage(chatReq.Query)
+		userMsg := s.llmSvc.BuildHumanMessage(ctx, app.AccountID, llm, appConfig.ImageInput, chatReq.Query, chatReq.ImageUrls)
 		agentState.Messages = append(agentState.Messages, userMsg)
 	}
 
@@ -392,13 +470,14 @@ func (s *OpenAPIService) processStreamChatAsync(
 
 		// 除了ping事件，其他事件全部记录
 		if agentThought.Event != agenteneity.EventPing {
-			// 单独处理agent_message事件，因为该事件为数据叠加
-			if agentThought.Event == agenteneity.EventAgentMessage {
+			// 单独处理agent_message与agent_reasoning事件，因为该事件为数据叠加
+			if agentThought.Event == agenteneity.EventAgentMessage || agentThought.Event == agenteneity.EventAgentReasoning {
 				if existing, exists := agentThoughts[eventID]; exists {
 					// 叠加智能体消息事件
 					existing.Thought = existing.Thought + agentThought.Thought
 					existing.Answer = existing.Answer + agentThought.Answer
 					existing.Latency = agentThought.Latency
+					existing.MergeUsage(agentThought)
 					agentThoughts[eventID] = existing
 				} else {
 					// 初始化智能体消息事件
@@ -412,17 +491,19 @@ func (s *OpenAPIService) processStreamChatAsync(
 
 		// 构建响应数据
 		data := map[string]any{
-			"id":              eventID,
-			"conversation_id": conversation.ID.String(),
-			"message_id":      message.ID.String(),
-			"task_id":         agentState.TaskID.String(),
-			"event":           string(agentThought.Event),
-			"thought":         agentThought.Thought,
-			"observation":     agentThought.Observation,
-			"tool":            agentThought.Tool,
-			"tool_input":      agentThought.ToolInput,
-			"answer":          agentThought.Answer,
-			"latency":         agentThought.Latency,
+			"id":                eventID,
+			"conversation_id":   conversation.ID.String(),
+			"message_id":        message.ID.String(),
+			"task_id":           agentState.TaskID.String(),
+			"event":             string(agentThought.Event),
+			"thought":           agentThought.Thought,
+			"observation":       agentThought.Observation,
+			"tool":              agentThought.Tool,
+			"tool_input":        agentThought.ToolInput,
+			"sub_thoughts":      agentThought.SubThoughts,
+			"answer":            agentThought.Answer,
+			"structured_output": agentThought.StructuredOutput,
+			"latency":           agentThought.Latency,
 		}
 
 		jsonData, _ := sonic.Marshal(data)
@@ -448,6 +529,45 @@ func (s *OpenAPIService) processStreamChatAsync(
 		// 记录错误但不中断流程
 		logs.Errorf("Failed to save agent thoughts: %v", err)
 	}
+
+	// 投递长期记忆摘要任务
+	err = s.conversationService.ScheduleConversationSummary(ctx, app.AccountID, conversation.ID, appConfig.LongTermMemory, appConfig.ModelConfig)
+	if err != nil {
+		logs.Errorf("Failed to schedule conversation summary: %v", err)
+	}
+}
+
+// blockingConfirmationUnavailable 阻塞模式下需要确认的工具被拒绝执行时的原因
+const blockingConfirmationUnavailable = "阻塞模式下无法确认工具调用，请使用流式模式(stream=true)调用"
+
+// newAgent 根据应用运行时配置创建Agent，LLM支持工具调用时使用FunctionCallAgent，否则使用ReactAgent，
+// confirmationUnavailable不为空时需要确认的工具直接拒绝执行
+func (s *OpenAPIService) newAgent(ctx context.Context, endUser *entity.EndUser, appConfig *resp.AppDraftConfigResp, llm llmentity.BaseLanguageModel,
+	tools []tool.InvokableTool, confirmationUnavailable string) (agent.BaseAgent, error) {
+	// 1. 创建Agent配置
+	agentConfig := &agenteneity.AgentConfig{
+		UserID:                  endUser.TenantID,
+		InvokeFrom:              consts.InvokeFromServiceAPI,
+		PresetPrompt:            appConfig.PresetPrompt,
+		EnableLongTermMemory:    appConfig.LongTermMemory["enabled"].(bool),
+		Tools:                   tools,
+		ConfirmationTools:       s.appConfigSvc.GetConfirmationToolNames(appConfig.Tools),
+		ConfirmationUnavailable: confirmationUnavailable,
+		OutputSchema:            s.appConfigSvc.GetOutputSchema(appConfig.StructuredOutput),
+	}
+
+	if appConfig.LongTermMemory != nil {
+		if enable, ok := appConfig.LongTermMemory["enable"].(bool); ok {
+			agentConfig.EnableLongTermMemory = enable
+		}
+	}
+
+	if err := util.ConvertViaJSON(&agentConfig.ReviewConfig, appConfig.ReviewConfig); err != nil {
+		return nil, err
+	}
+
+	// 2. 根据LLM特性选择Agent类型
+	return agent.NewAgent(llm, agentConfig, s.agentManager), nil
 }
 
 // createInvokableToolFromInfo 从ToolInfo创建InvokableTool实例
@@ -10,6 +10,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 
@@ -34,7 +35,8 @@ var ProviderSet = wire.NewSet(
 
 func InitOpenAIModule(db *gorm.DB, conversationSvc *conversation.ConversationModule,
 	retrieverModule *retriever.RetrieverModule, llmModule *llm.LLMModule, appConfig *app_config.AppConfigModule,
-	appModule *app.AppModule, agent *agent.AgentQueueManagerFactory, token *memory.TokenBufferMemory) *OpenAPIModule {
+	appModule *app.AppModule, agent *agent.AgentQueueManagerFactory, token *memory.TokenBufferMemory,
+	userMemoryModule *user_memory.UserMemoryModule) *OpenAPIModule {
 	wire.Build(
 		ProviderSet,
 
@@ -44,6 +46,7 @@ func InitOpenAIModule(db *gorm.DB, conversationSvc *conversation.ConversationMod
 		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 		wire.FieldsOf(new(*app.AppModule), "Service"),
 		wire.FieldsOf(new(*app_config.AppConfigModule), "Service"),
+		wire.FieldsOf(new(*user_memory.UserMemoryModule), "Service"),
 	)
 	return new(OpenAPIModule)
 }
@@ -18,13 +18,14 @@ import (
 	"github.com/crazyfrankie/voidx/internal/openapi/repository/dao"
 	"github.com/crazyfrankie/voidx/internal/openapi/service"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitOpenAIModule(db *gorm.DB, conversationSvc *conversation.ConversationModule, retrieverModule *retriever.RetrieverModule, llmModule *llm.LLMModule, appConfig *app_config.AppConfigModule, appModule *app.AppModule, agent2 *agent.AgentQueueManagerFactory, token *memory.TokenBufferMemory) *OpenAPIModule {
+func InitOpenAIModule(db *gorm.DB, conversationSvc *conversation.ConversationModule, retrieverModule *retriever.RetrieverModule, llmModule *llm.LLMModule, appConfig *app_config.AppConfigModule, appModule *app.AppModule, agent2 *agent.AgentQueueManagerFactory, token *memory.TokenBufferMemory, userMemoryModule *user_memory.UserMemoryModule) *OpenAPIModule {
 	openAPIDao := dao.NewOpenAPIDao(db)
 	openAPIRepo := repository.NewOpenAPIRepo(openAPIDao)
 	conversationService := conversationSvc.Service
@@ -32,7 +33,8 @@ func InitOpenAIModule(db *gorm.DB, conversationSvc *conversation.ConversationMod
 	llmService := llmModule.Service
 	appConfigService := appConfig.Service
 	appService := appModule.Service
-	openAPIService := service.NewOpenAPIService(openAPIRepo, conversationService, retrievalService, llmService, appConfigService, appService, agent2, token)
+	userMemoryService := userMemoryModule.Service
+	openAPIService := service.NewOpenAPIService(openAPIRepo, conversationService, retrievalService, llmService, appConfigService, appService, agent2, token, userMemoryService)
 	openAPIHandler := handler.NewOpenAPIHandler(openAPIService)
 	openAPIModule := &OpenAPIModule{
 		Handler: openAPIHandler,
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// whitespacePattern 匹配连续的空白字符
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// htmlHeadingLevels HTML标题元素对应的标题级别
+var htmlHeadingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// htmlSkippedElements 不包含可见正文的元素
+var htmlSkippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Svg: true, atom.Iframe: true,
+}
+
+// htmlParagraphElements 前后需要以空行分隔的块级元素
+var htmlParagraphElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Nav: true, atom.Blockquote: true,
+	atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Table: true,
+	atom.Figure: true, atom.Form: true, atom.Hr: true,
+}
+
+// htmlLineElements 前后需要换行的元素
+var htmlLineElements = map[atom.Atom]bool{
+	atom.Br: true, atom.Li: true, atom.Tr: true, atom.Dt: true, atom.Dd: true,
+	atom.Caption: true, atom.Figcaption: true,
+}
+
+// HTMLTextSplitter HTML文本分割器，按h1-h6标题将页面切分为章节，只保留可见正文，
+// 章节过长时再按递归字符规则切分，文本块保留所在章节的标题路径
+type HTMLTextSplitter struct {
+	MaxHeadingLevel int
+	splitter        *RecursiveCharacterTextSplitter
+}
+
+// NewHTMLTextSplitter 创建HTML文本分割器
+func NewHTMLTextSplitter(config TextSplitterConfig, lengthFunction func(string) int) *HTMLTextSplitter {
+	return &HTMLTextSplitter{
+		MaxHeadingLevel: config.MaxHeadingLevel,
+		splitter:        NewRecursiveCharacterTextSplitter(config, lengthFunction),
+	}
+}
+
+// SplitText 分割文本，文本块前拼接所在章节的标题路径
+func (h *HTMLTextSplitter) SplitText(text string) ([]string, error) {
+	return contextualTexts(h.SplitChunks(text))
+}
+
+// SplitChunks 按标题切分章节，并保留每个文本块的标题路径
+func (h *HTMLTextSplitter) SplitChunks(text string) ([]TextChunk, error) {
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &sectionBuilder{}
+	h.walk(root, builder, false)
+	builder.flush()
+
+	return splitSections(builder.sections, h.splitter)
+}
+
+// walk 深度优先遍历节点，将标题作为章节的分隔，其余可见文本写入章节正文
+func (h *HTMLTextSplitter) walk(node *html.Node, builder *sectionBuilder, preformatted bool) {
+	switch node.Type {
+	case html.TextNode:
+		if preformatted {
+			builder.body.WriteString(node.Data)
+		} else {
+			writeInlineText(builder, whitespacePattern.ReplaceAllString(node.Data, " "))
+		}
+		return
+	case html.ElementNode:
+		if htmlSkippedElements[node.DataAtom] {
+			return
+		}
+		if level, ok := htmlHeadingLevels[node.DataAtom]; ok && level <= h.MaxHeadingLevel {
+			builder.startSection(level, whitespacePattern.ReplaceAllString(htmlText(node), " "))
+			return
+		}
+	}
+
+	separator := ""
+	if node.Type == html.ElementNode {
+		switch {
+		case htmlParagraphElements[node.DataAtom]:
+			separator = "\n\n"
+		case htmlLineElements[node.DataAtom]:
+			separator = "\n"
+		case node.DataAtom == atom.Td || node.DataAtom == atom.Th:
+			separator = " "
+		}
+		preformatted = preformatted || node.DataAtom == atom.Pre
+	}
+
+	writeInlineText(builder, separator)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		h.walk(child, builder, preformatted)
+	}
+	writeInlineText(builder, separator)
+}
+
+// writeInlineText 写入章节正文，忽略行首与重复的空白，保证元素之间只有一个分隔
+func writeInlineText(builder *sectionBuilder, text string) {
+	body := builder.body.String()
+	switch {
+	case body == "" || strings.HasSuffix(body, "\n\n"):
+		text = strings.TrimLeft(text, " \n")
+	case strings.HasSuffix(body, "\n"):
+		text = strings.TrimLeft(text, " ")
+		if text == "\n\n" {
+			text = "\n"
+		} else if text == "\n" {
+			text = ""
+		}
+	case strings.HasSuffix(body, " "):
+		text = strings.TrimLeft(text, " ")
+	}
+	builder.body.WriteString(text)
+}
+
+// htmlText 获取节点内的所有文本
+func htmlText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+
+	var text strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		text.WriteString(htmlText(child))
+	}
+
+	return text.String()
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLTextSplitter(t *testing.T) {
+	splitter := NewHTMLTextSplitter(parseTextSplitterConfig(map[string]any{"splitter": "html"}), nil)
+
+	chunks, err := splitter.SplitChunks(`<html><head><title>t</title><style>p{}</style></head><body>
+<nav>menu</nav>
+<p>lead   text</p>
+<h1>Guide</h1>
+<p>first <b>bold</b> line<br>second line</p>
+<h2>Table</h2>
+<table><tr><th>k</th><th>v</th></tr><tr><td>a</td><td>1</td></tr></table>
+<h2>Code</h2>
+<pre>x  =  1
+y = 2</pre>
+<script>alert(1)</script>
+</body></html>`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TextChunk{
+		{Content: "menu\n\nlead text"},
+		{Content: "first bold line\nsecond line", HeadingPath: []string{"Guide"}},
+		{Content: "k v\na 1", HeadingPath: []string{"Guide", "Table"}},
+		{Content: "x  =  1\ny = 2", HeadingPath: []string{"Guide", "Code"}},
+	}, chunks)
+}
+
+func TestHTMLTextSplitterMaxHeadingLevel(t *testing.T) {
+	splitter := NewHTMLTextSplitter(parseTextSplitterConfig(map[string]any{"max_heading_level": 1}), nil)
+
+	texts, err := splitter.SplitText("<h1>A</h1><p>one</p><h2>B</h2><p>two</p>")
+	assert.NoError(t, err)
+	// headings below the max level are kept as body text
+	assert.Equal(t, []string{"A\none\n\nB\n\ntwo"}, texts)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownHeadingPattern 匹配ATX风格的Markdown标题，如"## 标题"
+	markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})[ \t]+(.+?)[ \t#]*$`)
+	// markdownFencePattern 匹配代码块的开始与结束标记，代码块中的#不是标题
+	markdownFencePattern = regexp.MustCompile("^[ \t]*(```|~~~)")
+	// trailingSpacePattern 匹配行尾的空白字符
+	trailingSpacePattern = regexp.MustCompile(`[ \t]+\n`)
+	// extraNewlinePattern 匹配3个或更多连续的换行符
+	extraNewlinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// MarkdownTextSplitter Markdown文本分割器，按标题将文档切分为章节，章节过长时再按递归字符规则切分，
+// 文本块保留所在章节的标题路径
+type MarkdownTextSplitter struct {
+	MaxHeadingLevel int
+	splitter        *RecursiveCharacterTextSplitter
+}
+
+// NewMarkdownTextSplitter 创建Markdown文本分割器
+func NewMarkdownTextSplitter(config TextSplitterConfig, lengthFunction func(string) int) *MarkdownTextSplitter {
+	return &MarkdownTextSplitter{
+		MaxHeadingLevel: config.MaxHeadingLevel,
+		splitter:        NewRecursiveCharacterTextSplitter(config, lengthFunction),
+	}
+}
+
+// SplitText 分割文本，文本块前拼接所在章节的标题路径
+func (m *MarkdownTextSplitter) SplitText(text string) ([]string, error) {
+	return contextualTexts(m.SplitChunks(text))
+}
+
+// SplitChunks 按标题切分章节，并保留每个文本块的标题路径
+func (m *MarkdownTextSplitter) SplitChunks(text string) ([]TextChunk, error) {
+	builder := &sectionBuilder{}
+	fence := ""
+	for _, line := range strings.Split(text, "\n") {
+		// 1. 代码块中的内容原样保留
+		if match := markdownFencePattern.FindStringSubmatch(line); match != nil {
+			if fence == "" {
+				fence = match[1]
+			} else if fence == match[1] {
+				fence = ""
+			}
+		} else if fence == "" {
+			// 2. 遇到不超过最大级别的标题时开始新的章节
+			if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil && len(match[1]) <= m.MaxHeadingLevel {
+				builder.startSection(len(match[1]), match[2])
+				continue
+			}
+		}
+
+		builder.body.WriteString(line)
+		builder.body.WriteString("\n")
+	}
+	builder.flush()
+
+	return splitSections(builder.sections, m.splitter)
+}
+
+// textSection 文档中的一个章节
+type textSection struct {
+	headingPath []string
+	content     string
+}
+
+// sectionHeading 章节标题及其级别
+type sectionHeading struct {
+	level int
+	title string
+}
+
+// sectionBuilder 按标题层级收集章节，章节的标题路径由当前标题及其所有上级标题组成
+type sectionBuilder struct {
+	headings []sectionHeading
+	body     strings.Builder
+	sections []textSection
+}
+
+// startSection 结束当前章节，并以传递的标题开始新的章节
+func (b *sectionBuilder) startSection(level int, title string) {
+	b.flush()
+
+	// 同级及更低级别的标题不再是新章节的上级标题
+	for len(b.headings) > 0 && b.headings[len(b.headings)-1].level >= level {
+		b.headings = b.headings[:len(b.headings)-1]
+	}
+	if title = strings.TrimSpace(title); title != "" {
+		b.headings = append(b.headings, sectionHeading{level: level, title: title})
+	}
+}
+
+// flush 将已收集的正文记录为一个章节，没有正文的章节会被忽略
+func (b *sectionBuilder) flush() {
+	content := trailingSpacePattern.ReplaceAllString(b.body.String(), "\n")
+	content = strings.TrimSpace(extraNewlinePattern.ReplaceAllString(content, "\n\n"))
+	b.body.Reset()
+	if content == "" {
+		return
+	}
+
+	var headingPath []string
+	for _, heading := range b.headings {
+		headingPath = append(headingPath, heading.title)
+	}
+	b.sections = append(b.sections, textSection{headingPath: headingPath, content: content})
+}
+
+// splitSections 将章节正文切分为文本块，章节过长时使用递归字符分割器继续切分
+func splitSections(sections []textSection, splitter *RecursiveCharacterTextSplitter) ([]TextChunk, error) {
+	var chunks []TextChunk
+	for _, section := range sections {
+		texts, err := splitter.SplitText(section.content)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, toTextChunks(texts, section.headingPath)...)
+	}
+
+	return chunks, nil
+}
+
+// contextualTexts 将文本块转换为拼接了标题路径的文本
+func contextualTexts(chunks []TextChunk, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		texts = append(texts, chunk.ContextualContent())
+	}
+
+	return texts, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownTextSplitter(t *testing.T) {
+	splitter := NewMarkdownTextSplitter(parseTextSplitterConfig(map[string]any{"splitter": "markdown"}), nil)
+
+	chunks, err := splitter.SplitChunks(`intro
+
+# Guide
+overview
+
+## Install ##
+run it
+
+` + "```" + `
+# not a heading
+` + "```" + `
+
+### Linux
+apt
+
+## Usage
+use it
+#hashtag is not a heading
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TextChunk{
+		{Content: "intro"},
+		{Content: "overview", HeadingPath: []string{"Guide"}},
+		{Content: "run it\n\n```\n# not a heading\n```", HeadingPath: []string{"Guide", "Install"}},
+		{Content: "apt", HeadingPath: []string{"Guide", "Install", "Linux"}},
+		{Content: "use it\n#hashtag is not a heading", HeadingPath: []string{"Guide", "Usage"}},
+	}, chunks)
+}
+
+func TestMarkdownTextSplitterMaxHeadingLevel(t *testing.T) {
+	splitter := NewMarkdownTextSplitter(parseTextSplitterConfig(map[string]any{"max_heading_level": 1}), nil)
+
+	texts, err := splitter.SplitText("# A\none\n## B\ntwo\n# C\nthree")
+	assert.NoError(t, err)
+	// headings below the max level stay in the body
+	assert.Equal(t, []string{"A\none\n## B\ntwo", "C\nthree"}, texts)
+}
+
+func TestMarkdownTextSplitterLongSection(t *testing.T) {
+	splitter := NewMarkdownTextSplitter(TextSplitterConfig{
+		ChunkSize:       10,
+		Separators:      []string{"\n\n", " "},
+		MaxHeadingLevel: 6,
+	}, nil)
+
+	chunks, err := splitter.SplitChunks("# Title\n\naaaa bbbb cccc")
+	assert.NoError(t, err)
+	// every chunk of a long section keeps the heading path
+	assert.Equal(t, []TextChunk{
+		{Content: "aaaa bbbb", HeadingPath: []string{"Title"}},
+		{Content: "cccc", HeadingPath: []string{"Title"}},
+	}, chunks)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"regexp"
 	"strings"
 
@@ -10,6 +12,7 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/process_rule/repository"
+	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/crazyfrankie/voidx/types/errno"
 )
 
@@ -25,16 +28,41 @@ func NewProcessRuleService(repo *repository.ProcessRuleRepo) *ProcessRuleService
 
 // TextSplitterConfig 文本分割器配置
 type TextSplitterConfig struct {
-	ChunkSize    int      `json:"chunk_size"`
-	ChunkOverlap int      `json:"chunk_overlap"`
-	Separators   []string `json:"separators"`
+	Splitter     consts.SplitterType `json:"splitter"`
+	ChunkSize    int                 `json:"chunk_size"`
+	ChunkOverlap int                 `json:"chunk_overlap"`
+	Separators   []string            `json:"separators"`
+	// MaxHeadingLevel Markdown与HTML分割器切分章节的最大标题级别，更低级别的标题作为正文
+	MaxHeadingLevel int `json:"max_heading_level"`
+	// BreakpointPercentile 语义分割器的切分阈值，相邻句子的向量距离超过该百分位数时切分
+	BreakpointPercentile float64 `json:"breakpoint_percentile"`
 }
 
 // TextSplitter 文本分割器接口
 type TextSplitter interface {
 	SplitText(text string) ([]string, error)
+	// SplitChunks 分割文本并保留文本块所在的标题路径，不识别文档结构的分割器标题路径为空
+	SplitChunks(text string) ([]TextChunk, error)
 }
 
+// TextChunk 分割得到的文本块
+type TextChunk struct {
+	Content     string
+	HeadingPath []string
+}
+
+// ContextualContent 返回在内容前拼接标题路径的文本，为检索提供文本块所在章节的上下文
+func (c TextChunk) ContextualContent() string {
+	if len(c.HeadingPath) == 0 {
+		return c.Content
+	}
+
+	return strings.Join(c.HeadingPath, " > ") + "\n" + c.Content
+}
+
+// EmbedFunction 文本向量化函数，语义分割器使用其计算相邻句子的相似度
+type EmbedFunction func(texts []string) ([][]float64, error)
+
 // RecursiveCharacterTextSplitter 递归字符文本分割器
 type RecursiveCharacterTextSplitter struct {
 	ChunkSize        int
@@ -64,6 +92,26 @@ func (r *RecursiveCharacterTextSplitter) SplitText(text string) ([]string, error
 	return r.splitTextRecursive(text, r.Separators)
 }
 
+// SplitChunks 分割文本，递归字符分割器不识别文档结构
+func (r *RecursiveCharacterTextSplitter) SplitChunks(text string) ([]TextChunk, error) {
+	texts, err := r.SplitText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTextChunks(texts, nil), nil
+}
+
+// toTextChunks 将分割得到的文本转换为位于同一标题路径下的文本块
+func toTextChunks(texts []string, headingPath []string) []TextChunk {
+	chunks := make([]TextChunk, 0, len(texts))
+	for _, text := range texts {
+		chunks = append(chunks, TextChunk{Content: text, HeadingPath: headingPath})
+	}
+
+	return chunks
+}
+
 // splitTextRecursive 递归分割文本
 func (r *RecursiveCharacterTextSplitter) splitTextRecursive(text string, separators []string) ([]string, error) {
 	var finalChunks []string
@@ -181,11 +229,13 @@ func (r *RecursiveCharacterTextSplitter) splitByLength(text string) []string {
 	return chunks
 }
 
-// GetTextSplitterByProcessRule 根据传递的处理规则+长度计算函数，获取相应的文本分割器
+// GetTextSplitterByProcessRule 根据传递的处理规则+长度计算函数，获取相应的文本分割器，
+// 语义分割器使用embedFunction计算句子向量
 func (s *ProcessRuleService) GetTextSplitterByProcessRule(
 	ctx context.Context,
 	processRule *entity.ProcessRule,
 	lengthFunction func(string) int,
+	embedFunction EmbedFunction,
 ) (TextSplitter, error) {
 	// 解析规则JSON
 	rule := processRule.Rule
@@ -196,18 +246,158 @@ func (s *ProcessRuleService) GetTextSplitterByProcessRule(
 		return nil, errno.ErrInternalServer.AppendBizMessage(errors.New("获取应用的调试会话消息列表"))
 	}
 
-	config := TextSplitterConfig{}
+	return newTextSplitter(parseTextSplitterConfig(segment), lengthFunction, embedFunction)
+}
 
-	if chunkSize, ok := segment["chunk_size"].(float64); ok {
-		config.ChunkSize = int(chunkSize)
-	} else {
-		config.ChunkSize = 1000 // 默认值
+// GetChildTextSplitterByProcessRule 获取父子分段中将父片段切分为子片段的文本分割器，未配置child_segment时按句子切分
+func (s *ProcessRuleService) GetChildTextSplitterByProcessRule(
+	ctx context.Context,
+	processRule *entity.ProcessRule,
+	lengthFunction func(string) int,
+	embedFunction EmbedFunction,
+) (TextSplitter, error) {
+	childSegment, ok := processRule.Rule["child_segment"].(map[string]any)
+	if !ok {
+		childSegment = consts.DefaultChildSegmentRule
 	}
 
-	if chunkOverlap, ok := segment["chunk_overlap"].(float64); ok {
-		config.ChunkOverlap = int(chunkOverlap)
-	} else {
-		config.ChunkOverlap = 200 // 默认值
+	return newTextSplitter(parseTextSplitterConfig(childSegment), lengthFunction, embedFunction)
+}
+
+// GetChildSplitterKeyByProcessRule 获取父子分段中子片段分割配置的标识，子片段的分割配置不变时标识不变
+func (s *ProcessRuleService) GetChildSplitterKeyByProcessRule(processRule *entity.ProcessRule) string {
+	childSegment, ok := processRule.Rule["child_segment"].(map[string]any)
+	if !ok {
+		childSegment = consts.DefaultChildSegmentRule
+	}
+
+	return fmt.Sprintf("%+v", parseTextSplitterConfig(childSegment))
+}
+
+// newTextSplitter 根据分段规则中的splitter字段创建文本分割器，未配置时使用递归字符分割器
+func newTextSplitter(config TextSplitterConfig, lengthFunction func(string) int, embedFunction EmbedFunction) (TextSplitter, error) {
+	switch config.Splitter {
+	case "", consts.SplitterTypeRecursiveCharacter:
+		return NewRecursiveCharacterTextSplitter(config, lengthFunction), nil
+	case consts.SplitterTypeMarkdown:
+		return NewMarkdownTextSplitter(config, lengthFunction), nil
+	case consts.SplitterTypeHTML:
+		return NewHTMLTextSplitter(config, lengthFunction), nil
+	case consts.SplitterTypeToken:
+		return NewTokenTextSplitter(config, lengthFunction), nil
+	case consts.SplitterTypeSemantic:
+		if embedFunction == nil {
+			return nil, errno.ErrInternalServer.AppendBizMessage(errors.New("语义分割器缺少向量化函数"))
+		}
+		return NewSemanticTextSplitter(config, lengthFunction, embedFunction), nil
+	default:
+		return nil, errno.ErrValidate.AppendBizMessage(fmt.Errorf("不支持的文本分割器: %s", config.Splitter))
+	}
+}
+
+// ValidateProcessRule 校验处理规则中的分段配置，创建文档与爬取来源时调用，避免规则错误到异步构建时才暴露。
+// 自定义与父子分段必须配置segment，父子分段的child_segment可选
+func ValidateProcessRule(mode string, rule map[string]any) error {
+	switch consts.ProcessType(mode) {
+	case "", consts.ProcessTypeAutomatic, consts.ProcessTypeCustom, consts.ProcessTypeHierarchical:
+	default:
+		return fmt.Errorf("unsupported process type: %s", mode)
+	}
+
+	segment, exists := rule["segment"]
+	if !exists {
+		if consts.ProcessType(mode) == consts.ProcessTypeCustom || consts.ProcessType(mode) == consts.ProcessTypeHierarchical {
+			return errors.New("segment is required")
+		}
+	} else if err := validateSegmentRule("segment", segment); err != nil {
+		return err
+	}
+
+	if childSegment, exists := rule["child_segment"]; exists {
+		return validateSegmentRule("child_segment", childSegment)
+	}
+
+	return nil
+}
+
+// validateSegmentRule 校验单个分段配置的分割器类型与取值范围
+func validateSegmentRule(name string, value any) error {
+	segment, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s must be an object", name)
+	}
+
+	if splitter, exists := segment["splitter"]; exists {
+		s, ok := splitter.(string)
+		switch consts.SplitterType(s) {
+		case consts.SplitterTypeRecursiveCharacter, consts.SplitterTypeMarkdown, consts.SplitterTypeHTML,
+			consts.SplitterTypeToken, consts.SplitterTypeSemantic:
+		default:
+			ok = false
+		}
+		if !ok {
+			return fmt.Errorf("%s.splitter is not supported: %v", name, splitter)
+		}
+	}
+
+	ranges := []struct {
+		key      string
+		min, max float64
+	}{
+		{key: "chunk_size", min: 1, max: math.MaxInt32},
+		{key: "chunk_overlap", min: 0, max: math.MaxInt32},
+		{key: "max_heading_level", min: 1, max: 6},
+		{key: "breakpoint_percentile", min: 1, max: 100},
+	}
+	for _, r := range ranges {
+		value, exists := segment[r.key]
+		if !exists {
+			continue
+		}
+		number, ok := numberValue(value)
+		if !ok || number < r.min || number > r.max {
+			return fmt.Errorf("%s.%s must be a number between %v and %v", name, r.key, r.min, r.max)
+		}
+	}
+
+	if separators, exists := segment["separators"]; exists {
+		list, ok := separators.([]any)
+		if !ok {
+			return fmt.Errorf("%s.separators must be a list of strings", name)
+		}
+		for _, sep := range list {
+			if _, ok := sep.(string); !ok {
+				return fmt.Errorf("%s.separators must be a list of strings", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// numberValue 读取json解码或代码中定义的数值配置
+func numberValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// parseTextSplitterConfig 从分段规则中解析文本分割器配置，未配置的字段使用默认值
+func parseTextSplitterConfig(segment map[string]any) TextSplitterConfig {
+	config := TextSplitterConfig{
+		ChunkSize:            intValue(segment["chunk_size"], 1000),
+		ChunkOverlap:         intValue(segment["chunk_overlap"], 200),
+		MaxHeadingLevel:      intValue(segment["max_heading_level"], 6),
+		BreakpointPercentile: 95,
+	}
+
+	if splitter, ok := segment["splitter"].(string); ok {
+		config.Splitter = consts.SplitterType(splitter)
 	}
 
 	if separators, ok := segment["separators"].([]any); ok {
@@ -221,7 +411,57 @@ func (s *ProcessRuleService) GetTextSplitterByProcessRule(
 		config.Separators = []string{"\n\n", "\n", " ", ""}
 	}
 
-	return NewRecursiveCharacterTextSplitter(config, lengthFunction), nil
+	if config.MaxHeadingLevel < 1 || config.MaxHeadingLevel > 6 {
+		config.MaxHeadingLevel = 6
+	}
+
+	switch percentile := segment["breakpoint_percentile"].(type) {
+	case float64:
+		config.BreakpointPercentile = percentile
+	case int:
+		config.BreakpointPercentile = float64(percentile)
+	}
+	if config.BreakpointPercentile <= 0 || config.BreakpointPercentile > 100 {
+		config.BreakpointPercentile = 95
+	}
+
+	return config
+}
+
+// intValue 读取json解码或代码中定义的整数配置，未配置时返回默认值
+func intValue(value any, defaultValue int) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return defaultValue
+	}
+}
+
+// QuestionVariantConfig 问答对知识库导入时使用模型生成相似问法的配置
+type QuestionVariantConfig struct {
+	// Count 每个问答对生成的相似问法数量
+	Count int `json:"count"`
+	// ModelConfig 生成使用的模型配置，格式与应用的模型配置一致，未配置时使用默认模型
+	ModelConfig map[string]any `json:"model_config"`
+}
+
+// GetQuestionVariantConfigByProcessRule 解析处理规则中的question_variants配置，未配置或生成数量不大于0时返回nil
+func (s *ProcessRuleService) GetQuestionVariantConfigByProcessRule(processRule *entity.ProcessRule) *QuestionVariantConfig {
+	questionVariants, ok := processRule.Rule["question_variants"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	config := &QuestionVariantConfig{Count: intValue(questionVariants["count"], 0)}
+	if config.Count <= 0 {
+		return nil
+	}
+	config.ModelConfig, _ = questionVariants["model_config"].(map[string]any)
+
+	return config
 }
 
 // CleanTextByProcessRule 根据传递的处理规则清除多余的字符串
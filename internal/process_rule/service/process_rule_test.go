@@ -0,0 +1,229 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+func TestRecursiveCharacterTextSplitter(t *testing.T) {
+	splitter := NewRecursiveCharacterTextSplitter(TextSplitterConfig{
+		ChunkSize:  12,
+		Separators: []string{"\n\n", "\n", " ", ""},
+	}, nil)
+
+	texts, err := splitter.SplitText("aaaa bbbb\n\ncccc dddd eeee\n\nff")
+	assert.NoError(t, err)
+	// the long paragraph is split by the next separator, its pieces are not merged with the following paragraph
+	assert.Equal(t, []string{"aaaa bbbb", "cccc dddd", "eeee", "ff"}, texts)
+	for _, text := range texts {
+		assert.LessOrEqual(t, len(text), 12)
+	}
+}
+
+func TestRecursiveCharacterTextSplitterOverlap(t *testing.T) {
+	splitter := NewRecursiveCharacterTextSplitter(TextSplitterConfig{
+		ChunkSize:    11,
+		ChunkOverlap: 5,
+		Separators:   []string{" "},
+	}, nil)
+
+	texts, err := splitter.SplitText("aaa bbb ccc ddd")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aaa bbb", "bbb ccc", "ccc ddd"}, texts)
+}
+
+func TestRecursiveCharacterTextSplitterByLength(t *testing.T) {
+	splitter := NewRecursiveCharacterTextSplitter(TextSplitterConfig{ChunkSize: 4, ChunkOverlap: 1}, nil)
+
+	texts, err := splitter.SplitText("abcdefghij")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcd", "defg", "ghij"}, texts)
+}
+
+func TestRecursiveCharacterTextSplitterInvalidSeparator(t *testing.T) {
+	splitter := NewRecursiveCharacterTextSplitter(TextSplitterConfig{ChunkSize: 4, Separators: []string{"("}}, nil)
+
+	_, err := splitter.SplitText("abcdefghij")
+	assert.Error(t, err)
+}
+
+func TestParseTextSplitterConfig(t *testing.T) {
+	config := parseTextSplitterConfig(map[string]any{})
+	assert.Equal(t, TextSplitterConfig{
+		ChunkSize:            1000,
+		ChunkOverlap:         200,
+		Separators:           []string{"\n\n", "\n", " ", ""},
+		MaxHeadingLevel:      6,
+		BreakpointPercentile: 95,
+	}, config)
+
+	config = parseTextSplitterConfig(map[string]any{
+		"splitter":              "semantic",
+		"chunk_size":            float64(300),
+		"chunk_overlap":         20,
+		"separators":            []any{"\n", 1, "。"},
+		"max_heading_level":     float64(9),
+		"breakpoint_percentile": 80,
+	})
+	assert.Equal(t, TextSplitterConfig{
+		Splitter:             consts.SplitterTypeSemantic,
+		ChunkSize:            300,
+		ChunkOverlap:         20,
+		Separators:           []string{"\n", "。"},
+		MaxHeadingLevel:      6,
+		BreakpointPercentile: 80,
+	}, config)
+}
+
+func TestNewTextSplitter(t *testing.T) {
+	embed := func(texts []string) ([][]float64, error) { return nil, nil }
+
+	tests := []struct {
+		splitter consts.SplitterType
+		embed    EmbedFunction
+		want     any
+		wantErr  bool
+	}{
+		{splitter: "", want: &RecursiveCharacterTextSplitter{}},
+		{splitter: consts.SplitterTypeRecursiveCharacter, want: &RecursiveCharacterTextSplitter{}},
+		{splitter: consts.SplitterTypeMarkdown, want: &MarkdownTextSplitter{}},
+		{splitter: consts.SplitterTypeHTML, want: &HTMLTextSplitter{}},
+		{splitter: consts.SplitterTypeToken, want: &TokenTextSplitter{}},
+		{splitter: consts.SplitterTypeSemantic, embed: embed, want: &SemanticTextSplitter{}},
+		{splitter: consts.SplitterTypeSemantic, wantErr: true},
+		{splitter: "sentence", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.splitter), func(t *testing.T) {
+			splitter, err := newTextSplitter(TextSplitterConfig{Splitter: tt.splitter, ChunkSize: 10}, nil, tt.embed)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, tt.want, splitter)
+		})
+	}
+}
+
+func TestValidateProcessRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		rule    map[string]any
+		wantErr string
+	}{
+		{name: "automatic without rule", mode: "automatic"},
+		{name: "default rule", mode: "custom", rule: consts.DefaultProcessRule["rule"].(map[string]any)},
+		{
+			name: "semantic",
+			mode: "custom",
+			rule: map[string]any{"segment": map[string]any{"splitter": "semantic", "chunk_size": 500, "breakpoint_percentile": float64(90)}},
+		},
+		{
+			name: "hierarchical with child segment",
+			mode: "hierarchical",
+			rule: map[string]any{
+				"segment":       map[string]any{"splitter": "markdown", "max_heading_level": 3},
+				"child_segment": map[string]any{"splitter": "token", "chunk_size": 100, "chunk_overlap": 0},
+			},
+		},
+		{name: "unknown mode", mode: "manual", wantErr: "unsupported process type: manual"},
+		{name: "custom without segment", mode: "custom", rule: map[string]any{}, wantErr: "segment is required"},
+		{name: "segment not an object", mode: "custom", rule: map[string]any{"segment": "auto"}, wantErr: "segment must be an object"},
+		{
+			name:    "unknown splitter",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"splitter": "sentence"}},
+			wantErr: "segment.splitter is not supported: sentence",
+		},
+		{
+			name:    "splitter not a string",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"splitter": 1}},
+			wantErr: "segment.splitter is not supported: 1",
+		},
+		{
+			name:    "percentile out of range",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"splitter": "semantic", "breakpoint_percentile": float64(120)}},
+			wantErr: "segment.breakpoint_percentile must be a number between 1 and 100",
+		},
+		{
+			name:    "percentile not a number",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"splitter": "semantic", "breakpoint_percentile": "95"}},
+			wantErr: "segment.breakpoint_percentile must be a number between 1 and 100",
+		},
+		{
+			name:    "zero chunk size",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"chunk_size": 0}},
+			wantErr: "segment.chunk_size must be a number between 1 and 2.147483647e+09",
+		},
+		{
+			name:    "heading level out of range",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"splitter": "html", "max_heading_level": 7}},
+			wantErr: "segment.max_heading_level must be a number between 1 and 6",
+		},
+		{
+			name:    "separators not strings",
+			mode:    "custom",
+			rule:    map[string]any{"segment": map[string]any{"separators": []any{"\n", 1}}},
+			wantErr: "segment.separators must be a list of strings",
+		},
+		{
+			name: "invalid child segment",
+			mode: "hierarchical",
+			rule: map[string]any{
+				"segment":       map[string]any{},
+				"child_segment": map[string]any{"splitter": "page"},
+			},
+			wantErr: "child_segment.splitter is not supported: page",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProcessRule(tt.mode, tt.rule)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTextChunkContextualContent(t *testing.T) {
+	assert.Equal(t, "body", TextChunk{Content: "body"}.ContextualContent())
+	assert.Equal(t, "A > B\nbody", TextChunk{Content: "body", HeadingPath: []string{"A", "B"}}.ContextualContent())
+	assert.True(t, strings.HasPrefix(TextChunk{Content: "x", HeadingPath: []string{"A"}}.ContextualContent(), "A\n"))
+}
+
+func TestGetChildSplitterKeyByProcessRule(t *testing.T) {
+	s := &ProcessRuleService{}
+	rule := func(childSegment map[string]any) *entity.ProcessRule {
+		processRule := &entity.ProcessRule{
+			Mode: string(consts.ProcessTypeHierarchical),
+			Rule: map[string]any{"segment": map[string]any{"chunk_size": float64(1000)}},
+		}
+		if childSegment != nil {
+			processRule.Rule["child_segment"] = childSegment
+		}
+		return processRule
+	}
+
+	small := s.GetChildSplitterKeyByProcessRule(rule(map[string]any{"chunk_size": float64(200), "chunk_overlap": float64(20)}))
+	assert.Equal(t, small, s.GetChildSplitterKeyByProcessRule(rule(map[string]any{"chunk_overlap": float64(20), "chunk_size": float64(200)})))
+	assert.NotEqual(t, small, s.GetChildSplitterKeyByProcessRule(rule(map[string]any{"chunk_size": float64(300), "chunk_overlap": float64(20)})))
+	assert.NotEqual(t, small, s.GetChildSplitterKeyByProcessRule(rule(nil)))
+	assert.Equal(t, s.GetChildSplitterKeyByProcessRule(rule(nil)), s.GetChildSplitterKeyByProcessRule(rule(consts.DefaultChildSegmentRule)))
+}
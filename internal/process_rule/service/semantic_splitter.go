@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// semanticEmbedBatchSize 语义分割器每次向量化的句子数量，避免超过向量模型单次请求的上限
+const semanticEmbedBatchSize = 64
+
+// SemanticTextSplitter 语义文本分割器，计算相邻句子的向量距离，在距离超过BreakpointPercentile百分位数处切分，
+// 文本块的长度同时不超过ChunkSize
+type SemanticTextSplitter struct {
+	ChunkSize            int
+	BreakpointPercentile float64
+	LengthFunction       func(string) int
+	EmbedFunction        EmbedFunction
+	sentenceSplitter     *TokenTextSplitter
+}
+
+// NewSemanticTextSplitter 创建语义文本分割器
+func NewSemanticTextSplitter(config TextSplitterConfig, lengthFunction func(string) int, embedFunction EmbedFunction) *SemanticTextSplitter {
+	sentenceSplitter := NewTokenTextSplitter(config, lengthFunction)
+
+	return &SemanticTextSplitter{
+		ChunkSize:            config.ChunkSize,
+		BreakpointPercentile: config.BreakpointPercentile,
+		LengthFunction:       sentenceSplitter.LengthFunction,
+		EmbedFunction:        embedFunction,
+		sentenceSplitter:     sentenceSplitter,
+	}
+}
+
+// SplitChunks 分割文本，语义分割不识别文档结构
+func (s *SemanticTextSplitter) SplitChunks(text string) ([]TextChunk, error) {
+	texts, err := s.SplitText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTextChunks(texts, nil), nil
+}
+
+// SplitText 分割文本
+func (s *SemanticTextSplitter) SplitText(text string) ([]string, error) {
+	// 1. 将文本切分为句子，超过ChunkSize的句子继续切分
+	var sentences []string
+	for _, sentence := range sentencePattern.FindAllString(text, -1) {
+		if strings.TrimSpace(sentence) == "" {
+			continue
+		}
+		sentences = append(sentences, s.sentenceSplitter.splitLongUnit(sentence)...)
+	}
+	if len(sentences) <= 1 {
+		return s.sentenceSplitter.SplitText(text)
+	}
+
+	// 2. 每个句子与前后各一个句子组合后向量化，减少短句带来的噪声
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		windows[i] = strings.Join(sentences[max(i-1, 0):min(i+2, len(sentences))], "")
+	}
+	embeddings := make([][]float64, 0, len(windows))
+	for i := 0; i < len(windows); i += semanticEmbedBatchSize {
+		batch, err := s.EmbedFunction(windows[i:min(i+semanticEmbedBatchSize, len(windows))])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences: %w", err)
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	if len(embeddings) != len(sentences) {
+		return nil, fmt.Errorf("expected %d sentence embeddings, got %d", len(sentences), len(embeddings))
+	}
+
+	// 3. 计算相邻句子的余弦距离，距离超过百分位数阈值的位置作为切分点
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity(embeddings[i], embeddings[i+1])
+	}
+	threshold := percentile(distances, s.BreakpointPercentile)
+
+	// 4. 在切分点或长度即将超过ChunkSize时生成文本块
+	var chunks []string
+	var current []string
+	total := 0
+	appendChunk := func() {
+		if chunk := strings.TrimSpace(strings.Join(current, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current, total = nil, 0
+	}
+	for i, sentence := range sentences {
+		length := s.LengthFunction(sentence)
+		if len(current) > 0 && total+length > s.ChunkSize {
+			appendChunk()
+		}
+		current = append(current, sentence)
+		total += length
+		if i < len(distances) && distances[i] > threshold {
+			appendChunk()
+		}
+	}
+	appendChunk()
+
+	return chunks, nil
+}
+
+// cosineSimilarity 计算两个向量的余弦相似度，任一向量为零向量时返回0
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// percentile 使用线性插值计算百分位数
+func percentile(values []float64, p float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
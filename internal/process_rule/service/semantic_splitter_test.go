@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// topicEmbed embeds a text by the topics it mentions, so sentences about the same topic are similar
+func topicEmbed(calls *int) EmbedFunction {
+	return func(texts []string) ([][]float64, error) {
+		*calls++
+		embeddings := make([][]float64, 0, len(texts))
+		for _, text := range texts {
+			embeddings = append(embeddings, []float64{
+				float64(strings.Count(text, "cat")),
+				float64(strings.Count(text, "car")),
+			})
+		}
+		return embeddings, nil
+	}
+}
+
+func TestSemanticTextSplitter(t *testing.T) {
+	calls := 0
+	splitter := NewSemanticTextSplitter(TextSplitterConfig{ChunkSize: 1000, BreakpointPercentile: 50}, nil, topicEmbed(&calls))
+
+	texts, err := splitter.SplitText("The cat sleeps. A cat purrs. My cat eats. The car starts. A car stops. Old car rusts.")
+	assert.NoError(t, err)
+	// the topic changes between the third and the fourth sentence
+	assert.Equal(t, []string{
+		"The cat sleeps. A cat purrs. My cat eats.",
+		"The car starts. A car stops. Old car rusts.",
+	}, texts)
+	assert.Equal(t, 1, calls)
+}
+
+func TestSemanticTextSplitterChunkSize(t *testing.T) {
+	calls := 0
+	splitter := NewSemanticTextSplitter(TextSplitterConfig{ChunkSize: 30, BreakpointPercentile: 100}, nil, topicEmbed(&calls))
+
+	texts, err := splitter.SplitText("The cat sleeps. A cat purrs. My cat eats.")
+	assert.NoError(t, err)
+	// no distance exceeds the 100th percentile, chunks are only cut by length
+	assert.Equal(t, []string{"The cat sleeps. A cat purrs.", "My cat eats."}, texts)
+}
+
+func TestSemanticTextSplitterSingleSentence(t *testing.T) {
+	calls := 0
+	splitter := NewSemanticTextSplitter(TextSplitterConfig{ChunkSize: 100, BreakpointPercentile: 95}, nil, topicEmbed(&calls))
+
+	texts, err := splitter.SplitText("only one sentence")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"only one sentence"}, texts)
+	assert.Equal(t, 0, calls)
+}
+
+func TestSemanticTextSplitterEmbedError(t *testing.T) {
+	splitter := NewSemanticTextSplitter(TextSplitterConfig{ChunkSize: 100, BreakpointPercentile: 95}, nil,
+		func(texts []string) ([][]float64, error) { return nil, errors.New("quota exceeded") })
+
+	_, err := splitter.SplitText("one. two. three.")
+	assert.ErrorContains(t, err, "quota exceeded")
+
+	splitter.EmbedFunction = func(texts []string) ([][]float64, error) { return [][]float64{{1}}, nil }
+	_, err = splitter.SplitText("one. two. three.")
+	assert.EqualError(t, err, "expected 3 sentence embeddings, got 1")
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{4, 1, 3, 2}
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 2.5, percentile(values, 50))
+	assert.Equal(t, 4.0, percentile(values, 100))
+	assert.Equal(t, []float64{4, 1, 3, 2}, values)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1.0, cosineSimilarity([]float64{1, 2}, []float64{2, 4}), 1e-9)
+	assert.InDelta(t, 0.0, cosineSimilarity([]float64{1, 0}, []float64{0, 1}), 1e-9)
+	assert.Equal(t, 0.0, cosineSimilarity([]float64{0, 0}, []float64{1, 1}))
+}
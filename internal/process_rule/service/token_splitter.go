@@ -0,0 +1,117 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// sentencePattern 匹配以句末标点、英文句号加空白或换行结尾的句子，末尾不完整的句子同样会被匹配
+	sentencePattern = regexp.MustCompile(`(?s).+?(?:[。！？；!?;\n]+|\.\s+|$)`)
+	// wordPattern 匹配单词及其后的空白字符
+	wordPattern = regexp.MustCompile(`\S+\s*|\s+`)
+)
+
+// TokenTextSplitter 按token数切分文本的分割器，文本块的token数不超过ChunkSize，
+// 优先在句子与单词的边界处切分，相邻文本块之间重叠不超过ChunkOverlap个token
+type TokenTextSplitter struct {
+	ChunkSize      int
+	ChunkOverlap   int
+	LengthFunction func(string) int
+}
+
+// NewTokenTextSplitter 创建按token数切分的文本分割器，lengthFunction为知识库向量模型的token计数函数
+func NewTokenTextSplitter(config TextSplitterConfig, lengthFunction func(string) int) *TokenTextSplitter {
+	if lengthFunction == nil {
+		lengthFunction = func(s string) int { return len(s) }
+	}
+
+	return &TokenTextSplitter{
+		ChunkSize:      config.ChunkSize,
+		ChunkOverlap:   config.ChunkOverlap,
+		LengthFunction: lengthFunction,
+	}
+}
+
+// SplitChunks 分割文本，按token数切分不识别文档结构
+func (t *TokenTextSplitter) SplitChunks(text string) ([]TextChunk, error) {
+	texts, err := t.SplitText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return toTextChunks(texts, nil), nil
+}
+
+// SplitText 分割文本
+func (t *TokenTextSplitter) SplitText(text string) ([]string, error) {
+	// 1. 将文本切分为句子，超过ChunkSize的句子继续按单词与字符切分
+	var units []string
+	var lengths []int
+	for _, sentence := range sentencePattern.FindAllString(text, -1) {
+		for _, unit := range t.splitLongUnit(sentence) {
+			units = append(units, unit)
+			lengths = append(lengths, t.LengthFunction(unit))
+		}
+	}
+
+	// 2. 依次合并切分单元，超过ChunkSize时生成文本块，并保留末尾的单元作为下一个文本块的重叠部分
+	var chunks []string
+	appendChunk := func(units []string) {
+		if chunk := strings.TrimSpace(strings.Join(units, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	start, total := 0, 0
+	for i := range units {
+		if total+lengths[i] > t.ChunkSize && i > start {
+			appendChunk(units[start:i])
+
+			overlap, next := 0, i
+			for next > start+1 && overlap+lengths[next-1] <= t.ChunkOverlap && overlap+lengths[next-1]+lengths[i] <= t.ChunkSize {
+				next--
+				overlap += lengths[next]
+			}
+			start, total = next, overlap
+		}
+		total += lengths[i]
+	}
+	if start < len(units) {
+		appendChunk(units[start:])
+	}
+
+	return chunks, nil
+}
+
+// splitLongUnit 将超过ChunkSize的文本按单词切分，单个单词仍然过长时按字符切分
+func (t *TokenTextSplitter) splitLongUnit(unit string) []string {
+	if t.ChunkSize <= 0 || t.LengthFunction(unit) <= t.ChunkSize {
+		return []string{unit}
+	}
+
+	var parts []string
+	if words := wordPattern.FindAllString(unit, -1); len(words) > 1 {
+		for _, word := range words {
+			parts = append(parts, t.splitLongUnit(word)...)
+		}
+		return parts
+	}
+
+	runes := []rune(unit)
+	for len(runes) > 0 {
+		// 二分查找token数不超过ChunkSize的最长前缀
+		low, high := 1, len(runes)
+		for low < high {
+			mid := (low + high + 1) / 2
+			if t.LengthFunction(string(runes[:mid])) <= t.ChunkSize {
+				low = mid
+			} else {
+				high = mid - 1
+			}
+		}
+		parts = append(parts, string(runes[:low]))
+		runes = runes[low:]
+	}
+
+	return parts
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wordCount counts whitespace separated words, standing in for a tokenizer
+func wordCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestTokenTextSplitter(t *testing.T) {
+	splitter := NewTokenTextSplitter(TextSplitterConfig{ChunkSize: 6, ChunkOverlap: 3}, wordCount)
+
+	texts, err := splitter.SplitText("one two three. four five six. seven eight. nine ten eleven twelve.")
+	assert.NoError(t, err)
+	// chunks end at sentence boundaries and repeat the last sentence that fits the overlap
+	assert.Equal(t, []string{
+		"one two three. four five six.",
+		"four five six. seven eight.",
+		"seven eight. nine ten eleven twelve.",
+	}, texts)
+}
+
+func TestTokenTextSplitterLongSentence(t *testing.T) {
+	splitter := NewTokenTextSplitter(TextSplitterConfig{ChunkSize: 3}, wordCount)
+
+	texts, err := splitter.SplitText("a b c d e f g")
+	assert.NoError(t, err)
+	// a sentence longer than the chunk size is split at word boundaries
+	assert.Equal(t, []string{"a b c", "d e f", "g"}, texts)
+}
+
+func TestTokenTextSplitterLongWord(t *testing.T) {
+	splitter := NewTokenTextSplitter(TextSplitterConfig{ChunkSize: 4}, utf8.RuneCountInString)
+
+	texts, err := splitter.SplitText("知识库检索增强生成")
+	assert.NoError(t, err)
+	// a single word longer than the chunk size is split by runes, never inside a rune
+	assert.Equal(t, []string{"知识库检", "索增强生", "成"}, texts)
+}
+
+func TestTokenTextSplitterChunks(t *testing.T) {
+	splitter := NewTokenTextSplitter(TextSplitterConfig{ChunkSize: 100}, nil)
+
+	chunks, err := splitter.SplitChunks("第一句。第二句！\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []TextChunk{{Content: "第一句。第二句！"}}, chunks)
+}
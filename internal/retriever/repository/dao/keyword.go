@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
 )
 
 // KeywordDao 关键词表存储库
@@ -45,15 +46,79 @@ func (d *KeywordDao) Update(ctx context.Context, keywordTableID uuid.UUID, updat
 	return d.db.WithContext(ctx).Model(&entity.Keyword{}).Where("id = ?", keywordTableID).Updates(updates).Error
 }
 
-func (d *KeywordDao) GetKeywordBySegments(ctx context.Context, segmentIDs []uuid.UUID) ([]entity.Segment, error) {
+// GetSegmentContents 获取片段的内容，问答对片段同时获取问题与相似问法，用于构建倒排索引
+func (d *KeywordDao) GetSegmentContents(ctx context.Context, segmentIDs []uuid.UUID) ([]entity.Segment, error) {
 	var segments []entity.Segment
 
-	if err := d.db.WithContext(ctx).Model(&entity.Segment{}).
+	if err := d.db.WithContext(ctx).
 		Where("id IN ?", segmentIDs).
-		Select("id, keywords").
-		Scan(&segments).Error; err != nil {
+		Select("id", "content", "question", "question_variants").
+		Find(&segments).Error; err != nil {
 		return nil, err
 	}
 
 	return segments, nil
 }
+
+// GetUnindexedSegments 按ID顺序获取afterID之后已启用且完成索引但没有倒排记录的片段，用于补建倒排索引
+func (d *KeywordDao) GetUnindexedSegments(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.Segment, error) {
+	var segments []entity.Segment
+
+	postings := d.db.Model(&entity.KeywordPosting{}).Select("1").Where("keyword_postings.segment_id = segments.id")
+	if err := d.db.WithContext(ctx).
+		Where("enabled = ? AND status = ?", true, consts.SegmentStatusCompleted).
+		Where("id > ? AND NOT EXISTS (?)", afterID, postings).
+		Select("id", "dataset_id").
+		Order("id").
+		Limit(limit).
+		Find(&segments).Error; err != nil {
+		return nil, err
+	}
+
+	return segments, nil
+}
+
+// ReplacePostings 在同一事务中删除片段的旧倒排记录并写入新记录，同时更新关键词表的BM25统计信息
+func (d *KeywordDao) ReplacePostings(ctx context.Context, keywordTableID uuid.UUID, segmentIDs []uuid.UUID, postings []entity.KeywordPosting) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. 统计被替换片段的数量与长度，每个片段的所有倒排记录长度相同
+		var removed struct {
+			SegmentCount int
+			TotalLength  int64
+		}
+		segments := tx.Model(&entity.KeywordPosting{}).
+			Select("segment_id, MAX(segment_length) AS segment_length").
+			Where("segment_id IN ?", segmentIDs).
+			Group("segment_id")
+		if err := tx.Table("(?) AS s", segments).
+			Select("COUNT(*) AS segment_count, COALESCE(SUM(segment_length), 0) AS total_length").
+			Scan(&removed).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("segment_id IN ?", segmentIDs).Delete(&entity.KeywordPosting{}).Error; err != nil {
+			return err
+		}
+
+		// 2. 写入新的倒排记录
+		added := make(map[uuid.UUID]int)
+		for _, posting := range postings {
+			added[posting.SegmentID] = posting.SegmentLength
+		}
+		addedLength := int64(0)
+		for _, length := range added {
+			addedLength += int64(length)
+		}
+		if len(postings) > 0 {
+			if err := tx.CreateInBatches(postings, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		// 3. 更新关键词表的片段数与总长度
+		return tx.Model(&entity.Keyword{}).Where("id = ?", keywordTableID).Updates(map[string]any{
+			"segment_count": gorm.Expr("segment_count - ? + ?", removed.SegmentCount, len(added)),
+			"total_length":  gorm.Expr("total_length - ? + ?", removed.TotalLength, addedLength),
+		}).Error
+	})
+}
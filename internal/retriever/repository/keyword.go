@@ -39,8 +39,19 @@ func (r *KeywordRepository) Update(ctx context.Context, keywordTableID uuid.UUID
 	return r.dao.Update(ctx, keywordTableID, updates)
 }
 
-func (r *KeywordRepository) GetKeywordBySegments(ctx context.Context, segmentIDs []uuid.UUID) ([]entity.Segment, error) {
-	return r.dao.GetKeywordBySegments(ctx, segmentIDs)
+// GetSegmentContents 获取片段的内容
+func (r *KeywordRepository) GetSegmentContents(ctx context.Context, segmentIDs []uuid.UUID) ([]entity.Segment, error) {
+	return r.dao.GetSegmentContents(ctx, segmentIDs)
+}
+
+// GetUnindexedSegments 获取已启用且完成索引但没有倒排记录的片段
+func (r *KeywordRepository) GetUnindexedSegments(ctx context.Context, afterID uuid.UUID, limit int) ([]entity.Segment, error) {
+	return r.dao.GetUnindexedSegments(ctx, afterID, limit)
+}
+
+// ReplacePostings 替换片段的倒排记录并更新BM25统计信息
+func (r *KeywordRepository) ReplacePostings(ctx context.Context, keywordTableID uuid.UUID, segmentIDs []uuid.UUID, postings []entity.KeywordPosting) error {
+	return r.dao.ReplacePostings(ctx, keywordTableID, segmentIDs, postings)
 }
 
 func (r *KeywordRepository) AcquireLock(ctx context.Context, key string) string {
@@ -3,23 +3,32 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
+	"github.com/crazyfrankie/voidx/internal/core/qa"
+	"github.com/crazyfrankie/voidx/internal/core/retrievers"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository"
 )
 
-const lockKeyPrefix = "lock:keyword_table:"
+const (
+	lockKeyPrefix = "lock:keyword_table:"
+	// backfillBatchSize 补建倒排索引时每批处理的片段数
+	backfillBatchSize = 500
+)
 
 type KeywordService struct {
-	repo *repository.KeywordRepository
+	repo         *repository.KeywordRepository
+	jiebaService *retrievers.JiebaService
 }
 
 // NewKeywordService 创建一个新的关键词表服务
-func NewKeywordService(repo *repository.KeywordRepository) *KeywordService {
+func NewKeywordService(repo *repository.KeywordRepository, jiebaService *retrievers.JiebaService) *KeywordService {
 	return &KeywordService{
-		repo: repo,
+		repo:         repo,
+		jiebaService: jiebaService,
 	}
 }
 
@@ -43,8 +52,12 @@ func (s *KeywordService) GetKeywordByDateSet(ctx context.Context, datasetID uuid
 	return keyword, nil
 }
 
-// AddKeywords 向知识库的关键词表添加关键词
-func (s *KeywordService) AddKeywords(ctx context.Context, datasetID uuid.UUID, segmentID []uuid.UUID) error {
+// AddKeywords 将片段写入知识库的倒排索引，已存在的片段会被重新索引
+func (s *KeywordService) AddKeywords(ctx context.Context, datasetID uuid.UUID, segmentIDs []uuid.UUID) error {
+	if len(segmentIDs) == 0 {
+		return nil
+	}
+
 	// 1. 获取锁
 	lockKey := getLockKey(datasetID)
 	lock := s.repo.AcquireLock(ctx, lockKey)
@@ -53,56 +66,47 @@ func (s *KeywordService) AddKeywords(ctx context.Context, datasetID uuid.UUID, s
 	}
 	defer s.repo.ReleaseLock(ctx, lockKey, lock)
 
-	// 2. 获取关键词表记录
-	record, err := s.repo.GetByDatasetID(ctx, datasetID)
+	// 2. 获取关键词表记录，不存在时创建
+	keywordTable, err := s.GetKeywordByDateSet(ctx, datasetID)
 	if err != nil {
-		return fmt.Errorf("获取关键词表记录失败: %w", err)
-	}
-
-	// 3. 转换keyword_table为map[string]map[string]struct{}
-	keywordTable := make(map[string]map[string]struct{})
-	for field, keywords := range record.KeywordMap {
-		keywordSet := make(map[string]struct{})
-		for _, kw := range keywords {
-			keywordSet[kw] = struct{}{}
-		}
-		keywordTable[field] = keywordSet
+		return err
 	}
 
-	// 4. 查询片段信息
-	segments, err := s.repo.GetKeywordBySegments(ctx, segmentID)
+	// 3. 查询片段内容
+	segments, err := s.repo.GetSegmentContents(ctx, segmentIDs)
 	if err != nil {
 		return fmt.Errorf("查询片段失败: %w", err)
 	}
 
-	// 5. 更新关键词表
+	// 4. 分词并统计每个片段的词频，问答对片段使用问题与相似问法建立索引
+	postings := make([]entity.KeywordPosting, 0)
 	for _, seg := range segments {
-		for _, keyword := range seg.Keywords {
-			if _, exists := keywordTable[keyword]; !exists {
-				keywordTable[keyword] = make(map[string]struct{})
-			}
-			keywordTable[keyword][seg.ID.String()] = struct{}{}
+		content := seg.Content
+		if seg.Question != "" {
+			content = strings.Join(qa.IndexContents(seg.Question, seg.QuestionVariants), "\n")
 		}
-	}
-
-	// 6. 转换回存储格式并更新
-	updateMap := make(map[string][]string)
-	for field, idSet := range keywordTable {
-		ids := make([]string, 0, len(idSet))
-		for id := range idSet {
-			ids = append(ids, id)
+		frequencies, length := s.jiebaService.TermFrequencies(content)
+		for term, frequency := range frequencies {
+			postings = append(postings, entity.KeywordPosting{
+				DatasetID:     datasetID,
+				Term:          term,
+				SegmentID:     seg.ID,
+				TermFrequency: frequency,
+				SegmentLength: length,
+			})
 		}
-		updateMap[field] = ids
 	}
 
-	return s.repo.Update(ctx, record.ID, map[string]any{
-		"dataset_id":  datasetID,
-		"keyword_map": updateMap,
-	})
+	// 5. 替换片段的倒排记录
+	return s.repo.ReplacePostings(ctx, keywordTable.ID, segmentIDs, postings)
 }
 
-// RemoveSegmentIDs 从知识库的关键词表中移除指定的片段ID
+// RemoveSegmentIDs 从知识库的倒排索引中移除指定的片段
 func (s *KeywordService) RemoveSegmentIDs(ctx context.Context, datasetID uuid.UUID, segmentIDs []uuid.UUID) error {
+	if len(segmentIDs) == 0 {
+		return nil
+	}
+
 	// 获取锁
 	lockKey := getLockKey(datasetID)
 	lock := s.repo.AcquireLock(ctx, lockKey)
@@ -117,32 +121,36 @@ func (s *KeywordService) RemoveSegmentIDs(ctx context.Context, datasetID uuid.UU
 		return fmt.Errorf("failed to get keyword table: %w", err)
 	}
 
-	// 创建segmentID集合，用于快速查找
-	segmentIDSet := make(map[string]struct{}, len(segmentIDs))
-	for _, id := range segmentIDs {
-		segmentIDSet[id.String()] = struct{}{}
-	}
+	// 删除片段的倒排记录
+	return s.repo.ReplacePostings(ctx, keywordTable.ID, segmentIDs, nil)
+}
 
-	// 从每个关键词的片段ID列表中移除指定的片段ID
-	for keyword, ids := range keywordTable.KeywordMap {
-		newIDs := make([]string, 0, len(ids))
-		for _, id := range ids {
-			if _, exists := segmentIDSet[id]; !exists {
-				newIDs = append(newIDs, id)
-			}
+// BackfillPostings 为倒排索引上线前已完成索引的片段补建倒排记录，可重复执行，
+// 已有倒排记录的片段会被跳过
+func (s *KeywordService) BackfillPostings(ctx context.Context) error {
+	afterID := uuid.Nil
+	for {
+		// 1. 按ID分批获取没有倒排记录的片段，没有分出词的片段也不会被重复处理
+		segments, err := s.repo.GetUnindexedSegments(ctx, afterID, backfillBatchSize)
+		if err != nil {
+			return err
 		}
+		if len(segments) == 0 {
+			return nil
+		}
+		afterID = segments[len(segments)-1].ID
 
-		if len(newIDs) > 0 {
-			keywordTable.KeywordMap[keyword] = newIDs
-		} else {
-			delete(keywordTable.KeywordMap, keyword)
+		// 2. 按知识库分组写入倒排索引
+		segmentIDs := make(map[uuid.UUID][]uuid.UUID)
+		for _, seg := range segments {
+			segmentIDs[seg.DatasetID] = append(segmentIDs[seg.DatasetID], seg.ID)
+		}
+		for datasetID, ids := range segmentIDs {
+			if err := s.AddKeywords(ctx, datasetID, ids); err != nil {
+				return err
+			}
 		}
 	}
-
-	// 保存关键词表
-	return s.repo.Update(ctx, keywordTable.ID, map[string]any{
-		"keyword_map": keywordTable.KeywordMap,
-	})
 }
 
 func getLockKey(datasetID uuid.UUID) string {
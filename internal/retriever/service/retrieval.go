@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/pkg/logs"
@@ -71,6 +72,13 @@ func (t *datasetRetrievalTool) InvokableRun(ctx context.Context, argumentsInJSON
 		ScoreThreshold: t.score,
 		RetrieverType:  string(t.retrievalStrategy),
 	}
+	// 重排序、混合检索融合与元数据过滤配置透传给检索器
+	searchReq.Options = make(map[string]any)
+	for _, key := range []string{"rerank", "fusion", "metadata_filter"} {
+		if value, ok := t.retrievalConfig[key]; ok {
+			searchReq.Options[key] = value
+		}
+	}
 
 	// 执行检索
 	results, err := t.service.SearchInDatasets(ctx, t.userID, searchReq)
@@ -81,6 +89,11 @@ func (t *datasetRetrievalTool) InvokableRun(ctx context.Context, argumentsInJSON
 	// 格式化结果
 	var resultTexts []string
 	for i, result := range results {
+		// 问答对同时提供问题，便于模型判断答案是否适用
+		if result.Question != "" {
+			resultTexts = append(resultTexts, fmt.Sprintf("文档%d: 问题：%s\n答案：%s", i+1, result.Question, result.Content))
+			continue
+		}
 		resultTexts = append(resultTexts, fmt.Sprintf("文档%d: %s", i+1, result.Content))
 	}
 
@@ -91,13 +104,15 @@ func (t *datasetRetrievalTool) InvokableRun(ctx context.Context, argumentsInJSON
 type RetrievalService struct {
 	RetrieverFactory  *retrievers.RetrieverFactory
 	intentClassifier  *IntentClassifier
+	tableStore        *table.Store
 }
 
 // NewRetrievalService 创建一个新的检索服务
-func NewRetrievalService(retrieverFactory *retrievers.RetrieverFactory) *RetrievalService {
+func NewRetrievalService(retrieverFactory *retrievers.RetrieverFactory, tableStore *table.Store) *RetrievalService {
 	return &RetrievalService{
 		RetrieverFactory: retrieverFactory,
 		intentClassifier: NewIntentClassifier(),
+		tableStore:       tableStore,
 	}
 }
 
@@ -167,14 +182,17 @@ func (s *RetrievalService) SearchInDatasets(ctx context.Context, userID uuid.UUI
 		searchReq.RetrieverType = "semantic"
 	}
 
-	// 3. 创建检索选项
-	options := map[string]any{
-		"k":               searchReq.K,
-		"score_threshold": searchReq.ScoreThreshold,
+	// 3. 创建检索选项，重排序等其他选项由调用方通过Options传入
+	options := make(map[string]any, len(searchReq.Options)+3)
+	for key, value := range searchReq.Options {
+		options[key] = value
 	}
+	options["k"] = searchReq.K
+	options["score_threshold"] = searchReq.ScoreThreshold
+	options["account_id"] = userID
 
 	// 4. 创建检索器
-	retriever, err := s.createRetriever(searchReq.RetrieverType, searchReq.DatasetIDs, options)
+	retriever, err := s.createRetriever(ctx, searchReq.RetrieverType, searchReq.DatasetIDs, options)
 	if err != nil {
 		return nil, err
 	}
@@ -199,13 +217,13 @@ func (s *RetrievalService) SearchInDatasets(ctx context.Context, userID uuid.UUI
 }
 
 // createRetriever 创建检索器
-func (s *RetrievalService) createRetriever(retrieverType string, datasetIDs []uuid.UUID, options map[string]any) (interface{}, error) {
+func (s *RetrievalService) createRetriever(ctx context.Context, retrieverType string, datasetIDs []uuid.UUID, options map[string]any) (interface{}, error) {
 	// 默认使用混合检索
 	if retrieverType == "" {
 		retrieverType = string(retrievers.RetrieverTypeHybrid)
 	}
 
-	return s.RetrieverFactory.CreateRetriever(context.Background(), retrievers.RetrieverType(retrieverType), datasetIDs, options)
+	return s.RetrieverFactory.CreateRetriever(ctx, retrievers.RetrieverType(retrieverType), datasetIDs, options)
 }
 
 // retrieveDocuments 执行文档检索
@@ -217,6 +235,12 @@ func (s *RetrievalService) retrieveDocuments(ctx context.Context, retriever inte
 		return r.Retrieve(ctx, query)
 	case *retrievers.HybridRetriever:
 		return r.Retrieve(ctx, query)
+	case *retrievers.RerankRetriever:
+		return r.Retrieve(ctx, query)
+	case *retrievers.ParentChildRetriever:
+		return r.Retrieve(ctx, query)
+	case *retrievers.QARetriever:
+		return r.Retrieve(ctx, query)
 	default:
 		return nil, fmt.Errorf("unsupported retriever type: %T", retriever)
 	}
@@ -269,6 +293,28 @@ func (s *RetrievalService) convertDocumentToSearchResult(doc *schema.Document) r
 		result.Score = score
 	}
 
+	if rerankScore, ok := doc.MetaData["rerank_score"].(float64); ok {
+		result.RerankScore = &rerankScore
+	}
+
+	if parentSegmentID, ok := doc.MetaData["parent_segment_id"].(string); ok {
+		if id, err := uuid.Parse(parentSegmentID); err == nil {
+			result.ParentSegmentID = &id
+		}
+	}
+
+	if childContent, ok := doc.MetaData["child_content"].(string); ok {
+		result.ChildContent = childContent
+	}
+
+	if question, ok := doc.MetaData["question"].(string); ok {
+		result.Question = question
+	}
+
+	if matchedQuestion, ok := doc.MetaData["matched_question"].(string); ok {
+		result.MatchedQuestion = matchedQuestion
+	}
+
 	if position, ok := doc.MetaData["position"].(int); ok {
 		result.Position = position
 	}
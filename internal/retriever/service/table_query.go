@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/core/table"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+const (
+	// tableQueryRowLimit 表格查询工具最多返回的行数
+	tableQueryRowLimit = 50
+	// tableQuerySampleRows 生成SQL时提供给模型的示例行数
+	tableQuerySampleRows = 3
+	// tableQueryMaxAttempts SQL校验或执行失败时最多生成SQL的次数
+	tableQueryMaxAttempts = 2
+)
+
+// TableQueryInput 表格查询工具的输入结构
+type TableQueryInput struct {
+	Question string `json:"question" description:"需要通过查询表格数据回答的问题"`
+}
+
+// tableQueryResult 表格查询工具的输出结构
+type tableQueryResult struct {
+	SQL string `json:"sql"`
+	*table.QueryResult
+}
+
+// tableQueryTool 表格知识库查询工具实现，使用应用的模型将问题转换为SQL后查询知识库的数据表
+type tableQueryTool struct {
+	service      *RetrievalService
+	tableDataset table.TableDataset
+	chatModel    model.BaseChatModel
+}
+
+// Info 实现 tool.BaseTool 接口
+func (t *tableQueryTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	dataset, datasetTable := t.tableDataset.Dataset, t.tableDataset.Table
+
+	columns := make([]string, 0, len(datasetTable.Columns))
+	for _, column := range datasetTable.Columns {
+		columns = append(columns, column.Description)
+	}
+	desc := fmt.Sprintf("查询表格知识库《%s》中的数据，适合回答统计、汇总、筛选、排名等需要计算的问题。", dataset.Name)
+	if dataset.Description != "" {
+		desc += dataset.Description + "。"
+	}
+	desc += fmt.Sprintf("表格共%d行，包含字段：%s", datasetTable.RowCount, strings.Join(columns, "、"))
+
+	return &schema.ToolInfo{
+		Name: "table_query_" + strings.ReplaceAll(dataset.ID.String(), "-", "")[:12],
+		Desc: desc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"question": {
+				Type:     schema.String,
+				Desc:     "需要通过查询表格数据回答的问题",
+				Required: true,
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 实现 tool.InvokableTool 接口
+func (t *tableQueryTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	// 1. 解析输入参数
+	var input TableQueryInput
+	if err := json.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	// 2. 获取示例数据，帮助模型理解字段的取值格式
+	datasetTable := &t.tableDataset.Table
+	samples, err := t.service.tableStore.Query(ctx, datasetTable, "SELECT * FROM "+datasetTable.Name, tableQuerySampleRows)
+	if err != nil {
+		logs.Errorf("Failed to query sample rows of table %s: %v", datasetTable.Name, err)
+	}
+
+	// 3. 生成SQL并执行，校验或执行失败时将错误反馈给模型重新生成
+	var sql string
+	var lastErr error
+	for range tableQueryMaxAttempts {
+		sql, err = table.GenerateSQL(ctx, t.chatModel, datasetTable, samples, input.Question, sql, lastErr)
+		if err != nil {
+			return "", err
+		}
+
+		result, err := t.service.tableStore.Query(ctx, datasetTable, sql, tableQueryRowLimit)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return sonic.MarshalString(tableQueryResult{SQL: sql, QueryResult: result})
+	}
+
+	return fmt.Sprintf("表格查询失败，SQL: %s，错误: %v", sql, lastErr), nil
+}
+
+// CreateTableQueryTools 为关联的表格知识库分别构建一个eino表格查询工具，生成SQL时使用传递的应用模型，
+// 还没有导入过数据的表格知识库不会构建工具
+func (s *RetrievalService) CreateTableQueryTools(ctx context.Context, userID uuid.UUID, datasets []uuid.UUID,
+	chatModel model.BaseChatModel) ([]tool.InvokableTool, error) {
+	tableDatasets, err := s.tableStore.ListTableDatasets(ctx, userID, datasets)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]tool.InvokableTool, 0, len(tableDatasets))
+	for _, tableDataset := range tableDatasets {
+		tools = append(tools, &tableQueryTool{
+			service:      s,
+			tableDataset: tableDataset,
+			chatModel:    chatModel,
+		})
+	}
+
+	return tools, nil
+}
@@ -4,7 +4,9 @@ package retriever
 
 import (
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
+	"github.com/crazyfrankie/voidx/internal/core/llm"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository/cache"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository/dao"
@@ -25,7 +27,7 @@ type RetrieverModule struct {
 
 // InitRetrieverModule 初始化检索模块
 func InitRetrieverModule(db *gorm.DB, cmd redis.Cmdable, vectorStore *milvus.Store,
-	embedding *embedding.EmbeddingService, jiebaService *retrievers.JiebaService) *RetrieverModule {
+	embedding *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, llmCore *llm.LanguageModelManager) *RetrieverModule {
 	wire.Build(
 		cache.NewKeyWordCache,
 		dao.NewKeywordDao,
@@ -35,6 +37,8 @@ func InitRetrieverModule(db *gorm.DB, cmd redis.Cmdable, vectorStore *milvus.Sto
 		service.NewKeywordService,
 		// 初始化检索器工厂
 		initRetrieverFactory,
+		// 初始化表格知识库数据表存储
+		table.NewStore,
 		// 初始化检索服务
 		service.NewRetrievalService,
 
@@ -44,6 +48,6 @@ func InitRetrieverModule(db *gorm.DB, cmd redis.Cmdable, vectorStore *milvus.Sto
 }
 
 // initRetrieverFactory 初始化检索器工厂
-func initRetrieverFactory(db *gorm.DB, vectorStore *milvus.Store, embedding *embedding.EmbeddingService, jiebaService *retrievers.JiebaService) *retrievers.RetrieverFactory {
-	return retrievers.NewRetrieverFactory(db, embedding, jiebaService)
+func initRetrieverFactory(db *gorm.DB, vectorStore *milvus.Store, embedding *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, llmCore *llm.LanguageModelManager) *retrievers.RetrieverFactory {
+	return retrievers.NewRetrieverFactory(db, vectorStore, embedding, jiebaService, llmCore)
 }
@@ -8,7 +8,9 @@ package retriever
 
 import (
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
+	"github.com/crazyfrankie/voidx/internal/core/llm"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/core/table"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository/cache"
 	"github.com/crazyfrankie/voidx/internal/retriever/repository/dao"
@@ -21,13 +23,14 @@ import (
 // Injectors from wire.go:
 
 // InitRetrieverModule 初始化检索模块
-func InitRetrieverModule(db *gorm.DB, cmd redis.Cmdable, vectorStore *milvus.Store, embedding2 *embedding.EmbeddingService, jiebaService *retrievers.JiebaService) *RetrieverModule {
+func InitRetrieverModule(db *gorm.DB, cmd redis.Cmdable, vectorStore *milvus.Store, embedding2 *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, llmCore *llm.LanguageModelManager) *RetrieverModule {
 	keywordDao := dao.NewKeywordDao(db)
 	keyWordCache := cache.NewKeyWordCache(cmd)
 	keywordRepository := repository.NewKeywordRepository(keywordDao, keyWordCache)
-	keywordService := service.NewKeywordService(keywordRepository)
-	retrieverFactory := initRetrieverFactory(db, vectorStore, embedding2, jiebaService)
-	retrievalService := service.NewRetrievalService(retrieverFactory)
+	keywordService := service.NewKeywordService(keywordRepository, jiebaService)
+	retrieverFactory := initRetrieverFactory(db, vectorStore, embedding2, jiebaService, llmCore)
+	store := table.NewStore(db)
+	retrievalService := service.NewRetrievalService(retrieverFactory, store)
 	retrieverModule := &RetrieverModule{
 		KeyWord: keywordService,
 		Service: retrievalService,
@@ -47,6 +50,6 @@ type RetrieverModule struct {
 }
 
 // initRetrieverFactory 初始化检索器工厂
-func initRetrieverFactory(db *gorm.DB, vectorStore *milvus.Store, embedding2 *embedding.EmbeddingService, jiebaService *retrievers.JiebaService) *retrievers.RetrieverFactory {
-	return retrievers.NewRetrieverFactory(db, vectorStore, embedding2, jiebaService)
+func initRetrieverFactory(db *gorm.DB, vectorStore *milvus.Store, embedding2 *embedding.EmbeddingService, jiebaService *retrievers.JiebaService, llmCore *llm.LanguageModelManager) *retrievers.RetrieverFactory {
+	return retrievers.NewRetrieverFactory(db, vectorStore, embedding2, jiebaService, llmCore)
 }
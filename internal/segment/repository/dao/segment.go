@@ -60,12 +60,18 @@ func (d *SegmentDao) GetSegmentsByDocumentID(
 	var segments []entity.Segment
 	var total int64
 
-	query := d.db.WithContext(ctx).Where("document_id = ?", documentID)
+	// 父子分段的子片段挂载在父片段下返回，列表只包含顶层片段
+	query := d.db.WithContext(ctx).Where("document_id = ? AND parent_id = ?", documentID, uuid.Nil)
 
-	// 添加关键词搜索
+	// 添加关键词搜索，父片段的子片段命中时同样返回该父片段
 	if pageReq.SearchWord != "" {
-		query = query.Where("content ILIKE ? OR keywords::text ILIKE ?",
-			"%"+pageReq.SearchWord+"%", "%"+pageReq.SearchWord+"%")
+		children := d.db.Model(&entity.Segment{}).
+			Select("parent_id").
+			Where("document_id = ? AND parent_id <> ?", documentID, uuid.Nil).
+			Where("content ILIKE ? OR keywords::text ILIKE ?", "%"+pageReq.SearchWord+"%", "%"+pageReq.SearchWord+"%")
+		// 问答对片段同时搜索问题
+		query = query.Where("content ILIKE ? OR question ILIKE ? OR keywords::text ILIKE ? OR id IN (?)",
+			"%"+pageReq.SearchWord+"%", "%"+pageReq.SearchWord+"%", "%"+pageReq.SearchWord+"%", children)
 	}
 
 	// 计算总数
@@ -87,6 +93,18 @@ func (d *SegmentDao) GetSegmentsByDocumentID(
 	return segments, total, nil
 }
 
+func (d *SegmentDao) GetChildSegments(ctx context.Context, parentIDs []uuid.UUID) ([]entity.Segment, error) {
+	var segments []entity.Segment
+	err := d.db.WithContext(ctx).
+		Where("parent_id IN ?", parentIDs).
+		Order("position ASC").
+		Find(&segments).Error
+	if err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
 func (d *SegmentDao) GetSegmentByID(ctx context.Context, id uuid.UUID) (*entity.Segment, error) {
 	var segment entity.Segment
 	err := d.db.WithContext(ctx).Where("id = ?", id).First(&segment).Error
@@ -102,8 +120,9 @@ func (d *SegmentDao) UpdateSegment(ctx context.Context, id uuid.UUID, updates ma
 		Updates(updates).Error
 }
 
+// DeleteSegment 删除片段，父片段的子片段一并删除
 func (d *SegmentDao) DeleteSegment(ctx context.Context, id uuid.UUID) error {
-	return d.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.Segment{}).Error
+	return d.db.WithContext(ctx).Where("id = ? OR parent_id = ?", id, id).Delete(&entity.Segment{}).Error
 }
 
 func (d *SegmentDao) GetSegments(ctx context.Context, ids []uuid.UUID) ([]entity.Segment, error) {
@@ -124,6 +143,15 @@ func (d *SegmentDao) GetDocument(ctx context.Context, docID uuid.UUID) (*entity.
 	return &document, nil
 }
 
+func (d *SegmentDao) GetDataset(ctx context.Context, datasetID uuid.UUID) (*entity.Dataset, error) {
+	var dataset entity.Dataset
+	err := d.db.WithContext(ctx).Where("id = ?", datasetID).First(&dataset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
 func (d *SegmentDao) GetMaxSegmentPosition(ctx context.Context, docID uuid.UUID) (int, error) {
 	var position int
 
@@ -34,6 +34,10 @@ func (r *SegmentRepo) GetSegmentsByDocumentID(ctx context.Context, documentID uu
 	return r.dao.GetSegmentsByDocumentID(ctx, documentID, pageReq)
 }
 
+func (r *SegmentRepo) GetChildSegments(ctx context.Context, parentIDs []uuid.UUID) ([]entity.Segment, error) {
+	return r.dao.GetChildSegments(ctx, parentIDs)
+}
+
 func (r *SegmentRepo) GetSegmentByID(ctx context.Context, id uuid.UUID) (*entity.Segment, error) {
 	return r.dao.GetSegmentByID(ctx, id)
 }
@@ -50,6 +54,12 @@ func (r *SegmentRepo) GetSegments(ctx context.Context, segmentIDs []uuid.UUID) (
 	return r.dao.GetSegments(ctx, segmentIDs)
 }
 
+// dataset call
+
+func (r *SegmentRepo) GetDataset(ctx context.Context, datasetID uuid.UUID) (*entity.Dataset, error) {
+	return r.dao.GetDataset(ctx, datasetID)
+}
+
 // document call
 
 func (r *SegmentRepo) GetDocument(ctx context.Context, docID uuid.UUID) (*entity.Document, error) {
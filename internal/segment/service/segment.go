@@ -3,19 +3,24 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
 
 	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
+	"github.com/crazyfrankie/voidx/internal/core/qa"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/segment/repository"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
 	"github.com/crazyfrankie/voidx/types/consts"
 	"github.com/crazyfrankie/voidx/types/errno"
@@ -27,11 +32,12 @@ type SegmentService struct {
 	jiebaSvc     *retrievers.JiebaService
 	keywordSvc   *retriever.KeyWordService
 	vecSvc       vecstore.SearchStore
+	llmSvc       *llm.Service
 }
 
 func NewSegmentService(repo *repository.SegmentRepo, embeddingSvc *embedding.EmbeddingService,
-	jiebaSvc *retrievers.JiebaService, vecSvc vecstore.SearchStore, keywordSvc *retriever.KeyWordService) *SegmentService {
-	return &SegmentService{repo: repo, embeddingSvc: embeddingSvc, jiebaSvc: jiebaSvc, vecSvc: vecSvc, keywordSvc: keywordSvc}
+	jiebaSvc *retrievers.JiebaService, vecSvc vecstore.SearchStore, keywordSvc *retriever.KeyWordService, llmSvc *llm.Service) *SegmentService {
+	return &SegmentService{repo: repo, embeddingSvc: embeddingSvc, jiebaSvc: jiebaSvc, vecSvc: vecSvc, keywordSvc: keywordSvc, llmSvc: llmSvc}
 }
 
 func (s *SegmentService) CreateSegment(ctx context.Context, datasetID, documentID uuid.UUID, createReq req.CreateSegmentReq) (*entity.Segment, error) {
@@ -63,18 +69,49 @@ func (s *SegmentService) CreateSegment(ctx context.Context, datasetID, documentI
 		return nil, errors.New("当前文档不可新增片段，请稍后尝试")
 	}
 
-	// 4.提取文档片段的最大位置
+	// 问答对知识库的片段必须传递问题，其他知识库的片段不能设置问题
+	dataset, err := s.repo.GetDataset(ctx, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if dataset.Type == consts.DatasetTypeQA {
+		if strings.TrimSpace(createReq.Question) == "" {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("问答对知识库的片段需要传递问题"))
+		}
+		if createReq.ParentID != nil {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("问答对知识库不支持父子分段"))
+		}
+	} else if createReq.Question != "" || len(createReq.QuestionVariants) > 0 {
+		return nil, errno.ErrValidate.AppendBizMessage(errors.New("只有问答对知识库的片段可以设置问题"))
+	}
+
+	// 4.传递了父片段时校验其为当前文档下的父片段，新增的片段作为其子片段
+	parentID := uuid.Nil
+	if createReq.ParentID != nil {
+		parent, err := s.repo.GetSegmentByID(ctx, *createReq.ParentID)
+		if err != nil || parent.DocumentID != documentID || parent.ParentID != uuid.Nil || parent.NodeID != uuid.Nil {
+			return nil, errno.ErrValidate.AppendBizMessage(errors.New("父片段不存在或不属于当前文档"))
+		}
+		parentID = parent.ID
+	}
+
+	// 5.提取文档片段的最大位置
 	position, err := s.repo.GetMaxSegmentPosition(ctx, documentID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 5.检测是否传递了keywords，如果没有传递的话，调用jieba服务生成关键词
+	// 6.检测是否传递了keywords，如果没有传递的话，调用jieba服务生成关键词，问答对使用问题生成
+	question := strings.TrimSpace(createReq.Question)
 	if createReq.Keywords == nil {
-		createReq.Keywords = s.jiebaSvc.ExtractKeywords(createReq.Content, 10)
+		if question != "" {
+			createReq.Keywords = s.jiebaSvc.ExtractKeywords(question, 10)
+		} else {
+			createReq.Keywords = s.jiebaSvc.ExtractKeywords(createReq.Content, 10)
+		}
 	}
 
-	// 6. 创建片段
+	// 7. 创建片段
 	position += 1
 	segment := &entity.Segment{
 		ID:             uuid.New(),
@@ -82,6 +119,7 @@ func (s *SegmentService) CreateSegment(ctx context.Context, datasetID, documentI
 		DatasetID:      datasetID,
 		DocumentID:     documentID,
 		NodeID:         uuid.New(),
+		ParentID:       parentID,
 		Position:       position,
 		Content:        createReq.Content,
 		CharacterCount: len(createReq.Content),
@@ -91,32 +129,27 @@ func (s *SegmentService) CreateSegment(ctx context.Context, datasetID, documentI
 		Enabled:        true,
 		Status:         consts.SegmentStatusCompleted,
 		CompletedAt:    time.Now().Unix(),
+		Metadata:       doc.Metadata,
+	}
+	if question != "" {
+		segment.Question = question
+		segment.QuestionVariants = qa.NormalizeVariants(question, createReq.QuestionVariants)
+		segment.Hash = qa.Hash(question, createReq.Content)
 	}
 	err = s.repo.CreateSegment(ctx, segment)
 
-	// 7.往向量数据库中新增数据
-	_, err = s.vecSvc.Store(ctx, []*schema.Document{
-		{
-			Content: createReq.Content,
-			MetaData: map[string]any{
-				"account_id":       userID,
-				"dataset_id":       datasetID,
-				"document_id":      documentID,
-				"segment_id":       segment.ID,
-				"node_id":          segment.NodeID,
-				"document_enabled": doc.Enabled,
-				"segment_enabled":  true,
-			},
-		},
-	})
+	// 8.使用知识库固定的向量模型往向量数据库中新增数据
+	if err := s.storeSegmentVectors(ctx, dataset, doc, segment); err != nil {
+		return nil, err
+	}
 
-	// 8.重新计算片段的字符总数以及token总数
+	// 9.重新计算片段的字符总数以及token总数
 	docCharCnt, docTokenCnt, err := s.repo.GetDocumentSegmentCounts(ctx, documentID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 9.更新文档的对应信息
+	// 10.更新文档的对应信息
 	if err := s.repo.UpdateDocument(ctx, documentID, map[string]any{
 		"character_count": docCharCnt,
 		"token_count":     docTokenCnt,
@@ -124,7 +157,7 @@ func (s *SegmentService) CreateSegment(ctx context.Context, datasetID, documentI
 		return nil, err
 	}
 
-	// 10.更新关键词表信息
+	// 11.更新关键词表信息
 	if err := s.keywordSvc.AddKeywords(ctx, datasetID, []uuid.UUID{segment.ID}); err != nil {
 		return nil, err
 	}
@@ -153,19 +186,21 @@ func (s *SegmentService) GetSegmentsWithPage(ctx context.Context, datasetID, doc
 		return nil, resp.Paginator{}, err
 	}
 
+	// 获取父片段下的子片段
+	segmentIDs := make([]uuid.UUID, 0, len(segments))
+	for _, segment := range segments {
+		segmentIDs = append(segmentIDs, segment.ID)
+	}
+	children, err := s.getChildSegmentResps(ctx, segmentIDs)
+	if err != nil {
+		return nil, resp.Paginator{}, err
+	}
+
 	// 转换为响应格式
 	segmentResps := make([]resp.SegmentResp, len(segments))
 	for i, segment := range segments {
-		segmentResps[i] = resp.SegmentResp{
-			ID:         segment.ID,
-			DatasetID:  segment.DatasetID,
-			DocumentID: segment.DocumentID,
-			Content:    segment.Content,
-			Keywords:   segment.Keywords,
-			Enabled:    segment.Enabled,
-			Status:     string(segment.Status),
-			Utime:      segment.Utime,
-		}
+		segmentResps[i] = toSegmentResp(segment)
+		segmentResps[i].Children = children[segment.ID]
 	}
 
 	// 计算分页信息
@@ -206,18 +241,16 @@ func (s *SegmentService) GetSegment(ctx context.Context, datasetID, documentID,
 		return nil, errno.ErrValidate.AppendBizMessage(errors.New("片段不属于指定文档"))
 	}
 
-	segmentResp := &resp.SegmentResp{
-		ID:         segment.ID,
-		DatasetID:  segment.DatasetID,
-		DocumentID: segment.DocumentID,
-		Content:    segment.Content,
-		Keywords:   segment.Keywords,
-		Enabled:    segment.Enabled,
-		Status:     string(segment.Status),
-		Utime:      segment.Utime,
+	segmentResp := toSegmentResp(*segment)
+	if segment.ParentID == uuid.Nil {
+		children, err := s.getChildSegmentResps(ctx, []uuid.UUID{segment.ID})
+		if err != nil {
+			return nil, err
+		}
+		segmentResp.Children = children[segment.ID]
 	}
 
-	return segmentResp, nil
+	return &segmentResp, nil
 }
 
 func (s *SegmentService) UpdateSegment(ctx context.Context, datasetID, documentID, segmentID uuid.UUID, updateReq req.UpdateSegmentReq) error {
@@ -258,7 +291,65 @@ func (s *SegmentService) UpdateSegment(ctx context.Context, datasetID, documentI
 		updates["enabled_status"] = *updateReq.EnabledStatus
 	}
 
-	return s.repo.UpdateSegment(ctx, segmentID, updates)
+	// 问答对片段修改问题或相似问法时，需要重新建立索引
+	questionUpdated := updateReq.Question != "" || updateReq.QuestionVariants != nil
+	if questionUpdated {
+		if segment.Question == "" {
+			return errno.ErrValidate.AppendBizMessage(errors.New("只有问答对片段可以修改问题"))
+		}
+		if question := strings.TrimSpace(updateReq.Question); question != "" {
+			segment.Question = question
+		}
+		if updateReq.QuestionVariants != nil {
+			segment.QuestionVariants = *updateReq.QuestionVariants
+		}
+		segment.QuestionVariants = qa.NormalizeVariants(segment.Question, segment.QuestionVariants)
+		variantsJSON, _ := sonic.Marshal(segment.QuestionVariants)
+		updates["question"] = segment.Question
+		updates["question_variants"] = string(variantsJSON)
+
+		// 没有传递关键词时使用新的问题重新生成关键词
+		if updateReq.Keywords == nil {
+			keywordsJSON, _ := sonic.Marshal(s.jiebaSvc.ExtractKeywords(segment.Question, 10))
+			updates["keywords"] = string(keywordsJSON)
+		}
+	}
+
+	// 问答对片段的哈希由问题与答案共同计算，修改任意一项都需要更新
+	if segment.Question != "" && (questionUpdated || updateReq.Content != "") {
+		if updateReq.Content != "" {
+			segment.Content = updateReq.Content
+		}
+		updates["hash"] = qa.Hash(segment.Question, segment.Content)
+	}
+
+	if err := s.repo.UpdateSegment(ctx, segmentID, updates); err != nil {
+		return err
+	}
+	if !questionUpdated || segment.Status != consts.SegmentStatusCompleted {
+		return nil
+	}
+
+	// 向量数据库不支持局部更新，删除问答对原有的向量记录后重新写入
+	dataset, err := s.repo.GetDataset(ctx, datasetID)
+	if err != nil {
+		return err
+	}
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if err := s.vecSvc.Delete(ctx, qa.SegmentVectorIDs(segment)); err != nil {
+		return err
+	}
+	if err := s.storeSegmentVectors(ctx, dataset, doc, segment); err != nil {
+		return err
+	}
+	if !segment.Enabled {
+		return nil
+	}
+
+	return s.keywordSvc.AddKeywords(ctx, datasetID, []uuid.UUID{segment.ID})
 }
 
 func (s *SegmentService) DeleteSegment(ctx context.Context, datasetID, documentID, segmentID uuid.UUID) error {
@@ -287,7 +378,19 @@ func (s *SegmentService) DeleteSegment(ctx context.Context, datasetID, documentI
 		return errno.ErrValidate.AppendBizMessage(errors.New("片段不属于指定文档"))
 	}
 
-	return s.repo.DeleteSegment(ctx, segmentID)
+	if err := s.repo.DeleteSegment(ctx, segmentID); err != nil {
+		return err
+	}
+
+	// 删除片段的向量记录，问答对的问题与每个相似问法各对应一条记录
+	if vectorIDs := qa.SegmentVectorIDs(segment); len(vectorIDs) > 0 {
+		if err := s.vecSvc.Delete(ctx, vectorIDs); err != nil {
+			return err
+		}
+	}
+
+	// 从倒排索引中移除片段，避免关键词检索命中已删除的片段
+	return s.keywordSvc.RemoveSegmentIDs(ctx, datasetID, []uuid.UUID{segmentID})
 }
 
 func (s *SegmentService) UpdateSegmentEnabled(ctx context.Context, datasetID, documentID, segmentID uuid.UUID, enabled bool) error {
@@ -317,12 +420,101 @@ func (s *SegmentService) UpdateSegmentEnabled(ctx context.Context, datasetID, do
 	}
 
 	updates := map[string]any{
-		"enabled_status": enabled,
+		"enabled": enabled,
+	}
+	if err := s.repo.UpdateSegment(ctx, segmentID, updates); err != nil {
+		return err
 	}
 
-	return s.repo.UpdateSegment(ctx, segmentID, updates)
+	// 禁用时从倒排索引中移除，启用已完成索引的片段时重新写入，使关键词检索只命中启用的片段
+	if !enabled {
+		return s.keywordSvc.RemoveSegmentIDs(ctx, datasetID, []uuid.UUID{segmentID})
+	}
+	if segment.Status != consts.SegmentStatusCompleted {
+		return nil
+	}
+
+	return s.keywordSvc.AddKeywords(ctx, datasetID, []uuid.UUID{segmentID})
 }
 
 func (s *SegmentService) GetSegments(ctx context.Context, segmentIDS []uuid.UUID) ([]entity.Segment, error) {
 	return s.repo.GetSegments(ctx, segmentIDS)
 }
+
+// storeSegmentVectors 使用知识库固定的向量模型将片段写入向量数据库，问答对片段的问题与每个相似问法分别写入一条记录
+func (s *SegmentService) storeSegmentVectors(ctx context.Context, dataset *entity.Dataset, doc *entity.Document, segment *entity.Segment) error {
+	embedder, err := s.llmSvc.LoadDatasetEmbedder(ctx, dataset)
+	if err != nil {
+		return err
+	}
+	var storeOpts []indexer.Option
+	if embedder != nil {
+		storeOpts = append(storeOpts, vecstore.WithIndexerEmbedding(embedder))
+	}
+
+	contents := []string{segment.Content}
+	if segment.Question != "" {
+		contents = qa.IndexContents(segment.Question, segment.QuestionVariants)
+	}
+	documents := make([]*schema.Document, 0, len(contents))
+	for i, content := range contents {
+		documents = append(documents, &schema.Document{
+			ID:      qa.VectorID(segment.NodeID, i),
+			Content: content,
+			MetaData: map[string]any{
+				"account_id":       segment.AccountID.String(),
+				"dataset_id":       segment.DatasetID.String(),
+				"document_id":      segment.DocumentID.String(),
+				"segment_id":       segment.ID.String(),
+				"node_id":          segment.NodeID.String(),
+				"document_enabled": doc.Enabled,
+				"segment_enabled":  segment.Enabled,
+				"metadata":         doc.Metadata,
+			},
+		})
+	}
+
+	_, err = s.vecSvc.Store(ctx, documents, storeOpts...)
+	return err
+}
+
+// getChildSegmentResps 获取父片段下的子片段，按父片段id分组并按位置排序
+func (s *SegmentService) getChildSegmentResps(ctx context.Context, parentIDs []uuid.UUID) (map[uuid.UUID][]resp.SegmentResp, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	children, err := s.repo.GetChildSegments(ctx, parentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID][]resp.SegmentResp)
+	for _, child := range children {
+		result[child.ParentID] = append(result[child.ParentID], toSegmentResp(child))
+	}
+
+	return result, nil
+}
+
+func toSegmentResp(segment entity.Segment) resp.SegmentResp {
+	segmentResp := resp.SegmentResp{
+		ID:               segment.ID,
+		DatasetID:        segment.DatasetID,
+		DocumentID:       segment.DocumentID,
+		Content:          segment.Content,
+		Keywords:         segment.Keywords,
+		HeadingPath:      segment.HeadingPath,
+		Enabled:          segment.Enabled,
+		Status:           string(segment.Status),
+		Utime:            segment.Utime,
+		Question:         segment.Question,
+		QuestionVariants: segment.QuestionVariants,
+	}
+	if segment.ParentID != uuid.Nil {
+		parentID := segment.ParentID
+		segmentResp.ParentID = &parentID
+	}
+
+	return segmentResp
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/segment/handler"
 	"github.com/crazyfrankie/voidx/internal/segment/repository"
@@ -35,12 +36,14 @@ var SegmentSet = wire.NewSet(
 func InitSegmentModule(db *gorm.DB, embeddings *embedding.EmbeddingService,
 	jiebaSvc *retrievers.JiebaService,
 	vecSvc *vecstore.VecStoreService,
-	keywordSvc *retriever.RetrieverModule) *SegmentModule {
+	keywordSvc *retriever.RetrieverModule,
+	llmModule *llm.LLMModule) *SegmentModule {
 	wire.Build(
 		SegmentSet,
 
 		wire.Struct(new(SegmentModule), "*"),
 		wire.FieldsOf(new(*retriever.RetrieverModule), "KeyWord"),
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 	)
 	return new(SegmentModule)
 }
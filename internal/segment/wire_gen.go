@@ -9,6 +9,7 @@ package segment
 import (
 	"github.com/crazyfrankie/voidx/internal/core/embedding"
 	"github.com/crazyfrankie/voidx/internal/core/retrievers"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
 	"github.com/crazyfrankie/voidx/internal/segment/handler"
 	"github.com/crazyfrankie/voidx/internal/segment/repository"
@@ -21,11 +22,12 @@ import (
 
 // Injectors from wire.go:
 
-func InitSegmentModule(db *gorm.DB, embeddings *embedding.EmbeddingService, jiebaSvc *retrievers.JiebaService, vecSvc *vecstore.VecStoreService, keywordSvc *retriever.RetrieverModule) *SegmentModule {
+func InitSegmentModule(db *gorm.DB, embeddings *embedding.EmbeddingService, jiebaSvc *retrievers.JiebaService, vecSvc *vecstore.VecStoreService, keywordSvc *retriever.RetrieverModule, llmModule *llm.LLMModule) *SegmentModule {
 	segmentDao := dao.NewSegmentDao(db)
 	segmentRepo := repository.NewSegmentRepo(segmentDao)
 	keywordService := keywordSvc.KeyWord
-	segmentService := service.NewSegmentService(segmentRepo, embeddings, jiebaSvc, vecSvc, keywordService)
+	llmService := llmModule.Service
+	segmentService := service.NewSegmentService(segmentRepo, embeddings, jiebaSvc, vecSvc, keywordService, llmService)
 	segmentHandler := handler.NewSegmentHandler(segmentService)
 	segmentModule := &SegmentModule{
 		Handler: segmentHandler,
@@ -0,0 +1,161 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+
+	conversationService "github.com/crazyfrankie/voidx/internal/conversation/service"
+	"github.com/crazyfrankie/voidx/internal/conversation/task"
+	llmService "github.com/crazyfrankie/voidx/internal/llm/service"
+	userMemoryService "github.com/crazyfrankie/voidx/internal/user_memory/service"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+// ConversationConsumer 会话任务消费者
+type ConversationConsumer struct {
+	consumerGroup       sarama.ConsumerGroup
+	conversationService *conversationService.ConversationService
+	llmService          *llmService.LLMService
+	userMemoryService   *userMemoryService.UserMemoryService
+	topics              []string
+}
+
+// NewConversationConsumer 创建会话任务消费者
+func NewConversationConsumer(brokers []string, groupID string,
+	conversationSvc *conversationService.ConversationService, llmSvc *llmService.LLMService,
+	userMemorySvc *userMemoryService.UserMemoryService) (*ConversationConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConversationConsumer{
+		consumerGroup:       consumerGroup,
+		conversationService: conversationSvc,
+		llmService:          llmSvc,
+		userMemoryService:   userMemorySvc,
+		topics:              []string{task.SummaryTopic},
+	}, nil
+}
+
+// Start 启动消费者
+func (c *ConversationConsumer) Start(ctx context.Context) error {
+	handler := &conversationConsumerGroupHandler{
+		conversationService: c.conversationService,
+		llmService:          c.llmService,
+		userMemoryService:   c.userMemoryService,
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
+				logs.Errorf("Error from consumer: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// Close 关闭消费者
+func (c *ConversationConsumer) Close() error {
+	return c.consumerGroup.Close()
+}
+
+// conversationConsumerGroupHandler 消费者组处理器
+type conversationConsumerGroupHandler struct {
+	conversationService *conversationService.ConversationService
+	llmService          *llmService.LLMService
+	userMemoryService   *userMemoryService.UserMemoryService
+}
+
+// Setup 设置消费者组
+func (h *conversationConsumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup 清理消费者组
+func (h *conversationConsumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim 消费消息
+func (h *conversationConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case message := <-claim.Messages():
+			if message == nil {
+				return nil
+			}
+
+			err := h.handleMessage(message)
+			if err != nil {
+				logs.Errorf("Failed to handle message: %v", err)
+			}
+
+			session.MarkMessage(message, "")
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// handleMessage 处理消息
+func (h *conversationConsumerGroupHandler) handleMessage(message *sarama.ConsumerMessage) error {
+	var summaryTask task.SummaryTask
+	if err := sonic.Unmarshal(message.Value, &summaryTask); err != nil {
+		logs.Errorf("Failed to unmarshal summary task: %v", err)
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch message.Topic {
+	case task.SummaryTopic:
+		return h.handleSummaryTask(ctx, summaryTask)
+	default:
+		logs.Errorf("Unknown topic: %s", message.Topic)
+		return nil
+	}
+}
+
+// handleSummaryTask 处理会话长期记忆摘要任务
+func (h *conversationConsumerGroupHandler) handleSummaryTask(ctx context.Context, summaryTask task.SummaryTask) error {
+	if summaryTask.TaskType != task.TaskTypeSummarize {
+		return nil
+	}
+
+	// 使用应用配置的模型生成摘要
+	llm, err := h.llmService.LoadLanguageModel(ctx, summaryTask.AccountID, summaryTask.ModelConfig)
+	if err != nil {
+		logs.Errorf("Failed to load language model for conversation %s: %v", summaryTask.ConversationID, err)
+		return err
+	}
+
+	conversation, messages, err := h.conversationService.SummarizeConversation(ctx, llm, summaryTask.ConversationID)
+	if err != nil {
+		logs.Errorf("Failed to summarize conversation %s: %v", summaryTask.ConversationID, err)
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	// 从本次汇总的消息中提取用户记忆，每条消息只会被汇总一次，因此不会重复提取
+	err = h.userMemoryService.ExtractUserMemories(ctx, llm, conversation, messages)
+	if err != nil {
+		logs.Errorf("Failed to extract user memories from conversation %s: %v", summaryTask.ConversationID, err)
+		return err
+	}
+
+	return nil
+}
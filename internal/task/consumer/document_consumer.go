@@ -33,7 +33,7 @@ func NewDocumentConsumer(brokers []string, groupID string, indexingService *serv
 	return &DocumentConsumer{
 		consumerGroup:   consumerGroup,
 		indexingService: indexingService,
-		topics:          []string{"document.build", "document.update_enabled", "document.delete"},
+		topics:          []string{"document.build", "document.update_enabled", "document.update_metadata", "document.update_content", "document.delete", "document.crawl"},
 	}, nil
 }
 
@@ -113,8 +113,14 @@ func (h *documentConsumerGroupHandler) handleMessage(message *sarama.ConsumerMes
 		return h.handleBuildDocumentTask(ctx, documentTask)
 	case "document.update_enabled":
 		return h.handleUpdateDocumentEnabledTask(ctx, documentTask)
+	case "document.update_metadata":
+		return h.handleUpdateDocumentMetadataTask(ctx, documentTask)
+	case "document.update_content":
+		return h.handleUpdateDocumentContentTask(ctx, documentTask)
 	case "document.delete":
 		return h.handleDeleteDocumentTask(ctx, documentTask)
+	case "document.crawl":
+		return h.handleCrawlTask(ctx, documentTask)
 	default:
 		logs.Errorf("Unknown topic: %s", message.Topic)
 		return nil
@@ -153,6 +159,38 @@ func (h *documentConsumerGroupHandler) handleUpdateDocumentEnabledTask(ctx conte
 	return nil
 }
 
+// handleUpdateDocumentMetadataTask 处理更新文档元数据任务
+func (h *documentConsumerGroupHandler) handleUpdateDocumentMetadataTask(ctx context.Context, documentTask task.DocumentTask) error {
+	if documentTask.TaskType != task.TaskTypeUpdateMetadata {
+		return nil
+	}
+
+	err := h.indexingService.UpdateDocumentMetadata(ctx, documentTask.DocumentID)
+	if err != nil {
+		logs.Errorf("Failed to update document metadata %s: %v", documentTask.DocumentID, err)
+		return err
+	}
+
+	logs.Errorf("Successfully updated document metadata: %s", documentTask.DocumentID)
+	return nil
+}
+
+// handleUpdateDocumentContentTask 处理增量更新文档内容任务
+func (h *documentConsumerGroupHandler) handleUpdateDocumentContentTask(ctx context.Context, documentTask task.DocumentTask) error {
+	if documentTask.TaskType != task.TaskTypeUpdateContent {
+		return nil
+	}
+
+	err := h.indexingService.UpdateDocumentContent(ctx, documentTask.DocumentID)
+	if err != nil {
+		logs.Errorf("Failed to update document content %s: %v", documentTask.DocumentID, err)
+		return err
+	}
+
+	logs.Errorf("Successfully updated document content: %s", documentTask.DocumentID)
+	return nil
+}
+
 // handleDeleteDocumentTask 处理删除文档任务
 func (h *documentConsumerGroupHandler) handleDeleteDocumentTask(ctx context.Context, documentTask task.DocumentTask) error {
 	if documentTask.TaskType != task.TaskTypeDelete {
@@ -168,3 +206,19 @@ func (h *documentConsumerGroupHandler) handleDeleteDocumentTask(ctx context.Cont
 	logs.Errorf("Successfully deleted document: %s", documentTask.DocumentID)
 	return nil
 }
+
+// handleCrawlTask 处理网页爬取任务
+func (h *documentConsumerGroupHandler) handleCrawlTask(ctx context.Context, documentTask task.DocumentTask) error {
+	if documentTask.TaskType != task.TaskTypeCrawl {
+		return nil
+	}
+
+	err := h.indexingService.CrawlSource(ctx, documentTask.CrawlSourceID)
+	if err != nil {
+		logs.Errorf("Failed to crawl source %s: %v", documentTask.CrawlSourceID, err)
+		return err
+	}
+
+	logs.Infof("Successfully crawled source: %s", documentTask.CrawlSourceID)
+	return nil
+}
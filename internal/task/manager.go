@@ -5,8 +5,11 @@ import (
 	"sync"
 
 	"github.com/crazyfrankie/voidx/internal/app"
+	"github.com/crazyfrankie/voidx/internal/conversation"
 	"github.com/crazyfrankie/voidx/internal/index"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/task/consumer"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/pkg/logs"
 )
 
@@ -15,11 +18,16 @@ type TaskManager struct {
 	documentConsumer *consumer.DocumentConsumer
 	appConsumer      *consumer.AppConsumer
 	datasetConsumer  *consumer.DatasetConsumer
+	convConsumer     *consumer.ConversationConsumer
+	crawlScheduler   *CrawlScheduler
+	indexingService  *index.Service
+	llmService       *llm.Service
 	wg               sync.WaitGroup
 }
 
 // NewTaskManager 创建任务管理器
-func NewTaskManager(brokers []string, indexingService *index.Service, appService *app.Service) (*TaskManager, error) {
+func NewTaskManager(brokers []string, indexingService *index.Service, appService *app.Service,
+	conversationService *conversation.Service, llmService *llm.Service, userMemoryService *user_memory.Service) (*TaskManager, error) {
 	// 创建文档消费者
 	documentConsumer, err := consumer.NewDocumentConsumer(brokers, "document-consumer-group", indexingService)
 	if err != nil {
@@ -38,15 +46,27 @@ func NewTaskManager(brokers []string, indexingService *index.Service, appService
 		return nil, err
 	}
 
+	// 创建会话消费者
+	convConsumer, err := consumer.NewConversationConsumer(brokers, "conversation-consumer-group", conversationService, llmService, userMemoryService)
+	if err != nil {
+		return nil, err
+	}
+
 	return &TaskManager{
 		documentConsumer: documentConsumer,
 		appConsumer:      appConsumer,
 		datasetConsumer:  datasetConsumer,
+		convConsumer:     convConsumer,
+		crawlScheduler:   NewCrawlScheduler(indexingService),
+		indexingService:  indexingService,
+		llmService:       llmService,
 	}, nil
 }
 
-// Start 启动所有消费者
+// Start 执行启动时的数据迁移并启动所有消费者
 func (m *TaskManager) Start(ctx context.Context) error {
+	m.migrate(ctx)
+
 	// 启动文档消费者
 	m.wg.Add(1)
 	go func() {
@@ -74,10 +94,38 @@ func (m *TaskManager) Start(ctx context.Context) error {
 		}
 	}()
 
+	// 启动会话消费者
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := m.convConsumer.Start(ctx); err != nil {
+			logs.Errorf("Conversation consumer error: %v", err)
+		}
+	}()
+
+	// 启动网页爬取调度器
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := m.crawlScheduler.Start(ctx); err != nil {
+			logs.Errorf("Crawl scheduler error: %v", err)
+		}
+	}()
+
 	logs.Info("All task consumers started successfully")
 	return nil
 }
 
+// migrate 执行启动时的数据迁移，迁移均可重复执行，失败时只记录日志不影响服务启动
+func (m *TaskManager) migrate(ctx context.Context) {
+	if err := m.llmService.EncryptPlaintextAPIKeys(ctx); err != nil {
+		logs.Errorf("Failed to encrypt plaintext model provider API keys: %v", err)
+	}
+	if err := m.indexingService.BackfillKeywordPostings(ctx); err != nil {
+		logs.Errorf("Failed to backfill keyword postings: %v", err)
+	}
+}
+
 // Stop 停止所有消费者
 func (m *TaskManager) Stop() error {
 	if m.documentConsumer != nil {
@@ -98,6 +146,18 @@ func (m *TaskManager) Stop() error {
 		}
 	}
 
+	if m.convConsumer != nil {
+		if err := m.convConsumer.Close(); err != nil {
+			logs.Errorf("Failed to close conversation consumer: %v", err)
+		}
+	}
+
+	if m.crawlScheduler != nil {
+		if err := m.crawlScheduler.Close(); err != nil {
+			logs.Errorf("Failed to close crawl scheduler: %v", err)
+		}
+	}
+
 	// 等待所有消费者停止
 	m.wg.Wait()
 	logs.Info("All task consumers stopped")
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/crazyfrankie/voidx/internal/index"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+)
+
+// crawlScheduleInterval 检查到期网页爬取来源的间隔
+const crawlScheduleInterval = time.Minute
+
+// CrawlScheduler 定时重新爬取到达下一次爬取时间的网页来源
+type CrawlScheduler struct {
+	indexingService *index.Service
+	cancel          context.CancelFunc
+	mu              sync.Mutex
+}
+
+// NewCrawlScheduler 创建网页爬取调度器
+func NewCrawlScheduler(indexingService *index.Service) *CrawlScheduler {
+	return &CrawlScheduler{indexingService: indexingService}
+}
+
+// Start 启动调度器，阻塞直到ctx结束或调度器被关闭
+func (s *CrawlScheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(crawlScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.indexingService.RecrawlDueSources(ctx); err != nil && ctx.Err() == nil {
+				logs.Errorf("Failed to recrawl due sources: %v", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Close 关闭调度器
+func (s *CrawlScheduler) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/base/response"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/internal/user_memory/service"
+	"github.com/crazyfrankie/voidx/pkg/util"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+type UserMemoryHandler struct {
+	svc *service.UserMemoryService
+}
+
+func NewUserMemoryHandler(svc *service.UserMemoryService) *UserMemoryHandler {
+	return &UserMemoryHandler{svc: svc}
+}
+
+func (h *UserMemoryHandler) RegisterRoute(r *gin.RouterGroup) {
+	memoryGroup := r.Group("user-memories")
+	{
+		memoryGroup.GET("", h.GetUserMemoriesWithPage())
+		memoryGroup.PUT("/:memory_id", h.UpdateUserMemory())
+		memoryGroup.DELETE("/:memory_id", h.DeleteUserMemory())
+		memoryGroup.DELETE("", h.DeleteUserMemories())
+	}
+}
+
+// GetUserMemoriesWithPage 获取指定用户在应用下的记忆分页列表
+func (h *UserMemoryHandler) GetUserMemoriesWithPage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var pageReq req.GetUserMemoriesWithPageReq
+		if err := c.ShouldBindQuery(&pageReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		// 设置默认值
+		if pageReq.CurrentPage == 0 {
+			pageReq.CurrentPage = 1
+		}
+		if pageReq.PageSize == 0 {
+			pageReq.PageSize = 20
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		memories, paginator, err := h.svc.GetUserMemoriesWithPage(c.Request.Context(), userID, pageReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Data(c, map[string]any{
+			"list":      memories,
+			"paginator": paginator,
+		})
+	}
+}
+
+// UpdateUserMemory 根据传递的信息更新用户记忆
+func (h *UserMemoryHandler) UpdateUserMemory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		memoryID, err := uuid.Parse(c.Param("memory_id"))
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		var updateReq req.UpdateUserMemoryReq
+		if err := c.ShouldBindJSON(&updateReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		err = h.svc.UpdateUserMemory(c.Request.Context(), memoryID, userID, updateReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
+// DeleteUserMemory 根据传递的id删除单条用户记忆
+func (h *UserMemoryHandler) DeleteUserMemory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		memoryID, err := uuid.Parse(c.Param("memory_id"))
+		if err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		err = h.svc.DeleteUserMemory(c.Request.Context(), memoryID, userID)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
+// DeleteUserMemories 删除指定用户在应用下的全部记忆
+func (h *UserMemoryHandler) DeleteUserMemories() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var deleteReq req.DeleteUserMemoriesReq
+		if err := c.ShouldBindQuery(&deleteReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate)
+			return
+		}
+
+		userID, err := util.GetCurrentUserID(c.Request.Context())
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		err = h.svc.DeleteUserMemories(c.Request.Context(), userID, deleteReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
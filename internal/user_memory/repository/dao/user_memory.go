@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+type UserMemoryDao struct {
+	db *gorm.DB
+}
+
+func NewUserMemoryDao(db *gorm.DB) *UserMemoryDao {
+	return &UserMemoryDao{db: db}
+}
+
+// CreateUserMemory 创建用户记忆
+func (d *UserMemoryDao) CreateUserMemory(ctx context.Context, memory *entity.UserMemory) error {
+	return d.db.WithContext(ctx).Create(memory).Error
+}
+
+// GetUserMemoryByID 根据ID获取用户记忆
+func (d *UserMemoryDao) GetUserMemoryByID(ctx context.Context, id uuid.UUID) (*entity.UserMemory, error) {
+	var memory entity.UserMemory
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&memory).Error
+	if err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
+
+// GetUserMemoriesByIDs 根据ID列表获取指定归属者的用户记忆
+func (d *UserMemoryDao) GetUserMemoriesByIDs(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID, ids []uuid.UUID) ([]entity.UserMemory, error) {
+	var memories []entity.UserMemory
+	err := d.db.WithContext(ctx).
+		Where("app_id = ? AND owner_type = ? AND owner_id = ? AND id IN ?", appID, ownerType, ownerID, ids).
+		Find(&memories).Error
+	if err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+// GetUserMemoriesWithPage 分页获取指定归属者的用户记忆
+func (d *UserMemoryDao) GetUserMemoriesWithPage(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID, currentPage, pageSize int) ([]entity.UserMemory, int64, error) {
+	query := d.db.WithContext(ctx).Model(&entity.UserMemory{}).
+		Where("app_id = ? AND owner_type = ? AND owner_id = ?", appID, ownerType, ownerID)
+
+	// 获取总数
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	var memories []entity.UserMemory
+	offset := (currentPage - 1) * pageSize
+	err := query.Order("utime DESC").Offset(offset).Limit(pageSize).Find(&memories).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return memories, total, nil
+}
+
+// GetUserMemoryIDsByOwner 获取指定归属者全部用户记忆的ID
+func (d *UserMemoryDao) GetUserMemoryIDsByOwner(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := d.db.WithContext(ctx).Model(&entity.UserMemory{}).
+		Where("app_id = ? AND owner_type = ? AND owner_id = ?", appID, ownerType, ownerID).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UpdateUserMemory 更新用户记忆
+func (d *UserMemoryDao) UpdateUserMemory(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return d.db.WithContext(ctx).Model(&entity.UserMemory{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteUserMemories 根据ID列表删除用户记忆
+func (d *UserMemoryDao) DeleteUserMemories(ctx context.Context, ids []uuid.UUID) error {
+	return d.db.WithContext(ctx).Where("id IN ?", ids).Delete(&entity.UserMemory{}).Error
+}
+
+// GetAppByID 根据ID获取应用
+func (d *UserMemoryDao) GetAppByID(ctx context.Context, id uuid.UUID) (*entity.App, error) {
+	var app entity.App
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&app).Error
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
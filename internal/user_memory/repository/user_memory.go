@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/internal/user_memory/repository/dao"
+	"github.com/crazyfrankie/voidx/types/consts"
+)
+
+type UserMemoryRepo struct {
+	dao *dao.UserMemoryDao
+}
+
+func NewUserMemoryRepo(d *dao.UserMemoryDao) *UserMemoryRepo {
+	return &UserMemoryRepo{dao: d}
+}
+
+// CreateUserMemory 创建用户记忆
+func (r *UserMemoryRepo) CreateUserMemory(ctx context.Context, memory *entity.UserMemory) error {
+	return r.dao.CreateUserMemory(ctx, memory)
+}
+
+// GetUserMemoryByID 根据ID获取用户记忆
+func (r *UserMemoryRepo) GetUserMemoryByID(ctx context.Context, id uuid.UUID) (*entity.UserMemory, error) {
+	return r.dao.GetUserMemoryByID(ctx, id)
+}
+
+// GetUserMemoriesByIDs 根据ID列表获取指定归属者的用户记忆
+func (r *UserMemoryRepo) GetUserMemoriesByIDs(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID, ids []uuid.UUID) ([]entity.UserMemory, error) {
+	return r.dao.GetUserMemoriesByIDs(ctx, appID, ownerType, ownerID, ids)
+}
+
+// GetUserMemoriesWithPage 分页获取指定归属者的用户记忆
+func (r *UserMemoryRepo) GetUserMemoriesWithPage(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID, currentPage, pageSize int) ([]entity.UserMemory, int64, error) {
+	return r.dao.GetUserMemoriesWithPage(ctx, appID, ownerType, ownerID, currentPage, pageSize)
+}
+
+// GetUserMemoryIDsByOwner 获取指定归属者全部用户记忆的ID
+func (r *UserMemoryRepo) GetUserMemoryIDsByOwner(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID) ([]uuid.UUID, error) {
+	return r.dao.GetUserMemoryIDsByOwner(ctx, appID, ownerType, ownerID)
+}
+
+// UpdateUserMemory 更新用户记忆
+func (r *UserMemoryRepo) UpdateUserMemory(ctx context.Context, id uuid.UUID, updates map[string]any) error {
+	return r.dao.UpdateUserMemory(ctx, id, updates)
+}
+
+// DeleteUserMemories 根据ID列表删除用户记忆
+func (r *UserMemoryRepo) DeleteUserMemories(ctx context.Context, ids []uuid.UUID) error {
+	return r.dao.DeleteUserMemories(ctx, ids)
+}
+
+// GetAppByID 根据ID获取应用
+func (r *UserMemoryRepo) GetAppByID(ctx context.Context, id uuid.UUID) (*entity.App, error) {
+	return r.dao.GetAppByID(ctx, id)
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	"github.com/crazyfrankie/voidx/internal/core/embedding"
+	"github.com/crazyfrankie/voidx/internal/models/entity"
+	"github.com/crazyfrankie/voidx/internal/models/req"
+	"github.com/crazyfrankie/voidx/internal/models/resp"
+	"github.com/crazyfrankie/voidx/internal/user_memory/repository"
+	"github.com/crazyfrankie/voidx/pkg/logs"
+	"github.com/crazyfrankie/voidx/types/consts"
+	"github.com/crazyfrankie/voidx/types/errno"
+)
+
+const (
+	// recallTopK 每次对话召回的用户记忆条数
+	recallTopK = 5
+
+	// extractContextTopK 提取记忆时作为已有记忆参考的条数
+	extractContextTopK = 10
+
+	// extractMaxMemories 单次提取最多保存的记忆条数
+	extractMaxMemories = 5
+
+	// duplicateScore 新记忆与已有记忆相似度达到该值时视为对已有记忆的更新
+	duplicateScore = 0.9
+)
+
+type UserMemoryService struct {
+	repo              *repository.UserMemoryRepo
+	embeddingsService *embedding.EmbeddingService
+	vectorStore       vecstore.SearchStore
+}
+
+func NewUserMemoryService(repo *repository.UserMemoryRepo, embeddingsService *embedding.EmbeddingService,
+	vectorStore vecstore.SearchStore) *UserMemoryService {
+	return &UserMemoryService{
+		repo:              repo,
+		embeddingsService: embeddingsService,
+		vectorStore:       vectorStore,
+	}
+}
+
+// OwnerOfConversation 根据会话的调用来源获取记忆的归属者，开放API与微信公众号的会话归属终端用户，其余归属登录账号
+func (s *UserMemoryService) OwnerOfConversation(conversation *entity.Conversation) (consts.UserMemoryOwnerType, uuid.UUID) {
+	switch conversation.InvokeFrom {
+	case consts.InvokeFromServiceAPI, consts.InvokeFromEndUser:
+		return consts.UserMemoryOwnerEndUser, conversation.CreatedBy
+	default:
+		return consts.UserMemoryOwnerAccount, conversation.CreatedBy
+	}
+}
+
+// RecallLongTermMemory 召回与本次提问相关的用户记忆，并与会话摘要组合为智能体的长期记忆，
+// 应用未开启长期记忆或召回失败时只返回会话摘要
+func (s *UserMemoryService) RecallLongTermMemory(ctx context.Context, conversation *entity.Conversation,
+	query string, longTermMemory map[string]any) string {
+	if enable, _ := longTermMemory["enable"].(bool); !enable || strings.TrimSpace(query) == "" {
+		return conversation.Summary
+	}
+
+	ownerType, ownerID := s.OwnerOfConversation(conversation)
+	memories, _, err := s.searchUserMemories(ctx, conversation.AppID, ownerType, ownerID, query, recallTopK)
+	if err != nil {
+		logs.Errorf("Failed to recall user memories for conversation %s: %v", conversation.ID, err)
+		return conversation.Summary
+	}
+	if len(memories) == 0 {
+		return conversation.Summary
+	}
+
+	recalled := strings.ReplaceAll(consts.UserMemoryRecallTemplate, "{memories}", formatUserMemories(memories))
+	if conversation.Summary == "" {
+		return recalled
+	}
+
+	return conversation.Summary + "\n\n" + recalled
+}
+
+// ExtractUserMemories 使用传递的模型从新的会话消息中提取用户的事实与偏好，与已有记忆高度相似的视为更新，其余新增为记忆
+func (s *UserMemoryService) ExtractUserMemories(ctx context.Context, llm model.BaseChatModel,
+	conversation *entity.Conversation, messages []entity.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	ownerType, ownerID := s.OwnerOfConversation(conversation)
+
+	// 1. 将新的会话拼接成文本，并召回相关的已有记忆作为去重参考
+	lines := make([]string, 0, len(messages)*2)
+	queries := make([]string, 0, len(messages))
+	for _, message := range messages {
+		lines = append(lines, "Human: "+message.Query, "AI: "+message.Answer)
+		queries = append(queries, message.Query)
+	}
+	existing, _, err := s.searchUserMemories(ctx, conversation.AppID, ownerType, ownerID, strings.Join(queries, "\n"), extractContextTopK)
+	if err != nil {
+		return err
+	}
+
+	// 2. 填充提取模板并调用模型
+	prompt := strings.NewReplacer(
+		"{memories}", formatUserMemories(existing),
+		"{new_lines}", strings.Join(lines, "\n"),
+	).Replace(consts.UserMemoryExtractTemplate)
+	response, err := llm.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return fmt.Errorf("failed to extract user memories: %w", err)
+	}
+
+	// 3. 逐条保存提取出的记忆
+	for _, content := range parseExtractedMemories(response.Content) {
+		if err := s.saveUserMemory(ctx, conversation, ownerType, ownerID, content); err != nil {
+			logs.Errorf("Failed to save user memory for conversation %s: %v", conversation.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetUserMemoriesWithPage 分页获取指定归属者的用户记忆
+func (s *UserMemoryService) GetUserMemoriesWithPage(ctx context.Context, userID uuid.UUID,
+	pageReq req.GetUserMemoriesWithPageReq) ([]resp.GetUserMemoriesWithPageResp, resp.Paginator, error) {
+	ownerType := consts.UserMemoryOwnerType(pageReq.OwnerType)
+	if err := s.checkPermission(ctx, userID, pageReq.AppID, ownerType, pageReq.OwnerID); err != nil {
+		return nil, resp.Paginator{}, err
+	}
+
+	memories, total, err := s.repo.GetUserMemoriesWithPage(ctx, pageReq.AppID, ownerType, pageReq.OwnerID,
+		pageReq.CurrentPage, pageReq.PageSize)
+	if err != nil {
+		return nil, resp.Paginator{}, err
+	}
+
+	memoryResps := make([]resp.GetUserMemoriesWithPageResp, len(memories))
+	for i, memory := range memories {
+		memoryResps[i] = resp.GetUserMemoriesWithPageResp{
+			ID:             memory.ID,
+			AppID:          memory.AppID,
+			OwnerType:      memory.OwnerType.String(),
+			OwnerID:        memory.OwnerID,
+			ConversationID: memory.ConversationID,
+			Content:        memory.Content,
+			Utime:          memory.Utime,
+			Ctime:          memory.Ctime,
+		}
+	}
+
+	// 计算分页信息
+	totalPages := (int(total) + pageReq.PageSize - 1) / pageReq.PageSize
+	paginator := resp.Paginator{
+		CurrentPage: pageReq.CurrentPage,
+		PageSize:    pageReq.PageSize,
+		TotalPage:   totalPages,
+		TotalRecord: int(total),
+	}
+
+	return memoryResps, paginator, nil
+}
+
+// UpdateUserMemory 更新用户记忆内容并重新写入向量数据库
+func (s *UserMemoryService) UpdateUserMemory(ctx context.Context, memoryID, userID uuid.UUID, updateReq req.UpdateUserMemoryReq) error {
+	// 1. 获取记忆并校验权限
+	memory, err := s.getUserMemory(ctx, memoryID, userID)
+	if err != nil {
+		return err
+	}
+
+	content := strings.TrimSpace(updateReq.Content)
+	if content == "" {
+		return errno.ErrValidate.AppendBizMessage(errors.New("记忆内容不能为空"))
+	}
+
+	// 2. 更新数据库记录与向量
+	if err := s.repo.UpdateUserMemory(ctx, memory.ID, map[string]any{"content": content}); err != nil {
+		return err
+	}
+	memory.Content = content
+
+	return s.storeVector(ctx, memory)
+}
+
+// DeleteUserMemory 删除单条用户记忆
+func (s *UserMemoryService) DeleteUserMemory(ctx context.Context, memoryID, userID uuid.UUID) error {
+	memory, err := s.getUserMemory(ctx, memoryID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteUserMemories(ctx, []uuid.UUID{memory.ID})
+}
+
+// DeleteUserMemories 删除指定归属者在应用下的全部记忆，用于响应用户的隐私删除请求
+func (s *UserMemoryService) DeleteUserMemories(ctx context.Context, userID uuid.UUID, deleteReq req.DeleteUserMemoriesReq) error {
+	ownerType := consts.UserMemoryOwnerType(deleteReq.OwnerType)
+	if err := s.checkPermission(ctx, userID, deleteReq.AppID, ownerType, deleteReq.OwnerID); err != nil {
+		return err
+	}
+
+	ids, err := s.repo.GetUserMemoryIDsByOwner(ctx, deleteReq.AppID, ownerType, deleteReq.OwnerID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.deleteUserMemories(ctx, ids)
+}
+
+// saveUserMemory 保存一条提取出的记忆，与已有记忆高度相似时更新已有记忆
+func (s *UserMemoryService) saveUserMemory(ctx context.Context, conversation *entity.Conversation,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID, content string) error {
+	similar, scores, err := s.searchUserMemories(ctx, conversation.AppID, ownerType, ownerID, content, 1)
+	if err != nil {
+		return err
+	}
+
+	if len(similar) > 0 && scores[0] >= duplicateScore {
+		memory := &similar[0]
+		if memory.Content == content {
+			return nil
+		}
+		err = s.repo.UpdateUserMemory(ctx, memory.ID, map[string]any{
+			"content":         content,
+			"conversation_id": conversation.ID,
+		})
+		if err != nil {
+			return err
+		}
+		memory.Content = content
+		memory.ConversationID = conversation.ID
+
+		return s.storeVector(ctx, memory)
+	}
+
+	memory := &entity.UserMemory{
+		ID:             uuid.New(),
+		AppID:          conversation.AppID,
+		OwnerType:      ownerType,
+		OwnerID:        ownerID,
+		ConversationID: conversation.ID,
+		Content:        content,
+	}
+	if err := s.repo.CreateUserMemory(ctx, memory); err != nil {
+		return err
+	}
+
+	return s.storeVector(ctx, memory)
+}
+
+// searchUserMemories 在向量数据库中检索归属者的相关记忆，并以数据库中的记录为准返回记忆及其相似度
+func (s *UserMemoryService) searchUserMemories(ctx context.Context, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID, query string, topK int) ([]entity.UserMemory, []float64, error) {
+	filter := &vecstore.DSL{
+		Op: vecstore.OpAnd,
+		Value: []*vecstore.DSL{
+			{Op: vecstore.OpEq, Field: "app_id", Value: appID.String()},
+			{Op: vecstore.OpEq, Field: "owner_type", Value: ownerType.String()},
+			{Op: vecstore.OpEq, Field: "owner_id", Value: ownerID.String()},
+		},
+	}
+	docs, err := s.vectorStore.Retrieve(ctx, query, retriever.WithTopK(topK), retriever.WithDSLInfo(filter.DSL()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve user memories: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(docs))
+	for _, doc := range docs {
+		if id, err := uuid.Parse(doc.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	records, err := s.repo.GetUserMemoriesByIDs(ctx, appID, ownerType, ownerID, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordMap := make(map[string]entity.UserMemory, len(records))
+	for _, record := range records {
+		recordMap[record.ID.String()] = record
+	}
+
+	// 保持向量检索的相关度顺序，跳过数据库中已删除的记忆
+	memories := make([]entity.UserMemory, 0, len(records))
+	scores := make([]float64, 0, len(records))
+	for _, doc := range docs {
+		if record, ok := recordMap[doc.ID]; ok {
+			memories = append(memories, record)
+			scores = append(scores, doc.Score())
+		}
+	}
+
+	return memories, scores, nil
+}
+
+// storeVector 使用嵌入服务生成记忆的向量并写入向量数据库
+func (s *UserMemoryService) storeVector(ctx context.Context, memory *entity.UserMemory) error {
+	vector, err := s.embeddingsService.Embeddings(ctx, memory.Content)
+	if err != nil {
+		return fmt.Errorf("failed to embed user memory: %w", err)
+	}
+
+	doc := &schema.Document{
+		ID:      memory.ID.String(),
+		Content: memory.Content,
+		MetaData: map[string]any{
+			"app_id":     memory.AppID.String(),
+			"owner_type": memory.OwnerType.String(),
+			"owner_id":   memory.OwnerID.String(),
+		},
+	}
+	doc.WithDenseVector(vector)
+
+	// 先删除旧向量再写入，保证更新后的内容替换原有记录
+	if err := s.vectorStore.Delete(ctx, []string{doc.ID}); err != nil {
+		logs.Errorf("Failed to delete user memory vector %s: %v", doc.ID, err)
+	}
+	if _, err := s.vectorStore.Store(ctx, []*schema.Document{doc}); err != nil {
+		return fmt.Errorf("failed to store user memory vector: %w", err)
+	}
+
+	return nil
+}
+
+// deleteUserMemories 同时删除向量数据库与数据库中的记忆
+func (s *UserMemoryService) deleteUserMemories(ctx context.Context, ids []uuid.UUID) error {
+	docIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		docIDs = append(docIDs, id.String())
+	}
+	if err := s.vectorStore.Delete(ctx, docIDs); err != nil {
+		return fmt.Errorf("failed to delete user memory vectors: %w", err)
+	}
+
+	return s.repo.DeleteUserMemories(ctx, ids)
+}
+
+// getUserMemory 获取记忆并校验当前账号是否有权限管理
+func (s *UserMemoryService) getUserMemory(ctx context.Context, memoryID, userID uuid.UUID) (*entity.UserMemory, error) {
+	memory, err := s.repo.GetUserMemoryByID(ctx, memoryID)
+	if err != nil {
+		return nil, errno.ErrNotFound.AppendBizMessage(errors.New("该记忆不存在"))
+	}
+
+	if err := s.checkPermission(ctx, userID, memory.AppID, memory.OwnerType, memory.OwnerID); err != nil {
+		return nil, err
+	}
+
+	return memory, nil
+}
+
+// checkPermission 账号可以管理自己的记忆，应用所有者可以管理应用下全部用户的记忆
+func (s *UserMemoryService) checkPermission(ctx context.Context, userID, appID uuid.UUID,
+	ownerType consts.UserMemoryOwnerType, ownerID uuid.UUID) error {
+	if ownerType == consts.UserMemoryOwnerAccount && ownerID == userID {
+		return nil
+	}
+
+	app, err := s.repo.GetAppByID(ctx, appID)
+	if err != nil {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("该应用不存在"))
+	}
+	if app.AccountID != userID {
+		return errno.ErrForbidden.AppendBizMessage(errors.New("当前账号无权限管理该应用的用户记忆"))
+	}
+
+	return nil
+}
+
+// formatUserMemories 将记忆格式化为以"- "开头的列表文本
+func formatUserMemories(memories []entity.UserMemory) string {
+	if len(memories) == 0 {
+		return "无"
+	}
+
+	lines := make([]string, 0, len(memories))
+	for _, memory := range memories {
+		lines = append(lines, "- "+memory.Content)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parseExtractedMemories 解析模型输出的记忆列表
+func parseExtractedMemories(content string) []string {
+	memories := make([]string, 0, extractMaxMemories)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*•"))
+		if line == "" || line == "无" {
+			continue
+		}
+		memories = append(memories, line)
+		if len(memories) == extractMaxMemories {
+			break
+		}
+	}
+
+	return memories
+}
@@ -0,0 +1,40 @@
+//go:build wireinject
+// +build wireinject
+
+package user_memory
+
+import (
+	"github.com/google/wire"
+	"gorm.io/gorm"
+
+	"github.com/crazyfrankie/voidx/infra/contract/document/vecstore"
+	"github.com/crazyfrankie/voidx/internal/core/embedding"
+	"github.com/crazyfrankie/voidx/internal/user_memory/handler"
+	"github.com/crazyfrankie/voidx/internal/user_memory/repository"
+	"github.com/crazyfrankie/voidx/internal/user_memory/repository/dao"
+	"github.com/crazyfrankie/voidx/internal/user_memory/service"
+)
+
+type Handler = handler.UserMemoryHandler
+type Service = service.UserMemoryService
+
+type UserMemoryModule struct {
+	Handler *Handler
+	Service *Service
+}
+
+var ProviderSet = wire.NewSet(
+	dao.NewUserMemoryDao,
+	repository.NewUserMemoryRepo,
+	service.NewUserMemoryService,
+	handler.NewUserMemoryHandler,
+)
+
+func InitUserMemoryModule(db *gorm.DB, embeddingsSvc *embedding.EmbeddingService, vectorStore vecstore.SearchStore) *UserMemoryModule {
+	wire.Build(
+		ProviderSet,
+
+		wire.Struct(new(UserMemoryModule), "*"),
+	)
+	return new(UserMemoryModule)
+}
@@ -27,6 +27,7 @@ func (h *WebAppHandler) RegisterRoute(r *gin.RouterGroup) {
 		webappGroup.GET("/:token/info", h.GetWebAppInfo())
 		webappGroup.POST("/:token/chat", h.WebAppChat())
 		webappGroup.POST("/:token/chat/:task_id/stop", h.StopWebAppChat())
+		webappGroup.POST("/:token/chat/:task_id/confirm", h.ConfirmWebAppChat())
 		webappGroup.GET("/:token/conversations", h.GetConversations())
 		webappGroup.GET("/:token/conversations/:conversation_id/messages", h.GetConversationMessages())
 		webappGroup.DELETE("/:token/conversations/:conversation_id", h.DeleteConversation())
@@ -120,6 +121,36 @@ func (h *WebAppHandler) StopWebAppChat() gin.HandlerFunc {
 	}
 }
 
+func (h *WebAppHandler) ConfirmWebAppChat() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Param("token")
+		if token == "" {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate.AppendBizMessage(errors.New("token不能为空")))
+			return
+		}
+
+		taskID := c.Param("task_id")
+		if taskID == "" {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate.AppendBizMessage(errors.New("task_id不能为空")))
+			return
+		}
+
+		var confirmReq req.ConfirmToolCallReq
+		if err := c.ShouldBindJSON(&confirmReq); err != nil {
+			response.InvalidParamRequestResponse(c, errno.ErrValidate.AppendBizMessage(err))
+			return
+		}
+
+		err := h.svc.ConfirmWebAppChat(c.Request.Context(), token, taskID, confirmReq)
+		if err != nil {
+			response.InternalServerErrorResponse(c, err)
+			return
+		}
+
+		response.Success(c)
+	}
+}
+
 func (h *WebAppHandler) GetConversations() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.Param("token")
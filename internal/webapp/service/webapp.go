@@ -19,7 +19,9 @@ import (
 	"github.com/crazyfrankie/voidx/internal/models/req"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/webapp/repository"
+	"github.com/crazyfrankie/voidx/pkg/logs"
 	"github.com/crazyfrankie/voidx/pkg/sonic"
 	"github.com/crazyfrankie/voidx/pkg/util"
 	"github.com/crazyfrankie/voidx/types/consts"
@@ -34,11 +36,13 @@ type WebAppService struct {
 	tokenBufMem     *memory.TokenBufferMemory
 	repo            *repository.WebAppRepo
 	llmSvc          *llm.Service
+	userMemorySvc   *user_memory.Service
 }
 
 func NewWebAppService(repo *repository.WebAppRepo, appConfigSvc *app_config.Service,
 	conversationSvc *conversation.Service, llmSvc *llm.Service, retrievalSvc *retriever.Service,
-	tokenBufMem *memory.TokenBufferMemory, agentManager *agent.AgentQueueManagerFactory) *WebAppService {
+	tokenBufMem *memory.TokenBufferMemory, agentManager *agent.AgentQueueManagerFactory,
+	userMemorySvc *user_memory.Service) *WebAppService {
 	return &WebAppService{
 		repo:            repo,
 		conversationSvc: conversationSvc,
@@ -47,6 +51,7 @@ func NewWebAppService(repo *repository.WebAppRepo, appConfigSvc *app_config.Serv
 		llmSvc:          llmSvc,
 		tokenBufMem:     tokenBufMem,
 		agentManager:    agentManager,
+		userMemorySvc:   userMemorySvc,
 	}
 }
 
@@ -64,7 +69,7 @@ func (s *WebAppService) GetWebAppInfo(ctx context.Context, token string) (*resp.
 	}
 
 	// 从语言模型管理器中加载大语言模型以获取features
-	languageModel, err := s.llmSvc.LoadLanguageModel(appConfig.ModelConfig)
+	languageModel, err := s.llmSvc.LoadLanguageModel(ctx, app.AccountID, appConfig.ModelConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +159,7 @@ func (s *WebAppService) WebAppChat(ctx context.Context, token string, chatReq re
 		return nil, err
 	}
 
-	languageModel, err := s.llmSvc.LoadLanguageModel(appConfig.ModelConfig)
+	languageModel, err := s.llmSvc.LoadLanguageModel(ctx, app.AccountID, appConfig.ModelConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +179,7 @@ func (s *WebAppService) WebAppChat(ctx context.Context, token string, chatReq re
 	}
 
 	// 8. 将草稿配置中的tools转换成工具
-	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, appConfig.Tools)
+	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, app.AccountID, appConfig.Tools)
 	if err != nil {
 		return nil, err
 	}
@@ -192,6 +197,12 @@ func (s *WebAppService) WebAppChat(ctx context.Context, token string, chatReq re
 			return nil, err
 		}
 		tools = append(tools, datasetTool)
+		// 表格知识库使用应用的模型生成SQL查询
+		tableTools, err := s.retrievalSvc.CreateTableQueryTools(ctx, app.AccountID, datasetIDs, languageModel)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, tableTools...)
 	}
 
 	// 11. 检测是否关联工作流，如果关联了工作流则将工作流构建成工具添加到tools中
@@ -208,6 +219,23 @@ func (s *WebAppService) WebAppChat(ctx context.Context, token string, chatReq re
 		tools = append(tools, workflowTools...)
 	}
 
+	// 检测是否关联了其他应用，如果关联了应用则将应用构建成工具添加到tools中
+	if len(appConfig.Apps) > 0 {
+		var appIDs []uuid.UUID
+		for _, a := range appConfig.Apps {
+			idStr, _ := a["id"].(string)
+			if appID, err := uuid.Parse(idStr); err == nil {
+				appIDs = append(appIDs, appID)
+			}
+		}
+
+		appTools, err := s.appConfigSvc.GetToolsByAppIDs(ctx, app.AccountID, appIDs)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, appTools...)
+	}
+
 	// 12. 根据LLM是否支持tool_call决定使用不同的Agent
 	agentConfig := &entities.AgentConfig{
 		UserID:               accountID,
@@ -215,25 +243,20 @@ func (s *WebAppService) WebAppChat(ctx context.Context, token string, chatReq re
 		PresetPrompt:         appConfig.PresetPrompt,
 		EnableLongTermMemory: appConfig.LongTermMemory != nil && appConfig.LongTermMemory["enable"].(bool),
 		Tools:                tools,
+		ConfirmationTools:    s.appConfigSvc.GetConfirmationToolNames(appConfig.Tools),
+		OutputSchema:         s.appConfigSvc.GetOutputSchema(appConfig.StructuredOutput),
 	}
 	if err := util.ConvertViaJSON(&agentConfig.ReviewConfig, appConfig.ReviewConfig); err != nil {
 		return nil, err
 	}
 
-	var agentInstance agent.BaseAgent
-	features := languageModel.GetFeatures()
-	for _, f := range features {
-		if f == llmentity.FeatureFunctionCall {
-			agentInstance = agent.NewFunctionCallAgent(languageModel, agentConfig, s.agentManager)
-		}
-	}
-	agentInstance = agent.NewReactAgent(languageModel, agentConfig, s.agentManager)
+	agentInstance := agent.NewAgent(languageModel, agentConfig, s.agentManager)
 
 	// 13. 创建响应流通道
 	responseStream := make(chan string, 100)
 
 	// 14. 启动异步处理
-	go s.processWebAppChat(ctx, agentInstance, convers, message, chatReq, history, accountID, responseStream)
+	go s.processWebAppChat(ctx, agentInstance, languageModel, app, appConfig, convers, message, chatReq, history, accountID, responseStream)
 
 	return responseStream, nil
 }
@@ -257,6 +280,38 @@ func (s *WebAppService) StopWebAppChat(ctx context.Context, token, taskID string
 	return s.agentManager.StopTask(ctx, task, uid, consts.InvokeFromWebApp)
 }
 
+func (s *WebAppService) ConfirmWebAppChat(ctx context.Context, token, taskID string, confirmReq req.ConfirmToolCallReq) error {
+	// 验证应用
+	_, err := s.repo.GetAppByToken(ctx, token)
+	if err != nil {
+		return errno.ErrNotFound.AppendBizMessage(errors.New("WebApp不存在或未发布"))
+	}
+
+	task, err := uuid.Parse(taskID)
+	if err != nil {
+		return err
+	}
+	uid, err := util.GetCurrentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 修改工具参数时必须传递合法的json参数
+	if confirmReq.Action == string(entities.ConfirmationEdit) {
+		var arguments map[string]any
+		if err := sonic.UnmarshalString(confirmReq.Arguments, &arguments); err != nil {
+			return errno.ErrValidate.AppendBizMessage(errors.New("修改后的工具参数必须是合法的JSON"))
+		}
+	}
+
+	thoughtID, _ := uuid.Parse(confirmReq.ThoughtID)
+	return s.agentManager.ConfirmTask(ctx, task, thoughtID, uid, consts.InvokeFromWebApp, &entities.ConfirmationDecision{
+		Action:    entities.ConfirmationAction(confirmReq.Action),
+		Arguments: confirmReq.Arguments,
+		Reason:    confirmReq.Reason,
+	})
+}
+
 func (s *WebAppService) GetConversations(ctx context.Context, token string, getReq req.GetWebAppConversationsReq) ([]resp.WebAppConversationResp, error) {
 	// 验证应用
 	app, err := s.repo.GetAppByToken(ctx, token)
@@ -291,7 +346,7 @@ func (s *WebAppService) GetConversations(ctx context.Context, token string, getR
 }
 
 // processWebAppChat 处理WebApp对话的异步逻辑
-func (s *WebAppService) processWebAppChat(ctx context.Context, agentInstance agent.BaseAgent,
+func (s *WebAppService) processWebAppChat(ctx context.Context, agentInstance agent.BaseAgent, languageModel llmentity.BaseLanguageModel, app *entity.App, appConfig *resp.AppDraftConfigResp,
 	conversation *entity.Conversation, message *entity.Message, chatReq req.WebAppChatReq,
 	history []*schema.Message, accountID uuid.UUID, responseStream chan<- string) {
 	defer close(responseStream)
@@ -302,10 +357,14 @@ func (s *WebAppService) processWebAppChat(ctx context.Context, agentInstance age
 		convertHistory = append(convertHistory, h)
 	}
 
+	// 添加当前用户消息，图片交由模型或视觉模型处理
+	messages := append([]*schema.Message{}, convertHistory...)
+	messages = append(messages, s.llmSvc.BuildHumanMessage(ctx, app.AccountID, languageModel, appConfig.ImageInput, chatReq.Query, chatReq.ImageUrls))
+
 	agentInput := agententities.AgentState{
-		Messages:       []*schema.Message{},
+		Messages:       messages,
 		History:        convertHistory,
-		LongTermMemory: conversation.Summary,
+		LongTermMemory: s.userMemorySvc.RecallLongTermMemory(ctx, conversation, chatReq.Query, appConfig.LongTermMemory),
 	}
 
 	// 定义字典存储推理过程
@@ -324,17 +383,14 @@ func (s *WebAppService) processWebAppChat(ctx context.Context, agentInstance age
 
 		// 将数据填充到agent_thought，便于存储到数据库服务中
 		if agentThought.Event != agententities.EventPing {
-			// 除了agent_message数据为叠加，其他均为覆盖
-			if agentThought.Event == entities.EventAgentMessage {
+			// 除了agent_message与agent_reasoning数据为叠加，其他均为覆盖
+			if agentThought.Event == entities.EventAgentMessage || agentThought.Event == entities.EventAgentReasoning {
 				if existingThought, exists := agentThoughts[eventID]; exists {
 					// 叠加智能体消息
 					existingThought.Thought += agentThought.Thought
 					existingThought.Answer += agentThought.Answer
-					existingThought.MessageTokenCount = agentThought.MessageTokenCount
-					existingThought.AnswerTokenCount = agentThought.AnswerTokenCount
-					existingThought.TotalTokenCount = agentThought.TotalTokenCount
-					existingThought.TotalPrice = agentThought.TotalPrice
 					existingThought.Latency = agentThought.Latency
+					existingThought.MergeUsage(agentThought)
 				} else {
 					// 初始化智能体消息事件
 					agentThoughts[eventID] = agentThought
@@ -356,7 +412,9 @@ func (s *WebAppService) processWebAppChat(ctx context.Context, agentInstance age
 			"observation":       agentThought.Observation,
 			"tool":              agentThought.Tool,
 			"tool_input":        agentThought.ToolInput,
+			"sub_thoughts":      agentThought.SubThoughts,
 			"answer":            agentThought.Answer,
+			"structured_output": agentThought.StructuredOutput,
 			"total_token_count": agentThought.TotalTokenCount,
 			"total_price":       agentThought.TotalPrice,
 			"latency":           agentThought.Latency,
@@ -387,6 +445,12 @@ func (s *WebAppService) processWebAppChat(ctx context.Context, agentInstance age
 		// 记录错误但不中断流程
 		// TODO: 添加日志记录
 	}
+
+	// 投递长期记忆摘要任务
+	err = s.conversationSvc.ScheduleConversationSummary(ctx, app.AccountID, conversation.ID, appConfig.LongTermMemory, appConfig.ModelConfig)
+	if err != nil {
+		logs.Errorf("Failed to schedule conversation summary: %v", err)
+	}
 }
 
 // sendErrorEvent 发送错误事件
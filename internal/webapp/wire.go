@@ -10,6 +10,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/google/wire"
 	"gorm.io/gorm"
 
@@ -38,6 +39,7 @@ func InitWebAppModule(db *gorm.DB, tokenBufMem *memory.TokenBufferMemory,
 	llmModule *llm.LLMModule,
 	agentService *agent.Service,
 	retrievalModule *retriever.RetrieverModule,
+	userMemoryModule *user_memory.UserMemoryModule,
 ) *WebAppModule {
 	wire.Build(
 		WebAppSet,
@@ -47,6 +49,7 @@ func InitWebAppModule(db *gorm.DB, tokenBufMem *memory.TokenBufferMemory,
 		wire.FieldsOf(new(*app_config.AppConfigModule), "Service"),
 		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 		wire.FieldsOf(new(*retriever.RetrieverModule), "Service"),
+		wire.FieldsOf(new(*user_memory.UserMemoryModule), "Service"),
 	)
 	return new(WebAppModule)
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/webapp/handler"
 	"github.com/crazyfrankie/voidx/internal/webapp/repository"
 	"github.com/crazyfrankie/voidx/internal/webapp/repository/dao"
@@ -23,14 +24,15 @@ import (
 
 // Injectors from wire.go:
 
-func InitWebAppModule(db *gorm.DB, tokenBufMem *memory.TokenBufferMemory, appConfigModule *app_config.AppConfigModule, conversationModule *conversation.ConversationModule, llmModule *llm.LLMModule, agentService *agent.Service, retrievalModule *retriever.RetrieverModule) *WebAppModule {
+func InitWebAppModule(db *gorm.DB, tokenBufMem *memory.TokenBufferMemory, appConfigModule *app_config.AppConfigModule, conversationModule *conversation.ConversationModule, llmModule *llm.LLMModule, agentService *agent.Service, retrievalModule *retriever.RetrieverModule, userMemoryModule *user_memory.UserMemoryModule) *WebAppModule {
 	webAppDao := dao.NewWebAppDao(db)
 	webAppRepo := repository.NewWebAppRepo(webAppDao)
 	appConfigService := appConfigModule.Service
 	conversationService := conversationModule.Service
 	llmService := llmModule.Service
 	retrievalService := retrievalModule.Service
-	webAppService := service.NewWebAppService(webAppRepo, appConfigService, conversationService, llmService, retrievalService, tokenBufMem, agentService)
+	userMemoryService := userMemoryModule.Service
+	webAppService := service.NewWebAppService(webAppRepo, appConfigService, conversationService, llmService, retrievalService, tokenBufMem, agentService, userMemoryService)
 	webAppHandler := handler.NewWebAppHandler(webAppService)
 	webAppModule := &WebAppModule{
 		Handler: webAppHandler,
@@ -11,7 +11,6 @@ import (
 
 	"github.com/crazyfrankie/voidx/internal/core/agent"
 	agenteneity "github.com/crazyfrankie/voidx/internal/core/agent/entities"
-	llmentity "github.com/crazyfrankie/voidx/internal/core/llm/entities"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/models/resp"
 	"github.com/crazyfrankie/voidx/internal/wechat/repository"
@@ -126,7 +125,7 @@ func (s *WechatService) handleNormalMessage(ctx context.Context, content string,
 func (s *WechatService) processMessageAsync(ctx context.Context, app *entity.App,
 	appConfig *resp.AppDraftConfigResp, messageID uuid.UUID, conversationID uuid.UUID, query string) {
 	// 1. 加载语言模型
-	llm, err := s.llmSvc.LoadLanguageModel(appConfig.ModelConfig)
+	llm, err := s.llmSvc.LoadLanguageModel(ctx, app.AccountID, appConfig.ModelConfig)
 	if err != nil {
 		logs.Errorf("Failed to load language model: %v", err)
 		return
@@ -145,7 +144,7 @@ func (s *WechatService) processMessageAsync(ctx context.Context, app *entity.App
 	}
 
 	// 3. 构建工具链
-	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, appConfig.Tools)
+	tools, err := s.appConfigSvc.GetToolsByToolsConfig(ctx, app.AccountID, appConfig.Tools)
 	if err != nil {
 		logs.Errorf("Failed to get tools by config: %v", err)
 		return
@@ -165,6 +164,13 @@ func (s *WechatService) processMessageAsync(ctx context.Context, app *entity.App
 			return
 		}
 		tools = append(tools, datasetRetrieval)
+		// 表格知识库使用应用的模型生成SQL查询
+		tableTools, err := s.retrievalSvc.CreateTableQueryTools(ctx, app.AccountID, datasetIDs, llm)
+		if err != nil {
+			logs.Errorf("Failed to create table query tools: %v", err)
+			return
+		}
+		tools = append(tools, tableTools...)
 	}
 
 	// 6.检测是否关联工作流，如果关联了工作流则将工作流构建成工具添加到tools中
@@ -182,44 +188,63 @@ func (s *WechatService) processMessageAsync(ctx context.Context, app *entity.App
 		tools = append(tools, workflowTool...)
 	}
 
+	// 检测是否关联了其他应用，如果关联了应用则将应用构建成工具添加到tools中
+	if len(appConfig.Apps) > 0 {
+		appIDs := make([]uuid.UUID, 0, len(appConfig.Apps))
+		for _, a := range appConfig.Apps {
+			idStr, _ := a["id"].(string)
+			if appID, err := uuid.Parse(idStr); err == nil {
+				appIDs = append(appIDs, appID)
+			}
+		}
+		appTools, err := s.appConfigSvc.GetToolsByAppIDs(ctx, app.AccountID, appIDs)
+		if err != nil {
+			logs.Errorf("Failed to get app tools: %v", err)
+			return
+		}
+		tools = append(tools, appTools...)
+	}
+
 	// 7.根据LLM是否支持tool_call决定使用不同的Agent
+	// 微信被动回复无法展示确认请求，需要确认的工具直接拒绝执行
 	agentCfg := &agenteneity.AgentConfig{
-		UserID:               app.AccountID,
-		InvokeFrom:           consts.InvokeFromDebugger,
-		PresetPrompt:         appConfig.PresetPrompt,
-		EnableLongTermMemory: appConfig.LongTermMemory["enabled"].(bool),
-		Tools:                tools,
+		UserID:                  app.AccountID,
+		InvokeFrom:              consts.InvokeFromDebugger,
+		PresetPrompt:            appConfig.PresetPrompt,
+		EnableLongTermMemory:    appConfig.LongTermMemory["enabled"].(bool),
+		Tools:                   tools,
+		ConfirmationTools:       s.appConfigSvc.GetConfirmationToolNames(appConfig.Tools),
+		ConfirmationUnavailable: "微信公众号中无法确认工具调用",
 	}
 	if err := util.ConvertViaJSON(&agentCfg.ReviewConfig, appConfig.ReviewConfig); err != nil {
 		logs.Errorf("Failed to convert review config: %v", err)
 		return
 	}
-	agentIns := agent.NewFunctionCallAgent(llm, agentCfg, s.agentManager)
-	for _, f := range llm.GetFeatures() {
-		if f == llmentity.FeatureToolCall {
-			agentIns = agent.NewFunctionCallAgent(llm, agentCfg, s.agentManager)
-			break
+	agentIns := agent.NewAgent(llm, agentCfg, s.agentManager)
 
-		}
+	// 8.定义智能体状态基础数据
+	agentState := agenteneity.AgentState{
+		History:        history,
+		LongTermMemory: s.userMemorySvc.RecallLongTermMemory(ctx, conversation, query, appConfig.LongTermMemory),
+		Messages:       []*schema.Message{llm.ConvertToHumanMessage(query, nil)},
+	}
 
-		// 8.定义智能体状态基础数据
-		agentState := agenteneity.AgentState{
-			History:        history,
-			LongTermMemory: conversation.Summary,
-			Messages:       []*schema.Message{llm.ConvertToHumanMessage(query, nil)},
-		}
+	// 9.调用智能体获取执行结果
+	agentResult, err := agentIns.Invoke(ctx, agentState)
+	if err != nil {
+		logs.Errorf("Agent invocation failed: %v", err)
+		return
+	}
 
-		// 9.调用智能体获取执行结果
-		agentResult, err := agentIns.Invoke(ctx, agentState)
-		if err != nil {
-			logs.Errorf("Agent invocation failed: %v", err)
-			return
-		}
+	// 10.将数据存储到数据库中，包含会话、消息、推理过程
+	err = s.conversationSvc.SaveAgentThoughts(ctx, app.AccountID, app.ID, conversationID, messageID, agentResult.AgentThoughts)
+	if err != nil {
+		logs.Errorf("Failed to save agent thoughts: %v", err)
+	}
 
-		// 10.将数据存储到数据库中，包含会话、消息、推理过程
-		err = s.conversationSvc.SaveAgentThoughts(ctx, app.AccountID, app.ID, conversationID, messageID, agentResult.AgentThoughts)
-		if err != nil {
-			logs.Errorf("Failed to save agent thoughts: %v", err)
-		}
+	// 11.投递长期记忆摘要任务
+	err = s.conversationSvc.ScheduleConversationSummary(ctx, app.AccountID, conversationID, appConfig.LongTermMemory, appConfig.ModelConfig)
+	if err != nil {
+		logs.Errorf("Failed to schedule conversation summary: %v", err)
 	}
 }
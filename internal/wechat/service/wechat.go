@@ -20,6 +20,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/models/entity"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/wechat/repository"
 	"github.com/crazyfrankie/voidx/types/consts"
 )
@@ -33,11 +34,12 @@ type WechatService struct {
 	llmSvc          *llm.Service
 	agentManager    *agent.AgentQueueManagerFactory
 	tokenBufMem     *memory.TokenBufferMemory
+	userMemorySvc   *user_memory.Service
 }
 
 func NewWechatService(wec *wechat.Wechat, repo *repository.WechatRepository, retrievalSvc *retriever.Service, appConfigSvc *app_config.Service,
 	conversationSvc *conversation.Service, llmSvc *llm.Service, tokenBufMem *memory.TokenBufferMemory,
-	agentManager *agent.AgentQueueManagerFactory) *WechatService {
+	agentManager *agent.AgentQueueManagerFactory, userMemorySvc *user_memory.Service) *WechatService {
 	return &WechatService{
 		wec:             wec,
 		repo:            repo,
@@ -47,6 +49,7 @@ func NewWechatService(wec *wechat.Wechat, repo *repository.WechatRepository, ret
 		llmSvc:          llmSvc,
 		tokenBufMem:     tokenBufMem,
 		agentManager:    agentManager,
+		userMemorySvc:   userMemorySvc,
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/wechat/handler"
 	"github.com/crazyfrankie/voidx/internal/wechat/repository"
 	"github.com/crazyfrankie/voidx/internal/wechat/repository/dao"
@@ -26,7 +27,7 @@ type WechatModule struct {
 
 func InitWechatModule(db *gorm.DB, wec *wechat.Wechat, retrieval *retriever.RetrieverModule, appConfigSvc *app_config.AppConfigModule,
 	conversationSvc *conversation.ConversationModule, llmSvc *llm.LLMModule, agentService *agent.Service,
-	tokenBufMem *memory.TokenBufferMemory) *WechatModule {
+	tokenBufMem *memory.TokenBufferMemory, userMemoryModule *user_memory.UserMemoryModule) *WechatModule {
 	wire.Build(
 		dao.NewWechatDao,
 		repository.NewWechatRepository,
@@ -38,6 +39,7 @@ func InitWechatModule(db *gorm.DB, wec *wechat.Wechat, retrieval *retriever.Retr
 		wire.FieldsOf(new(*app_config.AppConfigModule), "Service"),
 		wire.FieldsOf(new(*conversation.ConversationModule), "Service"),
 		wire.FieldsOf(new(*llm.LLMModule), "Service"),
+		wire.FieldsOf(new(*user_memory.UserMemoryModule), "Service"),
 	)
 	return new(WechatModule)
 }
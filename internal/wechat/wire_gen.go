@@ -13,6 +13,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/core/memory"
 	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/retriever"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/wechat/handler"
 	"github.com/crazyfrankie/voidx/internal/wechat/repository"
 	"github.com/crazyfrankie/voidx/internal/wechat/repository/dao"
@@ -23,14 +24,15 @@ import (
 
 // Injectors from wire.go:
 
-func InitWechatModule(db *gorm.DB, wec *wechat.Wechat, retrieval *retriever.RetrieverModule, appConfigSvc *app_config.AppConfigModule, conversationSvc *conversation.ConversationModule, llmSvc *llm.LLMModule, agentService *agent.Service, tokenBufMem *memory.TokenBufferMemory) *WechatModule {
+func InitWechatModule(db *gorm.DB, wec *wechat.Wechat, retrieval *retriever.RetrieverModule, appConfigSvc *app_config.AppConfigModule, conversationSvc *conversation.ConversationModule, llmSvc *llm.LLMModule, agentService *agent.Service, tokenBufMem *memory.TokenBufferMemory, userMemoryModule *user_memory.UserMemoryModule) *WechatModule {
 	wechatDao := dao.NewWechatDao(db)
 	wechatRepository := repository.NewWechatRepository(wechatDao)
 	retrievalService := retrieval.Service
 	appConfigService := appConfigSvc.Service
 	conversationService := conversationSvc.Service
 	llmService := llmSvc.Service
-	wechatService := service.NewWechatService(wec, wechatRepository, retrievalService, appConfigService, conversationService, llmService, tokenBufMem, agentService)
+	userMemoryService := userMemoryModule.Service
+	wechatService := service.NewWechatService(wec, wechatRepository, retrievalService, appConfigService, conversationService, llmService, tokenBufMem, agentService, userMemoryService)
 	wechatHandler := handler.NewWechatHandler(wechatService)
 	wechatModule := &WechatModule{
 		Handler: wechatHandler,
@@ -3,12 +3,16 @@ package ioc
 import (
 	"github.com/crazyfrankie/voidx/conf"
 	"github.com/crazyfrankie/voidx/internal/app"
+	"github.com/crazyfrankie/voidx/internal/conversation"
 	"github.com/crazyfrankie/voidx/internal/index"
+	"github.com/crazyfrankie/voidx/internal/llm"
 	"github.com/crazyfrankie/voidx/internal/task"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 )
 
-func InitTask(indexService *index.Service, appService *app.Service) *task.TaskManager {
-	manager, err := task.NewTaskManager(conf.GetConf().Kafka.Brokers, indexService, appService)
+func InitTask(indexService *index.Service, appService *app.Service,
+	conversationService *conversation.Service, llmService *llm.Service, userMemoryService *user_memory.Service) *task.TaskManager {
+	manager, err := task.NewTaskManager(conf.GetConf().Kafka.Brokers, indexService, appService, conversationService, llmService, userMemoryService)
 	if err != nil {
 		panic(err)
 	}
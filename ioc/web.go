@@ -19,6 +19,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/platform"
 	"github.com/crazyfrankie/voidx/internal/segment"
 	"github.com/crazyfrankie/voidx/internal/upload"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/webapp"
 	"github.com/crazyfrankie/voidx/internal/wechat"
 	"github.com/crazyfrankie/voidx/internal/workflow"
@@ -37,7 +38,8 @@ func InitWeb(mws []gin.HandlerFunc, account *account.Handler, ai *ai.Handler, an
 	conversation *conversation.Handler, dataset *dataset.Handler,
 	document *document.Handler, llm *llm.Handler, oauth *oauth.Handler,
 	openapi *openapi.Handler, platform *platform.Handler, segment *segment.Handler,
-	upload *upload.Handler, webapp *webapp.Handler, wechat *wechat.Handler, workflow *workflow.Handler) *gin.Engine {
+	upload *upload.Handler, webapp *webapp.Handler, wechat *wechat.Handler, workflow *workflow.Handler,
+	userMemory *user_memory.Handler) *gin.Engine {
 	srv := gin.Default()
 	srv.Use(mws...)
 
@@ -66,6 +68,7 @@ func InitWeb(mws []gin.HandlerFunc, account *account.Handler, ai *ai.Handler, an
 	webapp.RegisterRoute(apiGroup)
 	wechat.RegisterRoute(apiGroup)
 	workflow.RegisterRoute(apiGroup)
+	userMemory.RegisterRoute(apiGroup)
 
 	return srv
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/segment"
 	"github.com/crazyfrankie/voidx/internal/task"
 	"github.com/crazyfrankie/voidx/internal/upload"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/webapp"
 	"github.com/crazyfrankie/voidx/internal/wechat"
 	"github.com/crazyfrankie/voidx/internal/workflow"
@@ -73,6 +74,7 @@ func InitApplication() *Application {
 		retriever.InitRetrieverModule,
 		segment.InitSegmentModule,
 		upload.InitUploadModule,
+		user_memory.InitUserMemoryModule,
 		webapp.InitWebAppModule,
 		wechat.InitWechatModule,
 		workflow.InitWorkflowModule,
@@ -94,16 +96,20 @@ func InitApplication() *Application {
 		wire.FieldsOf(new(*builtin_app.BuiltinModule), "Handler"),
 		wire.FieldsOf(new(*builtin_tools.BuiltinToolsModule), "Handler"),
 		wire.FieldsOf(new(*conversation.ConversationModule), "Handler"),
+		wire.FieldsOf(new(*conversation.ConversationModule), "Service"),
 		wire.FieldsOf(new(*dataset.DataSetModule), "Handler"),
 		wire.FieldsOf(new(*document.DocumentModule), "Handler"),
 		wire.FieldsOf(new(*index.IndexModule), "Service"),
 		wire.FieldsOf(new(*llm.LLMModule), "Handler"),
+		wire.FieldsOf(new(*llm.LLMModule), "Service"),
 		wire.FieldsOf(new(*oauth.OAuthModule), "Handler"),
 		wire.FieldsOf(new(*openapi.OpenAPIModule), "Handler"),
 		wire.FieldsOf(new(*platform.PlatformModule), "Handler"),
 		wire.FieldsOf(new(*segment.SegmentModule), "Handler"),
 		wire.FieldsOf(new(*upload.UploadModule), "Handler"),
 		wire.FieldsOf(new(*upload.UploadModule), "Service"),
+		wire.FieldsOf(new(*user_memory.UserMemoryModule), "Handler"),
+		wire.FieldsOf(new(*user_memory.UserMemoryModule), "Service"),
 		wire.FieldsOf(new(*webapp.WebAppModule), "Handler"),
 		wire.FieldsOf(new(*wechat.WechatModule), "Handler"),
 		wire.FieldsOf(new(*workflow.WorkflowModule), "Handler"),
@@ -32,6 +32,7 @@ import (
 	"github.com/crazyfrankie/voidx/internal/segment"
 	"github.com/crazyfrankie/voidx/internal/task"
 	"github.com/crazyfrankie/voidx/internal/upload"
+	"github.com/crazyfrankie/voidx/internal/user_memory"
 	"github.com/crazyfrankie/voidx/internal/vecstore"
 	"github.com/crazyfrankie/voidx/internal/webapp"
 	"github.com/crazyfrankie/voidx/internal/wechat"
@@ -49,14 +50,15 @@ func InitApplication() *Application {
 	db := InitDB()
 	accountModule := account.InitAccountModule(db)
 	accountHandler := accountModule.Handler
-	conversationModule := conversation.InitConversationModule(db)
-	aiModule := ai.InitAIModule(db, conversationModule)
+	languageModelManager := InitLLMCore()
+	llmModule := llm.InitLLMModule(db, languageModelManager)
+	conversationModule := conversation.InitConversationModule(db, cmdable, llmModule)
+	aiModule := ai.InitAIModule(db, conversationModule, llmModule)
 	aiHandler := aiModule.Handler
 	openAI := InitEmbedding()
 	store := InitVectorStore(openAI)
 	vecStoreService := vecstore.NewVecStoreService(store)
 	tokenBufferMemory := InitTokenBufMem(db)
-	languageModelManager := InitLLMCore()
 	builtinProviderManager := InitBuiltinToolsManager()
 	apiProviderManager := InitApiToolsManager()
 	appConfigModule := app_config.InitAppConfigModule(db, languageModelManager, builtinProviderManager, apiProviderManager)
@@ -64,10 +66,10 @@ func InitApplication() *Application {
 	uploadModule := upload.InitUploadModule(db, storage)
 	embeddingService := InitEmbeddingService(cmdable, openAI)
 	jiebaService := InitJiebaService()
-	retrieverModule := retriever.InitRetrieverModule(db, cmdable, store, embeddingService, jiebaService)
+	retrieverModule := retriever.InitRetrieverModule(db, cmdable, store, embeddingService, jiebaService, languageModelManager)
 	agentQueueManager := InitAgentManager(cmdable)
-	llmModule := llm.InitLLMModule(languageModelManager)
-	appModule := app.InitAppModule(db, vecStoreService, tokenBufferMemory, languageModelManager, appConfigModule, uploadModule, retrieverModule, agentQueueManager, llmModule, apiProviderManager, builtinProviderManager, conversationModule)
+	userMemoryModule := user_memory.InitUserMemoryModule(db, embeddingService, vecStoreService)
+	appModule := app.InitAppModule(db, tokenBufferMemory, languageModelManager, appConfigModule, agentQueueManager, uploadModule, retrieverModule, llmModule, apiProviderManager, builtinProviderManager, conversationModule, userMemoryModule)
 	analysisModule := analysis.InitAnalysisModule(db, cmdable, appModule)
 	analysisHandler := analysisModule.Handler
 	apiKeyModule := api_key.InitApiKeyModule(db)
@@ -77,7 +79,7 @@ func InitApplication() *Application {
 	appHandler := appModule.Handler
 	assistantModule := assistant_agent.InitAssistantModule(db, conversationModule, agentQueueManager, languageModelManager, tokenBufferMemory)
 	assistantAgentHandler := assistantModule.Handler
-	audioModule := audio.InitAudioModule(db)
+	audioModule := audio.InitAudioModule(db, llmModule)
 	audioHandler := audioModule.Handler
 	authModule := auth.InitAuthModule(db, cmdable, token)
 	authHandler := authModule.Handler
@@ -88,35 +90,39 @@ func InitApplication() *Application {
 	builtinToolsModule := builtin_tools.InitBuiltinToolsModule(builtinCategoryManager, builtinProviderManager)
 	builtinToolsHandler := builtinToolsModule.Handler
 	conversationHandler := conversationModule.Handler
-	segmentModule := segment.InitSegmentModule(db, embeddingService, jiebaService, vecStoreService, retrieverModule)
-	dataSetModule := dataset.InitDatasetHandler(db, retrieverModule, segmentModule)
+	segmentModule := segment.InitSegmentModule(db, embeddingService, jiebaService, vecStoreService, retrieverModule, llmModule)
+	dataSetModule := dataset.InitDatasetHandler(db, retrieverModule, segmentModule, llmModule)
 	datasetHandler := dataSetModule.Handler
-	documentModule := document.InitDocumentModule(db)
+	documentModule := document.InitDocumentModule(db, cmdable)
 	documentHandler := documentModule.Handler
 	llmHandler := llmModule.Handler
 	oAuthModule := oauth.InitOAuthModule(db, token)
 	oAuthHandler := oAuthModule.Handler
-	openAPIModule := openapi.InitOpenAIModule(db, conversationModule, retrieverModule, llmModule, appConfigModule, appModule, agentQueueManager, tokenBufferMemory)
+	openAPIModule := openapi.InitOpenAIModule(db, conversationModule, retrieverModule, llmModule, appConfigModule, appModule, agentQueueManager, tokenBufferMemory, userMemoryModule)
 	openAPIHandler := openAPIModule.Handler
 	platformModule := platform.InitPlatformModule(db)
 	platformHandler := platformModule.Handler
 	segmentHandler := segmentModule.Handler
 	uploadFileHandler := uploadModule.Handler
-	webAppModule := webapp.InitWebAppModule(db, tokenBufferMemory, appConfigModule, conversationModule, llmModule, agentQueueManager, retrieverModule)
+	webAppModule := webapp.InitWebAppModule(db, tokenBufferMemory, appConfigModule, conversationModule, llmModule, agentQueueManager, retrieverModule, userMemoryModule)
 	webAppHandler := webAppModule.Handler
 	wechatWechat := InitWechat()
-	wechatModule := wechat.InitWechatModule(db, wechatWechat, retrieverModule, appConfigModule, conversationModule, llmModule, agentQueueManager, tokenBufferMemory)
+	wechatModule := wechat.InitWechatModule(db, wechatWechat, retrieverModule, appConfigModule, conversationModule, llmModule, agentQueueManager, tokenBufferMemory, userMemoryModule)
 	wechatHandler := wechatModule.Handler
 	workflowModule := workflow.InitWorkflowModule(db, builtinProviderManager)
 	workflowHandler := workflowModule.Handler
-	engine := InitWeb(v, accountHandler, aiHandler, analysisHandler, apiKeyHandler, apiToolHandler, appHandler, assistantAgentHandler, audioHandler, authHandler, builtinAppHandler, builtinToolsHandler, conversationHandler, datasetHandler, documentHandler, llmHandler, oAuthHandler, openAPIHandler, platformHandler, segmentHandler, uploadFileHandler, webAppHandler, wechatHandler, workflowHandler)
+	userMemoryHandler := userMemoryModule.Handler
+	engine := InitWeb(v, accountHandler, aiHandler, analysisHandler, apiKeyHandler, apiToolHandler, appHandler, assistantAgentHandler, audioHandler, authHandler, builtinAppHandler, builtinToolsHandler, conversationHandler, datasetHandler, documentHandler, llmHandler, oAuthHandler, openAPIHandler, platformHandler, segmentHandler, uploadFileHandler, webAppHandler, wechatHandler, workflowHandler, userMemoryHandler)
 	ossService := uploadModule.Service
 	fileExtractor := InitFileExtractor(ossService)
 	processRuleModule := process_rule.InitProcessRuleModule(db)
-	indexModule := index.InitIndexModule(db, cmdable, fileExtractor, embeddingService, jiebaService, processRuleModule, retrieverModule, vecStoreService)
+	indexModule := index.InitIndexModule(db, cmdable, cmdable, fileExtractor, embeddingService, jiebaService, processRuleModule, retrieverModule, vecStoreService, llmModule, uploadModule)
 	indexingService := indexModule.Service
 	appService := appModule.Service
-	taskManager := InitTask(indexingService, appService)
+	conversationService := conversationModule.Service
+	llmService := llmModule.Service
+	userMemoryService := userMemoryModule.Service
+	taskManager := InitTask(indexingService, appService, conversationService, llmService, userMemoryService)
 	application := &Application{
 		Server:   engine,
 		Consumer: taskManager,
@@ -0,0 +1,329 @@
+// Package jsonschema validates decoded JSON values against the commonly used subset of JSON Schema:
+// type, enum, const, properties, required, additionalProperties, items, length and range keywords.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var knownTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// CheckSchema checks that a schema is well-formed and describes an object or an array at its root
+func CheckSchema(schema map[string]any) error {
+	if len(schema) == 0 {
+		return fmt.Errorf("schema is empty")
+	}
+
+	types := schemaTypes(schema)
+	if len(types) == 0 {
+		return fmt.Errorf("schema root must declare a type")
+	}
+	for _, t := range types {
+		if t != "object" && t != "array" {
+			return fmt.Errorf("schema root must be an object or an array")
+		}
+	}
+
+	return checkSchema(schema, "$")
+}
+
+func checkSchema(schema map[string]any, path string) error {
+	if raw, ok := schema["type"]; ok {
+		types := schemaTypes(schema)
+		if len(types) == 0 {
+			return fmt.Errorf("%s: type must be a string or a list of strings, got %v", path, raw)
+		}
+		for _, t := range types {
+			if !knownTypes[t] {
+				return fmt.Errorf("%s: unknown type %q", path, t)
+			}
+		}
+	}
+
+	if raw, ok := schema["properties"]; ok {
+		properties, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: properties must be an object", path)
+		}
+		for name, sub := range properties {
+			subSchema, ok := sub.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s.%s: schema must be an object", path, name)
+			}
+			if err := checkSchema(subSchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if raw, ok := schema["required"]; ok {
+		required, ok := raw.([]any)
+		if !ok {
+			return fmt.Errorf("%s: required must be a list of strings", path)
+		}
+		for _, name := range required {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("%s: required must be a list of strings", path)
+			}
+		}
+	}
+
+	if raw, ok := schema["items"]; ok {
+		items, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: items must be an object", path)
+		}
+		if err := checkSchema(items, path+"[]"); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := schema["enum"]; ok {
+		if _, ok := raw.([]any); !ok {
+			return fmt.Errorf("%s: enum must be a list", path)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates a value decoded from JSON (map[string]any, []any, string, bool, nil and numbers
+// decoded as float64, int64 or json.Number) against the schema and returns the first violation found
+func Validate(schema map[string]any, value any) error {
+	return validate(schema, value, "$")
+}
+
+func validate(schema map[string]any, value any, path string) error {
+	// 1. type
+	if types := schemaTypes(schema); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), typeOf(value))
+		}
+	}
+
+	// 2. enum and const
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if equal(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of %v", path, enum)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !equal(constant, value) {
+		return fmt.Errorf("%s: value must be %v", path, constant)
+	}
+
+	// 3. keywords of the concrete type
+	switch v := value.(type) {
+	case map[string]any:
+		return validateObject(schema, v, path)
+	case []any:
+		return validateArray(schema, v, path)
+	case string:
+		length := utf8.RuneCountInString(v)
+		if minLength, ok := number(schema["minLength"]); ok && float64(length) < minLength {
+			return fmt.Errorf("%s: string is shorter than %v", path, minLength)
+		}
+		if maxLength, ok := number(schema["maxLength"]); ok && float64(length) > maxLength {
+			return fmt.Errorf("%s: string is longer than %v", path, maxLength)
+		}
+	default:
+		n, ok := number(v)
+		if !ok {
+			break
+		}
+		if minimum, ok := number(schema["minimum"]); ok && n < minimum {
+			return fmt.Errorf("%s: %v is less than %v", path, v, minimum)
+		}
+		if maximum, ok := number(schema["maximum"]); ok && n > maximum {
+			return fmt.Errorf("%s: %v is greater than %v", path, v, maximum)
+		}
+	}
+
+	return nil
+}
+
+func validateObject(schema map[string]any, value map[string]any, path string) error {
+	properties, _ := schema["properties"].(map[string]any)
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, raw := range required {
+			name, _ := raw.(string)
+			if _, exists := value[name]; !exists {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+	}
+
+	// Iterate in a stable order so the reported violation is deterministic
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if sub, ok := properties[name].(map[string]any); ok {
+			if err := validate(sub, value[name], path+"."+name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+		case map[string]any:
+			if err := validate(additional, value[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateArray(schema map[string]any, value []any, path string) error {
+	if minItems, ok := number(schema["minItems"]); ok && float64(len(value)) < minItems {
+		return fmt.Errorf("%s: array has fewer than %v items", path, minItems)
+	}
+	if maxItems, ok := number(schema["maxItems"]); ok && float64(len(value)) > maxItems {
+		return fmt.Errorf("%s: array has more than %v items", path, maxItems)
+	}
+
+	if items, ok := schema["items"].(map[string]any); ok {
+		for i, item := range value {
+			if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemaTypes returns the types declared by the schema, type may be a string or a list of strings
+func schemaTypes(schema map[string]any) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	case []string:
+		return t
+	default:
+		return nil
+	}
+}
+
+func matchType(t string, value any) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := number(value)
+		return ok
+	case "integer":
+		f, ok := number(value)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return false
+	}
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		if _, ok := number(value); ok {
+			return "number"
+		}
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// number converts the numeric representations produced by JSON decoders to float64
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func equal(a, b any) bool {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
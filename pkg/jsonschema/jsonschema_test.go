@@ -0,0 +1,148 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crazyfrankie/voidx/pkg/sonic"
+)
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]any
+		wantErr bool
+	}{
+		{name: "empty", schema: map[string]any{}, wantErr: true},
+		{name: "no root type", schema: map[string]any{"properties": map[string]any{}}, wantErr: true},
+		{name: "scalar root", schema: map[string]any{"type": "string"}, wantErr: true},
+		{name: "object root", schema: map[string]any{"type": "object"}},
+		{name: "array root", schema: map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+		{name: "type list", schema: map[string]any{"type": []any{"object", "array"}}},
+		{
+			name:    "unknown nested type",
+			schema:  map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"type": "date"}}},
+			wantErr: true,
+		},
+		{
+			name:    "properties not an object",
+			schema:  map[string]any{"type": "object", "properties": []any{"a"}},
+			wantErr: true,
+		},
+		{
+			name:    "required not strings",
+			schema:  map[string]any{"type": "object", "required": []any{1}},
+			wantErr: true,
+		},
+		{
+			name:    "items not an object",
+			schema:  map[string]any{"type": "array", "items": "string"},
+			wantErr: true,
+		},
+		{
+			name:    "enum not a list",
+			schema:  map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"enum": "x"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSchema(tt.schema)
+			assert.Equal(t, tt.wantErr, err != nil, "err: %v", err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"name", "count"},
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "minLength": 1, "maxLength": 5},
+			"count": map[string]any{"type": "integer", "minimum": 1, "maximum": 10},
+			"score": map[string]any{"type": "number", "minimum": 0.5},
+			"level": map[string]any{"enum": []any{"low", "high"}},
+			"kind":  map[string]any{"const": "report"},
+			"tags": map[string]any{
+				"type":     "array",
+				"minItems": 1,
+				"maxItems": 2,
+				"items":    map[string]any{"type": "string"},
+			},
+			"note": map[string]any{"type": []any{"string", "null"}},
+		},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name    string
+		value   any
+		wantErr string
+	}{
+		{name: "float64 integer", value: map[string]any{"name": "a", "count": float64(3)}},
+		{name: "int64 integer", value: map[string]any{"name": "a", "count": int64(3)}},
+		{name: "int integer", value: map[string]any{"name": "a", "count": 3}},
+		{name: "json.Number integer", value: map[string]any{"name": "a", "count": json.Number("3")}},
+		{name: "int64 number", value: map[string]any{"name": "a", "count": 1, "score": int64(2)}},
+		{name: "nullable", value: map[string]any{"name": "a", "count": 1, "note": nil}},
+		{name: "enum and const", value: map[string]any{"name": "a", "count": 1, "level": "low", "kind": "report"}},
+		{name: "array items", value: map[string]any{"name": "a", "count": 1, "tags": []any{"x", "y"}}},
+		{name: "not an object", value: []any{}, wantErr: "$: expected object, got array"},
+		{name: "missing required", value: map[string]any{"name": "a"}, wantErr: `$: missing required property "count"`},
+		{name: "fraction is not integer", value: map[string]any{"name": "a", "count": 1.5}, wantErr: "$.count: expected integer, got number"},
+		{name: "json.Number fraction", value: map[string]any{"name": "a", "count": json.Number("1.5")}, wantErr: "$.count: expected integer, got number"},
+		{name: "int64 below minimum", value: map[string]any{"name": "a", "count": int64(0)}, wantErr: "$.count: 0 is less than 1"},
+		{name: "int64 above maximum", value: map[string]any{"name": "a", "count": int64(11)}, wantErr: "$.count: 11 is greater than 10"},
+		{name: "json.Number above maximum", value: map[string]any{"name": "a", "count": json.Number("12")}, wantErr: "$.count: 12 is greater than 10"},
+		{name: "number below minimum", value: map[string]any{"name": "a", "count": 1, "score": 0.1}, wantErr: "$.score: 0.1 is less than 0.5"},
+		{name: "string for integer", value: map[string]any{"name": "a", "count": "3"}, wantErr: "$.count: expected integer, got string"},
+		{name: "too short", value: map[string]any{"name": "", "count": 1}, wantErr: "$.name: string is shorter than 1"},
+		{name: "too long in runes", value: map[string]any{"name": "你好世界啊呀", "count": 1}, wantErr: "$.name: string is longer than 5"},
+		{name: "not in enum", value: map[string]any{"name": "a", "count": 1, "level": "mid"}, wantErr: "$.level: value is not one of [low high]"},
+		{name: "wrong const", value: map[string]any{"name": "a", "count": 1, "kind": "memo"}, wantErr: "$.kind: value must be report"},
+		{name: "too few items", value: map[string]any{"name": "a", "count": 1, "tags": []any{}}, wantErr: "$.tags: array has fewer than 1 items"},
+		{name: "too many items", value: map[string]any{"name": "a", "count": 1, "tags": []any{"x", "y", "z"}}, wantErr: "$.tags: array has more than 2 items"},
+		{name: "bad item", value: map[string]any{"name": "a", "count": 1, "tags": []any{"x", 1}}, wantErr: "$.tags[1]: expected string, got number"},
+		{name: "additional property", value: map[string]any{"name": "a", "count": 1, "extra": true}, wantErr: `$: unexpected property "extra"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(schema, tt.value)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateSonicDecoded(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count": map[string]any{"type": "integer", "minimum": 1, "maximum": 5},
+		},
+	}
+
+	var value any
+	assert.NoError(t, sonic.UnmarshalString(`{"count": 3}`, &value))
+	assert.NoError(t, Validate(schema, value))
+
+	assert.NoError(t, sonic.UnmarshalString(`{"count": 9}`, &value))
+	assert.EqualError(t, Validate(schema, value), "$.count: 9 is greater than 5")
+}
+
+func TestValidateAdditionalPropertiesSchema(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "number"},
+	}
+
+	assert.NoError(t, Validate(schema, map[string]any{"a": int64(1), "b": 2.5}))
+	assert.EqualError(t, Validate(schema, map[string]any{"a": "x"}), "$.a: expected number, got string")
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"strconv"
 
-	"github.com/crazyfrankie/frx/lang/ptr"
+	"github.com/crazyfrankie/voidx/pkg/lang/ptr"
 )
 
 // StrToInt64E returns strconv.ParseInt(v, 10, 64)
@@ -0,0 +1,77 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// AESGCMEncrypt encrypts the plaintext with AES-256-GCM under a key derived from the secret,
+// the result is the base64 of the nonce followed by the ciphertext
+func AESGCMEncrypt(secret, plaintext string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// AESGCMDecrypt decrypts a value produced by AESGCMEncrypt with the same secret
+func AESGCMDecrypt(secret, ciphertext string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// LooksAESGCMEncrypted reports whether the value has the shape of a value produced by AESGCMEncrypt,
+// the base64 of a nonce, a tag and possibly a ciphertext. It cannot tell which secret was used.
+func LooksAESGCMEncrypted(value string) bool {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return false
+	}
+
+	// AES-GCM uses a 12 byte nonce and a 16 byte tag
+	return len(sealed) >= 12+16
+}
+
+func newGCM(secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, errors.New("encryption secret is empty")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
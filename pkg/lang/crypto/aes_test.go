@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAESGCM(t *testing.T) {
+	encrypted, err := AESGCMEncrypt("secret", "sk-plain-api-key")
+	assert.NoError(t, err)
+	assert.True(t, LooksAESGCMEncrypted(encrypted))
+
+	plaintext, err := AESGCMDecrypt("secret", encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-plain-api-key", plaintext)
+
+	_, err = AESGCMDecrypt("other", encrypted)
+	assert.Error(t, err)
+
+	_, err = AESGCMEncrypt("", "value")
+	assert.Error(t, err)
+}
+
+func TestLooksAESGCMEncrypted(t *testing.T) {
+	assert.False(t, LooksAESGCMEncrypted("sk-plain-api-key"))
+	assert.False(t, LooksAESGCMEncrypted("abcd"))
+	assert.False(t, LooksAESGCMEncrypted(""))
+}
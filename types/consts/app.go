@@ -48,6 +48,7 @@ var DefaultAppConfig = map[string]any{
 	"preset_prompt": "",
 	"tools":         []any{},
 	"workflows":     []any{},
+	"apps":          []any{},
 	"datasets":      []any{},
 	"retrieval_config": map[string]any{
 		"retrieval_strategy": "semantic",
@@ -78,7 +79,16 @@ var DefaultAppConfig = map[string]any{
 			"preset_response": "",
 		},
 		"outputs_config": map[string]any{
-			"enable": false,
+			"enable":          false,
+			"preset_response": "",
 		},
+		"moderators": []any{},
+	},
+	"structured_output": map[string]any{
+		"enable": false,
+		"schema": map[string]any{},
+	},
+	"image_input": map[string]any{
+		"enable": false,
 	},
 }
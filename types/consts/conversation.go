@@ -89,6 +89,7 @@ const (
 	InvokeFromDebugger       InvokeFrom = "debugger"        // 调试页面
 	InvokeFromAssistantAgent InvokeFrom = "assistant_agent" // 辅助Agent调用
 	InvokeFromEndUser        InvokeFrom = "end_user"
+	InvokeFromAppTool        InvokeFrom = "app_tool" // 作为工具被其他应用调用
 )
 
 // MessageStatus 会话状态
@@ -2,6 +2,18 @@ package consts
 
 // Dataset相关常量定义
 
+// DatasetType 知识库类型枚举
+type DatasetType string
+
+const (
+	// DatasetTypeText 文本知识库，文档切分为片段后建立索引，未传递类型时的默认值
+	DatasetTypeText DatasetType = "text"
+	// DatasetTypeTable 表格知识库，CSV/XLSX文档导入为独立的数据表，通过自然语言生成SQL查询
+	DatasetTypeTable DatasetType = "table"
+	// DatasetTypeQA 问答对知识库，CSV/XLSX文档按行导入为问答对，使用问题召回、返回答案
+	DatasetTypeQA DatasetType = "qa"
+)
+
 // DefaultDatasetDescriptionFormatter 默认知识库描述格式化文本
 const DefaultDatasetDescriptionFormatter = "当你需要回答管理《{name}》的时候可以引用该知识库。"
 
@@ -11,6 +23,8 @@ type ProcessType string
 const (
 	ProcessTypeAutomatic ProcessType = "automatic"
 	ProcessTypeCustom    ProcessType = "custom"
+	// ProcessTypeHierarchical 父子分段，segment规则切分父片段，child_segment规则将父片段切分为子片段
+	ProcessTypeHierarchical ProcessType = "hierarchical"
 )
 
 // DefaultProcessRule 默认的处理规则
@@ -38,6 +52,37 @@ var DefaultProcessRule = map[string]any{
 	},
 }
 
+// DefaultChildSegmentRule 父子分段默认的子片段切分规则，按句子切分
+var DefaultChildSegmentRule = map[string]any{
+	"separators": []any{
+		"\n",
+		"。|！|？",
+		"\\.\\s|\\!\\s|\\?\\s",
+		"；|;\\s",
+		"，|,\\s",
+		" ",
+		"",
+	},
+	"chunk_size":    200,
+	"chunk_overlap": 0,
+}
+
+// SplitterType 分段规则中的文本分割器类型枚举
+type SplitterType string
+
+const (
+	// SplitterTypeRecursiveCharacter 按分隔符递归切分，未配置分割器时的默认值
+	SplitterTypeRecursiveCharacter SplitterType = "recursive_character"
+	// SplitterTypeMarkdown 按Markdown标题切分章节，片段保留所在的标题路径
+	SplitterTypeMarkdown SplitterType = "markdown"
+	// SplitterTypeHTML 按HTML的h1-h6标题切分章节，片段保留所在的标题路径
+	SplitterTypeHTML SplitterType = "html"
+	// SplitterTypeToken 按知识库向量模型的token数切分
+	SplitterTypeToken SplitterType = "token"
+	// SplitterTypeSemantic 在相邻句子向量相似度明显下降处切分
+	SplitterTypeSemantic SplitterType = "semantic"
+)
+
 // DocumentStatus 文档状态类型枚举
 type DocumentStatus string
 
@@ -60,6 +105,16 @@ const (
 	SegmentStatusError     SegmentStatus = "error"
 )
 
+// CrawlStatus 网页爬取来源的爬取状态类型枚举
+type CrawlStatus string
+
+const (
+	CrawlStatusWaiting   CrawlStatus = "waiting"
+	CrawlStatusCrawling  CrawlStatus = "crawling"
+	CrawlStatusCompleted CrawlStatus = "completed"
+	CrawlStatusError     CrawlStatus = "error"
+)
+
 // RetrievalStrategy 检索策略类型枚举
 type RetrievalStrategy string
 
@@ -76,3 +131,13 @@ const (
 	RetrievalSourceHitTesting RetrievalSource = "hit_testing"
 	RetrievalSourceApp        RetrievalSource = "app"
 )
+
+// MetadataFieldType 知识库元数据字段类型枚举
+type MetadataFieldType string
+
+const (
+	MetadataFieldTypeString MetadataFieldType = "string"
+	MetadataFieldTypeNumber MetadataFieldType = "number"
+	MetadataFieldTypeTime   MetadataFieldType = "time" // 值为秒级时间戳
+	MetadataFieldTypeSelect MetadataFieldType = "select"
+)
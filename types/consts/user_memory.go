@@ -0,0 +1,36 @@
+package consts
+
+// UserMemory相关常量定义
+
+// UserMemoryOwnerType 用户记忆归属类型
+type UserMemoryOwnerType string
+
+const (
+	UserMemoryOwnerEndUser UserMemoryOwnerType = "end_user" // 开放API与微信公众号的终端用户
+	UserMemoryOwnerAccount UserMemoryOwnerType = "account"  // 调试页面与WebApp的登录账号
+)
+
+func (ot UserMemoryOwnerType) String() string {
+	return string(ot)
+}
+
+// UserMemoryExtractTemplate 用户记忆提取模板
+const UserMemoryExtractTemplate = `你是一个负责整理用户长期记忆的助手，请从新的对话中提取关于用户本人、在之后的对话中仍然有用的事实与偏好，例如身份、职业、所在地、兴趣、习惯、明确表达过的喜好与要求等。
+
+规则:
+1. 只提取与用户本人相关且长期有效的信息，忽略寒暄、一次性的问题以及AI的观点。
+2. 每条记忆是一句完整、独立的陈述，以"用户"作为主语，并与用户的语言保持一致。
+3. 已有记忆中已经包含的信息不要重复提取；如果新的对话更新了已有记忆，输出更新后的完整陈述。
+4. 每条记忆单独占一行并以"- "开头，最多输出5条；如果没有需要记住的信息，只输出"无"。
+
+已有记忆:
+{memories}
+
+新的对话:
+{new_lines}
+
+提取的记忆:`
+
+// UserMemoryRecallTemplate 召回的用户记忆拼接到长期记忆中的模板
+const UserMemoryRecallTemplate = `关于用户的已知信息:
+{memories}`